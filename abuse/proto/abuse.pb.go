@@ -0,0 +1,438 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.21.0
+// 	protoc        v3.11.4
+// source: abuse/proto/abuse.proto
+
+package proto
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type Decision int32
+
+const (
+	Decision_ALLOW Decision = 0
+	Decision_DENY  Decision = 1
+	Decision_DELAY Decision = 2
+)
+
+// Enum value maps for Decision.
+var (
+	Decision_name = map[int32]string{
+		0: "ALLOW",
+		1: "DENY",
+		2: "DELAY",
+	}
+	Decision_value = map[string]int32{
+		"ALLOW": 0,
+		"DENY":  1,
+		"DELAY": 2,
+	}
+)
+
+func (x Decision) Enum() *Decision {
+	p := new(Decision)
+	*p = x
+	return p
+}
+
+func (x Decision) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Decision) Descriptor() protoreflect.EnumDescriptor {
+	return file_abuse_proto_abuse_proto_enumTypes[0].Descriptor()
+}
+
+func (Decision) Type() protoreflect.EnumType {
+	return &file_abuse_proto_abuse_proto_enumTypes[0]
+}
+
+func (x Decision) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Decision.Descriptor instead.
+func (Decision) EnumDescriptor() ([]byte, []int) {
+	return file_abuse_proto_abuse_proto_rawDescGZIP(), []int{0}
+}
+
+type ScoreOrderRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RegistrationID int64    `protobuf:"varint,1,opt,name=registrationID,proto3" json:"registrationID,omitempty"`
+	Names          []string `protobuf:"bytes,2,rep,name=names,proto3" json:"names,omitempty"`
+	// Unix nanoseconds at which the requesting account was created.
+	AccountCreatedAt int64 `protobuf:"varint,3,opt,name=accountCreatedAt,proto3" json:"accountCreatedAt,omitempty"`
+	// The number of failed authorizations for each name by the requesting
+	// account within the last failureWindow nanoseconds.
+	RecentFailures map[string]int64 `protobuf:"bytes,4,rep,name=recentFailures,proto3" json:"recentFailures,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	FailureWindow  int64            `protobuf:"varint,5,opt,name=failureWindow,proto3" json:"failureWindow,omitempty"`
+}
+
+func (x *ScoreOrderRequest) Reset() {
+	*x = ScoreOrderRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_abuse_proto_abuse_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScoreOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreOrderRequest) ProtoMessage() {}
+
+func (x *ScoreOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_abuse_proto_abuse_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreOrderRequest.ProtoReflect.Descriptor instead.
+func (*ScoreOrderRequest) Descriptor() ([]byte, []int) {
+	return file_abuse_proto_abuse_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ScoreOrderRequest) GetRegistrationID() int64 {
+	if x != nil {
+		return x.RegistrationID
+	}
+	return 0
+}
+
+func (x *ScoreOrderRequest) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+func (x *ScoreOrderRequest) GetAccountCreatedAt() int64 {
+	if x != nil {
+		return x.AccountCreatedAt
+	}
+	return 0
+}
+
+func (x *ScoreOrderRequest) GetRecentFailures() map[string]int64 {
+	if x != nil {
+		return x.RecentFailures
+	}
+	return nil
+}
+
+func (x *ScoreOrderRequest) GetFailureWindow() int64 {
+	if x != nil {
+		return x.FailureWindow
+	}
+	return 0
+}
+
+type ScoreOrderResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Decision Decision `protobuf:"varint,1,opt,name=decision,proto3,enum=abuse.Decision" json:"decision,omitempty"`
+	// A human readable explanation of a DENY or DELAY decision, which may be
+	// shown to the subscriber.
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	// For DELAY decisions, the number of nanoseconds the subscriber should
+	// wait before retrying.
+	RetryAfter int64 `protobuf:"varint,3,opt,name=retryAfter,proto3" json:"retryAfter,omitempty"`
+}
+
+func (x *ScoreOrderResponse) Reset() {
+	*x = ScoreOrderResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_abuse_proto_abuse_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScoreOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreOrderResponse) ProtoMessage() {}
+
+func (x *ScoreOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_abuse_proto_abuse_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreOrderResponse.ProtoReflect.Descriptor instead.
+func (*ScoreOrderResponse) Descriptor() ([]byte, []int) {
+	return file_abuse_proto_abuse_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ScoreOrderResponse) GetDecision() Decision {
+	if x != nil {
+		return x.Decision
+	}
+	return Decision_ALLOW
+}
+
+func (x *ScoreOrderResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ScoreOrderResponse) GetRetryAfter() int64 {
+	if x != nil {
+		return x.RetryAfter
+	}
+	return 0
+}
+
+var File_abuse_proto_abuse_proto protoreflect.FileDescriptor
+
+var file_abuse_proto_abuse_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x61, 0x62, 0x75, 0x73, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x62,
+	0x75, 0x73, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x61, 0x62, 0x75, 0x73, 0x65,
+	0x22, 0xbc, 0x02, 0x0a, 0x11, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e,
+	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x14,
+	0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e,
+	0x61, 0x6d, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x10, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10,
+	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74,
+	0x12, 0x54, 0x0a, 0x0e, 0x72, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72,
+	0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x61, 0x62, 0x75, 0x73, 0x65,
+	0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x72, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x46, 0x61,
+	0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72,
+	0x65, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x66,
+	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x1a, 0x41, 0x0a, 0x13,
+	0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0x79, 0x0a, 0x12, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x08, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x61, 0x62, 0x75, 0x73, 0x65, 0x2e,
+	0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65,
+	0x74, 0x72, 0x79, 0x41, 0x66, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a,
+	0x72, 0x65, 0x74, 0x72, 0x79, 0x41, 0x66, 0x74, 0x65, 0x72, 0x2a, 0x2a, 0x0a, 0x08, 0x44, 0x65,
+	0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x09, 0x0a, 0x05, 0x41, 0x4c, 0x4c, 0x4f, 0x57, 0x10,
+	0x00, 0x12, 0x08, 0x0a, 0x04, 0x44, 0x45, 0x4e, 0x59, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x44,
+	0x45, 0x4c, 0x41, 0x59, 0x10, 0x02, 0x32, 0x50, 0x0a, 0x09, 0x41, 0x6e, 0x74, 0x69, 0x41, 0x62,
+	0x75, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x0a, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x12, 0x18, 0x2e, 0x61, 0x62, 0x75, 0x73, 0x65, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x61, 0x62,
+	0x75, 0x73, 0x65, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x2c, 0x5a, 0x2a, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x74, 0x73, 0x65, 0x6e, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65, 0x72, 0x2f, 0x61, 0x62, 0x75, 0x73, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_abuse_proto_abuse_proto_rawDescOnce sync.Once
+	file_abuse_proto_abuse_proto_rawDescData = file_abuse_proto_abuse_proto_rawDesc
+)
+
+func file_abuse_proto_abuse_proto_rawDescGZIP() []byte {
+	file_abuse_proto_abuse_proto_rawDescOnce.Do(func() {
+		file_abuse_proto_abuse_proto_rawDescData = protoimpl.X.CompressGZIP(file_abuse_proto_abuse_proto_rawDescData)
+	})
+	return file_abuse_proto_abuse_proto_rawDescData
+}
+
+var file_abuse_proto_abuse_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_abuse_proto_abuse_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_abuse_proto_abuse_proto_goTypes = []interface{}{
+	(Decision)(0),              // 0: abuse.Decision
+	(*ScoreOrderRequest)(nil),  // 1: abuse.ScoreOrderRequest
+	(*ScoreOrderResponse)(nil), // 2: abuse.ScoreOrderResponse
+	nil,                        // 3: abuse.ScoreOrderRequest.RecentFailuresEntry
+}
+var file_abuse_proto_abuse_proto_depIdxs = []int32{
+	3, // 0: abuse.ScoreOrderRequest.recentFailures:type_name -> abuse.ScoreOrderRequest.RecentFailuresEntry
+	0, // 1: abuse.ScoreOrderResponse.decision:type_name -> abuse.Decision
+	1, // 2: abuse.AntiAbuse.ScoreOrder:input_type -> abuse.ScoreOrderRequest
+	2, // 3: abuse.AntiAbuse.ScoreOrder:output_type -> abuse.ScoreOrderResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_abuse_proto_abuse_proto_init() }
+func file_abuse_proto_abuse_proto_init() {
+	if File_abuse_proto_abuse_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_abuse_proto_abuse_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScoreOrderRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_abuse_proto_abuse_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScoreOrderResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_abuse_proto_abuse_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_abuse_proto_abuse_proto_goTypes,
+		DependencyIndexes: file_abuse_proto_abuse_proto_depIdxs,
+		EnumInfos:         file_abuse_proto_abuse_proto_enumTypes,
+		MessageInfos:      file_abuse_proto_abuse_proto_msgTypes,
+	}.Build()
+	File_abuse_proto_abuse_proto = out.File
+	file_abuse_proto_abuse_proto_rawDesc = nil
+	file_abuse_proto_abuse_proto_goTypes = nil
+	file_abuse_proto_abuse_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// AntiAbuseClient is the client API for AntiAbuse service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AntiAbuseClient interface {
+	ScoreOrder(ctx context.Context, in *ScoreOrderRequest, opts ...grpc.CallOption) (*ScoreOrderResponse, error)
+}
+
+type antiAbuseClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAntiAbuseClient(cc grpc.ClientConnInterface) AntiAbuseClient {
+	return &antiAbuseClient{cc}
+}
+
+func (c *antiAbuseClient) ScoreOrder(ctx context.Context, in *ScoreOrderRequest, opts ...grpc.CallOption) (*ScoreOrderResponse, error) {
+	out := new(ScoreOrderResponse)
+	err := c.cc.Invoke(ctx, "/abuse.AntiAbuse/ScoreOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AntiAbuseServer is the server API for AntiAbuse service.
+type AntiAbuseServer interface {
+	ScoreOrder(context.Context, *ScoreOrderRequest) (*ScoreOrderResponse, error)
+}
+
+// UnimplementedAntiAbuseServer can be embedded to have forward compatible implementations.
+type UnimplementedAntiAbuseServer struct {
+}
+
+func (*UnimplementedAntiAbuseServer) ScoreOrder(context.Context, *ScoreOrderRequest) (*ScoreOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScoreOrder not implemented")
+}
+
+func RegisterAntiAbuseServer(s *grpc.Server, srv AntiAbuseServer) {
+	s.RegisterService(&_AntiAbuse_serviceDesc, srv)
+}
+
+func _AntiAbuse_ScoreOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScoreOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AntiAbuseServer).ScoreOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/abuse.AntiAbuse/ScoreOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AntiAbuseServer).ScoreOrder(ctx, req.(*ScoreOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _AntiAbuse_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "abuse.AntiAbuse",
+	HandlerType: (*AntiAbuseServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ScoreOrder",
+			Handler:    _AntiAbuse_ScoreOrder_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "abuse/proto/abuse.proto",
+}
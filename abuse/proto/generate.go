@@ -0,0 +1,3 @@
+package proto
+
+//go:generate sh -c "cd ../.. && protoc --go_opt=paths=source_relative --go_out=plugins=grpc:. abuse/proto/abuse.proto"
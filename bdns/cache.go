@@ -0,0 +1,167 @@
+package bdns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type answerCacheKey struct{}
+
+// WithAnswerCacheKey returns a context which makes a DNSClient returned by
+// NewAnswerCache share its answers with other lookups whose contexts carry the
+// same key. The VA keys the lookups it makes for one validation by the
+// validation's account, identifier, challenge type and token, so that each of
+// the perspectives validating it in-process looks up the same names only once.
+func WithAnswerCacheKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, answerCacheKey{}, key)
+}
+
+// cacheEntryKey identifies the answers to one lookup made with one
+// WithAnswerCacheKey key.
+type cacheEntryKey struct {
+	key   string
+	qtype uint16
+	name  string
+}
+
+// cacheEntry is a lookup which is in progress, or finished once done is
+// closed. Finished lookups are kept until expires.
+type cacheEntry struct {
+	done    chan struct{}
+	expires time.Time
+	result  interface{}
+	err     error
+}
+
+// answerCache is a DNSClient which shares the answers to identical lookups
+// made with the same WithAnswerCacheKey key for ttl. Concurrent identical
+// lookups wait for the first to finish. Failed lookups aren't shared: a lookup
+// whose answer is being waited on fails, the waiting lookups are made again.
+type answerCache struct {
+	DNSClient
+	ttl     time.Duration
+	clk     clock.Clock
+	lookups *prometheus.CounterVec
+
+	mu        sync.Mutex
+	entries   map[cacheEntryKey]*cacheEntry
+	lastSweep time.Time
+}
+
+// NewAnswerCache returns a DNSClient which makes lookups with client, and
+// shares their answers for ttl between lookups made with the same
+// WithAnswerCacheKey key. Lookups made without a key aren't cached.
+func NewAnswerCache(client DNSClient, ttl time.Duration, clk clock.Clock, stats prometheus.Registerer) DNSClient {
+	lookups := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dns_answer_cache_lookups",
+			Help: "Number of DNS lookups made with a validation's answer cache key, by query type and whether the answer was shared (hit) or not (miss)",
+		},
+		[]string{"qtype", "result"},
+	)
+	stats.MustRegister(lookups)
+	return &answerCache{
+		DNSClient: client,
+		ttl:       ttl,
+		clk:       clk,
+		lookups:   lookups,
+		entries:   make(map[cacheEntryKey]*cacheEntry),
+		lastSweep: clk.Now(),
+	}
+}
+
+// lookup returns the shared answer to the lookup of qtype for name, making it
+// with fetch if there is none.
+func (c *answerCache) lookup(ctx context.Context, qtype uint16, name string, fetch func() (interface{}, error)) (interface{}, error) {
+	key, ok := ctx.Value(answerCacheKey{}).(string)
+	if !ok {
+		return fetch()
+	}
+	k := cacheEntryKey{key: key, qtype: qtype, name: strings.ToLower(name)}
+	qtypeStr := dns.TypeToString[qtype]
+
+	c.mu.Lock()
+	now := c.clk.Now()
+	if now.Sub(c.lastSweep) >= c.ttl {
+		// Forget the expired answers, so that the map only holds those of
+		// recent validations.
+		for k, e := range c.entries {
+			if !e.expires.IsZero() && !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	e, found := c.entries[k]
+	if found && !e.expires.IsZero() && !now.Before(e.expires) {
+		found = false
+	}
+	if !found {
+		e = &cacheEntry{done: make(chan struct{})}
+		c.entries[k] = e
+	}
+	c.mu.Unlock()
+
+	if !found {
+		c.lookups.With(prometheus.Labels{"qtype": qtypeStr, "result": "miss"}).Inc()
+		e.result, e.err = fetch()
+		c.mu.Lock()
+		if e.err != nil {
+			delete(c.entries, k)
+		} else {
+			e.expires = c.clk.Now().Add(c.ttl)
+		}
+		c.mu.Unlock()
+		close(e.done)
+		return e.result, e.err
+	}
+
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if e.err != nil {
+		c.lookups.With(prometheus.Labels{"qtype": qtypeStr, "result": "miss"}).Inc()
+		return fetch()
+	}
+	c.lookups.With(prometheus.Labels{"qtype": qtypeStr, "result": "hit"}).Inc()
+	return e.result, nil
+}
+
+// LookupTXT looks up the TXT records for hostname, sharing the answer as
+// described by NewAnswerCache.
+func (c *answerCache) LookupTXT(ctx context.Context, hostname string) ([]string, error) {
+	result, err := c.lookup(ctx, dns.TypeTXT, hostname, func() (interface{}, error) {
+		return c.DNSClient.LookupTXT(ctx, hostname)
+	})
+	txts, _ := result.([]string)
+	return txts, err
+}
+
+// LookupHost looks up the addresses of hostname, sharing the answer as
+// described by NewAnswerCache.
+func (c *answerCache) LookupHost(ctx context.Context, hostname string) ([]net.IP, error) {
+	result, err := c.lookup(ctx, dns.TypeA, hostname, func() (interface{}, error) {
+		return c.DNSClient.LookupHost(ctx, hostname)
+	})
+	addrs, _ := result.([]net.IP)
+	return addrs, err
+}
+
+// LookupCAA looks up the CAA records for hostname, sharing the answer as
+// described by NewAnswerCache.
+func (c *answerCache) LookupCAA(ctx context.Context, hostname string) ([]*dns.CAA, error) {
+	result, err := c.lookup(ctx, dns.TypeCAA, hostname, func() (interface{}, error) {
+		return c.DNSClient.LookupCAA(ctx, hostname)
+	})
+	caas, _ := result.([]*dns.CAA)
+	return caas, err
+}
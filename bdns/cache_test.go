@@ -0,0 +1,145 @@
+package bdns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// countingDNSClient counts the lookups made of each name, failing those of
+// names in broken. If block is non-nil, lookups wait for it to be closed.
+type countingDNSClient struct {
+	sync.Mutex
+	lookups map[string]int
+	broken  map[string]bool
+	block   chan struct{}
+}
+
+func (c *countingDNSClient) lookup(hostname string) error {
+	if c.block != nil {
+		<-c.block
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.lookups[hostname]++
+	if c.broken[hostname] {
+		return errors.New("broken")
+	}
+	return nil
+}
+
+func (c *countingDNSClient) count(hostname string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.lookups[hostname]
+}
+
+func (c *countingDNSClient) LookupTXT(_ context.Context, hostname string) ([]string, error) {
+	return []string{"txt"}, c.lookup(hostname)
+}
+
+func (c *countingDNSClient) LookupHost(_ context.Context, hostname string) ([]net.IP, error) {
+	return []net.IP{net.ParseIP("127.0.0.1")}, c.lookup(hostname)
+}
+
+func (c *countingDNSClient) LookupCAA(_ context.Context, hostname string) ([]*dns.CAA, error) {
+	return []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org"}}, c.lookup(hostname)
+}
+
+func cacheLookups(c DNSClient, qtype, result string) int {
+	return test.CountCounter(c.(*answerCache).lookups.With(prometheus.Labels{"qtype": qtype, "result": result}))
+}
+
+func TestAnswerCache(t *testing.T) {
+	inner := &countingDNSClient{
+		lookups: make(map[string]int),
+		broken:  map[string]bool{"broken.com": true},
+	}
+	clk := clock.NewFake()
+	cache := NewAnswerCache(inner, time.Minute, clk, metrics.NoopRegisterer)
+
+	// Lookups made without a key aren't cached.
+	for i := 0; i < 2; i++ {
+		_, err := cache.LookupTXT(context.Background(), "example.com")
+		test.AssertNotError(t, err, "LookupTXT failed")
+	}
+	test.AssertEquals(t, inner.count("example.com"), 2)
+	test.AssertEquals(t, cacheLookups(cache, "TXT", "miss"), 0)
+
+	// Lookups made with the same key share their answers, regardless of the
+	// case of the name looked up.
+	ctx := WithAnswerCacheKey(context.Background(), "validation")
+	txts, err := cache.LookupTXT(ctx, "example.net")
+	test.AssertNotError(t, err, "LookupTXT failed")
+	test.AssertDeepEquals(t, txts, []string{"txt"})
+	txts, err = cache.LookupTXT(ctx, "EXAMPLE.net")
+	test.AssertNotError(t, err, "LookupTXT failed")
+	test.AssertDeepEquals(t, txts, []string{"txt"})
+	test.AssertEquals(t, inner.count("example.net"), 1)
+	test.AssertEquals(t, cacheLookups(cache, "TXT", "miss"), 1)
+	test.AssertEquals(t, cacheLookups(cache, "TXT", "hit"), 1)
+
+	// Lookups of other types, or made with other keys, don't.
+	addrs, err := cache.LookupHost(ctx, "example.net")
+	test.AssertNotError(t, err, "LookupHost failed")
+	test.AssertEquals(t, len(addrs), 1)
+	caas, err := cache.LookupCAA(ctx, "example.net")
+	test.AssertNotError(t, err, "LookupCAA failed")
+	test.AssertEquals(t, len(caas), 1)
+	_, err = cache.LookupTXT(WithAnswerCacheKey(context.Background(), "other validation"), "example.net")
+	test.AssertNotError(t, err, "LookupTXT failed")
+	test.AssertEquals(t, inner.count("example.net"), 4)
+	test.AssertEquals(t, cacheLookups(cache, "A", "miss"), 1)
+	test.AssertEquals(t, cacheLookups(cache, "CAA", "miss"), 1)
+
+	// Answers expire after the TTL.
+	clk.Add(time.Minute)
+	_, err = cache.LookupTXT(ctx, "example.net")
+	test.AssertNotError(t, err, "LookupTXT failed")
+	test.AssertEquals(t, inner.count("example.net"), 5)
+
+	// Failed lookups aren't cached.
+	for i := 0; i < 2; i++ {
+		_, err = cache.LookupTXT(ctx, "broken.com")
+		test.AssertError(t, err, "LookupTXT of broken.com succeeded")
+	}
+	test.AssertEquals(t, inner.count("broken.com"), 2)
+}
+
+func TestAnswerCacheConcurrentLookups(t *testing.T) {
+	inner := &countingDNSClient{
+		lookups: make(map[string]int),
+		block:   make(chan struct{}),
+	}
+	cache := NewAnswerCache(inner, time.Minute, clock.NewFake(), metrics.NoopRegisterer)
+	ctx := WithAnswerCacheKey(context.Background(), "validation")
+
+	// Identical lookups made while the first is in progress wait for its
+	// answer rather than making their own.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.LookupHost(ctx, "example.com")
+			test.AssertNotError(t, err, "LookupHost failed")
+		}()
+	}
+	for cacheLookups(cache, "A", "miss") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(inner.block)
+	wg.Wait()
+	test.AssertEquals(t, inner.count("example.com"), 1)
+	test.AssertEquals(t, cacheLookups(cache, "A", "hit"), 4)
+}
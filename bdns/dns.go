@@ -157,6 +157,7 @@ type DNSClientImpl struct {
 	dnsClient                exchanger
 	servers                  []string
 	allowRestrictedAddresses bool
+	requireDNSSEC            bool
 	maxTries                 int
 	clk                      clock.Clock
 	log                      blog.Logger
@@ -165,6 +166,19 @@ type DNSClientImpl struct {
 	totalLookupTime   *prometheus.HistogramVec
 	timeoutCounter    *prometheus.CounterVec
 	idMismatchCounter *prometheus.CounterVec
+	serverHealthy     *prometheus.GaugeVec
+	serverErrorRate   *prometheus.GaugeVec
+	serverLatency     *prometheus.GaugeVec
+	serverEjections   *prometheus.CounterVec
+
+	// healthMu protects the results of the most recent health checks, the
+	// servers to fall back to if every server failed them, and the passive
+	// health tracking state of each server.
+	healthMu        sync.RWMutex
+	unhealthy       map[string]bool
+	fallbackServers []string
+	healthPolicy    HealthPolicy
+	serverStats     map[string]*serverStats
 }
 
 var _ DNSClient = &DNSClientImpl{}
@@ -174,7 +188,10 @@ type exchanger interface {
 }
 
 // NewDNSClientImpl constructs a new DNS resolver object that utilizes the
-// provided list of DNS servers for resolution.
+// provided list of DNS servers for resolution. Servers are queried over plain
+// UDP, falling back to TCP for truncated responses, unless they're given as a
+// DNS-over-HTTPS URL ("https://resolver.example.com/dns-query") or a
+// DNS-over-TLS address ("tls://resolver.example.com:853").
 func NewDNSClientImpl(
 	readTimeout time.Duration,
 	servers []string,
@@ -183,11 +200,7 @@ func NewDNSClientImpl(
 	maxTries int,
 	log blog.Logger,
 ) *DNSClientImpl {
-	dnsClient := new(dns.Client)
-
-	// Set timeout for underlying net.Conn
-	dnsClient.ReadTimeout = readTimeout
-	dnsClient.Net = "udp"
+	dnsClient := newTransportExchanger(readTimeout)
 
 	queryTime := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -219,7 +232,36 @@ func NewDNSClientImpl(
 		},
 		[]string{"qtype", "resolver"},
 	)
-	stats.MustRegister(queryTime, totalLookupTime, timeoutCounter, idMismatchCounter)
+	serverHealthy := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dns_server_healthy",
+			Help: "Whether a DNS server passed its most recent health check and isn't ejected from rotation (1) or not (0)",
+		},
+		[]string{"resolver"},
+	)
+	serverErrorRate := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dns_server_error_rate",
+			Help: "Fraction of the queries in a DNS server's health tracking window which failed",
+		},
+		[]string{"resolver"},
+	)
+	serverLatency := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dns_server_latency",
+			Help: "Average round trip time in seconds of the queries in a DNS server's health tracking window",
+		},
+		[]string{"resolver"},
+	)
+	serverEjections := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dns_server_ejections",
+			Help: "Number of times a DNS server was ejected from rotation for its error rate or latency",
+		},
+		[]string{"resolver"},
+	)
+	stats.MustRegister(queryTime, totalLookupTime, timeoutCounter, idMismatchCounter, serverHealthy,
+		serverErrorRate, serverLatency, serverEjections)
 
 	return &DNSClientImpl{
 		dnsClient:                dnsClient,
@@ -231,6 +273,10 @@ func NewDNSClientImpl(
 		totalLookupTime:          totalLookupTime,
 		timeoutCounter:           timeoutCounter,
 		idMismatchCounter:        idMismatchCounter,
+		serverHealthy:            serverHealthy,
+		serverErrorRate:          serverErrorRate,
+		serverLatency:            serverLatency,
+		serverEjections:          serverEjections,
 		log:                      log,
 	}
 }
@@ -250,37 +296,53 @@ func NewTestDNSClientImpl(
 	return resolver
 }
 
-// exchangeOne performs a single DNS exchange with a randomly chosen server
-// out of the server list, returning the response, time, and error (if any).
-// We assume that the upstream resolver requests and validates DNSSEC records
-// itself.
-func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string, qtype uint16) (resp *dns.Msg, err error) {
+// newQuery returns a query for the qtype records of hostname.
+func (dnsClient *DNSClientImpl) newQuery(hostname string, qtype uint16) *dns.Msg {
 	m := new(dns.Msg)
 	// Set question type
 	m.SetQuestion(dns.Fqdn(hostname), qtype)
 	// Set the AD bit in the query header so that the resolver knows that
 	// we are interested in this bit in the response header. If this isn't
 	// set the AD bit in the response is useless (RFC 6840 Section 5.7).
-	// This has no security implications, it simply allows us to gather
-	// metrics about the percentage of responses that are secured with
-	// DNSSEC.
+	// Unless DNSSEC is required, this has no security implications, it
+	// simply allows us to gather metrics about the percentage of responses
+	// that are secured with DNSSEC.
 	m.AuthenticatedData = true
 	// Tell the resolver that we're willing to receive responses up to 4096 bytes.
 	// This happens sometimes when there are a very large number of CAA records
-	// present.
-	m.SetEdns0(4096, false)
+	// present. If DNSSEC is required, also set the DO bit so that answers from
+	// signed zones include their RRSIGs.
+	m.SetEdns0(4096, dnsClient.requireDNSSEC)
+	return m
+}
 
-	if len(dnsClient.servers) < 1 {
+// exchangeOne performs a single DNS exchange with a randomly chosen server
+// out of the server list, returning the response, time, and error (if any).
+// We assume that the upstream resolver requests and validates DNSSEC records
+// itself.
+func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string, qtype uint16) (*dns.Msg, error) {
+	return dnsClient.exchange(ctx, hostname, dnsClient.newQuery(hostname, qtype))
+}
+
+// exchange sends the query m for hostname as described by exchangeOne.
+func (dnsClient *DNSClientImpl) exchange(ctx context.Context, hostname string, m *dns.Msg) (resp *dns.Msg, err error) {
+	qtype := m.Question[0].Qtype
+	servers := dnsClient.availableServers()
+	if len(servers) < 1 {
 		return nil, fmt.Errorf("Not configured with at least one DNS Server")
 	}
 
 	// Randomly pick a server
-	chosenServerIndex := rand.Intn(len(dnsClient.servers))
-	chosenServer := dnsClient.servers[chosenServerIndex]
+	chosenServerIndex := rand.Intn(len(servers))
+	chosenServer := servers[chosenServerIndex]
 
 	start := dnsClient.clk.Now()
 	client := dnsClient.dnsClient
 	qtypeStr := dns.TypeToString[qtype]
+	maxTries, backoff := dnsClient.maxTries, time.Duration(0)
+	if policy, ok := ctx.Value(retryPolicyKey{}).(retryPolicy); ok {
+		maxTries, backoff = policy.maxTries, policy.backoff
+	}
 	tries := 1
 	defer func() {
 		result, authenticated := "failed", ""
@@ -301,6 +363,7 @@ func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string
 
 		go func() {
 			rsp, rtt, err := client.Exchange(m, chosenServer)
+			dnsClient.recordQuery(chosenServer, err != nil || rsp.Rcode == dns.RcodeServerFailure, rtt)
 			result, authenticated := "failed", ""
 			if rsp != nil {
 				result = dns.RcodeToString[rsp.Rcode]
@@ -350,15 +413,23 @@ func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string
 			if r.err != nil {
 				operr, ok := r.err.(*net.OpError)
 				isRetryable := ok && operr.Temporary()
-				hasRetriesLeft := tries < dnsClient.maxTries
+				hasRetriesLeft := tries < maxTries
 				if isRetryable && hasRetriesLeft {
+					// Wait before retrying, doubling the wait after each try. If the
+					// context is done while waiting, the next try is abandoned.
+					if backoff > 0 {
+						select {
+						case <-ctx.Done():
+						case <-time.After(backoff << uint(tries-1)):
+						}
+					}
 					tries++
 					// Chose a new server to retry the query with by incrementing the
 					// chosen server index modulo the number of servers. This ensures that
 					// if one dns server isn't available we retry with the next in the
 					// list.
-					chosenServerIndex = (chosenServerIndex + 1) % len(dnsClient.servers)
-					chosenServer = dnsClient.servers[chosenServerIndex]
+					chosenServerIndex = (chosenServerIndex + 1) % len(servers)
+					chosenServer = servers[chosenServerIndex]
 					continue
 				} else if isRetryable && !hasRetriesLeft {
 					dnsClient.timeoutCounter.With(prometheus.Labels{
@@ -380,13 +451,70 @@ type dnsResp struct {
 	err error
 }
 
+type retryPolicyKey struct{}
+
+type retryPolicy struct {
+	maxTries int
+	backoff  time.Duration
+}
+
+// WithRetryPolicy returns a context which makes DNSClientImpl try queries
+// made with it up to maxTries times, instead of the client's configured number,
+// if they fail with a temporary error. Before each retry it waits for backoff,
+// doubled after every try. This lets callers tune the lookups made for
+// different purposes separately.
+func WithRetryPolicy(ctx context.Context, maxTries int, backoff time.Duration) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, retryPolicy{maxTries: maxTries, backoff: backoff})
+}
+
+// RequireDNSSEC makes the client require DNSSEC-authenticated answers to TXT
+// and CAA queries for names in signed zones. Its servers must be trusted
+// validating resolvers, which set the AD bit on answers they authenticated.
+func (dnsClient *DNSClientImpl) RequireDNSSEC() {
+	dnsClient.requireDNSSEC = true
+}
+
+// exchangeAuthenticated performs a DNS exchange like exchangeOne, and if the
+// client requires DNSSEC, checks that the response wasn't from a signed zone
+// which failed, or wasn't subject to, DNSSEC validation.
+func (dnsClient *DNSClientImpl) exchangeAuthenticated(ctx context.Context, hostname string, qtype uint16) (*dns.Msg, error) {
+	r, err := dnsClient.exchangeOne(ctx, hostname, qtype)
+	if err != nil || !dnsClient.requireDNSSEC {
+		return r, err
+	}
+
+	if r.Rcode == dns.RcodeServerFailure {
+		// A validating resolver answers SERVFAIL for names whose DNSSEC
+		// signatures are bogus. If it can answer the query with checking
+		// disabled (RFC 4035 Section 3.2.2), that's why this query failed.
+		m := dnsClient.newQuery(hostname, qtype)
+		m.CheckingDisabled = true
+		cdResp, cdErr := dnsClient.exchange(ctx, hostname, m)
+		if cdErr == nil && cdResp.Rcode != dns.RcodeServerFailure {
+			return nil, errDNSSECBogus
+		}
+		return r, nil
+	}
+
+	// An answer carrying signatures is from a signed zone, so it must have
+	// been authenticated.
+	if !r.AuthenticatedData {
+		for _, rr := range r.Answer {
+			if rr.Header().Rrtype == dns.TypeRRSIG {
+				return nil, errDNSSECUnauthenticated
+			}
+		}
+	}
+	return r, nil
+}
+
 // LookupTXT sends a DNS query to find all TXT records associated with
 // the provided hostname which it returns along with the returned
 // DNS authority section.
 func (dnsClient *DNSClientImpl) LookupTXT(ctx context.Context, hostname string) ([]string, error) {
 	var txt []string
 	dnsType := dns.TypeTXT
-	r, err := dnsClient.exchangeOne(ctx, hostname, dnsType)
+	r, err := dnsClient.exchangeAuthenticated(ctx, hostname, dnsType)
 	if err != nil {
 		return nil, &DNSError{dnsType, hostname, err, -1}
 	}
@@ -423,6 +551,15 @@ func isPrivateV6(ip net.IP) bool {
 	return false
 }
 
+// IsReservedIP returns true if ip is in one of the private or reserved address
+// ranges that Boulder never resolves hostnames to.
+func IsReservedIP(ip net.IP) bool {
+	if ip.To4() != nil {
+		return isPrivateV4(ip)
+	}
+	return isPrivateV6(ip)
+}
+
 func (dnsClient *DNSClientImpl) lookupIP(ctx context.Context, hostname string, ipType uint16) ([]dns.RR, error) {
 	resp, err := dnsClient.exchangeOne(ctx, hostname, ipType)
 	if err != nil {
@@ -485,7 +622,7 @@ func (dnsClient *DNSClientImpl) LookupHost(ctx context.Context, hostname string)
 // the provided hostname.
 func (dnsClient *DNSClientImpl) LookupCAA(ctx context.Context, hostname string) ([]*dns.CAA, error) {
 	dnsType := dns.TypeCAA
-	r, err := dnsClient.exchangeOne(ctx, hostname, dnsType)
+	r, err := dnsClient.exchangeAuthenticated(ctx, hostname, dnsType)
 	if err != nil {
 		return nil, &DNSError{dnsType, hostname, err, -1}
 	}
@@ -726,3 +726,116 @@ func TestRotateServerOnErr(t *testing.T) {
 	// We expect that the C server eventually served all of the lookups attempted
 	test.AssertEquals(t, mock.lookups["c"], maxTries*2)
 }
+
+// dnssecExchanger is an exchanger which answers queries as a validating
+// resolver would for a signed zone: SERVFAIL if the zone's signatures are
+// bogus, unless checking is disabled, and otherwise an answer with an RRSIG,
+// which is authenticated if authenticated is set.
+type dnssecExchanger struct {
+	bogus         bool
+	authenticated bool
+	queries       []*dns.Msg
+}
+
+func (e *dnssecExchanger) Exchange(m *dns.Msg, a string) (*dns.Msg, time.Duration, error) {
+	e.queries = append(e.queries, m)
+	r := new(dns.Msg)
+	r.SetReply(m)
+	if e.bogus && !m.CheckingDisabled {
+		r.Rcode = dns.RcodeServerFailure
+		return r, time.Millisecond, nil
+	}
+	name := m.Question[0].Name
+	r.AuthenticatedData = e.authenticated
+	r.Answer = append(r.Answer,
+		&dns.TXT{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET},
+			Txt: []string{"signed"},
+		},
+		&dns.RRSIG{
+			Hdr:         dns.RR_Header{Name: name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET},
+			TypeCovered: dns.TypeTXT,
+		})
+	return r, time.Millisecond, nil
+}
+
+func TestRequireDNSSEC(t *testing.T) {
+	testCases := []struct {
+		name          string
+		require       bool
+		exchanger     *dnssecExchanger
+		expectedError string
+	}{
+		{
+			name:      "authenticated answer",
+			require:   true,
+			exchanger: &dnssecExchanger{authenticated: true},
+		},
+		{
+			name:          "unauthenticated answer",
+			require:       true,
+			exchanger:     &dnssecExchanger{},
+			expectedError: "DNS problem: unauthenticated answer from DNSSEC-signed zone looking up TXT for signed.example.com",
+		},
+		{
+			name:          "bogus",
+			require:       true,
+			exchanger:     &dnssecExchanger{bogus: true},
+			expectedError: "DNS problem: DNSSEC validation failure looking up TXT for signed.example.com",
+		},
+		{
+			name:      "unauthenticated answer, DNSSEC not required",
+			exchanger: &dnssecExchanger{},
+		},
+		{
+			name:          "bogus, DNSSEC not required",
+			exchanger:     &dnssecExchanger{bogus: true},
+			expectedError: "DNS problem: SERVFAIL looking up TXT for signed.example.com - the domain's nameservers may be malfunctioning",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, metrics.NoopRegisterer, clock.NewFake(), 1, blog.UseMock())
+			client.dnsClient = tc.exchanger
+			if tc.require {
+				client.RequireDNSSEC()
+			}
+
+			txts, err := client.LookupTXT(context.Background(), "signed.example.com")
+			if tc.expectedError != "" {
+				test.AssertError(t, err, "lookup succeeded")
+				test.AssertEquals(t, err.Error(), tc.expectedError)
+				dnsErr, ok := err.(*DNSError)
+				test.Assert(t, ok, "lookup didn't return a DNSError")
+				test.AssertEquals(t, dnsErr.DNSSECFailure(), tc.require)
+				return
+			}
+			test.AssertNotError(t, err, "lookup failed")
+			test.AssertDeepEquals(t, txts, []string{"signed"})
+			// The DO bit is only set when DNSSEC is required.
+			test.AssertEquals(t, tc.exchanger.queries[0].IsEdns0().Do(), tc.require)
+		})
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	isTempErr := &net.OpError{Op: "read", Err: tempError(true)}
+	dr := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, metrics.NoopRegisterer, clock.NewFake(), 1, blog.UseMock())
+
+	// Without a retry policy the client's maxTries applies.
+	dr.dnsClient = &testExchanger{errs: []error{isTempErr, isTempErr, nil}}
+	_, err := dr.LookupTXT(context.Background(), "example.com")
+	test.AssertError(t, err, "lookup succeeded without retries")
+
+	// A retry policy overrides it, and backs off between tries.
+	te := &testExchanger{errs: []error{isTempErr, isTempErr, nil}}
+	dr.dnsClient = te
+	backoff := 10 * time.Millisecond
+	started := time.Now()
+	_, err = dr.LookupTXT(WithRetryPolicy(context.Background(), 3, backoff), "example.com")
+	test.AssertNotError(t, err, "lookup failed despite retries")
+	test.AssertEquals(t, te.count, 3)
+	if took := time.Since(started); took < 3*backoff {
+		t.Errorf("lookup took %s, expected at least %s of backoff", took, 3*backoff)
+	}
+}
@@ -0,0 +1,364 @@
+package bdns
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// healthCheckName is the name queried by health checks. Every resolver should
+// be able to answer a query for the root zone's NS records.
+const healthCheckName = "."
+
+// HealthPolicy configures the passive health tracking enabled by
+// EjectUnhealthyServers. A server is ejected from rotation once too many of
+// its recent queries failed, or they took too long on average, and is then
+// re-probed until it passes a health check.
+type HealthPolicy struct {
+	// Window is the number of a server's most recent queries which its error
+	// rate and latency are calculated over. A server is only ejected once it
+	// has a full window of queries, and it starts a new window when readmitted.
+	Window int
+	// MaxErrorRate is the fraction of the queries in the window which may fail,
+	// with an error or a SERVFAIL response, before the server is ejected.
+	MaxErrorRate float64
+	// MaxLatency, if non-zero, is the average round trip time over the window
+	// above which the server is ejected.
+	MaxLatency time.Duration
+	// ProbeInterval is how often ejected servers are health checked.
+	ProbeInterval time.Duration
+}
+
+// queryResult is the outcome of one query sent to a server.
+type queryResult struct {
+	failed  bool
+	latency time.Duration
+}
+
+// serverStats holds the results of a server's most recent queries in a ring
+// buffer, and when they got it ejected from rotation.
+type serverStats struct {
+	results []queryResult
+	next    int
+	full    bool
+	// ejectedAt is the zero time unless the server is ejected.
+	ejectedAt time.Time
+}
+
+func (s *serverStats) record(r queryResult) {
+	s.results[s.next] = r
+	s.next = (s.next + 1) % len(s.results)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// summary returns the number of queries in the server's window, the fraction
+// of them which failed, and their average latency.
+func (s *serverStats) summary() (queries int, errorRate float64, latency time.Duration) {
+	queries = s.next
+	if s.full {
+		queries = len(s.results)
+	}
+	if queries == 0 {
+		return 0, 0, 0
+	}
+	var failed int
+	var total time.Duration
+	for _, r := range s.results[:queries] {
+		if r.failed {
+			failed++
+		}
+		total += r.latency
+	}
+	return queries, float64(failed) / float64(queries), total / time.Duration(queries)
+}
+
+// reset empties the server's window and readmits it to rotation.
+func (s *serverStats) reset() {
+	s.next = 0
+	s.full = false
+	s.ejectedAt = time.Time{}
+}
+
+// ResolverState describes one of a DNSClientImpl's servers, as reported by the
+// /debug/resolvers endpoint.
+type ResolverState struct {
+	Server string `json:"server"`
+	// Fallback is true for the fallback servers passed to StartHealthChecks.
+	Fallback bool `json:"fallback,omitempty"`
+	// InRotation is true if queries are currently sent to the server.
+	InRotation bool `json:"inRotation"`
+	// FailedHealthCheck is true if the server failed its most recent periodic
+	// health check.
+	FailedHealthCheck bool `json:"failedHealthCheck"`
+	// EjectedAt is when the server was ejected from rotation by passive health
+	// tracking, if it's currently ejected.
+	EjectedAt *time.Time `json:"ejectedAt,omitempty"`
+	// Queries, ErrorRate and AverageLatency describe the server's current
+	// passive health tracking window.
+	Queries        int     `json:"queries"`
+	ErrorRate      float64 `json:"errorRate"`
+	AverageLatency float64 `json:"averageLatencySeconds"`
+}
+
+// StartHealthChecks sends a health check query to each of the client's servers
+// every interval, until ctx is done. Queries are then only sent to servers
+// which passed their most recent health check. If none have, queries are sent
+// to the fallbackServers instead, which are typically plain UDP/TCP resolvers
+// backing up DoH or DoT upstreams. If there are no fallbackServers, every
+// server is used until one passes a health check.
+func (dnsClient *DNSClientImpl) StartHealthChecks(ctx context.Context, interval time.Duration, fallbackServers []string) {
+	dnsClient.healthMu.Lock()
+	dnsClient.fallbackServers = fallbackServers
+	dnsClient.healthMu.Unlock()
+	dnsClient.checkHealth()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				dnsClient.checkHealth()
+			}
+		}
+	}()
+}
+
+// EjectUnhealthyServers makes the client track the error rate and latency of
+// the queries it sends to each of its servers, and eject servers which exceed
+// the limits of policy from rotation. Every policy.ProbeInterval, until ctx is
+// done, each ejected server is sent a health check query, and readmitted if it
+// passes. If every server is ejected, queries fall back as they do when every
+// server fails its periodic health check.
+func (dnsClient *DNSClientImpl) EjectUnhealthyServers(ctx context.Context, policy HealthPolicy) {
+	if policy.Window < 1 {
+		policy.Window = 1
+	}
+	stats := make(map[string]*serverStats, len(dnsClient.servers))
+	for _, server := range dnsClient.servers {
+		stats[server] = &serverStats{results: make([]queryResult, policy.Window)}
+	}
+	dnsClient.healthMu.Lock()
+	dnsClient.healthPolicy = policy
+	dnsClient.serverStats = stats
+	dnsClient.healthMu.Unlock()
+	go func() {
+		ticker := time.NewTicker(policy.ProbeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				dnsClient.probeEjected()
+			}
+		}
+	}()
+}
+
+// probe sends a health check query to server, returning an error if it fails
+// or is answered with SERVFAIL.
+func (dnsClient *DNSClientImpl) probe(server string) error {
+	m := new(dns.Msg)
+	m.SetQuestion(healthCheckName, dns.TypeNS)
+	m.SetEdns0(4096, false)
+	r, _, err := dnsClient.dnsClient.Exchange(m, server)
+	if err != nil {
+		return err
+	}
+	if r.Rcode == dns.RcodeServerFailure {
+		return fmt.Errorf("health check answered with %s", dns.RcodeToString[r.Rcode])
+	}
+	return nil
+}
+
+// checkHealth sends a health check query to each of the client's servers
+// concurrently, and records which of them failed.
+func (dnsClient *DNSClientImpl) checkHealth() {
+	unhealthy := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, server := range dnsClient.servers {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			err := dnsClient.probe(server)
+			if err != nil {
+				dnsClient.log.Warningf("DNS health check failed for server=[%s] err=[%v]", server, err)
+				mu.Lock()
+				unhealthy[server] = true
+				mu.Unlock()
+			}
+		}(server)
+	}
+	wg.Wait()
+
+	dnsClient.healthMu.Lock()
+	defer dnsClient.healthMu.Unlock()
+	dnsClient.unhealthy = unhealthy
+	for _, server := range dnsClient.servers {
+		dnsClient.updateHealthyGauge(server)
+	}
+}
+
+// probeEjected sends a health check query to each ejected server
+// concurrently, and readmits those which pass to rotation.
+func (dnsClient *DNSClientImpl) probeEjected() {
+	var ejected []string
+	dnsClient.healthMu.RLock()
+	for _, server := range dnsClient.servers {
+		if stats, ok := dnsClient.serverStats[server]; ok && !stats.ejectedAt.IsZero() {
+			ejected = append(ejected, server)
+		}
+	}
+	dnsClient.healthMu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, server := range ejected {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			err := dnsClient.probe(server)
+			if err != nil {
+				dnsClient.log.Debugf("Ejected DNS server=[%s] failed its probe err=[%v]", server, err)
+				return
+			}
+			dnsClient.healthMu.Lock()
+			defer dnsClient.healthMu.Unlock()
+			dnsClient.serverStats[server].reset()
+			dnsClient.updateHealthyGauge(server)
+			dnsClient.log.Infof("Readmitting DNS server=[%s] to rotation", server)
+		}(server)
+	}
+	wg.Wait()
+}
+
+// recordQuery adds the result of a query sent to server to its passive health
+// tracking window, ejecting it from rotation if the window exceeds the
+// client's HealthPolicy. It does nothing unless EjectUnhealthyServers was
+// called, and results aren't recorded for servers which are already ejected.
+func (dnsClient *DNSClientImpl) recordQuery(server string, failed bool, latency time.Duration) {
+	dnsClient.healthMu.Lock()
+	defer dnsClient.healthMu.Unlock()
+	stats, ok := dnsClient.serverStats[server]
+	if !ok || !stats.ejectedAt.IsZero() {
+		return
+	}
+	stats.record(queryResult{failed: failed, latency: latency})
+	queries, errorRate, avgLatency := stats.summary()
+	labels := prometheus.Labels{"resolver": server}
+	dnsClient.serverErrorRate.With(labels).Set(errorRate)
+	dnsClient.serverLatency.With(labels).Set(avgLatency.Seconds())
+
+	policy := dnsClient.healthPolicy
+	if queries < policy.Window {
+		return
+	}
+	tooSlow := policy.MaxLatency > 0 && avgLatency > policy.MaxLatency
+	if errorRate <= policy.MaxErrorRate && !tooSlow {
+		return
+	}
+	stats.ejectedAt = dnsClient.clk.Now()
+	dnsClient.serverEjections.With(labels).Inc()
+	dnsClient.updateHealthyGauge(server)
+	dnsClient.log.Warningf("Ejecting DNS server=[%s] from rotation: errorRate=[%.2f] averageLatency=[%s] over its last %d queries",
+		server, errorRate, avgLatency, queries)
+}
+
+// updateHealthyGauge sets the dns_server_healthy gauge of server to whether it
+// passed its most recent health check and isn't ejected. The caller must hold
+// healthMu.
+func (dnsClient *DNSClientImpl) updateHealthyGauge(server string) {
+	var value float64
+	if dnsClient.isHealthy(server) {
+		value = 1
+	}
+	dnsClient.serverHealthy.With(prometheus.Labels{"resolver": server}).Set(value)
+}
+
+// isHealthy returns true if server passed its most recent health check and
+// isn't ejected. The caller must hold healthMu.
+func (dnsClient *DNSClientImpl) isHealthy(server string) bool {
+	if dnsClient.unhealthy[server] {
+		return false
+	}
+	if stats, ok := dnsClient.serverStats[server]; ok && !stats.ejectedAt.IsZero() {
+		return false
+	}
+	return true
+}
+
+// availableServers returns the servers which queries should be sent to: those
+// which passed their most recent health check and aren't ejected, or the
+// fallback servers if there are none. Before any health checks have run, every
+// server is available.
+func (dnsClient *DNSClientImpl) availableServers() []string {
+	dnsClient.healthMu.RLock()
+	defer dnsClient.healthMu.RUnlock()
+	return dnsClient.inRotation()
+}
+
+// inRotation implements availableServers. The caller must hold healthMu.
+func (dnsClient *DNSClientImpl) inRotation() []string {
+	var healthy []string
+	for _, server := range dnsClient.servers {
+		if dnsClient.isHealthy(server) {
+			healthy = append(healthy, server)
+		}
+	}
+	if len(healthy) == len(dnsClient.servers) {
+		return dnsClient.servers
+	}
+	if len(healthy) > 0 {
+		return healthy
+	}
+	if len(dnsClient.fallbackServers) > 0 {
+		return dnsClient.fallbackServers
+	}
+	return dnsClient.servers
+}
+
+// ResolverStates returns the current health of each of the client's servers,
+// followed by its fallback servers.
+func (dnsClient *DNSClientImpl) ResolverStates() []ResolverState {
+	dnsClient.healthMu.RLock()
+	defer dnsClient.healthMu.RUnlock()
+	inRotation := make(map[string]bool)
+	for _, server := range dnsClient.inRotation() {
+		inRotation[server] = true
+	}
+
+	var states []ResolverState
+	for _, server := range dnsClient.servers {
+		state := ResolverState{
+			Server:            server,
+			InRotation:        inRotation[server],
+			FailedHealthCheck: dnsClient.unhealthy[server],
+		}
+		if stats, ok := dnsClient.serverStats[server]; ok {
+			var latency time.Duration
+			state.Queries, state.ErrorRate, latency = stats.summary()
+			state.AverageLatency = latency.Seconds()
+			if !stats.ejectedAt.IsZero() {
+				ejectedAt := stats.ejectedAt
+				state.EjectedAt = &ejectedAt
+			}
+		}
+		states = append(states, state)
+	}
+	for _, server := range dnsClient.fallbackServers {
+		states = append(states, ResolverState{
+			Server:     server,
+			Fallback:   true,
+			InRotation: inRotation[server],
+		})
+	}
+	return states
+}
@@ -0,0 +1,143 @@
+package bdns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestHealthChecks(t *testing.T) {
+	client := NewTestDNSClientImpl(time.Second*10, []string{"a", "b"}, metrics.NoopRegisterer, clock.NewFake(), 1, blog.UseMock())
+	mock := &rotateFailureExchanger{
+		brokenAddresses: map[string]bool{"a": true},
+		lookups:         make(map[string]int),
+	}
+	client.dnsClient = mock
+
+	// Before any health checks, every server is used.
+	test.AssertDeepEquals(t, client.availableServers(), []string{"a", "b"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client.StartHealthChecks(ctx, time.Hour, []string{"fallback"})
+	test.AssertDeepEquals(t, client.availableServers(), []string{"b"})
+	healthy, err := test.GaugeValueWithLabels(client.serverHealthy, prometheus.Labels{"resolver": "a"})
+	test.AssertNotError(t, err, "getting gauge value")
+	test.AssertEquals(t, healthy, 0)
+	healthy, err = test.GaugeValueWithLabels(client.serverHealthy, prometheus.Labels{"resolver": "b"})
+	test.AssertNotError(t, err, "getting gauge value")
+	test.AssertEquals(t, healthy, 1)
+
+	// Queries are only sent to healthy servers.
+	mock.lookups = make(map[string]int)
+	for i := 0; i < 5; i++ {
+		_, err := client.LookupTXT(context.Background(), "example.com")
+		test.AssertNotError(t, err, "lookup failed")
+	}
+	test.AssertEquals(t, mock.lookups["a"], 0)
+	test.AssertEquals(t, mock.lookups["b"], 5)
+
+	// If every server is unhealthy, the fallback servers are used.
+	mock.brokenAddresses["b"] = true
+	client.checkHealth()
+	test.AssertDeepEquals(t, client.availableServers(), []string{"fallback"})
+	_, err = client.LookupTXT(context.Background(), "example.com")
+	test.AssertNotError(t, err, "lookup failed")
+	test.AssertEquals(t, mock.lookups["fallback"], 1)
+}
+
+func TestEjectUnhealthyServers(t *testing.T) {
+	fc := clock.NewFake()
+	client := NewTestDNSClientImpl(time.Second*10, []string{"a", "b"}, metrics.NoopRegisterer, fc, 1, blog.UseMock())
+	mock := &rotateFailureExchanger{
+		brokenAddresses: map[string]bool{"a": true},
+		lookups:         make(map[string]int),
+	}
+	client.dnsClient = mock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client.EjectUnhealthyServers(ctx, HealthPolicy{
+		Window:        4,
+		MaxErrorRate:  0.5,
+		MaxLatency:    100 * time.Millisecond,
+		ProbeInterval: time.Hour,
+	})
+
+	// A server isn't judged until it has a full window of queries, and may
+	// fail up to MaxErrorRate of them.
+	client.recordQuery("a", false, time.Millisecond)
+	client.recordQuery("a", false, time.Millisecond)
+	client.recordQuery("a", true, time.Millisecond)
+	test.AssertDeepEquals(t, client.availableServers(), []string{"a", "b"})
+	client.recordQuery("a", true, time.Millisecond)
+	test.AssertDeepEquals(t, client.availableServers(), []string{"a", "b"})
+
+	// Once the window's error rate exceeds MaxErrorRate, the server is ejected.
+	client.recordQuery("a", true, time.Millisecond)
+	test.AssertDeepEquals(t, client.availableServers(), []string{"b"})
+	test.AssertEquals(t, test.CountCounterVec("resolver", "a", client.serverEjections), 1)
+	healthy, err := test.GaugeValueWithLabels(client.serverHealthy, prometheus.Labels{"resolver": "a"})
+	test.AssertNotError(t, err, "getting gauge value")
+	test.AssertEquals(t, healthy, 0)
+
+	// Queries are only sent to servers in rotation, and their results are
+	// recorded.
+	for i := 0; i < 3; i++ {
+		_, err := client.LookupTXT(context.Background(), "example.com")
+		test.AssertNotError(t, err, "lookup failed")
+	}
+	test.AssertEquals(t, mock.lookups["a"], 0)
+	test.AssertEquals(t, mock.lookups["b"], 3)
+
+	// A server which is too slow is ejected as well. When every server is
+	// ejected and there are no fallback servers, every server is used.
+	client.recordQuery("b", false, time.Second)
+	test.AssertDeepEquals(t, client.availableServers(), []string{"a", "b"})
+
+	test.AssertDeepEquals(t, client.ResolverStates(), []ResolverState{
+		{
+			Server:         "a",
+			InRotation:     true,
+			EjectedAt:      timePtr(fc.Now()),
+			Queries:        4,
+			ErrorRate:      0.75,
+			AverageLatency: 0.001,
+		},
+		{
+			Server:         "b",
+			InRotation:     true,
+			EjectedAt:      timePtr(fc.Now()),
+			Queries:        4,
+			ErrorRate:      0,
+			AverageLatency: 0.2515,
+		},
+	})
+
+	// Ejected servers are readmitted with an empty window once they pass a
+	// probe.
+	client.probeEjected()
+	test.AssertDeepEquals(t, client.availableServers(), []string{"b"})
+	states := client.ResolverStates()
+	test.Assert(t, states[0].EjectedAt != nil, "server which failed its probe was readmitted")
+	test.Assert(t, states[1].EjectedAt == nil, "server which passed its probe wasn't readmitted")
+	test.AssertEquals(t, states[1].Queries, 0)
+
+	mock.brokenAddresses["a"] = false
+	client.probeEjected()
+	test.AssertDeepEquals(t, client.availableServers(), []string{"a", "b"})
+	healthy, err = test.GaugeValueWithLabels(client.serverHealthy, prometheus.Labels{"resolver": "a"})
+	test.AssertNotError(t, err, "getting gauge value")
+	test.AssertEquals(t, healthy, 1)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -43,6 +43,9 @@ func (mock *MockDNSClient) LookupTXT(_ context.Context, hostname string) ([]stri
 		// expected token + test account jwk thumbprint
 		return []string{"LPsIwTo7o8BoG0-vjCyGQGBWSVIPxI-i_X336eUOQZo"}, nil
 	}
+	if hostname == "_acme-challenge.dnssec-bogus.com" {
+		return nil, &DNSError{dns.TypeTXT, hostname, errDNSSECBogus, -1}
+	}
 	// empty-txts.com always returns zero TXT records
 	if hostname == "_acme-challenge.empty-txts.com" {
 		return []string{}, nil
@@ -120,5 +123,8 @@ func (mock *MockDNSClient) LookupHost(_ context.Context, hostname string) ([]net
 
 // LookupCAA returns mock records for use in tests.
 func (mock *MockDNSClient) LookupCAA(_ context.Context, domain string) ([]*dns.CAA, error) {
+	if domain == "dnssec-bogus.com" {
+		return nil, &DNSError{dns.TypeCAA, domain, errDNSSECBogus, -1}
+	}
 	return nil, nil
 }
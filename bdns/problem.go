@@ -2,6 +2,7 @@ package bdns
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 
@@ -24,7 +25,9 @@ func (d DNSError) Underlying() error {
 func (d DNSError) Error() string {
 	var detail, additional string
 	if d.underlying != nil {
-		if netErr, ok := d.underlying.(*net.OpError); ok {
+		if d.DNSSECFailure() {
+			detail = d.underlying.Error()
+		} else if netErr, ok := d.underlying.(*net.OpError); ok {
 			if netErr.Timeout() {
 				detail = detailDNSTimeout
 			} else {
@@ -59,6 +62,17 @@ func (d DNSError) Timeout() bool {
 	return false
 }
 
+// DNSSECFailure returns true if the lookup failed because DNSSEC was required
+// and the answer was bogus or unauthenticated.
+func (d DNSError) DNSSECFailure() bool {
+	return d.underlying == errDNSSECBogus || d.underlying == errDNSSECUnauthenticated
+}
+
+var (
+	errDNSSECBogus           = errors.New("DNSSEC validation failure")
+	errDNSSECUnauthenticated = errors.New("unauthenticated answer from DNSSEC-signed zone")
+)
+
 const detailDNSTimeout = "query timed out"
 const detailDNSNetFailure = "networking error"
 const detailServerFailure = "server failure at resolver"
@@ -0,0 +1,194 @@
+package bdns
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// dohPrefix marks a server address as the URL of a DNS-over-HTTPS (RFC 8484)
+	// upstream, e.g. "https://resolver.example.com/dns-query".
+	dohPrefix = "https://"
+	// dotPrefix marks a server address as the host:port of a DNS-over-TLS
+	// (RFC 7858) upstream, e.g. "tls://resolver.example.com:853".
+	dotPrefix = "tls://"
+
+	// dohMediaType is the media type of DNS wire format messages sent to and
+	// received from DoH upstreams.
+	dohMediaType = "application/dns-message"
+
+	// maxIdleDoTConns is the number of idle connections kept open to each DoT
+	// upstream for reuse by later queries.
+	maxIdleDoTConns = 4
+)
+
+// transportExchanger is the exchanger used by DNSClientImpl. It sends each
+// query using the transport indicated by the server address: DoH for
+// "https://" URLs, DoT for "tls://" addresses, and otherwise plain UDP,
+// falling back to TCP for truncated responses.
+type transportExchanger struct {
+	udp *dns.Client
+	tcp *dns.Client
+	doh *dohExchanger
+	dot *dotExchanger
+}
+
+func newTransportExchanger(readTimeout time.Duration) *transportExchanger {
+	return &transportExchanger{
+		udp: &dns.Client{Net: "udp", ReadTimeout: readTimeout},
+		tcp: &dns.Client{Net: "tcp", ReadTimeout: readTimeout},
+		doh: &dohExchanger{
+			client: &http.Client{
+				Timeout: readTimeout,
+				Transport: &http.Transport{
+					MaxIdleConnsPerHost: maxIdleDoTConns,
+					IdleConnTimeout:     90 * time.Second,
+					TLSHandshakeTimeout: readTimeout,
+				},
+			},
+		},
+		dot: &dotExchanger{
+			client: &dns.Client{Net: "tcp-tls", ReadTimeout: readTimeout},
+			idle:   make(map[string][]*dns.Conn),
+		},
+	}
+}
+
+// Exchange sends m to the server at address a, using the transport a
+// indicates.
+func (te *transportExchanger) Exchange(m *dns.Msg, a string) (*dns.Msg, time.Duration, error) {
+	if strings.HasPrefix(a, dohPrefix) {
+		return te.doh.Exchange(m, a)
+	}
+	if strings.HasPrefix(a, dotPrefix) {
+		return te.dot.Exchange(m, strings.TrimPrefix(a, dotPrefix))
+	}
+	r, rtt, err := te.udp.Exchange(m, a)
+	if err == nil && r.Truncated {
+		return te.tcp.Exchange(m, a)
+	}
+	return r, rtt, err
+}
+
+// dohExchanger sends queries to DNS-over-HTTPS upstreams using POST requests.
+// Connections are reused by the underlying http.Client.
+type dohExchanger struct {
+	client *http.Client
+}
+
+// Exchange POSTs m to the DoH upstream at the URL a.
+func (de *dohExchanger) Exchange(m *dns.Msg, a string) (*dns.Msg, time.Duration, error) {
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequest("POST", a, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", dohMediaType)
+	req.Header.Set("Accept", dohMediaType)
+
+	start := time.Now()
+	resp, err := de.client.Do(req)
+	if err != nil {
+		// Unwrap the net package error, if there is one, so that temporary
+		// network errors are retried as they are for other transports.
+		if urlErr, ok := err.(*url.Error); ok {
+			if opErr, ok := urlErr.Err.(*net.OpError); ok {
+				return nil, time.Since(start), opErr
+			}
+		}
+		return nil, time.Since(start), err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	rtt := time.Since(start)
+	if err != nil {
+		return nil, rtt, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, rtt, fmt.Errorf("DoH upstream returned HTTP status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != dohMediaType {
+		return nil, rtt, fmt.Errorf("DoH upstream returned unexpected Content-Type %q", ct)
+	}
+
+	r := new(dns.Msg)
+	err = r.Unpack(body)
+	if err != nil {
+		return nil, rtt, err
+	}
+	if r.Id != m.Id {
+		return r, rtt, dns.ErrId
+	}
+	return r, rtt, nil
+}
+
+// dotExchanger sends queries to DNS-over-TLS upstreams, keeping idle
+// connections open for reuse by later queries.
+type dotExchanger struct {
+	client *dns.Client
+
+	sync.Mutex
+	idle map[string][]*dns.Conn
+}
+
+// getConn returns an idle connection to the upstream at address, if there is
+// one, or else dials a new one.
+func (de *dotExchanger) getConn(address string) (*dns.Conn, bool, error) {
+	de.Lock()
+	conns := de.idle[address]
+	if len(conns) > 0 {
+		conn := conns[len(conns)-1]
+		de.idle[address] = conns[:len(conns)-1]
+		de.Unlock()
+		return conn, true, nil
+	}
+	de.Unlock()
+	conn, err := de.client.Dial(address)
+	return conn, false, err
+}
+
+// putConn returns a connection which completed an exchange to the idle pool,
+// or closes it if the pool is full.
+func (de *dotExchanger) putConn(address string, conn *dns.Conn) {
+	de.Lock()
+	defer de.Unlock()
+	if len(de.idle[address]) >= maxIdleDoTConns {
+		_ = conn.Close()
+		return
+	}
+	de.idle[address] = append(de.idle[address], conn)
+}
+
+// Exchange sends m to the DoT upstream at address. If a reused connection
+// fails, because the upstream closed it while it was idle, the query is sent
+// again on a new connection.
+func (de *dotExchanger) Exchange(m *dns.Msg, address string) (*dns.Msg, time.Duration, error) {
+	for {
+		conn, reused, err := de.getConn(address)
+		if err != nil {
+			return nil, 0, err
+		}
+		r, rtt, err := de.client.ExchangeWithConn(m, conn)
+		if err != nil {
+			_ = conn.Close()
+			if reused {
+				continue
+			}
+			return r, rtt, err
+		}
+		de.putConn(address, conn)
+		return r, rtt, nil
+	}
+}
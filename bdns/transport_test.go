@@ -0,0 +1,133 @@
+package bdns
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/dns"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// dohHandler answers DoH queries by forwarding them to the loopback resolver.
+func dohHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil || r.Method != "POST" || r.Header.Get("Content-Type") != dohMediaType {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	m := new(dns.Msg)
+	err = m.Unpack(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := dns.Exchange(m, dnsLoopbackAddr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	packed, err := resp.Pack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", dohMediaType)
+	_, _ = w.Write(packed)
+}
+
+func TestDoH(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dns-query", dohHandler)
+	srv := httptest.NewUnstartedServer(mux)
+	var conns int
+	var mu sync.Mutex
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := NewTestDNSClientImpl(time.Second*10, []string{srv.URL + "/dns-query"}, metrics.NoopRegisterer, clock.NewFake(), 1, blog.UseMock())
+	client.dnsClient.(*transportExchanger).doh.client = srv.Client()
+
+	for i := 0; i < 3; i++ {
+		txts, err := client.LookupTXT(context.Background(), "split-txt.letsencrypt.org")
+		test.AssertNotError(t, err, "DoH lookup failed")
+		test.AssertDeepEquals(t, txts, []string{"abc"})
+	}
+	// Every query was sent over the same connection.
+	mu.Lock()
+	test.AssertEquals(t, conns, 1)
+	mu.Unlock()
+
+	client.servers = []string{srv.URL + "/not-found"}
+	_, err := client.LookupTXT(context.Background(), "split-txt.letsencrypt.org")
+	test.AssertError(t, err, "DoH lookup succeeded despite HTTP error")
+}
+
+// countingListener counts the connections it accepts.
+type countingListener struct {
+	net.Listener
+	sync.Mutex
+	accepted int
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.Lock()
+		l.accepted++
+		l.Unlock()
+	}
+	return conn, err
+}
+
+func TestDoT(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("../test/test-example.pem", "../test/test-example.key")
+	test.AssertNotError(t, err, "loading test certificate")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	test.AssertNotError(t, err, "listening")
+	listener := &countingListener{Listener: l}
+
+	started := make(chan struct{})
+	srv := &dns.Server{
+		Listener:          tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{cert}}),
+		Handler:           dns.HandlerFunc(mockDNSQuery),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = srv.ActivateAndServe()
+	}()
+	<-started
+	defer func() {
+		_ = srv.Shutdown()
+	}()
+
+	client := NewTestDNSClientImpl(time.Second*10, []string{dotPrefix + l.Addr().String()}, metrics.NoopRegisterer, clock.NewFake(), 1, blog.UseMock())
+	client.dnsClient.(*transportExchanger).dot.client.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	for i := 0; i < 3; i++ {
+		txts, err := client.LookupTXT(context.Background(), "split-txt.letsencrypt.org")
+		test.AssertNotError(t, err, "DoT lookup failed")
+		test.AssertDeepEquals(t, txts, []string{"abc"})
+	}
+	// Every query was sent over the same connection.
+	listener.Lock()
+	test.AssertEquals(t, listener.accepted, 1)
+	listener.Unlock()
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -16,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -39,6 +41,7 @@ import (
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -93,6 +96,7 @@ const (
 	csrExtensionBasic             = "basic"
 	csrExtensionTLSFeature        = "tls-feature"
 	csrExtensionTLSFeatureInvalid = "tls-feature-invalid"
+	csrExtensionTLSFeatureIgnored = "tls-feature-ignored"
 	csrExtensionOther             = "other"
 )
 
@@ -110,11 +114,30 @@ const (
 	certType    = certificateType("certificate")
 )
 
+// certProfile holds the CFSSL profile names and validity period used to issue
+// certificates for a single named certificate profile.
+type certProfile struct {
+	rsaProfile     string
+	ecdsaProfile   string
+	validityPeriod time.Duration
+	// shortLived profiles omit revocation information.
+	shortLived bool
+	// omitCommonName profiles issue certificates with an empty Subject.
+	omitCommonName bool
+}
+
 // CertificateAuthorityImpl represents a CA that signs certificates, CRLs, and
 // OCSP responses.
 type CertificateAuthorityImpl struct {
 	rsaProfile   string
 	ecdsaProfile string
+	// signingProfiles are the CFSSL signing profiles, by name, including
+	// those derived for certificate profiles. They are used to compute
+	// issuance profile hashes.
+	signingProfiles map[string]*cfsslConfig.SigningProfile
+	// A map from certificate profile name to the named certificate profile,
+	// used when an issuance request asks for a non-default profile.
+	certProfiles map[string]certProfile
 	// A map from issuer cert common name to an internalIssuer struct
 	issuers map[string]*internalIssuer
 	// A map from issuer ID to internalIssuer
@@ -126,23 +149,48 @@ type CertificateAuthorityImpl struct {
 	keyPolicy          goodkey.KeyPolicy
 	clk                clock.Clock
 	log                blog.Logger
-	prefix             int // Prepended to the serial number
+	serialPrefix       []byte // Prepended to the serial number
+	serialLength       int    // In bytes, including serialPrefix
 	validityPeriod     time.Duration
 	backdate           time.Duration
 	maxNames           int
+	mustStaple         mustStaplePolicy
 	signatureCount     *prometheus.CounterVec
 	csrExtensionCount  *prometheus.CounterVec
 	orphanCount        *prometheus.CounterVec
 	adoptedOrphanCount *prometheus.CounterVec
 	signErrorCounter   *prometheus.CounterVec
+	lintErrorCounter   *prometheus.CounterVec
 	orphanQueue        *goque.Queue
 	ocspLifetime       time.Duration
+	// linter lints final certificates before they are signed. It is nil if
+	// linting is not configured.
+	linter *linter
+	// certThrottle and ocspThrottle limit concurrent signing operations. They
+	// are nil if no limit is configured.
+	certThrottle *signingThrottle
+	ocspThrottle *signingThrottle
+	// ocspDelegateFallbacks counts OCSP responses signed with an issuer key
+	// because that issuer's delegated responder had expired.
+	ocspDelegateFallbacks prometheus.Counter
 }
 
 // Issuer represents a single issuer certificate, along with its key.
 type Issuer struct {
 	Signer crypto.Signer
 	Cert   *x509.Certificate
+	// CRLURLBase and CRLShards optionally configure sharded CRL distribution
+	// points, as described for ca_config.IssuerConfig.
+	CRLURLBase string
+	CRLShards  int
+	// UseRSAPSS makes this issuer sign certificates and OCSP responses with
+	// RSASSA-PSS rather than PKCS#1 v1.5. It requires an RSA issuer key.
+	UseRSAPSS bool
+	// OCSPDelegateLifetime and OCSPDelegateRenewBefore, if set, make OCSP
+	// responses for this issuer be signed by a delegated responder, as
+	// described for ca_config.OCSPDelegateConfig.
+	OCSPDelegateLifetime    time.Duration
+	OCSPDelegateRenewBefore time.Duration
 }
 
 // localSigner is an interface describing the functions of a cfssl.local.Signer
@@ -158,6 +206,31 @@ type internalIssuer struct {
 	cert       *x509.Certificate
 	eeSigner   localSigner
 	ocspSigner crypto.Signer
+	crlURLBase string
+	crlShards  int
+	// usePSS is true if this issuer signs with RSASSA-PSS. The eeSigner is
+	// already set up for it.
+	usePSS bool
+	// ocspDelegate is the issuer's delegated OCSP responder, or nil if OCSP
+	// responses are signed directly by the issuer.
+	ocspDelegate *ocspDelegate
+}
+
+// createOCSPResponse signs an OCSP response for a certificate from this
+// issuer, using the given responder certificate and key. The responder is
+// either the issuer itself or its delegate.
+func (i *internalIssuer) createOCSPResponse(tbsResponse ocsp.Response, responderCert *x509.Certificate, signer crypto.Signer) ([]byte, error) {
+	if responderCert != i.cert {
+		tbsResponse.Certificate = responderCert
+	}
+	if i.usePSS {
+		signer = pssSigner{signer}
+	}
+	resp, err := ocsp.CreateResponse(i.cert, responderCert, tbsResponse, signer)
+	if err != nil || !i.usePSS {
+		return resp, err
+	}
+	return setOCSPSignatureAlgorithm(resp, pssAlgorithmIdentifier())
 }
 
 func makeInternalIssuers(
@@ -173,7 +246,22 @@ func makeInternalIssuers(
 		if iss.Cert == nil || iss.Signer == nil {
 			return nil, errors.New("Issuer with nil cert or signer specified.")
 		}
-		eeSigner, err := local.NewSigner(iss.Signer, iss.Cert, x509.SHA256WithRSA, policy)
+		sigAlgo := x509.SHA256WithRSA
+		if iss.UseRSAPSS {
+			if _, ok := iss.Signer.Public().(*rsa.PublicKey); !ok {
+				return nil, errors.New("UseRSAPSS requires an issuer with an RSA key")
+			}
+			sigAlgo = x509.SHA256WithRSAPSS
+		}
+		eeSigner, err := local.NewSigner(iss.Signer, iss.Cert, sigAlgo, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := validateCRLShards(iss.CRLURLBase, iss.CRLShards); err != nil {
+			return nil, err
+		}
+		err = validateOCSPDelegate(iss.OCSPDelegateLifetime, iss.OCSPDelegateRenewBefore, lifespanOCSP)
 		if err != nil {
 			return nil, err
 		}
@@ -182,11 +270,21 @@ func makeInternalIssuers(
 		if internalIssuers[cn] != nil {
 			return nil, errors.New("Multiple issuer certs with the same CommonName are not supported")
 		}
-		internalIssuers[cn] = &internalIssuer{
+		ii := &internalIssuer{
 			cert:       iss.Cert,
 			eeSigner:   eeSigner,
 			ocspSigner: iss.Signer,
+			crlURLBase: iss.CRLURLBase,
+			crlShards:  iss.CRLShards,
+			usePSS:     iss.UseRSAPSS,
+		}
+		if iss.OCSPDelegateLifetime > 0 {
+			ii.ocspDelegate = &ocspDelegate{
+				lifetime:    iss.OCSPDelegateLifetime,
+				renewBefore: iss.OCSPDelegateRenewBefore,
+			}
 		}
+		internalIssuers[cn] = ii
 	}
 	return internalIssuers, nil
 }
@@ -195,8 +293,7 @@ func makeInternalIssuers(
 // is used for identifying which issuer issued a certificate in the
 // certificateStatus table.
 func idForIssuer(cert *x509.Certificate) int64 {
-	h := sha256.Sum256(cert.Raw)
-	return big.NewInt(0).SetBytes(h[:4]).Int64()
+	return core.IssuerID(cert)
 }
 
 // NewCertificateAuthorityImpl creates a CA instance that can sign certificates
@@ -216,8 +313,8 @@ func NewCertificateAuthorityImpl(
 	var ca *CertificateAuthorityImpl
 	var err error
 
-	if config.SerialPrefix <= 0 || config.SerialPrefix >= 256 {
-		err = errors.New("Must have a positive non-zero serial prefix less than 256 for CA.")
+	serialPrefix, serialLength, err := serialPrefixAndLength(config)
+	if err != nil {
 		return nil, err
 	}
 
@@ -242,6 +339,31 @@ func NewCertificateAuthorityImpl(
 		}
 	}
 
+	lintErrorCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lint_errors",
+		Help: "A counter of certificates refused because of pre-issuance lint failures, labelled by lint name",
+	}, []string{"lint"})
+	stats.MustRegister(lintErrorCounter)
+
+	var certLinter *linter
+	if config.Lint != nil {
+		certLinter, err = newLinter(config.Lint, lintErrorCounter)
+		if err != nil {
+			return nil, err
+		}
+		// This must happen before loadCertProfiles, which derives new CFSSL
+		// profiles from the lint registries of existing ones.
+		err = certLinter.applyToSigning(cfsslConfigObj.Signing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	certProfiles, err := loadCertProfiles(config.CertProfiles, cfsslConfigObj.Signing)
+	if err != nil {
+		return nil, err
+	}
+
 	internalIssuers, err := makeInternalIssuers(
 		issuers,
 		cfsslConfigObj.Signing,
@@ -296,6 +418,18 @@ func NewCertificateAuthorityImpl(
 	}, []string{"type"})
 	stats.MustRegister(signErrorCounter)
 
+	throttleRejections := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signing_throttle_rejections",
+		Help: "A counter of signing requests rejected because too many were in progress, labelled by purpose",
+	}, []string{"purpose"})
+	stats.MustRegister(throttleRejections)
+
+	ocspDelegateFallbacks := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ocsp_delegate_fallbacks",
+		Help: "A counter of OCSP responses signed with the issuer key because the delegated responder was unusable",
+	})
+	stats.MustRegister(ocspDelegateFallbacks)
+
 	ca = &CertificateAuthorityImpl{
 		sa:                 sa,
 		pa:                 pa,
@@ -303,7 +437,10 @@ func NewCertificateAuthorityImpl(
 		defaultIssuer:      defaultIssuer,
 		rsaProfile:         rsaProfile,
 		ecdsaProfile:       ecdsaProfile,
-		prefix:             config.SerialPrefix,
+		certProfiles:       certProfiles,
+		signingProfiles:    cfsslConfigObj.Signing.Profiles,
+		serialPrefix:       serialPrefix,
+		serialLength:       serialLength,
 		clk:                clk,
 		log:                logger,
 		keyPolicy:          keyPolicy,
@@ -314,6 +451,12 @@ func NewCertificateAuthorityImpl(
 		orphanQueue:        orphanQueue,
 		ocspLifetime:       config.LifespanOCSP.Duration,
 		signErrorCounter:   signErrorCounter,
+		lintErrorCounter:   lintErrorCounter,
+		linter:             certLinter,
+		certThrottle:       newSigningThrottle("certificate", config.CertificateSigningLimit, throttleRejections),
+		ocspThrottle:       newSigningThrottle("ocsp", config.OCSPSigningLimit, throttleRejections),
+
+		ocspDelegateFallbacks: ocspDelegateFallbacks,
 	}
 
 	ca.idToIssuer = make(map[int64]*internalIssuer)
@@ -340,9 +483,242 @@ func NewCertificateAuthorityImpl(
 
 	ca.maxNames = config.MaxNames
 
+	switch policy := mustStaplePolicy(config.MustStaple); policy {
+	case "":
+		ca.mustStaple = mustStapleHonor
+	case mustStapleHonor, mustStapleIgnore, mustStapleReject:
+		ca.mustStaple = policy
+	default:
+		return nil, fmt.Errorf("unknown mustStaple policy %q", config.MustStaple)
+	}
+
+	err = ca.logProfileHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue the first delegated OCSP responders now, so that we never fall
+	// back to signing with the issuer key merely because we just started.
+	err = ca.renewOCSPDelegates()
+	if err != nil {
+		return nil, fmt.Errorf("issuing OCSP delegates: %s", err)
+	}
+
 	return ca, nil
 }
 
+// maxShortLivedValidity is the longest validity period allowed for a
+// certificate profile that omits revocation information. The Baseline
+// Requirements only permit Short-lived Subscriber Certificates to omit the
+// AIA OCSP URL and CRL distribution points if they are valid for no more than
+// seven days.
+const maxShortLivedValidity = 7 * 24 * time.Hour
+
+// aiaOCSPLint is the name of the ZLint lint that requires an AIA OCSP URL in
+// subscriber certificates.
+const aiaOCSPLint = "e_sub_cert_aia_does_not_contain_ocsp_url"
+
+// loadCertProfiles validates the named certificate profiles and returns them
+// keyed by name. For short-lived profiles, and profiles which omit the
+// keyEncipherment key usage, it adds copies of the referenced CFSSL profiles,
+// modified accordingly, to the provided signing policy. This means such
+// certificates never share a CFSSL profile with those issued under the
+// referenced profiles, so for example a long-lived certificate can't be issued
+// without revocation information and vice versa. It must be called before the
+// signing policy is used to create any signers.
+func loadCertProfiles(profiles map[string]ca_config.CertProfileConfig, signing *cfsslConfig.Signing) (map[string]certProfile, error) {
+	certProfiles := make(map[string]certProfile, len(profiles))
+	for name, pc := range profiles {
+		if name == "" {
+			return nil, errors.New("certificate profile names must not be empty")
+		}
+		if pc.RSAProfile == "" || pc.ECDSAProfile == "" {
+			return nil, fmt.Errorf("certificate profile %q must specify rsaProfile and ecdsaProfile", name)
+		}
+		rsaProfile, ecdsaProfile := signing.Profiles[pc.RSAProfile], signing.Profiles[pc.ECDSAProfile]
+		if rsaProfile == nil || ecdsaProfile == nil {
+			return nil, fmt.Errorf("certificate profile %q references an unknown CFSSL profile", name)
+		}
+		profile := certProfile{
+			rsaProfile:     pc.RSAProfile,
+			ecdsaProfile:   pc.ECDSAProfile,
+			validityPeriod: pc.Expiry.Duration,
+			shortLived:     pc.ShortLived,
+			omitCommonName: pc.OmitCommonName,
+		}
+		if pc.ShortLived {
+			if pc.Expiry.Duration <= 0 || pc.Expiry.Duration > maxShortLivedValidity {
+				return nil, fmt.Errorf("short-lived certificate profile %q must specify an expiry of at most %s", name, maxShortLivedValidity)
+			}
+			// CFSSL falls back to the default profile's OCSP and CRL URLs when a
+			// profile doesn't specify its own, so they must be empty too.
+			if signing.Default != nil && (signing.Default.OCSP != "" || signing.Default.CRL != "") {
+				return nil, fmt.Errorf("short-lived certificate profile %q cannot be used when the default CFSSL profile has OCSP or CRL URLs", name)
+			}
+		} else {
+			defaultOCSP := signing.Default != nil && signing.Default.OCSP != ""
+			if !defaultOCSP && (rsaProfile.OCSP == "" || ecdsaProfile.OCSP == "") {
+				return nil, fmt.Errorf("certificate profile %q is not short-lived but references a CFSSL profile without an OCSP URL", name)
+			}
+		}
+		// CFSSL fills in an empty Subject from the CSR when the CSR whitelist
+		// allows it, which would undo OmitCommonName.
+		if pc.OmitCommonName && (copiesCSRSubject(rsaProfile) || copiesCSRSubject(ecdsaProfile)) {
+			return nil, fmt.Errorf("certificate profile %q omits the CommonName but references a CFSSL profile which copies the CSR's Subject", name)
+		}
+		if !pc.ShortLived && !pc.OmitKeyEncipherment {
+			certProfiles[name] = profile
+			continue
+		}
+		profile.rsaProfile = derivedProfileName(name, pc.RSAProfile)
+		profile.ecdsaProfile = derivedProfileName(name, pc.ECDSAProfile)
+		for derivedName, orig := range map[string]*cfsslConfig.SigningProfile{
+			profile.rsaProfile:   rsaProfile,
+			profile.ecdsaProfile: ecdsaProfile,
+		} {
+			if signing.Profiles[derivedName] != nil {
+				return nil, fmt.Errorf("certificate profile %q conflicts with existing CFSSL profile %q", name, derivedName)
+			}
+			derived := *orig
+			if pc.ShortLived {
+				derived.OCSP = ""
+				derived.CRL = ""
+				// Short-lived certificates are exempt from the requirement to
+				// include an OCSP URL, so that lint must not block their
+				// issuance.
+				derived.ExcludeLints = append(orig.ExcludeLints[:len(orig.ExcludeLints):len(orig.ExcludeLints)], aiaOCSPLint)
+				if orig.LintRegistry != nil {
+					registry, err := excludeLints(orig.LintRegistry, []string{aiaOCSPLint})
+					if err != nil {
+						return nil, err
+					}
+					derived.LintRegistry = registry
+				}
+			}
+			if pc.OmitKeyEncipherment {
+				derived.Usage = nil
+				for _, usage := range orig.Usage {
+					if usage != "key encipherment" {
+						derived.Usage = append(derived.Usage, usage)
+					}
+				}
+			}
+			signing.Profiles[derivedName] = &derived
+		}
+		certProfiles[name] = profile
+	}
+	return certProfiles, nil
+}
+
+// copiesCSRSubject returns true if CFSSL copies the Subject of a CSR signed
+// using the given profile into the certificate.
+func copiesCSRSubject(profile *cfsslConfig.SigningProfile) bool {
+	return profile.CSRWhitelist == nil || profile.CSRWhitelist.Subject
+}
+
+// derivedProfileName returns the name of the CFSSL profile derived from
+// cfsslProfile for use by the certificate profile certProfile.
+func derivedProfileName(certProfile, cfsslProfile string) string {
+	return fmt.Sprintf("%s-%s-derived", certProfile, cfsslProfile)
+}
+
+// issuanceProfile is the part of a certificate profile, together with the
+// CFSSL signing profile it uses for one key type, which determines the contents
+// of the certificates issued under it. The SHA-256 hash of its JSON encoding
+// identifies the profile a certificate was issued under.
+type issuanceProfile struct {
+	Usage             []string
+	IssuerURL         []string
+	OCSP              string
+	CRL               string
+	OCSPNoCheck       bool
+	Policies          []cfsslConfig.CertificatePolicy
+	AllowedExtensions []cfsslConfig.OID
+	CSRWhitelist      *cfsslConfig.CSRWhitelist
+	ExcludeLints      []string
+	ValidityPeriod    time.Duration
+	Backdate          time.Duration
+	ShortLived        bool
+	OmitCommonName    bool
+}
+
+// profileHash returns the SHA-256 hash of the issuance profile for
+// certificates issued under certProfile with the named CFSSL profile, along
+// with the JSON encoding of the profile which was hashed. The hash only
+// changes when the configuration affecting those certificates does.
+func (ca *CertificateAuthorityImpl) profileHash(certProfile certProfile, cfsslProfile string) ([]byte, []byte, error) {
+	sp := ca.signingProfiles[cfsslProfile]
+	if sp == nil {
+		return nil, nil, fmt.Errorf("unknown CFSSL profile %q", cfsslProfile)
+	}
+	profileJSON, err := json.Marshal(issuanceProfile{
+		Usage:             sp.Usage,
+		IssuerURL:         sp.IssuerURL,
+		OCSP:              sp.OCSP,
+		CRL:               sp.CRL,
+		OCSPNoCheck:       sp.OCSPNoCheck,
+		Policies:          sp.Policies,
+		AllowedExtensions: sp.AllowedExtensions,
+		CSRWhitelist:      sp.CSRWhitelist,
+		ExcludeLints:      sp.ExcludeLints,
+		ValidityPeriod:    certProfile.validityPeriod,
+		Backdate:          ca.backdate,
+		ShortLived:        certProfile.shortLived,
+		OmitCommonName:    certProfile.omitCommonName,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := sha256.Sum256(profileJSON)
+	return hash[:], profileJSON, nil
+}
+
+// logProfileHashes audit logs the hash and contents of every issuance profile
+// this CA can issue under, so that the profile hash logged for any certificate
+// can be traced back to its configuration.
+func (ca *CertificateAuthorityImpl) logProfileHashes() error {
+	names := []string{""}
+	for name := range ca.certProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile, err := ca.profileFor(name)
+		if err != nil {
+			return err
+		}
+		for _, cfsslProfile := range []string{profile.rsaProfile, profile.ecdsaProfile} {
+			hash, profileJSON, err := ca.profileHash(profile, cfsslProfile)
+			if err != nil {
+				return fmt.Errorf("hashing certificate profile %q: %s", name, err)
+			}
+			ca.log.AuditInfof("Issuance profile: name=[%s] cfsslProfile=[%s] profileHash=[%x] profile=%s",
+				name, cfsslProfile, hash, profileJSON)
+		}
+	}
+	return nil
+}
+
+// profileFor returns the certificate profile with the given name. The empty
+// name refers to the CA's default profile.
+func (ca *CertificateAuthorityImpl) profileFor(name string) (certProfile, error) {
+	if name == "" {
+		return certProfile{
+			rsaProfile:     ca.rsaProfile,
+			ecdsaProfile:   ca.ecdsaProfile,
+			validityPeriod: ca.validityPeriod,
+		}, nil
+	}
+	profile, ok := ca.certProfiles[name]
+	if !ok {
+		return certProfile{}, berrors.InternalServerError("unknown certificate profile %q", name)
+	}
+	if profile.validityPeriod == 0 {
+		profile.validityPeriod = ca.validityPeriod
+	}
+	return profile, nil
+}
+
 // noteSignError is called after operations that may cause a CFSSL
 // or PKCS11 signing error.
 func (ca *CertificateAuthorityImpl) noteSignError(err error) {
@@ -353,11 +729,26 @@ func (ca *CertificateAuthorityImpl) noteSignError(err error) {
 	}
 }
 
+// mustStaplePolicy determines how the CA handles CSRs requesting the
+// Must-Staple TLS Feature extension.
+type mustStaplePolicy string
+
+const (
+	// mustStapleHonor includes the extension in the certificate.
+	mustStapleHonor = mustStaplePolicy("honor")
+	// mustStapleIgnore issues the certificate without the extension.
+	mustStapleIgnore = mustStaplePolicy("ignore")
+	// mustStapleReject refuses to issue the certificate.
+	mustStapleReject = mustStaplePolicy("reject")
+)
+
 // Extract supported extensions from a CSR.  The following extensions are
 // currently supported:
 //
 // * 1.3.6.1.5.5.7.1.24 - TLS Feature [RFC7633], with the "must staple" value.
-//                        Any other value will result in an error.
+//                        Any other value will result in an error. Depending
+//                        on the CA's mustStaple policy the extension may
+//                        instead be left out, or the CSR rejected.
 //
 // Other requested extensions are silently ignored.
 func (ca *CertificateAuthorityImpl) extensionsFromCSR(csr *x509.CertificateRequest) ([]signer.Extension, error) {
@@ -391,7 +782,14 @@ func (ca *CertificateAuthorityImpl) extensionsFromCSR(csr *x509.CertificateReque
 						return nil, berrors.MalformedError("unsupported value for extension with OID %v", ext.Type)
 					}
 
-					extensions = append(extensions, mustStapleExtension)
+					switch ca.mustStaple {
+					case mustStapleReject:
+						return nil, berrors.BadCSRError("CSR requests the Must-Staple TLS Feature extension, which this CA no longer supports. Remove it from the CSR and try again")
+					case mustStapleIgnore:
+						ca.csrExtensionCount.With(prometheus.Labels{csrExtensionCategory: csrExtensionTLSFeatureIgnored}).Inc()
+					default:
+						extensions = append(extensions, mustStapleExtension)
+					}
 				case ext.Type.Equal(oidAuthorityInfoAccess),
 					ext.Type.Equal(oidAuthorityKeyIdentifier),
 					ext.Type.Equal(oidBasicConstraints),
@@ -455,7 +853,7 @@ func (ca *CertificateAuthorityImpl) GenerateOCSP(ctx context.Context, req *capb.
 	} else {
 		cert, err := x509.ParseCertificate(req.CertDER)
 		if err != nil {
-			ca.log.AuditErr(err.Error())
+			blog.ForContext(ctx, ca.log).AuditErr(err.Error())
 			return nil, err
 		}
 
@@ -485,7 +883,16 @@ func (ca *CertificateAuthorityImpl) GenerateOCSP(ctx context.Context, req *capb.
 		tbsResponse.RevocationReason = int(req.Reason)
 	}
 
-	ocspResponse, err := ocsp.CreateResponse(issuer.cert, issuer.cert, tbsResponse, issuer.ocspSigner)
+	release, err := ca.ocspThrottle.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	responderCert, signer, fallback := issuer.ocspResponder(ca.clk.Now(), tbsResponse.NextUpdate)
+	if fallback {
+		ca.ocspDelegateFallbacks.Inc()
+	}
+	ocspResponse, err := issuer.createOCSPResponse(tbsResponse, responderCert, signer)
+	release()
 	ca.noteSignError(err)
 	if err == nil {
 		ca.signatureCount.With(prometheus.Labels{"purpose": "ocsp"}).Inc()
@@ -499,26 +906,40 @@ func (ca *CertificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 		return nil, berrors.InternalServerError("Incomplete issue certificate request")
 	}
 
-	serialBigInt, validity, err := ca.generateSerialNumberAndValidity()
+	profile, err := ca.profileFor(issueReq.CertificateProfileName)
 	if err != nil {
 		return nil, err
 	}
 
+	serialBigInt, validity, err := ca.generateSerialNumberAndValidity(profile.validityPeriod)
+	if err != nil {
+		return nil, err
+	}
+	if issueReq.NotBefore != 0 || issueReq.NotAfter != 0 {
+		validity, err = ca.requestedValidity(issueReq, profile.validityPeriod, validity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	serialHex := core.SerialToString(serialBigInt)
 	regID := issueReq.RegistrationID
-	nowNanos := ca.clk.Now().UnixNano()
+	now := ca.clk.Now()
+	nowNanos := now.UnixNano()
 	expiresNanos := validity.NotAfter.UnixNano()
 	_, err = ca.sa.AddSerial(ctx, &sapb.AddSerialRequest{
-		Serial:  &serialHex,
-		RegID:   &regID,
-		Created: &nowNanos,
-		Expires: &expiresNanos,
+		Serial:      &serialHex,
+		RegID:       &regID,
+		Created:     &nowNanos,
+		Expires:     &expiresNanos,
+		CreatedTime: bgrpc.TimeToPB(now),
+		ExpiresTime: bgrpc.TimeToPB(validity.NotAfter),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	precertDER, err := ca.issuePrecertificateInner(ctx, issueReq, serialBigInt, validity)
+	precertDER, profileHash, err := ca.issuePrecertificateInner(ctx, issueReq, profile, serialBigInt, validity)
 	if err != nil {
 		return nil, err
 	}
@@ -529,15 +950,16 @@ func (ca *CertificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 	})
 	if err != nil {
 		err = berrors.InternalServerError(err.Error())
-		ca.log.AuditInfof("OCSP Signing failure: serial=[%s] err=[%s]", serialHex, err)
+		blog.ForContext(ctx, ca.log).AuditInfof("OCSP Signing failure: serial=[%s] err=[%s]", serialHex, err)
 		return nil, err
 	}
 
 	req := &sapb.AddCertificateRequest{
-		Der:    precertDER,
-		RegID:  &regID,
-		Ocsp:   ocspResp.Response,
-		Issued: &nowNanos,
+		Der:         precertDER,
+		RegID:       &regID,
+		Ocsp:        ocspResp.Response,
+		Issued:      &nowNanos,
+		ProfileHash: profileHash,
 	}
 
 	// we currently only use one issuer, in the future when we support multiple
@@ -551,14 +973,15 @@ func (ca *CertificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 		err = berrors.InternalServerError(err.Error())
 		// Note: This log line is parsed by cmd/orphan-finder. If you make any
 		// changes here, you should make sure they are reflected in orphan-finder.
-		ca.log.AuditErrf("Failed RPC to store at SA, orphaning precertificate: serial=[%s] cert=[%s] err=[%v], regID=[%d], orderID=[%d]",
+		blog.ForContext(ctx, ca.log).AuditErrf("Failed RPC to store at SA, orphaning precertificate: serial=[%s] cert=[%s] err=[%v], regID=[%d], orderID=[%d]",
 			serialHex, hex.EncodeToString(precertDER), err, issueReq.RegistrationID, issueReq.OrderID)
 		if ca.orphanQueue != nil {
 			ca.queueOrphan(&orphanedCert{
-				DER:      precertDER,
-				RegID:    regID,
-				OCSPResp: ocspResp.Response,
-				Precert:  true,
+				DER:         precertDER,
+				RegID:       regID,
+				OCSPResp:    ocspResp.Response,
+				Precert:     true,
+				ProfileHash: profileHash,
 			})
 		}
 		return nil, err
@@ -605,7 +1028,7 @@ func (ca *CertificateAuthorityImpl) IssueCertificateForPrecertificate(ctx contex
 	serialHex := core.SerialToString(precert.SerialNumber)
 	if _, err = ca.sa.GetCertificate(ctx, serialHex); err == nil {
 		err = berrors.InternalServerError("issuance of duplicate final certificate requested: %s", serialHex)
-		ca.log.AuditErr(err.Error())
+		blog.ForContext(ctx, ca.log).AuditErr(err.Error())
 		return nil, err
 	} else if !berrors.Is(err, berrors.NotFound) {
 		return nil, fmt.Errorf("error checking for duplicate issuance of %s: %s", serialHex, err)
@@ -619,7 +1042,21 @@ func (ca *CertificateAuthorityImpl) IssueCertificateForPrecertificate(ctx contex
 		}
 		scts = append(scts, sct)
 	}
+	// CFSSL doesn't lint certificates issued from precertificates, so do it
+	// here before they are signed.
+	if ca.linter != nil {
+		err = ca.linter.check(precert, scts, ca.defaultIssuer.cert)
+		if err != nil {
+			blog.ForContext(ctx, ca.log).AuditErrf("Signing failed: serial=[%s] err=[%v]", serialHex, err)
+			return nil, err
+		}
+	}
+	release, err := ca.certThrottle.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	certPEM, err := ca.defaultIssuer.eeSigner.SignFromPrecert(precert, scts)
+	release()
 	if err != nil {
 		return nil, err
 	}
@@ -627,11 +1064,11 @@ func (ca *CertificateAuthorityImpl) IssueCertificateForPrecertificate(ctx contex
 	block, _ := pem.Decode(certPEM)
 	if block == nil || block.Type != "CERTIFICATE" {
 		err = berrors.InternalServerError("invalid certificate value returned")
-		ca.log.AuditErrf("PEM decode error, aborting: serial=[%s] pem=[%s] err=[%v]", serialHex, certPEM, err)
+		blog.ForContext(ctx, ca.log).AuditErrf("PEM decode error, aborting: serial=[%s] pem=[%s] err=[%v]", serialHex, certPEM, err)
 		return nil, err
 	}
 	certDER := block.Bytes
-	ca.log.AuditInfof("Signing success: serial=[%s] names=[%s] certificate=[%s]",
+	blog.ForContext(ctx, ca.log).AuditInfof("Signing success: serial=[%s] names=[%s] certificate=[%s]",
 		serialHex, strings.Join(precert.DNSNames, ", "), hex.EncodeToString(req.DER),
 		hex.EncodeToString(certDER))
 	err = ca.storeCertificate(ctx, req.RegistrationID, req.OrderID, precert.SerialNumber, certDER)
@@ -657,12 +1094,12 @@ type validity struct {
 	NotAfter  time.Time
 }
 
-func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity() (*big.Int, validity, error) {
-	// We want 136 bits of random number, plus an 8-bit instance id prefix.
-	const randBits = 136
-	serialBytes := make([]byte, randBits/8+1)
-	serialBytes[0] = byte(ca.prefix)
-	_, err := rand.Read(serialBytes[1:])
+func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity(validityPeriod time.Duration) (*big.Int, validity, error) {
+	// The serial is the instance's prefix followed by random bytes, by default
+	// 136 bits of them after a one byte prefix.
+	serialBytes := make([]byte, ca.serialLength)
+	copy(serialBytes, ca.serialPrefix)
+	_, err := rand.Read(serialBytes[len(ca.serialPrefix):])
 	if err != nil {
 		err = berrors.InternalServerError("failed to generate serial: %s", err)
 		ca.log.AuditErrf("Serial randomness failed, err=[%v]", err)
@@ -674,16 +1111,45 @@ func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity() (*big.Int,
 	notBefore := ca.clk.Now().Add(-1 * ca.backdate)
 	validity := validity{
 		NotBefore: notBefore,
-		NotAfter:  notBefore.Add(ca.validityPeriod),
+		NotAfter:  notBefore.Add(validityPeriod),
 	}
 
 	return serialBigInt, validity, nil
 }
 
-func (ca *CertificateAuthorityImpl) issuePrecertificateInner(ctx context.Context, issueReq *capb.IssueCertificateRequest, serialBigInt *big.Int, validity validity) ([]byte, error) {
+// requestedValidity adjusts the default validity v of a certificate to the
+// notBefore and notAfter requested for it. A requested notBefore earlier than
+// the default, backdated, one is ignored, and the certificate is backdated
+// less than usual if that's needed to keep it within the profile's validity
+// period. If even that isn't enough, an error is returned.
+func (ca *CertificateAuthorityImpl) requestedValidity(issueReq *capb.IssueCertificateRequest, validityPeriod time.Duration, v validity) (validity, error) {
+	if issueReq.NotBefore != 0 {
+		if notBefore := time.Unix(0, issueReq.NotBefore); notBefore.After(v.NotBefore) {
+			v.NotBefore = notBefore
+			v.NotAfter = notBefore.Add(validityPeriod)
+		}
+	}
+	if issueReq.NotAfter != 0 {
+		v.NotAfter = time.Unix(0, issueReq.NotAfter)
+	}
+	if !v.NotAfter.After(v.NotBefore) {
+		return validity{}, berrors.InternalServerError("requested notAfter %s is not after notBefore %s", v.NotAfter, v.NotBefore)
+	}
+	if v.NotAfter.Sub(v.NotBefore) > validityPeriod {
+		earliest := v.NotAfter.Add(-validityPeriod)
+		if earliest.After(ca.clk.Now()) {
+			return validity{}, berrors.InternalServerError("requested validity period from %s to %s exceeds the certificate profile's %s",
+				v.NotBefore, v.NotAfter, validityPeriod)
+		}
+		v.NotBefore = earliest
+	}
+	return v, nil
+}
+
+func (ca *CertificateAuthorityImpl) issuePrecertificateInner(ctx context.Context, issueReq *capb.IssueCertificateRequest, certProfile certProfile, serialBigInt *big.Int, validity validity) ([]byte, []byte, error) {
 	csr, err := x509.ParseCertificateRequest(issueReq.Csr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err := csrlib.VerifyCSR(
@@ -694,23 +1160,23 @@ func (ca *CertificateAuthorityImpl) issuePrecertificateInner(ctx context.Context
 		ca.pa,
 		issueReq.RegistrationID,
 	); err != nil {
-		ca.log.AuditErr(err.Error())
+		blog.ForContext(ctx, ca.log).AuditErr(err.Error())
 		// VerifyCSR returns berror instances that can be passed through as-is
 		// without wrapping.
-		return nil, err
+		return nil, nil, err
 	}
 
 	extensions, err := ca.extensionsFromCSR(csr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	issuer := ca.defaultIssuer
 
 	if issuer.cert.NotAfter.Before(validity.NotAfter) {
 		err = berrors.InternalServerError("cannot issue a certificate that expires after the issuer certificate")
-		ca.log.AuditErr(err.Error())
-		return nil, err
+		blog.ForContext(ctx, ca.log).AuditErr(err.Error())
+		return nil, nil, err
 	}
 
 	// Convert the CSR to PEM
@@ -722,20 +1188,36 @@ func (ca *CertificateAuthorityImpl) issuePrecertificateInner(ctx context.Context
 	var profile string
 	switch csr.PublicKey.(type) {
 	case *rsa.PublicKey:
-		profile = ca.rsaProfile
+		profile = certProfile.rsaProfile
 	case *ecdsa.PublicKey:
-		profile = ca.ecdsaProfile
+		profile = certProfile.ecdsaProfile
+	case ed25519.PublicKey:
+		// Like ECDSA keys, Ed25519 keys can only be used for signatures, so
+		// they share the ECDSA profile and its key usages.
+		if !features.Enabled(features.Ed25519Issuance) {
+			err = berrors.InternalServerError("unsupported key type %T", csr.PublicKey)
+			blog.ForContext(ctx, ca.log).AuditErr(err.Error())
+			return nil, nil, err
+		}
+		profile = certProfile.ecdsaProfile
 	default:
 		err = berrors.InternalServerError("unsupported key type %T", csr.PublicKey)
-		ca.log.AuditErr(err.Error())
-		return nil, err
+		blog.ForContext(ctx, ca.log).AuditErr(err.Error())
+		return nil, nil, err
+	}
+
+	profileHash, _, err := ca.profileHash(certProfile, profile)
+	if err != nil {
+		err = berrors.InternalServerError("failed to hash issuance profile: %s", err)
+		blog.ForContext(ctx, ca.log).AuditErr(err.Error())
+		return nil, nil, err
 	}
 
 	// Send the cert off for signing
 	req := signer.SignRequest{
 		Request: csrPEM,
 		Profile: profile,
-		Hosts:   csr.DNSNames,
+		Hosts:   csrlib.NamesFromCSR(csr),
 		Subject: &signer.Subject{
 			CN: csr.Subject.CommonName,
 		},
@@ -745,13 +1227,25 @@ func (ca *CertificateAuthorityImpl) issuePrecertificateInner(ctx context.Context
 		NotAfter:      validity.NotAfter,
 		ReturnPrecert: true,
 	}
+	if certProfile.omitCommonName {
+		req.Subject.CN = ""
+	}
+	if !certProfile.shortLived {
+		req.CRLOverride = issuer.crlURL(serialBigInt)
+	}
 
 	serialHex := core.SerialToString(serialBigInt)
 
-	ca.log.AuditInfof("Signing: serial=[%s] names=[%s] csr=[%s]",
-		serialHex, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw))
+	release, err := ca.certThrottle.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blog.ForContext(ctx, ca.log).AuditInfof("Signing: serial=[%s] names=[%s] profileHash=[%x] csr=[%s]",
+		serialHex, strings.Join(req.Hosts, ", "), profileHash, hex.EncodeToString(csr.Raw))
 
 	certPEM, err := issuer.eeSigner.Sign(req)
+	release()
 	ca.noteSignError(err)
 	if err != nil {
 		// If the Signing error was a pre-issuance lint error then marshal the
@@ -760,37 +1254,40 @@ func (ca *CertificateAuthorityImpl) issuePrecertificateInner(ctx context.Context
 			// NOTE(@cpu): We throw away the JSON marshal error here. If marshaling
 			// fails for some reason it's acceptable to log an empty string for the
 			// JSON component.
+			for name := range lErr.ErrorResults {
+				ca.lintErrorCounter.WithLabelValues(name).Inc()
+			}
 			lintErrsJSON, _ := json.Marshal(lErr.ErrorResults)
-			ca.log.AuditErrf("Signing failed: serial=[%s] err=[%v] lintErrors=%s",
+			blog.ForContext(ctx, ca.log).AuditErrf("Signing failed: serial=[%s] err=[%v] lintErrors=%s",
 				serialHex, err, string(lintErrsJSON))
-			return nil, berrors.InternalServerError("failed to sign certificate: %s", err)
+			return nil, nil, berrors.InternalServerError("failed to sign certificate: %s", err)
 		}
 
 		err = berrors.InternalServerError("failed to sign certificate: %s", err)
-		ca.log.AuditErrf("Signing failed: serial=[%s] err=[%v]", serialHex, err)
-		return nil, err
+		blog.ForContext(ctx, ca.log).AuditErrf("Signing failed: serial=[%s] err=[%v]", serialHex, err)
+		return nil, nil, err
 	}
 	ca.signatureCount.WithLabelValues(string(precertType)).Inc()
 
 	if len(certPEM) == 0 {
 		err = berrors.InternalServerError("no certificate returned by server")
-		ca.log.AuditErrf("PEM empty from Signer: serial=[%s] err=[%v]", serialHex, err)
-		return nil, err
+		blog.ForContext(ctx, ca.log).AuditErrf("PEM empty from Signer: serial=[%s] err=[%v]", serialHex, err)
+		return nil, nil, err
 	}
 
 	block, _ := pem.Decode(certPEM)
 	if block == nil || block.Type != "CERTIFICATE" {
 		err = berrors.InternalServerError("invalid certificate value returned")
-		ca.log.AuditErrf("PEM decode error, aborting: serial=[%s] pem=[%s] err=[%v]", serialHex, certPEM, err)
-		return nil, err
+		blog.ForContext(ctx, ca.log).AuditErrf("PEM decode error, aborting: serial=[%s] pem=[%s] err=[%v]", serialHex, certPEM, err)
+		return nil, nil, err
 	}
 	certDER := block.Bytes
 
-	ca.log.AuditInfof("Signing success: serial=[%s] names=[%s] csr=[%s] precertificate=[%s]",
-		serialHex, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw),
+	blog.ForContext(ctx, ca.log).AuditInfof("Signing success: serial=[%s] names=[%s] profileHash=[%x] csr=[%s] precertificate=[%s]",
+		serialHex, strings.Join(req.Hosts, ", "), profileHash, hex.EncodeToString(csr.Raw),
 		hex.EncodeToString(certDER))
 
-	return certDER, nil
+	return certDER, profileHash, nil
 }
 
 func (ca *CertificateAuthorityImpl) storeCertificate(
@@ -807,7 +1304,7 @@ func (ca *CertificateAuthorityImpl) storeCertificate(
 		err = berrors.InternalServerError(err.Error())
 		// Note: This log line is parsed by cmd/orphan-finder. If you make any
 		// changes here, you should make sure they are reflected in orphan-finder.
-		ca.log.AuditErrf("Failed RPC to store at SA, orphaning certificate: serial=[%s] cert=[%s] err=[%v], regID=[%d], orderID=[%d]",
+		blog.ForContext(ctx, ca.log).AuditErrf("Failed RPC to store at SA, orphaning certificate: serial=[%s] cert=[%s] err=[%v], regID=[%d], orderID=[%d]",
 			core.SerialToString(serialBigInt), hex.EncodeToString(certDER), err, regID, orderID)
 		if ca.orphanQueue != nil {
 			ca.queueOrphan(&orphanedCert{
@@ -825,6 +1322,9 @@ type orphanedCert struct {
 	OCSPResp []byte
 	RegID    int64
 	Precert  bool
+	// ProfileHash is the hash of the issuance profile of an orphaned
+	// precertificate.
+	ProfileHash []byte
 }
 
 func (ca *CertificateAuthorityImpl) queueOrphan(o *orphanedCert) {
@@ -877,10 +1377,11 @@ func (ca *CertificateAuthorityImpl) integrateOrphan() error {
 	if orphan.Precert {
 		issuedNanos := issued.UnixNano()
 		_, err = ca.sa.AddPrecertificate(context.Background(), &sapb.AddCertificateRequest{
-			Der:    orphan.DER,
-			RegID:  &orphan.RegID,
-			Ocsp:   orphan.OCSPResp,
-			Issued: &issuedNanos,
+			Der:         orphan.DER,
+			RegID:       &orphan.RegID,
+			Ocsp:        orphan.OCSPResp,
+			Issued:      &issuedNanos,
+			ProfileHash: orphan.ProfileHash,
 		})
 		if err != nil && !berrors.Is(err, berrors.Duplicate) {
 			return fmt.Errorf("failed to store orphaned precertificate: %s", err)
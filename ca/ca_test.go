@@ -1,11 +1,13 @@
 package ca
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
@@ -94,6 +96,12 @@ var (
 	// * DNSNames = example.com, example2.com
 	ECDSACSR = mustRead("./testdata/ecdsa.der.csr")
 
+	// CSR generated by OpenSSL:
+	// * Random Ed25519 public key.
+	// * CN = not-example.com
+	// * DNSNames = not-example.com, www.not-example.com
+	Ed25519CSR = mustRead("./testdata/ed25519.der.csr")
+
 	// OIDExtensionCTPoison is defined in RFC 6962 s3.1.
 	OIDExtensionCTPoison = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
 
@@ -142,6 +150,7 @@ type testCtx struct {
 
 type mockSA struct {
 	certificate core.Certificate
+	precertReq  *sapb.AddCertificateRequest
 }
 
 func (m *mockSA) AddCertificate(ctx context.Context, der []byte, _ int64, _ []byte, _ *time.Time) (string, error) {
@@ -150,6 +159,7 @@ func (m *mockSA) AddCertificate(ctx context.Context, der []byte, _ int64, _ []by
 }
 
 func (m *mockSA) AddPrecertificate(ctx context.Context, req *sapb.AddCertificateRequest) (*corepb.Empty, error) {
+	m.precertReq = req
 	return &corepb.Empty{}, nil
 }
 
@@ -257,7 +267,7 @@ func setup(t *testing.T) *testCtx {
 		},
 	}
 
-	issuers := []Issuer{{caKey, caCert}}
+	issuers := []Issuer{{Signer: caKey, Cert: caCert}}
 
 	keyPolicy := goodkey.KeyPolicy{
 		AllowRSA:           true,
@@ -655,6 +665,372 @@ func TestRejectValidityTooLong(t *testing.T) {
 	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
 }
 
+func TestCertProfiles(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"shortlived": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			Expiry:       cmd.ConfigDuration{Duration: 160 * time.Hour},
+		},
+	}
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	// A request for the named profile should use that profile's validity period
+	resp, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:                    CNandSANCSR,
+		RegistrationID:         arbitraryRegID,
+		CertificateProfileName: "shortlived",
+	})
+	test.AssertNotError(t, err, "Failed to issue precertificate with named profile")
+	cert, err := x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, cert.NotAfter.Sub(cert.NotBefore), 160*time.Hour)
+
+	// A request for an unknown profile should fail
+	_, err = ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:                    CNandSANCSR,
+		RegistrationID:         arbitraryRegID,
+		CertificateProfileName: "unknown",
+	})
+	test.AssertError(t, err, "Issued precertificate with unknown profile")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
+
+	// A profile referencing a CFSSL profile that doesn't exist should be
+	// rejected at startup
+	testCtx.caConfig.CertProfiles["broken"] = ca_config.CertProfileConfig{
+		RSAProfile:   "nope",
+		ECDSAProfile: ecdsaProfileName,
+	}
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertError(t, err, "CA should have failed with unknown CFSSL profile")
+}
+
+func TestRequestedValidity(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"shortlived": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			Expiry:       cmd.ConfigDuration{Duration: 160 * time.Hour},
+		},
+	}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	now := testCtx.fc.Now()
+	issue := func(notBefore, notAfter time.Time) (*x509.Certificate, error) {
+		req := &capb.IssueCertificateRequest{
+			Csr:                    CNandSANCSR,
+			RegistrationID:         arbitraryRegID,
+			CertificateProfileName: "shortlived",
+		}
+		if !notBefore.IsZero() {
+			req.NotBefore = notBefore.UnixNano()
+		}
+		if !notAfter.IsZero() {
+			req.NotAfter = notAfter.UnixNano()
+		}
+		resp, err := ca.IssuePrecertificate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return x509.ParseCertificate(resp.DER)
+	}
+
+	// The requested window is used as is.
+	cert, err := issue(now.Add(time.Hour), now.Add(50*time.Hour))
+	test.AssertNotError(t, err, "Failed to issue precertificate with requested validity")
+	test.AssertEquals(t, cert.NotBefore, now.Add(time.Hour).UTC().Truncate(time.Second))
+	test.AssertEquals(t, cert.NotAfter, now.Add(50*time.Hour).UTC().Truncate(time.Second))
+
+	// Without a requested notBefore, the certificate is backdated as usual.
+	cert, err = issue(time.Time{}, now.Add(50*time.Hour))
+	test.AssertNotError(t, err, "Failed to issue precertificate with requested notAfter")
+	test.AssertEquals(t, cert.NotBefore, now.Add(-time.Hour).UTC().Truncate(time.Second))
+
+	// Without a requested notAfter, the profile's validity period is used.
+	cert, err = issue(now.Add(time.Hour), time.Time{})
+	test.AssertNotError(t, err, "Failed to issue precertificate with requested notBefore")
+	test.AssertEquals(t, cert.NotAfter.Sub(cert.NotBefore), 160*time.Hour)
+
+	// A window which is only too long because of the backdate is shortened by
+	// backdating less.
+	cert, err = issue(time.Time{}, now.Add(160*time.Hour))
+	test.AssertNotError(t, err, "Failed to issue precertificate with maximal notAfter")
+	test.AssertEquals(t, cert.NotBefore, now.UTC().Truncate(time.Second))
+
+	// Otherwise, windows longer than the profile's validity period, and those
+	// which end before they start, are refused.
+	_, err = issue(now.Add(time.Hour), now.Add(170*time.Hour))
+	test.AssertError(t, err, "Issued precertificate with too long a validity period")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
+	_, err = issue(now.Add(2*time.Hour), now.Add(time.Hour))
+	test.AssertError(t, err, "Issued precertificate with notAfter before notBefore")
+}
+
+func TestShortLivedCertProfile(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"shortlived": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			Expiry:       cmd.ConfigDuration{Duration: 160 * time.Hour},
+			ShortLived:   true,
+		},
+	}
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	// Certificates issued under the short-lived profile should have no
+	// revocation information
+	resp, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:                    CNandSANCSR,
+		RegistrationID:         arbitraryRegID,
+		CertificateProfileName: "shortlived",
+	})
+	test.AssertNotError(t, err, "Failed to issue precertificate with short-lived profile")
+	cert, err := x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, cert.NotAfter.Sub(cert.NotBefore), 160*time.Hour)
+	test.AssertEquals(t, len(cert.OCSPServer), 0)
+	test.AssertEquals(t, len(cert.CRLDistributionPoints), 0)
+
+	// Certificates issued under the default profile should be unaffected
+	resp, err = ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:            CNandSANCSR,
+		RegistrationID: arbitraryRegID,
+	})
+	test.AssertNotError(t, err, "Failed to issue precertificate with default profile")
+	cert, err = x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertDeepEquals(t, cert.OCSPServer, []string{"http://not-example.com/ocsp"})
+	test.AssertDeepEquals(t, cert.CRLDistributionPoints, []string{"http://not-example.com/crl"})
+
+	noOCSP := *testCtx.caConfig.CFSSL.Signing.Profiles[rsaProfileName]
+	noOCSP.OCSP = ""
+	testCtx.caConfig.CFSSL.Signing.Profiles["noOCSP"] = &noOCSP
+
+	testCases := []struct {
+		name    string
+		profile ca_config.CertProfileConfig
+	}{
+		{
+			name: "short-lived without expiry",
+			profile: ca_config.CertProfileConfig{
+				RSAProfile:   rsaProfileName,
+				ECDSAProfile: ecdsaProfileName,
+				ShortLived:   true,
+			},
+		},
+		{
+			name: "short-lived with expiry too long",
+			profile: ca_config.CertProfileConfig{
+				RSAProfile:   rsaProfileName,
+				ECDSAProfile: ecdsaProfileName,
+				Expiry:       cmd.ConfigDuration{Duration: 8 * 24 * time.Hour},
+				ShortLived:   true,
+			},
+		},
+		{
+			name: "long-lived without OCSP URL",
+			profile: ca_config.CertProfileConfig{
+				RSAProfile:   "noOCSP",
+				ECDSAProfile: ecdsaProfileName,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{"broken": tc.profile}
+			_, err := NewCertificateAuthorityImpl(
+				testCtx.caConfig,
+				sa,
+				testCtx.pa,
+				testCtx.fc,
+				testCtx.stats,
+				testCtx.issuers,
+				testCtx.keyPolicy,
+				testCtx.logger,
+				nil)
+			test.AssertError(t, err, "CA should have failed with invalid certificate profile")
+		})
+	}
+}
+
+func TestMinimalCertProfile(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"minimal": {
+			RSAProfile:          rsaProfileName,
+			ECDSAProfile:        ecdsaProfileName,
+			OmitCommonName:      true,
+			OmitKeyEncipherment: true,
+		},
+	}
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	// Certificates issued under the minimal profile should have an empty
+	// Subject and only the digitalSignature key usage
+	resp, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:                    CNandSANCSR,
+		RegistrationID:         arbitraryRegID,
+		CertificateProfileName: "minimal",
+	})
+	test.AssertNotError(t, err, "Failed to issue precertificate with minimal profile")
+	cert, err := x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, cert.Subject.CommonName, "")
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature)
+	test.AssertDeepEquals(t, cert.DNSNames, []string{"not-example.com", "www.not-example.com"})
+	test.AssertDeepEquals(t, cert.OCSPServer, []string{"http://not-example.com/ocsp"})
+
+	// Certificates issued under the default profile should be unaffected
+	resp, err = ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:            CNandSANCSR,
+		RegistrationID: arbitraryRegID,
+	})
+	test.AssertNotError(t, err, "Failed to issue precertificate with default profile")
+	cert, err = x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, cert.Subject.CommonName, "not-example.com")
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment)
+
+	// A profile omitting the CommonName can't reference a CFSSL profile which
+	// copies the CSR's Subject
+	copiesSubject := *testCtx.caConfig.CFSSL.Signing.Profiles[rsaProfileName]
+	copiesSubject.CSRWhitelist = nil
+	testCtx.caConfig.CFSSL.Signing.Profiles["copiesSubject"] = &copiesSubject
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"broken": {
+			RSAProfile:     "copiesSubject",
+			ECDSAProfile:   ecdsaProfileName,
+			OmitCommonName: true,
+		},
+	}
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertError(t, err, "CA should have failed with CFSSL profile copying the CSR's Subject")
+}
+
+func TestProfileHash(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"minimal": {
+			RSAProfile:     rsaProfileName,
+			ECDSAProfile:   ecdsaProfileName,
+			OmitCommonName: true,
+		},
+	}
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issue := func(profileName string) []byte {
+		t.Helper()
+		testCtx.logger.Clear()
+		_, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+			Csr:                    CNandSANCSR,
+			RegistrationID:         arbitraryRegID,
+			CertificateProfileName: profileName,
+		})
+		test.AssertNotError(t, err, "Failed to issue precertificate")
+		hash := sa.precertReq.ProfileHash
+		test.AssertEquals(t, len(hash), sha256.Size)
+		// The hash is included in the issuance audit log lines
+		test.AssertEquals(t, len(testCtx.logger.GetAllMatching(fmt.Sprintf(`Signing success: .* profileHash=\[%x\]`, hash))), 1)
+		return hash
+	}
+	defaultHash := issue("")
+	test.AssertByteEquals(t, issue(""), defaultHash)
+	minimalHash := issue("minimal")
+	test.Assert(t, !bytes.Equal(defaultHash, minimalHash), "Different profiles have the same hash")
+
+	// A CA created with the same configuration computes the same hashes, and
+	// logs the profiles they were computed from
+	testCtx.logger.Clear()
+	ca, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+	test.AssertEquals(t, len(testCtx.logger.GetAllMatching(fmt.Sprintf(`Issuance profile: name=\[minimal\] cfsslProfile=\[%s\] profileHash=\[%x\]`, rsaProfileName, minimalHash))), 1)
+	test.AssertByteEquals(t, issue("minimal"), minimalHash)
+}
+
 func TestSingleAIAEnforcement(t *testing.T) {
 	pa, err := policy.New(nil)
 	test.AssertNotError(t, err, "Couldn't create PA")
@@ -716,6 +1092,27 @@ func issueCertificateSubTestProfileSelectionECDSA(t *testing.T, i *TestCertifica
 	test.AssertEquals(t, i.cert.KeyUsage, expectedKeyUsage)
 }
 
+func TestIssuePrecertificateEd25519(t *testing.T) {
+	ca, _ := issueCertificateSubTestSetup(t)
+	issueReq := &capb.IssueCertificateRequest{Csr: Ed25519CSR, RegistrationID: arbitraryRegID}
+
+	_, err := ca.IssuePrecertificate(ctx, issueReq)
+	test.AssertError(t, err, "Issued precertificate for Ed25519 key with feature disabled")
+	test.Assert(t, berrors.Is(err, berrors.BadCSR), "Incorrect error type returned")
+
+	err = features.Set(map[string]bool{"Ed25519Issuance": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	response, err := ca.IssuePrecertificate(ctx, issueReq)
+	test.AssertNotError(t, err, "Failed to issue precertificate for Ed25519 key")
+	cert, err := x509.ParseCertificate(response.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, cert.PublicKeyAlgorithm, x509.Ed25519)
+	// Ed25519 keys, like ECDSA keys, should only be usable for signatures.
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature)
+}
+
 func countMustStaple(t *testing.T, cert *x509.Certificate) (count int) {
 	oidTLSFeature := asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
 	for _, ext := range cert.Extensions {
@@ -737,6 +1134,61 @@ func issueCertificateSubTestMustStaple(t *testing.T, i *TestCertificateIssuance)
 	test.AssertEquals(t, countMustStaple(t, i.cert), 1)
 }
 
+func TestMustStaplePolicy(t *testing.T) {
+	testCases := []struct {
+		policy      string
+		expectCount int
+		expectErr   bool
+	}{
+		{policy: "", expectCount: 1},
+		{policy: "honor", expectCount: 1},
+		{policy: "ignore", expectCount: 0},
+		{policy: "reject", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			testCtx := setup(t)
+			testCtx.caConfig.MustStaple = tc.policy
+			ca, err := NewCertificateAuthorityImpl(
+				testCtx.caConfig,
+				&mockSA{},
+				testCtx.pa,
+				testCtx.fc,
+				testCtx.stats,
+				testCtx.issuers,
+				testCtx.keyPolicy,
+				testCtx.logger,
+				nil)
+			test.AssertNotError(t, err, "Failed to create CA")
+
+			resp, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{Csr: MustStapleCSR, RegistrationID: arbitraryRegID})
+			if tc.expectErr {
+				test.AssertError(t, err, "Issued a certificate requesting Must-Staple")
+				test.Assert(t, berrors.Is(err, berrors.BadCSR), "Incorrect error type returned")
+				return
+			}
+			test.AssertNotError(t, err, "Failed to issue precertificate")
+			cert, err := x509.ParseCertificate(resp.DER)
+			test.AssertNotError(t, err, "Certificate failed to parse")
+			test.AssertEquals(t, countMustStaple(t, cert), tc.expectCount)
+		})
+	}
+
+	testCtx := setup(t)
+	testCtx.caConfig.MustStaple = "sometimes"
+	_, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertError(t, err, "CA should have failed with unknown mustStaple policy")
+}
+
 func issueCertificateSubTestTLSFeatureUnknown(t *testing.T, ca *CertificateAuthorityImpl, _ *mockSA) {
 	test.AssertEquals(t, test.CountCounterVec(csrExtensionCategory, csrExtensionTLSFeature, ca.csrExtensionCount), 1)
 	test.AssertEquals(t, test.CountCounterVec(csrExtensionCategory, csrExtensionTLSFeatureInvalid, ca.csrExtensionCount), 1)
@@ -871,6 +1323,93 @@ func (m *getCertErrorSA) GetCertificate(ctx context.Context, serial string) (cor
 	return core.Certificate{}, fmt.Errorf("i don't like it")
 }
 
+func TestIssueCertificateForPrecertificateLinting(t *testing.T) {
+	testCtx := setup(t)
+	// Most lints don't apply to certificates issued before the Baseline
+	// Requirements took effect, so issue during the test CA's validity instead.
+	testCtx.fc.Set(caCert.NotBefore.Add(time.Hour))
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+	precert, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue precert")
+	sctBytes, err := makeSCTs()
+	test.AssertNotError(t, err, "Failed to make SCTs")
+	req := &capb.IssueCertificateForPrecertificateRequest{
+		DER:            precert.DER,
+		SCTs:           sctBytes,
+		RegistrationID: arbitraryRegID,
+	}
+
+	// n_subject_common_name_included is a notice, so it doesn't fail when
+	// only warnings and errors are considered failures.
+	testCtx = setup(t)
+	testCtx.caConfig.Lint = &ca_config.LintConfig{
+		ErrLevel:     lint.Notice,
+		IncludeLints: []string{"n_subject_common_name_included"},
+	}
+	ca, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+	_, err = ca.IssueCertificateForPrecertificate(ctx, req)
+	test.AssertNotError(t, err, "Failed to issue cert from precert")
+
+	// With notices considered failures the final certificate must be refused.
+	testCtx = setup(t)
+	testCtx.caConfig.Lint = &ca_config.LintConfig{
+		ErrLevel:     lint.Pass,
+		IncludeLints: []string{"n_subject_common_name_included"},
+	}
+	ca, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+	_, err = ca.IssueCertificateForPrecertificate(ctx, req)
+	test.AssertError(t, err, "Issued cert from precert that failed linting")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
+	test.AssertEquals(t, err.Error(), "certificate failed linting: n_subject_common_name_included")
+	test.AssertEquals(t, test.CountCounterVec("lint", "n_subject_common_name_included", ca.lintErrorCounter), 1)
+	test.AssertEquals(t, test.CountCounterVec("purpose", string(certType), ca.signatureCount), 0)
+
+	// Lint configs without an error level are rejected.
+	testCtx = setup(t)
+	testCtx.caConfig.Lint = &ca_config.LintConfig{}
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertError(t, err, "Created CA with lint config missing errLevel")
+}
+
 func TestIssueCertificateForPrecertificateDuplicateSerial(t *testing.T) {
 	testCtx := setup(t)
 	sa := &dupeSA{}
@@ -1204,6 +1743,56 @@ func TestIssuePrecertificateLinting(t *testing.T) {
 	regex := `ERR: \[AUDIT\] Signing failed: serial=\[.*\] err=\[pre-issuance linting found 2 error results\] lintErrors=\{"foobar":\{"result":"error","details":"foobar is error"\},"foobar2":\{"result":"warn","details":"foobar2 is warning"\}\}`
 	matches := testCtx.logger.GetAllMatching(regex)
 	test.AssertEquals(t, len(matches), 1)
+
+	// Each failed lint should be counted.
+	test.AssertEquals(t, test.CountCounterVec("lint", "foobar", ca.lintErrorCounter), 1)
+	test.AssertEquals(t, test.CountCounterVec("lint", "foobar2", ca.lintErrorCounter), 1)
+}
+
+func TestLinterApplyToSigning(t *testing.T) {
+	l, err := newLinter(&ca_config.LintConfig{
+		ErrLevel:     lint.Warn,
+		IncludeLints: []string{"n_subject_common_name_included", aiaOCSPLint},
+	}, nil)
+	test.AssertNotError(t, err, "Failed to create linter")
+
+	signing := &cfsslConfig.Signing{
+		Profiles: map[string]*cfsslConfig.SigningProfile{
+			"unset": {},
+			"ignoring": {
+				LintErrLevel: lint.Pass,
+				ExcludeLints: []string{"n_subject_common_name_included", "e_not_included"},
+			},
+		},
+	}
+	err = l.applyToSigning(signing)
+	test.AssertNotError(t, err, "applyToSigning failed")
+
+	unset := signing.Profiles["unset"]
+	test.AssertEquals(t, unset.LintErrLevel, lint.Warn)
+	test.AssertDeepEquals(t, unset.LintRegistry.Names(), []string{aiaOCSPLint, "n_subject_common_name_included"})
+	ignoring := signing.Profiles["ignoring"]
+	test.AssertEquals(t, ignoring.LintErrLevel, lint.Pass)
+	test.AssertDeepEquals(t, ignoring.LintRegistry.Names(), []string{aiaOCSPLint})
+}
+
+func TestLinterOnionLints(t *testing.T) {
+	config := &ca_config.LintConfig{
+		ErrLevel:     lint.Warn,
+		IncludeLints: append([]string{"n_subject_common_name_included"}, onionLints...),
+	}
+	l, err := newLinter(config, nil)
+	test.AssertNotError(t, err, "Failed to create linter")
+	test.AssertEquals(t, len(l.registry.Names()), 3)
+
+	// With the OnionIdentifiers feature, the lints forbidding DV certificates
+	// for .onion names aren't run.
+	_ = features.Set(map[string]bool{"OnionIdentifiers": true})
+	defer features.Reset()
+	l, err = newLinter(config, nil)
+	test.AssertNotError(t, err, "Failed to create linter")
+	test.AssertDeepEquals(t, l.registry.Names(), []string{"n_subject_common_name_included"})
+	test.AssertDeepEquals(t, l.shortLivedRegistry.Names(), []string{"n_subject_common_name_included"})
 }
 
 func TestGenerateOCSPWithIssuerID(t *testing.T) {
@@ -3,6 +3,7 @@ package ca_config
 import (
 	cfsslConfig "github.com/cloudflare/cfssl/config"
 	"github.com/letsencrypt/pkcs11key/v4"
+	"github.com/zmap/zlint/v2/lint"
 
 	"github.com/letsencrypt/boulder/cmd"
 )
@@ -20,7 +21,20 @@ type CAConfig struct {
 
 	RSAProfile   string
 	ECDSAProfile string
+	// SerialPrefix is the first byte of every serial this CA issues, so that
+	// serials from different CA instances can't collide. It must be between 1
+	// and 255, and is ignored if SerialPrefixHex is set.
 	SerialPrefix int
+	// SerialPrefixHex is the hex encoded prefix of every serial this CA
+	// issues, for instances which need more than one byte of it. Its first
+	// byte must be non-zero.
+	SerialPrefixHex string
+	// SerialLength is the length of every serial this CA issues in bytes,
+	// including its prefix. It defaults to 18, and may be up to 20, so that
+	// serials can be lengthened by raising it once every SA and OCSP
+	// responder accepts longer ones. At least 8 bytes must be left for
+	// randomness after the prefix.
+	SerialLength int
 	// Issuers contains configuration information for each issuer cert and key
 	// this CA knows about. The first in the list is used as the default.
 	Issuers []IssuerConfig
@@ -37,6 +51,22 @@ type CAConfig struct {
 	MaxNames int
 	CFSSL    cfsslConfig.Config
 
+	// MustStaple controls how CSRs requesting the Must-Staple TLS Feature
+	// extension are handled. "honor", the default, includes the extension in
+	// the certificate. "ignore" issues the certificate without it, and
+	// "reject" refuses to issue with a badCSR error.
+	MustStaple string
+
+	// CertProfiles is a map of certificate profile names, as requested by an
+	// ACME order, to the CFSSL profiles and validity period used to issue
+	// certificates for that profile. Orders that don't request a profile are
+	// issued using RSAProfile, ECDSAProfile and Expiry above.
+	CertProfiles map[string]CertProfileConfig
+
+	// Lint, if present, enables linting of every precertificate and final
+	// certificate before it is signed with an issuer's key. See LintConfig.
+	Lint *LintConfig
+
 	// WeakKeyFile is the path to a JSON file containing truncated RSA modulus
 	// hashes of known easily enumerable keys.
 	WeakKeyFile string
@@ -48,13 +78,81 @@ type CAConfig struct {
 
 	SAService *cmd.GRPCClientConfig
 
+	// KeyPolicyService, if present, is used to check keys instead of the
+	// local weak and blocked key lists, which are then not loaded.
+	KeyPolicyService *cmd.GRPCClientConfig
+
 	// Path to directory holding orphan queue files, if not provided an orphan queue
 	// is not used.
 	OrphanQueueDir string
 
+	// CertificateSigningLimit and OCSPSigningLimit bound the number of
+	// concurrent precertificate and certificate signing operations, and OCSP
+	// signing operations, respectively.
+	CertificateSigningLimit SigningLimit
+	OCSPSigningLimit        SigningLimit
+
 	Features map[string]bool
 }
 
+// SigningLimit bounds the concurrency of one kind of signing operation so
+// that a burst of requests can't overwhelm the HSM.
+type SigningLimit struct {
+	// MaxConcurrent is the most signing operations which may be in progress at
+	// once. Zero means no limit.
+	MaxConcurrent int
+	// MaxWaiting is the most requests which may wait for one of the
+	// MaxConcurrent slots. Further requests are rejected immediately with a
+	// ResourceExhausted error.
+	MaxWaiting int
+	// RetryAfter, if non-zero, is returned to rejected requests as a hint of
+	// when to try again.
+	RetryAfter cmd.ConfigDuration
+}
+
+// CertProfileConfig describes a single named certificate profile.
+type CertProfileConfig struct {
+	// RSAProfile and ECDSAProfile are the names of the CFSSL signing profiles
+	// used for CSRs with RSA and ECDSA public keys respectively.
+	RSAProfile   string
+	ECDSAProfile string
+	// Expiry is how long certificates issued under this profile are valid for.
+	// If unset, the CA's default Expiry is used. It should match the expiry
+	// field of the referenced CFSSL profiles.
+	Expiry cmd.ConfigDuration
+	// ShortLived indicates that certificates issued under this profile omit
+	// the AIA OCSP URL and CRL distribution points. Short-lived profiles must
+	// set an Expiry of at most seven days. Profiles that are not short-lived
+	// must reference CFSSL profiles with an OCSP URL.
+	ShortLived bool
+	// OmitCommonName indicates that certificates issued under this profile
+	// have an empty Subject, even if the CSR requested a CommonName. All of
+	// the certificate's names are still included as SANs.
+	OmitCommonName bool
+	// OmitKeyEncipherment indicates that certificates issued under this profile
+	// don't assert the keyEncipherment key usage, even if the referenced CFSSL
+	// profiles do. Modern TLS doesn't use RSA key exchange, so RSA certificates
+	// only need the digitalSignature key usage.
+	OmitKeyEncipherment bool
+}
+
+// LintConfig configures the zlint lints run against each certificate before it
+// is signed. Each certificate is first signed by a throwaway key and linted; it
+// is only signed by the issuer's key if no configured lint fails. The lints run
+// against precertificates are further restricted by each CFSSL profile's
+// ignored_lints.
+type LintConfig struct {
+	// ErrLevel is the lint result status above which a lint is considered to
+	// have failed. For example "pass" fails on any notice, warning or error, and
+	// "warn" fails only on errors. It is also used as the lint_error_level of
+	// CFSSL profiles that don't specify their own.
+	ErrLevel lint.LintStatus
+	// IncludeLints, if not empty, restricts linting to the named lints.
+	IncludeLints []string
+	// ExcludeLints names lints that are never run.
+	ExcludeLints []string
+}
+
 // IssuerConfig contains info about an issuer: private key and issuer cert.
 // It should contain either a File path to a PEM-format private key,
 // or a PKCS11Config defining how to load a module for an HSM.
@@ -67,4 +165,42 @@ type IssuerConfig struct {
 	// Number of sessions to open with the HSM. For maximum performance,
 	// this should be equal to the number of cores in the HSM. Defaults to 1.
 	NumSessions int
+	// AdditionalPKCS11 configures further HSMs holding the same key as the one
+	// above. NumSessions sessions are opened with each, and signing fails over
+	// between all of the sessions.
+	AdditionalPKCS11 []*pkcs11key.Config
+	// AdditionalConfigFiles are files from which further pkcs11key.Configs,
+	// used like those in AdditionalPKCS11, will be read and parsed.
+	AdditionalConfigFiles []string
+	// HealthCheckInterval is how often each PKCS#11 session is checked by
+	// querying its HSM's token. Defaults to one minute.
+	HealthCheckInterval cmd.ConfigDuration
+	// CRLURLBase and CRLShards, if set, make certificates from this issuer
+	// point at the CRL shard which will list them, instead of the CRL URL
+	// from the CFSSL profile. The CRL distribution point is CRLURLBase
+	// followed by the shard index and ".crl", where the shard index is
+	// computed by ca.CRLShard.
+	CRLURLBase string
+	CRLShards  int
+	// UseRSAPSS makes this issuer sign certificates and OCSP responses with
+	// RSASSA-PSS (SHA-256, MGF1 with SHA-256, 32 byte salt) instead of
+	// PKCS#1 v1.5. The issuer key must be RSA.
+	UseRSAPSS bool
+	// OCSPDelegate, if present, makes the CA sign OCSP responses for this
+	// issuer with a delegated responder certificate instead of the issuer key.
+	OCSPDelegate *OCSPDelegateConfig
+}
+
+// OCSPDelegateConfig configures a delegated OCSP responder for an issuer. The
+// CA generates the responder's key itself, keeps it only in memory, and issues
+// the responder a certificate with the id-kp-OCSPSigning EKU and the
+// id-pkix-ocsp-nocheck extension. It issues a replacement, with a new key,
+// when the current certificate is within RenewBefore of expiry. If that fails
+// and the certificate would expire before an OCSP response's nextUpdate, the
+// response is signed with the issuer key instead.
+type OCSPDelegateConfig struct {
+	// Lifetime is the validity period of each responder certificate.
+	Lifetime cmd.ConfigDuration
+	// RenewBefore must be longer than LifespanOCSP, and shorter than Lifetime.
+	RenewBefore cmd.ConfigDuration
 }
@@ -0,0 +1,45 @@
+package ca
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+)
+
+// CRLShard returns the index of the CRL shard, out of numShards, which lists
+// the certificate with the given serial. Serials are random, so taking the
+// serial modulo the number of shards spreads certificates evenly across them.
+// Anything which produces sharded CRLs must use the same scheme so that each
+// certificate appears on the CRL its distribution point names.
+func CRLShard(serial *big.Int, numShards int) int {
+	return int(new(big.Int).Mod(serial, big.NewInt(int64(numShards))).Int64())
+}
+
+// validateCRLShards checks an issuer's sharded CRL configuration. Both fields
+// must be set, or neither.
+func validateCRLShards(base string, numShards int) error {
+	if base == "" && numShards == 0 {
+		return nil
+	}
+	if numShards <= 0 {
+		return errors.New("CRLShards must be positive when CRLURLBase is set")
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return fmt.Errorf("invalid CRLURLBase %q: %s", base, err)
+	}
+	if u.Scheme != "http" || u.Host == "" {
+		return fmt.Errorf("CRLURLBase %q must be an absolute http URL", base)
+	}
+	return nil
+}
+
+// crlURL returns the CRL distribution point for the certificate with the
+// given serial, or the empty string if the issuer doesn't use sharded CRLs.
+func (i *internalIssuer) crlURL(serial *big.Int) string {
+	if i.crlShards <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s%d.crl", i.crlURLBase, CRLShard(serial, i.crlShards))
+}
@@ -0,0 +1,108 @@
+package ca
+
+import (
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	ca_config "github.com/letsencrypt/boulder/ca/config"
+	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestCRLShard(t *testing.T) {
+	test.AssertEquals(t, CRLShard(big.NewInt(0), 10), 0)
+	test.AssertEquals(t, CRLShard(big.NewInt(1234), 10), 4)
+	test.AssertEquals(t, CRLShard(big.NewInt(1234), 1), 0)
+	serial, ok := new(big.Int).SetString("ff00000000000000000000000000000000000d", 16)
+	test.Assert(t, ok, "failed to parse serial")
+	test.AssertEquals(t, CRLShard(serial, 128), 13)
+}
+
+func TestValidateCRLShards(t *testing.T) {
+	testCases := []struct {
+		base      string
+		numShards int
+		valid     bool
+	}{
+		{"", 0, true},
+		{"http://c.example.com/crls/", 128, true},
+		{"http://c.example.com/crls/", 0, false},
+		{"", 128, false},
+		{"https://c.example.com/crls/", 128, false},
+		{"c.example.com/crls/", 128, false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s/%d", tc.base, tc.numShards), func(t *testing.T) {
+			err := validateCRLShards(tc.base, tc.numShards)
+			if tc.valid {
+				test.AssertNotError(t, err, "valid config rejected")
+			} else {
+				test.AssertError(t, err, "invalid config accepted")
+			}
+		})
+	}
+}
+
+func TestShardedCRLDistributionPoint(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"shortlived": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			Expiry:       cmd.ConfigDuration{Duration: 160 * time.Hour},
+			ShortLived:   true,
+		},
+	}
+	issuers := []Issuer{{
+		Signer:     caKey,
+		Cert:       caCert,
+		CRLURLBase: "http://c.example.com/crls/",
+		CRLShards:  16,
+	}}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	resp, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue precertificate")
+	cert, err := x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	expected := fmt.Sprintf("http://c.example.com/crls/%d.crl", CRLShard(cert.SerialNumber, 16))
+	test.AssertDeepEquals(t, cert.CRLDistributionPoints, []string{expected})
+
+	// Short-lived certificates still have no CRL distribution point.
+	resp, err = ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:                    CNandSANCSR,
+		RegistrationID:         arbitraryRegID,
+		CertificateProfileName: "shortlived",
+	})
+	test.AssertNotError(t, err, "Failed to issue short-lived precertificate")
+	cert, err = x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, len(cert.CRLDistributionPoints), 0)
+
+	issuers[0].CRLShards = 0
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertError(t, err, "CA accepted CRLURLBase without CRLShards")
+}
@@ -0,0 +1,220 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	cfsslConfig "github.com/cloudflare/cfssl/config"
+	"github.com/cloudflare/cfssl/helpers"
+	"github.com/cloudflare/cfssl/signer"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/prometheus/client_golang/prometheus"
+	zx509 "github.com/zmap/zcrypto/x509"
+	"github.com/zmap/zlint/v2"
+	"github.com/zmap/zlint/v2/lint"
+
+	ca_config "github.com/letsencrypt/boulder/ca/config"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+)
+
+// linter runs a configured set of zlint lints against certificates before they
+// are signed by an issuer's real key. Each certificate is first signed by a
+// throwaway key, generated when the linter is created, and the result is
+// linted. CFSSL performs the equivalent check for precertificates, using the
+// registry and error level applied to its profiles by applyToSigning, but it
+// doesn't lint final certificates, so the CA calls check for those.
+type linter struct {
+	key      crypto.Signer
+	errLevel lint.LintStatus
+	registry lint.Registry
+	// shortLivedRegistry is used for certificates without an OCSP URL, which
+	// are only issued under short-lived certificate profiles.
+	shortLivedRegistry lint.Registry
+	lintErrors         *prometheus.CounterVec
+}
+
+// onionLints are the ZLint lints which predate Ballot SC27, and so only permit
+// .onion names in EV certificates with a Tor service descriptor extension.
+// They're excluded when the OnionIdentifiers feature allows DV certificates for
+// version 3 .onion names.
+var onionLints = []string{
+	"e_san_dns_name_onion_not_ev_cert",
+	"e_ext_tor_service_descriptor_hash_invalid",
+}
+
+// newLinter returns a linter for the given config. lintErrors is incremented,
+// labelled by lint name, for every failed lint.
+func newLinter(config *ca_config.LintConfig, lintErrors *prometheus.CounterVec) (*linter, error) {
+	if config.ErrLevel == lint.Reserved {
+		return nil, errors.New("lint config must specify an errLevel")
+	}
+	registry := lint.GlobalRegistry()
+	if len(config.IncludeLints) > 0 || len(config.ExcludeLints) > 0 {
+		var err error
+		registry, err = registry.Filter(lint.FilterOptions{
+			IncludeNames: config.IncludeLints,
+			ExcludeNames: config.ExcludeLints,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if features.Enabled(features.OnionIdentifiers) {
+		var err error
+		registry, err = excludeLints(registry, onionLints)
+		if err != nil {
+			return nil, err
+		}
+	}
+	shortLivedRegistry, err := excludeLints(registry, []string{aiaOCSPLint})
+	if err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &linter{
+		key:                key,
+		errLevel:           config.ErrLevel,
+		registry:           registry,
+		shortLivedRegistry: shortLivedRegistry,
+		lintErrors:         lintErrors,
+	}, nil
+}
+
+// applyToSigning sets the lint registry of every profile in the CFSSL signing
+// policy to the linter's registry, less any lints the profile ignores, and sets
+// the lint error level of profiles that don't have one. It must be called
+// before the signing policy is used to create any signers, because CFSSL only
+// generates its throwaway key for signers with linting enabled.
+func (l *linter) applyToSigning(signing *cfsslConfig.Signing) error {
+	profiles := make([]*cfsslConfig.SigningProfile, 0, len(signing.Profiles)+1)
+	for _, profile := range signing.Profiles {
+		profiles = append(profiles, profile)
+	}
+	if signing.Default != nil {
+		profiles = append(profiles, signing.Default)
+	}
+	for _, profile := range profiles {
+		registry, err := excludeLints(l.registry, profile.ExcludeLints)
+		if err != nil {
+			return err
+		}
+		profile.LintRegistry = registry
+		if profile.LintErrLevel == lint.Reserved {
+			profile.LintErrLevel = l.errLevel
+		}
+	}
+	return nil
+}
+
+// excludeLints returns registry without the named lints. Unlike
+// registry.Filter, names that aren't in the registry are ignored.
+func excludeLints(registry lint.Registry, names []string) (lint.Registry, error) {
+	var present []string
+	for _, name := range names {
+		if registry.ByName(name) != nil {
+			present = append(present, name)
+		}
+	}
+	if len(present) == 0 {
+		return registry, nil
+	}
+	return registry.Filter(lint.FilterOptions{ExcludeNames: present})
+}
+
+// check lints the final certificate that would be produced from precert and
+// scts, returning an error naming the failed lints if any lint fails. precert
+// must have been issued by issuer.
+func (l *linter) check(precert *x509.Certificate, scts []ct.SignedCertificateTimestamp, issuer *x509.Certificate) error {
+	tbs, err := finalTBSCertificate(precert, scts)
+	if err != nil {
+		return err
+	}
+	// The throwaway key is substituted for the issuer's public key so that
+	// the issuer's name and key identifier still appear in the linted
+	// certificate.
+	lintIssuer := *issuer
+	lintIssuer.PublicKey = l.key.Public()
+	tbs.AuthorityKeyId = issuer.SubjectKeyId
+	tbs.SignatureAlgorithm = x509.ECDSAWithSHA256
+	der, err := x509.CreateCertificate(rand.Reader, tbs, &lintIssuer, tbs.PublicKey, l.key)
+	if err != nil {
+		return berrors.InternalServerError("failed to sign certificate for linting: %s", err)
+	}
+	lintCert, err := zx509.ParseCertificate(der)
+	if err != nil {
+		return berrors.InternalServerError("failed to parse certificate for linting: %s", err)
+	}
+	registry := l.registry
+	if len(precert.OCSPServer) == 0 {
+		registry = l.shortLivedRegistry
+	}
+	var failed []string
+	for name, res := range zlint.LintCertificateEx(lintCert, registry).Results {
+		if res.Status > l.errLevel {
+			failed = append(failed, name)
+			l.lintErrors.WithLabelValues(name).Inc()
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return berrors.InternalServerError("certificate failed linting: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// finalTBSCertificate returns the template for the final certificate corresponding
+// to precert, in the same way as CFSSL's SignFromPrecert: the CT poison
+// extension is removed and an SCT list extension containing scts is added.
+func finalTBSCertificate(precert *x509.Certificate, scts []ct.SignedCertificateTimestamp) (*x509.Certificate, error) {
+	serializedList, err := helpers.SerializeSCTList(scts)
+	if err != nil {
+		return nil, err
+	}
+	serializedList, err = asn1.Marshal(serializedList)
+	if err != nil {
+		return nil, err
+	}
+	tbs := &x509.Certificate{
+		SignatureAlgorithm:          precert.SignatureAlgorithm,
+		PublicKeyAlgorithm:          precert.PublicKeyAlgorithm,
+		PublicKey:                   precert.PublicKey,
+		Version:                     precert.Version,
+		SerialNumber:                precert.SerialNumber,
+		Issuer:                      precert.Issuer,
+		Subject:                     precert.Subject,
+		NotBefore:                   precert.NotBefore,
+		NotAfter:                    precert.NotAfter,
+		KeyUsage:                    precert.KeyUsage,
+		BasicConstraintsValid:       precert.BasicConstraintsValid,
+		IsCA:                        precert.IsCA,
+		MaxPathLen:                  precert.MaxPathLen,
+		MaxPathLenZero:              precert.MaxPathLenZero,
+		PermittedDNSDomainsCritical: precert.PermittedDNSDomainsCritical,
+	}
+	poisoned := false
+	for _, ext := range precert.Extensions {
+		if ext.Id.Equal(signer.CTPoisonOID) {
+			poisoned = true
+			continue
+		}
+		tbs.ExtraExtensions = append(tbs.ExtraExtensions, ext)
+	}
+	if !poisoned {
+		return nil, fmt.Errorf("precertificate %x has no CT poison extension", precert.SerialNumber)
+	}
+	tbs.ExtraExtensions = append(tbs.ExtraExtensions, pkix.Extension{Id: signer.SCTListOID, Value: serializedList})
+	return tbs, nil
+}
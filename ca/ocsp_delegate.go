@@ -0,0 +1,184 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// oidOCSPNoCheck is id-pkix-ocsp-nocheck, from RFC 6960 Section 4.2.2.2.1.
+// Its value is always NULL.
+var oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// ocspDelegate holds an issuer's current delegated OCSP responder: a short
+// lived certificate with the id-kp-OCSPSigning EKU, issued by the issuer to a
+// key which the CA generates and holds only in memory.
+type ocspDelegate struct {
+	lifetime    time.Duration
+	renewBefore time.Duration
+
+	sync.RWMutex
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// validateOCSPDelegate checks an issuer's delegated responder configuration.
+// A delegate must be renewed more than one OCSP response lifetime before it
+// expires, so that every response it signs can be verified until that
+// response's nextUpdate.
+func validateOCSPDelegate(lifetime, renewBefore, lifespanOCSP time.Duration) error {
+	if lifetime == 0 && renewBefore == 0 {
+		return nil
+	}
+	if renewBefore <= lifespanOCSP {
+		return fmt.Errorf("OCSP delegate renewBefore (%s) must be longer than the OCSP response lifetime (%s)", renewBefore, lifespanOCSP)
+	}
+	if lifetime <= renewBefore {
+		return fmt.Errorf("OCSP delegate lifetime (%s) must be longer than renewBefore (%s)", lifetime, renewBefore)
+	}
+	return nil
+}
+
+// current returns the delegate's certificate and key if the certificate is
+// valid for the whole of the period from now until nextUpdate, and nils
+// otherwise.
+func (d *ocspDelegate) current(now, nextUpdate time.Time) (*x509.Certificate, crypto.Signer) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.cert == nil || now.Before(d.cert.NotBefore) || d.cert.NotAfter.Before(nextUpdate) {
+		return nil, nil
+	}
+	return d.cert, d.key
+}
+
+// needsRenewal returns true if there is no delegate, or if the current one
+// expires within renewBefore of now.
+func (d *ocspDelegate) needsRenewal(now time.Time) bool {
+	d.RLock()
+	defer d.RUnlock()
+	return d.cert == nil || d.cert.NotAfter.Sub(now) < d.renewBefore
+}
+
+// ocspResponder returns the certificate and key which should sign an OCSP
+// response for this issuer, valid from now until nextUpdate. That is the
+// delegated responder if the issuer has one which covers the response, and the
+// issuer itself otherwise. fallback is true if the issuer has a delegate which
+// couldn't be used.
+func (i *internalIssuer) ocspResponder(now, nextUpdate time.Time) (cert *x509.Certificate, signer crypto.Signer, fallback bool) {
+	if i.ocspDelegate == nil {
+		return i.cert, i.ocspSigner, false
+	}
+	cert, signer = i.ocspDelegate.current(now, nextUpdate)
+	if cert == nil {
+		return i.cert, i.ocspSigner, true
+	}
+	return cert, signer, false
+}
+
+// newDelegateKey generates a key for a delegated OCSP responder, of the same
+// type as the issuer's key.
+func newDelegateKey(issuerKey crypto.PublicKey) (crypto.Signer, error) {
+	switch issuerKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case *ecdsa.PublicKey:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported issuer key type %T", issuerKey)
+	}
+}
+
+// renewOCSPDelegate generates a new key, issues a delegated OCSP responder
+// certificate for it, and makes it the issuer's current delegate.
+func (ca *CertificateAuthorityImpl) renewOCSPDelegate(issuer *internalIssuer) error {
+	delegate := issuer.ocspDelegate
+	if delegate == nil {
+		return errors.New("issuer has no OCSP delegate configured")
+	}
+	key, err := newDelegateKey(issuer.cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	serial, validity, err := ca.generateSerialNumberAndValidity(delegate.lifetime)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   fmt.Sprintf("OCSP %s", core.SerialToString(serial)),
+			Organization: issuer.cert.Subject.Organization,
+			Country:      issuer.cert.Subject.Country,
+		},
+		NotBefore:   validity.NotBefore,
+		NotAfter:    validity.NotAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidOCSPNoCheck, Value: asn1.NullBytes},
+		},
+	}
+	if issuer.usePSS {
+		template.SignatureAlgorithm = x509.SHA256WithRSAPSS
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer.cert, key.Public(), issuer.ocspSigner)
+	ca.noteSignError(err)
+	if err != nil {
+		return err
+	}
+	ca.signatureCount.With(prometheus.Labels{"purpose": "ocspDelegate"}).Inc()
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+	ca.log.AuditInfof("Issued OCSP delegate: issuer=[%s] serial=[%s] notAfter=[%s] certificate=[%s]",
+		issuer.cert.Subject.CommonName, core.SerialToString(serial), cert.NotAfter, hex.EncodeToString(der))
+
+	delegate.Lock()
+	defer delegate.Unlock()
+	delegate.cert = cert
+	delegate.key = key
+	return nil
+}
+
+// renewOCSPDelegates renews the delegated OCSP responder of each issuer which
+// has one that needs renewal. It returns the last error encountered, if any.
+func (ca *CertificateAuthorityImpl) renewOCSPDelegates() error {
+	var lastErr error
+	for _, issuer := range ca.issuers {
+		if issuer.ocspDelegate == nil || !issuer.ocspDelegate.needsRenewal(ca.clk.Now()) {
+			continue
+		}
+		err := ca.renewOCSPDelegate(issuer)
+		if err != nil {
+			ca.log.AuditErrf("Failed to renew OCSP delegate: issuer=[%s] err=[%s]", issuer.cert.Subject.CommonName, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// OCSPDelegateRenewalLoop renews the delegated OCSP responders of all issuers
+// which use them as they approach expiry. It runs forever. If renewal keeps
+// failing, OCSP responses fall back to being signed with the issuer key once a
+// delegate can no longer cover them.
+func (ca *CertificateAuthorityImpl) OCSPDelegateRenewalLoop() {
+	for {
+		ca.clk.Sleep(time.Minute)
+		_ = ca.renewOCSPDelegates()
+	}
+}
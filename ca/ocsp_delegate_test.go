@@ -0,0 +1,102 @@
+package ca
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestValidateOCSPDelegate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		lifetime    time.Duration
+		renewBefore time.Duration
+		expectErr   bool
+	}{
+		{"no delegate", 0, 0, false},
+		{"valid", 30 * 24 * time.Hour, 10 * 24 * time.Hour, false},
+		{"renewBefore too short", 30 * 24 * time.Hour, 24 * time.Hour, true},
+		{"lifetime too short", 10 * 24 * time.Hour, 10 * 24 * time.Hour, true},
+		{"renewBefore missing", 30 * 24 * time.Hour, 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateOCSPDelegate(tc.lifetime, tc.renewBefore, 96*time.Hour)
+			if tc.expectErr {
+				test.AssertError(t, err, "expected error")
+			} else {
+				test.AssertNotError(t, err, "unexpected error")
+			}
+		})
+	}
+}
+
+func TestOCSPDelegate(t *testing.T) {
+	testCtx := setup(t)
+	issuers := []Issuer{{
+		Signer:                  caKey,
+		Cert:                    caCert,
+		OCSPDelegateLifetime:    30 * 24 * time.Hour,
+		OCSPDelegateRenewBefore: 10 * 24 * time.Hour,
+	}}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	resp, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue precertificate")
+	generateOCSP := func() *ocsp.Response {
+		t.Helper()
+		ocspResp, err := ca.GenerateOCSP(ctx, &capb.GenerateOCSPRequest{
+			CertDER: resp.DER,
+			Status:  string(core.OCSPStatusGood),
+		})
+		test.AssertNotError(t, err, "Failed to generate OCSP")
+		parsed, err := ocsp.ParseResponse(ocspResp.Response, caCert)
+		test.AssertNotError(t, err, "Failed to parse / validate OCSP response")
+		return parsed
+	}
+
+	// The response should be signed by a delegate, which has the OCSP signing
+	// EKU and the OCSP no-check extension.
+	parsed := generateOCSP()
+	test.Assert(t, parsed.Certificate != nil, "OCSP response didn't include a delegated responder")
+	delegate := parsed.Certificate
+	test.AssertDeepEquals(t, delegate.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+	var noCheck bool
+	for _, ext := range delegate.Extensions {
+		if ext.Id.Equal(oidOCSPNoCheck) {
+			noCheck = true
+		}
+	}
+	test.Assert(t, noCheck, "delegated responder lacks id-pkix-ocsp-nocheck")
+
+	// Within renewBefore of expiry a new delegate, with a new key, replaces it.
+	testCtx.fc.Add(21 * 24 * time.Hour)
+	test.AssertNotError(t, ca.renewOCSPDelegates(), "Failed to renew OCSP delegate")
+	parsed = generateOCSP()
+	test.Assert(t, parsed.Certificate != nil, "OCSP response didn't include a delegated responder")
+	test.Assert(t, !parsed.Certificate.Equal(delegate), "OCSP delegate wasn't renewed")
+	test.AssertEquals(t, test.CountCounter(ca.ocspDelegateFallbacks), 0)
+
+	// If renewal doesn't happen and the delegate expires, responses are signed
+	// by the issuer.
+	testCtx.fc.Add(30 * 24 * time.Hour)
+	parsed = generateOCSP()
+	test.Assert(t, parsed.Certificate == nil, "OCSP response was signed by an expired delegate")
+	test.AssertEquals(t, test.CountCounter(ca.ocspDelegateFallbacks), 1)
+}
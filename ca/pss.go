@@ -0,0 +1,116 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+)
+
+var (
+	oidSignatureRSAPSS = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidMGF1            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+// pssParameters is the RSASSA-PSS-params structure from RFC 4055, Section 3.1.
+// The trailer field is omitted because its only permitted value is the
+// default.
+type pssParameters struct {
+	Hash       pkix.AlgorithmIdentifier `asn1:"explicit,tag:0"`
+	MGF        pkix.AlgorithmIdentifier `asn1:"explicit,tag:1"`
+	SaltLength int                      `asn1:"explicit,tag:2"`
+}
+
+// pssAlgorithmIdentifier returns the AlgorithmIdentifier for RSASSA-PSS with
+// SHA-256, MGF1 with SHA-256, and a 32 byte salt. This is the encoding
+// required by Section 7.1.3.2 of the Baseline Requirements, and matches the
+// one crypto/x509 uses for x509.SHA256WithRSAPSS.
+func pssAlgorithmIdentifier() pkix.AlgorithmIdentifier {
+	sha256Algo := pkix.AlgorithmIdentifier{
+		Algorithm:  oidSHA256,
+		Parameters: asn1.NullRawValue,
+	}
+	mgf1Params, err := asn1.Marshal(sha256Algo)
+	if err != nil {
+		panic(err)
+	}
+	params, err := asn1.Marshal(pssParameters{
+		Hash:       sha256Algo,
+		MGF:        pkix.AlgorithmIdentifier{Algorithm: oidMGF1, Parameters: asn1.RawValue{FullBytes: mgf1Params}},
+		SaltLength: crypto.SHA256.Size(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return pkix.AlgorithmIdentifier{
+		Algorithm:  oidSignatureRSAPSS,
+		Parameters: asn1.RawValue{FullBytes: params},
+	}
+}
+
+// pssSigner wraps an RSA crypto.Signer so that it produces RSASSA-PSS
+// signatures, with a salt as long as the hash, whatever SignerOpts it is
+// called with. It lets us use signing code which only knows about PKCS#1 v1.5,
+// such as ocsp.CreateResponse.
+type pssSigner struct {
+	crypto.Signer
+}
+
+func (s pssSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.Signer.Sign(rand, digest, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+		Hash:       opts.HashFunc(),
+	})
+}
+
+// ocspResponseASN1, ocspResponseBytes, and ocspBasicResponse mirror the
+// unexported types used by golang.org/x/crypto/ocsp, except that the
+// tbsResponseData is left unparsed so that it is reencoded byte for byte.
+type ocspResponseASN1 struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// setOCSPSignatureAlgorithm replaces the signatureAlgorithm of a DER encoded
+// OCSP response. golang.org/x/crypto/ocsp can't produce RSASSA-PSS signed
+// responses, so we sign them through a pssSigner and then correct the
+// AlgorithmIdentifier, which doesn't form part of the signed data.
+func setOCSPSignatureAlgorithm(der []byte, algo pkix.AlgorithmIdentifier) ([]byte, error) {
+	var resp ocspResponseASN1
+	rest, err := asn1.Unmarshal(der, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after OCSP response")
+	}
+	var basic ocspBasicResponse
+	rest, err = asn1.Unmarshal(resp.Response.Response, &basic)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after OCSP basic response")
+	}
+	basic.SignatureAlgorithm = algo
+	resp.Response.Response, err = asn1.Marshal(basic)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(resp)
+}
@@ -0,0 +1,97 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"testing"
+
+	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// pssSHA256AlgorithmIdentifier is the encoding of an RSASSA-PSS
+// AlgorithmIdentifier using SHA-256, MGF1 with SHA-256, and a 32 byte salt,
+// as given in Section 7.1.3.2 of the Baseline Requirements.
+const pssSHA256AlgorithmIdentifier = "304106092a864886f70d01010a3034a00f300d06096086480165030402010500a11c301a06092a864886f70d010108300d06096086480165030402010500a203020120"
+
+// signedObject is the outer structure shared by certificates and basic OCSP
+// responses: some signed data, followed by the signature algorithm and the
+// signature.
+type signedObject struct {
+	TBS       asn1.RawValue
+	Algorithm asn1.RawValue
+	Signature asn1.BitString
+}
+
+func TestPSSAlgorithmIdentifier(t *testing.T) {
+	der, err := asn1.Marshal(pssAlgorithmIdentifier())
+	test.AssertNotError(t, err, "failed to marshal AlgorithmIdentifier")
+	test.AssertEquals(t, hex.EncodeToString(der), pssSHA256AlgorithmIdentifier)
+}
+
+func TestRSAPSSIssuance(t *testing.T) {
+	testCtx := setup(t)
+	issuers := []Issuer{{Signer: caKey, Cert: caCert, UseRSAPSS: true}}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	resp, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue precertificate")
+	cert, err := x509.ParseCertificate(resp.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, cert.SignatureAlgorithm, x509.SHA256WithRSAPSS)
+	test.AssertNotError(t, cert.CheckSignatureFrom(caCert), "Certificate signature didn't verify")
+	var signedCert signedObject
+	_, err = asn1.Unmarshal(resp.DER, &signedCert)
+	test.AssertNotError(t, err, "Failed to unmarshal certificate")
+	test.AssertEquals(t, hex.EncodeToString(signedCert.Algorithm.FullBytes), pssSHA256AlgorithmIdentifier)
+
+	ocspResp, err := ca.GenerateOCSP(ctx, &capb.GenerateOCSPRequest{
+		CertDER: resp.DER,
+		Status:  string(core.OCSPStatusGood),
+	})
+	test.AssertNotError(t, err, "Failed to generate OCSP")
+	var outer ocspResponseASN1
+	_, err = asn1.Unmarshal(ocspResp.Response, &outer)
+	test.AssertNotError(t, err, "Failed to unmarshal OCSP response")
+	var signedResp signedObject
+	_, err = asn1.Unmarshal(outer.Response.Response, &signedResp)
+	test.AssertNotError(t, err, "Failed to unmarshal basic OCSP response")
+	test.AssertEquals(t, hex.EncodeToString(signedResp.Algorithm.FullBytes), pssSHA256AlgorithmIdentifier)
+	digest := sha256.Sum256(signedResp.TBS.FullBytes)
+	err = rsa.VerifyPSS(caCert.PublicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signedResp.Signature.Bytes, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+	})
+	test.AssertNotError(t, err, "OCSP response signature didn't verify")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate ECDSA key")
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		[]Issuer{{Signer: ecKey, Cert: caCert, UseRSAPSS: true}},
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertError(t, err, "CA accepted UseRSAPSS with an ECDSA key")
+}
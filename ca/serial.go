@@ -0,0 +1,63 @@
+package ca
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	ca_config "github.com/letsencrypt/boulder/ca/config"
+)
+
+const (
+	// defaultSerialLength is the length, in bytes, of the serials issued by a
+	// CA which doesn't configure SerialLength: a one byte prefix followed by
+	// 136 random bits. It's also the shortest length allowed, so that a
+	// serial's prefix is always at the start of core.SerialToString's
+	// zero-padded 36 character encoding of it.
+	defaultSerialLength = 18
+	// maxSerialLength is the longest serial RFC 5280 Section 4.1.2.2 allows,
+	// in bytes.
+	maxSerialLength = 20
+	// minSerialRandomBytes is the least randomness a serial may contain, as the
+	// Baseline Requirements require at least 64 bits of CSPRNG output.
+	minSerialRandomBytes = 8
+)
+
+// serialPrefixAndLength returns the prefix of the serials issued by a CA with
+// the given config, and their total length in bytes, or an error if the config
+// doesn't describe serials we can issue.
+func serialPrefixAndLength(config ca_config.CAConfig) ([]byte, int, error) {
+	var prefix []byte
+	if config.SerialPrefixHex != "" {
+		var err error
+		prefix, err = hex.DecodeString(config.SerialPrefixHex)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid serialPrefixHex %q: %s", config.SerialPrefixHex, err)
+		}
+		if prefix[0] == 0 {
+			return nil, 0, errors.New("serialPrefixHex must not start with a zero byte")
+		}
+	} else {
+		if config.SerialPrefix <= 0 || config.SerialPrefix >= 256 {
+			return nil, 0, errors.New("Must have a positive non-zero serial prefix less than 256 for CA.")
+		}
+		prefix = []byte{byte(config.SerialPrefix)}
+	}
+
+	length := config.SerialLength
+	if length == 0 {
+		length = defaultSerialLength
+	}
+	if length < defaultSerialLength || length > maxSerialLength {
+		return nil, 0, fmt.Errorf("serialLength must be between %d and %d bytes, got %d", defaultSerialLength, maxSerialLength, length)
+	}
+	if length-len(prefix) < minSerialRandomBytes {
+		return nil, 0, fmt.Errorf("a %d byte serial prefix leaves fewer than %d random bytes in a %d byte serial", len(prefix), minSerialRandomBytes, length)
+	}
+	// Serials are positive, so one whose first bit is set gains a leading zero
+	// byte when DER encoded, which a maximum length serial has no room for.
+	if length == maxSerialLength && prefix[0] >= 0x80 {
+		return nil, 0, fmt.Errorf("%d byte serials must have a prefix below 0x80", maxSerialLength)
+	}
+	return prefix, length, nil
+}
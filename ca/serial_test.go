@@ -0,0 +1,118 @@
+package ca
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	ca_config "github.com/letsencrypt/boulder/ca/config"
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSerialPrefixAndLength(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         ca_config.CAConfig
+		expectedPrefix []byte
+		expectedLength int
+		expectedErr    string
+	}{
+		{
+			name:           "default",
+			config:         ca_config.CAConfig{SerialPrefix: 255},
+			expectedPrefix: []byte{0xff},
+			expectedLength: 18,
+		},
+		{
+			name:           "hex prefix and length",
+			config:         ca_config.CAConfig{SerialPrefix: 255, SerialPrefixHex: "7f01", SerialLength: 20},
+			expectedPrefix: []byte{0x7f, 0x01},
+			expectedLength: 20,
+		},
+		{
+			name:           "low prefix at maximum length",
+			config:         ca_config.CAConfig{SerialPrefix: 1, SerialLength: 20},
+			expectedPrefix: []byte{0x01},
+			expectedLength: 20,
+		},
+		{
+			name:        "no prefix",
+			config:      ca_config.CAConfig{},
+			expectedErr: "Must have a positive non-zero serial prefix less than 256 for CA.",
+		},
+		{
+			name:        "invalid hex prefix",
+			config:      ca_config.CAConfig{SerialPrefixHex: "7g"},
+			expectedErr: `invalid serialPrefixHex "7g": encoding/hex: invalid byte: U+0067 'g'`,
+		},
+		{
+			name:        "zero hex prefix",
+			config:      ca_config.CAConfig{SerialPrefixHex: "00ff"},
+			expectedErr: "serialPrefixHex must not start with a zero byte",
+		},
+		{
+			name:        "too short",
+			config:      ca_config.CAConfig{SerialPrefix: 1, SerialLength: 16},
+			expectedErr: "serialLength must be between 18 and 20 bytes, got 16",
+		},
+		{
+			name:        "too long",
+			config:      ca_config.CAConfig{SerialPrefix: 1, SerialLength: 21},
+			expectedErr: "serialLength must be between 18 and 20 bytes, got 21",
+		},
+		{
+			name:        "too little randomness",
+			config:      ca_config.CAConfig{SerialPrefixHex: "0102030405060708090a0b", SerialLength: 18},
+			expectedErr: "a 11 byte serial prefix leaves fewer than 8 random bytes in a 18 byte serial",
+		},
+		{
+			name:        "high prefix at maximum length",
+			config:      ca_config.CAConfig{SerialPrefix: 255, SerialLength: 20},
+			expectedErr: "20 byte serials must have a prefix below 0x80",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prefix, length, err := serialPrefixAndLength(tc.config)
+			if tc.expectedErr != "" {
+				test.AssertError(t, err, "invalid config was accepted")
+				test.AssertEquals(t, err.Error(), tc.expectedErr)
+				return
+			}
+			test.AssertNotError(t, err, "valid config was rejected")
+			test.AssertByteEquals(t, prefix, tc.expectedPrefix)
+			test.AssertEquals(t, length, tc.expectedLength)
+		})
+	}
+}
+
+func TestGenerateSerialNumber(t *testing.T) {
+	ca := &CertificateAuthorityImpl{
+		serialPrefix: []byte{0x7f, 0x01},
+		serialLength: 20,
+		clk:          clock.NewFake(),
+		log:          blog.NewMock(),
+	}
+	serial, _, err := ca.generateSerialNumberAndValidity(time.Hour)
+	test.AssertNotError(t, err, "generating serial")
+	serialString := core.SerialToString(serial)
+	test.AssertEquals(t, len(serialString), 40)
+	test.AssertEquals(t, serialString[:4], "7f01")
+	test.Assert(t, core.ValidSerial(serialString), "generated serial isn't valid")
+
+	// A prefix below 0x10 keeps its leading zero in serials longer than the
+	// default.
+	ca.serialPrefix = []byte{0x01}
+	for _, length := range []int{19, 20} {
+		ca.serialLength = length
+		serial, _, err := ca.generateSerialNumberAndValidity(time.Hour)
+		test.AssertNotError(t, err, "generating serial")
+		serialString := core.SerialToString(serial)
+		test.AssertEquals(t, len(serialString), 2*length)
+		test.AssertEquals(t, serialString[:2], "01")
+		test.Assert(t, core.ValidSerial(serialString), "generated serial isn't valid")
+	}
+}
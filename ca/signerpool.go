@@ -0,0 +1,250 @@
+package ca
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// PoolMember is one of the signers in a SignerPool, typically a single PKCS#11
+// session. The Name is used to label the member's metrics and log lines.
+// Check, if set, probes the member's health without signing anything, for
+// instance by querying its HSM's token.
+type PoolMember struct {
+	Name   string
+	Signer crypto.Signer
+	Check  func() error
+}
+
+// SignerPoolMetrics holds the metrics shared by all SignerPools in a process.
+type SignerPoolMetrics struct {
+	latency *prometheus.HistogramVec
+	healthy *prometheus.GaugeVec
+}
+
+// NewSignerPoolMetrics creates and registers the metrics used by SignerPools.
+func NewSignerPoolMetrics(stats prometheus.Registerer) *SignerPoolMetrics {
+	latency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "signer_pool_sign_latency",
+			Help:    "Histogram of signing latencies by pool, member and result",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"pool", "member", "result"})
+	stats.MustRegister(latency)
+	healthy := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "signer_pool_member_healthy",
+			Help: "Whether a signer pool member is healthy (1) or not (0)",
+		},
+		[]string{"pool", "member"})
+	stats.MustRegister(healthy)
+	return &SignerPoolMetrics{latency: latency, healthy: healthy}
+}
+
+type poolMember struct {
+	PoolMember
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+func (m *poolMember) isHealthy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.healthy
+}
+
+// setHealthy records the health of the member and returns its previous
+// health.
+func (m *poolMember) setHealthy(healthy bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	was := m.healthy
+	m.healthy = healthy
+	return was
+}
+
+// SignerPool is a crypto.Signer which spreads signing operations across a set
+// of signers holding the same key, such as multiple PKCS#11 sessions on one or
+// more HSMs. Each signing operation checks out an idle member, so concurrent
+// operations never queue behind the same session while another is idle, and
+// members are checked out in the order they were returned. A member whose
+// signing operation fails is marked unhealthy and the operation is retried on
+// another member. Unhealthy members are only used when no healthy member could
+// sign, and are marked healthy again when a signing operation or health check
+// succeeds.
+type SignerPool struct {
+	name    string
+	public  crypto.PublicKey
+	members []*poolMember
+
+	// mu protects idle, the members not currently checked out, in the order
+	// they were returned. returned is signalled whenever a member is returned
+	// or its health changes.
+	mu       sync.Mutex
+	idle     []*poolMember
+	returned *sync.Cond
+
+	clk     clock.Clock
+	log     blog.Logger
+	metrics *SignerPoolMetrics
+}
+
+// NewSignerPool creates a SignerPool from the given members, all of which must
+// use the key corresponding to public. All members start out healthy.
+func NewSignerPool(
+	name string,
+	public crypto.PublicKey,
+	members []PoolMember,
+	clk clock.Clock,
+	logger blog.Logger,
+	metrics *SignerPoolMetrics,
+) (*SignerPool, error) {
+	if len(members) == 0 {
+		return nil, errors.New("signer pool must have at least one member")
+	}
+	p := &SignerPool{
+		name:    name,
+		public:  public,
+		clk:     clk,
+		log:     logger,
+		metrics: metrics,
+	}
+	p.returned = sync.NewCond(&p.mu)
+	for _, m := range members {
+		if !core.KeyDigestEquals(m.Signer.Public(), public) {
+			return nil, fmt.Errorf("signer pool member %q has the wrong public key", m.Name)
+		}
+		p.members = append(p.members, &poolMember{PoolMember: m, healthy: true})
+		metrics.healthy.WithLabelValues(name, m.Name).Set(1)
+	}
+	p.idle = append(p.idle, p.members...)
+	return p, nil
+}
+
+// Public returns the public key shared by the pool's members.
+func (p *SignerPool) Public() crypto.PublicKey {
+	return p.public
+}
+
+// Sign signs digest with the first member checked out that is able to.
+// Healthy members are tried first. If no member can sign, the last error is
+// returned.
+func (p *SignerPool) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	tried := make(map[*poolMember]bool, len(p.members))
+	var lastErr error
+	for {
+		m := p.checkout(tried)
+		if m == nil {
+			return nil, lastErr
+		}
+		tried[m] = true
+		sig, err := p.signWith(m, rand, digest, opts)
+		p.checkin(m)
+		if err == nil {
+			return sig, nil
+		}
+		lastErr = err
+	}
+}
+
+// checkout removes and returns the idle member which was returned least
+// recently and hasn't been tried, waiting for one to be returned if necessary.
+// While any untried member is healthy only healthy members are checked out.
+// It returns nil once every member has been tried.
+func (p *SignerPool) checkout(tried map[*poolMember]bool) *poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		untried, untriedHealthy := false, false
+		for _, m := range p.members {
+			if !tried[m] {
+				untried = true
+				untriedHealthy = untriedHealthy || m.isHealthy()
+			}
+		}
+		if !untried {
+			return nil
+		}
+		for i, m := range p.idle {
+			if !tried[m] && m.isHealthy() == untriedHealthy {
+				p.idle = append(p.idle[:i], p.idle[i+1:]...)
+				return m
+			}
+		}
+		p.returned.Wait()
+	}
+}
+
+// checkin returns a member checked out by checkout to the pool.
+func (p *SignerPool) checkin(m *poolMember) {
+	p.mu.Lock()
+	p.idle = append(p.idle, m)
+	p.mu.Unlock()
+	p.returned.Broadcast()
+}
+
+// signWith signs using the given member, records the latency of the operation
+// and updates the member's health based on the result.
+func (p *SignerPool) signWith(m *poolMember, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	began := p.clk.Now()
+	sig, err := m.Signer.Sign(rand, digest, opts)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	p.metrics.latency.WithLabelValues(p.name, m.Name, result).Observe(p.clk.Since(began).Seconds())
+	p.setHealthy(m, err)
+	return sig, err
+}
+
+func (p *SignerPool) setHealthy(m *poolMember, err error) {
+	healthy := err == nil
+	was := m.setHealthy(healthy)
+	if was != healthy {
+		// Wake any signing operation waiting for a member of the other health.
+		p.mu.Lock()
+		p.returned.Broadcast()
+		p.mu.Unlock()
+	}
+	if healthy {
+		p.metrics.healthy.WithLabelValues(p.name, m.Name).Set(1)
+		if !was {
+			p.log.Infof("signer pool %q member %q is healthy again", p.name, m.Name)
+		}
+		return
+	}
+	p.metrics.healthy.WithLabelValues(p.name, m.Name).Set(0)
+	if was {
+		p.log.Errf("signer pool %q member %q failed, marking unhealthy: %s", p.name, m.Name, err)
+	}
+}
+
+// CheckHealth probes every member of the pool which has a Check, and updates
+// each member's health accordingly. Members without a Check are only marked
+// healthy again by a successful signing operation.
+func (p *SignerPool) CheckHealth() {
+	for _, m := range p.members {
+		if m.Check != nil {
+			p.setHealthy(m, m.Check())
+		}
+	}
+}
+
+// HealthCheckLoop runs CheckHealth every interval. It never returns.
+func (p *SignerPool) HealthCheckLoop(interval time.Duration) {
+	for {
+		p.clk.Sleep(interval)
+		p.CheckHealth()
+	}
+}
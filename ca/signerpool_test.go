@@ -0,0 +1,160 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/jmhodges/clock"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var testDigest = sha256.Sum256([]byte("boulder signer pool test"))
+
+// flakySigner wraps a crypto.Signer, counting signing operations and failing
+// them, and health checks, while broken is set.
+type flakySigner struct {
+	crypto.Signer
+	broken bool
+	signs  int
+}
+
+func (s *flakySigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.signs++
+	if s.broken {
+		return nil, errors.New("HSM unavailable")
+	}
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func (s *flakySigner) check() error {
+	if s.broken {
+		return errors.New("HSM unavailable")
+	}
+	return nil
+}
+
+func setupSignerPool(t *testing.T, n int) (*SignerPool, []*flakySigner) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	var signers []*flakySigner
+	var members []PoolMember
+	for i := 0; i < n; i++ {
+		s := &flakySigner{Signer: key}
+		signers = append(signers, s)
+		members = append(members, PoolMember{Name: string(rune('a' + i)), Signer: s, Check: s.check})
+	}
+	pool, err := NewSignerPool("test", key.Public(), members, clock.NewFake(), blog.NewMock(), NewSignerPoolMetrics(metrics.NoopRegisterer))
+	test.AssertNotError(t, err, "NewSignerPool failed")
+	return pool, signers
+}
+
+func TestNewSignerPool(t *testing.T) {
+	_, err := NewSignerPool("test", nil, nil, clock.NewFake(), blog.NewMock(), NewSignerPoolMetrics(metrics.NoopRegisterer))
+	test.AssertError(t, err, "NewSignerPool accepted no members")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	_, err = NewSignerPool("test", key.Public(), []PoolMember{{Name: "a", Signer: other}},
+		clock.NewFake(), blog.NewMock(), NewSignerPoolMetrics(metrics.NoopRegisterer))
+	test.AssertError(t, err, "NewSignerPool accepted a member with the wrong key")
+}
+
+func TestSignerPoolSpreadsLoad(t *testing.T) {
+	pool, signers := setupSignerPool(t, 3)
+	for i := 0; i < 6; i++ {
+		_, err := pool.Sign(rand.Reader, testDigest[:], crypto.SHA256)
+		test.AssertNotError(t, err, "Sign failed")
+	}
+	for _, s := range signers {
+		test.AssertEquals(t, s.signs, 2)
+	}
+}
+
+func TestSignerPoolFailover(t *testing.T) {
+	pool, signers := setupSignerPool(t, 2)
+	signers[0].broken = true
+
+	// Every signature should succeed, with at most one attempt on the broken
+	// member before it is marked unhealthy.
+	for i := 0; i < 4; i++ {
+		_, err := pool.Sign(rand.Reader, testDigest[:], crypto.SHA256)
+		test.AssertNotError(t, err, "Sign failed")
+	}
+	test.AssertEquals(t, signers[0].signs, 1)
+	test.AssertEquals(t, signers[1].signs, 4)
+	test.AssertEquals(t, test.CountHistogramSamples(pool.metrics.latency.WithLabelValues("test", "a", "failure")), 1)
+	test.AssertEquals(t, test.CountHistogramSamples(pool.metrics.latency.WithLabelValues("test", "b", "success")), 4)
+	test.Assert(t, !pool.members[0].isHealthy(), "broken member still healthy")
+
+	// When every member is unhealthy they should all be tried.
+	signers[1].broken = true
+	_, err := pool.Sign(rand.Reader, testDigest[:], crypto.SHA256)
+	test.AssertError(t, err, "Sign succeeded with every member broken")
+	signers[1].broken = false
+	_, err = pool.Sign(rand.Reader, testDigest[:], crypto.SHA256)
+	test.AssertNotError(t, err, "Sign failed with an unhealthy but working member")
+
+	// A health check should mark the repaired member healthy again, without
+	// signing anything.
+	signers[0].broken = false
+	signs := signers[0].signs + signers[1].signs
+	pool.CheckHealth()
+	test.Assert(t, pool.members[0].isHealthy(), "repaired member not healthy after health check")
+	test.Assert(t, pool.members[1].isHealthy(), "working member not healthy after health check")
+	test.AssertEquals(t, signers[0].signs+signers[1].signs, signs)
+}
+
+// blockingSigner wraps a crypto.Signer, signalling started and then waiting
+// for release before each signing operation.
+type blockingSigner struct {
+	crypto.Signer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func TestSignerPoolSkipsBusyMembers(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	slow := &blockingSigner{Signer: key, started: make(chan struct{}), release: make(chan struct{})}
+	fast := &flakySigner{Signer: key}
+	pool, err := NewSignerPool("test", key.Public(), []PoolMember{
+		{Name: "slow", Signer: slow},
+		{Name: "fast", Signer: fast},
+	}, clock.NewFake(), blog.NewMock(), NewSignerPoolMetrics(metrics.NoopRegisterer))
+	test.AssertNotError(t, err, "NewSignerPool failed")
+
+	done := make(chan error)
+	go func() {
+		_, err := pool.Sign(rand.Reader, testDigest[:], crypto.SHA256)
+		done <- err
+	}()
+	<-slow.started
+
+	// While the slow member is busy every other signing operation should use
+	// the idle one rather than queueing behind it.
+	for i := 0; i < 3; i++ {
+		_, err = pool.Sign(rand.Reader, testDigest[:], crypto.SHA256)
+		test.AssertNotError(t, err, "Sign failed")
+	}
+	test.AssertEquals(t, fast.signs, 3)
+
+	close(slow.release)
+	test.AssertNotError(t, <-done, "Sign failed")
+}
@@ -0,0 +1,86 @@
+package ca
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	ca_config "github.com/letsencrypt/boulder/ca/config"
+)
+
+// signingThrottle bounds the number of signing operations of one kind which
+// may be in progress at once, so that a burst of requests can't overwhelm the
+// HSM. Requests beyond the limit wait for a free slot, up to a bounded number
+// of waiters, after which they are rejected.
+//
+// A nil *signingThrottle places no limit on signing.
+type signingThrottle struct {
+	purpose    string
+	slots      chan struct{}
+	waiting    int64
+	maxWaiting int64
+	retryAfter time.Duration
+	rejections *prometheus.CounterVec
+}
+
+// newSigningThrottle returns a signingThrottle implementing the given limit,
+// or nil if the limit doesn't restrict concurrency.
+func newSigningThrottle(purpose string, limit ca_config.SigningLimit, rejections *prometheus.CounterVec) *signingThrottle {
+	if limit.MaxConcurrent <= 0 {
+		return nil
+	}
+	return &signingThrottle{
+		purpose:    purpose,
+		slots:      make(chan struct{}, limit.MaxConcurrent),
+		maxWaiting: int64(limit.MaxWaiting),
+		retryAfter: limit.RetryAfter.Duration,
+		rejections: rejections,
+	}
+}
+
+// acquire waits for a free signing slot and returns a function which releases
+// it. If too many requests are already waiting, or ctx is done before a slot
+// becomes free, it returns a ResourceExhausted gRPC error carrying a
+// retry-after hint, in seconds, in the response trailer.
+func (t *signingThrottle) acquire(ctx context.Context) (func(), error) {
+	if t == nil {
+		return func() {}, nil
+	}
+	release := func() { <-t.slots }
+	select {
+	case t.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if atomic.AddInt64(&t.waiting, 1) > t.maxWaiting {
+		atomic.AddInt64(&t.waiting, -1)
+		return nil, t.reject(ctx, "queue full")
+	}
+	defer atomic.AddInt64(&t.waiting, -1)
+	select {
+	case t.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, t.reject(ctx, "timed out waiting")
+	}
+}
+
+func (t *signingThrottle) reject(ctx context.Context, reason string) error {
+	t.rejections.WithLabelValues(t.purpose).Inc()
+	if t.retryAfter > 0 {
+		seconds := int(math.Ceil(t.retryAfter.Seconds()))
+		// Setting the trailer fails outside of a gRPC server, in which case the
+		// hint is simply omitted.
+		_ = grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+	}
+	return status.Errorf(codes.ResourceExhausted, "too many concurrent %s signing requests: %s", t.purpose, reason)
+}
@@ -0,0 +1,77 @@
+package ca
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ca_config "github.com/letsencrypt/boulder/ca/config"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func newTestThrottle(maxConcurrent, maxWaiting int) (*signingThrottle, *prometheus.CounterVec) {
+	rejections := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signing_throttle_rejections",
+		Help: "test",
+	}, []string{"purpose"})
+	return newSigningThrottle("certificate", ca_config.SigningLimit{
+		MaxConcurrent: maxConcurrent,
+		MaxWaiting:    maxWaiting,
+	}, rejections), rejections
+}
+
+func TestSigningThrottleUnlimited(t *testing.T) {
+	throttle, _ := newTestThrottle(0, 0)
+	test.Assert(t, throttle == nil, "throttle without MaxConcurrent wasn't nil")
+	for i := 0; i < 10; i++ {
+		_, err := throttle.acquire(context.Background())
+		test.AssertNotError(t, err, "nil throttle refused request")
+	}
+}
+
+func TestSigningThrottle(t *testing.T) {
+	throttle, rejections := newTestThrottle(1, 1)
+
+	release, err := throttle.acquire(context.Background())
+	test.AssertNotError(t, err, "first acquire failed")
+
+	// The second request should wait for the first to be released.
+	acquired := make(chan error)
+	go func() {
+		release, err := throttle.acquire(context.Background())
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	for i := 0; i < 100; i++ {
+		if atomic.LoadInt64(&throttle.waiting) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// With the queue full, a third request should be refused at once.
+	_, err = throttle.acquire(context.Background())
+	test.AssertError(t, err, "acquire succeeded with full queue")
+	test.AssertEquals(t, status.Code(err), codes.ResourceExhausted)
+	test.AssertEquals(t, test.CountCounterVec("purpose", "certificate", rejections), 1)
+
+	release()
+	test.AssertNotError(t, <-acquired, "waiting acquire failed")
+
+	// A waiting request should give up when its context is done.
+	release, err = throttle.acquire(context.Background())
+	test.AssertNotError(t, err, "acquire failed")
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = throttle.acquire(ctx)
+	test.AssertEquals(t, status.Code(err), codes.ResourceExhausted)
+	test.AssertEquals(t, test.CountCounterVec("purpose", "certificate", rejections), 2)
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/user"
@@ -12,6 +14,9 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
 
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
@@ -22,6 +27,7 @@ import (
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/ratelimit"
 	"github.com/letsencrypt/boulder/revocation"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
@@ -32,13 +38,29 @@ usage:
 admin-revoker serial-revoke --config <path> <serial> <reason-code>
 admin-revoker batched-serial-revoke --config <path> <serial-file-path> <reason-code> <parallelism>
 admin-revoker reg-revoke --config <path> <registration-id> <reason-code>
+admin-revoker incident-revoke --config <path> <incident-id> <reason-code>
 admin-revoker list-reasons --config <path>
+admin-revoker incident-serials --config <path> <incident-id>
+admin-revoker serial-incidents --config <path> <serial>
+admin-revoker add-override --config <path> <limit-name> <registration-id|key> <threshold> <lifetime> <reason>
+admin-revoker expire-override --config <path> <override-id>
+admin-revoker list-overrides --config <path> [all]
 
 command descriptions:
   serial-revoke       Revoke a single certificate by the hex serial number
   batched-serial-revoke Revokes all certificates contained in a file of hex serial numbers
   reg-revoke          Revoke all certificates associated with a registration ID
+  incident-revoke     Revoke all certificates affected by an incident, at the
+                      rate the RA allows for mass revocations
   list-reasons        List all revocation reason codes
+  incident-serials    List the hex serial numbers affected by an incident, one per
+                      line, in the form batched-serial-revoke reads
+  serial-incidents    List the enabled incidents which affected a certificate
+  add-override        Override a rate limit's threshold for an account, or a key
+                      such as a domain, for a lifetime such as 720h. The RA
+                      applies it without a redeploy
+  expire-override     Expire a rate limit override immediately
+  list-overrides      List the unexpired rate limit overrides, or all of them
 
 args:
   config    File path to the configuration file for this service
@@ -75,7 +97,7 @@ func setupContext(c config) (core.RegistrationAuthority, blog.Logger, *db.Wrappe
 
 	dbURL, err := c.Revoker.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	dbMap, err := sa.NewDbMap(dbURL, c.Revoker.DBConfig.MaxDBConns)
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettingsFromDBConfig(c.Revoker.DBConfig))
 	cmd.FailOnError(err, "Couldn't setup database connection")
 
 	saConn, err := bgrpc.ClientSetup(c.Revoker.SAService, tlsConfig, clientMetrics, clk)
@@ -106,7 +128,7 @@ func revokeCertificate(ctx context.Context, certObj core.Certificate, reasonCode
 }
 
 func revokeBySerial(ctx context.Context, serial string, reasonCode revocation.Reason, rac core.RegistrationAuthority, logger blog.Logger, dbMap db.Executor) error {
-	certObj, err := sa.SelectCertificate(dbMap, serial)
+	certObj, err := sa.SelectCertificateWithDER(dbMap, serial)
 	if err != nil {
 		if db.IsNoRows(err) {
 			return berrors.NotFoundError("certificate with serial %q not found", serial)
@@ -122,7 +144,16 @@ func revokeByReg(ctx context.Context, regID int64, reasonCode revocation.Reason,
 		return err
 	}
 	for _, certObj := range certObjs {
-		err = revokeCertificate(ctx, certObj.Certificate, reasonCode, rac, logger)
+		cert := certObj.Certificate
+		if len(cert.DER) == 0 {
+			// The certificate's DER has been pruned, so revoke it using its
+			// precertificate.
+			cert, err = sa.SelectCertificateWithDER(dbMap, cert.Serial)
+			if err != nil {
+				return err
+			}
+		}
+		err = revokeCertificate(ctx, cert, reasonCode, rac, logger)
 		if err != nil {
 			return err
 		}
@@ -165,6 +196,186 @@ func revokeBatch(rac core.RegistrationAuthority, logger blog.Logger, dbMap *db.W
 	return nil
 }
 
+// revokeIncident revokes every certificate affected by the given incident by
+// streaming its serials from the SA to the RA, which revokes them at a bounded
+// rate and records the incident with each revocation.
+func revokeIncident(ctx context.Context, incidentID int64, reasonCode revocation.Reason, rac core.RegistrationAuthority, sac core.StorageGetter, logger blog.Logger) error {
+	u, err := user.Current()
+	if err != nil {
+		return err
+	}
+
+	// Cancelling the context stops the SA's stream if the RA's fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	serials := make(chan string)
+	listErr := make(chan error, 1)
+	go func() {
+		defer close(serials)
+		listErr <- sac.SerialsForIncident(ctx, &sapb.SerialsForIncidentRequest{IncidentID: &incidentID}, func(serial *sapb.IncidentSerial) error {
+			select {
+			case serials <- serial.GetSerial():
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	code := int64(reasonCode)
+	first := true
+	recv := func() (*rapb.AdministrativelyRevokeCertificatesRequest, error) {
+		serial, ok := <-serials
+		if !ok {
+			return nil, io.EOF
+		}
+		req := &rapb.AdministrativelyRevokeCertificatesRequest{Serial: &serial}
+		if first {
+			// The RA takes these from the first request.
+			req.Code = &code
+			req.AdminName = &u.Username
+			req.IncidentID = &incidentID
+			first = false
+		}
+		return req, nil
+	}
+	resp, err := rac.AdministrativelyRevokeCertificates(ctx, recv)
+	if err != nil {
+		return err
+	}
+	err = <-listErr
+	if err != nil {
+		return fmt.Errorf("revoked %d certificates before failing to list serials for incident: %s", resp.GetRevoked(), err)
+	}
+	logger.AuditInfof("Revoked certificates for incident %d: reason=[%s] revoked=[%d] alreadyRevoked=[%d] failed=[%s]",
+		incidentID, revocation.ReasonToString[reasonCode], resp.GetRevoked(), resp.GetAlreadyRevoked(), strings.Join(resp.FailedSerials, ","))
+	return nil
+}
+
+// listIncidentSerials writes the serial of each certificate affected by the
+// given incident to w, one per line.
+func listIncidentSerials(ctx context.Context, incidentID int64, sac core.StorageGetter, w io.Writer) error {
+	return sac.SerialsForIncident(ctx, &sapb.SerialsForIncidentRequest{IncidentID: &incidentID}, func(serial *sapb.IncidentSerial) error {
+		_, err := fmt.Fprintln(w, serial.GetSerial())
+		return err
+	})
+}
+
+// listSerialIncidents writes the ID, URL, and renew-by date of each enabled
+// incident which affected the certificate with the given serial to w.
+func listSerialIncidents(ctx context.Context, serial string, sac core.StorageGetter, w io.Writer) error {
+	incidents, err := sac.IncidentsForSerial(ctx, &sapb.Serial{Serial: &serial})
+	if err != nil {
+		return err
+	}
+	for _, incident := range incidents.Incidents {
+		renewBy, err := bgrpc.PBOrNanosToTime(incident.RenewByTime, incident.RenewBy)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%d\t%s\trenew by %s\n",
+			incident.GetId(),
+			incident.GetUrl(),
+			renewBy.UTC().Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addOverride stores an override of the named rate limit's threshold, for the
+// registration ID or key given as target, which expires after lifetime.
+// Targets which are integers are registration IDs, since no limit's keys are.
+func addOverride(ctx context.Context, limitName, target string, threshold int64, lifetime time.Duration, reason string, sac core.StorageAdder, logger blog.Logger, clk clock.Clock) error {
+	if !ratelimit.ValidLimitName(limitName) {
+		return fmt.Errorf("unknown rate limit %q", limitName)
+	}
+	if lifetime <= 0 {
+		return fmt.Errorf("lifetime %s is not positive", lifetime)
+	}
+	if reason == "" {
+		return errors.New("a reason is required")
+	}
+	u, err := user.Current()
+	if err != nil {
+		return err
+	}
+	expires := clk.Now().Add(lifetime)
+	expiresNanos := expires.UnixNano()
+	req := &sapb.RateLimitOverride{
+		LimitName:   &limitName,
+		Threshold:   &threshold,
+		Reason:      &reason,
+		Requester:   &u.Username,
+		Expires:     &expiresNanos,
+		ExpiresTime: bgrpc.TimeToPB(expires),
+	}
+	regID, err := strconv.ParseInt(target, 10, 64)
+	if err == nil {
+		req.RegistrationID = &regID
+	} else {
+		req.Key = &target
+	}
+	override, err := sac.AddRateLimitOverride(ctx, req)
+	if err != nil {
+		return err
+	}
+	logger.AuditInfof("Added rate limit override %d: limit=[%s] target=[%s] threshold=[%d] expires=[%s] requester=[%s] reason=[%s]",
+		override.GetId(), limitName, target, threshold, expires.UTC().Format(time.RFC3339), u.Username, reason)
+	return nil
+}
+
+// expireOverride makes the rate limit override with the given ID expire now.
+func expireOverride(ctx context.Context, id int64, sac core.StorageAdder, logger blog.Logger) error {
+	u, err := user.Current()
+	if err != nil {
+		return err
+	}
+	_, err = sac.ExpireRateLimitOverride(ctx, &sapb.ExpireRateLimitOverrideRequest{Id: &id, Requester: &u.Username})
+	if err != nil {
+		return err
+	}
+	logger.AuditInfof("Expired rate limit override %d: requester=[%s]", id, u.Username)
+	return nil
+}
+
+// listOverrides writes the ID, limit, target, threshold, expiry, requester and
+// reason of each unexpired rate limit override, or of every override if
+// includeExpired is set, to w.
+func listOverrides(ctx context.Context, includeExpired bool, sac core.StorageGetter, w io.Writer) error {
+	resp, err := sac.GetRateLimitOverrides(ctx, &sapb.GetRateLimitOverridesRequest{IncludeExpired: &includeExpired})
+	if err != nil {
+		return err
+	}
+	for _, o := range resp.Overrides {
+		target := o.GetKey()
+		if o.GetRegistrationID() != 0 {
+			target = fmt.Sprintf("registration %d", o.GetRegistrationID())
+		}
+		expires, err := bgrpc.PBOrNanosToTime(o.ExpiresTime, o.Expires)
+		if err != nil {
+			return err
+		}
+		line := fmt.Sprintf("%d\t%s\t%s\t%d\texpires %s\t%s\t%s",
+			o.GetId(),
+			o.GetLimitName(),
+			target,
+			o.GetThreshold(),
+			expires.UTC().Format(time.RFC3339),
+			o.GetRequester(),
+			o.GetReason())
+		if o.GetExpiredBy() != "" {
+			line += fmt.Sprintf("\texpired by %s", o.GetExpiredBy())
+		}
+		_, err = fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // This abstraction is needed so that we can use sort.Sort below
 type revocationCodes []revocation.Reason
 
@@ -249,6 +460,59 @@ func main() {
 		})
 		cmd.FailOnError(err, "Couldn't revoke certificate by registration")
 
+	case command == "incident-revoke" && len(args) == 2:
+		// 1: incident ID,  2: reasonCode
+		incidentID, err := strconv.ParseInt(args[0], 10, 64)
+		cmd.FailOnError(err, "Incident ID argument must be an integer")
+		reasonCode, err := strconv.Atoi(args[1])
+		cmd.FailOnError(err, "Reason code argument must be an integer")
+
+		rac, logger, _, sac := setupContext(c)
+		defer logger.AuditPanic()
+		err = revokeIncident(ctx, incidentID, revocation.Reason(reasonCode), rac, sac, logger)
+		cmd.FailOnError(err, "Couldn't revoke certificates for incident")
+
+	case command == "incident-serials" && len(args) == 1:
+		// 1: incident ID
+		incidentID, err := strconv.ParseInt(args[0], 10, 64)
+		cmd.FailOnError(err, "Incident ID argument must be an integer")
+
+		_, _, _, sac := setupContext(c)
+		err = listIncidentSerials(ctx, incidentID, sac, os.Stdout)
+		cmd.FailOnError(err, "Couldn't list serials for incident")
+
+	case command == "serial-incidents" && len(args) == 1:
+		// 1: serial
+		_, _, _, sac := setupContext(c)
+		err = listSerialIncidents(ctx, args[0], sac, os.Stdout)
+		cmd.FailOnError(err, "Couldn't list incidents for serial")
+
+	case command == "add-override" && len(args) == 5:
+		// 1: limit name, 2: registration ID or key, 3: threshold, 4: lifetime,
+		// 5: reason
+		threshold, err := strconv.ParseInt(args[2], 10, 64)
+		cmd.FailOnError(err, "Threshold argument must be an integer")
+		lifetime, err := time.ParseDuration(args[3])
+		cmd.FailOnError(err, "Lifetime argument must be a duration")
+
+		_, logger, _, sac := setupContext(c)
+		err = addOverride(ctx, args[0], args[1], threshold, lifetime, args[4], sac, logger, cmd.Clock())
+		cmd.FailOnError(err, "Couldn't add rate limit override")
+
+	case command == "expire-override" && len(args) == 1:
+		// 1: override ID
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		cmd.FailOnError(err, "Override ID argument must be an integer")
+
+		_, logger, _, sac := setupContext(c)
+		err = expireOverride(ctx, id, sac, logger)
+		cmd.FailOnError(err, "Couldn't expire rate limit override")
+
+	case command == "list-overrides" && (len(args) == 0 || (len(args) == 1 && args[0] == "all")):
+		_, _, _, sac := setupContext(c)
+		err = listOverrides(ctx, len(args) == 1, sac, os.Stdout)
+		cmd.FailOnError(err, "Couldn't list rate limit overrides")
+
 	case command == "list-reasons":
 		var codes revocationCodes
 		for k := range revocation.ReasonToString {
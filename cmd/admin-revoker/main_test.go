@@ -1,25 +1,30 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"os/user"
 	"testing"
 	"time"
 
 	"github.com/jmhodges/clock"
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/goodkey"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/mocks"
 	"github.com/letsencrypt/boulder/ra"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/sa/satest"
@@ -95,3 +100,165 @@ func TestRevokeBatch(t *testing.T) {
 		test.AssertEquals(t, status.Status, core.OCSPStatusRevoked)
 	}
 }
+
+type mockSAIncidents struct {
+	mocks.StorageAuthority
+}
+
+func (sa *mockSAIncidents) IncidentsForSerial(_ context.Context, req *sapb.Serial) (*sapb.Incidents, error) {
+	id := int64(1)
+	url := "https://example.com/incident/1"
+	renewBy := time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	return &sapb.Incidents{Incidents: []*sapb.Incident{{Id: &id, Url: &url, RenewBy: &renewBy}}}, nil
+}
+
+func (sa *mockSAIncidents) SerialsForIncident(_ context.Context, req *sapb.SerialsForIncidentRequest, send func(*sapb.IncidentSerial) error) error {
+	for _, serial := range []string{"aa", "bb"} {
+		serial := serial
+		err := send(&sapb.IncidentSerial{Serial: &serial})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestListIncidents(t *testing.T) {
+	sac := &mockSAIncidents{}
+
+	var out bytes.Buffer
+	err := listIncidentSerials(context.Background(), 1, sac, &out)
+	test.AssertNotError(t, err, "listIncidentSerials failed")
+	test.AssertEquals(t, out.String(), "aa\nbb\n")
+
+	out.Reset()
+	err = listSerialIncidents(context.Background(), "aa", sac, &out)
+	test.AssertNotError(t, err, "listSerialIncidents failed")
+	test.AssertEquals(t, out.String(), "1\thttps://example.com/incident/1\trenew by 2020-08-01T00:00:00Z\n")
+}
+
+type mockRAIncidents struct {
+	core.RegistrationAuthority
+	reqs []*rapb.AdministrativelyRevokeCertificatesRequest
+}
+
+func (ra *mockRAIncidents) AdministrativelyRevokeCertificates(_ context.Context, recv func() (*rapb.AdministrativelyRevokeCertificatesRequest, error)) (*rapb.AdministrativelyRevokeCertificatesResponse, error) {
+	for {
+		req, err := recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ra.reqs = append(ra.reqs, req)
+	}
+	revoked := int64(len(ra.reqs))
+	var alreadyRevoked int64
+	return &rapb.AdministrativelyRevokeCertificatesResponse{Revoked: &revoked, AlreadyRevoked: &alreadyRevoked}, nil
+}
+
+func TestRevokeIncident(t *testing.T) {
+	log := blog.NewMock()
+	rac := &mockRAIncidents{}
+	err := revokeIncident(context.Background(), 1, 4, rac, &mockSAIncidents{}, log)
+	test.AssertNotError(t, err, "revokeIncident failed")
+
+	u, err := user.Current()
+	test.AssertNotError(t, err, "user.Current failed")
+	test.AssertEquals(t, len(rac.reqs), 2)
+	// The reason, admin and incident are only sent with the first serial.
+	test.AssertEquals(t, rac.reqs[0].GetSerial(), "aa")
+	test.AssertEquals(t, rac.reqs[0].GetCode(), int64(4))
+	test.AssertEquals(t, rac.reqs[0].GetAdminName(), u.Username)
+	test.AssertEquals(t, rac.reqs[0].GetIncidentID(), int64(1))
+	test.AssertEquals(t, rac.reqs[1].GetSerial(), "bb")
+	test.Assert(t, rac.reqs[1].IncidentID == nil, "incident ID sent with later serial")
+	test.AssertEquals(t, len(log.GetAllMatching(`Revoked certificates for incident 1: reason=\[superseded\] revoked=\[2\]`)), 1)
+}
+
+type mockSAOverrides struct {
+	mocks.StorageAuthority
+	added   *sapb.RateLimitOverride
+	expired *sapb.ExpireRateLimitOverrideRequest
+}
+
+func (sa *mockSAOverrides) AddRateLimitOverride(_ context.Context, req *sapb.RateLimitOverride) (*sapb.RateLimitOverride, error) {
+	sa.added = req
+	id := int64(7)
+	return &sapb.RateLimitOverride{Id: &id}, nil
+}
+
+func (sa *mockSAOverrides) ExpireRateLimitOverride(_ context.Context, req *sapb.ExpireRateLimitOverrideRequest) (*corepb.Empty, error) {
+	sa.expired = req
+	return &corepb.Empty{}, nil
+}
+
+func (sa *mockSAOverrides) GetRateLimitOverrides(_ context.Context, req *sapb.GetRateLimitOverridesRequest) (*sapb.RateLimitOverrides, error) {
+	expires := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	ids := []int64{1, 2}
+	names := []string{"certificatesPerName", "newOrdersPerAccount"}
+	key, regID := "example.com", int64(101)
+	thresholds := []int64{100, 500}
+	requester, reason := "root", "hosting provider"
+	overrides := []*sapb.RateLimitOverride{
+		{Id: &ids[0], LimitName: &names[0], Key: &key, Threshold: &thresholds[0], Expires: &expires, Requester: &requester, Reason: &reason},
+	}
+	if req.GetIncludeExpired() {
+		expiredBy := "admin"
+		overrides = append(overrides, &sapb.RateLimitOverride{
+			Id: &ids[1], LimitName: &names[1], RegistrationID: &regID, Threshold: &thresholds[1], Expires: &expires, Requester: &requester, Reason: &reason, ExpiredBy: &expiredBy,
+		})
+	}
+	return &sapb.RateLimitOverrides{Overrides: overrides}, nil
+}
+
+func TestOverrides(t *testing.T) {
+	log := blog.NewMock()
+	clk := clock.NewFake()
+	clk.Set(time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC))
+	sac := &mockSAOverrides{}
+	u, err := user.Current()
+	test.AssertNotError(t, err, "user.Current failed")
+
+	err = addOverride(context.Background(), "certificatesPerName", "example.com", 100, 24*time.Hour, "hosting provider", sac, log, clk)
+	test.AssertNotError(t, err, "addOverride failed")
+	test.AssertEquals(t, sac.added.GetLimitName(), "certificatesPerName")
+	test.AssertEquals(t, sac.added.GetKey(), "example.com")
+	test.Assert(t, sac.added.RegistrationID == nil, "override for a key has a registration ID")
+	test.AssertEquals(t, sac.added.GetThreshold(), int64(100))
+	test.AssertEquals(t, sac.added.GetExpires(), clk.Now().Add(24*time.Hour).UnixNano())
+	test.AssertEquals(t, sac.added.GetRequester(), u.Username)
+	test.AssertEquals(t, sac.added.GetReason(), "hosting provider")
+	test.AssertEquals(t, len(log.GetAllMatching(`Added rate limit override 7: limit=\[certificatesPerName\] target=\[example.com\]`)), 1)
+
+	err = addOverride(context.Background(), "newOrdersPerAccount", "101", 500, time.Hour, "hosting provider", sac, log, clk)
+	test.AssertNotError(t, err, "addOverride failed")
+	test.AssertEquals(t, sac.added.GetRegistrationID(), int64(101))
+	test.Assert(t, sac.added.Key == nil, "override for a registration has a key")
+
+	err = addOverride(context.Background(), "certificatesPerFish", "example.com", 1, time.Hour, "fish", sac, log, clk)
+	test.AssertError(t, err, "addOverride accepted an unknown limit")
+	err = addOverride(context.Background(), "certificatesPerName", "example.com", 1, -time.Hour, "past", sac, log, clk)
+	test.AssertError(t, err, "addOverride accepted a negative lifetime")
+	err = addOverride(context.Background(), "certificatesPerName", "example.com", 1, time.Hour, "", sac, log, clk)
+	test.AssertError(t, err, "addOverride accepted an empty reason")
+
+	err = expireOverride(context.Background(), 7, sac, log)
+	test.AssertNotError(t, err, "expireOverride failed")
+	test.AssertEquals(t, sac.expired.GetId(), int64(7))
+	test.AssertEquals(t, sac.expired.GetRequester(), u.Username)
+	test.AssertEquals(t, len(log.GetAllMatching(`Expired rate limit override 7`)), 1)
+
+	var out bytes.Buffer
+	err = listOverrides(context.Background(), false, sac, &out)
+	test.AssertNotError(t, err, "listOverrides failed")
+	test.AssertEquals(t, out.String(), "1\tcertificatesPerName\texample.com\t100\texpires 2020-09-01T00:00:00Z\troot\thosting provider\n")
+
+	out.Reset()
+	err = listOverrides(context.Background(), true, sac, &out)
+	test.AssertNotError(t, err, "listOverrides failed")
+	test.AssertEquals(t, out.String(),
+		"1\tcertificatesPerName\texample.com\t100\texpires 2020-09-01T00:00:00Z\troot\thosting provider\n"+
+			"2\tnewOrdersPerAccount\tregistration 101\t500\texpires 2020-09-01T00:00:00Z\troot\thosting provider\texpired by admin\n")
+}
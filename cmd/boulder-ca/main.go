@@ -8,11 +8,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/beeker1121/goque"
 
 	"github.com/cloudflare/cfssl/helpers"
+	"github.com/jmhodges/clock"
 	pkcs11key "github.com/letsencrypt/pkcs11key/v4"
+	"github.com/miekg/pkcs11"
 
 	"github.com/letsencrypt/boulder/ca"
 	ca_config "github.com/letsencrypt/boulder/ca/config"
@@ -21,7 +24,9 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	goodkeypb "github.com/letsencrypt/boulder/goodkey/proto"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/policy"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -34,26 +39,43 @@ type config struct {
 	Syslog cmd.SyslogConfig
 }
 
-func loadIssuers(c config) ([]ca.Issuer, error) {
+// signerPoolDeps holds what loadIssuer needs to build a ca.SignerPool for
+// issuers whose keys are held in HSMs.
+type signerPoolDeps struct {
+	clk     clock.Clock
+	logger  blog.Logger
+	metrics *ca.SignerPoolMetrics
+}
+
+func loadIssuers(c config, deps signerPoolDeps) ([]ca.Issuer, error) {
 	var issuers []ca.Issuer
 	for _, issuerConfig := range c.CA.Issuers {
-		priv, cert, err := loadIssuer(issuerConfig)
+		priv, cert, err := loadIssuer(issuerConfig, deps)
 		cmd.FailOnError(err, "Couldn't load private key")
-		issuers = append(issuers, ca.Issuer{
-			Signer: priv,
-			Cert:   cert,
-		})
+		cmd.RegisterDebugIssuer(cert)
+		issuer := ca.Issuer{
+			Signer:     priv,
+			Cert:       cert,
+			CRLURLBase: issuerConfig.CRLURLBase,
+			CRLShards:  issuerConfig.CRLShards,
+			UseRSAPSS:  issuerConfig.UseRSAPSS,
+		}
+		if issuerConfig.OCSPDelegate != nil {
+			issuer.OCSPDelegateLifetime = issuerConfig.OCSPDelegate.Lifetime.Duration
+			issuer.OCSPDelegateRenewBefore = issuerConfig.OCSPDelegate.RenewBefore.Duration
+		}
+		issuers = append(issuers, issuer)
 	}
 	return issuers, nil
 }
 
-func loadIssuer(issuerConfig ca_config.IssuerConfig) (crypto.Signer, *x509.Certificate, error) {
+func loadIssuer(issuerConfig ca_config.IssuerConfig, deps signerPoolDeps) (crypto.Signer, *x509.Certificate, error) {
 	cert, err := core.LoadCert(issuerConfig.CertFile)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	signer, err := loadSigner(issuerConfig, cert)
+	signer, err := loadSigner(issuerConfig, cert, deps)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -64,7 +86,7 @@ func loadIssuer(issuerConfig ca_config.IssuerConfig) (crypto.Signer, *x509.Certi
 	return signer, cert, err
 }
 
-func loadSigner(issuerConfig ca_config.IssuerConfig, cert *x509.Certificate) (crypto.Signer, error) {
+func loadSigner(issuerConfig ca_config.IssuerConfig, cert *x509.Certificate, deps signerPoolDeps) (crypto.Signer, error) {
 	if issuerConfig.File != "" {
 		keyBytes, err := ioutil.ReadFile(issuerConfig.File)
 		if err != nil {
@@ -80,29 +102,141 @@ func loadSigner(issuerConfig ca_config.IssuerConfig, cert *x509.Certificate) (cr
 
 	var pkcs11Config *pkcs11key.Config
 	if issuerConfig.ConfigFile != "" {
-		contents, err := ioutil.ReadFile(issuerConfig.ConfigFile)
+		var err error
+		pkcs11Config, err = readPKCS11Config(issuerConfig.ConfigFile)
 		if err != nil {
 			return nil, err
 		}
-		pkcs11Config = new(pkcs11key.Config)
-		err = json.Unmarshal(contents, pkcs11Config)
+	} else {
+		pkcs11Config = issuerConfig.PKCS11
+	}
+	pkcs11Configs := append([]*pkcs11key.Config{pkcs11Config}, issuerConfig.AdditionalPKCS11...)
+	for _, configFile := range issuerConfig.AdditionalConfigFiles {
+		pc, err := readPKCS11Config(configFile)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		pkcs11Config = issuerConfig.PKCS11
+		pkcs11Configs = append(pkcs11Configs, pc)
 	}
-	if pkcs11Config.Module == "" ||
-		pkcs11Config.TokenLabel == "" ||
-		pkcs11Config.PIN == "" {
-		return nil, fmt.Errorf("Missing a field in pkcs11Config %#v", pkcs11Config)
+	for _, pc := range pkcs11Configs {
+		if pc == nil ||
+			pc.Module == "" ||
+			pc.TokenLabel == "" ||
+			pc.PIN == "" {
+			return nil, fmt.Errorf("Missing a field in pkcs11Config %#v", pc)
+		}
 	}
 	numSessions := issuerConfig.NumSessions
 	if numSessions <= 0 {
 		numSessions = 1
 	}
-	return pkcs11key.NewPool(numSessions, pkcs11Config.Module,
-		pkcs11Config.TokenLabel, pkcs11Config.PIN, cert.PublicKey)
+
+	var members []ca.PoolMember
+	for i, pc := range pkcs11Configs {
+		hsmMembers, err := openSessions(i, pc, numSessions, cert.PublicKey)
+		if err != nil {
+			// Carry on without this HSM so that the CA can still start when
+			// one of several HSMs is unavailable.
+			deps.logger.AuditErrf("Failed to open sessions with HSM %d for issuer %q: %s",
+				i, cert.Subject.CommonName, err)
+			continue
+		}
+		members = append(members, hsmMembers...)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no HSM sessions could be opened for issuer %q", cert.Subject.CommonName)
+	}
+
+	pool, err := ca.NewSignerPool(cert.Subject.CommonName, cert.PublicKey, members, deps.clk, deps.logger, deps.metrics)
+	if err != nil {
+		return nil, err
+	}
+	interval := issuerConfig.HealthCheckInterval.Duration
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	go pool.HealthCheckLoop(interval)
+	return pool, nil
+}
+
+// readPKCS11Config reads and parses a pkcs11key.Config from configFile.
+func readPKCS11Config(configFile string) (*pkcs11key.Config, error) {
+	contents, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	pkcs11Config := new(pkcs11key.Config)
+	err = json.Unmarshal(contents, pkcs11Config)
+	if err != nil {
+		return nil, err
+	}
+	return pkcs11Config, nil
+}
+
+// tokenCheck returns a health check for the HSM described by pkcs11Config,
+// which succeeds if its token is present and answers a token info query. It
+// doesn't sign anything.
+func tokenCheck(pkcs11Config *pkcs11key.Config) (func() error, error) {
+	module := pkcs11.New(pkcs11Config.Module)
+	if module == nil {
+		return nil, fmt.Errorf("failed to load module %q", pkcs11Config.Module)
+	}
+	// pkcs11key has already initialized the module.
+	err := module.Initialize()
+	if err != nil && err != pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		return nil, fmt.Errorf("failed to initialize module: %s", err)
+	}
+	return func() error {
+		slots, err := module.GetSlotList(true)
+		if err != nil {
+			return err
+		}
+		for _, slot := range slots {
+			info, err := module.GetTokenInfo(slot)
+			if err != nil {
+				return err
+			}
+			if info.Label == pkcs11Config.TokenLabel {
+				return nil
+			}
+		}
+		return fmt.Errorf("no slot found matching token label %q", pkcs11Config.TokenLabel)
+	}, nil
+}
+
+// openSessions opens n sessions with the HSM described by pkcs11Config. If any
+// session can't be opened, the others are closed and an error is returned. We
+// stop at the first failure to avoid locking the token if, e.g., the PIN is
+// wrong.
+func openSessions(hsm int, pkcs11Config *pkcs11key.Config, n int, publicKey crypto.PublicKey) ([]ca.PoolMember, error) {
+	var keys []*pkcs11key.Key
+	for i := 0; i < n; i++ {
+		key, err := pkcs11key.New(pkcs11Config.Module, pkcs11Config.TokenLabel, pkcs11Config.PIN, publicKey)
+		if err != nil {
+			for _, k := range keys {
+				_ = k.Destroy()
+			}
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	// The health check shares the module pkcs11key initialized above.
+	check, err := tokenCheck(pkcs11Config)
+	if err != nil {
+		for _, k := range keys {
+			_ = k.Destroy()
+		}
+		return nil, err
+	}
+	var members []ca.PoolMember
+	for i, key := range keys {
+		members = append(members, ca.PoolMember{
+			Name:   fmt.Sprintf("hsm%d-session%d", hsm, i),
+			Signer: key,
+			Check:  check,
+		})
+	}
+	return members, nil
 }
 
 func main() {
@@ -152,21 +286,32 @@ func main() {
 	err = pa.SetHostnamePolicyFile(c.CA.HostnamePolicyFile)
 	cmd.FailOnError(err, "Couldn't load hostname policy file")
 
-	issuers, err := loadIssuers(c)
+	clk := cmd.Clock()
+
+	issuers, err := loadIssuers(c, signerPoolDeps{
+		clk:     clk,
+		logger:  logger,
+		metrics: ca.NewSignerPoolMetrics(scope),
+	})
 	cmd.FailOnError(err, "Couldn't load issuers")
 
 	tlsConfig, err := c.CA.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
 
-	clk := cmd.Clock()
-
 	clientMetrics := bgrpc.NewClientMetrics(scope)
 	conn, err := bgrpc.ClientSetup(c.CA.SAService, tlsConfig, clientMetrics, clk)
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
 	sa := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(conn))
 
-	kp, err := goodkey.NewKeyPolicy(c.CA.WeakKeyFile, c.CA.BlockedKeyFile, sa.KeyBlocked)
-	cmd.FailOnError(err, "Unable to create key policy")
+	var kp goodkey.KeyPolicy
+	if c.CA.KeyPolicyService != nil {
+		kpConn, err := bgrpc.ClientSetup(c.CA.KeyPolicyService, tlsConfig, clientMetrics, clk)
+		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to key policy service")
+		kp = goodkey.NewRemoteKeyPolicy(goodkeypb.NewKeyPolicyClient(kpConn))
+	} else {
+		kp, err = goodkey.NewKeyPolicy(c.CA.WeakKeyFile, c.CA.BlockedKeyFile, sa.KeyBlocked)
+		cmd.FailOnError(err, "Unable to create key policy")
+	}
 
 	var orphanQueue *goque.Queue
 	if c.CA.OrphanQueueDir != "" {
@@ -191,6 +336,8 @@ func main() {
 		go cai.OrphanIntegrationLoop()
 	}
 
+	go cai.OCSPDelegateRenewalLoop()
+
 	serverMetrics := bgrpc.NewServerMetrics(scope)
 	caSrv, caListener, err := bgrpc.NewServer(c.CA.GRPCCA, tlsConfig, serverMetrics, clk)
 	cmd.FailOnError(err, "Unable to setup CA gRPC server")
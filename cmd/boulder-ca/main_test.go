@@ -1,8 +1,13 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
+	pkcs11key "github.com/letsencrypt/pkcs11key/v4"
+
 	"github.com/letsencrypt/boulder/ca/config"
 )
 
@@ -10,7 +15,7 @@ func TestLoadIssuerSuccess(t *testing.T) {
 	signer, cert, err := loadIssuer(ca_config.IssuerConfig{
 		File:     "../../test/test-ca.key",
 		CertFile: "../../test/test-ca2.pem",
-	})
+	}, signerPoolDeps{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -26,7 +31,7 @@ func TestLoadIssuerBadKey(t *testing.T) {
 	_, _, err := loadIssuer(ca_config.IssuerConfig{
 		File:     "/dev/null",
 		CertFile: "../../test/test-ca2.pem",
-	})
+	}, signerPoolDeps{})
 	if err == nil {
 		t.Fatal("loadIssuer succeeded when loading key from /dev/null")
 	}
@@ -36,8 +41,49 @@ func TestLoadIssuerBadCert(t *testing.T) {
 	_, _, err := loadIssuer(ca_config.IssuerConfig{
 		File:     "../../test/test-ca.key",
 		CertFile: "/dev/null",
-	})
+	}, signerPoolDeps{})
 	if err == nil {
 		t.Fatal("loadIssuer succeeded when loading key from /dev/null")
 	}
 }
+
+func TestLoadIssuerAdditionalConfigFiles(t *testing.T) {
+	f, err := ioutil.TempFile("", "pkcs11-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// The additional HSM's config is read from its file and checked like the
+	// primary one, so a missing PIN is caught before any session is opened.
+	_, err = f.WriteString(`{"Module": "/usr/lib/softhsm/libsofthsm2.so", "TokenLabel": "intermediate"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, _, err = loadIssuer(ca_config.IssuerConfig{
+		PKCS11: &pkcs11key.Config{
+			Module:     "/usr/lib/softhsm/libsofthsm2.so",
+			TokenLabel: "intermediate",
+			PIN:        "1234",
+		},
+		AdditionalConfigFiles: []string{f.Name()},
+		CertFile:              "../../test/test-ca2.pem",
+	}, signerPoolDeps{})
+	if err == nil || !strings.Contains(err.Error(), "Missing a field") {
+		t.Fatalf("loadIssuer didn't reject additional config file missing a PIN: %v", err)
+	}
+
+	_, _, err = loadIssuer(ca_config.IssuerConfig{
+		PKCS11: &pkcs11key.Config{
+			Module:     "/usr/lib/softhsm/libsofthsm2.so",
+			TokenLabel: "intermediate",
+			PIN:        "1234",
+		},
+		AdditionalConfigFiles: []string{"/does/not/exist"},
+		CertFile:              "../../test/test-ca2.pem",
+	}, signerPoolDeps{})
+	if err == nil {
+		t.Fatal("loadIssuer succeeded with a missing additional config file")
+	}
+}
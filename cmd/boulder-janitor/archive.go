@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/db"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// newArchivedOrdersJob returns a batchedDBJob configured to delete rows from
+// the archivedOrders table, and their related rows, once they have been
+// archived for longer than the grace period.
+func newArchivedOrdersJob(
+	dbMap db.DatabaseMap,
+	log blog.Logger,
+	clk clock.Clock,
+	config CleanupConfig) *batchedDBJob {
+	// Orders are archived in ID order by the orders job, so the `archived`
+	// column increases along with the ID as getWork requires.
+	workQuery := `SELECT id, archived AS expires FROM archivedOrders
+		 WHERE
+		   id > :startID
+		 LIMIT :limit`
+	log.Debugf("Creating ArchivedOrders job from config: %#v", config)
+	j := &batchedDBJob{
+		db:          dbMap,
+		log:         log,
+		clk:         clk,
+		purgeBefore: config.GracePeriod.Duration,
+		workSleep:   config.WorkSleep.Duration,
+		batchSize:   config.BatchSize,
+		maxDPS:      config.MaxDPS,
+		parallelism: config.Parallelism,
+		table:       "archivedOrders",
+		workQuery:   workQuery,
+	}
+	j.deleteHandler = func(orderID int64) error {
+		return deleteArchivedOrder(j, orderID)
+	}
+	return j
+}
+
+// deleteArchivedOrder deletes the archived order with the given ID and its
+// archived requestedNames and orderToAuthz2 rows inside of a transaction.
+func deleteArchivedOrder(j *batchedDBJob, orderID int64) error {
+	_, err := db.WithTransaction(context.Background(), j.db, func(txWithCtx db.Executor) (interface{}, error) {
+		for _, t := range []string{"archivedRequestedNames", "archivedOrderToAuthz2"} {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE orderID = ?`, t)
+			res, err := txWithCtx.Exec(query, orderID)
+			if err != nil {
+				return nil, err
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			deletedStat.WithLabelValues(t).Add(float64(affected))
+		}
+		if _, err := txWithCtx.Exec(`DELETE FROM archivedOrders WHERE id = ?`, orderID); err != nil {
+			return nil, err
+		}
+		deletedStat.WithLabelValues("archivedOrders").Inc()
+		j.log.Debugf("deleted archived order ID %d and associated rows", orderID)
+		return nil, nil
+	})
+	return err
+}
+
+// newArchivedAuthz2Job returns a batchedDBJob configured to delete rows from
+// the archivedAuthz2 table once they have been archived for longer than the
+// grace period.
+func newArchivedAuthz2Job(
+	dbMap db.DatabaseMap,
+	log blog.Logger,
+	clk clock.Clock,
+	config CleanupConfig) *batchedDBJob {
+	// Authorizations are archived by expired-authz-purger2 in roughly ID order,
+	// so the `archived` column increases along with the ID closely enough for
+	// getWork.
+	workQuery := `SELECT id, archived AS expires FROM archivedAuthz2
+		 WHERE
+		   id > :startID
+		 LIMIT :limit`
+	log.Debugf("Creating ArchivedAuthz2 job from config: %#v", config)
+	return &batchedDBJob{
+		db:          dbMap,
+		log:         log,
+		clk:         clk,
+		purgeBefore: config.GracePeriod.Duration,
+		workSleep:   config.WorkSleep.Duration,
+		batchSize:   config.BatchSize,
+		maxDPS:      config.MaxDPS,
+		parallelism: config.Parallelism,
+		table:       "archivedAuthz2",
+		workQuery:   workQuery,
+	}
+}
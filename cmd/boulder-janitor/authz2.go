@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/db"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// newAuthz2Job returns a batchedDBJob configured to delete expired rows from
+// the authz2 table. Unlike the expired-authz-purger2 it doesn't archive them.
+// The orderToAuthz2 rows which reference them are deleted along with their
+// orders by the orders job.
+func newAuthz2Job(
+	dbMap db.DatabaseMap,
+	log blog.Logger,
+	clk clock.Clock,
+	config CleanupConfig) *batchedDBJob {
+	workQuery := `SELECT id, expires FROM authz2
+		 WHERE
+		   id > :startID
+		 LIMIT :limit`
+	log.Debugf("Creating Authz2 job from config: %#v", config)
+	return &batchedDBJob{
+		db:          dbMap,
+		log:         log,
+		clk:         clk,
+		purgeBefore: config.GracePeriod.Duration,
+		workSleep:   config.WorkSleep.Duration,
+		batchSize:   config.BatchSize,
+		maxDPS:      config.MaxDPS,
+		parallelism: config.Parallelism,
+		table:       "authz2",
+		workQuery:   workQuery,
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/db"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// minCertificateDERRetention is the shortest time after issuance for which
+// the certificateDER job may keep a final certificate's DER, so that
+// subscribers have time to download certificates from their orders.
+const minCertificateDERRetention = time.Hour * 24 * 7
+
+// newCertificateDERJob returns a batchedDBJob configured to prune the DER of
+// final certificates from the certificates table once config's GracePeriod has
+// passed since they were issued. Their metadata is kept, and their
+// precertificates, whose DER is kept too, stand in for them wherever Boulder
+// needs their DER. The full certificates remain available from CT logs.
+func newCertificateDERJob(
+	dbMap db.DatabaseMap,
+	log blog.Logger,
+	clk clock.Clock,
+	config CleanupConfig) *batchedDBJob {
+	workQuery := `SELECT id, issued AS expires FROM certificates
+		 WHERE
+		   id > :startID AND
+		   der != ''
+		 LIMIT :limit`
+	log.Debugf("Creating CertificateDER job from config: %#v", config)
+	j := &batchedDBJob{
+		db:             dbMap,
+		log:            log,
+		clk:            clk,
+		purgeBefore:    config.GracePeriod.Duration,
+		minPurgeBefore: minCertificateDERRetention,
+		workSleep:      config.WorkSleep.Duration,
+		batchSize:      config.BatchSize,
+		maxDPS:         config.MaxDPS,
+		parallelism:    config.Parallelism,
+		table:          "certificateDER",
+		workQuery:      workQuery,
+	}
+	j.deleteHandler = func(id int64) error {
+		_, err := j.db.Exec("UPDATE certificates SET der = '' WHERE id = ?", id)
+		if err != nil {
+			return err
+		}
+		deletedStat.WithLabelValues(j.table).Inc()
+		j.log.Debugf("pruned DER of certificate ID %d", id)
+		return nil
+	}
+	return j
+}
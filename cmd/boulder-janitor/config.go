@@ -29,6 +29,16 @@ type CleanupConfig struct {
 	MaxDPS int
 }
 
+// OrdersConfig describes the configuration of the cleanup job for the orders
+// table.
+type OrdersConfig struct {
+	CleanupConfig
+	// Archive controls whether orders and their related rows are copied to the
+	// archivedOrders, archivedRequestedNames and archivedOrderToAuthz2 tables
+	// before being deleted.
+	Archive bool
+}
+
 var (
 	errInvalidGracePeriod   = errors.New("grace period must be > 0")
 	errInvalidParallelism   = errors.New("parallelism must be > 0")
@@ -73,7 +83,33 @@ type Config struct {
 
 		// Orders describes a cleanup job for the orders table and related rows
 		// (requestedNames, orderToAuthz2, orderFqdnSets).
-		Orders CleanupConfig
+		Orders OrdersConfig
+
+		// ArchivedOrders describes a cleanup job for the archivedOrders table
+		// and related rows (archivedRequestedNames, archivedOrderToAuthz2). The
+		// GracePeriod is measured from when the order was archived.
+		ArchivedOrders CleanupConfig
+
+		// ArchivedAuthz2 describes a cleanup job for the archivedAuthz2 table.
+		// The GracePeriod is measured from when the authorization was archived.
+		ArchivedAuthz2 CleanupConfig
+
+		// Authz2 describes a cleanup job for the authz2 table. The GracePeriod
+		// is measured from when the authorization expired.
+		Authz2 CleanupConfig
+
+		// FQDNSets describes a cleanup job for the fqdnSets table. The
+		// GracePeriod is measured from when the certificate expired.
+		FQDNSets CleanupConfig
+
+		// KeyHashToSerial describes a cleanup job for the keyHashToSerial
+		// table. The GracePeriod is measured from when the certificate expired.
+		KeyHashToSerial CleanupConfig
+
+		// CertificateDER describes a job pruning the DER of final
+		// certificates, keeping their metadata. The GracePeriod is measured
+		// from when the certificate was issued, and must be at least 7 days.
+		CertificateDER CleanupConfig
 	}
 }
 
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/db"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// newFQDNSetsJob returns a batchedDBJob configured to delete rows from the
+// fqdnSets table once the certificates they record have expired.
+func newFQDNSetsJob(
+	dbMap db.DatabaseMap,
+	log blog.Logger,
+	clk clock.Clock,
+	config CleanupConfig) *batchedDBJob {
+	workQuery := `SELECT id, expires FROM fqdnSets
+		 WHERE
+		   id > :startID
+		 LIMIT :limit`
+	log.Debugf("Creating FQDNSets job from config: %#v", config)
+	return &batchedDBJob{
+		db:          dbMap,
+		log:         log,
+		clk:         clk,
+		purgeBefore: config.GracePeriod.Duration,
+		workSleep:   config.WorkSleep.Duration,
+		batchSize:   config.BatchSize,
+		maxDPS:      config.MaxDPS,
+		parallelism: config.Parallelism,
+		table:       "fqdnSets",
+		workQuery:   workQuery,
+	}
+}
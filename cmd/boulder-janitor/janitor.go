@@ -44,6 +44,7 @@ func New(clk clock.Clock, config Config) (*janitor, error) {
 	scope.MustRegister(errStat)
 	scope.MustRegister(deletedStat)
 	scope.MustRegister(workStat)
+	scope.MustRegister(archivedStat)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
@@ -52,7 +53,7 @@ func New(clk clock.Clock, config Config) (*janitor, error) {
 	if err != nil {
 		return nil, err
 	}
-	dbMap, err := sa.NewDbMap(dbURL, config.Janitor.DBConfig.MaxDBConns)
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettingsFromDBConfig(config.Janitor.DBConfig))
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +100,24 @@ func newJobs(
 	if config.Janitor.Orders.Enabled {
 		jobs = append(jobs, newOrdersJob(dbMap, logger, clk, config.Janitor.Orders))
 	}
+	if config.Janitor.ArchivedOrders.Enabled {
+		jobs = append(jobs, newArchivedOrdersJob(dbMap, logger, clk, config.Janitor.ArchivedOrders))
+	}
+	if config.Janitor.ArchivedAuthz2.Enabled {
+		jobs = append(jobs, newArchivedAuthz2Job(dbMap, logger, clk, config.Janitor.ArchivedAuthz2))
+	}
+	if config.Janitor.Authz2.Enabled {
+		jobs = append(jobs, newAuthz2Job(dbMap, logger, clk, config.Janitor.Authz2))
+	}
+	if config.Janitor.FQDNSets.Enabled {
+		jobs = append(jobs, newFQDNSetsJob(dbMap, logger, clk, config.Janitor.FQDNSets))
+	}
+	if config.Janitor.KeyHashToSerial.Enabled {
+		jobs = append(jobs, newKeyHashToSerialJob(dbMap, logger, clk, config.Janitor.KeyHashToSerial))
+	}
+	if config.Janitor.CertificateDER.Enabled {
+		jobs = append(jobs, newCertificateDERJob(dbMap, logger, clk, config.Janitor.CertificateDER))
+	}
 	// There must be at least one job
 	if len(jobs) == 0 {
 		return nil, errNoJobsConfigured
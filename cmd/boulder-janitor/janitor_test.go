@@ -47,6 +47,71 @@ func TestNewJobs(t *testing.T) {
 			"parallelism": 1
 		}
 	}
+}`
+	archiveConfig := `{
+	"janitor": {
+		"orders": {
+			"enabled": true,
+			"gracePeriod": "2184h",
+			"batchSize": 1,
+			"parallelism": 1,
+			"archive": true
+		},
+		"archivedOrders": {
+			"enabled": true,
+			"gracePeriod": "2184h",
+			"batchSize": 1,
+			"parallelism": 1
+		},
+		"archivedAuthz2": {
+			"enabled": true,
+			"gracePeriod": "2184h",
+			"batchSize": 1,
+			"parallelism": 1
+		}
+	}
+}`
+	oneTimeUseConfig := `{
+	"janitor": {
+		"authz2": {
+			"enabled": true,
+			"gracePeriod": "2184h",
+			"batchSize": 1,
+			"parallelism": 1
+		},
+		"fqdnSets": {
+			"enabled": true,
+			"gracePeriod": "2184h",
+			"batchSize": 1,
+			"parallelism": 1
+		},
+		"keyHashToSerial": {
+			"enabled": true,
+			"gracePeriod": "2184h",
+			"batchSize": 1,
+			"parallelism": 1
+		}
+	}
+}`
+	certificateDERConfig := `{
+	"janitor": {
+		"certificateDER": {
+			"enabled": true,
+			"gracePeriod": "720h",
+			"batchSize": 1,
+			"parallelism": 1
+		}
+	}
+}`
+	shortCertificateDERConfig := `{
+	"janitor": {
+		"certificateDER": {
+			"enabled": true,
+			"gracePeriod": "24h",
+			"batchSize": 1,
+			"parallelism": 1
+		}
+	}
 }`
 	testCases := []struct {
 		name              string
@@ -69,6 +134,26 @@ func TestNewJobs(t *testing.T) {
 			config:            allConfig,
 			expectedTableJobs: []string{"certificates", "certificateStatus", "certificatesPerName"},
 		},
+		{
+			name:              "orders and archive jobs enabled",
+			config:            archiveConfig,
+			expectedTableJobs: []string{"orders", "archivedOrders", "archivedAuthz2"},
+		},
+		{
+			name:              "authz2, fqdnSets and keyHashToSerial jobs enabled",
+			config:            oneTimeUseConfig,
+			expectedTableJobs: []string{"authz2", "fqdnSets", "keyHashToSerial"},
+		},
+		{
+			name:              "certificateDER job enabled",
+			config:            certificateDERConfig,
+			expectedTableJobs: []string{"certificateDER"},
+		},
+		{
+			name:          "certificateDER retention too short",
+			config:        shortCertificateDERConfig,
+			expectedError: errShortRetention,
+		},
 	}
 
 	for _, tc := range testCases {
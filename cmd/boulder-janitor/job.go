@@ -39,6 +39,15 @@ var (
 			Help: "Number of deletions by table the boulder-janitor has performed.",
 		},
 		[]string{"table"})
+	// archivedStat is a prometheus counter vector tracking the number of rows
+	// copied to archive tables by the janitor before deletion, sliced by a
+	// table label.
+	archivedStat = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "janitor_archived",
+			Help: "Number of rows by table the boulder-janitor has archived before deleting.",
+		},
+		[]string{"table"})
 	// workStat is a prometheus counter vector tracking the number of rows found
 	// during a batchedJob's getWork stage and queued into the work channel sliced
 	// by a table label.
@@ -62,6 +71,10 @@ type batchedDBJob struct {
 	// purgeBefore indicates the cut-off for the the resoruce being cleaned up by
 	// the job. Rows that older than now - purgeBefore are deleted.
 	purgeBefore time.Duration
+	// minPurgeBefore, if set, replaces the package's minPurgeBefore as the
+	// smallest purgeBefore allowed, for jobs which prune data that's no longer
+	// needed well before certificates expire.
+	minPurgeBefore time.Duration
 	// workSleep is a duration that the job will sleep between getWork() calls
 	// when no new work is found. If not provided, defaults to a minute.
 	workSleep time.Duration
@@ -84,11 +97,12 @@ type batchedDBJob struct {
 }
 
 var (
-	errNoTable       = errors.New("table must not be empty")
-	errNoPurgeBefore = fmt.Errorf("purgeBefore must be greater than %s", minPurgeBefore)
-	errNoBatchSize   = errors.New("batchSize must be > 0")
-	errNoParallelism = errors.New("parallelism must be > 0")
-	errNoWorkQuery   = errors.New("workQuery must not be empty")
+	errNoTable        = errors.New("table must not be empty")
+	errNoPurgeBefore  = fmt.Errorf("purgeBefore must be greater than %s", minPurgeBefore)
+	errShortRetention = errors.New("purgeBefore must be at least the job's minPurgeBefore")
+	errNoBatchSize    = errors.New("batchSize must be > 0")
+	errNoParallelism  = errors.New("parallelism must be > 0")
+	errNoWorkQuery    = errors.New("workQuery must not be empty")
 )
 
 // valid checks that the batchedDBJob has all required fields set correctly and
@@ -97,7 +111,11 @@ func (j *batchedDBJob) valid() error {
 	if j.table == "" {
 		return errNoTable
 	}
-	if j.purgeBefore <= minPurgeBefore {
+	if j.minPurgeBefore != 0 {
+		if j.purgeBefore < j.minPurgeBefore {
+			return errShortRetention
+		}
+	} else if j.purgeBefore <= minPurgeBefore {
 		return errNoPurgeBefore
 	}
 	if j.batchSize <= 0 {
@@ -358,6 +358,27 @@ func TestBatchedDBJobValid(t *testing.T) {
 			},
 			expectedErr: errNoWorkQuery,
 		},
+		{
+			name: "purgeBefore below job's minimum",
+			j: batchedDBJob{
+				table:          "chef's",
+				purgeBefore:    time.Hour,
+				minPurgeBefore: time.Hour * 24,
+			},
+			expectedErr: errShortRetention,
+		},
+		{
+			name: "purgeBefore above job's minimum",
+			j: batchedDBJob{
+				table:          "chef's",
+				purgeBefore:    time.Hour * 24,
+				minPurgeBefore: time.Hour * 24,
+				batchSize:      1,
+				parallelism:    1,
+				workQuery:      "GET leftovers FROM fridge",
+			},
+			expectedErr: nil,
+		},
 		{
 			name: "valid",
 			j: batchedDBJob{
@@ -378,3 +399,25 @@ func TestBatchedDBJobValid(t *testing.T) {
 		})
 	}
 }
+
+func TestPruneCertificateDER(t *testing.T) {
+	log, clk := setup()
+	testID := int64(1)
+	testDB := &mockDB{
+		t:               t,
+		expectedQuery:   "UPDATE certificates SET der = '' WHERE id = ?",
+		expectedExecArg: testID,
+	}
+	job := newCertificateDERJob(testDB, log, clk, CleanupConfig{})
+
+	// Pruning a certificate's DER updates its row rather than deleting it.
+	testDB.errResult = errors.New("database is on vacation")
+	err := job.deleteHandler(testID)
+	test.AssertError(t, err, "no error returned from deleteHandler with bad DB")
+	test.AssertEquals(t, test.CountCounterVec("table", "certificateDER", deletedStat), 0)
+
+	testDB.errResult = nil
+	err = job.deleteHandler(testID)
+	test.AssertNotError(t, err, "unexpected error from deleteHandler")
+	test.AssertEquals(t, test.CountCounterVec("table", "certificateDER", deletedStat), 1)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/db"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// newKeyHashToSerialJob returns a batchedDBJob configured to delete rows from
+// the keyHashToSerial table once the certificates they record have expired.
+// The bad-key-revoker only looks up unexpired certificates, so it never needs
+// them again.
+func newKeyHashToSerialJob(
+	dbMap db.DatabaseMap,
+	log blog.Logger,
+	clk clock.Clock,
+	config CleanupConfig) *batchedDBJob {
+	workQuery := `SELECT id, certNotAfter AS expires FROM keyHashToSerial
+		 WHERE
+		   id > :startID
+		 LIMIT :limit`
+	log.Debugf("Creating KeyHashToSerial job from config: %#v", config)
+	return &batchedDBJob{
+		db:          dbMap,
+		log:         log,
+		clk:         clk,
+		purgeBefore: config.GracePeriod.Duration,
+		workSleep:   config.WorkSleep.Duration,
+		batchSize:   config.BatchSize,
+		maxDPS:      config.MaxDPS,
+		parallelism: config.Parallelism,
+		table:       "keyHashToSerial",
+		workQuery:   workQuery,
+	}
+}
@@ -3,22 +3,27 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jmhodges/clock"
 
 	"github.com/letsencrypt/boulder/db"
+	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
 )
 
 type ordersJob struct {
 	*batchedDBJob
+	// archive controls whether orders are copied to the archive tables before
+	// being deleted.
+	archive bool
 }
 
 func newOrdersJob(
 	dbMap db.DatabaseMap,
 	log blog.Logger,
 	clk clock.Clock,
-	config CleanupConfig) *batchedDBJob {
+	config OrdersConfig) *batchedDBJob {
 	purgeBefore := config.GracePeriod.Duration
 	workQuery := `SELECT id, expires FROM orders
 		 WHERE
@@ -38,6 +43,7 @@ func newOrdersJob(
 			table:       "orders",
 			workQuery:   workQuery,
 		},
+		archive: config.Archive,
 	}
 	j.batchedDBJob.deleteHandler = j.deleteOrder
 	return j.batchedDBJob
@@ -49,8 +55,22 @@ func (j *ordersJob) deleteOrder(orderID int64) error {
 	// Either all of the rows associated with the order ID will be deleted or the
 	// transaction will be rolled back.
 	_, err := db.WithTransaction(ctx, j.db, func(txWithCtx db.Executor) (interface{}, error) {
+		// Copy the order to the archive tables first so that it is only deleted
+		// if it has been archived.
+		if j.archive {
+			if err := archiveOrder(txWithCtx, orderID, j.clk.Now()); err != nil {
+				return nil, err
+			}
+			archivedStat.WithLabelValues("orders").Inc()
+		}
 		// Delete table rows in the childTables that reference the order being deleted.
 		childTables := []string{"requestedNames", "orderFqdnSets", "orderToAuthz2"}
+		if features.Enabled(features.OrderValidityWindows) {
+			childTables = append(childTables, "orderValidityWindows")
+		}
+		if features.Enabled(features.RenewalInfo) {
+			childTables = append(childTables, "replacementOrders")
+		}
 		for _, t := range childTables {
 			query := fmt.Sprintf(`DELETE FROM %s WHERE orderID = ?`, t)
 			res, err := txWithCtx.Exec(query, orderID)
@@ -73,3 +93,32 @@ func (j *ordersJob) deleteOrder(orderID int64) error {
 	})
 	return err
 }
+
+// archiveOrder copies the order with the given ID, its requestedNames rows and
+// its orderToAuthz2 rows into the archive tables. The orderFqdnSets rows aren't
+// archived since they can be recomputed from the requested names.
+func archiveOrder(tx db.Executor, orderID int64, archived time.Time) error {
+	_, err := tx.Exec(
+		`INSERT INTO archivedOrders
+			(id, registrationID, expires, error, certificateSerial, beganProcessing,
+			 created, certificateProfileName, archived)
+		SELECT id, registrationID, expires, error, certificateSerial, beganProcessing,
+			created, certificateProfileName, ?
+		FROM orders WHERE id = ?`,
+		archived, orderID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO archivedRequestedNames (id, orderID, reversedName)
+		SELECT id, orderID, reversedName FROM requestedNames WHERE orderID = ?`,
+		orderID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO archivedOrderToAuthz2 (orderID, authzID)
+		SELECT orderID, authzID FROM orderToAuthz2 WHERE orderID = ?`,
+		orderID)
+	return err
+}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jmhodges/clock"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
@@ -18,21 +23,11 @@ import (
 	"github.com/letsencrypt/boulder/test/vars"
 )
 
-func TestDeleteOrder(t *testing.T) {
+// addTestOrder creates a registration, a pending authorization and an order
+// for that authorization using the given SA.
+func addTestOrder(t *testing.T, ssa *sa.SQLStorageAuthority, fc clock.FakeClock) *corepb.Order {
+	t.Helper()
 	ctx := context.Background()
-	log, fc := setup()
-
-	// Create one dbMap for the SA with the SA user.
-	dbMap, err := sa.NewDbMap(vars.DBConnSA, 0)
-	test.AssertNotError(t, err, "error creating db map")
-	// Create a SSA backed by the SA user dbMap
-	ssa, err := sa.NewSQLStorageAuthority(dbMap, fc, log, metrics.NoopRegisterer, 1)
-	test.AssertNotError(t, err, "error creating SA")
-
-	// Don't forget to cleanup!
-	defer func() {
-		test.ResetSATestDatabase(t)
-	}()
 
 	// Create a test registration
 	jwk := satest.GoodJWK()
@@ -75,13 +70,35 @@ func TestDeleteOrder(t *testing.T) {
 		V2Authorizations: []int64{ids.Ids[0]},
 	})
 	test.AssertNotError(t, err, "error creating test order")
+	return testOrder
+}
+
+func TestDeleteOrder(t *testing.T) {
+	ctx := context.Background()
+	log, fc := setup()
+
+	// Create one dbMap for the SA with the SA user.
+	dbMap, err := sa.NewDbMap(vars.DBConnSA, 0)
+	test.AssertNotError(t, err, "error creating db map")
+	// Create a SSA backed by the SA user dbMap
+	ssa, err := sa.NewSQLStorageAuthority(dbMap, fc, log, metrics.NoopRegisterer, 1)
+	test.AssertNotError(t, err, "error creating SA")
+
+	// Don't forget to cleanup!
+	defer func() {
+		test.ResetSATestDatabase(t)
+	}()
+
+	testOrder := addTestOrder(t, ssa, fc)
 
 	// Create a cleanup config for the Orders job
-	config := CleanupConfig{
-		WorkSleep:   cmd.ConfigDuration{Duration: time.Second},
-		BatchSize:   1,
-		MaxDPS:      1,
-		Parallelism: 1,
+	config := OrdersConfig{
+		CleanupConfig: CleanupConfig{
+			WorkSleep:   cmd.ConfigDuration{Duration: time.Second},
+			BatchSize:   1,
+			MaxDPS:      1,
+			Parallelism: 1,
+		},
 	}
 
 	// Create a dbMap for the janitor user. We don't want to use the SA dbMap
@@ -128,3 +145,128 @@ func TestDeleteOrder(t *testing.T) {
 	test.AssertNotError(t, err, "error finding orderFqdnSets rows")
 	test.AssertEquals(t, len(orderFqdnSetIDs), 0)
 }
+
+func TestArchiveOrder(t *testing.T) {
+	// The archive tables only exist in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("archive tables require config-next database schema")
+	}
+	log, fc := setup()
+
+	dbMap, err := sa.NewDbMap(vars.DBConnSA, 0)
+	test.AssertNotError(t, err, "error creating db map")
+	ssa, err := sa.NewSQLStorageAuthority(dbMap, fc, log, metrics.NoopRegisterer, 1)
+	test.AssertNotError(t, err, "error creating SA")
+	defer test.ResetSATestDatabase(t)
+
+	testOrder := addTestOrder(t, ssa, fc)
+
+	config := OrdersConfig{
+		CleanupConfig: CleanupConfig{
+			WorkSleep:   cmd.ConfigDuration{Duration: time.Second},
+			BatchSize:   1,
+			MaxDPS:      1,
+			Parallelism: 1,
+		},
+		Archive: true,
+	}
+	janitorDbMap, err := sa.NewDbMap("janitor@tcp(boulder-mysql:3306)/boulder_sa_test", 0)
+	test.AssertNotError(t, err, "error creating db map")
+
+	j := newOrdersJob(janitorDbMap, log, fc, config)
+	err = j.deleteHandler(*testOrder.Id)
+	test.AssertNotError(t, err, "error calling deleteHandler")
+
+	_, err = ssa.GetOrder(context.Background(), &sapb.OrderRequest{Id: testOrder.Id})
+	test.AssertEquals(t, berrors.Is(err, berrors.NotFound), true)
+
+	// The order and its related rows should be in the archive tables.
+	var regID int64
+	err = janitorDbMap.SelectOne(
+		&regID,
+		"SELECT registrationID FROM archivedOrders WHERE id = ?;",
+		*testOrder.Id)
+	test.AssertNotError(t, err, "error finding archived order")
+	test.AssertEquals(t, regID, *testOrder.RegistrationID)
+
+	var reversedNames []string
+	_, err = janitorDbMap.Select(
+		&reversedNames,
+		"SELECT reversedName FROM archivedRequestedNames WHERE orderID = ?;",
+		*testOrder.Id)
+	test.AssertNotError(t, err, "error finding archived requestedNames rows")
+	test.AssertDeepEquals(t, reversedNames, []string{"com.example.test"})
+
+	var authzIDs []int64
+	_, err = janitorDbMap.Select(
+		&authzIDs,
+		"SELECT authzID FROM archivedOrderToAuthz2 WHERE orderID = ?;",
+		*testOrder.Id)
+	test.AssertNotError(t, err, "error finding archived orderToAuthz2 rows")
+	test.AssertDeepEquals(t, authzIDs, testOrder.V2Authorizations)
+
+	// Once the archive grace period has passed the archived order job should
+	// remove the archived rows.
+	archiveJob := newArchivedOrdersJob(janitorDbMap, log, fc, CleanupConfig{
+		GracePeriod: cmd.ConfigDuration{Duration: minPurgeBefore + time.Hour},
+		BatchSize:   1,
+		Parallelism: 1,
+	})
+	err = archiveJob.deleteHandler(*testOrder.Id)
+	test.AssertNotError(t, err, "error calling archived order deleteHandler")
+	count, err := janitorDbMap.SelectInt(
+		"SELECT COUNT(*) FROM archivedOrderToAuthz2 WHERE orderID = ?;",
+		*testOrder.Id)
+	test.AssertNotError(t, err, "error counting archived orderToAuthz2 rows")
+	test.AssertEquals(t, count, int64(0))
+	count, err = janitorDbMap.SelectInt(
+		"SELECT COUNT(*) FROM archivedOrders WHERE id = ?;",
+		*testOrder.Id)
+	test.AssertNotError(t, err, "error counting archived orders")
+	test.AssertEquals(t, count, int64(0))
+}
+
+func TestDeleteOrderNextChildTables(t *testing.T) {
+	// The orderValidityWindows and replacementOrders tables only exist in the
+	// config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("orderValidityWindows and replacementOrders tables require config-next database schema")
+	}
+	log, fc := setup()
+
+	err := features.Set(map[string]bool{"OrderValidityWindows": true, "RenewalInfo": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	dbMap, err := sa.NewDbMap(vars.DBConnSA, 0)
+	test.AssertNotError(t, err, "error creating db map")
+	ssa, err := sa.NewSQLStorageAuthority(dbMap, fc, log, metrics.NoopRegisterer, 1)
+	test.AssertNotError(t, err, "error creating SA")
+	defer test.ResetSATestDatabase(t)
+
+	testOrder := addTestOrder(t, ssa, fc)
+	_, err = dbMap.Exec(
+		"INSERT INTO orderValidityWindows (orderID, notBefore, notAfter) VALUES (?, ?, ?)",
+		*testOrder.Id, fc.Now(), fc.Now().Add(time.Hour))
+	test.AssertNotError(t, err, "error adding orderValidityWindows row")
+	_, err = dbMap.Exec(
+		"INSERT INTO replacementOrders (serial, orderID) VALUES (?, ?)",
+		"1234", *testOrder.Id)
+	test.AssertNotError(t, err, "error adding replacementOrders row")
+
+	janitorDbMap, err := sa.NewDbMap("janitor@tcp(boulder-mysql:3306)/boulder_sa_test", 0)
+	test.AssertNotError(t, err, "error creating db map")
+	j := newOrdersJob(janitorDbMap, log, fc, OrdersConfig{
+		CleanupConfig: CleanupConfig{BatchSize: 1, Parallelism: 1},
+	})
+	err = j.deleteHandler(*testOrder.Id)
+	test.AssertNotError(t, err, "error calling deleteHandler")
+
+	for _, table := range []string{"orderValidityWindows", "replacementOrders"} {
+		count, err := janitorDbMap.SelectInt(
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE orderID = ?;", table),
+			*testOrder.Id)
+		test.AssertNotError(t, err, "error counting rows")
+		test.AssertEquals(t, count, int64(0))
+	}
+}
@@ -7,19 +7,23 @@ import (
 	"os"
 	"time"
 
+	abusepb "github.com/letsencrypt/boulder/abuse/proto"
 	akamaipb "github.com/letsencrypt/boulder/akamai/proto"
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/csr"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	"github.com/letsencrypt/boulder/ctpolicy/ctconfig"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	goodkeypb "github.com/letsencrypt/boulder/goodkey/proto"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/policy"
 	pubpb "github.com/letsencrypt/boulder/publisher/proto"
 	"github.com/letsencrypt/boulder/ra"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/ratelimits"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 )
@@ -30,6 +34,15 @@ type config struct {
 		cmd.HostnamePolicyConfig
 
 		RateLimitPoliciesFilename string
+		// RateLimitOverridesRefresh is how often the rate limit overrides
+		// stored by the SA, which are added and expired with admin-revoker,
+		// are reloaded. A zero value disables them, leaving only the policy
+		// file's.
+		RateLimitOverridesRefresh cmd.ConfigDuration
+		// RateLimitsRedis, if present, is the Redis ring in which the
+		// key-value rate limiter stores its buckets. It's used while the
+		// KeyValueRateLimits or KeyValueRateLimitsCutover feature is enabled.
+		RateLimitsRedis *cmd.RedisConfig
 
 		MaxContactsPerRegistration int
 
@@ -38,6 +51,44 @@ type config struct {
 		CAService           *cmd.GRPCClientConfig
 		PublisherService    *cmd.GRPCClientConfig
 		AkamaiPurgerService *cmd.GRPCClientConfig
+		// KeyPolicyService, if present, is used to check keys instead of the
+		// local weak and blocked key lists, which are then not loaded.
+		KeyPolicyService *cmd.GRPCClientConfig
+
+		// AntiAbuseService, if present, is an external anti-abuse system the RA
+		// consults before creating each new order. Its Timeout bounds how long
+		// order creation may be held up waiting for a decision.
+		AntiAbuseService *cmd.GRPCClientConfig
+		// AntiAbuseFailClosed causes new orders to be refused if the
+		// AntiAbuseService can't be reached or doesn't respond within its
+		// timeout. By default such orders are allowed.
+		AntiAbuseFailClosed bool
+		// AntiAbuseFailureWindow controls how far back failed authorizations are
+		// counted when describing a new order to the AntiAbuseService.
+		AntiAbuseFailureWindow cmd.ConfigDuration
+
+		// RevocationWebhookTimeout bounds each request made to a subscriber's
+		// revocation webhook. A zero value disables revocation webhooks.
+		RevocationWebhookTimeout cmd.ConfigDuration
+		// RevocationWebhookReplaceWithin is how long after an administrative
+		// revocation subscribers are told they must replace the certificate by.
+		RevocationWebhookReplaceWithin cmd.ConfigDuration
+
+		// AutoPauseThreshold is how many failed authorizations for an
+		// identifier within AutoPauseWindow cause it to be paused for the
+		// account. A zero value disables automatic pausing. Requires the
+		// PausedIdentifiers feature.
+		AutoPauseThreshold int64
+		AutoPauseWindow    cmd.ConfigDuration
+		// AutoPauseDuration is how long an automatic pause lasts if the
+		// account doesn't unpause it first. A zero value means until unpaused.
+		AutoPauseDuration cmd.ConfigDuration
+		// AutoPauseAllowlist exempts accounts, by ID, and names, along with
+		// their subdomains, from automatic pausing.
+		AutoPauseAllowlist struct {
+			RegistrationIDs []int64
+			Names           []string
+		}
 
 		MaxNames int
 
@@ -76,6 +127,43 @@ type config struct {
 		// us to comply with Chrome CT policy which requires one SCT from a
 		// Google log and one SCT from any other log included in their policy.
 		CTLogGroups2 []ctconfig.CTGroup
+		// SCTPolicy describes which SCTs must be obtained from CTLogGroups2
+		// before a final certificate is issued. If it is omitted, one SCT is
+		// required from every group.
+		SCTPolicy *ctconfig.SCTPolicy
+		// FinalSigningReserve is how much of each issuance request's deadline
+		// is kept back for signing the final certificate. If the SCTs can't be
+		// collected before then the issuance is abandoned. Zero means SCTs may
+		// be collected right up to the deadline.
+		FinalSigningReserve cmd.ConfigDuration
+		// FinalizeTimeout bounds the background issuance of orders finalized
+		// while the AsyncFinalize feature is enabled. Orders whose issuance
+		// takes longer are failed. If it is omitted, a default of five minutes
+		// is used.
+		FinalizeTimeout cmd.ConfigDuration
+		// RequestedValidity allows new orders to request the notBefore and
+		// notAfter of their certificate while the OrderValidityWindows feature
+		// is enabled.
+		RequestedValidity struct {
+			// MaxValidity is the longest validity period which may be
+			// requested for certificates of each certificate profile, keyed by
+			// profile name with "" naming the CA's default profile. It must be
+			// no longer than the CA's validity period for the profile. Orders
+			// for profiles it doesn't list can't request a notBefore or
+			// notAfter.
+			MaxValidity map[string]cmd.ConfigDuration
+			// Clamp, if true, shortens requested validity periods which exceed
+			// the maximum rather than refusing the order.
+			Clamp bool
+		}
+		// CSRPolicies configures checks which CSRs for each certificate profile
+		// must pass, in addition to those every CSR must pass, keyed by profile
+		// name with "" naming the CA's default profile.
+		CSRPolicies map[string]csr.PolicyConfig
+		// AdminRevocationsPerSecond bounds the rate at which mass revocations
+		// by the admin-revoker revoke certificates. If it is omitted, a default
+		// of ten per second is used.
+		AdminRevocationsPerSecond int
 		// InformationalCTLogs are a set of CT logs we will always submit to
 		// but won't ever use the SCTs from. This may be because we want to
 		// test them or because they are not yet approved by a browser/root
@@ -163,6 +251,7 @@ func main() {
 
 	issuerCert, err = core.LoadCert(c.RA.IssuerCertPath)
 	cmd.FailOnError(err, "Failed to load issuer certificate")
+	cmd.RegisterDebugIssuer(issuerCert)
 
 	// Boulder's components assume that there will always be CT logs configured.
 	// Issuing a certificate without SCTs embedded is a miss-issuance event in the
@@ -185,7 +274,11 @@ func main() {
 			}
 		}
 	}
-	ctp = ctpolicy.New(pubc, c.RA.CTLogGroups2, c.RA.InformationalCTLogs, logger, scope)
+	if c.RA.SCTPolicy != nil {
+		err := c.RA.SCTPolicy.Setup(c.RA.CTLogGroups2)
+		cmd.FailOnError(err, "Invalid SCTPolicy")
+	}
+	ctp = ctpolicy.New(pubc, c.RA.CTLogGroups2, c.RA.InformationalCTLogs, c.RA.SCTPolicy, logger, scope)
 
 	saConn, err := bgrpc.ClientSetup(c.RA.SAService, tlsConfig, clientMetrics, clk)
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
@@ -203,8 +296,15 @@ func main() {
 		pendingAuthorizationLifetime = time.Duration(c.RA.PendingAuthorizationLifetimeDays) * 24 * time.Hour
 	}
 
-	kp, err := goodkey.NewKeyPolicy(c.RA.WeakKeyFile, c.RA.BlockedKeyFile, sac.KeyBlocked)
-	cmd.FailOnError(err, "Unable to create key policy")
+	var kp goodkey.KeyPolicy
+	if c.RA.KeyPolicyService != nil {
+		kpConn, err := bgrpc.ClientSetup(c.RA.KeyPolicyService, tlsConfig, clientMetrics, clk)
+		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to key policy service")
+		kp = goodkey.NewRemoteKeyPolicy(goodkeypb.NewKeyPolicyClient(kpConn))
+	} else {
+		kp, err = goodkey.NewKeyPolicy(c.RA.WeakKeyFile, c.RA.BlockedKeyFile, sac.KeyBlocked)
+		cmd.FailOnError(err, "Unable to create key policy")
+	}
 
 	if c.RA.MaxNames == 0 {
 		cmd.Fail("Error in RA config: MaxNames must not be 0")
@@ -236,13 +336,89 @@ func main() {
 	rai.CA = cac
 	rai.SA = sac
 
+	if len(c.RA.CSRPolicies) > 0 {
+		err = rai.SetCSRPolicies(c.RA.CSRPolicies)
+		cmd.FailOnError(err, "Couldn't configure CSR policies")
+	}
+
+	if c.RA.RateLimitOverridesRefresh.Duration > 0 {
+		err = rai.StartRateLimitOverrides(c.RA.RateLimitOverridesRefresh.Duration)
+		cmd.FailOnError(err, "Couldn't load rate limit overrides")
+	}
+
+	if c.RA.AntiAbuseService != nil {
+		if c.RA.AntiAbuseService.Timeout.Duration <= 0 {
+			cmd.Fail("AntiAbuseService.Timeout must be positive")
+		}
+		abuseConn, err := bgrpc.ClientSetup(c.RA.AntiAbuseService, tlsConfig, clientMetrics, clk)
+		cmd.FailOnError(err, "Unable to create anti-abuse client")
+		rai.SetAntiAbuseHook(
+			abusepb.NewAntiAbuseClient(abuseConn),
+			c.RA.AntiAbuseFailClosed,
+			c.RA.AntiAbuseFailureWindow.Duration,
+		)
+	}
+
+	if c.RA.FinalSigningReserve.Duration > 0 {
+		rai.SetFinalSigningReserve(c.RA.FinalSigningReserve.Duration)
+	}
+
+	if c.RA.FinalizeTimeout.Duration > 0 {
+		rai.SetFinalizeTimeout(c.RA.FinalizeTimeout.Duration)
+	}
+
+	if c.RA.AdminRevocationsPerSecond > 0 {
+		rai.SetAdminRevocationRate(c.RA.AdminRevocationsPerSecond)
+	}
+
+	if len(c.RA.RequestedValidity.MaxValidity) > 0 {
+		maxValidity := make(map[string]time.Duration, len(c.RA.RequestedValidity.MaxValidity))
+		for profile, validity := range c.RA.RequestedValidity.MaxValidity {
+			if validity.Duration <= 0 {
+				cmd.Fail(fmt.Sprintf("RequestedValidity.MaxValidity for profile %q must be positive", profile))
+			}
+			maxValidity[profile] = validity.Duration
+		}
+		rai.SetRequestedValidity(maxValidity, c.RA.RequestedValidity.Clamp)
+	}
+
+	if c.RA.RateLimitsRedis != nil {
+		ring, err := c.RA.RateLimitsRedis.NewRing()
+		cmd.FailOnError(err, "Failed to create Redis client for rate limits")
+		rai.SetKeyValueRateLimiter(ratelimits.NewLimiter(clk, ratelimits.NewRedisSource(ring, clk), scope))
+	}
+
+	if c.RA.RevocationWebhookTimeout.Duration > 0 {
+		rai.SetRevocationWebhooks(
+			c.RA.RevocationWebhookTimeout.Duration,
+			c.RA.RevocationWebhookReplaceWithin.Duration,
+		)
+	}
+
+	if c.RA.AutoPauseThreshold > 0 {
+		if c.RA.AutoPauseWindow.Duration <= 0 {
+			cmd.Fail("AutoPauseWindow must be positive when AutoPauseThreshold is set")
+		}
+		if c.RA.AutoPauseDuration.Duration < 0 {
+			cmd.Fail("AutoPauseDuration must not be negative")
+		}
+		rai.SetAutoPause(c.RA.AutoPauseThreshold, c.RA.AutoPauseWindow.Duration, c.RA.AutoPauseDuration.Duration)
+		rai.SetAutoPauseAllowlist(c.RA.AutoPauseAllowlist.RegistrationIDs, c.RA.AutoPauseAllowlist.Names)
+	}
+
 	serverMetrics := bgrpc.NewServerMetrics(scope)
 	grpcSrv, listener, err := bgrpc.NewServer(c.RA.GRPC, tlsConfig, serverMetrics, clk)
 	cmd.FailOnError(err, "Unable to setup RA gRPC server")
 	gw := bgrpc.NewRegistrationAuthorityServer(rai)
 	rapb.RegisterRegistrationAuthorityServer(grpcSrv, gw)
 
-	go cmd.CatchSignals(logger, grpcSrv.GracefulStop)
+	go cmd.CatchSignals(logger, func() {
+		grpcSrv.GracefulStop()
+		// Orders being finalized in the background would otherwise be left
+		// processing forever.
+		rai.WaitForFinalizations()
+		rai.WaitForRevocationNotifications()
+	})
 
 	err = cmd.FilterShutdownErrors(grpcSrv.Serve(listener))
 	cmd.FailOnError(err, "RA gRPC service failed")
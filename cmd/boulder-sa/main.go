@@ -2,11 +2,20 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/db"
+	eabpb "github.com/letsencrypt/boulder/eab/proto"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -20,11 +29,52 @@ type config struct {
 
 		// Max simultaneous SQL queries caused by a single RPC.
 		ParallelismPerRPC int
+
+		// ReadReplicas are read-only replicas of the database which serve
+		// reads that tolerate replication lag, like GetRevokedCertsByShard.
+		ReadReplicas []cmd.DBConfig
+		// MaxReplicaLag is how far behind the primary a read replica may be
+		// and still be used. Defaults to 5 seconds.
+		MaxReplicaLag cmd.ConfigDuration
+
+		// SlowQueryThreshold, if set, makes the SA log each database query
+		// which takes longer than it to run, along with the query's
+		// fingerprint and the RPC which made it.
+		SlowQueryThreshold cmd.ConfigDuration
+
+		// StreamLimits overrides the batch size, read ahead and rate of the
+		// SA's streaming RPCs, keyed by RPC name, e.g. "GetSerialsByKeyHash".
+		StreamLimits map[string]sa.StreamLimits
+
+		// RateLimitsDB, if set, is a separate database holding the rate limit
+		// counter tables, certificatesPerName and newOrdersRL, with its own
+		// connection pool.
+		RateLimitsDB *cmd.DBConfig
+		// IncidentsDB, if set, is a separate database holding the incidents
+		// and incidentSerials tables, with its own connection pool.
+		IncidentsDB *cmd.DBConfig
+
+		// SerialPrefixes, if set, are the prefixes, in lowercase hex, with
+		// which every serial the SA stores must start: one for each CA
+		// instance's serial prefix.
+		SerialPrefixes []string
 	}
 
 	Syslog cmd.SyslogConfig
 }
 
+// newDbMap connects to the database described by conf, reporting its metrics
+// as those of the named pool.
+func newDbMap(conf cmd.DBConfig, pool string, slowQueryThreshold time.Duration, scope prometheus.Registerer, logger blog.Logger) *db.WrappedMap {
+	dbURL, err := conf.URL()
+	cmd.FailOnError(err, fmt.Sprintf("Couldn't load %s DB URL", pool))
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettingsFromDBConfig(conf))
+	cmd.FailOnError(err, fmt.Sprintf("Couldn't connect to %s database", pool))
+	sa.InitDBMetrics(dbMap, scope, dbURL, pool)
+	dbMap.SetSlowQueryLog(slowQueryThreshold, logger)
+	return dbMap
+}
+
 func main() {
 	grpcAddr := flag.String("addr", "", "gRPC listen address override")
 	debugAddr := flag.String("debug-addr", "", "Debug server address override")
@@ -58,11 +108,12 @@ func main() {
 	dbURL, err := saConf.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
 
-	dbMap, err := sa.NewDbMap(dbURL, saConf.DBConfig.MaxDBConns)
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettingsFromDBConfig(saConf.DBConfig))
 	cmd.FailOnError(err, "Couldn't connect to SA database")
 
 	// Collect and periodically report DB metrics using the DBMap and prometheus scope.
-	sa.InitDBMetrics(dbMap, scope)
+	sa.InitDBMetrics(dbMap, scope, dbURL, "primary")
+	dbMap.SetSlowQueryLog(saConf.SlowQueryThreshold.Duration, logger)
 
 	clk := cmd.Clock()
 
@@ -72,6 +123,28 @@ func main() {
 	}
 	sai, err := sa.NewSQLStorageAuthority(dbMap, clk, logger, scope, parallel)
 	cmd.FailOnError(err, "Failed to create SA impl")
+	sai.SetStreamLimits(saConf.StreamLimits)
+	err = sai.SetSerialPrefixes(saConf.SerialPrefixes)
+	cmd.FailOnError(err, "Invalid serial prefixes")
+
+	if saConf.RateLimitsDB != nil {
+		sai.SetRateLimitsDB(newDbMap(*saConf.RateLimitsDB, "ratelimits", saConf.SlowQueryThreshold.Duration, scope, logger))
+	}
+	if saConf.IncidentsDB != nil {
+		sai.SetIncidentsDB(newDbMap(*saConf.IncidentsDB, "incidents", saConf.SlowQueryThreshold.Duration, scope, logger))
+	}
+
+	if len(saConf.ReadReplicas) > 0 {
+		var replicas []*db.WrappedMap
+		for i, replicaConf := range saConf.ReadReplicas {
+			replicas = append(replicas, newDbMap(replicaConf, fmt.Sprintf("replica-%d", i), saConf.SlowQueryThreshold.Duration, scope, logger))
+		}
+		maxLag := saConf.MaxReplicaLag.Duration
+		if maxLag == 0 {
+			maxLag = 5 * time.Second
+		}
+		sai.SetReadReplicas(replicas, maxLag, scope)
+	}
 
 	tls, err := c.SA.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
@@ -80,8 +153,16 @@ func main() {
 	cmd.FailOnError(err, "Unable to setup SA gRPC server")
 	gw := bgrpc.NewStorageAuthorityServer(sai)
 	sapb.RegisterStorageAuthorityServer(grpcSrv, gw)
-
-	go cmd.CatchSignals(logger, grpcSrv.GracefulStop)
+	eabpb.RegisterExternalAccountKeysServer(grpcSrv, sai)
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(grpcSrv, healthSrv)
+	sai.StartHealthChecks(healthSrv)
+
+	go cmd.CatchSignals(logger, func() {
+		// Tell clients to stop sending RPCs before draining the ones in flight.
+		healthSrv.Shutdown()
+		grpcSrv.GracefulStop()
+	})
 
 	err = cmd.FilterShutdownErrors(grpcSrv.Serve(listener))
 	cmd.FailOnError(err, "SA gRPC service failed")
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/sfe"
+)
+
+type config struct {
+	SFE struct {
+		cmd.ServiceConfig
+		ListenAddress string
+
+		// UnpauseKey is the key unpause tokens are MACed with. It must match
+		// the WFE's Unpause.Key.
+		UnpauseKey cmd.PasswordConfig
+
+		// ShutdownStopTimeout is how long in-flight requests are given to
+		// finish once the SFE's listener is closed.
+		ShutdownStopTimeout cmd.ConfigDuration
+
+		TLS cmd.TLSConfig
+
+		RAService *cmd.GRPCClientConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+
+	err = features.Set(c.SFE.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	stats, logger := cmd.StatsAndLogging(c.Syslog, c.SFE.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	clk := cmd.Clock()
+
+	unpauseKey, err := c.SFE.UnpauseKey.Pass()
+	cmd.FailOnError(err, "Failed to load unpause key")
+	if unpauseKey == "" {
+		cmd.Fail("UnpauseKey must not be empty")
+	}
+
+	tlsConfig, err := c.SFE.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+	clientMetrics := bgrpc.NewClientMetrics(stats)
+	raConn, err := bgrpc.ClientSetup(c.SFE.RAService, tlsConfig, clientMetrics, clk)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
+	rac := bgrpc.NewRegistrationAuthorityClient(rapb.NewRegistrationAuthorityClient(raConn))
+
+	sfei := sfe.NewSelfServiceFrontEndImpl(clk, logger, rac, []byte(unpauseKey))
+	sfei.RequestTimeout = c.SFE.RAService.Timeout.Duration
+
+	logger.Infof("Server running, listening on %s....", c.SFE.ListenAddress)
+	srv := http.Server{
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 120 * time.Second,
+		IdleTimeout:  120 * time.Second,
+		Addr:         c.SFE.ListenAddress,
+		Handler:      sfei.Handler(),
+	}
+
+	go func() {
+		err := srv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			cmd.FailOnError(err, "Running HTTP server")
+		}
+	}()
+
+	done := make(chan bool)
+	go cmd.CatchSignals(logger, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.SFE.ShutdownStopTimeout.Duration)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+		done <- true
+	})
+
+	// https://godoc.org/net/http#Server.Shutdown:
+	// When Shutdown is called, Serve, ListenAndServe, and ListenAndServeTLS
+	// immediately return ErrServerClosed. Make sure the program doesn't exit and
+	// waits instead for Shutdown to return.
+	<-done
+}
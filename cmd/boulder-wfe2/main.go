@@ -16,10 +16,14 @@ import (
 	"github.com/jmhodges/clock"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/eab"
+	eabpb "github.com/letsencrypt/boulder/eab/proto"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	goodkeypb "github.com/letsencrypt/boulder/goodkey/proto"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/nonce"
 	noncepb "github.com/letsencrypt/boulder/nonce/proto"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
@@ -38,6 +42,28 @@ type config struct {
 
 		AllowOrigins []string
 
+		// AllowLegacyGET lists the types of resource which may still be fetched
+		// with unauthenticated GET requests while the MandatoryPOSTAsGET
+		// feature flag is enabled, to give old clients a transition period.
+		// Accounts are always POST-only.
+		AllowLegacyGET struct {
+			Authorizations bool
+			Orders         bool
+			Certificates   bool
+		}
+
+		// JSONAccessLogs, if true, makes the WFE log each request as a single
+		// JSON object, with a request ID, rather than as space-separated fields
+		// followed by JSON.
+		JSONAccessLogs bool
+
+		// ShutdownDrainDelay is how long, after a shutdown signal is caught,
+		// the WFE keeps accepting new connections while its /healthz endpoint
+		// reports it as unhealthy, so that load balancers can stop sending it
+		// requests before its listeners are closed.
+		ShutdownDrainDelay cmd.ConfigDuration
+		// ShutdownStopTimeout is how long in-flight requests are given to
+		// finish once the WFE's listeners are closed.
 		ShutdownStopTimeout cmd.ConfigDuration
 
 		SubscriberAgreementURL string
@@ -46,6 +72,9 @@ type config struct {
 
 		RAService *cmd.GRPCClientConfig
 		SAService *cmd.GRPCClientConfig
+		// KeyPolicyService, if present, is used to check keys instead of the
+		// local blocked key list, which is then not loaded.
+		KeyPolicyService *cmd.GRPCClientConfig
 		// GetNonceService contains a gRPC config for any nonce-service instances
 		// which we want to retrieve nonces from. In a multi-DC deployment this
 		// should refer to any local nonce-service instances.
@@ -54,7 +83,15 @@ type config struct {
 		// gRPC configs we want to use to redeem nonces. In a multi-DC deployment
 		// this should contain all nonce-services from all DCs as we want to be
 		// able to redeem nonces generated at any DC.
+		//
+		// Nonces whose prefix isn't in the map are sent to the "any" entry, if
+		// there is one. If NoncePrefixKey is set, the prefix of every other
+		// entry is derived from its serverAddress, which must be the address
+		// that nonce-service instance listens on, and the map keys are ignored.
 		RedeemNonceServices map[string]cmd.GRPCClientConfig
+		// NoncePrefixKey is the key shared with the nonce-service instances
+		// from which they derive their prefixes. See nonce.DerivePrefix.
+		NoncePrefixKey cmd.PasswordConfig
 
 		// CertificateChains maps AIA issuer URLs to certificate filenames.
 		// Certificates are read into the chain in the order they are defined in the
@@ -67,13 +104,69 @@ type config struct {
 
 		Features map[string]bool
 
-		// DirectoryCAAIdentity is used for the /directory response's "meta"
-		// element's "caaIdentities" field. It should match the VA's "issuerDomain"
-		// configuration value (this value is the one used to enforce CAA)
-		DirectoryCAAIdentity string
+		// DirectoryCAAIdentities are used for the /directory response's "meta"
+		// element's "caaIdentities" field. They should be the CAA issuer domains
+		// the VA enforces CAA with, i.e. its "issuerDomain" and "issuerDomains".
+		// DirectoryCAAIdentity is a deprecated single-identity form, which is
+		// listed first if set.
+		DirectoryCAAIdentities []string
+		DirectoryCAAIdentity   string
+		// VAConfigFile, if set, is the path to the VA's configuration file. The
+		// WFE reads the VA's issuer domains from it at startup and refuses to
+		// start if they differ from the directory's CAA identities.
+		VAConfigFile string
 		// DirectoryWebsite is used for the /directory response's "meta" element's
 		// "website" field.
 		DirectoryWebsite string
+		// CertificateProfiles is a map of certificate profile names to
+		// descriptions. The names are advertised in the /directory response's
+		// "meta" element's "profiles" field and are the only values accepted in
+		// a new order's "profile" field. Each name must also be configured in
+		// the CA's "certProfiles".
+		CertificateProfiles map[string]string
+		// CertificateProfileAccounts restricts each certificate profile it
+		// names to the listed account IDs, for instance those of subscribers
+		// given external account binding keys for it. Each name must also be
+		// in CertificateProfiles.
+		CertificateProfileAccounts map[string][]int64
+
+		// OrdersPageSize is the number of order URLs in each page of an
+		// account's orders list. If zero, the orders list endpoint is disabled
+		// and accounts have no "orders" URL.
+		OrdersPageSize int
+
+		// BlockRolloverToBlockedKeys makes key rollover refuse new keys in the
+		// SA's blocked keys table, such as keys whose certificates were revoked
+		// for key compromise, even when the KeyPolicyService doesn't.
+		BlockRolloverToBlockedKeys bool
+
+		// ExpectedValidationDuration is how long a validation, including the
+		// remote VAs' multi-perspective checks, usually takes. It's sent as
+		// the Retry-After of challenges which are still processing. If zero, a
+		// default of five seconds is used.
+		ExpectedValidationDuration cmd.ConfigDuration
+
+		// ExternalAccountBinding, if present, makes an external account binding
+		// mandatory for new accounts. The MAC keys bindings are verified with
+		// are looked up using KeyService if it is present, and otherwise taken
+		// from StaticKeys, a map of key IDs to base64url encoded keys.
+		// KeyService may point to the SA, which serves keys from its
+		// externalAccountKeys table, or to an external account management
+		// system implementing the same gRPC service.
+		ExternalAccountBinding *struct {
+			StaticKeys map[string]string
+			KeyService *cmd.GRPCClientConfig
+		}
+
+		// Unpause, if present, makes paused errors link to the self-service
+		// frontend's unpause page at URL. The link carries a token, MACed with
+		// Key, which must match the self-service frontend's UnpauseKey, and
+		// valid for TokenLifetime.
+		Unpause *struct {
+			URL           string
+			Key           cmd.PasswordConfig
+			TokenLifetime cmd.ConfigDuration
+		}
 
 		// ACMEv2 requests (outside some registration/revocation messages) use a JWS with
 		// a KeyID header containing the full account URL. For new accounts this
@@ -92,6 +185,39 @@ type config struct {
 		// StaleTimeout determines how old should data be to be accessed via Boulder-specific GET-able APIs
 		StaleTimeout cmd.ConfigDuration
 
+		// EndpointLimits sets the request timeout and maximum request body
+		// size of individual endpoints, keyed by the path they are served at
+		// (e.g. "/acme/finalize/"). Endpoints without an entry, or with a zero
+		// value for either limit, use a five minute timeout and a 50000 byte
+		// maximum body size.
+		EndpointLimits map[string]struct {
+			Timeout        cmd.ConfigDuration
+			MaxRequestSize int64
+		}
+
+		// MaxConcurrentRequestsPerIP and MaxConcurrentRequestsPerAccount bound
+		// the number of requests in flight from a single source IP and, once
+		// requests are authenticated, for a single account. Requests beyond
+		// them are refused with a rateLimited problem. Zero is no limit.
+		MaxConcurrentRequestsPerIP      int
+		MaxConcurrentRequestsPerAccount int
+
+		// CompressEndpoints lists the paths of endpoints (e.g. "/directory")
+		// whose JSON and PEM responses are gzip or deflate compressed for
+		// clients which accept it. Endpoints whose responses mix secrets with
+		// attacker-influenced content shouldn't be listed, to avoid
+		// BREACH-style attacks.
+		CompressEndpoints []string
+
+		// StorageCacheTTL is the longest time for which registrations,
+		// authorizations and orders fetched from the SA are cached. Objects
+		// changed by this WFE are removed from the cache immediately, so this
+		// bounds how stale a response can be after a change made elsewhere,
+		// including how long a key rolled over or an account deactivated
+		// through another WFE can still authenticate requests here. A zero
+		// value disables the cache.
+		StorageCacheTTL cmd.ConfigDuration
+
 		// AuthorizationLifetimeDays defines how long authorizations will be
 		// considered valid for. The WFE uses this to find the creation date of
 		// authorizations by subtracing this value from the expiry. It should match
@@ -225,7 +351,46 @@ func loadCertificateChains(chainConfig map[string][]string, requireAtLeastOneCha
 	return results, issuerCerts, nil
 }
 
-func setupWFE(c config, logger blog.Logger, stats prometheus.Registerer, clk clock.Clock) (core.RegistrationAuthority, core.StorageAuthority, noncepb.NonceServiceClient, map[string]noncepb.NonceServiceClient) {
+// checkCAAIdentities returns an error unless identities are exactly the CAA
+// issuer domains that the VA configured by vaConfigFile treats as identifying
+// this CA, so that the directory never advertises an identity which CAA
+// records can't actually authorize, or omits one which they can.
+func checkCAAIdentities(identities []string, vaConfigFile string) error {
+	var vaConfig struct {
+		VA struct {
+			IssuerDomain  string
+			IssuerDomains []string
+		}
+	}
+	err := cmd.ReadConfigFile(vaConfigFile, &vaConfig)
+	if err != nil {
+		return fmt.Errorf("reading VA config: %s", err)
+	}
+	issuerDomains := vaConfig.VA.IssuerDomains
+	if vaConfig.VA.IssuerDomain != "" {
+		issuerDomains = append([]string{vaConfig.VA.IssuerDomain}, issuerDomains...)
+	}
+
+	advertised := make(map[string]bool, len(identities))
+	for _, identity := range identities {
+		advertised[identity] = true
+	}
+	enforced := make(map[string]bool, len(issuerDomains))
+	for _, domain := range issuerDomains {
+		enforced[domain] = true
+		if !advertised[domain] {
+			return fmt.Errorf("VA issuer domain %q is missing from the directory's CAA identities", domain)
+		}
+	}
+	for _, identity := range identities {
+		if !enforced[identity] {
+			return fmt.Errorf("directory CAA identity %q is not an issuer domain of the VA", identity)
+		}
+	}
+	return nil
+}
+
+func setupWFE(c config, logger blog.Logger, stats prometheus.Registerer, clk clock.Clock) (core.RegistrationAuthority, core.StorageAuthority, noncepb.NonceServiceClient, map[string]noncepb.NonceServiceClient, goodkey.KeyPolicy, eab.KeyStore) {
 	tlsConfig, err := c.WFE.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
 	clientMetrics := bgrpc.NewClientMetrics(stats)
@@ -243,15 +408,46 @@ func setupWFE(c config, logger blog.Logger, stats prometheus.Registerer, clk clo
 		rnsConn, err := bgrpc.ClientSetup(c.WFE.GetNonceService, tlsConfig, clientMetrics, clk)
 		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to get nonce service")
 		rns = noncepb.NewNonceServiceClient(rnsConn)
+		prefixKey, err := c.WFE.NoncePrefixKey.Pass()
+		cmd.FailOnError(err, "Failed to load nonce prefix key")
 		for prefix, serviceConfig := range c.WFE.RedeemNonceServices {
 			serviceConfig := serviceConfig
+			if prefixKey != "" && prefix != nonce.AnyPrefix {
+				prefix = nonce.DerivePrefix(serviceConfig.ServerAddress, prefixKey)
+			}
+			if _, present := npm[prefix]; present {
+				cmd.Fail(fmt.Sprintf("Multiple redeem nonce services have the prefix %q", prefix))
+			}
 			conn, err := bgrpc.ClientSetup(&serviceConfig, tlsConfig, clientMetrics, clk)
 			cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to redeem nonce service")
 			npm[prefix] = noncepb.NewNonceServiceClient(conn)
 		}
 	}
 
-	return rac, sac, rns, npm
+	var kp goodkey.KeyPolicy
+	if c.WFE.KeyPolicyService != nil {
+		kpConn, err := bgrpc.ClientSetup(c.WFE.KeyPolicyService, tlsConfig, clientMetrics, clk)
+		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to key policy service")
+		kp = goodkey.NewRemoteKeyPolicy(goodkeypb.NewKeyPolicyClient(kpConn))
+	} else {
+		// don't load any weak keys, but do load blocked keys
+		kp, err = goodkey.NewKeyPolicy("", c.WFE.BlockedKeyFile, sac.KeyBlocked)
+		cmd.FailOnError(err, "Unable to create key policy")
+	}
+
+	var eabKeys eab.KeyStore
+	if c.WFE.ExternalAccountBinding != nil {
+		if c.WFE.ExternalAccountBinding.KeyService != nil {
+			eabConn, err := bgrpc.ClientSetup(c.WFE.ExternalAccountBinding.KeyService, tlsConfig, clientMetrics, clk)
+			cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to external account keys service")
+			eabKeys = eab.NewRemoteKeyStore(eabpb.NewExternalAccountKeysClient(eabConn))
+		} else {
+			eabKeys, err = eab.NewStaticKeyStore(c.WFE.ExternalAccountBinding.StaticKeys)
+			cmd.FailOnError(err, "Unable to load external account keys")
+		}
+	}
+
+	return rac, sac, rns, npm, kp, eabKeys
 }
 
 type errorWriter struct {
@@ -289,6 +485,9 @@ func main() {
 
 	certChains, issuerCerts, err := loadCertificateChains(c.WFE.CertificateChains, true)
 	cmd.FailOnError(err, "Couldn't read configured CertificateChains")
+	for _, cert := range issuerCerts {
+		cmd.RegisterDebugIssuer(cert)
+	}
 
 	for aiaURL, chainPEM := range certChains {
 		allCertChains[aiaURL] = [][]byte{chainPEM}
@@ -316,10 +515,7 @@ func main() {
 
 	clk := cmd.Clock()
 
-	rac, sac, rns, npm := setupWFE(c, logger, stats, clk)
-	// don't load any weak keys, but do load blocked keys
-	kp, err := goodkey.NewKeyPolicy("", c.WFE.BlockedKeyFile, sac.KeyBlocked)
-	cmd.FailOnError(err, "Unable to create key policy")
+	rac, sac, rns, npm, kp, eabKeys := setupWFE(c, logger, stats, clk)
 
 	if c.WFE.StaleTimeout.Duration == 0 {
 		c.WFE.StaleTimeout.Duration = time.Minute * 10
@@ -339,12 +535,64 @@ func main() {
 	cmd.FailOnError(err, "Unable to create WFE")
 	wfe.RA = rac
 	wfe.SA = sac
+	if c.WFE.StorageCacheTTL.Duration > 0 {
+		wfe.EnableStorageCache(c.WFE.StorageCacheTTL.Duration)
+	}
 
 	wfe.SubscriberAgreementURL = c.WFE.SubscriberAgreementURL
 	wfe.AllowOrigins = c.WFE.AllowOrigins
-	wfe.DirectoryCAAIdentity = c.WFE.DirectoryCAAIdentity
+	wfe.JSONAccessLogs = c.WFE.JSONAccessLogs
+	wfe.AllowLegacyGET = wfe2.LegacyGETAllowances(c.WFE.AllowLegacyGET)
+	caaIdentities := c.WFE.DirectoryCAAIdentities
+	if c.WFE.DirectoryCAAIdentity != "" {
+		caaIdentities = append([]string{c.WFE.DirectoryCAAIdentity}, caaIdentities...)
+	}
+	if c.WFE.VAConfigFile != "" {
+		err = checkCAAIdentities(caaIdentities, c.WFE.VAConfigFile)
+		cmd.FailOnError(err, "Directory CAA identities don't match the VA's")
+	}
+	wfe.DirectoryCAAIdentities = caaIdentities
 	wfe.DirectoryWebsite = c.WFE.DirectoryWebsite
+	wfe.CertificateProfiles = c.WFE.CertificateProfiles
+	for profile := range c.WFE.CertificateProfileAccounts {
+		if _, ok := c.WFE.CertificateProfiles[profile]; !ok {
+			cmd.Fail(fmt.Sprintf("CertificateProfileAccounts names unknown profile %q", profile))
+		}
+	}
+	wfe.CertificateProfileAccounts = c.WFE.CertificateProfileAccounts
+	wfe.OrdersPageSize = c.WFE.OrdersPageSize
+	wfe.ExpectedValidationDuration = c.WFE.ExpectedValidationDuration.Duration
+	wfe.BlockRolloverToBlockedKeys = c.WFE.BlockRolloverToBlockedKeys
 	wfe.LegacyKeyIDPrefix = c.WFE.LegacyKeyIDPrefix
+	if len(c.WFE.EndpointLimits) > 0 {
+		wfe.EndpointLimits = make(map[string]wfe2.EndpointLimit, len(c.WFE.EndpointLimits))
+		for path, limit := range c.WFE.EndpointLimits {
+			wfe.EndpointLimits[path] = wfe2.EndpointLimit{
+				Timeout:        limit.Timeout.Duration,
+				MaxRequestSize: limit.MaxRequestSize,
+			}
+		}
+	}
+	if c.WFE.MaxConcurrentRequestsPerIP > 0 || c.WFE.MaxConcurrentRequestsPerAccount > 0 {
+		wfe.LimitConcurrentRequests(c.WFE.MaxConcurrentRequestsPerIP, c.WFE.MaxConcurrentRequestsPerAccount)
+	}
+	if len(c.WFE.CompressEndpoints) > 0 {
+		wfe.CompressEndpoints = make(map[string]bool, len(c.WFE.CompressEndpoints))
+		for _, path := range c.WFE.CompressEndpoints {
+			wfe.CompressEndpoints[path] = true
+		}
+	}
+	if eabKeys != nil {
+		wfe.RequireExternalAccountBinding(eabKeys)
+	}
+	if c.WFE.Unpause != nil {
+		unpauseKey, err := c.WFE.Unpause.Key.Pass()
+		cmd.FailOnError(err, "Failed to load unpause key")
+		if unpauseKey == "" || c.WFE.Unpause.TokenLifetime.Duration <= 0 {
+			cmd.Fail("Unpause requires a Key and a positive TokenLifetime")
+		}
+		wfe.EnableUnpause(c.WFE.Unpause.URL, []byte(unpauseKey), c.WFE.Unpause.TokenLifetime.Duration)
+	}
 
 	wfe.IssuerCert, err = cmd.LoadCert(c.Common.IssuerCert)
 	cmd.FailOnError(err, fmt.Sprintf("Couldn't read issuer cert [%s]", c.Common.IssuerCert))
@@ -388,6 +636,15 @@ func main() {
 
 	done := make(chan bool)
 	go cmd.CatchSignals(logger, func() {
+		wfe.StartDraining()
+		if c.WFE.ShutdownDrainDelay.Duration > 0 {
+			// Ask clients to reconnect, so that they pick a healthy instance,
+			// rather than keep using their existing connections to this one.
+			srv.SetKeepAlivesEnabled(false)
+			tlsSrv.SetKeepAlivesEnabled(false)
+			logger.Infof("Draining for %s before shutting down", c.WFE.ShutdownDrainDelay.Duration)
+			time.Sleep(c.WFE.ShutdownDrainDelay.Duration)
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), c.WFE.ShutdownStopTimeout.Duration)
 		defer cancel()
 		_ = srv.Shutdown(ctx)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
@@ -189,3 +190,30 @@ func TestLoadCertificateChains(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckCAAIdentities(t *testing.T) {
+	// The VA config used by the integration tests names a single issuer
+	// domain.
+	vaConfig := "../../test/config-next/va.json"
+	err := checkCAAIdentities([]string{"happy-hacker-ca.invalid"}, vaConfig)
+	test.AssertNotError(t, err, "CAA identities matching the VA's were rejected")
+
+	err = checkCAAIdentities(nil, vaConfig)
+	test.AssertError(t, err, "CAA identities missing the VA's issuer domain were accepted")
+	err = checkCAAIdentities([]string{"happy-hacker-ca.invalid", "other-ca.invalid"}, vaConfig)
+	test.AssertError(t, err, "CAA identities the VA doesn't enforce were accepted")
+	err = checkCAAIdentities([]string{"happy-hacker-ca.invalid"}, "/tmp/does.not.exist.json")
+	test.AssertError(t, err, "Missing VA config was accepted")
+
+	// Additional issuer domains count too.
+	multiConfig, err := ioutil.TempFile("", "va.json")
+	test.AssertNotError(t, err, "Creating temporary VA config")
+	defer os.Remove(multiConfig.Name())
+	_, err = multiConfig.WriteString(`{"va": {"issuerDomain": "a.invalid", "issuerDomains": ["b.invalid"]}}`)
+	test.AssertNotError(t, err, "Writing temporary VA config")
+	multiConfig.Close()
+	err = checkCAAIdentities([]string{"b.invalid", "a.invalid"}, multiConfig.Name())
+	test.AssertNotError(t, err, "CAA identities matching the VA's were rejected")
+	err = checkCAAIdentities([]string{"a.invalid"}, multiConfig.Name())
+	test.AssertError(t, err, "CAA identities missing an additional issuer domain were accepted")
+}
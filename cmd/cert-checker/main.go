@@ -154,6 +154,10 @@ func (c *certChecker) getCerts(unexpiredOnly bool) error {
 			return err
 		}
 		for _, cert := range certs {
+			// Certificates whose DER has been pruned can't be checked.
+			if len(cert.DER) == 0 {
+				continue
+			}
 			c.certs <- cert.Certificate
 		}
 		if len(certs) == 0 {
@@ -373,10 +377,10 @@ func main() {
 
 	saDbURL, err := config.CertChecker.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	saDbMap, err := sa.NewDbMap(saDbURL, config.CertChecker.DBConfig.MaxDBConns)
+	saDbMap, err := sa.NewDbMapWithSettings(saDbURL, sa.DbSettingsFromDBConfig(config.CertChecker.DBConfig))
 	cmd.FailOnError(err, "Could not connect to database")
 
-	sa.InitDBMetrics(saDbMap, prometheus.DefaultRegisterer)
+	sa.InitDBMetrics(saDbMap, prometheus.DefaultRegisterer, saDbURL, "primary")
 
 	checkerLatency := prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: "cert_checker_latency",
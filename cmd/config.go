@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-redis/redis/v7"
+
 	"github.com/letsencrypt/boulder/core"
 )
 
@@ -50,6 +52,16 @@ type DBConfig struct {
 	// A file containing a connect URL for the DB.
 	DBConnectFile string
 	MaxDBConns    int
+
+	// MaxIdleConns is the maximum number of idle connections kept open to the
+	// DB. If zero, database/sql's default of 2 is used.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused
+	// before it's closed. If zero, connections are reused forever.
+	ConnMaxLifetime ConfigDuration
+	// ConnMaxIdleTime is the maximum amount of time a connection may be idle
+	// before it's closed. If zero, idle connections are kept forever.
+	ConnMaxIdleTime ConfigDuration
 }
 
 // URL returns the DBConnect URL represented by this DBConfig object, either
@@ -141,6 +153,43 @@ func (t *TLSConfig) Load() (*tls.Config, error) {
 	}, nil
 }
 
+// RedisConfig defines how to connect to a ring of Redis servers, between which
+// keys are sharded by consistent hashing.
+type RedisConfig struct {
+	PasswordConfig
+	// ShardAddrs maps the name of each shard in the ring to the "host:port"
+	// address of its server. Names, rather than addresses, determine which
+	// shard a key belongs to, so a shard's server can be moved without
+	// reassigning its keys.
+	ShardAddrs map[string]string
+	// Timeout bounds dialing, reading from, and writing to a server. If zero,
+	// the client library's defaults are used.
+	Timeout ConfigDuration
+	// PoolSize is the maximum number of connections to each server. If zero,
+	// the client library's default is used.
+	PoolSize int
+}
+
+// NewRing returns a client for the ring of Redis servers described by the
+// RedisConfig.
+func (rc *RedisConfig) NewRing() (*redis.Ring, error) {
+	if len(rc.ShardAddrs) == 0 {
+		return nil, errors.New("no shard addresses in Redis config")
+	}
+	password, err := rc.Pass()
+	if err != nil {
+		return nil, fmt.Errorf("loading Redis password: %s", err)
+	}
+	return redis.NewRing(&redis.RingOptions{
+		Addrs:        rc.ShardAddrs,
+		Password:     password,
+		DialTimeout:  rc.Timeout.Duration,
+		ReadTimeout:  rc.Timeout.Duration,
+		WriteTimeout: rc.Timeout.Duration,
+		PoolSize:     rc.PoolSize,
+	}), nil
+}
+
 // RPCServerConfig contains configuration particular to a specific RPC server
 // type (e.g. RA, SA, etc)
 type RPCServerConfig struct {
@@ -181,9 +230,9 @@ func (d *ConfigDuration) UnmarshalJSON(b []byte) error {
 	return err
 }
 
-// MarshalJSON returns the string form of the duration, as a byte array.
+// MarshalJSON returns the string form of the duration, as a JSON string.
 func (d ConfigDuration) MarshalJSON() ([]byte, error) {
-	return []byte(d.Duration.String()), nil
+	return json.Marshal(d.Duration.String())
 }
 
 // UnmarshalYAML uses the same format as JSON, but is called by the YAML
@@ -206,6 +255,10 @@ func (d *ConfigDuration) UnmarshalYAML(unmarshal func(interface{}) error) error
 type GRPCClientConfig struct {
 	ServerAddress string
 	Timeout       ConfigDuration
+	// HealthCheckService, if set, is the service name whose status clients
+	// check with the gRPC health checking protocol, only sending RPCs to
+	// servers which report it as serving, e.g. "sa.StorageAuthority".
+	HealthCheckService string
 }
 
 // GRPCServerConfig contains the information needed to run a gRPC service
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/letsencrypt/boulder/test"
 )
@@ -32,6 +34,17 @@ func TestDBConfigURL(t *testing.T) {
 	}
 }
 
+func TestConfigDurationJSON(t *testing.T) {
+	b, err := json.Marshal(ConfigDuration{Duration: 90 * time.Second})
+	test.AssertNotError(t, err, "marshaling ConfigDuration")
+	test.AssertEquals(t, string(b), `"1m30s"`)
+
+	var d ConfigDuration
+	err = json.Unmarshal(b, &d)
+	test.AssertNotError(t, err, "unmarshaling ConfigDuration")
+	test.AssertEquals(t, d.Duration, 90*time.Second)
+}
+
 func TestPasswordConfig(t *testing.T) {
 	tests := []struct {
 		pc       PasswordConfig
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/letsencrypt/boulder/bdns"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+)
+
+// redacted replaces the values of secret config fields in the output of the
+// /debug/config endpoint.
+const redacted = "[REDACTED]"
+
+// secretFields are the lowercased names of config fields holding secrets,
+// beyond those whose names contain "password" or "secret".
+var secretFields = map[string]bool{
+	"dbconnect":   true,
+	"pin":         true,
+	"clienttoken": true,
+	"accesstoken": true,
+	// Proxy URLs may contain credentials.
+	"proxy":           true,
+	"validationproxy": true,
+}
+
+// debugState holds what the /debug/config endpoint reports: the config
+// loaded by ReadConfigFile, and the issuers registered with
+// RegisterDebugIssuer. It also holds the DNS client registered with
+// RegisterDebugResolvers, which the /debug/resolvers endpoint reports on.
+var debugState struct {
+	sync.RWMutex
+	config    interface{}
+	issuers   []debugIssuer
+	resolvers *bdns.DNSClientImpl
+}
+
+type debugIssuer struct {
+	CommonName  string    `json:"commonName"`
+	Serial      string    `json:"serial"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	KeyType     string    `json:"keyType"`
+	Fingerprint string    `json:"sha256Fingerprint"`
+}
+
+type debugBuildInfo struct {
+	Name      string `json:"name"`
+	BuildID   string `json:"buildID"`
+	BuildTime string `json:"buildTime"`
+	BuildHost string `json:"buildHost"`
+	GoVersion string `json:"goVersion"`
+}
+
+type debugConfigResponse struct {
+	Build    debugBuildInfo  `json:"build"`
+	Features map[string]bool `json:"features"`
+	Issuers  []debugIssuer   `json:"issuers"`
+	Config   interface{}     `json:"config"`
+}
+
+// RegisterDebugIssuer adds an issuer certificate which this service loaded to
+// the issuer inventory reported by the /debug/config endpoint.
+func RegisterDebugIssuer(cert *x509.Certificate) {
+	fingerprint := sha256.Sum256(cert.Raw)
+	debugState.Lock()
+	defer debugState.Unlock()
+	debugState.issuers = append(debugState.issuers, debugIssuer{
+		CommonName:  cert.Subject.CommonName,
+		Serial:      core.SerialToString(cert.SerialNumber),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		KeyType:     cert.PublicKeyAlgorithm.String(),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+	})
+}
+
+// RegisterDebugResolvers sets the DNS client whose resolvers' health is
+// reported by the /debug/resolvers endpoint.
+func RegisterDebugResolvers(client *bdns.DNSClientImpl) {
+	debugState.Lock()
+	defer debugState.Unlock()
+	debugState.resolvers = client
+}
+
+func setDebugConfig(config interface{}) {
+	debugState.Lock()
+	defer debugState.Unlock()
+	debugState.config = config
+}
+
+// isSecretField returns true if the config field with the given name holds a
+// secret. Fields naming the file or path a secret is read from are not
+// themselves secret.
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, "file") || strings.HasSuffix(name, "path") {
+		return false
+	}
+	return secretFields[name] || strings.Contains(name, "password") || strings.Contains(name, "secret")
+}
+
+// redactSecrets replaces the values of secret fields, at any depth, in a
+// config decoded from JSON into generic maps and slices.
+func redactSecrets(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if isSecretField(k) && field != nil && field != "" {
+				v[k] = redacted
+			} else {
+				v[k] = redactSecrets(field)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redactSecrets(elem)
+		}
+	}
+	return v
+}
+
+// debugConfig returns the contents of the /debug/config endpoint.
+func debugConfig() (*debugConfigResponse, error) {
+	debugState.RLock()
+	config := debugState.config
+	issuers := append([]debugIssuer{}, debugState.issuers...)
+	debugState.RUnlock()
+
+	// Round trip the config through JSON, so that it's reported with the field
+	// names it was configured with and its secrets can be found by name.
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	err = json.Unmarshal(configJSON, &generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return &debugConfigResponse{
+		Build: debugBuildInfo{
+			Name:      path.Base(os.Args[0]),
+			BuildID:   core.GetBuildID(),
+			BuildTime: core.GetBuildTime(),
+			BuildHost: core.GetBuildHost(),
+			GoVersion: runtime.Version(),
+		},
+		Features: features.All(),
+		Issuers:  issuers,
+		Config:   redactSecrets(generic),
+	}, nil
+}
+
+// debugConfigHandler serves the /debug/config endpoint, which reports the
+// configuration this service loaded, with secrets redacted, along with its
+// feature flags, issuers and build information.
+func debugConfigHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := debugConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// debugResolversHandler serves the /debug/resolvers endpoint, which reports
+// whether each of the DNS resolvers used by this service is in rotation, along
+// with its recent error rate and latency.
+func debugResolversHandler(w http.ResponseWriter, r *http.Request) {
+	debugState.RLock()
+	client := debugState.resolvers
+	debugState.RUnlock()
+	if client == nil {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := json.MarshalIndent(client.ResolverStates(), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
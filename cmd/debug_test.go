@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/bdns"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestDebugConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "debug-config")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "config.json")
+	err = ioutil.WriteFile(configPath, []byte(`{
+		"db": {"dbConnect": "user:hunter2@tcp(db:3306)/boulder", "maxDBConns": 10},
+		"mailer": {"password": "hunter2", "passwordFile": "/secrets/smtp", "username": "boulder"},
+		"hsms": [{"module": "/usr/lib/softhsm.so", "pin": "hsm-pin", "tokenLabel": "intermediate"}],
+		"clientSecret": "hunter2"
+	}`), 0600)
+	test.AssertNotError(t, err, "writing config")
+
+	var c struct {
+		DB     DBConfig
+		Mailer SMTPConfig
+		HSMs   []struct {
+			Module     string
+			PIN        string
+			TokenLabel string
+		}
+		ClientSecret string
+	}
+	err = ReadConfigFile(configPath, &c)
+	test.AssertNotError(t, err, "reading config")
+	// Changes made to the config after it was loaded are reported.
+	c.DB.MaxDBConns = 20
+
+	err = features.Set(map[string]bool{"IPIdentifiers": true})
+	test.AssertNotError(t, err, "setting features")
+	defer features.Reset()
+
+	cert, err := core.LoadCert("../test/test-ca.pem")
+	test.AssertNotError(t, err, "loading issuer")
+	RegisterDebugIssuer(cert)
+
+	w := httptest.NewRecorder()
+	debugConfigHandler(w, httptest.NewRequest("GET", "/debug/config", nil))
+	test.AssertEquals(t, w.Code, 200)
+	test.AssertEquals(t, w.Header().Get("Content-Type"), "application/json")
+	test.AssertNotContains(t, w.Body.String(), "hunter2")
+	test.AssertNotContains(t, w.Body.String(), "hsm-pin")
+
+	var resp struct {
+		Build struct {
+			GoVersion string
+		}
+		Features map[string]bool
+		Issuers  []debugIssuer
+		Config   struct {
+			DB     DBConfig
+			Mailer SMTPConfig
+			HSMs   []struct {
+				PIN        string
+				TokenLabel string
+			}
+			ClientSecret string
+		}
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	test.AssertNotError(t, err, "unmarshaling response")
+	test.Assert(t, resp.Build.GoVersion != "", "missing Go version")
+	test.Assert(t, resp.Features["IPIdentifiers"], "IPIdentifiers not reported as enabled")
+	test.Assert(t, !resp.Features["BatchCAARecheck"], "BatchCAARecheck reported as enabled")
+	test.AssertEquals(t, len(resp.Issuers), 1)
+	test.AssertEquals(t, resp.Issuers[0].CommonName, cert.Subject.CommonName)
+	test.AssertEquals(t, resp.Issuers[0].Serial, core.SerialToString(cert.SerialNumber))
+	test.AssertEquals(t, resp.Config.DB.DBConnect, redacted)
+	test.AssertEquals(t, resp.Config.DB.MaxDBConns, 20)
+	test.AssertEquals(t, resp.Config.Mailer.Password, redacted)
+	test.AssertEquals(t, resp.Config.Mailer.PasswordFile, "/secrets/smtp")
+	test.AssertEquals(t, resp.Config.Mailer.Username, "boulder")
+	test.AssertEquals(t, resp.Config.HSMs[0].PIN, redacted)
+	test.AssertEquals(t, resp.Config.HSMs[0].TokenLabel, "intermediate")
+	test.AssertEquals(t, resp.Config.ClientSecret, redacted)
+}
+
+func TestDebugResolvers(t *testing.T) {
+	w := httptest.NewRecorder()
+	debugResolversHandler(w, httptest.NewRequest("GET", "/debug/resolvers", nil))
+	test.AssertEquals(t, w.Code, 404)
+
+	client := bdns.NewTestDNSClientImpl(time.Second, []string{"127.0.0.1:8053"}, metrics.NoopRegisterer, clock.NewFake(), 1, blog.UseMock())
+	RegisterDebugResolvers(client)
+	defer RegisterDebugResolvers(nil)
+
+	w = httptest.NewRecorder()
+	debugResolversHandler(w, httptest.NewRequest("GET", "/debug/resolvers", nil))
+	test.AssertEquals(t, w.Code, 200)
+	test.AssertEquals(t, w.Header().Get("Content-Type"), "application/json")
+	var states []bdns.ResolverState
+	err := json.Unmarshal(w.Body.Bytes(), &states)
+	test.AssertNotError(t, err, "unmarshaling response")
+	test.AssertDeepEquals(t, states, []bdns.ResolverState{{Server: "127.0.0.1:8053", InRotation: true}})
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/jmhodges/clock"
@@ -25,6 +28,10 @@ type config struct {
 		GracePeriod  cmd.ConfigDuration
 		BatchSize    int
 		WaitDuration cmd.ConfigDuration
+		// Archive controls whether expired authorizations are copied to the
+		// archivedAuthz2 table before being deleted. The boulder-janitor's
+		// archivedAuthz2 job removes them from there.
+		Archive bool
 	}
 }
 
@@ -50,6 +57,60 @@ func deleteExpired(clk clock.Clock, gracePeriod time.Duration, batchSize int, db
 	return res.RowsAffected()
 }
 
+// archiveExpired copies up to batchSize expired authorizations into the
+// archivedAuthz2 table and deletes them from authz2 inside of a transaction,
+// so that an authorization is only deleted once it has been archived.
+func archiveExpired(clk clock.Clock, gracePeriod time.Duration, batchSize int, dbMap *db.WrappedMap) (int64, error) {
+	now := clk.Now()
+	deleted, err := db.WithTransaction(context.Background(), dbMap, func(tx db.Executor) (interface{}, error) {
+		var ids []int64
+		_, err := tx.Select(
+			&ids,
+			"SELECT id FROM authz2 WHERE expires <= :expires ORDER BY id LIMIT :limit",
+			map[string]interface{}{
+				"expires": now.Add(-gracePeriod),
+				"limit":   batchSize,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return int64(0), nil
+		}
+		args := []interface{}{now}
+		qmarks := make([]string, len(ids))
+		for i, id := range ids {
+			args = append(args, id)
+			qmarks[i] = "?"
+		}
+		idList := strings.Join(qmarks, ",")
+		_, err = tx.Exec(fmt.Sprintf(
+			`INSERT INTO archivedAuthz2
+				(id, identifierType, identifierValue, registrationID, status, expires,
+				 challenges, attempted, attemptedAt, token, validationError,
+				 validationRecord, archived)
+			SELECT id, identifierType, identifierValue, registrationID, status, expires,
+				challenges, attempted, attemptedAt, token, validationError,
+				validationRecord, ?
+			FROM authz2 WHERE id IN (%s)`, idList),
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		res, err := tx.Exec(fmt.Sprintf("DELETE FROM authz2 WHERE id IN (%s)", idList), args[1:]...)
+		if err != nil {
+			return nil, err
+		}
+		return res.RowsAffected()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted.(int64), nil
+}
+
 func main() {
 	singleRun := flag.Bool("single-run", false, "Exit after running first delete query instead of running indefinitely")
 	configPath := flag.String("config", "config.json", "Path to Boulder configuration file")
@@ -78,11 +139,16 @@ func main() {
 
 	dbURL, err := c.ExpiredAuthzPurger2.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	dbMap, err := sa.NewDbMap(dbURL, c.ExpiredAuthzPurger2.DBConfig.MaxDBConns)
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettingsFromDBConfig(c.ExpiredAuthzPurger2.DBConfig))
 	cmd.FailOnError(err, "Could not connect to database")
 
+	purge := deleteExpired
+	if c.ExpiredAuthzPurger2.Archive {
+		purge = archiveExpired
+	}
+
 	for {
-		deleted, err := deleteExpired(clk, c.ExpiredAuthzPurger2.GracePeriod.Duration, c.ExpiredAuthzPurger2.BatchSize, dbMap)
+		deleted, err := purge(clk, c.ExpiredAuthzPurger2.GracePeriod.Duration, c.ExpiredAuthzPurger2.BatchSize, dbMap)
 		if err != nil {
 			logger.Errf("failed to purge expired authorizations: %s", err)
 			if !*singleRun {
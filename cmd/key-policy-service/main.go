@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/goodkey"
+	goodkeypb "github.com/letsencrypt/boulder/goodkey/proto"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+type config struct {
+	KeyPolicyService struct {
+		cmd.ServiceConfig
+
+		SAService *cmd.GRPCClientConfig
+
+		// WeakKeyFile is the path to a JSON file containing truncated RSA
+		// modulus hashes of known easily enumerable keys.
+		WeakKeyFile string
+
+		// BlockedKeyFile is the path to a YAML file containing Base64 encoded
+		// SHA256 hashes of SubjectPublicKeyInfo's that should be considered
+		// administratively blocked.
+		BlockedKeyFile string
+
+		// ReloadInterval is how often WeakKeyFile and BlockedKeyFile are
+		// re-read. If zero, they are only read at startup.
+		ReloadInterval cmd.ConfigDuration
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func main() {
+	grpcAddr := flag.String("addr", "", "gRPC listen address override")
+	debugAddr := flag.String("debug-addr", "", "Debug server address override")
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+
+	err = features.Set(c.KeyPolicyService.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	if *grpcAddr != "" {
+		c.KeyPolicyService.GRPC.Address = *grpcAddr
+	}
+	if *debugAddr != "" {
+		c.KeyPolicyService.DebugAddr = *debugAddr
+	}
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, c.KeyPolicyService.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	tlsConfig, err := c.KeyPolicyService.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+
+	clk := cmd.Clock()
+
+	clientMetrics := bgrpc.NewClientMetrics(scope)
+	saConn, err := bgrpc.ClientSetup(c.KeyPolicyService.SAService, tlsConfig, clientMetrics, clk)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
+	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(saConn))
+
+	kps, err := goodkey.NewServer(c.KeyPolicyService.WeakKeyFile, c.KeyPolicyService.BlockedKeyFile, sac.KeyBlocked)
+	cmd.FailOnError(err, "Unable to create key policy service")
+
+	if c.KeyPolicyService.ReloadInterval.Duration > 0 {
+		go func() {
+			for {
+				clk.Sleep(c.KeyPolicyService.ReloadInterval.Duration)
+				err := kps.Reload()
+				if err != nil {
+					logger.AuditErrf("Failed to reload key lists, continuing to use previous lists: %s", err)
+				}
+			}
+		}()
+	}
+
+	serverMetrics := bgrpc.NewServerMetrics(scope)
+	grpcSrv, l, err := bgrpc.NewServer(c.KeyPolicyService.GRPC, tlsConfig, serverMetrics, clk)
+	cmd.FailOnError(err, "Unable to setup key policy service gRPC server")
+	goodkeypb.RegisterKeyPolicyServer(grpcSrv, kps)
+
+	go cmd.CatchSignals(logger, grpcSrv.GracefulStop)
+
+	err = cmd.FilterShutdownErrors(grpcSrv.Serve(l))
+	cmd.FailOnError(err, "Key policy service gRPC server failed")
+}
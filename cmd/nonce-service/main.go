@@ -17,6 +17,21 @@ type config struct {
 		Syslog      cmd.SyslogConfig
 		MaxUsed     int
 		NoncePrefix string
+
+		// NoncePrefixKey, if set, is a key shared with the WFEs from which
+		// the nonce prefix is derived, along with the gRPC listen address. See
+		// nonce.DerivePrefix. NoncePrefix must not be set as well.
+		NoncePrefixKey cmd.PasswordConfig
+
+		// Redis, if present, is a ring of Redis servers in which nonces are
+		// stored, rather than being tracked in memory. Every nonce service
+		// sharing the servers can redeem any of their nonces, which also
+		// survive restarts, so the WFEs can send every redemption to the
+		// "any" nonce service. MaxUsed is then unused.
+		Redis *cmd.RedisConfig
+		// NonceLifetime is how long nonces stored in Redis may be redeemed
+		// for. If zero, it defaults to an hour.
+		NonceLifetime cmd.ConfigDuration
 	}
 }
 
@@ -36,6 +51,26 @@ func (ns *nonceServer) Nonce(_ context.Context, _ *corepb.Empty) (*noncepb.Nonce
 	return &noncepb.NonceMessage{Nonce: nonce}, nil
 }
 
+type redisNonceServer struct {
+	inner *nonce.RedisNonceService
+}
+
+func (ns *redisNonceServer) Redeem(ctx context.Context, msg *noncepb.NonceMessage) (*noncepb.ValidMessage, error) {
+	valid, err := ns.inner.Valid(ctx, msg.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &noncepb.ValidMessage{Valid: valid}, nil
+}
+
+func (ns *redisNonceServer) Nonce(ctx context.Context, _ *corepb.Empty) (*noncepb.NonceMessage, error) {
+	nonce, err := ns.inner.Nonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &noncepb.NonceMessage{Nonce: nonce}, nil
+}
+
 func main() {
 	grpcAddr := flag.String("addr", "", "gRPC listen address override")
 	debugAddr := flag.String("debug-addr", "", "Debug server address override")
@@ -57,22 +92,40 @@ func main() {
 		c.NonceService.NoncePrefix = *prefixOverride
 	}
 
+	prefixKey, err := c.NonceService.NoncePrefixKey.Pass()
+	cmd.FailOnError(err, "Failed to load nonce prefix key")
+	if prefixKey != "" {
+		if c.NonceService.NoncePrefix != "" {
+			cmd.Fail("Only one of noncePrefix and noncePrefixKey may be configured")
+		}
+		c.NonceService.NoncePrefix = nonce.DerivePrefix(c.NonceService.GRPC.Address, prefixKey)
+	}
+
 	scope, logger := cmd.StatsAndLogging(c.NonceService.Syslog, c.NonceService.DebugAddr)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
-
-	ns, err := nonce.NewNonceService(scope, c.NonceService.MaxUsed, c.NonceService.NoncePrefix)
-	cmd.FailOnError(err, "Failed to initialize nonce service")
+	logger.Infof("Using nonce prefix %q", c.NonceService.NoncePrefix)
+
+	var server noncepb.NonceServiceServer
+	if c.NonceService.Redis != nil {
+		ring, err := c.NonceService.Redis.NewRing()
+		cmd.FailOnError(err, "Failed to create Redis client for nonces")
+		ns, err := nonce.NewRedisNonceService(ring, scope, c.NonceService.NoncePrefix, c.NonceService.NonceLifetime.Duration)
+		cmd.FailOnError(err, "Failed to initialize nonce service")
+		server = &redisNonceServer{inner: ns}
+	} else {
+		ns, err := nonce.NewNonceService(scope, c.NonceService.MaxUsed, c.NonceService.NoncePrefix)
+		cmd.FailOnError(err, "Failed to initialize nonce service")
+		server = &nonceServer{inner: ns}
+	}
 
 	tlsConfig, err := c.NonceService.TLS.Load()
 	cmd.FailOnError(err, "tlsConfig config")
 
-	nonceServer := &nonceServer{inner: ns}
-
 	serverMetrics := bgrpc.NewServerMetrics(scope)
 	grpcSrv, l, err := bgrpc.NewServer(c.NonceService.GRPC, tlsConfig, serverMetrics, cmd.Clock())
 	cmd.FailOnError(err, "Unable to setup nonce service gRPC server")
-	noncepb.RegisterNonceServiceServer(grpcSrv, nonceServer)
+	noncepb.RegisterNonceServiceServer(grpcSrv, server)
 
 	go cmd.CatchSignals(logger, grpcSrv.GracefulStop)
 
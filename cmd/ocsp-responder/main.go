@@ -246,10 +246,10 @@ as generated by Boulder's ceremony command.
 			dbConnect = config.Source
 		}
 		logger.Infof("Loading OCSP Database for CA Cert: %s", c.Common.IssuerCert)
-		dbMap, err := sa.NewDbMap(dbConnect, config.DBConfig.MaxDBConns)
+		dbMap, err := sa.NewDbMapWithSettings(dbConnect, sa.DbSettingsFromDBConfig(config.DBConfig))
 		cmd.FailOnError(err, "Could not connect to database")
 		sa.SetSQLDebug(dbMap, logger)
-		sa.InitDBMetrics(dbMap, stats)
+		sa.InitDBMetrics(dbMap, stats, dbConnect, "primary")
 
 		source, err = makeDBSource(
 			dbMap,
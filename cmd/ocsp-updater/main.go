@@ -8,6 +8,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/jmhodges/clock"
@@ -30,6 +31,7 @@ import (
  */
 type ocspDB interface {
 	Select(i interface{}, query string, args ...interface{}) ([]interface{}, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
 	SelectOne(holder interface{}, query string, args ...interface{}) error
 	Exec(query string, args ...interface{}) (sql.Result, error)
 }
@@ -56,14 +58,20 @@ type OCSPUpdater struct {
 	// Maximum number of individual OCSP updates to attempt in parallel. Making
 	// these requests in parallel allows us to get higher total throughput.
 	parallelGenerateOCSPRequests int
+	// Capacity of the queue between the goroutine reading stale certificate
+	// statuses from the database and the goroutines sending them to the CA.
+	// When the queue is full the reader blocks until a writer frees a slot.
+	queueSize int
 
 	purgerService akamaipb.AkamaiPurgerClient
 	// issuer is used to generate OCSP request URLs to purge
 	issuer *x509.Certificate
 
-	genStoreHistogram prometheus.Histogram
-	generatedCounter  *prometheus.CounterVec
-	storedCounter     *prometheus.CounterVec
+	genStoreHistogram   prometheus.Histogram
+	generatedCounter    *prometheus.CounterVec
+	storedCounter       *prometheus.CounterVec
+	queueDepth          prometheus.Gauge
+	queueStallHistogram prometheus.Histogram
 }
 
 func newUpdater(
@@ -86,6 +94,10 @@ func newUpdater(
 		// Default to 1
 		config.ParallelGenerateOCSPRequests = 1
 	}
+	if config.OCSPQueueSize == 0 {
+		// Default to one queued status per parallel request
+		config.OCSPQueueSize = config.ParallelGenerateOCSPRequests
+	}
 
 	genStoreHistogram := prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: "ocsp_updater_generate_and_store",
@@ -107,6 +119,16 @@ func newUpdater(
 		Help: "A histogram of ocsp-updater tick latencies labelled by result and whether the tick was considered longer than expected",
 	}, []string{"result", "long"})
 	stats.MustRegister(tickHistogram)
+	queueDepth := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ocsp_updater_queue_depth",
+		Help: "The number of stale certificate statuses waiting to be sent to the CA",
+	})
+	stats.MustRegister(queueDepth)
+	queueStallHistogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "ocsp_updater_queue_stall",
+		Help: "A histogram of the time spent waiting to enqueue a stale certificate status because the queue was full",
+	})
+	stats.MustRegister(queueStallHistogram)
 
 	updater := OCSPUpdater{
 		clk:                          clk,
@@ -115,11 +137,14 @@ func newUpdater(
 		log:                          log,
 		ocspMinTimeToExpiry:          config.OCSPMinTimeToExpiry.Duration,
 		parallelGenerateOCSPRequests: config.ParallelGenerateOCSPRequests,
+		queueSize:                    config.OCSPQueueSize,
 		purgerService:                apc,
 		genStoreHistogram:            genStoreHistogram,
 		generatedCounter:             generatedCounter,
 		storedCounter:                storedCounter,
 		tickHistogram:                tickHistogram,
+		queueDepth:                   queueDepth,
+		queueStallHistogram:          queueStallHistogram,
 		tickWindow:                   config.OldOCSPWindow.Duration,
 		batchSize:                    config.OldOCSPBatchSize,
 		maxBackoff:                   config.SignFailureBackoffMax.Duration,
@@ -137,26 +162,33 @@ func newUpdater(
 	return &updater, nil
 }
 
-func (updater *OCSPUpdater) findStaleOCSPResponses(oldestLastUpdatedTime time.Time, batchSize int) ([]core.CertificateStatus, error) {
-	statuses, err := sa.SelectCertificateStatuses(
+// scanStaleOCSPResponses calls send with each of up to batchSize certificate
+// statuses whose OCSP responses were last updated before
+// oldestLastUpdatedTime, oldest first, as they are read from the database.
+func (updater *OCSPUpdater) scanStaleOCSPResponses(oldestLastUpdatedTime time.Time, batchSize int, send func(core.CertificateStatus) error) error {
+	return sa.ScanCertificateStatuses(
 		updater.dbMap,
-		`WHERE ocspLastUpdated < :lastUpdate
+		send,
+		`WHERE ocspLastUpdated < ?
 		 AND NOT isExpired
 		 ORDER BY ocspLastUpdated ASC
-		 LIMIT :limit`,
-		map[string]interface{}{
-			"lastUpdate": oldestLastUpdatedTime,
-			"limit":      batchSize,
-		},
+		 LIMIT ?`,
+		oldestLastUpdatedTime,
+		batchSize,
 	)
-	if db.IsNoRows(err) {
-		return statuses, nil
-	}
+}
+
+func (updater *OCSPUpdater) findStaleOCSPResponses(oldestLastUpdatedTime time.Time, batchSize int) ([]core.CertificateStatus, error) {
+	var statuses []core.CertificateStatus
+	err := updater.scanStaleOCSPResponses(oldestLastUpdatedTime, batchSize, func(status core.CertificateStatus) error {
+		statuses = append(statuses, status)
+		return nil
+	})
 	return statuses, err
 }
 
 func getCertDER(selector ocspDB, serial string) ([]byte, error) {
-	cert, err := sa.SelectCertificate(selector, serial)
+	cert, err := sa.SelectCertificateWithDER(selector, serial)
 	if err != nil {
 		if db.IsNoRows(err) {
 			cert, err = sa.SelectPrecertificate(selector, serial)
@@ -229,23 +261,30 @@ func (updater *OCSPUpdater) markExpired(status core.CertificateStatus) error {
 	return err
 }
 
-func (updater *OCSPUpdater) generateOCSPResponses(ctx context.Context, statuses []core.CertificateStatus) error {
-	// Use the semaphore pattern from
-	// https://github.com/golang/go/wiki/BoundingResourceUse to send a number of
-	// GenerateOCSP / storeResponse requests in parallel, while limiting the total number of
-	// outstanding requests. The number of outstanding requests equals the
-	// capacity of the channel.
-	sem := make(chan int, updater.parallelGenerateOCSPRequests)
-	wait := func() {
-		sem <- 1 // Block until there's capacity.
-	}
-	done := func(start time.Time) {
-		<-sem // Indicate there's more capacity.
-		updater.genStoreHistogram.Observe(time.Since(start).Seconds())
+// enqueue adds a status to the queue, blocking if the queue is full. Time spent
+// blocked is recorded so that a slow CA shows up as stalls rather than as a
+// slow database.
+func (updater *OCSPUpdater) enqueue(queue chan<- core.CertificateStatus, status core.CertificateStatus) {
+	select {
+	case queue <- status:
+	default:
+		start := updater.clk.Now()
+		queue <- status
+		updater.queueStallHistogram.Observe(updater.clk.Since(start).Seconds())
 	}
+	updater.queueDepth.Set(float64(len(queue)))
+}
 
+// processQueue generates and stores OCSP responses for every status received
+// from the queue until it is closed. A fixed pool of goroutines is used so that
+// there are never more than parallelGenerateOCSPRequests outstanding requests
+// to the CA.
+func (updater *OCSPUpdater) processQueue(ctx context.Context, queue <-chan core.CertificateStatus) {
 	work := func(status core.CertificateStatus) {
-		defer done(updater.clk.Now())
+		start := time.Now()
+		defer func() {
+			updater.genStoreHistogram.Observe(time.Since(start).Seconds())
+		}()
 		meta, err := updater.generateResponse(ctx, status)
 		if err != nil {
 			updater.log.AuditErrf("Failed to generate OCSP response: %s", err)
@@ -262,38 +301,67 @@ func (updater *OCSPUpdater) generateOCSPResponses(ctx context.Context, statuses
 		updater.storedCounter.WithLabelValues("success").Inc()
 	}
 
-	for _, status := range statuses {
-		wait()
-		go work(status)
-	}
-	// Block until the channel reaches its full capacity again, indicating each
-	// goroutine has completed.
+	var wg sync.WaitGroup
 	for i := 0; i < updater.parallelGenerateOCSPRequests; i++ {
-		wait()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for status := range queue {
+				updater.queueDepth.Set(float64(len(queue)))
+				work(status)
+			}
+		}()
 	}
-	return nil
+	wg.Wait()
 }
 
-// updateOCSPResponses looks for certificates with stale OCSP responses and
-// generates/stores new ones
-func (updater *OCSPUpdater) updateOCSPResponses(ctx context.Context, batchSize int) error {
-	tickStart := updater.clk.Now()
-	statuses, err := updater.findStaleOCSPResponses(tickStart.Add(-updater.ocspMinTimeToExpiry), batchSize)
-	if err != nil {
-		updater.log.AuditErrf("Failed to find stale OCSP responses: %s", err)
-		return err
-	}
+func (updater *OCSPUpdater) generateOCSPResponses(ctx context.Context, statuses []core.CertificateStatus) error {
+	queue := make(chan core.CertificateStatus, updater.queueSize)
+	go func() {
+		defer close(queue)
+		for _, status := range statuses {
+			updater.enqueue(queue, status)
+		}
+	}()
+	updater.processQueue(ctx, queue)
+	return nil
+}
 
-	for _, s := range statuses {
+// readStaleOCSPResponses finds certificates with stale OCSP responses, marks
+// any that have expired, and sends them to the queue as each row is read. It is
+// run on its own goroutine so that reading from the database and generating
+// responses proceed concurrently, with the queue's capacity providing
+// backpressure. The query holds a database connection until every row has been
+// queued, so marking and storing responses need connections of their own.
+func (updater *OCSPUpdater) readStaleOCSPResponses(tickStart time.Time, batchSize int, queue chan<- core.CertificateStatus) error {
+	err := updater.scanStaleOCSPResponses(tickStart.Add(-updater.ocspMinTimeToExpiry), batchSize, func(s core.CertificateStatus) error {
 		if !s.IsExpired && tickStart.After(s.NotAfter) {
 			err := updater.markExpired(s)
 			if err != nil {
 				return err
 			}
 		}
+		updater.enqueue(queue, s)
+		return nil
+	})
+	if err != nil {
+		updater.log.AuditErrf("Failed to read stale OCSP responses: %s", err)
 	}
+	return err
+}
 
-	return updater.generateOCSPResponses(ctx, statuses)
+// updateOCSPResponses looks for certificates with stale OCSP responses and
+// generates/stores new ones
+func (updater *OCSPUpdater) updateOCSPResponses(ctx context.Context, batchSize int) error {
+	tickStart := updater.clk.Now()
+	queue := make(chan core.CertificateStatus, updater.queueSize)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(queue)
+		readErr <- updater.readStaleOCSPResponses(tickStart, batchSize, queue)
+	}()
+	updater.processQueue(ctx, queue)
+	return <-readErr
 }
 
 type config struct {
@@ -317,6 +385,10 @@ type OCSPUpdaterConfig struct {
 
 	OCSPMinTimeToExpiry          cmd.ConfigDuration
 	ParallelGenerateOCSPRequests int
+	// OCSPQueueSize is the maximum number of stale certificate statuses that
+	// may be read from the database but not yet sent to the CA. Defaults to
+	// ParallelGenerateOCSPRequests.
+	OCSPQueueSize int
 
 	AkamaiBaseURL           string
 	AkamaiClientToken       string
@@ -407,14 +479,18 @@ func main() {
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
-	// Configure DB
+	// Configure DB. Reading stale responses holds one connection for a whole
+	// batch while others are used to mark and store them.
+	if conf.MaxDBConns == 1 {
+		cmd.Fail("maxDBConns must be greater than 1")
+	}
 	dbURL, err := conf.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	dbMap, err := sa.NewDbMap(dbURL, conf.DBConfig.MaxDBConns)
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettingsFromDBConfig(conf.DBConfig))
 	cmd.FailOnError(err, "Could not connect to database")
 
 	// Collect and periodically report DB metrics using the DBMap and prometheus stats.
-	sa.InitDBMetrics(dbMap, stats)
+	sa.InitDBMetrics(dbMap, stats, dbURL, "primary")
 
 	clk := cmd.Clock()
 	ogc, apc := setupClients(conf, stats, clk)
@@ -40,6 +40,10 @@ func (ca *mockOCSP) GenerateOCSP(_ context.Context, req *capb.GenerateOCSPReques
 	return &capb.OCSPResponse{Response: []byte{1, 2, 3}}, nil
 }
 
+func (ca *mockOCSP) GenerateOCSPBatch(_ context.Context, _ ...grpc.CallOption) (capb.OCSPGenerator_GenerateOCSPBatchClient, error) {
+	return nil, errors.New("not implemented")
+}
+
 var log = blog.UseMock()
 
 func setup(t *testing.T) (*OCSPUpdater, core.StorageAuthority, *db.WrappedMap, clock.FakeClock, func()) {
@@ -412,6 +416,7 @@ func TestGenerateOCSPResponsePrecert(t *testing.T) {
 }
 
 type mockOCSPRecordIssuer struct {
+	mockOCSP
 	gotIssuer bool
 }
 
@@ -478,6 +483,9 @@ type brokenDB struct{}
 func (bdb *brokenDB) Select(i interface{}, query string, args ...interface{}) ([]interface{}, error) {
 	return nil, errors.New("broken")
 }
+func (bdb *brokenDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("broken")
+}
 func (bdb *brokenDB) SelectOne(holder interface{}, query string, args ...interface{}) error {
 	return errors.New("broken")
 }
@@ -510,3 +518,44 @@ func TestTickSleep(t *testing.T) {
 	test.AssertEquals(t, took, updater.tickWindow)
 
 }
+
+func TestGenerateOCSPResponsesQueueStall(t *testing.T) {
+	fc := clock.NewFake()
+	updater, err := newUpdater(
+		metrics.NoopRegisterer,
+		fc,
+		&brokenDB{},
+		&mockOCSP{sleepTime: 50 * time.Millisecond},
+		nil,
+		OCSPUpdaterConfig{
+			OldOCSPBatchSize:             1,
+			OldOCSPWindow:                cmd.ConfigDuration{Duration: time.Second},
+			ParallelGenerateOCSPRequests: 1,
+			OCSPQueueSize:                1,
+		},
+		"",
+		blog.NewMock(),
+	)
+	test.AssertNotError(t, err, "Failed to create newUpdater")
+	test.AssertEquals(t, updater.queueSize, 1)
+
+	issuerID := int64(1234)
+	var statuses []core.CertificateStatus
+	for i := 0; i < 4; i++ {
+		statuses = append(statuses, core.CertificateStatus{
+			Serial:   fmt.Sprintf("%036x", i),
+			Status:   core.OCSPStatusGood,
+			IssuerID: &issuerID,
+		})
+	}
+
+	// With a single slot in the queue and a slow CA, the reader must block
+	// waiting for the writer at least once.
+	err = updater.generateOCSPResponses(ctx, statuses)
+	test.AssertNotError(t, err, "Couldn't generate OCSP responses")
+	test.AssertEquals(t, test.CountCounterVec("result", "success", updater.generatedCounter), 4)
+	// Storing fails because of the broken DB, but every status must still have
+	// made it through the queue.
+	test.AssertEquals(t, test.CountCounterVec("result", "failed", updater.storedCounter), 4)
+	test.Assert(t, test.CountHistogramSamples(updater.queueStallHistogram) > 0, "expected the reader to stall on a full queue")
+}
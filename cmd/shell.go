@@ -217,6 +217,8 @@ func newStatsRegistry(addr string, logger blog.Logger) prometheus.Registerer {
 	mux.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
 
 	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/debug/config", http.HandlerFunc(debugConfigHandler))
+	mux.Handle("/debug/resolvers", http.HandlerFunc(debugResolversHandler))
 	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{
 		ErrorLog: promLogger{logger},
 	}))
@@ -276,13 +278,20 @@ func LoadCert(path string) (cert []byte, err error) {
 
 // ReadConfigFile takes a file path as an argument and attempts to
 // unmarshal the content of the file into a struct containing a
-// configuration of a boulder component.
+// configuration of a boulder component. The config is also reported, with
+// secrets redacted, by the debug server's /debug/config endpoint, which
+// reflects any changes made to it after loading.
 func ReadConfigFile(filename string, out interface{}) error {
 	configData, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(configData, out)
+	err = json.Unmarshal(configData, out)
+	if err != nil {
+		return err
+	}
+	setDebugConfig(out)
+	return nil
 }
 
 // VersionString produces a friendly Application version string.
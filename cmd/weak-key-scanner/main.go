@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/db"
+	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/sa"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+var certsScanned = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "weak_key_scanner_certs_scanned",
+	Help: "A counter of precertificates scanned labelled by result",
+}, []string{"result"})
+var scanLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "weak_key_scanner_scan_latency",
+	Help:    "A histogram of the time taken to complete a full scan of the window",
+	Buckets: prometheus.ExponentialBuckets(1, 2, 16),
+})
+
+// keyScanner periodically re-checks the public keys of recently issued,
+// unexpired precertificates against the current key policy. Keys that are now
+// considered bad (because the blocklist or weak key list grew, or because a
+// new detection algorithm was added to goodkey) have their serials recorded in
+// the incidentSerials table under the configured incident, where they can be
+// picked up by the mass revocation workflow.
+type keyScanner struct {
+	dbMap *db.WrappedMap
+	// incidentsDBMap holds the incidents and incidentSerials tables. It's the
+	// same as dbMap unless the incidents are kept in a separate database.
+	incidentsDBMap *db.WrappedMap
+	incidentID     int64
+	keyPolicy      goodkey.KeyPolicy
+	clk            clock.Clock
+	window         time.Duration
+	batchSize      int
+	logger         log.Logger
+}
+
+// issuedCertificate represents a row in the precertificates table
+type issuedCertificate struct {
+	ID             int64
+	Serial         string
+	RegistrationID int64
+	DER            []byte
+	Expires        time.Time
+}
+
+// keyBlocked implements goodkey.BlockedKeyCheckFunc by querying the
+// blockedKeys table directly, in the same way as the SA's KeyBlocked RPC.
+func (ks *keyScanner) keyBlocked(_ context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	exists := false
+	var id int64
+	if err := ks.dbMap.SelectOne(&id, `SELECT ID FROM blockedKeys WHERE keyHash = ?`, req.KeyHash); err != nil {
+		if db.IsNoRows(err) {
+			return &sapb.Exists{Exists: &exists}, nil
+		}
+		return nil, err
+	}
+	exists = true
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// selectBatch returns up to ks.batchSize unexpired precertificates issued
+// after earliest with an ID greater than afterID, ordered by ID.
+func (ks *keyScanner) selectBatch(earliest time.Time, afterID int64) ([]issuedCertificate, error) {
+	var batch []issuedCertificate
+	_, err := ks.dbMap.Select(
+		&batch,
+		`SELECT id, serial, registrationID, der, expires
+		FROM precertificates
+		WHERE id > ?
+		AND issued >= ?
+		AND expires > ?
+		ORDER BY id
+		LIMIT ?`,
+		afterID,
+		earliest,
+		ks.clk.Now(),
+		ks.batchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// checkCertificate parses the certificate and runs its public key through the
+// key policy. It returns a nil error if the key is acceptable, and
+// goodkey.ErrBadKey (wrapped) if it is not. Any other error indicates the
+// check itself could not be completed.
+func (ks *keyScanner) checkCertificate(ctx context.Context, cert issuedCertificate) error {
+	parsed, err := x509.ParseCertificate(cert.DER)
+	if err != nil {
+		return fmt.Errorf("parsing precertificate %s: %s", cert.Serial, err)
+	}
+	return ks.keyPolicy.GoodKey(ctx, parsed.PublicKey)
+}
+
+// checkIncident returns an error unless ks.incidentID names an incident in
+// the incidents table, so that the scanner doesn't record serials under an
+// incident which doesn't exist.
+func (ks *keyScanner) checkIncident() error {
+	var id int64
+	err := ks.incidentsDBMap.SelectOne(&id, "SELECT id FROM incidents WHERE id = ?", ks.incidentID)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return fmt.Errorf("no incident with ID %d", ks.incidentID)
+		}
+		return err
+	}
+	return nil
+}
+
+// recordIncident records the affected certificate in the incidentSerials
+// table under ks.incidentID. Certificates that have already been recorded by a
+// previous scan are left untouched.
+func (ks *keyScanner) recordIncident(cert issuedCertificate) error {
+	_, err := ks.incidentsDBMap.Exec(
+		`INSERT INTO incidentSerials
+		(incidentID, serial, registrationID)
+		VALUES
+		(?, ?, ?)`,
+		ks.incidentID,
+		cert.Serial,
+		cert.RegistrationID,
+	)
+	if err != nil && !db.IsDuplicate(err) {
+		return err
+	}
+	return nil
+}
+
+// scan checks every unexpired precertificate issued within ks.window and
+// returns the number of certificates found with bad keys.
+func (ks *keyScanner) scan(ctx context.Context) (int, error) {
+	earliest := ks.clk.Now().Add(-ks.window)
+	var afterID int64
+	found := 0
+	for {
+		batch, err := ks.selectBatch(earliest, afterID)
+		if err != nil {
+			return found, err
+		}
+		if len(batch) == 0 {
+			return found, nil
+		}
+		afterID = batch[len(batch)-1].ID
+		for _, cert := range batch {
+			err := ks.checkCertificate(ctx, cert)
+			if err == nil {
+				certsScanned.WithLabelValues("good").Inc()
+				continue
+			}
+			if !errors.Is(err, goodkey.ErrBadKey) {
+				certsScanned.WithLabelValues("error").Inc()
+				return found, err
+			}
+			certsScanned.WithLabelValues("bad").Inc()
+			ks.logger.AuditInfof("found certificate with bad key: serial=[%s] regID=[%d] incident=[%d] reason=[%s]",
+				cert.Serial, cert.RegistrationID, ks.incidentID, err)
+			err = ks.recordIncident(cert)
+			if err != nil {
+				return found, err
+			}
+			found++
+		}
+	}
+}
+
+func main() {
+	var config struct {
+		WeakKeyScanner struct {
+			cmd.DBConfig
+			DebugAddr string
+
+			// IncidentID is the ID of the incident, which must already exist in
+			// the incidents table, under which certificates with bad keys are
+			// recorded.
+			IncidentID int64
+			// IncidentsDB, if set, is a separate database holding the incidents
+			// and incidentSerials tables, as configured for the SA.
+			IncidentsDB *cmd.DBConfig
+
+			// WeakKeyFile and BlockedKeyFile are the same files used by the
+			// CA, RA, and WFE key policies.
+			WeakKeyFile    string
+			BlockedKeyFile string
+
+			// Window specifies how far back from the current time to look for
+			// issued certificates to scan.
+			Window cmd.ConfigDuration
+			// BatchSize specifies the maximum number of precertificates to
+			// select from the database at once.
+			BatchSize int
+			// Interval specifies how long to sleep between full scans.
+			Interval cmd.ConfigDuration
+		}
+
+		Syslog cmd.SyslogConfig
+	}
+	configPath := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+
+	if *configPath == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	err := cmd.ReadConfigFile(*configPath, &config)
+	cmd.FailOnError(err, "Failed reading config file")
+	c := config.WeakKeyScanner
+
+	if c.Window.Duration <= 0 {
+		cmd.Fail("WeakKeyScanner.Window must be positive")
+	}
+	if c.BatchSize <= 0 {
+		cmd.Fail("WeakKeyScanner.BatchSize must be positive")
+	}
+	if c.IncidentID <= 0 {
+		cmd.Fail("WeakKeyScanner.IncidentID must be positive")
+	}
+
+	scope, logger := cmd.StatsAndLogging(config.Syslog, c.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+	clk := cmd.Clock()
+
+	scope.MustRegister(certsScanned)
+	scope.MustRegister(scanLatency)
+
+	dbURL, err := c.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettingsFromDBConfig(c.DBConfig))
+	cmd.FailOnError(err, "Could not connect to database")
+	sa.SetSQLDebug(dbMap, logger)
+	sa.InitDBMetrics(dbMap, scope, dbURL, "primary")
+
+	incidentsDBMap := dbMap
+	if c.IncidentsDB != nil {
+		incidentsURL, err := c.IncidentsDB.URL()
+		cmd.FailOnError(err, "Couldn't load incidents DB URL")
+		incidentsDBMap, err = sa.NewDbMapWithSettings(incidentsURL, sa.DbSettingsFromDBConfig(*c.IncidentsDB))
+		cmd.FailOnError(err, "Could not connect to incidents database")
+		sa.SetSQLDebug(incidentsDBMap, logger)
+		sa.InitDBMetrics(incidentsDBMap, scope, incidentsURL, "incidents")
+	}
+
+	ks := &keyScanner{
+		dbMap:          dbMap,
+		incidentsDBMap: incidentsDBMap,
+		incidentID:     c.IncidentID,
+		clk:            clk,
+		window:         c.Window.Duration,
+		batchSize:      c.BatchSize,
+		logger:         logger,
+	}
+	ks.keyPolicy, err = goodkey.NewKeyPolicy(c.WeakKeyFile, c.BlockedKeyFile, ks.keyBlocked)
+	cmd.FailOnError(err, "Unable to create key policy")
+	cmd.FailOnError(ks.checkIncident(), "Unable to find incident")
+
+	go cmd.CatchSignals(logger, nil)
+
+	for {
+		start := clk.Now()
+		found, err := ks.scan(context.Background())
+		if err != nil {
+			logger.AuditErrf("failed to complete weak key scan: %s", err)
+		} else {
+			scanLatency.Observe(clk.Since(start).Seconds())
+			logger.Info(fmt.Sprintf("Scan complete, found %d certificates with bad keys. Sleeping for %s",
+				found, c.Interval.Duration))
+		}
+		clk.Sleep(c.Interval.Duration)
+	}
+}
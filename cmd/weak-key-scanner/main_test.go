@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/db"
+	"github.com/letsencrypt/boulder/goodkey"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/sa"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+func makeCert(t *testing.T, serial int64, notAfter time.Time) ([]byte, *ecdsa.PrivateKey) {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     []string{"example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, k.Public(), k)
+	test.AssertNotError(t, err, "failed to create test certificate")
+	return der, k
+}
+
+// blockKeys returns a goodkey.BlockedKeyCheckFunc that reports every key in
+// keys as blocked.
+func blockKeys(t *testing.T, keys ...*ecdsa.PrivateKey) goodkey.BlockedKeyCheckFunc {
+	t.Helper()
+	blocked := make(map[[32]byte]bool)
+	for _, k := range keys {
+		spki, err := x509.MarshalPKIXPublicKey(k.Public())
+		test.AssertNotError(t, err, "failed to marshal test key")
+		blocked[sha256.Sum256(spki)] = true
+	}
+	return func(_ context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+		var hash [32]byte
+		copy(hash[:], req.KeyHash)
+		exists := blocked[hash]
+		return &sapb.Exists{Exists: &exists}, nil
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	fc := clock.NewFake()
+	goodDER, _ := makeCert(t, 1, fc.Now().Add(time.Hour))
+	badDER, badKey := makeCert(t, 2, fc.Now().Add(time.Hour))
+
+	kp, err := goodkey.NewKeyPolicy("", "", blockKeys(t, badKey))
+	test.AssertNotError(t, err, "failed to create key policy")
+	ks := &keyScanner{keyPolicy: kp, clk: fc, logger: blog.NewMock()}
+
+	err = ks.checkCertificate(context.Background(), issuedCertificate{Serial: "01", DER: goodDER})
+	test.AssertNotError(t, err, "checkCertificate failed for good key")
+
+	err = ks.checkCertificate(context.Background(), issuedCertificate{Serial: "02", DER: badDER})
+	test.AssertError(t, err, "checkCertificate didn't fail for blocked key")
+	test.Assert(t, errors.Is(err, goodkey.ErrBadKey), "checkCertificate didn't return ErrBadKey")
+
+	err = ks.checkCertificate(context.Background(), issuedCertificate{Serial: "03", DER: []byte{1, 2, 3}})
+	test.AssertError(t, err, "checkCertificate didn't fail for malformed certificate")
+	test.Assert(t, !errors.Is(err, goodkey.ErrBadKey), "checkCertificate returned ErrBadKey for malformed certificate")
+}
+
+func insertRegistration(t *testing.T, dbMap *db.WrappedMap) int64 {
+	t.Helper()
+	jwkHash := make([]byte, 2)
+	_, err := rand.Read(jwkHash)
+	test.AssertNotError(t, err, "failed to read rand")
+	res, err := dbMap.Exec(
+		"INSERT INTO registrations (jwk, jwk_sha256, contact, agreement, initialIP, createdAt, status, LockCol) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		[]byte{},
+		fmt.Sprintf("%x", jwkHash),
+		"[]",
+		"yes",
+		[]byte{},
+		time.Now(),
+		string(core.StatusValid),
+		0,
+	)
+	test.AssertNotError(t, err, "failed to insert test registrations row")
+	regID, err := res.LastInsertId()
+	test.AssertNotError(t, err, "failed to get registration ID")
+	return regID
+}
+
+func insertPrecert(t *testing.T, dbMap *db.WrappedMap, serial string, regID int64, der []byte, issued, expires time.Time) {
+	t.Helper()
+	_, err := dbMap.Exec(
+		"INSERT INTO precertificates (serial, registrationID, der, issued, expires) VALUES (?, ?, ?, ?, ?)",
+		serial,
+		regID,
+		der,
+		issued,
+		expires,
+	)
+	test.AssertNotError(t, err, "failed to insert test precertificates row")
+}
+
+func TestScan(t *testing.T) {
+	// The incidents tables only exist in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("incidents tables require config-next database schema")
+	}
+	dbMap, err := sa.NewDbMap(vars.DBConnSAFullPerms, 0)
+	test.AssertNotError(t, err, "failed setting up db client")
+	defer test.ResetSATestDatabase(t)()
+
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	regID := insertRegistration(t, dbMap)
+
+	goodDER, _ := makeCert(t, 1, fc.Now().Add(time.Hour))
+	badDER, badKey := makeCert(t, 2, fc.Now().Add(time.Hour))
+	oldDER, oldKey := makeCert(t, 3, fc.Now().Add(time.Hour))
+	expiredDER, expiredKey := makeCert(t, 4, fc.Now().Add(-time.Hour))
+	insertPrecert(t, dbMap, "01", regID, goodDER, fc.Now(), fc.Now().Add(time.Hour))
+	insertPrecert(t, dbMap, "02", regID, badDER, fc.Now(), fc.Now().Add(time.Hour))
+	insertPrecert(t, dbMap, "03", regID, oldDER, fc.Now().Add(-48*time.Hour), fc.Now().Add(time.Hour))
+	insertPrecert(t, dbMap, "04", regID, expiredDER, fc.Now(), fc.Now().Add(-time.Hour))
+
+	kp, err := goodkey.NewKeyPolicy("", "", blockKeys(t, badKey, oldKey, expiredKey))
+	test.AssertNotError(t, err, "failed to create key policy")
+	ks := &keyScanner{
+		dbMap:          dbMap,
+		incidentsDBMap: dbMap,
+		incidentID:     1,
+		keyPolicy:      kp,
+		clk:            fc,
+		window:         24 * time.Hour,
+		batchSize:      1,
+		logger:         blog.NewMock(),
+	}
+
+	// The incident must exist before anything is recorded under it.
+	err = ks.checkIncident()
+	test.AssertError(t, err, "checkIncident accepted a missing incident")
+	_, err = dbMap.Exec(
+		`INSERT INTO incidents (id, url, renewBy, enabled) VALUES (1, "https://example.com/incident/1", ?, false)`,
+		fc.Now().Add(24*time.Hour))
+	test.AssertNotError(t, err, "failed to insert test incidents row")
+	err = ks.checkIncident()
+	test.AssertNotError(t, err, "checkIncident failed")
+
+	// Only the blocked certificate that is both unexpired and inside the
+	// scan window should be recorded.
+	found, err := ks.scan(context.Background())
+	test.AssertNotError(t, err, "scan failed")
+	test.AssertEquals(t, found, 1)
+	var serials []string
+	_, err = dbMap.Select(&serials, "SELECT serial FROM incidentSerials WHERE incidentID = 1")
+	test.AssertNotError(t, err, "failed to select incidents")
+	test.AssertDeepEquals(t, serials, []string{"02"})
+
+	// A second scan should not fail on the already recorded incident.
+	found, err = ks.scan(context.Background())
+	test.AssertNotError(t, err, "second scan failed")
+	test.AssertEquals(t, found, 1)
+	var count int
+	err = dbMap.SelectOne(&count, "SELECT COUNT(*) FROM incidentSerials")
+	test.AssertNotError(t, err, "failed to count incidents")
+	test.AssertEquals(t, count, 1)
+}
@@ -25,3 +25,9 @@ func DNSChallenge01(token string) Challenge {
 func TLSALPNChallenge01(token string) Challenge {
 	return newChallenge(ChallengeTypeTLSALPN01, token)
 }
+
+// OnionCSRChallenge01 constructs a random onion-csr-01 challenge. If token is
+// empty a random token will be generated, otherwise the provided token is used.
+func OnionCSRChallenge01(token string) Challenge {
+	return newChallenge(ChallengeTypeOnionCSR01, token)
+}
@@ -90,6 +90,21 @@ type RegistrationAuthority interface {
 
 	// [AdminRevoker]
 	AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, code revocation.Reason, adminName string) error
+
+	// [WebFrontEnd]
+	SetRevocationWebhook(ctx context.Context, req *rapb.SetRevocationWebhookRequest) (*corepb.Empty, error)
+
+	// [SelfServiceFrontEnd]
+	UnpauseAccount(ctx context.Context, req *rapb.UnpauseAccountRequest) (*corepb.Empty, error)
+
+	// [WebFrontEnd]
+	RevokeCertByKey(ctx context.Context, req *rapb.RevokeCertByKeyRequest) (*corepb.Empty, error)
+
+	// [AdminRevoker]
+	// AdministrativelyRevokeCertificates calls recv for each request until it
+	// returns io.EOF, rather than taking them all at once, since a mass
+	// revocation may cover very many certificates.
+	AdministrativelyRevokeCertificates(ctx context.Context, recv func() (*rapb.AdministrativelyRevokeCertificatesRequest, error)) (*rapb.AdministrativelyRevokeCertificatesResponse, error)
 }
 
 // ValidationAuthority defines the public interface for the Boulder VA
@@ -128,9 +143,11 @@ type StorageGetter interface {
 	CountOrders(ctx context.Context, acctID int64, earliest, latest time.Time) (int, error)
 	CountFQDNSets(ctx context.Context, window time.Duration, domains []string) (count int64, err error)
 	FQDNSetExists(ctx context.Context, domains []string) (exists bool, err error)
+	FQDNSetIssuedWithin(ctx context.Context, window time.Duration, domains []string) (issued bool, err error)
 	PreviousCertificateExists(ctx context.Context, req *sapb.PreviousCertificateExistsRequest) (exists *sapb.Exists, err error)
 	GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error)
 	GetOrderForNames(ctx context.Context, req *sapb.GetOrderForNamesRequest) (*corepb.Order, error)
+	GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error)
 	// New authz2 methods
 	GetAuthorization2(ctx context.Context, req *sapb.AuthorizationID2) (*corepb.Authorization, error)
 	GetAuthorizations2(ctx context.Context, req *sapb.GetAuthorizationsRequest) (*sapb.Authorizations, error)
@@ -140,6 +157,24 @@ type StorageGetter interface {
 	CountInvalidAuthorizations2(ctx context.Context, req *sapb.CountInvalidAuthorizationsRequest) (*sapb.Count, error)
 	GetValidAuthorizations2(ctx context.Context, req *sapb.GetValidAuthorizationsRequest) (*sapb.Authorizations, error)
 	KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error)
+	GetRevocationWebhook(ctx context.Context, req *sapb.RegistrationID) (*sapb.RevocationWebhook, error)
+	GetSerialMetadata(ctx context.Context, req *sapb.Serial) (*sapb.SerialMetadata, error)
+	ReplacementOrderExists(ctx context.Context, req *sapb.Serial) (*sapb.Exists, error)
+	GetPausedIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*sapb.PausedIdentifiers, error)
+	CountPaused(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error)
+	GetSerialsByKey(ctx context.Context, req *sapb.SPKIHash) (*sapb.Serials, error)
+	// GetSerialsByKeyHash calls send with each serial as it's read, rather than
+	// returning them all at once, since a widely shared key may have been used
+	// for very many certificates.
+	GetSerialsByKeyHash(ctx context.Context, req *sapb.SPKIHash, send func(serial string) error) error
+	GetRevokedCertsByShard(ctx context.Context, req *sapb.GetRevokedCertsByShardRequest) (*sapb.RevokedCerts, error)
+	IncidentsForSerial(ctx context.Context, req *sapb.Serial) (*sapb.Incidents, error)
+	// SerialsForIncident calls send with each serial affected by the incident
+	// as it's read, since an incident may affect very many certificates.
+	SerialsForIncident(ctx context.Context, req *sapb.SerialsForIncidentRequest, send func(*sapb.IncidentSerial) error) error
+	GetIncident(ctx context.Context, req *sapb.GetIncidentRequest) (*sapb.Incident, error)
+	GetIssuanceOutcome(ctx context.Context, req *sapb.Serial) (*sapb.IssuanceOutcome, error)
+	GetRateLimitOverrides(ctx context.Context, req *sapb.GetRateLimitOverridesRequest) (*sapb.RateLimitOverrides, error)
 }
 
 // StorageAdder are the Boulder SA's write/update methods
@@ -149,8 +184,10 @@ type StorageAdder interface {
 	AddCertificate(ctx context.Context, der []byte, regID int64, ocsp []byte, issued *time.Time) (digest string, err error)
 	AddPrecertificate(ctx context.Context, req *sapb.AddCertificateRequest) (*corepb.Empty, error)
 	AddSerial(ctx context.Context, req *sapb.AddSerialRequest) (*corepb.Empty, error)
+	RecordIssuanceFailure(ctx context.Context, req *sapb.IssuanceFailure) (*corepb.Empty, error)
 	DeactivateRegistration(ctx context.Context, id int64) error
 	NewOrder(ctx context.Context, order *corepb.Order) (*corepb.Order, error)
+	NewOrderAndAuthzs(ctx context.Context, req *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error)
 	SetOrderProcessing(ctx context.Context, order *corepb.Order) error
 	FinalizeOrder(ctx context.Context, order *corepb.Order) error
 	SetOrderError(ctx context.Context, order *corepb.Order) error
@@ -160,6 +197,12 @@ type StorageAdder interface {
 	FinalizeAuthorization2(ctx context.Context, req *sapb.FinalizeAuthorizationRequest) error
 	DeactivateAuthorization2(ctx context.Context, req *sapb.AuthorizationID2) (*corepb.Empty, error)
 	AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) (*corepb.Empty, error)
+	SetRevocationWebhook(ctx context.Context, req *sapb.RevocationWebhook) (*corepb.Empty, error)
+	PauseIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*corepb.Empty, error)
+	UnpauseAccount(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error)
+	AddRateLimitOverride(ctx context.Context, req *sapb.RateLimitOverride) (*sapb.RateLimitOverride, error)
+	ExpireRateLimitOverride(ctx context.Context, req *sapb.ExpireRateLimitOverrideRequest) (*corepb.Empty, error)
+	AddIncidentSerial(ctx context.Context, req *sapb.AddIncidentSerialRequest) (*corepb.Empty, error)
 }
 
 // StorageAuthority interface represents a simple key/value
@@ -57,12 +57,15 @@ const (
 	ChallengeTypeHTTP01    = AcmeChallenge("http-01")
 	ChallengeTypeDNS01     = AcmeChallenge("dns-01")
 	ChallengeTypeTLSALPN01 = AcmeChallenge("tls-alpn-01")
+	// ChallengeTypeOnionCSR01 proves control of a version 3 .onion name with
+	// a CSR signed by the hidden service's key.
+	ChallengeTypeOnionCSR01 = AcmeChallenge("onion-csr-01")
 )
 
 // IsValid tests whether the challenge is a known challenge
 func (c AcmeChallenge) IsValid() bool {
 	switch c {
-	case ChallengeTypeHTTP01, ChallengeTypeDNS01, ChallengeTypeTLSALPN01:
+	case ChallengeTypeHTTP01, ChallengeTypeDNS01, ChallengeTypeTLSALPN01, ChallengeTypeOnionCSR01:
 		return true
 	default:
 		return false
@@ -168,6 +171,19 @@ type ValidationRecord struct {
 	//   ...
 	// }
 	AddressesTried []net.IP `json:"addressesTried,omitempty"`
+
+	// ResponseStatus is the status line (e.g. "301 Moved Permanently") of the
+	// HTTP response received from the URL, for HTTP-01 challenges. Together
+	// with the records for each redirect followed, it lets subscribers see
+	// how a validation request was answered.
+	ResponseStatus string `json:"responseStatus,omitempty"`
+	// ResponseHeaders holds the values of the headers of interest, such as
+	// Server and Via, in that HTTP response. They identify the software and
+	// proxies which answered, to help investigate abuse.
+	ResponseHeaders map[string]string `json:"responseHeaders,omitempty"`
+	// DNSAnswers contains the TXT records found for DNS-01 challenges,
+	// truncated if there were many or they were long.
+	DNSAnswers []string `json:"dnsAnswers,omitempty"`
 }
 
 func looksLikeKeyAuthorization(str string) error {
@@ -208,6 +224,10 @@ type Challenge struct {
 	// Used by http-01, tls-sni-01, tls-alpn-01 and dns-01 challenges
 	Token string `json:"token,omitempty"`
 
+	// Nonce is the token of an onion-csr-01 challenge, which is presented to
+	// clients under this name instead.
+	Nonce string `json:"nonce,omitempty"`
+
 	// The expected KeyAuthorization for validation of the challenge. Populated by
 	// the RA prior to passing the challenge to the VA. For legacy reasons this
 	// field is called "ProvidedKeyAuthorization" because it was initially set by
@@ -219,6 +239,11 @@ type Challenge struct {
 	// Contains information about URLs used or redirected to and IPs resolved and
 	// used
 	ValidationRecord []ValidationRecord `json:"validationRecord,omitempty"`
+
+	// OnionCSRResponse is the client's response to an onion-csr-01 challenge,
+	// passed from the WFE to the VA with the challenge. It's never stored or
+	// shown to clients.
+	OnionCSRResponse *OnionCSRResponse `json:"-"`
 }
 
 // ExpectedKeyAuthorization computes the expected KeyAuthorization value for
@@ -262,7 +287,7 @@ func (ch Challenge) RecordsSane() bool {
 			ch.ValidationRecord[0].AddressUsed == nil || len(ch.ValidationRecord[0].AddressesResolved) == 0 {
 			return false
 		}
-	case ChallengeTypeDNS01:
+	case ChallengeTypeDNS01, ChallengeTypeOnionCSR01:
 		if len(ch.ValidationRecord) > 1 {
 			return false
 		}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// onionSuffix is the special-use domain (RFC 7686) of Tor hidden services.
+const onionSuffix = ".onion"
+
+// onionV3Version is the version byte of version 3 onion addresses.
+const onionV3Version = 0x03
+
+// onionV3AddressLength is the length of the base32 label of a version 3 onion
+// address: a 32 byte public key, 2 byte checksum and 1 byte version.
+const onionV3AddressLength = 56
+
+var onionEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// IsOnionName returns true if name is, or is a subdomain of, a .onion name.
+func IsOnionName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), onionSuffix)
+}
+
+// onionChecksum computes the checksum of a version 3 onion address for the
+// given public key.
+func onionChecksum(pub ed25519.PublicKey) []byte {
+	h := sha3.New256()
+	h.Write([]byte(".onion checksum"))
+	h.Write(pub)
+	h.Write([]byte{onionV3Version})
+	return h.Sum(nil)[:2]
+}
+
+// OnionPublicKey returns the hidden service public key encoded in a version 3
+// .onion name, which may have subdomains (e.g. "www.<address>.onion") or be a
+// wildcard. An error is returned if the name isn't a well formed version 3
+// onion address.
+func OnionPublicKey(name string) (ed25519.PublicKey, error) {
+	if !IsOnionName(name) {
+		return nil, errors.New("not a .onion name")
+	}
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(name), onionSuffix), ".")
+	address := labels[len(labels)-1]
+	if len(address) != onionV3AddressLength {
+		return nil, fmt.Errorf("%q is not a version 3 onion address", address)
+	}
+	decoded, err := onionEncoding.DecodeString(strings.ToUpper(address))
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid onion address: %s", address, err)
+	}
+	pub := ed25519.PublicKey(decoded[:ed25519.PublicKeySize])
+	checksum := decoded[ed25519.PublicKeySize : ed25519.PublicKeySize+2]
+	version := decoded[ed25519.PublicKeySize+2]
+	if version != onionV3Version {
+		return nil, fmt.Errorf("%q has unsupported onion address version %d", address, version)
+	}
+	if !bytes.Equal(checksum, onionChecksum(pub)) {
+		return nil, fmt.Errorf("%q has an incorrect onion address checksum", address)
+	}
+	return pub, nil
+}
+
+// OnionAddress returns the version 3 .onion name of the hidden service with
+// the given public key.
+func OnionAddress(pub ed25519.PublicKey) string {
+	var raw []byte
+	raw = append(raw, pub...)
+	raw = append(raw, onionChecksum(pub)...)
+	raw = append(raw, onionV3Version)
+	return strings.ToLower(onionEncoding.EncodeToString(raw)) + onionSuffix
+}
+
+// OnionCSRResponse is the payload of a client's response to an onion-csr-01
+// challenge.
+type OnionCSRResponse struct {
+	// CSR is the base64url encoded DER of a CSR signed by the hidden service's
+	// key, carrying the challenge's nonce.
+	CSR string `json:"csr"`
+	// OnionCAA optionally carries the hidden service's CAA records, since they
+	// can't be published in the DNS.
+	OnionCAA *OnionCAA `json:"onionCAA,omitempty"`
+}
+
+// OnionCAA is a set of CAA records for a hidden service, signed by its key.
+type OnionCAA struct {
+	// CAA contains the records in zone file presentation format, one per line,
+	// e.g. `caa 0 issue "letsencrypt.org"`.
+	CAA string `json:"caa"`
+	// Expiry is the Unix time after which the records must not be relied on.
+	Expiry int64 `json:"expiry"`
+	// Signature is the base64url encoded Ed25519 signature over SignedData.
+	Signature string `json:"signature"`
+}
+
+// SignedData returns the message the OnionCAA's signature covers:
+// "onion-caa|<expiry>|<caa>".
+func (o OnionCAA) SignedData() []byte {
+	return []byte(fmt.Sprintf("onion-caa|%d|%s", o.Expiry, o.CAA))
+}
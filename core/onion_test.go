@@ -0,0 +1,44 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestOnionPublicKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	address := OnionAddress(pub)
+	test.AssertEquals(t, len(address), onionV3AddressLength+len(onionSuffix))
+	test.Assert(t, IsOnionName(address), "address isn't an onion name")
+
+	for _, name := range []string{address, "www." + address, "*." + address, strings.ToUpper(address)} {
+		key, err := OnionPublicKey(name)
+		test.AssertNotError(t, err, "parsing "+name)
+		test.AssertDeepEquals(t, key, pub)
+	}
+
+	// Flipping a character of the key invalidates the checksum.
+	tampered := []byte(address)
+	if tampered[0] == 'a' {
+		tampered[0] = 'b'
+	} else {
+		tampered[0] = 'a'
+	}
+	_, err = OnionPublicKey(string(tampered))
+	test.AssertError(t, err, "parsed address with a bad checksum")
+
+	for _, name := range []string{
+		"example.com",
+		"expyuzz4wqqyqhjn.onion",
+		"onion",
+		strings.Repeat("0", onionV3AddressLength) + onionSuffix,
+	} {
+		_, err = OnionPublicKey(name)
+		test.AssertError(t, err, "parsed invalid onion name "+name)
+	}
+}
@@ -87,6 +87,14 @@ func Fingerprint256(data []byte) string {
 	return base64.RawURLEncoding.EncodeToString(d.Sum(nil))
 }
 
+// IssuerID generates a stable ID for an issuer certificate. This is used for
+// identifying which issuer issued a certificate in the certificateStatus and
+// revokedCertificates tables.
+func IssuerID(issuer *x509.Certificate) int64 {
+	h := sha256.Sum256(issuer.Raw)
+	return big.NewInt(0).SetBytes(h[:4]).Int64()
+}
+
 type Sha256Digest [sha256.Size]byte
 
 // KeyDigest produces a Base64-encoded SHA256 digest of a
@@ -151,9 +159,15 @@ func PublicKeysEqual(a, b interface{}) (bool, error) {
 }
 
 // SerialToString converts a certificate serial number (big.Int) to a String
-// consistently.
+// consistently. The string is at least 36 characters long, and always an even
+// number of characters, so that serials longer than 18 bytes whose first byte
+// is below 0x10 keep their leading zero.
 func SerialToString(serial *big.Int) string {
-	return fmt.Sprintf("%036x", serial)
+	s := fmt.Sprintf("%036x", serial)
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return s
 }
 
 // StringToSerial converts a string into a certificate serial number (big.Int)
@@ -163,18 +177,24 @@ func StringToSerial(serial string) (*big.Int, error) {
 	if !ValidSerial(serial) {
 		return &serialNum, errors.New("Invalid serial number")
 	}
-	_, err := fmt.Sscanf(serial, "%036x", &serialNum)
-	return &serialNum, err
+	_, ok := serialNum.SetString(serial, 16)
+	if !ok {
+		return &serialNum, errors.New("Invalid serial number")
+	}
+	return &serialNum, nil
 }
 
 // ValidSerial tests whether the input string represents a syntactically
-// valid serial number, i.e., that it is a valid hex string between 32
-// and 36 characters long.
+// valid serial number, i.e., that it is a valid hex string 32, 36, 38 or 40
+// characters long.
 func ValidSerial(serial string) bool {
 	// Originally, serial numbers were 32 hex characters long. We later increased
 	// them to 36, but we allow the shorter ones because they exist in some
-	// production databases.
-	if len(serial) != 32 && len(serial) != 36 {
+	// production databases. CAs may be configured to issue serials of up to 20
+	// bytes, the most RFC 5280 allows, which are 38 or 40 characters long.
+	switch len(serial) {
+	case 32, 36, 38, 40:
+	default:
 		return false
 	}
 	_, err := hex.DecodeString(serial)
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -53,6 +54,39 @@ func TestSerialUtils(t *testing.T) {
 	badSerial, err := StringToSerial("doop!!!!000")
 	test.AssertEquals(t, fmt.Sprintf("%v", err), "Invalid serial number")
 	fmt.Println(badSerial)
+
+	// Serials longer than 18 bytes round trip too.
+	long, ok := new(big.Int).SetString("7f0102030405060708090a0b0c0d0e0f10111213", 16)
+	test.Assert(t, ok, "Couldn't parse long serial")
+	serial = SerialToString(long)
+	test.AssertEquals(t, serial, "7f0102030405060708090a0b0c0d0e0f10111213")
+	serialNum, err = StringToSerial(serial)
+	test.AssertNotError(t, err, "Couldn't convert long serial number to *big.Int")
+	test.AssertEquals(t, serialNum.Cmp(long), 0)
+}
+
+func TestSerialRoundTrip(t *testing.T) {
+	// Serials of every allowed length round trip, including those whose first
+	// byte is below 0x10, which need a leading zero to be valid hex.
+	for _, length := range []int{18, 19, 20} {
+		for _, first := range []byte{0x01, 0x0f, 0x10, 0x7f} {
+			serialBytes := make([]byte, length)
+			serialBytes[0] = first
+			for i := 1; i < length; i++ {
+				serialBytes[i] = byte(i)
+			}
+			serial := new(big.Int).SetBytes(serialBytes)
+			str := SerialToString(serial)
+			test.AssertEquals(t, len(str), 2*length)
+			test.Assert(t, ValidSerial(str), fmt.Sprintf("serial %q isn't valid", str))
+			decoded, err := hex.DecodeString(str)
+			test.AssertNotError(t, err, "serial isn't valid hex")
+			test.AssertByteEquals(t, decoded, serialBytes)
+			parsed, err := StringToSerial(str)
+			test.AssertNotError(t, err, "StringToSerial failed")
+			test.AssertEquals(t, parsed.Cmp(serial), 0)
+		}
+	}
 }
 
 func TestBuildID(t *testing.T) {
@@ -156,6 +190,12 @@ func TestValidSerial(t *testing.T) {
 	test.AssertEquals(t, isValidSerial, true)
 	isValidSerial = ValidSerial(length36)
 	test.AssertEquals(t, isValidSerial, true)
+	isValidSerial = ValidSerial(strings.Repeat("A", 38))
+	test.AssertEquals(t, isValidSerial, true)
+	isValidSerial = ValidSerial(strings.Repeat("A", 40))
+	test.AssertEquals(t, isValidSerial, true)
+	isValidSerial = ValidSerial(strings.Repeat("A", 42))
+	test.AssertEquals(t, isValidSerial, false)
 }
 
 func TestRetryBackoff(t *testing.T) {
@@ -2,15 +2,15 @@ package csr
 
 import (
 	"context"
-	"crypto"
 	"crypto/x509"
-	"errors"
+	"net"
+	"sort"
 	"strings"
 
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
-	"github.com/letsencrypt/boulder/identifier"
 )
 
 // maxCNLength is the maximum length allowed for the common name as specified in RFC 5280
@@ -31,6 +31,9 @@ var goodSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
 	x509.ECDSAWithSHA256: true,
 	x509.ECDSAWithSHA384: true,
 	x509.ECDSAWithSHA512: true,
+	// PureEd25519 can only be used with an Ed25519 key, which the key policy
+	// rejects unless the Ed25519Issuance feature is enabled.
+	x509.PureEd25519: true,
 }
 
 var (
@@ -45,56 +48,27 @@ var (
 
 // VerifyCSR checks the validity of a x509.CertificateRequest. Before doing checks it normalizes
 // the CSR which lowers the case of DNS names and subject CN, and hoist a DNS name into the CN
-// if it is empty.
+// if it is empty. Its checks are those of a Policy with no additional configuration.
 func VerifyCSR(ctx context.Context, csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.KeyPolicy, pa core.PolicyAuthority, regID int64) error {
-	normalizeCSR(csr)
-	key, ok := csr.PublicKey.(crypto.PublicKey)
-	if !ok {
-		return invalidPubKey
+	policy, err := NewPolicy(PolicyConfig{}, maxNames, keyPolicy, pa)
+	if err != nil {
+		return berrors.InternalServerError("building CSR policy: %s", err)
 	}
-	if err := keyPolicy.GoodKey(ctx, key); err != nil {
-		if errors.Is(err, goodkey.ErrBadKey) {
-			return berrors.BadCSRError("invalid public key in CSR: %s", err)
-		}
-		return berrors.InternalServerError("error checking key validity: %s", err)
-	}
-	if !goodSignatureAlgorithms[csr.SignatureAlgorithm] {
-		return unsupportedSigAlg
-	}
-	if err := csr.CheckSignature(); err != nil {
-		return invalidSig
-	}
-	if len(csr.EmailAddresses) > 0 {
-		return invalidEmailPresent
-	}
-	if len(csr.IPAddresses) > 0 {
-		return invalidIPPresent
-	}
-	if len(csr.DNSNames) == 0 && csr.Subject.CommonName == "" {
-		return invalidNoDNS
-	}
-	if csr.Subject.CommonName == "" {
-		return invalidAllSANTooLong
-	}
-	if len(csr.Subject.CommonName) > maxCNLength {
-		return berrors.BadCSRError("CN was longer than %d bytes", maxCNLength)
-	}
-	if len(csr.DNSNames) > maxNames {
-		return berrors.BadCSRError("CSR contains more than %d DNS names", maxNames)
-	}
-	idents := make([]identifier.ACMEIdentifier, len(csr.DNSNames))
-	for i, dnsName := range csr.DNSNames {
-		idents[i] = identifier.DNSIdentifier(dnsName)
-	}
-	if err := pa.WillingToIssueWildcards(idents); err != nil {
-		return err
-	}
-	return nil
+	return policy.Verify(ctx, csr)
 }
 
 // normalizeCSR deduplicates and lowers the case of dNSNames and the subject CN.
-// It will also hoist a dNSName into the CN if it is empty.
+// It will also hoist a dNSName into the CN if it is empty. With the
+// IPIdentifiers feature enabled, a CN holding an IP address is moved into the
+// iPAddresses, which are deduplicated.
 func normalizeCSR(csr *x509.CertificateRequest) {
+	if features.Enabled(features.IPIdentifiers) {
+		if ip := net.ParseIP(csr.Subject.CommonName); ip != nil {
+			csr.IPAddresses = append(csr.IPAddresses, ip)
+			csr.Subject.CommonName = ""
+		}
+		csr.IPAddresses = uniqueIPs(csr.IPAddresses)
+	}
 	if csr.Subject.CommonName == "" {
 		var forcedCN string
 		// Promote the first SAN that is less than maxCNLength (if any)
@@ -111,3 +85,34 @@ func normalizeCSR(csr *x509.CertificateRequest) {
 	csr.Subject.CommonName = strings.ToLower(csr.Subject.CommonName)
 	csr.DNSNames = core.UniqueLowerNames(csr.DNSNames)
 }
+
+// uniqueIPs returns the unique addresses in ips, sorted by their textual form.
+// IPv4 addresses are returned in their 4 byte form.
+func uniqueIPs(ips []net.IP) []net.IP {
+	seen := make(map[string]net.IP)
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		seen[ip.String()] = ip
+	}
+	var unique []net.IP
+	for _, ip := range seen {
+		unique = append(unique, ip)
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].String() < unique[j].String()
+	})
+	return unique
+}
+
+// NamesFromCSR returns the unique, lowercased DNS names and the IP addresses,
+// in their textual form, which a CSR requests a certificate for. These are the
+// identifier values which must match an order's names.
+func NamesFromCSR(csr *x509.CertificateRequest) []string {
+	names := core.UniqueLowerNames(csr.DNSNames)
+	for _, ip := range uniqueIPs(csr.IPAddresses) {
+		names = append(names, ip.String())
+	}
+	return names
+}
@@ -2,6 +2,7 @@ package csr
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
 	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/test"
@@ -226,3 +228,69 @@ func TestNormalizeCSR(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyCSREd25519(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "error generating test key")
+	reqBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "a.com"},
+		DNSNames: []string{"a.com"},
+	}, private)
+	test.AssertNotError(t, err, "error generating test CSR")
+	req, err := x509.ParseCertificateRequest(reqBytes)
+	test.AssertNotError(t, err, "error parsing test CSR")
+
+	err = VerifyCSR(context.Background(), req, 100, testingPolicy, &mockPA{}, 0)
+	test.AssertError(t, err, "VerifyCSR accepted Ed25519 CSR with feature disabled")
+	test.Assert(t, berrors.Is(err, berrors.BadCSR), "wrong error type")
+
+	err = features.Set(map[string]bool{"Ed25519Issuance": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	err = VerifyCSR(context.Background(), req, 100, testingPolicy, &mockPA{}, 0)
+	test.AssertNotError(t, err, "VerifyCSR rejected Ed25519 CSR")
+}
+
+func TestVerifyCSRIPAddresses(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "error generating test key")
+	reqBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: "64.112.117.1"},
+		IPAddresses: []net.IP{net.ParseIP("64.112.117.1"), net.ParseIP("2602:80a:6000::1")},
+	}, private)
+	test.AssertNotError(t, err, "error generating test CSR")
+	parse := func() *x509.CertificateRequest {
+		req, err := x509.ParseCertificateRequest(reqBytes)
+		test.AssertNotError(t, err, "error parsing test CSR")
+		return req
+	}
+
+	err = VerifyCSR(context.Background(), parse(), 100, testingPolicy, &mockPA{}, 0)
+	test.AssertEquals(t, err, invalidIPPresent)
+
+	err = features.Set(map[string]bool{"IPIdentifiers": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	req := parse()
+	err = VerifyCSR(context.Background(), req, 100, testingPolicy, &mockPA{}, 0)
+	test.AssertNotError(t, err, "VerifyCSR rejected CSR with IP addresses")
+	// The IP address in the CN was moved into the IP addresses, and the
+	// duplicate removed.
+	test.AssertEquals(t, req.Subject.CommonName, "")
+	test.AssertEquals(t, len(req.DNSNames), 0)
+	test.AssertDeepEquals(t, NamesFromCSR(req), []string{"2602:80a:6000::1", "64.112.117.1"})
+
+	// IP addresses count towards the maximum number of names.
+	err = VerifyCSR(context.Background(), parse(), 1, testingPolicy, &mockPA{}, 0)
+	test.AssertError(t, err, "VerifyCSR accepted CSR with too many names")
+}
+
+func TestNamesFromCSR(t *testing.T) {
+	names := NamesFromCSR(&x509.CertificateRequest{
+		DNSNames:    []string{"B.com", "a.com", "b.com"},
+		IPAddresses: []net.IP{net.ParseIP("64.112.117.1"), net.IPv4(64, 112, 117, 1).To4()},
+	})
+	test.AssertDeepEquals(t, names, []string{"a.com", "b.com", "64.112.117.1"})
+}
@@ -0,0 +1,315 @@
+package csr
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/identifier"
+)
+
+// oidSubjectAltName is the OID of the subjectAltName extension, which every
+// CSR may request, since it's how names are requested.
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// PolicyConfig configures the checks which CSRs for a certificate profile must
+// pass in addition to those every CSR must pass. Its zero value adds none.
+type PolicyConfig struct {
+	// MaxNames, if non-zero, is the most names a CSR may request, in place of
+	// the RA's MaxNames.
+	MaxNames int
+	// KeyTypes, if not empty, restricts CSRs to public keys of the listed
+	// types: "RSA", "ECDSA" or "Ed25519". The key must also be acceptable to
+	// the key policy.
+	KeyTypes []string
+	// SignatureAlgorithms, if not empty, restricts CSRs to being signed with
+	// the listed algorithms, named as by x509.SignatureAlgorithm's String
+	// method, e.g. "SHA256-RSA" or "ECDSA-SHA256". Each must be one accepted
+	// for every CSR.
+	SignatureAlgorithms []string
+	// AllowedExtensions, if not nil, lists the OIDs, in dotted form, of the
+	// only extensions which CSRs may request besides the subjectAltName.
+	AllowedExtensions []string
+	// IdentifierTypes, if not empty, restricts CSRs to requesting names of the
+	// listed identifier types, "dns" or "ip", for profiles whose certificates
+	// shouldn't include the others. IP addresses are still only accepted if
+	// the IPIdentifiers feature is enabled.
+	IdentifierTypes []string
+	// ForbidWildcards refuses CSRs requesting wildcard DNS names, for profiles
+	// whose certificates shouldn't include them.
+	ForbidWildcards bool
+}
+
+// check is a single step of a Policy. It returns a berrors.BadCSR error if the
+// CSR fails the check, or another error if the check couldn't be completed.
+type check func(ctx context.Context, csr *x509.CertificateRequest) error
+
+// Policy is the pipeline of checks which a CSR must pass for a certificate to
+// be issued for it, in the order they're run.
+type Policy struct {
+	checks []check
+}
+
+// NewPolicy returns the Policy for CSRs of a certificate profile configured by
+// config: the checks every CSR must pass, using maxNames, keyPolicy and pa, with
+// those config adds or replaces.
+func NewPolicy(config PolicyConfig, maxNames int, keyPolicy *goodkey.KeyPolicy, pa core.PolicyAuthority) (*Policy, error) {
+	if config.MaxNames < 0 {
+		return nil, fmt.Errorf("MaxNames must not be negative, got %d", config.MaxNames)
+	}
+	if config.MaxNames != 0 {
+		maxNames = config.MaxNames
+	}
+
+	checks := []check{checkKey(keyPolicy)}
+	if len(config.KeyTypes) > 0 {
+		keyTypes := make(map[string]bool, len(config.KeyTypes))
+		for _, keyType := range config.KeyTypes {
+			if keyType != "RSA" && keyType != "ECDSA" && keyType != "Ed25519" {
+				return nil, fmt.Errorf("unknown key type %q", keyType)
+			}
+			keyTypes[keyType] = true
+		}
+		checks = append(checks, checkKeyType(keyTypes))
+	}
+
+	sigAlgs := goodSignatureAlgorithms
+	if len(config.SignatureAlgorithms) > 0 {
+		sigAlgs = make(map[x509.SignatureAlgorithm]bool, len(config.SignatureAlgorithms))
+		for _, name := range config.SignatureAlgorithms {
+			alg, ok := signatureAlgorithmByName(name)
+			if !ok {
+				return nil, fmt.Errorf("signature algorithm %q isn't one accepted for CSRs", name)
+			}
+			sigAlgs[alg] = true
+		}
+	}
+	checks = append(checks,
+		checkSignature(sigAlgs),
+		checkNoEmailAddresses,
+		checkIPAddresses,
+	)
+
+	if len(config.IdentifierTypes) > 0 || config.ForbidWildcards {
+		var identTypes map[identifier.IdentifierType]bool
+		if len(config.IdentifierTypes) > 0 {
+			identTypes = make(map[identifier.IdentifierType]bool, len(config.IdentifierTypes))
+			for _, identType := range config.IdentifierTypes {
+				t := identifier.IdentifierType(identType)
+				if t != identifier.DNS && t != identifier.IP {
+					return nil, fmt.Errorf("unknown identifier type %q", identType)
+				}
+				identTypes[t] = true
+			}
+		}
+		checks = append(checks, checkProfileNames(identTypes, config.ForbidWildcards))
+	}
+
+	checks = append(checks,
+		checkCommonName,
+		checkMaxNames(maxNames),
+	)
+
+	if config.AllowedExtensions != nil {
+		allowed := map[string]bool{oidSubjectAltName.String(): true}
+		for _, s := range config.AllowedExtensions {
+			oid, err := parseOID(s)
+			if err != nil {
+				return nil, err
+			}
+			// Extensions are looked up by their OID's canonical form, so
+			// "1.03.6" allows the same extension as "1.3.6".
+			allowed[oid.String()] = true
+		}
+		checks = append(checks, checkExtensions(allowed))
+	}
+
+	checks = append(checks, checkWillingToIssue(pa))
+	return &Policy{checks: checks}, nil
+}
+
+// Verify normalizes csr, as VerifyCSR does, then runs each of the policy's
+// checks in turn, returning the first failure.
+func (p *Policy) Verify(ctx context.Context, csr *x509.CertificateRequest) error {
+	normalizeCSR(csr)
+	for _, check := range p.checks {
+		err := check(ctx, csr)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signatureAlgorithmByName returns the signature algorithm accepted for every
+// CSR with the given name.
+func signatureAlgorithmByName(name string) (x509.SignatureAlgorithm, bool) {
+	for alg := range goodSignatureAlgorithms {
+		if alg.String() == name {
+			return alg, true
+		}
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
+
+// parseOID parses an OID in dotted form, e.g. "1.3.6.1.5.5.7.1.24".
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+	oid := make(asn1.ObjectIdentifier, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid OID %q", s)
+		}
+		oid[i] = n
+	}
+	return oid, nil
+}
+
+func checkKey(keyPolicy *goodkey.KeyPolicy) check {
+	return func(ctx context.Context, csr *x509.CertificateRequest) error {
+		key, ok := csr.PublicKey.(crypto.PublicKey)
+		if !ok {
+			return invalidPubKey
+		}
+		if err := keyPolicy.GoodKey(ctx, key); err != nil {
+			if errors.Is(err, goodkey.ErrBadKey) {
+				return berrors.BadCSRError("invalid public key in CSR: %s", err)
+			}
+			return berrors.InternalServerError("error checking key validity: %s", err)
+		}
+		return nil
+	}
+}
+
+func checkKeyType(keyTypes map[string]bool) check {
+	return func(_ context.Context, csr *x509.CertificateRequest) error {
+		var keyType string
+		switch csr.PublicKey.(type) {
+		case *rsa.PublicKey:
+			keyType = "RSA"
+		case *ecdsa.PublicKey:
+			keyType = "ECDSA"
+		case ed25519.PublicKey:
+			keyType = "Ed25519"
+		}
+		if !keyTypes[keyType] {
+			return berrors.BadCSRError("public key type not supported for the requested certificate profile")
+		}
+		return nil
+	}
+}
+
+func checkSignature(sigAlgs map[x509.SignatureAlgorithm]bool) check {
+	return func(_ context.Context, csr *x509.CertificateRequest) error {
+		if !sigAlgs[csr.SignatureAlgorithm] {
+			return unsupportedSigAlg
+		}
+		if err := csr.CheckSignature(); err != nil {
+			return invalidSig
+		}
+		return nil
+	}
+}
+
+func checkNoEmailAddresses(_ context.Context, csr *x509.CertificateRequest) error {
+	if len(csr.EmailAddresses) > 0 {
+		return invalidEmailPresent
+	}
+	return nil
+}
+
+func checkIPAddresses(_ context.Context, csr *x509.CertificateRequest) error {
+	if len(csr.IPAddresses) > 0 && !features.Enabled(features.IPIdentifiers) {
+		return invalidIPPresent
+	}
+	return nil
+}
+
+// checkProfileNames refuses CSRs requesting names the certificate profile's
+// certificates shouldn't include: those of identifier types not in
+// identTypes, unless it's nil, and wildcards if forbidWildcards is set. It's
+// run after normalization, so it covers the CN too.
+func checkProfileNames(identTypes map[identifier.IdentifierType]bool, forbidWildcards bool) check {
+	return func(_ context.Context, csr *x509.CertificateRequest) error {
+		if identTypes != nil {
+			if len(csr.DNSNames) > 0 && !identTypes[identifier.DNS] {
+				return berrors.BadCSRError("CSR contains DNS names, which the requested certificate profile doesn't allow")
+			}
+			if len(csr.IPAddresses) > 0 && !identTypes[identifier.IP] {
+				return berrors.BadCSRError("CSR contains IP addresses, which the requested certificate profile doesn't allow")
+			}
+		}
+		if forbidWildcards {
+			for _, name := range csr.DNSNames {
+				if strings.HasPrefix(name, "*.") {
+					return berrors.BadCSRError("CSR contains wildcard name %q, which the requested certificate profile doesn't allow", name)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func checkCommonName(_ context.Context, csr *x509.CertificateRequest) error {
+	if len(csr.DNSNames) == 0 && len(csr.IPAddresses) == 0 && csr.Subject.CommonName == "" {
+		return invalidNoDNS
+	}
+	// A certificate for only IP addresses has no CN, but if there are DNS names
+	// one of them must be short enough to be the CN.
+	if len(csr.DNSNames) > 0 && csr.Subject.CommonName == "" {
+		return invalidAllSANTooLong
+	}
+	if len(csr.Subject.CommonName) > maxCNLength {
+		return berrors.BadCSRError("CN was longer than %d bytes", maxCNLength)
+	}
+	return nil
+}
+
+func checkMaxNames(maxNames int) check {
+	return func(_ context.Context, csr *x509.CertificateRequest) error {
+		if len(csr.DNSNames)+len(csr.IPAddresses) > maxNames {
+			return berrors.BadCSRError("CSR contains more than %d DNS names", maxNames)
+		}
+		return nil
+	}
+}
+
+func checkExtensions(allowed map[string]bool) check {
+	return func(_ context.Context, csr *x509.CertificateRequest) error {
+		for _, ext := range csr.Extensions {
+			if !allowed[ext.Id.String()] {
+				return berrors.BadCSRError("CSR requests extension %s, which isn't allowed for the requested certificate profile", ext.Id)
+			}
+		}
+		return nil
+	}
+}
+
+func checkWillingToIssue(pa core.PolicyAuthority) check {
+	return func(_ context.Context, csr *x509.CertificateRequest) error {
+		idents := make([]identifier.ACMEIdentifier, 0, len(csr.DNSNames)+len(csr.IPAddresses))
+		for _, dnsName := range csr.DNSNames {
+			idents = append(idents, identifier.DNSIdentifier(dnsName))
+		}
+		for _, ip := range csr.IPAddresses {
+			idents = append(idents, identifier.IPIdentifier(ip))
+		}
+		return pa.WillingToIssueWildcards(idents)
+	}
+}
@@ -0,0 +1,183 @@
+package csr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"net"
+	"testing"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestNewPolicyConfigErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		config PolicyConfig
+	}{
+		{"negative MaxNames", PolicyConfig{MaxNames: -1}},
+		{"unknown key type", PolicyConfig{KeyTypes: []string{"DSA"}}},
+		{"unknown signature algorithm", PolicyConfig{SignatureAlgorithms: []string{"MD5-RSA"}}},
+		{"rejected signature algorithm", PolicyConfig{SignatureAlgorithms: []string{"DSA-SHA256"}}},
+		{"invalid OID", PolicyConfig{AllowedExtensions: []string{"1.3.six"}}},
+		{"short OID", PolicyConfig{AllowedExtensions: []string{"1"}}},
+		{"unknown identifier type", PolicyConfig{IdentifierTypes: []string{"email"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPolicy(tc.config, 100, testingPolicy, &mockPA{})
+			test.AssertError(t, err, "NewPolicy accepted invalid config")
+		})
+	}
+}
+
+func TestPolicyVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "error generating test key")
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "error generating test key")
+
+	mustStaple := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}, Value: []byte{0x30, 0x03, 0x02, 0x01, 0x05}}
+	other := pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x05, 0x00}}
+
+	makeCSR := func(template *x509.CertificateRequest, key interface{}) *x509.CertificateRequest {
+		reqBytes, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+		test.AssertNotError(t, err, "error generating test CSR")
+		req, err := x509.ParseCertificateRequest(reqBytes)
+		test.AssertNotError(t, err, "error parsing test CSR")
+		return req
+	}
+	plain := &x509.CertificateRequest{DNSNames: []string{"a.com", "b.com"}}
+
+	cases := []struct {
+		name     string
+		config   PolicyConfig
+		template *x509.CertificateRequest
+		key      interface{}
+		wantErr  bool
+	}{
+		{"zero config", PolicyConfig{}, plain, rsaKey, false},
+		{"key type allowed", PolicyConfig{KeyTypes: []string{"ECDSA"}}, plain, ecdsaKey, false},
+		{"key type not allowed", PolicyConfig{KeyTypes: []string{"ECDSA"}}, plain, rsaKey, true},
+		{
+			"signature algorithm allowed",
+			PolicyConfig{SignatureAlgorithms: []string{"SHA256-RSA"}},
+			plain, rsaKey, false,
+		},
+		{
+			"signature algorithm not allowed",
+			PolicyConfig{SignatureAlgorithms: []string{"SHA384-RSA"}},
+			plain, rsaKey, true,
+		},
+		{"MaxNames replaced", PolicyConfig{MaxNames: 1}, plain, rsaKey, true},
+		{
+			"nil AllowedExtensions allows any",
+			PolicyConfig{},
+			&x509.CertificateRequest{DNSNames: []string{"a.com"}, ExtraExtensions: []pkix.Extension{other}},
+			rsaKey, false,
+		},
+		{
+			"empty AllowedExtensions allows only SAN",
+			PolicyConfig{AllowedExtensions: []string{}},
+			&x509.CertificateRequest{DNSNames: []string{"a.com"}, ExtraExtensions: []pkix.Extension{mustStaple}},
+			rsaKey, true,
+		},
+		{
+			"extension allowed",
+			PolicyConfig{AllowedExtensions: []string{"1.3.6.1.5.5.7.1.24"}},
+			&x509.CertificateRequest{DNSNames: []string{"a.com"}, ExtraExtensions: []pkix.Extension{mustStaple}},
+			rsaKey, false,
+		},
+		{
+			"extension allowed by non-canonical OID",
+			PolicyConfig{AllowedExtensions: []string{"1.3.06.1.5.5.7.1.024"}},
+			&x509.CertificateRequest{DNSNames: []string{"a.com"}, ExtraExtensions: []pkix.Extension{mustStaple}},
+			rsaKey, false,
+		},
+		{
+			"wildcard allowed",
+			PolicyConfig{},
+			&x509.CertificateRequest{DNSNames: []string{"*.a.com"}},
+			rsaKey, false,
+		},
+		{
+			"wildcard forbidden",
+			PolicyConfig{ForbidWildcards: true},
+			&x509.CertificateRequest{DNSNames: []string{"a.com", "*.a.com"}},
+			rsaKey, true,
+		},
+		{
+			"wildcard CN forbidden",
+			PolicyConfig{ForbidWildcards: true},
+			&x509.CertificateRequest{Subject: pkix.Name{CommonName: "*.a.com"}, DNSNames: []string{"a.com"}},
+			rsaKey, true,
+		},
+		{"DNS names allowed", PolicyConfig{IdentifierTypes: []string{"dns"}}, plain, rsaKey, false},
+		{"DNS names not allowed", PolicyConfig{IdentifierTypes: []string{"ip"}}, plain, rsaKey, true},
+		{
+			"extension not allowed",
+			PolicyConfig{AllowedExtensions: []string{"1.3.6.1.5.5.7.1.24"}},
+			&x509.CertificateRequest{DNSNames: []string{"a.com"}, ExtraExtensions: []pkix.Extension{mustStaple, other}},
+			rsaKey, true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := NewPolicy(tc.config, 100, testingPolicy, &mockPA{})
+			test.AssertNotError(t, err, "NewPolicy failed")
+			err = policy.Verify(context.Background(), makeCSR(tc.template, tc.key))
+			if tc.wantErr {
+				test.AssertError(t, err, "Verify accepted CSR")
+				test.Assert(t, berrors.Is(err, berrors.BadCSR), "wrong error type")
+			} else {
+				test.AssertNotError(t, err, "Verify rejected CSR")
+			}
+		})
+	}
+}
+
+func TestPolicyIdentifierTypes(t *testing.T) {
+	err := features.Set(map[string]bool{"IPIdentifiers": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "error generating test key")
+	reqBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		IPAddresses: []net.IP{net.ParseIP("64.112.117.1")},
+	}, private)
+	test.AssertNotError(t, err, "error generating test CSR")
+	parse := func() *x509.CertificateRequest {
+		req, err := x509.ParseCertificateRequest(reqBytes)
+		test.AssertNotError(t, err, "error parsing test CSR")
+		return req
+	}
+
+	for _, identTypes := range [][]string{nil, {"ip"}, {"dns", "ip"}} {
+		policy, err := NewPolicy(PolicyConfig{IdentifierTypes: identTypes}, 100, testingPolicy, &mockPA{})
+		test.AssertNotError(t, err, "NewPolicy failed")
+		err = policy.Verify(context.Background(), parse())
+		test.AssertNotError(t, err, fmt.Sprintf("Verify rejected CSR with IP addresses for identifier types %q", identTypes))
+	}
+
+	policy, err := NewPolicy(PolicyConfig{IdentifierTypes: []string{"dns"}}, 100, testingPolicy, &mockPA{})
+	test.AssertNotError(t, err, "NewPolicy failed")
+	err = policy.Verify(context.Background(), parse())
+	test.AssertError(t, err, "Verify accepted CSR with IP addresses for a DNS-only profile")
+	test.Assert(t, berrors.Is(err, berrors.BadCSR), "wrong error type")
+
+	// The profile can't allow IP addresses when the feature is disabled.
+	features.Reset()
+	policy, err = NewPolicy(PolicyConfig{IdentifierTypes: []string{"ip"}}, 100, testingPolicy, &mockPA{})
+	test.AssertNotError(t, err, "NewPolicy failed")
+	err = policy.Verify(context.Background(), parse())
+	test.AssertEquals(t, err, invalidIPPresent)
+}
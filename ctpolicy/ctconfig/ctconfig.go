@@ -83,8 +83,80 @@ func (ld LogDescription) Info(exp time.Time) (string, string, error) {
 
 type CTGroup struct {
 	Name string
-	Logs []LogDescription
+	// Operator names the organization operating the logs in this group. It is
+	// used when an SCTPolicy requires SCTs from a number of distinct operators.
+	// If empty, the group's Name is used.
+	Operator string
+	Logs     []LogDescription
 	// How long to wait for one log to accept a certificate before moving on to
 	// the next.
 	Stagger cmd.ConfigDuration
 }
+
+// OperatorName returns the operator of the group's logs.
+func (g CTGroup) OperatorName() string {
+	if g.Operator != "" {
+		return g.Operator
+	}
+	return g.Name
+}
+
+// ValidityRequirement sets the minimum number of SCTs that must be embedded in
+// certificates with a validity period of at most MaxValidity. A zero
+// MaxValidity matches certificates of any validity period.
+type ValidityRequirement struct {
+	MaxValidity cmd.ConfigDuration
+	MinSCTs     int
+}
+
+// SCTPolicy describes the SCTs which must be obtained before a final
+// certificate is issued. Each CTGroup contributes at most one SCT.
+type SCTPolicy struct {
+	// RequiredGroups names the groups which must each provide an SCT.
+	RequiredGroups []string
+	// MinOperators is the minimum number of distinct log operators which must
+	// provide an SCT.
+	MinOperators int
+	// ValidityRequirements are checked in order and the first which matches
+	// the certificate's validity period applies. If none match, there is no
+	// minimum number of SCTs beyond that implied by the other fields.
+	ValidityRequirements []ValidityRequirement
+}
+
+// Setup checks that the policy can be satisfied by the given groups.
+func (p *SCTPolicy) Setup(groups []CTGroup) error {
+	names := make(map[string]bool)
+	operators := make(map[string]bool)
+	for _, g := range groups {
+		names[g.Name] = true
+		operators[g.OperatorName()] = true
+	}
+	for _, name := range p.RequiredGroups {
+		if !names[name] {
+			return fmt.Errorf("required group %q is not configured", name)
+		}
+	}
+	if p.MinOperators < 0 || p.MinOperators > len(operators) {
+		return fmt.Errorf("MinOperators must be between 0 and %d", len(operators))
+	}
+	for i, req := range p.ValidityRequirements {
+		if req.MaxValidity.Duration < 0 {
+			return fmt.Errorf("ValidityRequirements index %d has negative MaxValidity", i)
+		}
+		if req.MinSCTs < 0 || req.MinSCTs > len(groups) {
+			return fmt.Errorf("ValidityRequirements index %d MinSCTs must be between 0 and %d", i, len(groups))
+		}
+	}
+	return nil
+}
+
+// MinSCTs returns the minimum number of SCTs required for a certificate with
+// the given validity period.
+func (p *SCTPolicy) MinSCTs(validity time.Duration) int {
+	for _, req := range p.ValidityRequirements {
+		if req.MaxValidity.Duration == 0 || validity <= req.MaxValidity.Duration {
+			return req.MinSCTs
+		}
+	}
+	return 0
+}
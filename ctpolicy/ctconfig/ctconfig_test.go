@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -114,3 +115,62 @@ func TestLogInfo(t *testing.T) {
 	test.AssertEquals(t, uri, "b")
 	test.AssertEquals(t, key, "b")
 }
+
+func TestSCTPolicySetup(t *testing.T) {
+	groups := []CTGroup{
+		{Name: "a", Operator: "Google"},
+		{Name: "b", Operator: "Google"},
+		{Name: "c"},
+	}
+	for _, tc := range []struct {
+		policy SCTPolicy
+		err    string
+	}{
+		{
+			policy: SCTPolicy{},
+		},
+		{
+			policy: SCTPolicy{
+				RequiredGroups:       []string{"a"},
+				MinOperators:         2,
+				ValidityRequirements: []ValidityRequirement{{MinSCTs: 3}},
+			},
+		},
+		{
+			policy: SCTPolicy{RequiredGroups: []string{"d"}},
+			err:    `required group "d" is not configured`,
+		},
+		{
+			policy: SCTPolicy{MinOperators: 3},
+			err:    "MinOperators must be between 0 and 2",
+		},
+		{
+			policy: SCTPolicy{ValidityRequirements: []ValidityRequirement{{MinSCTs: 4}}},
+			err:    "ValidityRequirements index 0 MinSCTs must be between 0 and 3",
+		},
+		{
+			policy: SCTPolicy{ValidityRequirements: []ValidityRequirement{{MaxValidity: cmd.ConfigDuration{Duration: -time.Hour}}}},
+			err:    "ValidityRequirements index 0 has negative MaxValidity",
+		},
+	} {
+		err := tc.policy.Setup(groups)
+		if err != nil && tc.err != err.Error() {
+			t.Errorf("got error %q, wanted %q", err, tc.err)
+		} else if err == nil && tc.err != "" {
+			t.Errorf("expected error %q", tc.err)
+		}
+	}
+}
+
+func TestSCTPolicyMinSCTs(t *testing.T) {
+	policy := SCTPolicy{
+		ValidityRequirements: []ValidityRequirement{
+			{MaxValidity: cmd.ConfigDuration{Duration: 180 * 24 * time.Hour}, MinSCTs: 2},
+			{MinSCTs: 3},
+		},
+	}
+	test.AssertEquals(t, policy.MinSCTs(90*24*time.Hour), 2)
+	test.AssertEquals(t, policy.MinSCTs(180*24*time.Hour), 2)
+	test.AssertEquals(t, policy.MinSCTs(181*24*time.Hour), 3)
+	test.AssertEquals(t, (&SCTPolicy{}).MinSCTs(time.Hour), 0)
+}
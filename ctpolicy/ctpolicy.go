@@ -3,7 +3,9 @@ package ctpolicy
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/letsencrypt/boulder/canceled"
@@ -22,18 +24,33 @@ type CTPolicy struct {
 	groups        []ctconfig.CTGroup
 	informational []ctconfig.LogDescription
 	finalLogs     []ctconfig.LogDescription
+	policy        *ctconfig.SCTPolicy
+	required      map[string]bool
 	log           blog.Logger
 
 	winnerCounter *prometheus.CounterVec
 }
 
-// New creates a new CTPolicy struct
+// New creates a new CTPolicy struct. If policy is nil, an SCT is required from
+// every group.
 func New(pub core.Publisher,
 	groups []ctconfig.CTGroup,
 	informational []ctconfig.LogDescription,
+	policy *ctconfig.SCTPolicy,
 	log blog.Logger,
 	stats prometheus.Registerer,
 ) *CTPolicy {
+	required := make(map[string]bool)
+	if policy == nil {
+		for _, group := range groups {
+			required[group.Name] = true
+		}
+	} else {
+		for _, name := range policy.RequiredGroups {
+			required[name] = true
+		}
+	}
+
 	var finalLogs []ctconfig.LogDescription
 	for _, group := range groups {
 		for _, log := range group.Logs {
@@ -62,15 +79,18 @@ func New(pub core.Publisher,
 		groups:        groups,
 		informational: informational,
 		finalLogs:     finalLogs,
+		policy:        policy,
+		required:      required,
 		log:           log,
 		winnerCounter: winnerCounter,
 	}
 }
 
 type result struct {
-	sct []byte
-	log string
-	err error
+	sct   []byte
+	log   string
+	group ctconfig.CTGroup
+	err   error
 }
 
 // race submits an SCT to each log in a group and waits for the first response back,
@@ -137,9 +157,11 @@ func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group ctconfig
 	return nil, errors.New("all submissions failed")
 }
 
-// GetSCTs attempts to retrieve a SCT from each configured grouping of logs and returns
-// the set of SCTs to the caller.
-func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER, expiration time.Time) (core.SCTDERs, error) {
+// GetSCTs attempts to retrieve a SCT from each configured grouping of logs and
+// returns the set of SCTs to the caller. It returns as soon as the SCTs
+// obtained satisfy the policy for a certificate with the given validity
+// period, and returns a MissingSCTs error if the policy can't be satisfied.
+func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER, expiration time.Time, validity time.Duration) (core.SCTDERs, error) {
 	results := make(chan result, len(ctp.groups))
 	subCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -147,10 +169,7 @@ func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER, expiration
 		go func(i int, g ctconfig.CTGroup) {
 			sct, err := ctp.race(subCtx, cert, g, expiration)
 			// Only one of these will be non-nil
-			if err != nil {
-				results <- result{err: berrors.MissingSCTsError("CT log group %q: %s", g.Name, err)}
-			}
-			results <- result{sct: sct}
+			results <- result{sct: sct, group: g, err: err}
 		}(i, g)
 	}
 	isPrecert := true
@@ -178,17 +197,59 @@ func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER, expiration
 	}
 
 	var ret core.SCTDERs
+	var failures []string
+	groups := make(map[string]bool)
+	operators := make(map[string]bool)
 	for i := 0; i < len(ctp.groups); i++ {
 		res := <-results
-		// If any one group fails to get a SCT then we fail out immediately
-		// cancel any other in progress work as we can't continue
 		if res.err != nil {
-			// Returning triggers the defer'd context cancellation method
-			return nil, res.err
+			// If a required group fails to get a SCT then we fail out
+			// immediately and cancel any other in progress work as we can't
+			// continue. Returning triggers the defer'd context cancellation
+			// method.
+			if ctp.required[res.group.Name] {
+				return nil, berrors.MissingSCTsError("CT log group %q: %s", res.group.Name, res.err)
+			}
+			failures = append(failures, fmt.Sprintf("CT log group %q: %s", res.group.Name, res.err))
+			continue
 		}
 		ret = append(ret, res.sct)
+		groups[res.group.Name] = true
+		operators[res.group.OperatorName()] = true
+		if ctp.unmetPolicy(groups, operators, len(ret), validity) == "" {
+			return ret, nil
+		}
+	}
+	unmet := ctp.unmetPolicy(groups, operators, len(ret), validity)
+	if unmet == "" {
+		return ret, nil
+	}
+	if len(failures) > 0 {
+		unmet = fmt.Sprintf("%s (%s)", unmet, strings.Join(failures, "; "))
+	}
+	return nil, berrors.MissingSCTsError("SCT policy not met: %s", unmet)
+}
+
+// unmetPolicy returns a description of the first part of the SCT policy which
+// isn't satisfied by SCTs from the given groups and operators, or the empty
+// string if the policy is satisfied.
+func (ctp *CTPolicy) unmetPolicy(groups, operators map[string]bool, numSCTs int, validity time.Duration) string {
+	for name := range ctp.required {
+		if !groups[name] {
+			return fmt.Sprintf("no SCT from required group %q", name)
+		}
+	}
+	if ctp.policy == nil {
+		return ""
+	}
+	if len(operators) < ctp.policy.MinOperators {
+		return fmt.Sprintf("SCTs from %d operators, need %d", len(operators), ctp.policy.MinOperators)
+	}
+	minSCTs := ctp.policy.MinSCTs(validity)
+	if numSCTs < minSCTs {
+		return fmt.Sprintf("%d SCTs, need %d for validity period %s", numSCTs, minSCTs, validity)
 	}
-	return ret, nil
+	return ""
 }
 
 // SubmitFinalCert submits finalized certificates created from precertificates
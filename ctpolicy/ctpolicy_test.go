@@ -117,8 +117,8 @@ func TestGetSCTs(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ctp := New(tc.mock, tc.groups, nil, blog.NewMock(), metrics.NoopRegisterer)
-			ret, err := ctp.GetSCTs(tc.ctx, []byte{0}, time.Time{})
+			ctp := New(tc.mock, tc.groups, nil, nil, blog.NewMock(), metrics.NoopRegisterer)
+			ret, err := ctp.GetSCTs(tc.ctx, []byte{0}, time.Time{}, 0)
 			if tc.result != nil {
 				test.AssertDeepEquals(t, ret, tc.result)
 			} else if tc.errRegexp != nil {
@@ -171,8 +171,8 @@ func TestGetSCTsMetrics(t *testing.T) {
 				{URI: "ghi", Key: "jkl"},
 			},
 		},
-	}, nil, blog.NewMock(), metrics.NoopRegisterer)
-	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{})
+	}, nil, nil, blog.NewMock(), metrics.NoopRegisterer)
+	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, 0)
 	test.AssertNotError(t, err, "GetSCTs failed")
 	test.AssertEquals(t, test.CountCounter(ctp.winnerCounter.With(prometheus.Labels{"log": "ghi", "group": "a"})), 1)
 	test.AssertEquals(t, test.CountCounter(ctp.winnerCounter.With(prometheus.Labels{"log": "ghi", "group": "b"})), 1)
@@ -188,8 +188,8 @@ func TestGetSCTsFailMetrics(t *testing.T) {
 				{URI: "abc", Key: "def"},
 			},
 		},
-	}, nil, blog.NewMock(), metrics.NoopRegisterer)
-	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{})
+	}, nil, nil, blog.NewMock(), metrics.NoopRegisterer)
+	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, 0)
 	if err == nil {
 		t.Fatal("GetSCTs should have failed")
 	}
@@ -206,8 +206,8 @@ func TestGetSCTsFailMetrics(t *testing.T) {
 				{URI: "abc", Key: "def"},
 			},
 		},
-	}, nil, blog.NewMock(), metrics.NoopRegisterer)
-	_, err = ctp.GetSCTs(ctx, []byte{0}, time.Time{})
+	}, nil, nil, blog.NewMock(), metrics.NoopRegisterer)
+	_, err = ctp.GetSCTs(ctx, []byte{0}, time.Time{}, 0)
 	if err == nil {
 		t.Fatal("GetSCTs should have failed")
 	}
@@ -235,10 +235,99 @@ func TestStagger(t *testing.T) {
 				{URI: "ghi", Key: "jkl"},
 			},
 		},
-	}, nil, blog.NewMock(), metrics.NoopRegisterer)
-	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{})
+	}, nil, nil, blog.NewMock(), metrics.NoopRegisterer)
+	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, 0)
 	test.AssertNotError(t, err, "GetSCTs failed")
 	if countingPub.count != 1 {
 		t.Errorf("wrong number of requests to publisher. got %d, expected 1", countingPub.count)
 	}
 }
+
+func TestGetSCTsPolicy(t *testing.T) {
+	groups := []ctconfig.CTGroup{
+		{
+			Name:     "a",
+			Operator: "Google",
+			Logs:     []ctconfig.LogDescription{{URI: "a1", Key: "def"}},
+		},
+		{
+			Name:     "b",
+			Operator: "Google",
+			Logs:     []ctconfig.LogDescription{{URI: "b1", Key: "def"}},
+		},
+		{
+			Name: "c",
+			Logs: []ctconfig.LogDescription{{URI: "c1", Key: "def"}},
+		},
+	}
+	shortLived := 90 * 24 * time.Hour
+	longLived := 365 * 24 * time.Hour
+	testCases := []struct {
+		name      string
+		mock      core.Publisher
+		policy    ctconfig.SCTPolicy
+		validity  time.Duration
+		numSCTs   int
+		errRegexp *regexp.Regexp
+	}{
+		{
+			name:     "optional group failure",
+			mock:     &failOne{badURL: "b1"},
+			policy:   ctconfig.SCTPolicy{RequiredGroups: []string{"a"}, MinOperators: 2},
+			validity: shortLived,
+			numSCTs:  2,
+		},
+		{
+			name:      "required group failure",
+			mock:      &failOne{badURL: "a1"},
+			policy:    ctconfig.SCTPolicy{RequiredGroups: []string{"a"}},
+			validity:  shortLived,
+			errRegexp: regexp.MustCompile(`CT log group "a": all submissions failed`),
+		},
+		{
+			name:      "too few operators",
+			mock:      &failOne{badURL: "c1"},
+			policy:    ctconfig.SCTPolicy{MinOperators: 2},
+			validity:  shortLived,
+			errRegexp: regexp.MustCompile(`SCT policy not met: SCTs from 1 operators, need 2 \(CT log group "c": all submissions failed\)`),
+		},
+		{
+			name: "short validity needs fewer SCTs",
+			mock: &failOne{badURL: "c1"},
+			policy: ctconfig.SCTPolicy{ValidityRequirements: []ctconfig.ValidityRequirement{
+				{MaxValidity: cmd.ConfigDuration{Duration: 180 * 24 * time.Hour}, MinSCTs: 2},
+				{MinSCTs: 3},
+			}},
+			validity: shortLived,
+			numSCTs:  2,
+		},
+		{
+			name: "long validity needs more SCTs",
+			mock: &failOne{badURL: "c1"},
+			policy: ctconfig.SCTPolicy{ValidityRequirements: []ctconfig.ValidityRequirement{
+				{MaxValidity: cmd.ConfigDuration{Duration: 180 * 24 * time.Hour}, MinSCTs: 2},
+				{MinSCTs: 3},
+			}},
+			validity:  longLived,
+			errRegexp: regexp.MustCompile(`SCT policy not met: 2 SCTs, need 3 for validity period 8760h0m0s`),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := tc.policy
+			ctp := New(tc.mock, groups, nil, &policy, blog.NewMock(), metrics.NoopRegisterer)
+			ret, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, tc.validity)
+			if tc.errRegexp != nil {
+				test.AssertError(t, err, "GetSCTs didn't fail")
+				test.Assert(t, berrors.Is(err, berrors.MissingSCTs), "GetSCTs didn't return MissingSCTs error")
+				if !tc.errRegexp.MatchString(err.Error()) {
+					t.Errorf("Error %q did not match expected regexp %q", err, tc.errRegexp)
+				}
+				return
+			}
+			test.AssertNotError(t, err, "GetSCTs failed")
+			test.Assert(t, len(ret) >= tc.numSCTs, "GetSCTs returned too few SCTs")
+		})
+	}
+}
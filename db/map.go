@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	gorp "github.com/go-gorp/gorp/v3"
 )
@@ -80,38 +81,53 @@ func IsDuplicate(err error) bool {
 // results in ErrDatabaseOp instances before returning them to the caller.
 type WrappedMap struct {
 	*gorp.DbMap
+
+	slowQueries *slowQueryLog
+}
+
+// executor returns a WrappedExecutor for the map's queries.
+func (m *WrappedMap) executor() WrappedExecutor {
+	return WrappedExecutor{SqlExecutor: m.DbMap, slowQueries: m.slowQueries}
 }
 
 func (m *WrappedMap) Get(holder interface{}, keys ...interface{}) (interface{}, error) {
-	return WrappedExecutor{SqlExecutor: m.DbMap}.Get(holder, keys...)
+	return m.executor().Get(holder, keys...)
 }
 
 func (m *WrappedMap) Insert(list ...interface{}) error {
-	return WrappedExecutor{SqlExecutor: m.DbMap}.Insert(list...)
+	return m.executor().Insert(list...)
 }
 
 func (m *WrappedMap) Update(list ...interface{}) (int64, error) {
-	return WrappedExecutor{SqlExecutor: m.DbMap}.Update(list...)
+	return m.executor().Update(list...)
 }
 
 func (m *WrappedMap) Delete(list ...interface{}) (int64, error) {
-	return WrappedExecutor{SqlExecutor: m.DbMap}.Delete(list...)
+	return m.executor().Delete(list...)
 }
 
 func (m *WrappedMap) Select(holder interface{}, query string, args ...interface{}) ([]interface{}, error) {
-	return WrappedExecutor{SqlExecutor: m.DbMap}.Select(holder, query, args...)
+	return m.executor().Select(holder, query, args...)
 }
 
 func (m *WrappedMap) SelectOne(holder interface{}, query string, args ...interface{}) error {
-	return WrappedExecutor{SqlExecutor: m.DbMap}.SelectOne(holder, query, args...)
+	return m.executor().SelectOne(holder, query, args...)
 }
 
 func (m *WrappedMap) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return WrappedExecutor{SqlExecutor: m.DbMap}.Exec(query, args...)
+	return m.executor().Exec(query, args...)
+}
+
+func (m *WrappedMap) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := m.DbMap.Query(query, args...)
+	if err != nil {
+		return nil, errForQuery(query, "query", err, args)
+	}
+	return rows, nil
 }
 
 func (m *WrappedMap) WithContext(ctx context.Context) gorp.SqlExecutor {
-	return WrappedExecutor{SqlExecutor: m.DbMap.WithContext(ctx)}
+	return WrappedExecutor{SqlExecutor: m.DbMap.WithContext(ctx), ctx: ctx, slowQueries: m.slowQueries}
 }
 
 func (m *WrappedMap) Begin() (Transaction, error) {
@@ -124,6 +140,7 @@ func (m *WrappedMap) Begin() (Transaction, error) {
 	}
 	return WrappedTransaction{
 		Transaction: tx,
+		slowQueries: m.slowQueries,
 	}, err
 }
 
@@ -132,10 +149,17 @@ func (m *WrappedMap) Begin() (Transaction, error) {
 // caller.
 type WrappedTransaction struct {
 	*gorp.Transaction
+
+	slowQueries *slowQueryLog
+}
+
+// executor returns a WrappedExecutor for the transaction's queries.
+func (tx WrappedTransaction) executor() WrappedExecutor {
+	return WrappedExecutor{SqlExecutor: tx.Transaction, slowQueries: tx.slowQueries}
 }
 
 func (tx WrappedTransaction) WithContext(ctx context.Context) gorp.SqlExecutor {
-	return WrappedExecutor{SqlExecutor: tx.Transaction.WithContext(ctx)}
+	return WrappedExecutor{SqlExecutor: tx.Transaction.WithContext(ctx), ctx: ctx, slowQueries: tx.slowQueries}
 }
 
 func (tx WrappedTransaction) Commit() error {
@@ -147,38 +171,42 @@ func (tx WrappedTransaction) Rollback() error {
 }
 
 func (tx WrappedTransaction) Get(holder interface{}, keys ...interface{}) (interface{}, error) {
-	return (WrappedExecutor{SqlExecutor: tx.Transaction}).Get(holder, keys...)
+	return tx.executor().Get(holder, keys...)
 }
 
 func (tx WrappedTransaction) Insert(list ...interface{}) error {
-	return (WrappedExecutor{SqlExecutor: tx.Transaction}).Insert(list...)
+	return tx.executor().Insert(list...)
 }
 
 func (tx WrappedTransaction) Update(list ...interface{}) (int64, error) {
-	return (WrappedExecutor{SqlExecutor: tx.Transaction}).Update(list...)
+	return tx.executor().Update(list...)
 }
 
 func (tx WrappedTransaction) Delete(list ...interface{}) (int64, error) {
-	return (WrappedExecutor{SqlExecutor: tx.Transaction}).Delete(list...)
+	return tx.executor().Delete(list...)
 }
 
 func (tx WrappedTransaction) Select(holder interface{}, query string, args ...interface{}) ([]interface{}, error) {
-	return (WrappedExecutor{SqlExecutor: tx.Transaction}).Select(holder, query, args...)
+	return tx.executor().Select(holder, query, args...)
 }
 
 func (tx WrappedTransaction) SelectOne(holder interface{}, query string, args ...interface{}) error {
-	return (WrappedExecutor{SqlExecutor: tx.Transaction}).SelectOne(holder, query, args...)
+	return tx.executor().SelectOne(holder, query, args...)
 }
 
 func (tx WrappedTransaction) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return (WrappedExecutor{SqlExecutor: tx.Transaction}).Exec(query, args...)
+	return tx.executor().Exec(query, args...)
 }
 
 // WrappedExecutor wraps a gorp.SqlExecutor such that its major functions
 // wrap error results in ErrDatabaseOp instances before returning them to the
-// caller.
+// caller. If it has a slow query log, it also logs its slow queries.
 type WrappedExecutor struct {
 	gorp.SqlExecutor
+
+	// ctx is the context the SqlExecutor was bound to, if any.
+	ctx         context.Context
+	slowQueries *slowQueryLog
 }
 
 func errForOp(operation string, err error, list []interface{}) ErrDatabaseOp {
@@ -215,7 +243,13 @@ func errForQuery(query, operation string, err error, list []interface{}) ErrData
 }
 
 func (we WrappedExecutor) Get(holder interface{}, keys ...interface{}) (interface{}, error) {
+	start := time.Now()
 	res, err := we.SqlExecutor.Get(holder, keys...)
+	var rows int64
+	if res != nil {
+		rows = 1
+	}
+	we.observeOp(start, "get", []interface{}{holder}, rows, err)
 	if err != nil {
 		return res, errForOp("get", err, []interface{}{holder})
 	}
@@ -223,14 +257,19 @@ func (we WrappedExecutor) Get(holder interface{}, keys ...interface{}) (interfac
 }
 
 func (we WrappedExecutor) Insert(list ...interface{}) error {
-	if err := we.SqlExecutor.Insert(list...); err != nil {
+	start := time.Now()
+	err := we.SqlExecutor.Insert(list...)
+	we.observeOp(start, "insert", list, int64(len(list)), err)
+	if err != nil {
 		return errForOp("insert", err, list)
 	}
 	return nil
 }
 
 func (we WrappedExecutor) Update(list ...interface{}) (int64, error) {
+	start := time.Now()
 	updatedRows, err := we.SqlExecutor.Update(list...)
+	we.observeOp(start, "update", list, updatedRows, err)
 	if err != nil {
 		return updatedRows, errForOp("update", err, list)
 	}
@@ -238,7 +277,9 @@ func (we WrappedExecutor) Update(list ...interface{}) (int64, error) {
 }
 
 func (we WrappedExecutor) Delete(list ...interface{}) (int64, error) {
+	start := time.Now()
 	deletedRows, err := we.SqlExecutor.Delete(list...)
+	we.observeOp(start, "delete", list, deletedRows, err)
 	if err != nil {
 		return deletedRows, errForOp("delete", err, list)
 	}
@@ -246,7 +287,9 @@ func (we WrappedExecutor) Delete(list ...interface{}) (int64, error) {
 }
 
 func (we WrappedExecutor) Select(holder interface{}, query string, args ...interface{}) ([]interface{}, error) {
+	start := time.Now()
 	result, err := we.SqlExecutor.Select(holder, query, args...)
+	we.observeQuery(start, "select", query, int64(len(result)), err)
 	if err != nil {
 		return result, errForQuery(query, "select", err, []interface{}{holder})
 	}
@@ -254,7 +297,14 @@ func (we WrappedExecutor) Select(holder interface{}, query string, args ...inter
 }
 
 func (we WrappedExecutor) SelectOne(holder interface{}, query string, args ...interface{}) error {
-	if err := we.SqlExecutor.SelectOne(holder, query, args...); err != nil {
+	start := time.Now()
+	err := we.SqlExecutor.SelectOne(holder, query, args...)
+	var rows int64
+	if err == nil {
+		rows = 1
+	}
+	we.observeQuery(start, "select one", query, rows, err)
+	if err != nil {
 		return errForQuery(query, "select one", err, []interface{}{holder})
 	}
 	return nil
@@ -297,7 +347,15 @@ func tableFromQuery(query string) string {
 }
 
 func (we WrappedExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
 	res, err := we.SqlExecutor.Exec(query, args...)
+	if we.slowQueries.slow(start) {
+		var rows int64
+		if res != nil {
+			rows, _ = res.RowsAffected()
+		}
+		we.observeQuery(start, "exec", query, rows, err)
+	}
 	if err != nil {
 		return res, errForQuery(query, "exec", err, args)
 	}
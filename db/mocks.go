@@ -29,6 +29,12 @@ type Inserter interface {
 	Insert(list ...interface{}) error
 }
 
+// A Querier is anything that provides a `Query` function, whose rows can be
+// scanned one at a time.
+type Querier interface {
+	Query(string, ...interface{}) (*sql.Rows, error)
+}
+
 // A Execer is anything that provides an `Exec` function
 type Execer interface {
 	Exec(string, ...interface{}) (sql.Result, error)
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// slowQueryLog logs the queries which take longer than its threshold to run.
+type slowQueryLog struct {
+	threshold time.Duration
+	log       blog.Logger
+}
+
+// slowQuery is the JSON object logged for a slow query.
+type slowQuery struct {
+	// Method is the gRPC method being served when the query was made, if any.
+	Method string `json:",omitempty"`
+	// Op is the operation performed, e.g. "select" or "insert".
+	Op string
+	// Fingerprint identifies the query with its literal values, and the
+	// length of any lists of them, removed, so that the many instances of a
+	// query can be grouped together. Queries built by gorp, like inserts of a
+	// model, are identified by the operation and the model's type instead.
+	Fingerprint string
+	// Rows is the number of rows returned or affected by the query. MariaDB
+	// doesn't report the number of rows examined to clients, but they're
+	// recorded in the server's own slow query log, which the long_query_time
+	// set by adjustMySQLConfig in the SA enables.
+	Rows int64
+	// Seconds is how long the query took to run.
+	Seconds float64
+	// Error is true if the query failed.
+	Error bool `json:",omitempty"`
+}
+
+// slow returns true if a query started at start took longer than the
+// threshold. It's safe to call on a nil slowQueryLog, which never considers a
+// query slow.
+func (l *slowQueryLog) slow(start time.Time) bool {
+	return l != nil && time.Since(start) > l.threshold
+}
+
+// logQuery logs a query which was found to be slow.
+func (l *slowQueryLog) logQuery(ctx context.Context, start time.Time, q slowQuery) {
+	q.Seconds = time.Since(start).Seconds()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if method, ok := grpc.Method(ctx); ok {
+		q.Method = method
+	}
+	jsonQuery, err := json.Marshal(q)
+	if err != nil {
+		l.log.Errf("Slow query: marshaling %#v: %s", q, err)
+		return
+	}
+	blog.ForContext(ctx, l.log).Warningf("Slow query: %s", jsonQuery)
+}
+
+// SetSlowQueryLog makes m log, as a warning, each query made through it, or
+// through the transactions it begins, which takes longer than threshold to
+// run. A threshold of zero disables the log.
+func (m *WrappedMap) SetSlowQueryLog(threshold time.Duration, log blog.Logger) {
+	if threshold <= 0 {
+		m.slowQueries = nil
+		return
+	}
+	m.slowQueries = &slowQueryLog{threshold: threshold, log: log}
+}
+
+// observeQuery logs the query if it was slow.
+func (we WrappedExecutor) observeQuery(start time.Time, op, query string, rows int64, err error) {
+	if !we.slowQueries.slow(start) {
+		return
+	}
+	we.slowQueries.logQuery(we.ctx, start, slowQuery{
+		Op:          op,
+		Fingerprint: fingerprint(query),
+		Rows:        rows,
+		Error:       err != nil,
+	})
+}
+
+// observeOp logs the gorp operation on list if it was slow.
+func (we WrappedExecutor) observeOp(start time.Time, op string, list []interface{}, rows int64, err error) {
+	if !we.slowQueries.slow(start) {
+		return
+	}
+	model := "unknown"
+	if len(list) > 0 {
+		model = fmt.Sprintf("%T", list[0])
+	}
+	we.slowQueries.logQuery(we.ctx, start, slowQuery{
+		Op:          op,
+		Fingerprint: fmt.Sprintf("%s %s", op, model),
+		Rows:        rows,
+		Error:       err != nil,
+	})
+}
+
+var (
+	// fingerprintStringRegexp matches quoted string literals.
+	fingerprintStringRegexp = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+	// fingerprintNumberRegexp matches numeric literals, but not digits which
+	// are part of an identifier like authz2.
+	fingerprintNumberRegexp = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	// fingerprintListRegexp matches lists of placeholders, like the arguments
+	// of an IN clause.
+	fingerprintListRegexp = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+	// fingerprintRowsRegexp matches the repeated rows of a multi-row insert,
+	// once their lists of placeholders have been collapsed.
+	fingerprintRowsRegexp = regexp.MustCompile(`\((\?(?:\.\.\.)?)\)(?:\s*,\s*\(\?(?:\.\.\.)?\))+`)
+)
+
+// fingerprint normalizes query so that queries which differ only in their
+// literal values, the number of values in a list, or whitespace are the same.
+// Literals are replaced with placeholders, lists of placeholders are collapsed
+// to "?...", and the rows of a multi-row insert are collapsed to "(?...)...".
+func fingerprint(query string) string {
+	query = fingerprintStringRegexp.ReplaceAllString(query, "?")
+	query = fingerprintNumberRegexp.ReplaceAllString(query, "?")
+	query = strings.Join(strings.Fields(query), " ")
+	query = fingerprintListRegexp.ReplaceAllString(query, "?...")
+	query = fingerprintRowsRegexp.ReplaceAllString(query, "($1)...")
+	return query
+}
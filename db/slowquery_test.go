@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gorp "github.com/go-gorp/gorp/v3"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestFingerprint(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    "SELECT id FROM authz2 WHERE id = ?",
+			expected: "SELECT id FROM authz2 WHERE id = ?",
+		},
+		{
+			query:    "SELECT id FROM authz2 WHERE id = 1234 AND status = 'valid'",
+			expected: "SELECT id FROM authz2 WHERE id = ? AND status = ?",
+		},
+		{
+			query:    "SELECT id FROM authz2\n\t\tWHERE id IN (?, ?,?)",
+			expected: "SELECT id FROM authz2 WHERE id IN (?...)",
+		},
+		{
+			query:    `SELECT serial FROM certificates WHERE serial = "it's" LIMIT 1.5`,
+			expected: "SELECT serial FROM certificates WHERE serial = ? LIMIT ?",
+		},
+		{
+			query:    "INSERT INTO orderToAuthz2 (orderID, authzID) VALUES (?, ?), (?, ?), (?, ?)",
+			expected: "INSERT INTO orderToAuthz2 (orderID, authzID) VALUES (?...)...",
+		},
+		{
+			query:    "INSERT INTO requestedNames (reversedName) VALUES (?), (?)",
+			expected: "INSERT INTO requestedNames (reversedName) VALUES (?)...",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			test.AssertEquals(t, fingerprint(tc.query), tc.expected)
+		})
+	}
+}
+
+// slowExecutor is a gorp.SqlExecutor whose Select takes a little while.
+type slowExecutor struct {
+	gorp.SqlExecutor
+}
+
+func (se slowExecutor) Select(interface{}, string, ...interface{}) ([]interface{}, error) {
+	time.Sleep(10 * time.Millisecond)
+	return []interface{}{1, 2}, nil
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	log := blog.NewMock()
+	we := WrappedExecutor{
+		SqlExecutor: slowExecutor{},
+		ctx:         context.Background(),
+		slowQueries: &slowQueryLog{threshold: time.Millisecond, log: log},
+	}
+	_, err := we.Select(nil, "SELECT id FROM authz2 WHERE id IN (1, 2)")
+	test.AssertNotError(t, err, "selecting")
+	test.AssertEquals(t, len(log.GetAllMatching(`Slow query: {"Op":"select","Fingerprint":"SELECT id FROM authz2 WHERE id IN \(\?\.\.\.\)","Rows":2,`)), 1)
+
+	// Queries faster than the threshold aren't logged.
+	log.Clear()
+	we.slowQueries.threshold = time.Minute
+	_, err = we.Select(nil, "SELECT id FROM authz2 WHERE id IN (1, 2)")
+	test.AssertNotError(t, err, "selecting")
+	test.AssertEquals(t, len(log.GetAllMatching(`Slow query`)), 0)
+
+	// And nothing is logged without a slow query log.
+	we.slowQueries = nil
+	_, err = we.Select(nil, "SELECT id FROM authz2 WHERE id IN (1, 2)")
+	test.AssertNotError(t, err, "selecting")
+	test.AssertEquals(t, len(log.GetAllMatching(`Slow query`)), 0)
+}
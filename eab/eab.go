@@ -0,0 +1,70 @@
+// Package eab provides the MAC keys used to verify the external account
+// bindings of new ACME accounts, as described in RFC 8555 Section 7.3.4.
+package eab
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	eabpb "github.com/letsencrypt/boulder/eab/proto"
+)
+
+// ErrUnknownKey is returned by a KeyStore which has no key with the requested
+// key ID.
+var ErrUnknownKey = errors.New("unknown external account key ID")
+
+// KeyStore looks up external account MAC keys by their key ID.
+type KeyStore interface {
+	GetKey(ctx context.Context, keyID string) ([]byte, error)
+}
+
+type staticKeyStore map[string][]byte
+
+// NewStaticKeyStore returns a KeyStore holding a fixed set of keys, given as a
+// map of key IDs to base64url encoded MAC keys.
+func NewStaticKeyStore(keys map[string]string) (KeyStore, error) {
+	store := make(staticKeyStore, len(keys))
+	for keyID, encoded := range keys {
+		key, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding external account key %q: %s", keyID, err)
+		}
+		if len(key) == 0 {
+			return nil, fmt.Errorf("external account key %q is empty", keyID)
+		}
+		store[keyID] = key
+	}
+	return store, nil
+}
+
+func (s staticKeyStore) GetKey(_ context.Context, keyID string) ([]byte, error) {
+	key, present := s[keyID]
+	if !present {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+type remoteKeyStore struct {
+	client eabpb.ExternalAccountKeysClient
+}
+
+// NewRemoteKeyStore returns a KeyStore which looks keys up using the external
+// account keys service behind client. That service may be the SA, or an
+// external account management system.
+func NewRemoteKeyStore(client eabpb.ExternalAccountKeysClient) KeyStore {
+	return remoteKeyStore{client: client}
+}
+
+func (s remoteKeyStore) GetKey(ctx context.Context, keyID string) ([]byte, error) {
+	resp, err := s.client.GetExternalAccountKey(ctx, &eabpb.ExternalAccountKeyRequest{KeyID: keyID})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.HmacKey) == 0 {
+		return nil, ErrUnknownKey
+	}
+	return resp.HmacKey, nil
+}
@@ -0,0 +1,55 @@
+package eab
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	eabpb "github.com/letsencrypt/boulder/eab/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestStaticKeyStore(t *testing.T) {
+	ks, err := NewStaticKeyStore(map[string]string{"kid-1": "c2VjcmV0"})
+	test.AssertNotError(t, err, "NewStaticKeyStore failed")
+
+	key, err := ks.GetKey(context.Background(), "kid-1")
+	test.AssertNotError(t, err, "GetKey failed")
+	test.AssertByteEquals(t, key, []byte("secret"))
+
+	_, err = ks.GetKey(context.Background(), "kid-2")
+	test.AssertEquals(t, err, ErrUnknownKey)
+
+	_, err = NewStaticKeyStore(map[string]string{"kid-1": "not base64url!"})
+	test.AssertError(t, err, "NewStaticKeyStore accepted a malformed key")
+	_, err = NewStaticKeyStore(map[string]string{"kid-1": ""})
+	test.AssertError(t, err, "NewStaticKeyStore accepted an empty key")
+}
+
+// keysClient is an eabpb.ExternalAccountKeysClient with a fixed set of keys,
+// which fails requests for the key ID "broken".
+type keysClient map[string][]byte
+
+func (c keysClient) GetExternalAccountKey(_ context.Context, req *eabpb.ExternalAccountKeyRequest, _ ...grpc.CallOption) (*eabpb.ExternalAccountKey, error) {
+	if req.KeyID == "broken" {
+		return nil, errors.New("external account keys service unavailable")
+	}
+	return &eabpb.ExternalAccountKey{HmacKey: c[req.KeyID]}, nil
+}
+
+func TestRemoteKeyStore(t *testing.T) {
+	ks := NewRemoteKeyStore(keysClient{"kid-1": []byte("secret")})
+
+	key, err := ks.GetKey(context.Background(), "kid-1")
+	test.AssertNotError(t, err, "GetKey failed")
+	test.AssertByteEquals(t, key, []byte("secret"))
+
+	_, err = ks.GetKey(context.Background(), "kid-2")
+	test.AssertEquals(t, err, ErrUnknownKey)
+
+	_, err = ks.GetKey(context.Background(), "broken")
+	test.AssertError(t, err, "GetKey didn't fail when the service did")
+	test.Assert(t, err != ErrUnknownKey, "service failure reported as an unknown key")
+}
@@ -0,0 +1,306 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.21.0
+// 	protoc        v3.11.4
+// source: eab/proto/eab.proto
+
+package proto
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type ExternalAccountKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	KeyID string `protobuf:"bytes,1,opt,name=keyID,proto3" json:"keyID,omitempty"`
+}
+
+func (x *ExternalAccountKeyRequest) Reset() {
+	*x = ExternalAccountKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_eab_proto_eab_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExternalAccountKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExternalAccountKeyRequest) ProtoMessage() {}
+
+func (x *ExternalAccountKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_eab_proto_eab_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExternalAccountKeyRequest.ProtoReflect.Descriptor instead.
+func (*ExternalAccountKeyRequest) Descriptor() ([]byte, []int) {
+	return file_eab_proto_eab_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExternalAccountKeyRequest) GetKeyID() string {
+	if x != nil {
+		return x.KeyID
+	}
+	return ""
+}
+
+type ExternalAccountKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The MAC key with the requested key ID. Empty if there is no such key.
+	HmacKey []byte `protobuf:"bytes,1,opt,name=hmacKey,proto3" json:"hmacKey,omitempty"`
+}
+
+func (x *ExternalAccountKey) Reset() {
+	*x = ExternalAccountKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_eab_proto_eab_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExternalAccountKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExternalAccountKey) ProtoMessage() {}
+
+func (x *ExternalAccountKey) ProtoReflect() protoreflect.Message {
+	mi := &file_eab_proto_eab_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExternalAccountKey.ProtoReflect.Descriptor instead.
+func (*ExternalAccountKey) Descriptor() ([]byte, []int) {
+	return file_eab_proto_eab_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExternalAccountKey) GetHmacKey() []byte {
+	if x != nil {
+		return x.HmacKey
+	}
+	return nil
+}
+
+var File_eab_proto_eab_proto protoreflect.FileDescriptor
+
+var file_eab_proto_eab_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x65, 0x61, 0x62, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x65, 0x61, 0x62, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x65, 0x61, 0x62, 0x22, 0x31, 0x0a, 0x19, 0x45, 0x78,
+	0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x4b, 0x65, 0x79,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x44,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x44, 0x22, 0x2e, 0x0a,
+	0x12, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x4b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x68, 0x6d, 0x61, 0x63, 0x4b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x68, 0x6d, 0x61, 0x63, 0x4b, 0x65, 0x79, 0x32, 0x69, 0x0a,
+	0x13, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x4b, 0x65, 0x79, 0x73, 0x12, 0x52, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x45, 0x78, 0x74, 0x65, 0x72,
+	0x6e, 0x61, 0x6c, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x1e, 0x2e,
+	0x65, 0x61, 0x62, 0x2e, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x41, 0x63, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e,
+	0x65, 0x61, 0x62, 0x2e, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x41, 0x63, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x4b, 0x65, 0x79, 0x22, 0x00, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x74, 0x73, 0x65, 0x6e, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65, 0x72, 0x2f, 0x65, 0x61, 0x62, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_eab_proto_eab_proto_rawDescOnce sync.Once
+	file_eab_proto_eab_proto_rawDescData = file_eab_proto_eab_proto_rawDesc
+)
+
+func file_eab_proto_eab_proto_rawDescGZIP() []byte {
+	file_eab_proto_eab_proto_rawDescOnce.Do(func() {
+		file_eab_proto_eab_proto_rawDescData = protoimpl.X.CompressGZIP(file_eab_proto_eab_proto_rawDescData)
+	})
+	return file_eab_proto_eab_proto_rawDescData
+}
+
+var file_eab_proto_eab_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_eab_proto_eab_proto_goTypes = []interface{}{
+	(*ExternalAccountKeyRequest)(nil), // 0: eab.ExternalAccountKeyRequest
+	(*ExternalAccountKey)(nil),        // 1: eab.ExternalAccountKey
+}
+var file_eab_proto_eab_proto_depIdxs = []int32{
+	0, // 0: eab.ExternalAccountKeys.GetExternalAccountKey:input_type -> eab.ExternalAccountKeyRequest
+	1, // 1: eab.ExternalAccountKeys.GetExternalAccountKey:output_type -> eab.ExternalAccountKey
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_eab_proto_eab_proto_init() }
+func file_eab_proto_eab_proto_init() {
+	if File_eab_proto_eab_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_eab_proto_eab_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExternalAccountKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_eab_proto_eab_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExternalAccountKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_eab_proto_eab_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_eab_proto_eab_proto_goTypes,
+		DependencyIndexes: file_eab_proto_eab_proto_depIdxs,
+		MessageInfos:      file_eab_proto_eab_proto_msgTypes,
+	}.Build()
+	File_eab_proto_eab_proto = out.File
+	file_eab_proto_eab_proto_rawDesc = nil
+	file_eab_proto_eab_proto_goTypes = nil
+	file_eab_proto_eab_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ExternalAccountKeysClient is the client API for ExternalAccountKeys service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ExternalAccountKeysClient interface {
+	GetExternalAccountKey(ctx context.Context, in *ExternalAccountKeyRequest, opts ...grpc.CallOption) (*ExternalAccountKey, error)
+}
+
+type externalAccountKeysClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExternalAccountKeysClient(cc grpc.ClientConnInterface) ExternalAccountKeysClient {
+	return &externalAccountKeysClient{cc}
+}
+
+func (c *externalAccountKeysClient) GetExternalAccountKey(ctx context.Context, in *ExternalAccountKeyRequest, opts ...grpc.CallOption) (*ExternalAccountKey, error) {
+	out := new(ExternalAccountKey)
+	err := c.cc.Invoke(ctx, "/eab.ExternalAccountKeys/GetExternalAccountKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExternalAccountKeysServer is the server API for ExternalAccountKeys service.
+type ExternalAccountKeysServer interface {
+	GetExternalAccountKey(context.Context, *ExternalAccountKeyRequest) (*ExternalAccountKey, error)
+}
+
+// UnimplementedExternalAccountKeysServer can be embedded to have forward compatible implementations.
+type UnimplementedExternalAccountKeysServer struct {
+}
+
+func (*UnimplementedExternalAccountKeysServer) GetExternalAccountKey(context.Context, *ExternalAccountKeyRequest) (*ExternalAccountKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExternalAccountKey not implemented")
+}
+
+func RegisterExternalAccountKeysServer(s *grpc.Server, srv ExternalAccountKeysServer) {
+	s.RegisterService(&_ExternalAccountKeys_serviceDesc, srv)
+}
+
+func _ExternalAccountKeys_GetExternalAccountKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExternalAccountKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExternalAccountKeysServer).GetExternalAccountKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eab.ExternalAccountKeys/GetExternalAccountKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExternalAccountKeysServer).GetExternalAccountKey(ctx, req.(*ExternalAccountKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ExternalAccountKeys_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "eab.ExternalAccountKeys",
+	HandlerType: (*ExternalAccountKeysServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetExternalAccountKey",
+			Handler:    _ExternalAccountKeys_GetExternalAccountKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "eab/proto/eab.proto",
+}
@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/letsencrypt/boulder/identifier"
 )
@@ -27,6 +28,12 @@ const (
 	DNS
 	BadPublicKey
 	BadCSR
+	// Paused is returned when an account may not request certificates for
+	// some of its identifiers until it unpauses itself.
+	Paused
+	// AlreadyReplaced is returned when a new order claims to replace a
+	// certificate which another order has already replaced, or is replacing.
+	AlreadyReplaced
 )
 
 // BoulderError represents internal Boulder errors
@@ -34,6 +41,21 @@ type BoulderError struct {
 	Type      ErrorType
 	Detail    string
 	SubErrors []SubBoulderError
+	// RateLimit, if not nil, describes the rate limit exceeded by a RateLimit
+	// error.
+	RateLimit *RateLimitDetails `json:",omitempty"`
+}
+
+// RateLimitDetails describes an exceeded rate limit.
+type RateLimitDetails struct {
+	// Name is the limit's name in the rate limit policy configuration, e.g.
+	// "certificatesPerName".
+	Name string
+	// Usage is the count which reached the limit's Threshold.
+	Usage     int64
+	Threshold int64
+	// RetryAfter is how long the subscriber should wait before trying again.
+	RetryAfter time.Duration
 }
 
 // SubBoulderError represents sub-errors specific to an identifier that are
@@ -54,6 +76,7 @@ func (be *BoulderError) WithSubErrors(subErrs []SubBoulderError) *BoulderError {
 		Type:      be.Type,
 		Detail:    be.Detail,
 		SubErrors: append(be.SubErrors, subErrs...),
+		RateLimit: be.RateLimit,
 	}
 }
 
@@ -97,6 +120,14 @@ func RateLimitError(msg string, args ...interface{}) error {
 	}
 }
 
+// RateLimitExceededError returns a RateLimit error like RateLimitError, which
+// also carries the details of the exceeded limit.
+func RateLimitExceededError(details RateLimitDetails, msg string, args ...interface{}) error {
+	err := RateLimitError(msg, args...).(*BoulderError)
+	err.RateLimit = &details
+	return err
+}
+
 func RejectedIdentifierError(msg string, args ...interface{}) error {
 	return New(RejectedIdentifier, msg, args...)
 }
@@ -140,3 +171,11 @@ func BadPublicKeyError(msg string, args ...interface{}) error {
 func BadCSRError(msg string, args ...interface{}) error {
 	return New(BadCSR, msg, args...)
 }
+
+func PausedError(msg string, args ...interface{}) error {
+	return New(Paused, msg, args...)
+}
+
+func AlreadyReplacedError(msg string, args ...interface{}) error {
+	return New(AlreadyReplaced, msg, args...)
+}
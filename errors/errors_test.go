@@ -2,6 +2,7 @@ package errors
 
 import (
 	"testing"
+	"time"
 
 	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/test"
@@ -48,3 +49,26 @@ func TestWithSubErrors(t *testing.T) {
 	outResult = outResult.WithSubErrors([]SubBoulderError{anotherSubErr})
 	test.AssertDeepEquals(t, outResult.SubErrors, append(subErrs, anotherSubErr))
 }
+
+// TestRateLimitExceededError tests that a rate limit error carries the details
+// of the exceeded limit, including once suberrors are added to it.
+func TestRateLimitExceededError(t *testing.T) {
+	details := RateLimitDetails{
+		Name:       "certificatesPerName",
+		Usage:      50,
+		Threshold:  50,
+		RetryAfter: time.Hour,
+	}
+	err := RateLimitExceededError(details, "too many certificates for %s", "example.com")
+	test.Assert(t, Is(err, RateLimit), "RateLimitExceededError isn't a RateLimit error")
+	test.AssertEquals(t, err.Error(), "too many certificates for example.com: see https://letsencrypt.org/docs/rate-limits/")
+	test.AssertDeepEquals(t, *err.(*BoulderError).RateLimit, details)
+
+	withSubErrs := err.(*BoulderError).WithSubErrors([]SubBoulderError{
+		{
+			Identifier:   identifier.DNSIdentifier("example.com"),
+			BoulderError: err.(*BoulderError),
+		},
+	})
+	test.AssertDeepEquals(t, *withSubErrs.RateLimit, details)
+}
@@ -30,11 +30,29 @@ func _() {
 	_ = x[StoreRevokerInfo-19]
 	_ = x[RestrictRSAKeySizes-20]
 	_ = x[FasterNewOrdersRateLimit-21]
+	_ = x[CertificateProfiles-22]
+	_ = x[Ed25519Issuance-23]
+	_ = x[BatchCAARecheck-24]
+	_ = x[IPIdentifiers-25]
+	_ = x[StoreProfileHash-26]
+	_ = x[OnionIdentifiers-27]
+	_ = x[RenewalInfo-28]
+	_ = x[PausedIdentifiers-29]
+	_ = x[AsyncFinalize-30]
+	_ = x[OrderValidityWindows-31]
+	_ = x[StoreRevokedCertificates-32]
+	_ = x[StreamlineOrderAndAuthzs-33]
+	_ = x[FasterFQDNSetRateLimit-34]
+	_ = x[FasterGetValidAuthorizations-35]
+	_ = x[StoreIssuanceOutcomes-36]
+	_ = x[PropagateKeyCompromise-37]
+	_ = x[KeyValueRateLimits-38]
+	_ = x[KeyValueRateLimitsCutover-39]
 }
 
-const _FeatureFlag_name = "unusedWriteIssuedNamesPrecertHeadNonceStatusOKRemoveWFE2AccountIDCheckRenewalFirstParallelCheckFailedValidationDeleteUnusedChallengesBlockedKeyTableStoreKeyHashesCAAValidationMethodsCAAAccountURIEnforceMultiVAMultiVAFullResultsMandatoryPOSTAsGETAllowV1RegistrationV1DisableNewValidationsPrecertificateRevocationStripDefaultSchemePortStoreIssuerInfoStoreRevokerInfoRestrictRSAKeySizesFasterNewOrdersRateLimit"
+const _FeatureFlag_name = "unusedWriteIssuedNamesPrecertHeadNonceStatusOKRemoveWFE2AccountIDCheckRenewalFirstParallelCheckFailedValidationDeleteUnusedChallengesBlockedKeyTableStoreKeyHashesCAAValidationMethodsCAAAccountURIEnforceMultiVAMultiVAFullResultsMandatoryPOSTAsGETAllowV1RegistrationV1DisableNewValidationsPrecertificateRevocationStripDefaultSchemePortStoreIssuerInfoStoreRevokerInfoRestrictRSAKeySizesFasterNewOrdersRateLimitCertificateProfilesEd25519IssuanceBatchCAARecheckIPIdentifiersStoreProfileHashOnionIdentifiersRenewalInfoPausedIdentifiersAsyncFinalizeOrderValidityWindowsStoreRevokedCertificatesStreamlineOrderAndAuthzsFasterFQDNSetRateLimitFasterGetValidAuthorizationsStoreIssuanceOutcomesPropagateKeyCompromiseKeyValueRateLimitsKeyValueRateLimitsCutover"
 
-var _FeatureFlag_index = [...]uint16{0, 6, 29, 46, 65, 82, 111, 133, 148, 162, 182, 195, 209, 227, 245, 264, 287, 311, 333, 348, 364, 383, 407}
+var _FeatureFlag_index = [...]uint16{0, 6, 29, 46, 65, 82, 111, 133, 148, 162, 182, 195, 209, 227, 245, 264, 287, 311, 333, 348, 364, 383, 407, 426, 441, 456, 469, 485, 501, 512, 529, 542, 562, 586, 610, 632, 660, 681, 703, 721, 746}
 
 func (i FeatureFlag) String() string {
 	if i < 0 || i >= FeatureFlag(len(_FeatureFlag_index)-1) {
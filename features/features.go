@@ -58,6 +58,84 @@ const (
 	// FasterNewOrdersRateLimit enables use of a separate table for counting the
 	// new orders rate limit.
 	FasterNewOrdersRateLimit
+	// CertificateProfiles enables storage of the certificate profile requested
+	// for an order in the orders table. The WFE and RA refuse new orders naming
+	// a profile without it, so it must be enabled for all three.
+	CertificateProfiles
+	// Ed25519Issuance allows subscriber certificates to be issued for Ed25519
+	// public keys.
+	Ed25519Issuance
+	// BatchCAARecheck causes the RA to recheck CAA for all of an order's stale
+	// authorizations with a single IsCAAValidForNames RPC, so the VA can share
+	// lookups for their common parent domains.
+	BatchCAARecheck
+	// IPIdentifiers allows ACME v2 orders to include RFC 8738 IP address
+	// identifiers, validated with http-01 or tls-alpn-01, and certificates to
+	// be issued with iPAddress SANs.
+	IPIdentifiers
+	// StoreProfileHash enables storage of the hash of the issuance profile
+	// each precertificate was issued under in the precertificates table.
+	StoreProfileHash
+	// OnionIdentifiers allows ACME v2 orders to include version 3 .onion
+	// names, validated with the onion-csr-01 challenge, and certificates to be
+	// issued for them.
+	OnionIdentifiers
+	// RenewalInfo enables the ACME Renewal Information (ARI) renewalInfo
+	// endpoint, and the "replaces" field of new orders, which are tracked in
+	// the replacementOrders table.
+	RenewalInfo
+	// PausedIdentifiers causes the RA to refuse new orders for identifiers
+	// which have been paused for the requesting account, and to pause
+	// identifiers which persistently fail validation, using the paused table.
+	PausedIdentifiers
+	// AsyncFinalize causes the RA to return from FinalizeOrder as soon as the
+	// order is processing, issuing its certificate in the background while
+	// the client polls the order.
+	AsyncFinalize
+	// OrderValidityWindows allows new orders to request the notBefore and
+	// notAfter of their certificate, which are stored in the
+	// orderValidityWindows table.
+	OrderValidityWindows
+	// StoreRevokedCertificates causes the SA to record revocations in the
+	// revokedCertificates table, by issuer and CRL shard, so that the CRLs for
+	// a shard can be built without scanning the certificateStatus table.
+	StoreRevokedCertificates
+	// StreamlineOrderAndAuthzs causes the RA to create a new order and its new
+	// pending authorizations with a single call to the SA's NewOrderAndAuthzs,
+	// rather than calling NewAuthorizations2 and then NewOrder.
+	StreamlineOrderAndAuthzs
+	// FasterFQDNSetRateLimit causes the RA to check whether the exact set of
+	// names in a new certificate was issued within the certificatesPerFQDNSet
+	// window with the SA's FQDNSetIssuedWithin before counting them with
+	// CountFQDNSets, which it skips if they weren't.
+	FasterFQDNSetRateLimit
+	// FasterGetValidAuthorizations causes the SA's GetValidAuthorizations2 to
+	// read only the latest valid authorization for each name, rather than
+	// every valid authorization for the names, and pick the latest of those.
+	FasterGetValidAuthorizations
+	// StoreIssuanceOutcomes causes the SA to record, in the issuanceOutcomes
+	// table, whether each precertificate was followed by its final certificate,
+	// and the RA to record when finalization fails after a precertificate was
+	// issued.
+	StoreIssuanceOutcomes
+	// PropagateKeyCompromise causes the RA, when a certificate is revoked for
+	// keyCompromise by its subscriber or an administrator, to revoke every
+	// other unexpired certificate with the same key too, as it always does
+	// for RevokeCertByKey.
+	PropagateKeyCompromise
+	// KeyValueRateLimits causes the RA, when it's configured with a Redis
+	// backed key-value rate limiter, to spend each issued certificate against
+	// the certificatesPerName and certificatesPerFQDNSet limits there too, and
+	// to compare its decisions with those made by counting in the database.
+	// The database's decisions are still the ones enforced.
+	KeyValueRateLimits
+	// KeyValueRateLimitsCutover causes the RA to enforce the decisions of its
+	// key-value rate limiter for the certificatesPerName and
+	// certificatesPerFQDNSet limits instead of counting in the database. It
+	// should only be enabled once KeyValueRateLimits has been enabled for at
+	// least the limits' windows, so that the key-value buckets account for
+	// every certificate issued within them.
+	KeyValueRateLimitsCutover
 )
 
 // List of features and their default value, protected by fMu
@@ -84,6 +162,24 @@ var features = map[FeatureFlag]bool{
 	RestrictRSAKeySizes:           false,
 	FasterNewOrdersRateLimit:      false,
 	BlockedKeyTable:               false,
+	CertificateProfiles:           false,
+	Ed25519Issuance:               false,
+	BatchCAARecheck:               false,
+	IPIdentifiers:                 false,
+	StoreProfileHash:              false,
+	OnionIdentifiers:              false,
+	RenewalInfo:                   false,
+	PausedIdentifiers:             false,
+	AsyncFinalize:                 false,
+	OrderValidityWindows:          false,
+	StoreRevokedCertificates:      false,
+	StreamlineOrderAndAuthzs:      false,
+	FasterFQDNSetRateLimit:        false,
+	FasterGetValidAuthorizations:  false,
+	StoreIssuanceOutcomes:         false,
+	PropagateKeyCompromise:        false,
+	KeyValueRateLimits:            false,
+	KeyValueRateLimitsCutover:     false,
 }
 
 var fMu = new(sync.RWMutex)
@@ -128,6 +224,17 @@ func Enabled(n FeatureFlag) bool {
 	return v
 }
 
+// All returns whether each feature is enabled, keyed by feature name.
+func All() map[string]bool {
+	fMu.RLock()
+	defer fMu.RUnlock()
+	all := make(map[string]bool, len(features))
+	for f, v := range features {
+		all[f.String()] = v
+	}
+	return all
+}
+
 // Reset resets the features to their initial state
 func Reset() {
 	fMu.Lock()
@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"math/big"
@@ -14,6 +16,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
+	goodkeypb "github.com/letsencrypt/boulder/goodkey/proto"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 
 	"github.com/titanous/rocacheck"
@@ -65,6 +68,7 @@ type KeyPolicy struct {
 	weakRSAList        *WeakRSAKeys
 	blockedList        *blockedKeys
 	dbCheck            BlockedKeyCheckFunc
+	remote             goodkeypb.KeyPolicyClient
 }
 
 // NewKeyPolicy returns a KeyPolicy that allows RSA, ECDSA256 and ECDSA384.
@@ -98,16 +102,31 @@ func NewKeyPolicy(weakKeyFile, blockedKeyFile string, bkc BlockedKeyCheckFunc) (
 	return kp, nil
 }
 
+// NewRemoteKeyPolicy returns a KeyPolicy that delegates all checks to the key
+// policy service behind client.
+func NewRemoteKeyPolicy(client goodkeypb.KeyPolicyClient) KeyPolicy {
+	return KeyPolicy{remote: client}
+}
+
 // GoodKey returns true if the key is acceptable for both TLS use and account
 // key use (our requirements are the same for either one), according to basic
 // strength and algorithm checking. GoodKey only supports pointers: *rsa.PublicKey
-// and *ecdsa.PublicKey. It will reject non-pointer types.
+// and *ecdsa.PublicKey, with the exception of ed25519.PublicKey which is
+// accepted when the Ed25519Issuance feature is enabled. It will reject other
+// non-pointer types.
 // TODO: Support JSONWebKeys once go-jose migration is done.
 func (policy *KeyPolicy) GoodKey(ctx context.Context, key crypto.PublicKey) error {
+	if policy.remote != nil {
+		return policy.remoteGoodKey(ctx, key)
+	}
 	// Early rejection of unacceptable key types to guard subsequent checks.
 	switch t := key.(type) {
 	case *rsa.PublicKey, *ecdsa.PublicKey:
 		break
+	case ed25519.PublicKey:
+		if !features.Enabled(features.Ed25519Issuance) {
+			return badKey("unsupported key type %T", t)
+		}
 	default:
 		return badKey("unsupported key type %T", t)
 	}
@@ -137,11 +156,45 @@ func (policy *KeyPolicy) GoodKey(ctx context.Context, key crypto.PublicKey) erro
 		return policy.goodKeyRSA(t)
 	case *ecdsa.PublicKey:
 		return policy.goodKeyECDSA(t)
+	case ed25519.PublicKey:
+		return policy.goodKeyEd25519(t)
 	default:
 		return badKey("unsupported key type %T", key)
 	}
 }
 
+// remoteGoodKey checks the key using the key policy service. Keys rejected by
+// the service result in an ErrBadKey error, just as they would locally.
+func (policy *KeyPolicy) remoteGoodKey(ctx context.Context, key crypto.PublicKey) error {
+	switch t := key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		break
+	default:
+		return badKey("unsupported key type %T", t)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return badKey("%w", err)
+	}
+	resp, err := policy.remote.CheckKey(ctx, &goodkeypb.CheckKeyRequest{PublicKey: der})
+	if err != nil {
+		return err
+	}
+	if !resp.Good {
+		return badKey("%s", resp.Problem)
+	}
+	return nil
+}
+
+// goodKeyEd25519 determines if an Ed25519 pubkey meets our requirements. Ed25519
+// has a single fixed parameter set, so only the encoding is checked.
+func (policy *KeyPolicy) goodKeyEd25519(key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return badKey("key is %d bytes, Ed25519 keys must be %d bytes", len(key), ed25519.PublicKeySize)
+	}
+	return nil
+}
+
 // GoodKeyECDSA determines if an ECDSA pubkey meets our requirements
 func (policy *KeyPolicy) goodKeyECDSA(key *ecdsa.PublicKey) (err error) {
 	// Check the curve.
@@ -3,6 +3,7 @@ package goodkey
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -289,3 +290,22 @@ func TestRSAStrangeSize(t *testing.T) {
 	test.AssertError(t, err, "expected GoodKey to fail")
 	test.AssertEquals(t, err.Error(), "key size not supported: 4")
 }
+
+func TestEd25519(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "Error generating key")
+
+	err = testingPolicy.GoodKey(context.Background(), pub)
+	test.AssertError(t, err, "Should have rejected Ed25519 key with feature disabled")
+	test.AssertEquals(t, err.Error(), "unsupported key type ed25519.PublicKey")
+
+	err = features.Set(map[string]bool{"Ed25519Issuance": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), pub), "Should have accepted good key")
+
+	err = testingPolicy.GoodKey(context.Background(), pub[:ed25519.PublicKeySize-1])
+	test.AssertError(t, err, "Should have rejected truncated Ed25519 key")
+	test.Assert(t, errors.Is(err, ErrBadKey), "returned error is wrong type")
+}
@@ -0,0 +1,318 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.21.0
+// 	protoc        v3.11.4
+// source: goodkey/proto/goodkey.proto
+
+package proto
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type CheckKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The DER encoded SubjectPublicKeyInfo of the key to check.
+	PublicKey []byte `protobuf:"bytes,1,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+}
+
+func (x *CheckKeyRequest) Reset() {
+	*x = CheckKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goodkey_proto_goodkey_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CheckKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckKeyRequest) ProtoMessage() {}
+
+func (x *CheckKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goodkey_proto_goodkey_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckKeyRequest.ProtoReflect.Descriptor instead.
+func (*CheckKeyRequest) Descriptor() ([]byte, []int) {
+	return file_goodkey_proto_goodkey_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckKeyRequest) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+type CheckKeyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Whether the key is acceptable.
+	Good bool `protobuf:"varint,1,opt,name=good,proto3" json:"good,omitempty"`
+	// For unacceptable keys, a description of the problem which may be shown
+	// to the subscriber.
+	Problem string `protobuf:"bytes,2,opt,name=problem,proto3" json:"problem,omitempty"`
+}
+
+func (x *CheckKeyResponse) Reset() {
+	*x = CheckKeyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goodkey_proto_goodkey_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CheckKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckKeyResponse) ProtoMessage() {}
+
+func (x *CheckKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goodkey_proto_goodkey_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckKeyResponse.ProtoReflect.Descriptor instead.
+func (*CheckKeyResponse) Descriptor() ([]byte, []int) {
+	return file_goodkey_proto_goodkey_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckKeyResponse) GetGood() bool {
+	if x != nil {
+		return x.Good
+	}
+	return false
+}
+
+func (x *CheckKeyResponse) GetProblem() string {
+	if x != nil {
+		return x.Problem
+	}
+	return ""
+}
+
+var File_goodkey_proto_goodkey_proto protoreflect.FileDescriptor
+
+var file_goodkey_proto_goodkey_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x67, 0x6f, 0x6f, 0x64, 0x6b, 0x65, 0x79, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
+	0x67, 0x6f, 0x6f, 0x64, 0x6b, 0x65, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x67,
+	0x6f, 0x6f, 0x64, 0x6b, 0x65, 0x79, 0x22, 0x2f, 0x0a, 0x0f, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4b,
+	0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x75, 0x62,
+	0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75,
+	0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x22, 0x40, 0x0a, 0x10, 0x43, 0x68, 0x65, 0x63, 0x6b,
+	0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x67,
+	0x6f, 0x6f, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x67, 0x6f, 0x6f, 0x64, 0x12,
+	0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x32, 0x4e, 0x0a, 0x09, 0x4b, 0x65, 0x79,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x41, 0x0a, 0x08, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4b,
+	0x65, 0x79, 0x12, 0x18, 0x2e, 0x67, 0x6f, 0x6f, 0x64, 0x6b, 0x65, 0x79, 0x2e, 0x43, 0x68, 0x65,
+	0x63, 0x6b, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x67,
+	0x6f, 0x6f, 0x64, 0x6b, 0x65, 0x79, 0x2e, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4b, 0x65, 0x79, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x74, 0x73, 0x65, 0x6e, 0x63, 0x72,
+	0x79, 0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65, 0x72, 0x2f, 0x67, 0x6f, 0x6f, 0x64,
+	0x6b, 0x65, 0x79, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_goodkey_proto_goodkey_proto_rawDescOnce sync.Once
+	file_goodkey_proto_goodkey_proto_rawDescData = file_goodkey_proto_goodkey_proto_rawDesc
+)
+
+func file_goodkey_proto_goodkey_proto_rawDescGZIP() []byte {
+	file_goodkey_proto_goodkey_proto_rawDescOnce.Do(func() {
+		file_goodkey_proto_goodkey_proto_rawDescData = protoimpl.X.CompressGZIP(file_goodkey_proto_goodkey_proto_rawDescData)
+	})
+	return file_goodkey_proto_goodkey_proto_rawDescData
+}
+
+var file_goodkey_proto_goodkey_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_goodkey_proto_goodkey_proto_goTypes = []interface{}{
+	(*CheckKeyRequest)(nil),  // 0: goodkey.CheckKeyRequest
+	(*CheckKeyResponse)(nil), // 1: goodkey.CheckKeyResponse
+}
+var file_goodkey_proto_goodkey_proto_depIdxs = []int32{
+	0, // 0: goodkey.KeyPolicy.CheckKey:input_type -> goodkey.CheckKeyRequest
+	1, // 1: goodkey.KeyPolicy.CheckKey:output_type -> goodkey.CheckKeyResponse
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_goodkey_proto_goodkey_proto_init() }
+func file_goodkey_proto_goodkey_proto_init() {
+	if File_goodkey_proto_goodkey_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_goodkey_proto_goodkey_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CheckKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goodkey_proto_goodkey_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CheckKeyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_goodkey_proto_goodkey_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_goodkey_proto_goodkey_proto_goTypes,
+		DependencyIndexes: file_goodkey_proto_goodkey_proto_depIdxs,
+		MessageInfos:      file_goodkey_proto_goodkey_proto_msgTypes,
+	}.Build()
+	File_goodkey_proto_goodkey_proto = out.File
+	file_goodkey_proto_goodkey_proto_rawDesc = nil
+	file_goodkey_proto_goodkey_proto_goTypes = nil
+	file_goodkey_proto_goodkey_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// KeyPolicyClient is the client API for KeyPolicy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type KeyPolicyClient interface {
+	CheckKey(ctx context.Context, in *CheckKeyRequest, opts ...grpc.CallOption) (*CheckKeyResponse, error)
+}
+
+type keyPolicyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKeyPolicyClient(cc grpc.ClientConnInterface) KeyPolicyClient {
+	return &keyPolicyClient{cc}
+}
+
+func (c *keyPolicyClient) CheckKey(ctx context.Context, in *CheckKeyRequest, opts ...grpc.CallOption) (*CheckKeyResponse, error) {
+	out := new(CheckKeyResponse)
+	err := c.cc.Invoke(ctx, "/goodkey.KeyPolicy/CheckKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KeyPolicyServer is the server API for KeyPolicy service.
+type KeyPolicyServer interface {
+	CheckKey(context.Context, *CheckKeyRequest) (*CheckKeyResponse, error)
+}
+
+// UnimplementedKeyPolicyServer can be embedded to have forward compatible implementations.
+type UnimplementedKeyPolicyServer struct {
+}
+
+func (*UnimplementedKeyPolicyServer) CheckKey(context.Context, *CheckKeyRequest) (*CheckKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckKey not implemented")
+}
+
+func RegisterKeyPolicyServer(s *grpc.Server, srv KeyPolicyServer) {
+	s.RegisterService(&_KeyPolicy_serviceDesc, srv)
+}
+
+func _KeyPolicy_CheckKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyPolicyServer).CheckKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goodkey.KeyPolicy/CheckKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyPolicyServer).CheckKey(ctx, req.(*CheckKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _KeyPolicy_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "goodkey.KeyPolicy",
+	HandlerType: (*KeyPolicyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CheckKey",
+			Handler:    _KeyPolicy_CheckKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "goodkey/proto/goodkey.proto",
+}
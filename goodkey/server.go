@@ -0,0 +1,70 @@
+package goodkey
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"sync"
+
+	goodkeypb "github.com/letsencrypt/boulder/goodkey/proto"
+)
+
+// Server implements the key policy gRPC service. It holds the only copy of
+// the weak and blocked key lists, which can be reloaded without restarting
+// the service or any of its clients.
+type Server struct {
+	weakKeyFile    string
+	blockedKeyFile string
+	dbCheck        BlockedKeyCheckFunc
+
+	mu     sync.RWMutex
+	policy KeyPolicy
+}
+
+// NewServer returns a Server which checks keys against the given weak and
+// blocked key files and the blocked keys database check, as for NewKeyPolicy.
+func NewServer(weakKeyFile, blockedKeyFile string, bkc BlockedKeyCheckFunc) (*Server, error) {
+	s := &Server{
+		weakKeyFile:    weakKeyFile,
+		blockedKeyFile: blockedKeyFile,
+		dbCheck:        bkc,
+	}
+	err := s.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the weak and blocked key files. If either can't be read the
+// previously loaded lists continue to be used and an error is returned.
+func (s *Server) Reload() error {
+	policy, err := NewKeyPolicy(s.weakKeyFile, s.blockedKeyFile, s.dbCheck)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = policy
+	return nil
+}
+
+// CheckKey implements goodkeypb.KeyPolicyServer. Keys which fail the policy
+// result in a response with Good set to false rather than an error, so that
+// the client can tell them apart from failures to perform the check.
+func (s *Server) CheckKey(ctx context.Context, req *goodkeypb.CheckKeyRequest) (*goodkeypb.CheckKeyResponse, error) {
+	key, err := x509.ParsePKIXPublicKey(req.PublicKey)
+	if err != nil {
+		return &goodkeypb.CheckKeyResponse{Good: false, Problem: "malformed public key"}, nil
+	}
+	s.mu.RLock()
+	policy := s.policy
+	s.mu.RUnlock()
+	err = policy.GoodKey(ctx, key)
+	if errors.Is(err, ErrBadKey) {
+		return &goodkeypb.CheckKeyResponse{Good: false, Problem: err.Error()}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &goodkeypb.CheckKeyResponse{Good: true}, nil
+}
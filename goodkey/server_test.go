@@ -0,0 +1,115 @@
+package goodkey
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/letsencrypt/boulder/core"
+	goodkeypb "github.com/letsencrypt/boulder/goodkey/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// serverClient adapts a Server to goodkeypb.KeyPolicyClient without a network
+// connection.
+type serverClient struct {
+	server *Server
+}
+
+func (sc serverClient) CheckKey(ctx context.Context, req *goodkeypb.CheckKeyRequest, _ ...grpc.CallOption) (*goodkeypb.CheckKeyResponse, error) {
+	return sc.server.CheckKey(ctx, req)
+}
+
+type failingClient struct{}
+
+func (failingClient) CheckKey(context.Context, *goodkeypb.CheckKeyRequest, ...grpc.CallOption) (*goodkeypb.CheckKeyResponse, error) {
+	return nil, errors.New("key policy service unavailable")
+}
+
+func writeBlockedKeysFile(t *testing.T, name string, hexHash string) {
+	t.Helper()
+	err := ioutil.WriteFile(name, []byte("blockedHashesHex:\n  - "+hexHash+"\n"), 0640)
+	test.AssertNotError(t, err, "error writing test blockedKeys yaml file")
+}
+
+func TestServerReload(t *testing.T) {
+	testCert, err := core.LoadCert("../test/block-a-key/test/test.ecdsa.cert.pem")
+	test.AssertNotError(t, err, "error loading test.ecdsa.cert.pem")
+	der, err := x509.MarshalPKIXPublicKey(testCert.PublicKey)
+	test.AssertNotError(t, err, "failed to marshal public key")
+	digest, err := core.KeyDigest(testCert.PublicKey)
+	test.AssertNotError(t, err, "failed to digest public key")
+
+	f, err := ioutil.TempFile("", "test-blocked-keys-list.*.yaml")
+	test.AssertNotError(t, err, "error creating test blockedKeys yaml file")
+	defer os.Remove(f.Name())
+	writeBlockedKeysFile(t, f.Name(), hex.EncodeToString(digest[:]))
+
+	server, err := NewServer("", f.Name(), nil)
+	test.AssertNotError(t, err, "NewServer failed")
+	resp, err := server.CheckKey(context.Background(), &goodkeypb.CheckKeyRequest{PublicKey: der})
+	test.AssertNotError(t, err, "CheckKey failed")
+	test.Assert(t, !resp.Good, "blocked key was accepted")
+	test.AssertEquals(t, resp.Problem, "public key is forbidden")
+
+	// Once the key is removed from the list and the list is reloaded, the key
+	// should be accepted.
+	writeBlockedKeysFile(t, f.Name(), "0000000000000000000000000000000000000000000000000000000000000000")
+	test.AssertNotError(t, server.Reload(), "Reload failed")
+	resp, err = server.CheckKey(context.Background(), &goodkeypb.CheckKeyRequest{PublicKey: der})
+	test.AssertNotError(t, err, "CheckKey failed")
+	test.Assert(t, resp.Good, "unblocked key was rejected")
+
+	// A broken list shouldn't replace the working one.
+	err = ioutil.WriteFile(f.Name(), []byte("blocked: []\n"), 0640)
+	test.AssertNotError(t, err, "error writing test blockedKeys yaml file")
+	test.AssertError(t, server.Reload(), "Reload succeeded with empty list")
+	resp, err = server.CheckKey(context.Background(), &goodkeypb.CheckKeyRequest{PublicKey: der})
+	test.AssertNotError(t, err, "CheckKey failed")
+	test.Assert(t, resp.Good, "key was rejected after failed reload")
+
+	resp, err = server.CheckKey(context.Background(), &goodkeypb.CheckKeyRequest{PublicKey: []byte{1, 2, 3}})
+	test.AssertNotError(t, err, "CheckKey failed")
+	test.Assert(t, !resp.Good, "malformed key was accepted")
+}
+
+func TestRemoteKeyPolicy(t *testing.T) {
+	server, err := NewServer("", "", nil)
+	test.AssertNotError(t, err, "NewServer failed")
+	policy := NewRemoteKeyPolicy(serverClient{server})
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Error generating key")
+	test.AssertNotError(t, policy.GoodKey(context.Background(), &rsaKey.PublicKey), "Should have accepted good key")
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	test.AssertNotError(t, err, "Error generating key")
+	err = policy.GoodKey(context.Background(), &smallKey.PublicKey)
+	test.AssertError(t, err, "Should have rejected small key")
+	test.Assert(t, errors.Is(err, ErrBadKey), "err was not goodkey.ErrBadKey")
+	test.AssertEquals(t, err.Error(), "key too small: 1024")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	test.AssertNotError(t, err, "Error generating key")
+	err = policy.GoodKey(context.Background(), &ecKey.PublicKey)
+	test.Assert(t, errors.Is(err, ErrBadKey), "err was not goodkey.ErrBadKey")
+
+	err = policy.GoodKey(context.Background(), rsaKey.PublicKey)
+	test.Assert(t, errors.Is(err, ErrBadKey), "non-pointer key wasn't rejected with ErrBadKey")
+
+	// Failures to reach the service aren't bad keys.
+	policy = NewRemoteKeyPolicy(failingClient{})
+	err = policy.GoodKey(context.Background(), &rsaKey.PublicKey)
+	test.AssertError(t, err, "GoodKey succeeded without key policy service")
+	test.Assert(t, !errors.Is(err, ErrBadKey), "unavailable service resulted in ErrBadKey")
+}
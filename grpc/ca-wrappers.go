@@ -8,6 +8,7 @@ package grpc
 
 import (
 	"context"
+	"io"
 
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/core"
@@ -47,6 +48,10 @@ func (ogc OCSPGeneratorClientWrapper) GenerateOCSP(ctx context.Context, req *cap
 	return ogc.inner.GenerateOCSP(ctx, req)
 }
 
+func (ogc OCSPGeneratorClientWrapper) GenerateOCSPBatch(ctx context.Context, _ ...grpc.CallOption) (capb.OCSPGenerator_GenerateOCSPBatchClient, error) {
+	return ogc.inner.GenerateOCSPBatch(ctx)
+}
+
 // CertificateAuthorityServerWrapper is the gRPC version of a core.CertificateAuthority server
 type CertificateAuthorityServerWrapper struct {
 	inner core.CertificateAuthority
@@ -67,3 +72,34 @@ func (cas *CertificateAuthorityServerWrapper) IssueCertificateForPrecertificate(
 func (cas *CertificateAuthorityServerWrapper) GenerateOCSP(ctx context.Context, req *capb.GenerateOCSPRequest) (*capb.OCSPResponse, error) {
 	return cas.inner.GenerateOCSP(ctx, req)
 }
+
+// GenerateOCSPBatch signs an OCSP response for each request received on the
+// stream until the client closes its side of it. A request which can't be
+// signed gets a response carrying the error, so that one bad serial doesn't
+// end a batch of millions.
+func (cas *CertificateAuthorityServerWrapper) GenerateOCSPBatch(stream capb.OCSPGenerator_GenerateOCSPBatchServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp := &capb.OCSPBatchResponse{Serial: req.Serial}
+		if req.Serial == "" || req.IssuerID == 0 || len(req.CertDER) != 0 {
+			resp.Error = "batch OCSP requests must specify serial and issuerID, and not certDER"
+		} else {
+			ocspResp, err := cas.inner.GenerateOCSP(stream.Context(), req)
+			if err != nil {
+				resp.Error = err.Error()
+			} else {
+				resp.Response = ocspResp.Response
+			}
+		}
+		err = stream.Send(resp)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"google.golang.org/grpc"
+
+	capb "github.com/letsencrypt/boulder/ca/proto"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// ocspCA implements core.CertificateAuthority, signing OCSP responses which
+// are just the requested serial and status.
+type ocspCA struct{}
+
+func (ocspCA) IssuePrecertificate(context.Context, *capb.IssueCertificateRequest) (*capb.IssuePrecertificateResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (ocspCA) IssueCertificateForPrecertificate(context.Context, *capb.IssueCertificateForPrecertificateRequest) (*corepb.Certificate, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (ocspCA) GenerateOCSP(_ context.Context, req *capb.GenerateOCSPRequest) (*capb.OCSPResponse, error) {
+	if req.Serial == "bad" {
+		return nil, errors.New("unsignable serial")
+	}
+	return &capb.OCSPResponse{Response: []byte(req.Serial + ":" + req.Status)}, nil
+}
+
+func TestGenerateOCSPBatch(t *testing.T) {
+	clk := clock.NewFake()
+	lis, err := net.Listen("tcp", ":0")
+	test.AssertNotError(t, err, "failed to listen")
+	port := lis.Addr().(*net.TCPAddr).Port
+
+	si := newServerInterceptor(NewServerMetrics(metrics.NoopRegisterer), clk)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(si.intercept),
+		grpc.StreamInterceptor(si.interceptStream))
+	capb.RegisterOCSPGeneratorServer(s, NewCertificateAuthorityServer(ocspCA{}))
+	go func() {
+		if err := s.Serve(lis); err != nil &&
+			!strings.HasSuffix(err.Error(), "use of closed network connection") {
+			t.Errorf("s.Serve: %v", err)
+		}
+	}()
+	defer s.Stop()
+
+	ci := &clientInterceptor{
+		timeout: 30 * time.Second,
+		metrics: NewClientMetrics(metrics.NoopRegisterer),
+		clk:     clk,
+	}
+	conn, err := grpc.Dial(net.JoinHostPort("localhost", strconv.Itoa(port)),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(ci.intercept),
+		grpc.WithStreamInterceptor(ci.interceptStream))
+	test.AssertNotError(t, err, "failed to dial")
+	defer conn.Close()
+	ogc := NewOCSPGeneratorClient(capb.NewOCSPGeneratorClient(conn))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := ogc.GenerateOCSPBatch(ctx)
+	test.AssertNotError(t, err, "failed to open stream")
+
+	reqs := []*capb.GenerateOCSPRequest{
+		{Serial: "01", IssuerID: 1, Status: "good"},
+		{Serial: "bad", IssuerID: 1, Status: "good"},
+		{Serial: "02", Status: "good"},
+		{Serial: "03", IssuerID: 1, Status: "revoked"},
+	}
+	for _, req := range reqs {
+		test.AssertNotError(t, stream.Send(req), "failed to send request")
+	}
+	test.AssertNotError(t, stream.CloseSend(), "failed to close stream")
+
+	var resps []*capb.OCSPBatchResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		test.AssertNotError(t, err, "failed to receive response")
+		resps = append(resps, resp)
+	}
+	test.AssertEquals(t, len(resps), len(reqs))
+	test.AssertEquals(t, resps[0].Serial, "01")
+	test.AssertEquals(t, string(resps[0].Response), "01:good")
+	test.AssertEquals(t, resps[0].Error, "")
+	// A failure to sign one response doesn't end the stream
+	test.AssertEquals(t, resps[1].Serial, "bad")
+	test.AssertEquals(t, len(resps[1].Response), 0)
+	test.AssertContains(t, resps[1].Error, "unsignable serial")
+	// Requests must identify the certificate by serial and issuer ID
+	test.AssertEquals(t, resps[2].Serial, "02")
+	test.AssertContains(t, resps[2].Error, "must specify serial and issuerID")
+	test.AssertEquals(t, string(resps[3].Response), "03:revoked")
+
+	// The stream's request time was observed like a unary RPC's
+	test.AssertEquals(t, test.CountHistogramSamples(si.metrics.rpcLag), 1)
+}
@@ -3,6 +3,7 @@ package grpc
 import (
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
 
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -11,6 +12,9 @@ import (
 	bcreds "github.com/letsencrypt/boulder/grpc/creds"
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	// Imported for its client side health checking, enabled by
+	// HealthCheckService.
+	_ "google.golang.org/grpc/health"
 )
 
 // ClientSetup creates a gRPC TransportCredentials that presents
@@ -39,12 +43,23 @@ func ClientSetup(c *cmd.GRPCClientConfig, tlsConfig *tls.Config, metrics clientM
 		return nil, err
 	}
 	creds := bcreds.NewClientCredentials(tlsConfig.RootCAs, tlsConfig.Certificates, host)
-	return grpc.Dial(
-		"dns:///"+c.ServerAddress,
+	opts := []grpc.DialOption{
 		grpc.WithBalancerName("round_robin"),
 		grpc.WithTransportCredentials(creds),
 		grpc.WithUnaryInterceptor(ci.intercept),
-	)
+		grpc.WithStreamInterceptor(ci.interceptStream),
+	}
+	if c.HealthCheckService != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(healthCheckServiceConfig(c.HealthCheckService)))
+	}
+	return grpc.Dial("dns:///"+c.ServerAddress, opts...)
+}
+
+// healthCheckServiceConfig returns a gRPC service config which makes the
+// round robin balancer skip servers which don't report the named service as
+// serving.
+func healthCheckServiceConfig(service string) string {
+	return fmt.Sprintf(`{"healthCheckConfig": {"serviceName": %q}}`, service)
 }
 
 type registry interface {
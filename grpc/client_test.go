@@ -0,0 +1,19 @@
+package grpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestHealthCheckServiceConfig(t *testing.T) {
+	var sc struct {
+		HealthCheckConfig struct {
+			ServiceName string
+		}
+	}
+	err := json.Unmarshal([]byte(healthCheckServiceConfig("sa.StorageAuthority")), &sc)
+	test.AssertNotError(t, err, "service config isn't valid JSON")
+	test.AssertEquals(t, sc.HealthCheckConfig.ServiceName, "sa.StorageAuthority")
+}
@@ -9,6 +9,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	berrors "github.com/letsencrypt/boulder/errors"
 )
@@ -23,10 +24,16 @@ var (
 // context. errors.BoulderError error types are encoded using the grpc/metadata
 // in the context.Context for the RPC which is considered to be the 'proper'
 // method of encoding custom error types (grpc/grpc#4543 and grpc/grpc-go#478)
+//
+// ResourceExhausted gRPC errors, which servers use to shed load, are passed
+// through unchanged so that clients can recognize them.
 func wrapError(ctx context.Context, err error) error {
 	if err == nil {
 		return nil
 	}
+	if status.Code(err) == codes.ResourceExhausted {
+		return err
+	}
 	if berr, ok := err.(*berrors.BoulderError); ok {
 		pairs := []string{
 			"errortype", strconv.Itoa(int(berr.Type)),
@@ -47,6 +54,18 @@ func wrapError(ctx context.Context, err error) error {
 			pairs = append(pairs, string(jsonSubErrs))
 		}
 
+		// Similarly, if the details of an exceeded rate limit are present they
+		// are included as JSON.
+		if berr.RateLimit != nil {
+			jsonRateLimit, err := json.Marshal(berr.RateLimit)
+			if err != nil {
+				return berrors.InternalServerError(
+					"error marshaling json RateLimit, orig error %q",
+					err)
+			}
+			pairs = append(pairs, "ratelimit", string(jsonRateLimit))
+		}
+
 		// Ignoring the error return here is safe because if setting the metadata
 		// fails, we'll still return an error, but it will be interpreted on the
 		// other side as an InternalServerError instead of a more specific one.
@@ -98,6 +117,23 @@ func unwrapError(err error, md metadata.MD) error {
 			}
 			outErr = (outErr.(*berrors.BoulderError)).WithSubErrors(suberrs)
 		}
+		if rateLimitJSON, ok := md["ratelimit"]; ok {
+			if len(rateLimitJSON) != 1 {
+				return berrors.InternalServerError(
+					"multiple ratelimit metadata, wrapped error %q",
+					unwrappedErr,
+				)
+			}
+			var rateLimit berrors.RateLimitDetails
+			if err := json.Unmarshal([]byte(rateLimitJSON[0]), &rateLimit); err != nil {
+				return berrors.InternalServerError(
+					"error unmarshaling ratelimit JSON %q, wrapped error %q",
+					rateLimitJSON[0],
+					unwrappedErr,
+				)
+			}
+			outErr.(*berrors.BoulderError).RateLimit = &rateLimit
+		}
 		return outErr
 	}
 	return err
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/jmhodges/clock"
 	berrors "github.com/letsencrypt/boulder/errors"
@@ -95,3 +97,64 @@ func TestSubErrorWrapping(t *testing.T) {
 	test.Assert(t, err != nil, fmt.Sprintf("nil error returned, expected: %s", err))
 	test.AssertDeepEquals(t, err, es.err)
 }
+
+// TestRateLimitErrorWrapping tests that the details of an exceeded rate limit
+// survive wrapping and unwrapping across the RPC layer.
+func TestRateLimitErrorWrapping(t *testing.T) {
+	serverMetrics := NewServerMetrics(metrics.NoopRegisterer)
+	si := newServerInterceptor(serverMetrics, clock.NewFake())
+	ci := clientInterceptor{time.Second, NewClientMetrics(metrics.NoopRegisterer), clock.NewFake()}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(si.intercept))
+	es := &errorServer{}
+	testproto.RegisterChillerServer(srv, es)
+	lis, err := net.Listen("tcp", "127.0.0.1:")
+	test.AssertNotError(t, err, "Failed to create listener")
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(
+		lis.Addr().String(),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(ci.intercept),
+	)
+	test.AssertNotError(t, err, "Failed to dial grpc test server")
+	client := testproto.NewChillerClient(conn)
+
+	es.err = berrors.RateLimitExceededError(berrors.RateLimitDetails{
+		Name:       "newOrdersPerAccount",
+		Usage:      300,
+		Threshold:  300,
+		RetryAfter: 3 * time.Hour,
+	}, "too many new orders recently")
+
+	_, err = client.Chill(context.Background(), &testproto.Time{})
+	test.Assert(t, err != nil, "nil error returned")
+	test.AssertDeepEquals(t, err, es.err)
+}
+
+// TestResourceExhaustedPassthrough tests that ResourceExhausted errors and
+// their trailers reach the client unchanged.
+func TestResourceExhaustedPassthrough(t *testing.T) {
+	serverMetrics := NewServerMetrics(metrics.NoopRegisterer)
+	si := newServerInterceptor(serverMetrics, clock.NewFake())
+	ci := clientInterceptor{time.Second, NewClientMetrics(metrics.NoopRegisterer), clock.NewFake()}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(si.intercept))
+	es := &errorServer{err: status.Error(codes.ResourceExhausted, "too busy")}
+	testproto.RegisterChillerServer(srv, es)
+	lis, err := net.Listen("tcp", "127.0.0.1:")
+	test.AssertNotError(t, err, "Failed to create listener")
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(
+		lis.Addr().String(),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(ci.intercept),
+	)
+	test.AssertNotError(t, err, "Failed to dial grpc test server")
+	client := testproto.NewChillerClient(conn)
+
+	_, err = client.Chill(context.Background(), &testproto.Time{})
+	test.AssertEquals(t, status.Code(err), codes.ResourceExhausted)
+	test.AssertEquals(t, status.Convert(err).Message(), "too busy")
+}
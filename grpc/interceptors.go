@@ -15,6 +15,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/requestid"
 )
 
 const (
@@ -22,6 +23,9 @@ const (
 	meaningfulWorkOverhead = 100 * time.Millisecond
 	clientRequestTimeKey   = "client-request-time"
 	serverLatencyKey       = "server-latency"
+	// requestIDKey is the metadata key carrying the ID of the ACME request
+	// which caused an RPC, if any. See the requestid package.
+	requestIDKey = "request-id"
 )
 
 // serverInterceptor is a gRPC interceptor that adds Prometheus
@@ -52,6 +56,7 @@ func (si *serverInterceptor) intercept(ctx context.Context, req interface{}, inf
 			return nil, err
 		}
 	}
+	ctx = withIncomingRequestID(ctx)
 
 	// Shave 20 milliseconds off the deadline to ensure that if the RPC server times
 	// out any sub-calls it makes (like DNS lookups, or onwards RPCs), it has a
@@ -82,6 +87,64 @@ func (si *serverInterceptor) intercept(ctx context.Context, req interface{}, inf
 	return resp, err
 }
 
+// interceptStream is the streaming equivalent of intercept. It doesn't shorten
+// the deadline, since streams may be long lived, but an error ending the
+// stream is wrapped like one returned from a unary RPC.
+func (si *serverInterceptor) interceptStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if info == nil {
+		return berrors.InternalServerError("passed nil *grpc.StreamServerInfo")
+	}
+
+	if md, ok := metadata.FromIncomingContext(ss.Context()); ok && len(md[clientRequestTimeKey]) > 0 {
+		if err := si.observeLatency(md[clientRequestTimeKey][0]); err != nil {
+			return err
+		}
+	}
+
+	if requestID := requestid.FromContext(withIncomingRequestID(ss.Context())); requestID != "" {
+		ss = requestIDServerStream{ServerStream: ss, requestID: requestID}
+	}
+
+	err := si.metrics.grpcMetrics.StreamServerInterceptor()(srv, ss, info, handler)
+	if err != nil {
+		err = wrapError(ss.Context(), err)
+	}
+	return err
+}
+
+// withIncomingRequestID returns a copy of ctx carrying the request ID sent in
+// its incoming gRPC metadata, if there is one, so that it's included in the
+// server's audit log lines and passed along with any onward RPCs.
+func withIncomingRequestID(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md[requestIDKey]) == 0 {
+		return ctx
+	}
+	return requestid.WithID(ctx, md[requestIDKey][0])
+}
+
+// requestIDServerStream is a grpc.ServerStream whose context carries a request
+// ID.
+type requestIDServerStream struct {
+	grpc.ServerStream
+	requestID string
+}
+
+func (ss requestIDServerStream) Context() context.Context {
+	return requestid.WithID(ss.ServerStream.Context(), ss.requestID)
+}
+
+// outgoingMetadata returns the metadata to send with an RPC made at the
+// given time with ctx: the request time and, if ctx carries one, the ID of the
+// ACME request which caused it.
+func outgoingMetadata(ctx context.Context, now time.Time) metadata.MD {
+	md := metadata.New(map[string]string{clientRequestTimeKey: strconv.FormatInt(now.UnixNano(), 10)})
+	if requestID := requestid.FromContext(ctx); requestID != "" {
+		md.Set(requestIDKey, requestID)
+	}
+	return md
+}
+
 // splitMethodName is borrowed directly from
 // `grpc-ecosystem/go-grpc-prometheus/util.go` and is used to extract the
 // service and method name from the `method` argument to
@@ -149,12 +212,9 @@ func (ci *clientInterceptor) intercept(
 	// are down.
 	opts = append(opts, grpc.FailFast(false))
 
-	// Convert the current unix nano timestamp to a string for embedding in the grpc metadata
-	nowTS := strconv.FormatInt(ci.clk.Now().UnixNano(), 10)
-
-	// Create a grpc/metadata.Metadata instance for the request metadata.
-	// Initialize it with the request time.
-	reqMD := metadata.New(map[string]string{clientRequestTimeKey: nowTS})
+	// Create a grpc/metadata.Metadata instance for the request metadata,
+	// carrying the request time and request ID.
+	reqMD := outgoingMetadata(ctx, ci.clk.Now())
 	// Configure the localCtx with the metadata so it gets sent along in the request
 	localCtx = metadata.NewOutgoingContext(localCtx, reqMD)
 
@@ -194,6 +254,25 @@ func (ci *clientInterceptor) intercept(
 	return err
 }
 
+// interceptStream fulfils the grpc.StreamClientInterceptor interface. Unlike
+// intercept it doesn't apply the configured timeout, since streams may be long
+// lived, so callers must bound them using the provided context.
+func (ci *clientInterceptor) interceptStream(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	fullMethod string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	// Disable fail-fast so streams will retry until deadline, even if all
+	// backends are down.
+	opts = append(opts, grpc.FailFast(false))
+
+	ctx = metadata.NewOutgoingContext(ctx, outgoingMetadata(ctx, ci.clk.Now()))
+
+	return ci.metrics.grpcMetrics.StreamClientInterceptor()(ctx, desc, cc, fullMethod, streamer, opts...)
+}
+
 // deadlineDetails is an error type that we use in place of gRPC's
 // DeadlineExceeded errors in order to add more detail for debugging.
 type deadlineDetails struct {
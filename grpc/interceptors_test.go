@@ -19,6 +19,7 @@ import (
 
 	"github.com/letsencrypt/boulder/grpc/test_proto"
 	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/requestid"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -73,6 +74,43 @@ func TestClientInterceptor(t *testing.T) {
 	test.AssertError(t, err, "ci.intercept didn't fail when handler returned a error")
 }
 
+func TestRequestIDPropagation(t *testing.T) {
+	ci := clientInterceptor{
+		timeout: time.Second,
+		metrics: NewClientMetrics(metrics.NoopRegisterer),
+		clk:     clock.NewFake(),
+	}
+	var sent metadata.MD
+	invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	ctx := requestid.WithID(context.Background(), "abcd")
+	err := ci.intercept(ctx, "-service-test", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.AssertDeepEquals(t, sent[requestIDKey], []string{"abcd"})
+
+	// An RPC made outside of any ACME request sends no request ID.
+	err = ci.intercept(context.Background(), "-service-test", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.AssertEquals(t, len(sent[requestIDKey]), 0)
+
+	// The server makes the request ID it receives available to its handler.
+	si := newServerInterceptor(NewServerMetrics(metrics.NoopRegisterer), clock.NewFake())
+	var received string
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		received = requestid.FromContext(ctx)
+		return nil, nil
+	}
+	_, err = si.intercept(metadata.NewIncomingContext(context.Background(), sent), nil, &grpc.UnaryServerInfo{FullMethod: "-service-test"}, handler)
+	test.AssertNotError(t, err, "si.intercept failed")
+	test.AssertEquals(t, received, "")
+	sent.Set(requestIDKey, "abcd")
+	_, err = si.intercept(metadata.NewIncomingContext(context.Background(), sent), nil, &grpc.UnaryServerInfo{FullMethod: "-service-test"}, handler)
+	test.AssertNotError(t, err, "si.intercept failed")
+	test.AssertEquals(t, received, "abcd")
+}
+
 // TestFailFastFalse sends a gRPC request to a backend that is
 // unavailable, and ensures that the request doesn't error out until the
 // timeout is reached, i.e. that FailFast is set to false.
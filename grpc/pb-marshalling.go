@@ -6,9 +6,14 @@
 package grpc
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"google.golang.org/grpc/codes"
 	"gopkg.in/square/go-jose.v2"
 
@@ -72,6 +77,13 @@ func ChallengeToPB(challenge core.Challenge) (*corepb.Challenge, error) {
 			return nil, err
 		}
 	}
+	var onionCSRResponse []byte
+	if challenge.OnionCSRResponse != nil {
+		onionCSRResponse, err = json.Marshal(challenge.OnionCSRResponse)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &corepb.Challenge{
 		Type:              &ctype,
 		Status:            &st,
@@ -79,6 +91,7 @@ func ChallengeToPB(challenge core.Challenge) (*corepb.Challenge, error) {
 		KeyAuthorization:  &challenge.ProvidedKeyAuthorization,
 		Error:             prob,
 		Validationrecords: recordAry,
+		OnionCSRResponse:  onionCSRResponse,
 	}, nil
 }
 
@@ -113,6 +126,13 @@ func PBToChallenge(in *corepb.Challenge) (challenge core.Challenge, err error) {
 	if in.KeyAuthorization != nil {
 		ch.ProvidedKeyAuthorization = *in.KeyAuthorization
 	}
+	if len(in.OnionCSRResponse) > 0 {
+		ch.OnionCSRResponse = &core.OnionCSRResponse{}
+		err = json.Unmarshal(in.OnionCSRResponse, ch.OnionCSRResponse)
+		if err != nil {
+			return core.Challenge{}, err
+		}
+	}
 	return ch, nil
 }
 
@@ -130,14 +150,22 @@ func ValidationRecordToPB(record core.ValidationRecord) (*corepb.ValidationRecor
 	if err != nil {
 		return nil, err
 	}
-	return &corepb.ValidationRecord{
+	pb := &corepb.ValidationRecord{
 		Hostname:          &record.Hostname,
 		Port:              &record.Port,
 		AddressesResolved: addrs,
 		AddressUsed:       addrUsed,
 		Url:               &record.URL,
 		AddressesTried:    addrsTried,
-	}, nil
+		DnsAnswers:        record.DNSAnswers,
+		ResponseHeaders:   record.ResponseHeaders,
+	}
+	// Records of validations which made no HTTP request have no status, and
+	// must round-trip without one.
+	if record.ResponseStatus != "" {
+		pb.ResponseStatus = &record.ResponseStatus
+	}
+	return pb, nil
 }
 
 func PBToValidationRecord(in *corepb.ValidationRecord) (record core.ValidationRecord, err error) {
@@ -167,6 +195,9 @@ func PBToValidationRecord(in *corepb.ValidationRecord) (record core.ValidationRe
 		AddressUsed:       addrUsed,
 		URL:               *in.Url,
 		AddressesTried:    addrsTried,
+		ResponseStatus:    in.GetResponseStatus(),
+		DNSAnswers:        in.DnsAnswers,
+		ResponseHeaders:   in.ResponseHeaders,
 	}, nil
 }
 
@@ -310,7 +341,7 @@ func PBToAuthz(pb *corepb.Authorization) (core.Authorization, error) {
 	}
 	expires := time.Unix(0, *pb.Expires).UTC()
 	authz := core.Authorization{
-		Identifier:     identifier.ACMEIdentifier{Type: identifier.DNS, Value: *pb.Identifier},
+		Identifier:     identifier.FromValue(*pb.Identifier),
 		RegistrationID: *pb.RegistrationID,
 		Status:         core.AcmeStatus(*pb.Status),
 		Expires:        &expires,
@@ -419,3 +450,85 @@ func PBToAuthzMap(pb *sapb.Authorizations) (map[string]*core.Authorization, erro
 	}
 	return m, nil
 }
+
+// TimeToPB converts a time.Time to a Timestamp. The zero time.Time, which
+// would otherwise be sent as a valid if meaningless timestamp in year 1, is
+// converted to nil, as is any time a Timestamp can't represent.
+func TimeToPB(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+// PBToTime converts a Timestamp to a time.Time. It returns an error if ts is
+// nil, zero, which is the Unix epoch and is what an unset field reads as when
+// it's copied by value, or outside the range a Timestamp may hold.
+func PBToTime(ts *timestamp.Timestamp) (time.Time, error) {
+	if ts == nil || (ts.Seconds == 0 && ts.Nanos == 0) {
+		return time.Time{}, errors.New("timestamp is unset")
+	}
+	return ptypes.Timestamp(ts)
+}
+
+// PBOrNanosToTime converts a time sent during the migration from UnixNano
+// int64 fields to Timestamps, using ts if it's set, and otherwise falling back
+// to nanos. It returns an error if neither is set, or the one used is zero.
+func PBOrNanosToTime(ts *timestamp.Timestamp, nanos *int64) (time.Time, error) {
+	if ts != nil {
+		return PBToTime(ts)
+	}
+	if nanos == nil || *nanos == 0 {
+		return time.Time{}, errors.New("timestamp is unset")
+	}
+	return time.Unix(0, *nanos), nil
+}
+
+// RangeToPB converts a time range to a Range, setting both the UnixNano and
+// Timestamp forms of each end.
+func RangeToPB(earliest, latest time.Time) *sapb.Range {
+	earliestNanos := earliest.UnixNano()
+	latestNanos := latest.UnixNano()
+	return &sapb.Range{
+		Earliest:     &earliestNanos,
+		Latest:       &latestNanos,
+		EarliestTime: TimeToPB(earliest),
+		LatestTime:   TimeToPB(latest),
+	}
+}
+
+// PBToRange converts a Range to a time range, taking each end from whichever
+// of its Timestamp and UnixNano forms is set.
+func PBToRange(r *sapb.Range) (time.Time, time.Time, error) {
+	if r == nil {
+		return time.Time{}, time.Time{}, errors.New("range is unset")
+	}
+	earliest, err := PBOrNanosToTime(r.EarliestTime, r.Earliest)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid earliest: %s", err)
+	}
+	latest, err := PBOrNanosToTime(r.LatestTime, r.Latest)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid latest: %s", err)
+	}
+	return earliest, latest, nil
+}
+
+// rangeIsSet returns true if both ends of r are set, in either form.
+func rangeIsSet(r *sapb.Range) bool {
+	return r != nil && (r.Earliest != nil || r.EarliestTime != nil) && (r.Latest != nil || r.LatestTime != nil)
+}
+
+// OptionalPBOrNanosToTime is like PBOrNanosToTime, for times which may be
+// left unset, such as the expiry of a pause which never lapses. It returns
+// the zero time.Time if neither ts nor nanos is set, or nanos is zero.
+func OptionalPBOrNanosToTime(ts *timestamp.Timestamp, nanos *int64) (time.Time, error) {
+	if ts == nil && (nanos == nil || *nanos == 0) {
+		return time.Time{}, nil
+	}
+	return PBOrNanosToTime(ts, nanos)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"gopkg.in/square/go-jose.v2"
 
 	"github.com/letsencrypt/boulder/core"
@@ -88,6 +89,17 @@ func TestChallenge(t *testing.T) {
 	test.AssertNotError(t, err, "PBToChallenge failed")
 	test.AssertDeepEquals(t, recon, chall)
 
+	chall.Type = core.ChallengeTypeOnionCSR01
+	chall.OnionCSRResponse = &core.OnionCSRResponse{
+		CSR:      "csr",
+		OnionCAA: &core.OnionCAA{CAA: `caa 0 issue "letsencrypt.org"`, Expiry: 1, Signature: "sig"},
+	}
+	pb, err = ChallengeToPB(chall)
+	test.AssertNotError(t, err, "ChallengeToPB failed")
+	recon, err = PBToChallenge(pb)
+	test.AssertNotError(t, err, "PBToChallenge failed")
+	test.AssertDeepEquals(t, recon, chall)
+
 	_, err = PBToChallenge(nil)
 	test.AssertError(t, err, "PBToChallenge did not fail")
 	test.AssertEquals(t, err, ErrMissingParameters)
@@ -105,6 +117,9 @@ func TestValidationRecord(t *testing.T) {
 		AddressUsed:       ip,
 		URL:               "url",
 		AddressesTried:    []net.IP{ip},
+		ResponseStatus:    "200 OK",
+		DNSAnswers:        []string{"answer"},
+		ResponseHeaders:   map[string]string{"Server": "nginx"},
 	}
 
 	pb, err := ValidationRecordToPB(vr)
@@ -114,6 +129,12 @@ func TestValidationRecord(t *testing.T) {
 	recon, err := PBToValidationRecord(pb)
 	test.AssertNotError(t, err, "PBToValidationRecord failed")
 	test.AssertDeepEquals(t, recon, vr)
+
+	// A record without a response status has none set in the proto.
+	vr.ResponseStatus = ""
+	pb, err = ValidationRecordToPB(vr)
+	test.AssertNotError(t, err, "ValidationRecordToPB failed")
+	test.Assert(t, pb.ResponseStatus == nil, "Empty ResponseStatus was set")
 }
 
 func TestValidationResult(t *testing.T) {
@@ -355,3 +376,69 @@ func TestOrderValid(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeToPB(t *testing.T) {
+	now := time.Now()
+	ts := TimeToPB(now)
+	back, err := PBToTime(ts)
+	test.AssertNotError(t, err, "converting Timestamp back to time.Time")
+	test.Assert(t, back.Equal(now), "time didn't round trip")
+
+	// The zero time.Time isn't sent as a Timestamp, and nil and zero
+	// Timestamps aren't accepted as times.
+	test.Assert(t, TimeToPB(time.Time{}) == nil, "zero time.Time converted to a Timestamp")
+	_, err = PBToTime(nil)
+	test.AssertError(t, err, "nil Timestamp converted")
+	_, err = PBToTime(&timestamp.Timestamp{})
+	test.AssertError(t, err, "zero Timestamp converted")
+	_, err = PBToTime(&timestamp.Timestamp{Seconds: 1, Nanos: -1})
+	test.AssertError(t, err, "invalid Timestamp converted")
+}
+
+func TestPBOrNanosToTime(t *testing.T) {
+	now := time.Now()
+	nanos := now.UnixNano()
+	other := now.Add(time.Hour).UnixNano()
+
+	// The Timestamp takes precedence over the nanoseconds.
+	got, err := PBOrNanosToTime(TimeToPB(now), &other)
+	test.AssertNotError(t, err, "converting Timestamp")
+	test.AssertEquals(t, got.UnixNano(), nanos)
+
+	got, err = PBOrNanosToTime(nil, &nanos)
+	test.AssertNotError(t, err, "converting nanoseconds")
+	test.AssertEquals(t, got.UnixNano(), nanos)
+
+	zero := int64(0)
+	_, err = PBOrNanosToTime(nil, &zero)
+	test.AssertError(t, err, "zero nanoseconds converted")
+	_, err = PBOrNanosToTime(nil, nil)
+	test.AssertError(t, err, "unset time converted")
+	_, err = PBOrNanosToTime(&timestamp.Timestamp{}, &nanos)
+	test.AssertError(t, err, "zero Timestamp converted")
+}
+
+func TestOptionalPBOrNanosToTime(t *testing.T) {
+	now := time.Now()
+	nanos := now.UnixNano()
+
+	got, err := OptionalPBOrNanosToTime(TimeToPB(now), nil)
+	test.AssertNotError(t, err, "converting Timestamp")
+	test.AssertEquals(t, got.UnixNano(), nanos)
+
+	got, err = OptionalPBOrNanosToTime(nil, &nanos)
+	test.AssertNotError(t, err, "converting nanoseconds")
+	test.AssertEquals(t, got.UnixNano(), nanos)
+
+	// Unset times are the zero time.Time, but a zero Timestamp is still
+	// rejected, since it can only have been set by mistake.
+	zero := int64(0)
+	got, err = OptionalPBOrNanosToTime(nil, &zero)
+	test.AssertNotError(t, err, "converting zero nanoseconds")
+	test.Assert(t, got.IsZero(), "zero nanoseconds weren't the zero time")
+	got, err = OptionalPBOrNanosToTime(nil, nil)
+	test.AssertNotError(t, err, "converting unset time")
+	test.Assert(t, got.IsZero(), "unset time wasn't the zero time")
+	_, err = OptionalPBOrNanosToTime(&timestamp.Timestamp{}, nil)
+	test.AssertError(t, err, "zero Timestamp converted")
+}
@@ -9,6 +9,7 @@ package grpc
 import (
 	"context"
 	"crypto/x509"
+	"io"
 
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
@@ -164,6 +165,70 @@ func (rac RegistrationAuthorityClientWrapper) AdministrativelyRevokeCertificate(
 	return nil
 }
 
+func (rac RegistrationAuthorityClientWrapper) SetRevocationWebhook(ctx context.Context, request *rapb.SetRevocationWebhookRequest) (*corepb.Empty, error) {
+	_, err := rac.inner.SetRevocationWebhook(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+func (rac RegistrationAuthorityClientWrapper) UnpauseAccount(ctx context.Context, request *rapb.UnpauseAccountRequest) (*corepb.Empty, error) {
+	_, err := rac.inner.UnpauseAccount(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+func (rac RegistrationAuthorityClientWrapper) RevokeCertByKey(ctx context.Context, request *rapb.RevokeCertByKeyRequest) (*corepb.Empty, error) {
+	_, err := rac.inner.RevokeCertByKey(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+// AdministrativelyRevokeCertificates sends each request returned by recv to
+// the RA, until recv returns io.EOF, and returns the RA's summary of the
+// revocations.
+func (rac RegistrationAuthorityClientWrapper) AdministrativelyRevokeCertificates(ctx context.Context, recv func() (*rapb.AdministrativelyRevokeCertificatesRequest, error)) (*rapb.AdministrativelyRevokeCertificatesResponse, error) {
+	// Cancelling the context ends the stream if recv fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := rac.inner.AdministrativelyRevokeCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		request, err := recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = stream.Send(request)
+		if err == io.EOF {
+			// The RA ended the stream early. Its error is returned by
+			// CloseAndRecv.
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Revoked == nil || resp.AlreadyRevoked == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
 func (ras *RegistrationAuthorityClientWrapper) NewOrder(ctx context.Context, request *rapb.NewOrderRequest) (*corepb.Order, error) {
 	resp, err := ras.inner.NewOrder(ctx, request)
 	if err != nil {
@@ -328,6 +393,47 @@ func (ras *RegistrationAuthorityServerWrapper) AdministrativelyRevokeCertificate
 	return &corepb.Empty{}, nil
 }
 
+func (ras *RegistrationAuthorityServerWrapper) SetRevocationWebhook(ctx context.Context, request *rapb.SetRevocationWebhookRequest) (*corepb.Empty, error) {
+	if request == nil || request.RegistrationID == nil || request.Url == nil {
+		return nil, errIncompleteRequest
+	}
+	return ras.inner.SetRevocationWebhook(ctx, request)
+}
+
+func (ras *RegistrationAuthorityServerWrapper) UnpauseAccount(ctx context.Context, request *rapb.UnpauseAccountRequest) (*corepb.Empty, error) {
+	if request == nil || request.RegistrationID == nil {
+		return nil, errIncompleteRequest
+	}
+	return ras.inner.UnpauseAccount(ctx, request)
+}
+
+func (ras *RegistrationAuthorityServerWrapper) RevokeCertByKey(ctx context.Context, request *rapb.RevokeCertByKeyRequest) (*corepb.Empty, error) {
+	if request == nil || request.Cert == nil {
+		return nil, errIncompleteRequest
+	}
+	return ras.inner.RevokeCertByKey(ctx, request)
+}
+
+func (ras *RegistrationAuthorityServerWrapper) AdministrativelyRevokeCertificates(stream rapb.RegistrationAuthority_AdministrativelyRevokeCertificatesServer) error {
+	first := true
+	recv := func() (*rapb.AdministrativelyRevokeCertificatesRequest, error) {
+		request, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if request.Serial == nil || first && (request.Code == nil || request.AdminName == nil || request.IncidentID == nil) {
+			return nil, errIncompleteRequest
+		}
+		first = false
+		return request, nil
+	}
+	resp, err := ras.inner.AdministrativelyRevokeCertificates(stream.Context(), recv)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(resp)
+}
+
 func (ras *RegistrationAuthorityServerWrapper) NewOrder(ctx context.Context, request *rapb.NewOrderRequest) (*corepb.Order, error) {
 	if request == nil || request.RegistrationID == nil {
 		return nil, errIncompleteRequest
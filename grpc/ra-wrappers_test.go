@@ -0,0 +1,117 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jmhodges/clock"
+	"google.golang.org/grpc"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// revokingRA implements the AdministrativelyRevokeCertificates method of
+// core.RegistrationAuthority, revoking every serial it receives.
+type revokingRA struct {
+	core.RegistrationAuthority
+	serials []string
+}
+
+func (ra *revokingRA) AdministrativelyRevokeCertificates(_ context.Context, recv func() (*rapb.AdministrativelyRevokeCertificatesRequest, error)) (*rapb.AdministrativelyRevokeCertificatesResponse, error) {
+	for {
+		req, err := recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ra.serials = append(ra.serials, req.GetSerial())
+	}
+	revoked := int64(len(ra.serials))
+	var alreadyRevoked int64
+	return &rapb.AdministrativelyRevokeCertificatesResponse{Revoked: &revoked, AlreadyRevoked: &alreadyRevoked}, nil
+}
+
+func TestAdministrativelyRevokeCertificates(t *testing.T) {
+	clk := clock.NewFake()
+	lis, err := net.Listen("tcp", ":0")
+	test.AssertNotError(t, err, "failed to listen")
+	port := lis.Addr().(*net.TCPAddr).Port
+
+	si := newServerInterceptor(NewServerMetrics(metrics.NoopRegisterer), clk)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(si.intercept),
+		grpc.StreamInterceptor(si.interceptStream))
+	ra := &revokingRA{}
+	rapb.RegisterRegistrationAuthorityServer(s, NewRegistrationAuthorityServer(ra))
+	go func() {
+		if err := s.Serve(lis); err != nil &&
+			!strings.HasSuffix(err.Error(), "use of closed network connection") {
+			t.Errorf("s.Serve: %v", err)
+		}
+	}()
+	defer s.Stop()
+
+	ci := &clientInterceptor{
+		timeout: 30 * time.Second,
+		metrics: NewClientMetrics(metrics.NoopRegisterer),
+		clk:     clk,
+	}
+	conn, err := grpc.Dial(net.JoinHostPort("localhost", strconv.Itoa(port)),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(ci.intercept),
+		grpc.WithStreamInterceptor(ci.interceptStream))
+	test.AssertNotError(t, err, "failed to dial")
+	defer conn.Close()
+	rac := NewRegistrationAuthorityClient(rapb.NewRegistrationAuthorityClient(conn))
+
+	stream := func(reqs ...*rapb.AdministrativelyRevokeCertificatesRequest) func() (*rapb.AdministrativelyRevokeCertificatesRequest, error) {
+		return func() (*rapb.AdministrativelyRevokeCertificatesRequest, error) {
+			if len(reqs) == 0 {
+				return nil, io.EOF
+			}
+			req := reqs[0]
+			reqs = reqs[1:]
+			return req, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	code := int64(4)
+	admin := "root"
+	incidentID := int64(42)
+	resp, err := rac.AdministrativelyRevokeCertificates(ctx, stream(
+		&rapb.AdministrativelyRevokeCertificatesRequest{Serial: proto.String("01"), Code: &code, AdminName: &admin, IncidentID: &incidentID},
+		&rapb.AdministrativelyRevokeCertificatesRequest{Serial: proto.String("02")},
+		&rapb.AdministrativelyRevokeCertificatesRequest{Serial: proto.String("03")},
+	))
+	test.AssertNotError(t, err, "AdministrativelyRevokeCertificates failed")
+	test.AssertEquals(t, resp.GetRevoked(), int64(3))
+	test.AssertDeepEquals(t, ra.serials, []string{"01", "02", "03"})
+
+	// The first request must carry the reason code, admin name and incident
+	// ID, and every request a serial.
+	ra.serials = nil
+	_, err = rac.AdministrativelyRevokeCertificates(ctx, stream(
+		&rapb.AdministrativelyRevokeCertificatesRequest{Serial: proto.String("01"), Code: &code, AdminName: &admin},
+	))
+	test.AssertError(t, err, "AdministrativelyRevokeCertificates accepted first request without incident ID")
+	test.AssertEquals(t, len(ra.serials), 0)
+	_, err = rac.AdministrativelyRevokeCertificates(ctx, stream(
+		&rapb.AdministrativelyRevokeCertificatesRequest{Serial: proto.String("01"), Code: &code, AdminName: &admin, IncidentID: &incidentID},
+		&rapb.AdministrativelyRevokeCertificatesRequest{},
+	))
+	test.AssertError(t, err, "AdministrativelyRevokeCertificates accepted request without serial")
+	test.AssertDeepEquals(t, ra.serials, []string{"01"})
+}
@@ -8,6 +8,7 @@ package grpc
 
 import (
 	"context"
+	"io"
 	"net"
 	"time"
 
@@ -63,7 +64,8 @@ func (sac StorageAuthorityClientWrapper) GetCertificate(ctx context.Context, ser
 	if err != nil {
 		return core.Certificate{}, err
 	}
-	if response == nil || response.RegistrationID == nil || response.Serial == nil || response.Digest == nil || response.Der == nil || response.Issued == nil || response.Expires == nil {
+	// The DER may be empty if the janitor has pruned it.
+	if response == nil || response.RegistrationID == nil || response.Serial == nil || response.Digest == nil || response.Issued == nil || response.Expires == nil {
 		return core.Certificate{}, errIncompleteResponse
 	}
 	return PBToCert(response)
@@ -92,15 +94,9 @@ func (sac StorageAuthorityClientWrapper) GetCertificateStatus(ctx context.Contex
 }
 
 func (sac StorageAuthorityClientWrapper) CountCertificatesByNames(ctx context.Context, domains []string, earliest, latest time.Time) ([]*sapb.CountByNames_MapElement, error) {
-	earliestNano := earliest.UnixNano()
-	latestNano := latest.UnixNano()
-
 	response, err := sac.inner.CountCertificatesByNames(ctx, &sapb.CountCertificatesByNamesRequest{
 		Names: domains,
-		Range: &sapb.Range{
-			Earliest: &earliestNano,
-			Latest:   &latestNano,
-		},
+		Range: RangeToPB(earliest, latest),
 	})
 	if err != nil {
 		return nil, err
@@ -114,15 +110,9 @@ func (sac StorageAuthorityClientWrapper) CountCertificatesByNames(ctx context.Co
 }
 
 func (sac StorageAuthorityClientWrapper) CountRegistrationsByIP(ctx context.Context, ip net.IP, earliest, latest time.Time) (int, error) {
-	earliestNano := earliest.UnixNano()
-	latestNano := latest.UnixNano()
-
 	response, err := sac.inner.CountRegistrationsByIP(ctx, &sapb.CountRegistrationsByIPRequest{
-		Range: &sapb.Range{
-			Earliest: &earliestNano,
-			Latest:   &latestNano,
-		},
-		Ip: ip,
+		Range: RangeToPB(earliest, latest),
+		Ip:    ip,
 	})
 	if err != nil {
 		return 0, err
@@ -136,15 +126,9 @@ func (sac StorageAuthorityClientWrapper) CountRegistrationsByIP(ctx context.Cont
 }
 
 func (sac StorageAuthorityClientWrapper) CountRegistrationsByIPRange(ctx context.Context, ip net.IP, earliest, latest time.Time) (int, error) {
-	earliestNano := earliest.UnixNano()
-	latestNano := latest.UnixNano()
-
 	response, err := sac.inner.CountRegistrationsByIPRange(ctx, &sapb.CountRegistrationsByIPRequest{
-		Range: &sapb.Range{
-			Earliest: &earliestNano,
-			Latest:   &latestNano,
-		},
-		Ip: ip,
+		Range: RangeToPB(earliest, latest),
+		Ip:    ip,
 	})
 	if err != nil {
 		return 0, err
@@ -158,15 +142,9 @@ func (sac StorageAuthorityClientWrapper) CountRegistrationsByIPRange(ctx context
 }
 
 func (sac StorageAuthorityClientWrapper) CountOrders(ctx context.Context, acctID int64, earliest, latest time.Time) (int, error) {
-	earliestNano := earliest.UnixNano()
-	latestNano := latest.UnixNano()
-
 	response, err := sac.inner.CountOrders(ctx, &sapb.CountOrdersRequest{
 		AccountID: &acctID,
-		Range: &sapb.Range{
-			Earliest: &earliestNano,
-			Latest:   &latestNano,
-		},
+		Range:     RangeToPB(earliest, latest),
 	})
 	if err != nil {
 		return 0, err
@@ -239,6 +217,24 @@ func (sac StorageAuthorityClientWrapper) AddSerial(
 	return empty, nil
 }
 
+func (sac StorageAuthorityClientWrapper) FQDNSetIssuedWithin(ctx context.Context, window time.Duration, domains []string) (bool, error) {
+	windowNanos := window.Nanoseconds()
+
+	response, err := sac.inner.FQDNSetIssuedWithin(ctx, &sapb.CountFQDNSetsRequest{
+		Window:  &windowNanos,
+		Domains: domains,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if response == nil || response.Exists == nil {
+		return false, errIncompleteResponse
+	}
+
+	return *response.Exists, nil
+}
+
 func (sac StorageAuthorityClientWrapper) FQDNSetExists(ctx context.Context, domains []string) (bool, error) {
 	response, err := sac.inner.FQDNSetExists(ctx, &sapb.FQDNSetExistsRequest{Domains: domains})
 	if err != nil {
@@ -331,6 +327,17 @@ func (sas StorageAuthorityClientWrapper) NewOrder(ctx context.Context, request *
 	return resp, nil
 }
 
+func (sas StorageAuthorityClientWrapper) NewOrderAndAuthzs(ctx context.Context, req *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	resp, err := sas.inner.NewOrderAndAuthzs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || !orderValid(resp) {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
 func (sac StorageAuthorityClientWrapper) SetOrderProcessing(ctx context.Context, order *corepb.Order) error {
 	if _, err := sac.inner.SetOrderProcessing(ctx, order); err != nil {
 		return err
@@ -375,6 +382,17 @@ func (sas StorageAuthorityClientWrapper) GetOrderForNames(
 	return resp, nil
 }
 
+func (sas StorageAuthorityClientWrapper) GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	resp, err := sas.inner.GetOrdersForAccount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
 func (sas StorageAuthorityClientWrapper) GetAuthorization2(ctx context.Context, req *sapb.AuthorizationID2) (*corepb.Authorization, error) {
 	resp, err := sas.inner.GetAuthorization2(ctx, req)
 	if err != nil {
@@ -488,6 +506,231 @@ func (sac StorageAuthorityClientWrapper) KeyBlocked(ctx context.Context, req *sa
 	return sac.inner.KeyBlocked(ctx, req)
 }
 
+func (sac StorageAuthorityClientWrapper) SetRevocationWebhook(ctx context.Context, req *sapb.RevocationWebhook) (*corepb.Empty, error) {
+	// All return checking is done at the call site
+	return sac.inner.SetRevocationWebhook(ctx, req)
+}
+
+func (sac StorageAuthorityClientWrapper) GetRevocationWebhook(ctx context.Context, req *sapb.RegistrationID) (*sapb.RevocationWebhook, error) {
+	resp, err := sac.inner.GetRevocationWebhook(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.RegistrationID == nil || resp.Url == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetSerialMetadata(ctx context.Context, req *sapb.Serial) (*sapb.SerialMetadata, error) {
+	resp, err := sac.inner.GetSerialMetadata(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Serial == nil || resp.RegistrationID == nil ||
+		(resp.Created == nil && resp.CreatedTime == nil) ||
+		(resp.Expires == nil && resp.ExpiresTime == nil) {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) ReplacementOrderExists(ctx context.Context, req *sapb.Serial) (*sapb.Exists, error) {
+	resp, err := sac.inner.ReplacementOrderExists(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Exists == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetPausedIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*sapb.PausedIdentifiers, error) {
+	resp, err := sac.inner.GetPausedIdentifiers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) CountPaused(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error) {
+	resp, err := sac.inner.CountPaused(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Count == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetSerialsByKey(ctx context.Context, req *sapb.SPKIHash) (*sapb.Serials, error) {
+	resp, err := sac.inner.GetSerialsByKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+// GetSerialsByKeyHash calls send with each serial streamed by the SA, until
+// the stream ends or send returns an error.
+func (sac StorageAuthorityClientWrapper) GetSerialsByKeyHash(ctx context.Context, req *sapb.SPKIHash, send func(serial string) error) error {
+	stream, err := sac.inner.GetSerialsByKeyHash(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		serial, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if serial.Serial == nil {
+			return errIncompleteResponse
+		}
+		err = send(*serial.Serial)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (sac StorageAuthorityClientWrapper) GetRevokedCertsByShard(ctx context.Context, req *sapb.GetRevokedCertsByShardRequest) (*sapb.RevokedCerts, error) {
+	resp, err := sac.inner.GetRevokedCertsByShard(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) IncidentsForSerial(ctx context.Context, req *sapb.Serial) (*sapb.Incidents, error) {
+	resp, err := sac.inner.IncidentsForSerial(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetIncident(ctx context.Context, req *sapb.GetIncidentRequest) (*sapb.Incident, error) {
+	resp, err := sac.inner.GetIncident(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Id == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) AddIncidentSerial(ctx context.Context, req *sapb.AddIncidentSerialRequest) (*corepb.Empty, error) {
+	// All return checking is done at the call site
+	return sac.inner.AddIncidentSerial(ctx, req)
+}
+
+// SerialsForIncident calls send with each serial streamed by the SA, until the
+// stream ends or send returns an error.
+func (sac StorageAuthorityClientWrapper) SerialsForIncident(ctx context.Context, req *sapb.SerialsForIncidentRequest, send func(*sapb.IncidentSerial) error) error {
+	stream, err := sac.inner.SerialsForIncident(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		serial, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if serial.Serial == nil {
+			return errIncompleteResponse
+		}
+		err = send(serial)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (sac StorageAuthorityClientWrapper) GetIssuanceOutcome(ctx context.Context, req *sapb.Serial) (*sapb.IssuanceOutcome, error) {
+	resp, err := sac.inner.GetIssuanceOutcome(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Serial == nil || resp.RegistrationID == nil || (resp.PrecertIssued == nil && resp.PrecertIssuedTime == nil) || resp.Outcome == nil || (resp.Updated == nil && resp.UpdatedTime == nil) {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) RecordIssuanceFailure(ctx context.Context, req *sapb.IssuanceFailure) (*corepb.Empty, error) {
+	// All return checking is done at the call site
+	return sac.inner.RecordIssuanceFailure(ctx, req)
+}
+
+func (sac StorageAuthorityClientWrapper) PauseIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*corepb.Empty, error) {
+	// All return checking is done at the call site
+	return sac.inner.PauseIdentifiers(ctx, req)
+}
+
+func (sac StorageAuthorityClientWrapper) UnpauseAccount(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error) {
+	resp, err := sac.inner.UnpauseAccount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Count == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetRateLimitOverrides(ctx context.Context, req *sapb.GetRateLimitOverridesRequest) (*sapb.RateLimitOverrides, error) {
+	resp, err := sac.inner.GetRateLimitOverrides(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	for _, o := range resp.Overrides {
+		if o == nil || o.Id == nil || o.LimitName == nil || o.Threshold == nil || (o.Expires == nil && o.ExpiresTime == nil) {
+			return nil, errIncompleteResponse
+		}
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) AddRateLimitOverride(ctx context.Context, req *sapb.RateLimitOverride) (*sapb.RateLimitOverride, error) {
+	resp, err := sac.inner.AddRateLimitOverride(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Id == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sac StorageAuthorityClientWrapper) ExpireRateLimitOverride(ctx context.Context, req *sapb.ExpireRateLimitOverrideRequest) (*corepb.Empty, error) {
+	// All return checking is done at the call site
+	return sac.inner.ExpireRateLimitOverride(ctx, req)
+}
+
 // StorageAuthorityServerWrapper is the gRPC version of a core.ServerAuthority server
 type StorageAuthorityServerWrapper struct {
 	// TODO(#3119): Don't use core.StorageAuthority
@@ -565,11 +808,15 @@ func (sas StorageAuthorityServerWrapper) GetCertificateStatus(ctx context.Contex
 }
 
 func (sas StorageAuthorityServerWrapper) CountCertificatesByNames(ctx context.Context, request *sapb.CountCertificatesByNamesRequest) (*sapb.CountByNames, error) {
-	if request == nil || request.Range == nil || request.Range.Earliest == nil || request.Range.Latest == nil || request.Names == nil {
+	if request == nil || !rangeIsSet(request.Range) || request.Names == nil {
 		return nil, errIncompleteRequest
 	}
 
-	byNames, err := sas.inner.CountCertificatesByNames(ctx, request.Names, time.Unix(0, *request.Range.Earliest), time.Unix(0, *request.Range.Latest))
+	earliest, latest, err := PBToRange(request.Range)
+	if err != nil {
+		return nil, err
+	}
+	byNames, err := sas.inner.CountCertificatesByNames(ctx, request.Names, earliest, latest)
 	if err != nil {
 		return nil, err
 	}
@@ -578,15 +825,15 @@ func (sas StorageAuthorityServerWrapper) CountCertificatesByNames(ctx context.Co
 }
 
 func (sas StorageAuthorityServerWrapper) CountRegistrationsByIP(ctx context.Context, request *sapb.CountRegistrationsByIPRequest) (*sapb.Count, error) {
-	if request == nil || request.Ip == nil || request.Range == nil || request.Range.Earliest == nil || request.Range.Latest == nil {
+	if request == nil || request.Ip == nil || !rangeIsSet(request.Range) {
 		return nil, errIncompleteRequest
 	}
 
-	count, err := sas.inner.CountRegistrationsByIP(
-		ctx,
-		net.IP(request.Ip),
-		time.Unix(0, *request.Range.Earliest),
-		time.Unix(0, *request.Range.Latest))
+	earliest, latest, err := PBToRange(request.Range)
+	if err != nil {
+		return nil, err
+	}
+	count, err := sas.inner.CountRegistrationsByIP(ctx, net.IP(request.Ip), earliest, latest)
 	if err != nil {
 		return nil, err
 	}
@@ -596,15 +843,15 @@ func (sas StorageAuthorityServerWrapper) CountRegistrationsByIP(ctx context.Cont
 }
 
 func (sas StorageAuthorityServerWrapper) CountRegistrationsByIPRange(ctx context.Context, request *sapb.CountRegistrationsByIPRequest) (*sapb.Count, error) {
-	if request == nil || request.Ip == nil || request.Range == nil || request.Range.Earliest == nil || request.Range.Latest == nil {
+	if request == nil || request.Ip == nil || !rangeIsSet(request.Range) {
 		return nil, errIncompleteRequest
 	}
 
-	count, err := sas.inner.CountRegistrationsByIPRange(
-		ctx,
-		net.IP(request.Ip),
-		time.Unix(0, *request.Range.Earliest),
-		time.Unix(0, *request.Range.Latest))
+	earliest, latest, err := PBToRange(request.Range)
+	if err != nil {
+		return nil, err
+	}
+	count, err := sas.inner.CountRegistrationsByIPRange(ctx, net.IP(request.Ip), earliest, latest)
 	if err != nil {
 		return nil, err
 	}
@@ -614,15 +861,15 @@ func (sas StorageAuthorityServerWrapper) CountRegistrationsByIPRange(ctx context
 }
 
 func (sas StorageAuthorityServerWrapper) CountOrders(ctx context.Context, request *sapb.CountOrdersRequest) (*sapb.Count, error) {
-	if request == nil || request.AccountID == nil || request.Range == nil || request.Range.Earliest == nil || request.Range.Latest == nil {
+	if request == nil || request.AccountID == nil || !rangeIsSet(request.Range) {
 		return nil, errIncompleteRequest
 	}
 
-	count, err := sas.inner.CountOrders(ctx,
-		*request.AccountID,
-		time.Unix(0, *request.Range.Earliest),
-		time.Unix(0, *request.Range.Latest),
-	)
+	earliest, latest, err := PBToRange(request.Range)
+	if err != nil {
+		return nil, err
+	}
+	count, err := sas.inner.CountOrders(ctx, *request.AccountID, earliest, latest)
 	if err != nil {
 		return nil, err
 	}
@@ -647,6 +894,19 @@ func (sas StorageAuthorityServerWrapper) CountFQDNSets(ctx context.Context, requ
 	return &sapb.Count{Count: &castedCount}, nil
 }
 
+func (sas StorageAuthorityServerWrapper) FQDNSetIssuedWithin(ctx context.Context, request *sapb.CountFQDNSetsRequest) (*sapb.Exists, error) {
+	if request == nil || request.Window == nil || request.Domains == nil {
+		return nil, errIncompleteRequest
+	}
+
+	issued, err := sas.inner.FQDNSetIssuedWithin(ctx, time.Duration(*request.Window), request.Domains)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sapb.Exists{Exists: &issued}, nil
+}
+
 func (sas StorageAuthorityServerWrapper) FQDNSetExists(ctx context.Context, request *sapb.FQDNSetExistsRequest) (*sapb.Exists, error) {
 	if request == nil || request.Domains == nil {
 		return nil, errIncompleteRequest
@@ -741,6 +1001,21 @@ func (sas StorageAuthorityServerWrapper) NewOrder(ctx context.Context, request *
 	return sas.inner.NewOrder(ctx, request)
 }
 
+func (sas StorageAuthorityServerWrapper) NewOrderAndAuthzs(ctx context.Context, req *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	if req == nil || req.NewOrder == nil {
+		return nil, errIncompleteRequest
+	}
+	// The order's authorizations may all be created alongside it, in which
+	// case its V2Authorizations are empty.
+	order := req.NewOrder
+	if order.RegistrationID == nil || order.Expires == nil || order.Names == nil ||
+		(order.V2Authorizations == nil && req.NewAuthzs == nil) {
+		return nil, errIncompleteRequest
+	}
+
+	return sas.inner.NewOrderAndAuthzs(ctx, req)
+}
+
 func (sas StorageAuthorityServerWrapper) SetOrderProcessing(ctx context.Context, order *corepb.Order) (*corepb.Empty, error) {
 	if order == nil || !orderValid(order) {
 		return nil, errIncompleteRequest
@@ -794,6 +1069,11 @@ func (sas StorageAuthorityServerWrapper) GetOrderForNames(
 	return sas.inner.GetOrderForNames(ctx, request)
 }
 
+func (sas StorageAuthorityServerWrapper) GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	// All request checking is done in the method
+	return sas.inner.GetOrdersForAccount(ctx, req)
+}
+
 func (sas StorageAuthorityServerWrapper) GetAuthorization2(ctx context.Context, request *sapb.AuthorizationID2) (*corepb.Authorization, error) {
 	if request == nil || request.Id == nil {
 		return nil, errIncompleteRequest
@@ -803,7 +1083,7 @@ func (sas StorageAuthorityServerWrapper) GetAuthorization2(ctx context.Context,
 }
 
 func (sas StorageAuthorityServerWrapper) RevokeCertificate(ctx context.Context, req *sapb.RevokeCertificateRequest) (*corepb.Empty, error) {
-	if req == nil || req.Serial == nil || req.Reason == nil || req.Date == nil || req.Response == nil {
+	if req == nil || req.Serial == nil || req.Reason == nil || (req.Date == nil && req.DateTime == nil) || req.Response == nil {
 		return nil, errIncompleteRequest
 	}
 	return &corepb.Empty{}, sas.inner.RevokeCertificate(ctx, req)
@@ -818,7 +1098,7 @@ func (sas StorageAuthorityServerWrapper) NewAuthorizations2(ctx context.Context,
 }
 
 func (sas StorageAuthorityServerWrapper) GetAuthorizations2(ctx context.Context, req *sapb.GetAuthorizationsRequest) (*sapb.Authorizations, error) {
-	if req == nil || req.Domains == nil || req.RequireV2Authzs == nil || req.RegistrationID == nil || req.Now == nil {
+	if req == nil || req.Domains == nil || req.RequireV2Authzs == nil || req.RegistrationID == nil || (req.Now == nil && req.NowTime == nil) {
 		return nil, errIncompleteRequest
 	}
 
@@ -826,7 +1106,7 @@ func (sas StorageAuthorityServerWrapper) GetAuthorizations2(ctx context.Context,
 }
 
 func (sas StorageAuthorityServerWrapper) FinalizeAuthorization2(ctx context.Context, req *sapb.FinalizeAuthorizationRequest) (*corepb.Empty, error) {
-	if req == nil || req.Status == nil || req.Attempted == nil || req.Expires == nil || req.Id == nil {
+	if req == nil || req.Status == nil || req.Attempted == nil || (req.Expires == nil && req.ExpiresTime == nil) || req.Id == nil {
 		return nil, errIncompleteRequest
 	}
 
@@ -834,7 +1114,7 @@ func (sas StorageAuthorityServerWrapper) FinalizeAuthorization2(ctx context.Cont
 }
 
 func (sas StorageAuthorityServerWrapper) GetPendingAuthorization2(ctx context.Context, req *sapb.GetPendingAuthorizationRequest) (*corepb.Authorization, error) {
-	if req == nil || req.RegistrationID == nil || req.IdentifierValue == nil || req.ValidUntil == nil {
+	if req == nil || req.RegistrationID == nil || req.IdentifierValue == nil || (req.ValidUntil == nil && req.ValidUntilTime == nil) {
 		return nil, errIncompleteRequest
 	}
 
@@ -858,7 +1138,7 @@ func (sas StorageAuthorityServerWrapper) GetValidOrderAuthorizations2(ctx contex
 }
 
 func (sas StorageAuthorityServerWrapper) CountInvalidAuthorizations2(ctx context.Context, req *sapb.CountInvalidAuthorizationsRequest) (*sapb.Count, error) {
-	if req == nil || req.RegistrationID == nil || req.Hostname == nil || req.Range == nil || req.Range.Earliest == nil || req.Range.Latest == nil {
+	if req == nil || req.RegistrationID == nil || req.Hostname == nil || !rangeIsSet(req.Range) {
 		return nil, errIncompleteRequest
 	}
 
@@ -866,7 +1146,7 @@ func (sas StorageAuthorityServerWrapper) CountInvalidAuthorizations2(ctx context
 }
 
 func (sas StorageAuthorityServerWrapper) GetValidAuthorizations2(ctx context.Context, req *sapb.GetValidAuthorizationsRequest) (*sapb.Authorizations, error) {
-	if req == nil || req.Domains == nil || req.RegistrationID == nil || req.Now == nil {
+	if req == nil || req.Domains == nil || req.RegistrationID == nil || (req.Now == nil && req.NowTime == nil) {
 		return nil, errIncompleteRequest
 	}
 
@@ -890,3 +1170,127 @@ func (sas StorageAuthorityServerWrapper) KeyBlocked(ctx context.Context, req *sa
 	// All request checking is done in the method
 	return sas.inner.KeyBlocked(ctx, req)
 }
+
+func (sas StorageAuthorityServerWrapper) SetRevocationWebhook(ctx context.Context, req *sapb.RevocationWebhook) (*corepb.Empty, error) {
+	// All request checking is done in the method
+	return sas.inner.SetRevocationWebhook(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetRevocationWebhook(ctx context.Context, req *sapb.RegistrationID) (*sapb.RevocationWebhook, error) {
+	// All request checking is done in the method
+	return sas.inner.GetRevocationWebhook(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetSerialMetadata(ctx context.Context, req *sapb.Serial) (*sapb.SerialMetadata, error) {
+	// All request checking is done in the method
+	return sas.inner.GetSerialMetadata(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) ReplacementOrderExists(ctx context.Context, req *sapb.Serial) (*sapb.Exists, error) {
+	// All request checking is done in the method
+	return sas.inner.ReplacementOrderExists(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetPausedIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*sapb.PausedIdentifiers, error) {
+	// All request checking is done in the method
+	return sas.inner.GetPausedIdentifiers(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) CountPaused(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error) {
+	// All request checking is done in the method
+	return sas.inner.CountPaused(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetSerialsByKey(ctx context.Context, req *sapb.SPKIHash) (*sapb.Serials, error) {
+	// All request checking is done in the method
+	return sas.inner.GetSerialsByKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetSerialsByKeyHash(req *sapb.SPKIHash, stream sapb.StorageAuthority_GetSerialsByKeyHashServer) error {
+	if req == nil || req.KeyHash == nil {
+		return errIncompleteRequest
+	}
+	return sas.inner.GetSerialsByKeyHash(stream.Context(), req, func(serial string) error {
+		return stream.Send(&sapb.Serial{Serial: &serial})
+	})
+}
+
+func (sas StorageAuthorityServerWrapper) GetRevokedCertsByShard(ctx context.Context, req *sapb.GetRevokedCertsByShardRequest) (*sapb.RevokedCerts, error) {
+	if req == nil || req.IssuerID == nil || req.ShardIdx == nil ||
+		(req.ExpiresAfter == nil && req.ExpiresAfterTime == nil) ||
+		(req.RevokedBefore == nil && req.RevokedBeforeTime == nil) {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetRevokedCertsByShard(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) IncidentsForSerial(ctx context.Context, req *sapb.Serial) (*sapb.Incidents, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.IncidentsForSerial(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetIncident(ctx context.Context, req *sapb.GetIncidentRequest) (*sapb.Incident, error) {
+	if req == nil || req.Id == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetIncident(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddIncidentSerial(ctx context.Context, req *sapb.AddIncidentSerialRequest) (*corepb.Empty, error) {
+	// All request checking is done in the method
+	return sas.inner.AddIncidentSerial(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) SerialsForIncident(req *sapb.SerialsForIncidentRequest, stream sapb.StorageAuthority_SerialsForIncidentServer) error {
+	if req == nil || req.IncidentID == nil {
+		return errIncompleteRequest
+	}
+	return sas.inner.SerialsForIncident(stream.Context(), req, stream.Send)
+}
+
+func (sas StorageAuthorityServerWrapper) GetIssuanceOutcome(ctx context.Context, req *sapb.Serial) (*sapb.IssuanceOutcome, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetIssuanceOutcome(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) RecordIssuanceFailure(ctx context.Context, req *sapb.IssuanceFailure) (*corepb.Empty, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.RecordIssuanceFailure(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) PauseIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*corepb.Empty, error) {
+	// All request checking is done in the method
+	return sas.inner.PauseIdentifiers(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) UnpauseAccount(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error) {
+	// All request checking is done in the method
+	return sas.inner.UnpauseAccount(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetRateLimitOverrides(ctx context.Context, req *sapb.GetRateLimitOverridesRequest) (*sapb.RateLimitOverrides, error) {
+	if req == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetRateLimitOverrides(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddRateLimitOverride(ctx context.Context, req *sapb.RateLimitOverride) (*sapb.RateLimitOverride, error) {
+	if req == nil || req.LimitName == nil || req.Threshold == nil || req.Reason == nil || req.Requester == nil || (req.Expires == nil && req.ExpiresTime == nil) {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.AddRateLimitOverride(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) ExpireRateLimitOverride(ctx context.Context, req *sapb.ExpireRateLimitOverrideRequest) (*corepb.Empty, error) {
+	if req == nil || req.Id == nil || req.Requester == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.ExpireRateLimitOverride(ctx, req)
+}
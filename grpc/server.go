@@ -50,6 +50,7 @@ func NewServer(c *cmd.GRPCServerConfig, tlsConfig *tls.Config, metrics serverMet
 	return grpc.NewServer(
 		grpc.Creds(creds),
 		grpc.UnaryInterceptor(si.intercept),
+		grpc.StreamInterceptor(si.interceptStream),
 	), l, nil
 }
 
@@ -2,6 +2,7 @@ package log
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"log/syslog"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/requestid"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -181,6 +183,28 @@ func TestAuditObject(t *testing.T) {
 	}
 }
 
+func TestWithRequestID(t *testing.T) {
+	t.Parallel()
+	log := NewMock()
+
+	// Without a request ID, the logger is returned as is.
+	test.AssertEquals(t, WithRequestID(log, ""), Logger(log))
+	test.AssertEquals(t, ForContext(context.Background(), log), Logger(log))
+
+	ctx := requestid.WithID(context.Background(), "abcd")
+	reqLog := ForContext(ctx, log)
+	reqLog.AuditInfof("Signing %s", "things")
+	reqLog.AuditErr("Signing failed")
+	reqLog.AuditObject("Validation result", map[string]string{"a": "b"})
+	reqLog.Info("Not audited")
+	test.AssertDeepEquals(t, log.GetAll(), []string{
+		"INFO: [AUDIT] Signing things requestID=[abcd]",
+		"ERR: [AUDIT] Signing failed requestID=[abcd]",
+		`INFO: [AUDIT] Validation result requestID=[abcd] JSON={"a":"b"}`,
+		"INFO: Not audited",
+	})
+}
+
 func TestTransmission(t *testing.T) {
 	t.Parallel()
 
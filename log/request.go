@@ -0,0 +1,62 @@
+package log
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/letsencrypt/boulder/requestid"
+)
+
+// requestLogger is a Logger which adds a request ID to every audit log line.
+type requestLogger struct {
+	Logger
+	requestID string
+}
+
+// WithRequestID returns a Logger which adds requestID to every audit log line
+// written through it, so that the line can be correlated with the ACME
+// request which caused it. If requestID is empty, logger is returned as is.
+func WithRequestID(logger Logger, requestID string) Logger {
+	if requestID == "" {
+		return logger
+	}
+	return requestLogger{Logger: logger, requestID: requestID}
+}
+
+// ForContext is like WithRequestID, using the request ID carried by ctx.
+func ForContext(ctx context.Context, logger Logger) Logger {
+	return WithRequestID(logger, requestid.FromContext(ctx))
+}
+
+func (l requestLogger) tag(msg string) string {
+	return fmt.Sprintf("%s requestID=[%s]", msg, l.requestID)
+}
+
+func (l requestLogger) Err(msg string) {
+	l.Logger.Err(l.tag(msg))
+}
+
+func (l requestLogger) Errf(format string, a ...interface{}) {
+	l.Err(fmt.Sprintf(format, a...))
+}
+
+func (l requestLogger) AuditInfo(msg string) {
+	l.Logger.AuditInfo(l.tag(msg))
+}
+
+func (l requestLogger) AuditInfof(format string, a ...interface{}) {
+	l.AuditInfo(fmt.Sprintf(format, a...))
+}
+
+// AuditObject adds the request ID to msg, ahead of the JSON serialized obj.
+func (l requestLogger) AuditObject(msg string, obj interface{}) {
+	l.Logger.AuditObject(l.tag(msg), obj)
+}
+
+func (l requestLogger) AuditErr(msg string) {
+	l.Logger.AuditErr(l.tag(msg))
+}
+
+func (l requestLogger) AuditErrf(format string, a ...interface{}) {
+	l.AuditErr(fmt.Sprintf(format, a...))
+}
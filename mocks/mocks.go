@@ -377,6 +377,11 @@ func (sa *StorageAuthority) CountFQDNSets(_ context.Context, since time.Duration
 	return 0, nil
 }
 
+// FQDNSetIssuedWithin is a mock
+func (sa *StorageAuthority) FQDNSetIssuedWithin(_ context.Context, _ time.Duration, names []string) (bool, error) {
+	return false, nil
+}
+
 // FQDNSetExists is a mock
 func (sa *StorageAuthority) FQDNSetExists(_ context.Context, names []string) (bool, error) {
 	return false, nil
@@ -469,6 +474,11 @@ func (sa *StorageAuthority) NewOrder(_ context.Context, order *corepb.Order) (*c
 	return order, nil
 }
 
+// NewOrderAndAuthzs is a mock
+func (sa *StorageAuthority) NewOrderAndAuthzs(_ context.Context, req *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	return req.NewOrder, nil
+}
+
 // SetOrderProcessing is a mock
 func (sa *StorageAuthority) SetOrderProcessing(_ context.Context, order *corepb.Order) error {
 	return nil
@@ -552,6 +562,19 @@ func (sa *StorageAuthority) GetOrderForNames(_ context.Context, _ *sapb.GetOrder
 	return nil, nil
 }
 
+// GetOrdersForAccount is a mock. Each account has orders with IDs 1 through 10.
+func (sa *StorageAuthority) GetOrdersForAccount(_ context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	next := int64(10)
+	if req.GetBeforeID() > 0 && req.GetBeforeID() <= next {
+		next = req.GetBeforeID() - 1
+	}
+	ids := []int64{}
+	for ; next > 0 && int64(len(ids)) < req.GetLimit(); next-- {
+		ids = append(ids, next)
+	}
+	return &sapb.OrderIDs{Ids: ids}, nil
+}
+
 func (sa *StorageAuthority) GetValidOrderAuthorizations(_ context.Context, _ *sapb.GetValidOrderAuthorizationsRequest) (map[string]*core.Authorization, error) {
 	return nil, nil
 }
@@ -709,6 +732,124 @@ func (sa *StorageAuthority) KeyBlocked(ctx context.Context, req *sapb.KeyBlocked
 	return &sapb.Exists{Exists: &exists}, nil
 }
 
+// SetRevocationWebhook is a mock
+func (sa *StorageAuthority) SetRevocationWebhook(context.Context, *sapb.RevocationWebhook) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+// GetRevocationWebhook is a mock
+func (sa *StorageAuthority) GetRevocationWebhook(_ context.Context, req *sapb.RegistrationID) (*sapb.RevocationWebhook, error) {
+	return nil, berrors.NotFoundError("no revocation webhook for registration ID %d", req.GetId())
+}
+
+// GetSerialMetadata is a mock
+func (sa *StorageAuthority) GetSerialMetadata(_ context.Context, req *sapb.Serial) (*sapb.SerialMetadata, error) {
+	switch req.GetSerial() {
+	case "0000000000000000000000000000000000ee", "0000000000000000000000000000000000b2", "0000000000000000000000000000000000b3":
+		regID := int64(1)
+		created := sa.clk.Now().Add(-1 * time.Hour)
+		createdNS := created.UnixNano()
+		expiresNS := created.Add(90 * 24 * time.Hour).UnixNano()
+		return &sapb.SerialMetadata{
+			Serial:         req.Serial,
+			RegistrationID: &regID,
+			Created:        &createdNS,
+			Expires:        &expiresNS,
+		}, nil
+	}
+	return nil, berrors.NotFoundError("serial %q not found", req.GetSerial())
+}
+
+// ReplacementOrderExists is a mock
+func (sa *StorageAuthority) ReplacementOrderExists(_ context.Context, _ *sapb.Serial) (*sapb.Exists, error) {
+	exists := false
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// GetPausedIdentifiers is a mock. No identifiers are paused.
+func (sa *StorageAuthority) GetPausedIdentifiers(context.Context, *sapb.PauseRequest) (*sapb.PausedIdentifiers, error) {
+	return &sapb.PausedIdentifiers{}, nil
+}
+
+// GetSerialsByKey is a mock. No certificates share the key.
+func (sa *StorageAuthority) GetSerialsByKey(context.Context, *sapb.SPKIHash) (*sapb.Serials, error) {
+	return &sapb.Serials{}, nil
+}
+
+// GetSerialsByKeyHash is a mock. No certificates share the key.
+func (sa *StorageAuthority) GetSerialsByKeyHash(context.Context, *sapb.SPKIHash, func(string) error) error {
+	return nil
+}
+
+// GetRevokedCertsByShard is a mock. No certificates have been revoked.
+func (sa *StorageAuthority) GetRevokedCertsByShard(context.Context, *sapb.GetRevokedCertsByShardRequest) (*sapb.RevokedCerts, error) {
+	return &sapb.RevokedCerts{}, nil
+}
+
+// IncidentsForSerial is a mock. No serials are affected by incidents.
+func (sa *StorageAuthority) IncidentsForSerial(context.Context, *sapb.Serial) (*sapb.Incidents, error) {
+	return &sapb.Incidents{}, nil
+}
+
+// SerialsForIncident is a mock. Incidents affect no serials.
+func (sa *StorageAuthority) SerialsForIncident(context.Context, *sapb.SerialsForIncidentRequest, func(*sapb.IncidentSerial) error) error {
+	return nil
+}
+
+// GetIncident is a mock. There are no incidents.
+func (sa *StorageAuthority) GetIncident(_ context.Context, req *sapb.GetIncidentRequest) (*sapb.Incident, error) {
+	return nil, berrors.NotFoundError("no incident with ID %d", req.GetId())
+}
+
+// AddIncidentSerial is a mock
+func (sa *StorageAuthority) AddIncidentSerial(context.Context, *sapb.AddIncidentSerialRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+// GetIssuanceOutcome is a mock. No issuance outcomes are recorded.
+func (sa *StorageAuthority) GetIssuanceOutcome(_ context.Context, req *sapb.Serial) (*sapb.IssuanceOutcome, error) {
+	return nil, berrors.NotFoundError("no issuance outcome for serial %q", req.GetSerial())
+}
+
+// RecordIssuanceFailure is a mock
+func (sa *StorageAuthority) RecordIssuanceFailure(context.Context, *sapb.IssuanceFailure) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+// PauseIdentifiers is a mock
+func (sa *StorageAuthority) PauseIdentifiers(context.Context, *sapb.PauseRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+// CountPaused is a mock. No identifiers are paused.
+func (sa *StorageAuthority) CountPaused(context.Context, *sapb.RegistrationID) (*sapb.Count, error) {
+	var count int64
+	return &sapb.Count{Count: &count}, nil
+}
+
+// UnpauseAccount is a mock
+func (sa *StorageAuthority) UnpauseAccount(context.Context, *sapb.RegistrationID) (*sapb.Count, error) {
+	var count int64
+	return &sapb.Count{Count: &count}, nil
+}
+
+// GetRateLimitOverrides is a mock. No rate limits are overridden.
+func (sa *StorageAuthority) GetRateLimitOverrides(context.Context, *sapb.GetRateLimitOverridesRequest) (*sapb.RateLimitOverrides, error) {
+	return &sapb.RateLimitOverrides{}, nil
+}
+
+// AddRateLimitOverride is a mock
+func (sa *StorageAuthority) AddRateLimitOverride(_ context.Context, req *sapb.RateLimitOverride) (*sapb.RateLimitOverride, error) {
+	id := int64(1)
+	req.Id = &id
+	return req, nil
+}
+
+// ExpireRateLimitOverride is a mock
+func (sa *StorageAuthority) ExpireRateLimitOverride(context.Context, *sapb.ExpireRateLimitOverrideRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
 // Publisher is a mock
 type Publisher struct {
 	// empty
@@ -18,7 +18,9 @@ import (
 	"context"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -33,6 +35,22 @@ import (
 const (
 	defaultMaxUsed = 65536
 	nonceLen       = 32
+
+	// AnyPrefix is the key, in the prefix maps passed to RemoteRedeem, of the
+	// nonce service to which nonces with an unrecognized prefix are sent.
+	AnyPrefix = "any"
+)
+
+// The routes by which RemoteRedeemRoute sends a nonce for redemption.
+const (
+	// RoutePrefix means the nonce was sent to the service for its prefix.
+	RoutePrefix = "prefix"
+	// RouteAny means the nonce's prefix was unrecognized, so it was sent to
+	// the AnyPrefix service, which may not be the instance which issued it.
+	RouteAny = "any"
+	// RouteNone means there was no service to send the nonce to, so it was
+	// rejected.
+	RouteNone = "none"
 )
 
 var errInvalidNonceLength = errors.New("invalid nonce length")
@@ -70,21 +88,29 @@ func (h *int64Heap) Pop() interface{} {
 	return x
 }
 
+// validatePrefix checks a nonce prefix. If a prefix is provided it must be
+// four characters and valid base64. The prefix is required to be base64url as
+// RFC8555 section 6.5.1 requires that nonces use that encoding. As base64
+// operates on three byte binary segments we require the prefix to be three
+// bytes (four characters) so that the bytes preceding the prefix wouldn't
+// impact the encoding.
+func validatePrefix(prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+	if len(prefix) != 4 {
+		return errors.New("nonce prefix must be 4 characters")
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(prefix); err != nil {
+		return errors.New("nonce prefix must be valid base64url")
+	}
+	return nil
+}
+
 // NewNonceService constructs a NonceService with defaults
 func NewNonceService(stats prometheus.Registerer, maxUsed int, prefix string) (*NonceService, error) {
-	// If a prefix is provided it must be four characters and valid
-	// base64. The prefix is required to be base64url as RFC8555
-	// section 6.5.1 requires that nonces use that encoding.
-	// As base64 operates on three byte binary segments we require
-	// the prefix to be three bytes (four characters) so that the
-	// bytes preceding the prefix wouldn't impact the encoding.
-	if prefix != "" {
-		if len(prefix) != 4 {
-			return nil, errors.New("nonce prefix must be 4 characters")
-		}
-		if _, err := base64.RawURLEncoding.DecodeString(prefix); err != nil {
-			return nil, errors.New("nonce prefix must be valid base64url")
-		}
+	if err := validatePrefix(prefix); err != nil {
+		return nil, err
 	}
 
 	key := make([]byte, 16)
@@ -246,6 +272,17 @@ func (ns *NonceService) Valid(nonce string) bool {
 	return true
 }
 
+// DerivePrefix returns the nonce prefix of the nonce service listening on
+// address: the base64url encoding of the first three bytes of an HMAC-SHA256
+// of address, keyed with key. Nonce services and the WFEs which route
+// redemptions to them derive the same prefixes from a shared key, so that the
+// prefix of each instance doesn't have to be configured by hand.
+func DerivePrefix(address, key string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	_, _ = h.Write([]byte(address))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:3])
+}
+
 func splitNonce(nonce string) (string, string, error) {
 	if len(nonce) < 4 {
 		return "", "", errInvalidNonceLength
@@ -256,17 +293,31 @@ func splitNonce(nonce string) (string, string, error) {
 // RemoteRedeem checks the nonce prefix and routes the Redeem RPC
 // to the associated remote nonce service
 func RemoteRedeem(ctx context.Context, noncePrefixMap map[string]noncepb.NonceServiceClient, nonce string) (bool, error) {
+	valid, _, err := RemoteRedeemRoute(ctx, noncePrefixMap, nonce)
+	return valid, err
+}
+
+// RemoteRedeemRoute is like RemoteRedeem, but also returns the route by which
+// the nonce was sent for redemption: RoutePrefix, RouteAny or RouteNone. Nonces
+// whose prefix isn't in noncePrefixMap are sent to its AnyPrefix entry, if it
+// has one.
+func RemoteRedeemRoute(ctx context.Context, noncePrefixMap map[string]noncepb.NonceServiceClient, nonce string) (bool, string, error) {
 	prefix, _, err := splitNonce(nonce)
 	if err != nil {
-		return false, nil
+		return false, RouteNone, nil
 	}
+	route := RoutePrefix
 	nonceService, present := noncePrefixMap[prefix]
 	if !present {
-		return false, nil
+		route = RouteAny
+		nonceService, present = noncePrefixMap[AnyPrefix]
+		if !present {
+			return false, RouteNone, nil
+		}
 	}
 	resp, err := nonceService.Redeem(ctx, &noncepb.NonceMessage{Nonce: nonce})
 	if err != nil {
-		return false, err
+		return false, route, err
 	}
-	return resp.Valid, nil
+	return resp.Valid, route, nil
 }
@@ -202,6 +202,56 @@ func TestRemoteRedeem(t *testing.T) {
 	test.Assert(t, valid, "RemoteRedeem didn't honor remote result")
 }
 
+func TestRemoteRedeemRoute(t *testing.T) {
+	var redeemedBy string
+	client := func(name string) noncepb.NonceServiceClient {
+		return &malleableNonceClient{
+			redeem: func(ctx context.Context, in *noncepb.NonceMessage, opts ...grpc.CallOption) (*noncepb.ValidMessage, error) {
+				redeemedBy = name
+				return &noncepb.ValidMessage{Valid: true}, nil
+			},
+		}
+	}
+	prefixMap := map[string]noncepb.NonceServiceClient{
+		"wxyz": client("wxyz"),
+	}
+
+	// Without an "any" service, nonces with unknown prefixes are rejected.
+	valid, route, err := RemoteRedeemRoute(context.Background(), prefixMap, "abcddead")
+	test.AssertNotError(t, err, "RemoteRedeemRoute failed")
+	test.Assert(t, !valid, "RemoteRedeemRoute accepted nonce not in prefix map")
+	test.AssertEquals(t, route, RouteNone)
+
+	prefixMap[AnyPrefix] = client(AnyPrefix)
+	valid, route, err = RemoteRedeemRoute(context.Background(), prefixMap, "wxyzdead")
+	test.AssertNotError(t, err, "RemoteRedeemRoute failed")
+	test.Assert(t, valid, "RemoteRedeemRoute didn't honor remote result")
+	test.AssertEquals(t, route, RoutePrefix)
+	test.AssertEquals(t, redeemedBy, "wxyz")
+
+	valid, route, err = RemoteRedeemRoute(context.Background(), prefixMap, "abcddead")
+	test.AssertNotError(t, err, "RemoteRedeemRoute failed")
+	test.Assert(t, valid, "RemoteRedeemRoute didn't honor remote result")
+	test.AssertEquals(t, route, RouteAny)
+	test.AssertEquals(t, redeemedBy, AnyPrefix)
+
+	_, route, err = RemoteRedeemRoute(context.Background(), prefixMap, "q")
+	test.AssertNotError(t, err, "RemoteRedeemRoute failed")
+	test.AssertEquals(t, route, RouteNone)
+}
+
+func TestDerivePrefix(t *testing.T) {
+	prefix := DerivePrefix("nonce1.boulder:9101", "secret")
+	test.AssertEquals(t, len(prefix), 4)
+	test.AssertEquals(t, DerivePrefix("nonce1.boulder:9101", "secret"), prefix)
+	test.AssertNotEquals(t, DerivePrefix("nonce2.boulder:9101", "secret"), prefix)
+	test.AssertNotEquals(t, DerivePrefix("nonce1.boulder:9101", "other secret"), prefix)
+
+	// Derived prefixes are valid nonce service prefixes.
+	_, err := NewNonceService(metrics.NoopRegisterer, 0, prefix)
+	test.AssertNotError(t, err, "NewNonceService rejected derived prefix")
+}
+
 func TestNoncePrefixValidation(t *testing.T) {
 	_, err := NewNonceService(metrics.NoopRegisterer, 0, "hey")
 	test.AssertError(t, err, "NewNonceService didn't fail with short prefix")
@@ -0,0 +1,118 @@
+package nonce
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultNonceLifetime is how long nonces stored in Redis may be redeemed for,
+// if no lifetime is configured.
+const defaultNonceLifetime = time.Hour
+
+// RedisNonceService generates nonces and stores them in a ring of Redis
+// servers, from which they're deleted when redeemed. Unlike a NonceService,
+// whose nonces can only be redeemed by the instance which generated them and
+// are lost when it restarts, any RedisNonceService sharing the same servers can
+// redeem them, so the WFEs needn't route redemptions by nonce prefix.
+type RedisNonceService struct {
+	client       *redis.Ring
+	prefix       string
+	lifetime     time.Duration
+	nonceCreates prometheus.Counter
+	nonceRedeems *prometheus.CounterVec
+}
+
+// NewRedisNonceService constructs a RedisNonceService which stores nonces in
+// client's servers for lifetime, or an hour if lifetime is zero. The prefix,
+// if any, is only used to tell which nonce service generated a nonce.
+func NewRedisNonceService(client *redis.Ring, stats prometheus.Registerer, prefix string, lifetime time.Duration) (*RedisNonceService, error) {
+	if err := validatePrefix(prefix); err != nil {
+		return nil, err
+	}
+	if lifetime <= 0 {
+		lifetime = defaultNonceLifetime
+	}
+
+	nonceCreates := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "nonce_creates",
+		Help: "A counter of nonces generated",
+	})
+	stats.MustRegister(nonceCreates)
+	nonceRedeems := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "nonce_redeems",
+		Help: "A counter of nonce validations labelled by result",
+	}, []string{"result", "error"})
+	stats.MustRegister(nonceRedeems)
+
+	return &RedisNonceService{
+		client:       client,
+		prefix:       prefix,
+		lifetime:     lifetime,
+		nonceCreates: nonceCreates,
+		nonceRedeems: nonceRedeems,
+	}, nil
+}
+
+// redisKey is the Redis key under which nonce is stored.
+func redisKey(nonce string) string {
+	return "nonce:" + nonce
+}
+
+// Nonce generates a new random nonce, and stores it until it expires.
+func (ns *RedisNonceService) Nonce(ctx context.Context) (string, error) {
+	b := make([]byte, nonceLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	nonce := ns.prefix + base64.RawURLEncoding.EncodeToString(b)
+	stored, err := ns.client.WithContext(ctx).SetNX(redisKey(nonce), "", ns.lifetime).Result()
+	if err != nil {
+		return "", err
+	}
+	if !stored {
+		// A random 32 byte nonce should never collide with another.
+		return "", errors.New("generated a nonce which already exists")
+	}
+	ns.nonceCreates.Inc()
+	return nonce, nil
+}
+
+// Valid determines whether nonce was generated by a RedisNonceService sharing
+// ns's servers, and is neither expired nor already redeemed, returning true if
+// so. Deleting the nonce redeems it atomically, so a nonce redeemed
+// concurrently by several instances is only valid for one of them. An error is
+// returned only if the servers couldn't be reached.
+func (ns *RedisNonceService) Valid(ctx context.Context, nonce string) (bool, error) {
+	body := nonce
+	if ns.prefix != "" {
+		var err error
+		_, body, err = splitNonce(nonce)
+		if err != nil {
+			ns.nonceRedeems.WithLabelValues("invalid", "malformed").Inc()
+			return false, nil
+		}
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(decoded) != nonceLen {
+		ns.nonceRedeems.WithLabelValues("invalid", "malformed").Inc()
+		return false, nil
+	}
+
+	deleted, err := ns.client.WithContext(ctx).Del(redisKey(nonce)).Result()
+	if err != nil {
+		ns.nonceRedeems.WithLabelValues("invalid", "backend").Inc()
+		return false, err
+	}
+	if deleted == 0 {
+		ns.nonceRedeems.WithLabelValues("invalid", "unknown").Inc()
+		return false, nil
+	}
+	ns.nonceRedeems.WithLabelValues("valid", "").Inc()
+	return true, nil
+}
@@ -0,0 +1,69 @@
+package nonce
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+func TestRedisNonceService(t *testing.T) {
+	ctx := context.Background()
+	ring := redis.NewRing(&redis.RingOptions{
+		Addrs: map[string]string{"shard1": vars.RedisAddr},
+	})
+	defer ring.Close()
+
+	_, err := NewRedisNonceService(ring, metrics.NoopRegisterer, "zinc!", 0)
+	test.AssertError(t, err, "invalid prefix accepted")
+
+	ns, err := NewRedisNonceService(ring, metrics.NoopRegisterer, "zinc", 0)
+	test.AssertNotError(t, err, "Could not create nonce service")
+	n, err := ns.Nonce(ctx)
+	test.AssertNotError(t, err, "Could not create nonce")
+	test.AssertEquals(t, n[:4], "zinc")
+
+	// Another instance sharing the servers can redeem the nonce, but only
+	// once.
+	other, err := NewRedisNonceService(ring, metrics.NoopRegisterer, "taro", 0)
+	test.AssertNotError(t, err, "Could not create nonce service")
+	valid, err := other.Valid(ctx, n)
+	test.AssertNotError(t, err, "Valid failed")
+	test.Assert(t, valid, "nonce from another instance was rejected")
+	valid, err = ns.Valid(ctx, n)
+	test.AssertNotError(t, err, "Valid failed")
+	test.Assert(t, !valid, "nonce was redeemed twice")
+
+	// Nonces which were never issued, or are malformed, are rejected.
+	for _, bad := range []string{"", "zinc", "zinc!!!!", n[:4] + n[5:], "taro" + n[4:]} {
+		valid, err = ns.Valid(ctx, bad)
+		test.AssertNotError(t, err, "Valid failed")
+		test.Assert(t, !valid, "invalid nonce was accepted")
+	}
+
+	// A nonce redeemed concurrently is only valid once.
+	n, err = ns.Nonce(ctx)
+	test.AssertNotError(t, err, "Could not create nonce")
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	redeemed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			valid, err := ns.Valid(ctx, n)
+			if err == nil && valid {
+				mu.Lock()
+				redeemed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	test.AssertEquals(t, redeemed, 1)
+}
@@ -14,8 +14,10 @@ import (
 	"golang.org/x/net/idna"
 	"golang.org/x/text/unicode/norm"
 
+	"github.com/letsencrypt/boulder/bdns"
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/iana"
 	"github.com/letsencrypt/boulder/identifier"
 	blog "github.com/letsencrypt/boulder/log"
@@ -198,8 +200,30 @@ var (
 	errMalformedWildcard    = berrors.MalformedError("Domain name contains an invalid wildcard. A wildcard is only permitted before the first dot in a domain name")
 	errICANNTLDWildcard     = berrors.MalformedError("Domain name is a wildcard for an ICANN TLD")
 	errWildcardNotSupported = berrors.MalformedError("Wildcard domain names are not supported")
+	errMalformedIP          = berrors.MalformedError("IP address is malformed")
+	errNonCanonicalIP       = berrors.MalformedError("IP address is not in canonical form")
+	errReservedIP           = berrors.RejectedIdentifierError("The ACME server refuses to issue a certificate for an IP address in a reserved range")
+	errOnionName            = berrors.MalformedError("The ACME server can not issue a certificate for a .onion name")
+	errMalformedOnion       = berrors.MalformedError("Domain name is not a valid version 3 .onion name")
 )
 
+// ValidIP checks that an IP identifier value is an IPv4 or IPv6 address in
+// canonical form (as produced by net.IP's String method) which isn't in a
+// private or reserved address range.
+func ValidIP(value string) error {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return errMalformedIP
+	}
+	if ip.String() != value {
+		return errNonCanonicalIP
+	}
+	if bdns.IsReservedIP(ip) {
+		return errReservedIP
+	}
+	return nil
+}
+
 // ValidDomain checks that a domain isn't:
 //
 // * empty
@@ -349,9 +373,21 @@ func ValidEmail(address string) error {
 //  * MUST NOT be a label-wise suffix match for a name on the block list,
 //    where comparison is case-independent (normalized to lower case)
 //
+// When the IPIdentifiers feature is enabled, IP type identifiers are also
+// accepted. They:
+//
+//  * MUST be an IPv4 or IPv6 address in canonical textual form
+//  * MUST NOT be in a private or reserved address range
+//
 // If WillingToIssue returns an error, it will be of type MalformedRequestError
 // or RejectedIdentifierError
 func (pa *AuthorityImpl) WillingToIssue(id identifier.ACMEIdentifier) error {
+	if id.Type == identifier.IP {
+		if !features.Enabled(features.IPIdentifiers) {
+			return errIPAddress
+		}
+		return ValidIP(id.Value)
+	}
 	if id.Type != identifier.DNS {
 		return errInvalidIdentifier
 	}
@@ -361,6 +397,17 @@ func (pa *AuthorityImpl) WillingToIssue(id identifier.ACMEIdentifier) error {
 		return err
 	}
 
+	// .onion names can only be validated with onion-csr-01, which proves
+	// control of the hidden service key encoded in a version 3 address.
+	if core.IsOnionName(domain) {
+		if !features.Enabled(features.OnionIdentifiers) {
+			return errOnionName
+		}
+		if _, err := core.OnionPublicKey(domain); err != nil {
+			return errMalformedOnion
+		}
+	}
+
 	// Require no match against hostname block lists
 	if err := pa.checkHostLists(domain); err != nil {
 		return err
@@ -377,7 +424,8 @@ func (pa *AuthorityImpl) WillingToIssue(id identifier.ACMEIdentifier) error {
 // returned. In addition to the regular WillingToIssue checks this function
 // also checks each wildcard identifier to enforce that:
 //
-// * The identifier is a DNS type identifier
+// * The identifier is a DNS type identifier (or, with the IPIdentifiers
+//   feature enabled, an IP type identifier, which can't be a wildcard)
 // * There is at most one `*` wildcard character
 // * That the wildcard character is the leftmost label
 // * That the wildcard label is not immediately adjacent to a top level ICANN
@@ -434,7 +482,12 @@ func (pa *AuthorityImpl) WillingToIssueWildcards(idents []identifier.ACMEIdentif
 // willingToIssueWildcard vets a single identifier. It is used by
 // the plural WillingToIssueWildcards when evaluating a list of identifiers.
 func (pa *AuthorityImpl) willingToIssueWildcard(ident identifier.ACMEIdentifier) error {
-	// We're only willing to process DNS identifiers
+	// IP identifiers can't contain wildcards, so WillingToIssue has the final
+	// say on them.
+	if ident.Type == identifier.IP {
+		return pa.WillingToIssue(ident)
+	}
+	// Otherwise we're only willing to process DNS identifiers
 	if ident.Type != identifier.DNS {
 		return errInvalidIdentifier
 	}
@@ -535,9 +588,18 @@ func (pa *AuthorityImpl) ChallengesFor(identifier identifier.ACMEIdentifier) ([]
 
 	token := core.NewToken()
 
-	// If the identifier is for a DNS wildcard name we only
-	// provide a DNS-01 challenge as a matter of CA policy.
-	if strings.HasPrefix(identifier.Value, "*.") {
+	// .onion names, including wildcards, aren't in the DNS and can't be
+	// reached without Tor, so only onion-csr-01 is offered for them.
+	if core.IsOnionName(identifier.Value) {
+		if !pa.ChallengeTypeEnabled(core.ChallengeTypeOnionCSR01) {
+			return nil, fmt.Errorf(
+				"Challenges requested for .onion identifier but onion-csr-01 " +
+					"challenge type is not enabled")
+		}
+		return []core.Challenge{core.OnionCSRChallenge01(token)}, nil
+	} else if strings.HasPrefix(identifier.Value, "*.") {
+		// If the identifier is for a DNS wildcard name we only
+		// provide a DNS-01 challenge as a matter of CA policy.
 		// We must have the DNS-01 challenge type enabled to create challenges for
 		// a wildcard identifier per LE policy.
 		if !pa.ChallengeTypeEnabled(core.ChallengeTypeDNS01) {
@@ -547,6 +609,17 @@ func (pa *AuthorityImpl) ChallengesFor(identifier identifier.ACMEIdentifier) ([]
 		}
 		// Only provide a DNS-01-Wildcard challenge
 		challenges = []core.Challenge{core.DNSChallenge01(token)}
+	} else if identifier.Type == "ip" {
+		// There's no DNS zone in which to provision a DNS-01 challenge for an
+		// IP address, so only HTTP-01 and TLS-ALPN-01 are offered (RFC 8738
+		// Section 7).
+		if pa.ChallengeTypeEnabled(core.ChallengeTypeHTTP01) {
+			challenges = append(challenges, core.HTTPChallenge01(token))
+		}
+
+		if pa.ChallengeTypeEnabled(core.ChallengeTypeTLSALPN01) {
+			challenges = append(challenges, core.TLSALPNChallenge01(token))
+		}
 	} else {
 		// Otherwise we collect up challenges based on what is enabled.
 		if pa.ChallengeTypeEnabled(core.ChallengeTypeHTTP01) {
@@ -1,8 +1,10 @@
 package policy
 
 import (
+	"crypto/ed25519"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/letsencrypt/boulder/core"
@@ -154,7 +156,7 @@ func TestWillingToIssue(t *testing.T) {
 	test.AssertNotError(t, err, "Couldn't load rules")
 
 	// Test for invalid identifier type
-	ident := identifier.ACMEIdentifier{Type: "ip", Value: "example.com"}
+	ident := identifier.ACMEIdentifier{Type: "email", Value: "example.com"}
 	err = pa.WillingToIssue(ident)
 	if err != errInvalidIdentifier {
 		t.Error("Identifier was not correctly forbidden: ", ident)
@@ -418,6 +420,141 @@ func TestChallengesForWildcard(t *testing.T) {
 	test.AssertEquals(t, challenges[0].Type, core.ChallengeTypeDNS01)
 }
 
+func TestWillingToIssueIP(t *testing.T) {
+	pa := paImpl(t)
+
+	// Without the IPIdentifiers feature IP identifiers are refused outright.
+	err := pa.WillingToIssue(identifier.ACMEIdentifier{Type: identifier.IP, Value: "64.112.117.1"})
+	test.AssertEquals(t, err, errIPAddress)
+
+	_ = features.Set(map[string]bool{"IPIdentifiers": true})
+	defer features.Reset()
+
+	testCases := []struct {
+		ip  string
+		err error
+	}{
+		{"64.112.117.1", nil},
+		{"2602:80a:6000::1", nil},
+		{"", errMalformedIP},
+		{"example.com", errMalformedIP},
+		{"64.112.117.256", errMalformedIP},
+		{"2602:080a:6000::1", errNonCanonicalIP},
+		{"::ffff:64.112.117.1", errNonCanonicalIP},
+		{"10.0.0.1", errReservedIP},
+		{"127.0.0.1", errReservedIP},
+		{"::1", errReservedIP},
+		{"fe80::1", errReservedIP},
+	}
+	for _, tc := range testCases {
+		err := pa.WillingToIssue(identifier.ACMEIdentifier{Type: identifier.IP, Value: tc.ip})
+		if err != tc.err {
+			t.Errorf("WillingToIssue(%q): got %v, expected %v", tc.ip, err, tc.err)
+		}
+	}
+
+	// IP identifiers pass through the wildcard checks untouched.
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{
+		{Type: identifier.IP, Value: "64.112.117.1"},
+		{Type: identifier.IP, Value: "2602:80a:6000::1"},
+	})
+	test.AssertNotError(t, err, "WillingToIssueWildcards failed for an IP identifier")
+}
+
+func TestChallengesForIP(t *testing.T) {
+	pa, err := New(map[core.AcmeChallenge]bool{
+		core.ChallengeTypeHTTP01:    true,
+		core.ChallengeTypeTLSALPN01: true,
+		core.ChallengeTypeDNS01:     true,
+	})
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+
+	challenges, err := pa.ChallengesFor(identifier.ACMEIdentifier{Type: identifier.IP, Value: "64.112.117.1"})
+	test.AssertNotError(t, err, "ChallengesFor failed")
+	test.AssertEquals(t, len(challenges), 2)
+	for _, challenge := range challenges {
+		test.Assert(t, challenge.Type != core.ChallengeTypeDNS01, "DNS-01 challenge offered for an IP identifier")
+	}
+}
+
+// testOnionName returns a well formed version 3 .onion name.
+func testOnionName() string {
+	pub := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+	return core.OnionAddress(pub)
+}
+
+func TestWillingToIssueOnion(t *testing.T) {
+	pa := paImpl(t)
+	bannedBytes, err := yaml.Marshal(blockedNamesPolicy{
+		HighRiskBlockedNames: []string{"zombo.gov.us"},
+		ExactBlockedNames:    []string{"highvalue.letsdecrypt.org"},
+	})
+	test.AssertNotError(t, err, "Couldn't serialize banned list")
+	f, _ := ioutil.TempFile("", "test-onion-banlist.*.yaml")
+	defer os.Remove(f.Name())
+	err = ioutil.WriteFile(f.Name(), bannedBytes, 0640)
+	test.AssertNotError(t, err, "Couldn't write serialized banned list to file")
+	err = pa.SetHostnamePolicyFile(f.Name())
+	test.AssertNotError(t, err, "Couldn't load policy contents from file")
+
+	onion := testOnionName()
+
+	// Without the OnionIdentifiers feature .onion names are refused outright.
+	err = pa.WillingToIssue(identifier.DNSIdentifier(onion))
+	test.AssertEquals(t, err, errOnionName)
+
+	_ = features.Set(map[string]bool{"OnionIdentifiers": true})
+	defer features.Reset()
+
+	testCases := []struct {
+		domain string
+		err    error
+	}{
+		{onion, nil},
+		{"www." + onion, nil},
+		{"expyuzz4wqqyqhjn.onion", errMalformedOnion},
+		{strings.Replace(onion, onion[:1], "x", 1), errMalformedOnion},
+	}
+	for _, tc := range testCases {
+		err := pa.WillingToIssue(identifier.DNSIdentifier(tc.domain))
+		if err != tc.err {
+			t.Errorf("WillingToIssue(%q): got %v, expected %v", tc.domain, err, tc.err)
+		}
+	}
+
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{identifier.DNSIdentifier("*." + onion)})
+	test.AssertNotError(t, err, "WillingToIssueWildcards failed for a wildcard .onion name")
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{identifier.DNSIdentifier("*.expyuzz4wqqyqhjn.onion")})
+	test.AssertError(t, err, "WillingToIssueWildcards accepted a version 2 .onion name")
+	test.AssertContains(t, err.Error(), errMalformedOnion.Error())
+}
+
+func TestChallengesForOnion(t *testing.T) {
+	onion := identifier.DNSIdentifier("*." + testOnionName())
+
+	_, err := paImpl(t).ChallengesFor(onion)
+	test.AssertError(t, err, "ChallengesFor did not error for a .onion name "+
+		"when onion-csr-01 was disabled")
+
+	pa, err := New(map[core.AcmeChallenge]bool{
+		core.ChallengeTypeHTTP01:     true,
+		core.ChallengeTypeDNS01:      true,
+		core.ChallengeTypeOnionCSR01: true,
+	})
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	challenges, err := pa.ChallengesFor(onion)
+	test.AssertNotError(t, err, "ChallengesFor failed for a .onion name")
+	test.AssertEquals(t, len(challenges), 1)
+	test.AssertEquals(t, challenges[0].Type, core.ChallengeTypeOnionCSR01)
+
+	// onion-csr-01 is never offered for other names.
+	challenges, err = pa.ChallengesFor(identifier.DNSIdentifier("example.com"))
+	test.AssertNotError(t, err, "ChallengesFor failed")
+	for _, challenge := range challenges {
+		test.Assert(t, challenge.Type != core.ChallengeTypeOnionCSR01, "onion-csr-01 challenge offered for a DNS name")
+	}
+}
+
 // TestMalformedExactBlocklist tests that loading a YAML policy file with an
 // invalid exact blocklist entry will fail as expected.
 func TestMalformedExactBlocklist(t *testing.T) {
@@ -3,30 +3,36 @@ package probs
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/letsencrypt/boulder/identifier"
 )
 
 // Error types that can be used in ACME payloads
 const (
-	ConnectionProblem            = ProblemType("connection")
-	MalformedProblem             = ProblemType("malformed")
-	ServerInternalProblem        = ProblemType("serverInternal")
-	TLSProblem                   = ProblemType("tls")
-	UnauthorizedProblem          = ProblemType("unauthorized")
-	RateLimitedProblem           = ProblemType("rateLimited")
-	BadNonceProblem              = ProblemType("badNonce")
-	InvalidEmailProblem          = ProblemType("invalidEmail")
-	RejectedIdentifierProblem    = ProblemType("rejectedIdentifier")
-	AccountDoesNotExistProblem   = ProblemType("accountDoesNotExist")
-	CAAProblem                   = ProblemType("caa")
-	DNSProblem                   = ProblemType("dns")
-	AlreadyRevokedProblem        = ProblemType("alreadyRevoked")
-	OrderNotReadyProblem         = ProblemType("orderNotReady")
-	BadSignatureAlgorithmProblem = ProblemType("badSignatureAlgorithm")
-	BadPublicKeyProblem          = ProblemType("badPublicKey")
-	BadRevocationReasonProblem   = ProblemType("badRevocationReason")
-	BadCSRProblem                = ProblemType("badCSR")
+	ConnectionProblem              = ProblemType("connection")
+	MalformedProblem               = ProblemType("malformed")
+	ServerInternalProblem          = ProblemType("serverInternal")
+	TLSProblem                     = ProblemType("tls")
+	UnauthorizedProblem            = ProblemType("unauthorized")
+	RateLimitedProblem             = ProblemType("rateLimited")
+	BadNonceProblem                = ProblemType("badNonce")
+	InvalidEmailProblem            = ProblemType("invalidEmail")
+	RejectedIdentifierProblem      = ProblemType("rejectedIdentifier")
+	AccountDoesNotExistProblem     = ProblemType("accountDoesNotExist")
+	CAAProblem                     = ProblemType("caa")
+	DNSProblem                     = ProblemType("dns")
+	DNSSECProblem                  = ProblemType("dnssec")
+	AlreadyRevokedProblem          = ProblemType("alreadyRevoked")
+	OrderNotReadyProblem           = ProblemType("orderNotReady")
+	BadSignatureAlgorithmProblem   = ProblemType("badSignatureAlgorithm")
+	BadPublicKeyProblem            = ProblemType("badPublicKey")
+	BadRevocationReasonProblem     = ProblemType("badRevocationReason")
+	BadCSRProblem                  = ProblemType("badCSR")
+	AlreadyReplacedProblem         = ProblemType("alreadyReplaced")
+	ExternalAccountRequiredProblem = ProblemType("externalAccountRequired")
+	UserActionRequiredProblem      = ProblemType("userActionRequired")
+	InvalidProfileProblem          = ProblemType("invalidProfile")
 
 	V1ErrorNS = "urn:acme:error:"
 	V2ErrorNS = "urn:ietf:params:acme:error:"
@@ -46,6 +52,25 @@ type ProblemDetails struct {
 	// SubProblems are optional additional per-identifier problems. See
 	// RFC 8555 Section 6.7.1: https://tools.ietf.org/html/rfc8555#section-6.7.1
 	SubProblems []SubProblemDetails `json:"subproblems,omitempty"`
+	// RateLimit, for RateLimitedProblems, describes the exceeded rate limit.
+	RateLimit *RateLimitDetails `json:"rateLimit,omitempty"`
+	// RetryAfter, if non-zero, is sent to the client as a Retry-After header.
+	RetryAfter time.Duration `json:"-"`
+	// Instance, for UserActionRequiredProblems, is the URL of a page telling
+	// the subscriber what action to take. See RFC 8555 Section 6.7.
+	Instance string `json:"instance,omitempty"`
+	// RequestID identifies the request which the problem was sent in response
+	// to in Boulder's logs, so that subscribers can quote it when reporting
+	// errors. It's set by web.SendError.
+	RequestID string `json:"requestID,omitempty"`
+}
+
+// RateLimitDetails describes the rate limit exceeded by a RateLimitedProblem:
+// its name, and the usage which reached its threshold.
+type RateLimitDetails struct {
+	Limit     string `json:"limit"`
+	Usage     int64  `json:"usage"`
+	Threshold int64  `json:"threshold"`
 }
 
 // SubProblemDetails represents sub-problems specific to an identifier that are
@@ -68,6 +93,10 @@ func (pd *ProblemDetails) WithSubProblems(subProbs []SubProblemDetails) *Problem
 		Detail:      pd.Detail,
 		HTTPStatus:  pd.HTTPStatus,
 		SubProblems: append(pd.SubProblems, subProbs...),
+		RateLimit:   pd.RateLimit,
+		RetryAfter:  pd.RetryAfter,
+		Instance:    pd.Instance,
+		RequestID:   pd.RequestID,
 	}
 }
 
@@ -93,7 +122,8 @@ func ProblemDetailsToStatusCode(prob *ProblemDetails) int {
 		InvalidEmailProblem,
 		RejectedIdentifierProblem,
 		AccountDoesNotExistProblem,
-		BadRevocationReasonProblem:
+		BadRevocationReasonProblem,
+		InvalidProfileProblem:
 		return http.StatusBadRequest
 	case ServerInternalProblem:
 		return http.StatusInternalServerError
@@ -307,6 +337,15 @@ func DNS(detail string) *ProblemDetails {
 	}
 }
 
+// DNSSEC returns a ProblemDetails representing a DNSSECProblem
+func DNSSEC(detail string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       DNSSECProblem,
+		Detail:     detail,
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
 // OrderNotReady returns a ProblemDetails representing a OrderNotReadyProblem
 func OrderNotReady(detail string, a ...interface{}) *ProblemDetails {
 	return &ProblemDetails{
@@ -334,3 +373,46 @@ func BadCSR(detail string, a ...interface{}) *ProblemDetails {
 		HTTPStatus: http.StatusBadRequest,
 	}
 }
+
+// AlreadyReplaced returns a ProblemDetails representing an
+// AlreadyReplacedProblem, with a 409 Conflict status code.
+func AlreadyReplaced(detail string, a ...interface{}) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       AlreadyReplacedProblem,
+		Detail:     fmt.Sprintf(detail, a...),
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ExternalAccountRequired returns a ProblemDetails representing an
+// ExternalAccountRequiredProblem, with a 403 Forbidden status code.
+func ExternalAccountRequired(detail string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       ExternalAccountRequiredProblem,
+		Detail:     detail,
+		HTTPStatus: http.StatusForbidden,
+	}
+}
+
+// UserActionRequired returns a ProblemDetails representing a
+// UserActionRequiredProblem, with a 403 Forbidden status code. Callers should
+// set its Instance to the URL of a page describing the action required.
+func UserActionRequired(detail string, a ...interface{}) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       UserActionRequiredProblem,
+		Detail:     fmt.Sprintf(detail, a...),
+		HTTPStatus: http.StatusForbidden,
+	}
+}
+
+// InvalidProfile returns a ProblemDetails representing an
+// InvalidProfileProblem, for a new order which requests a certificate profile
+// that doesn't exist or isn't available to the account, with a 400 Bad Request
+// status code.
+func InvalidProfile(detail string, a ...interface{}) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       InvalidProfileProblem,
+		Detail:     fmt.Sprintf(detail, a...),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
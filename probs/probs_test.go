@@ -36,6 +36,7 @@ func TestProblemDetailsToStatusCode(t *testing.T) {
 		{&ProblemDetails{Type: ConnectionProblem, HTTPStatus: 200}, 200},
 		{&ProblemDetails{Type: AccountDoesNotExistProblem}, http.StatusBadRequest},
 		{&ProblemDetails{Type: BadRevocationReasonProblem}, http.StatusBadRequest},
+		{&ProblemDetails{Type: InvalidProfileProblem}, http.StatusBadRequest},
 	}
 
 	for _, c := range testCases {
@@ -64,6 +65,8 @@ func TestProblemDetailsConvenience(t *testing.T) {
 		{RejectedIdentifier("rejected identifier detail"), RejectedIdentifierProblem, http.StatusBadRequest, "rejected identifier detail"},
 		{AccountDoesNotExist("no account detail"), AccountDoesNotExistProblem, http.StatusBadRequest, "no account detail"},
 		{BadRevocationReason("only reason xxx is supported"), BadRevocationReasonProblem, http.StatusBadRequest, "only reason xxx is supported"},
+		{DNSSEC("DNSSEC validation failure"), DNSSECProblem, http.StatusBadRequest, "DNSSEC validation failure"},
+		{InvalidProfile("unknown profile %q", "longlived"), InvalidProfileProblem, http.StatusBadRequest, `unknown profile "longlived"`},
 	}
 
 	for _, c := range testCases {
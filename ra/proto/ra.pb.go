@@ -444,8 +444,12 @@ type NewOrderRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RegistrationID *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
-	Names          []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
+	RegistrationID         *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Names                  []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
+	CertificateProfileName *string  `protobuf:"bytes,3,opt,name=certificateProfileName" json:"certificateProfileName,omitempty"`
+	Replaces               *string  `protobuf:"bytes,4,opt,name=replaces" json:"replaces,omitempty"`
+	NotBefore              *int64   `protobuf:"varint,5,opt,name=notBefore" json:"notBefore,omitempty"`
+	NotAfter               *int64   `protobuf:"varint,6,opt,name=notAfter" json:"notAfter,omitempty"`
 }
 
 func (x *NewOrderRequest) Reset() {
@@ -494,6 +498,34 @@ func (x *NewOrderRequest) GetNames() []string {
 	return nil
 }
 
+func (x *NewOrderRequest) GetCertificateProfileName() string {
+	if x != nil && x.CertificateProfileName != nil {
+		return *x.CertificateProfileName
+	}
+	return ""
+}
+
+func (x *NewOrderRequest) GetReplaces() string {
+	if x != nil && x.Replaces != nil {
+		return *x.Replaces
+	}
+	return ""
+}
+
+func (x *NewOrderRequest) GetNotBefore() int64 {
+	if x != nil && x.NotBefore != nil {
+		return *x.NotBefore
+	}
+	return 0
+}
+
+func (x *NewOrderRequest) GetNotAfter() int64 {
+	if x != nil && x.NotAfter != nil {
+		return *x.NotAfter
+	}
+	return 0
+}
+
 type FinalizeOrderRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -549,6 +581,298 @@ func (x *FinalizeOrderRequest) GetCsr() []byte {
 	return nil
 }
 
+type SetRevocationWebhookRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RegistrationID *int64  `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Url            *string `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
+	// If validateOnly is true the URL is only validated, not stored.
+	ValidateOnly *bool `protobuf:"varint,3,opt,name=validateOnly" json:"validateOnly,omitempty"`
+}
+
+func (x *SetRevocationWebhookRequest) Reset() {
+	*x = SetRevocationWebhookRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ra_proto_ra_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetRevocationWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRevocationWebhookRequest) ProtoMessage() {}
+
+func (x *SetRevocationWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ra_proto_ra_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRevocationWebhookRequest.ProtoReflect.Descriptor instead.
+func (*SetRevocationWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_ra_proto_ra_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SetRevocationWebhookRequest) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
+	}
+	return 0
+}
+
+func (x *SetRevocationWebhookRequest) GetUrl() string {
+	if x != nil && x.Url != nil {
+		return *x.Url
+	}
+	return ""
+}
+
+func (x *SetRevocationWebhookRequest) GetValidateOnly() bool {
+	if x != nil && x.ValidateOnly != nil {
+		return *x.ValidateOnly
+	}
+	return false
+}
+
+type UnpauseAccountRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RegistrationID *int64 `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+}
+
+func (x *UnpauseAccountRequest) Reset() {
+	*x = UnpauseAccountRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ra_proto_ra_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnpauseAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnpauseAccountRequest) ProtoMessage() {}
+
+func (x *UnpauseAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ra_proto_ra_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnpauseAccountRequest.ProtoReflect.Descriptor instead.
+func (*UnpauseAccountRequest) Descriptor() ([]byte, []int) {
+	return file_ra_proto_ra_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UnpauseAccountRequest) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
+	}
+	return 0
+}
+
+type RevokeCertByKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cert []byte `protobuf:"bytes,1,opt,name=cert" json:"cert,omitempty"`
+}
+
+func (x *RevokeCertByKeyRequest) Reset() {
+	*x = RevokeCertByKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ra_proto_ra_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokeCertByKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeCertByKeyRequest) ProtoMessage() {}
+
+func (x *RevokeCertByKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ra_proto_ra_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeCertByKeyRequest.ProtoReflect.Descriptor instead.
+func (*RevokeCertByKeyRequest) Descriptor() ([]byte, []int) {
+	return file_ra_proto_ra_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RevokeCertByKeyRequest) GetCert() []byte {
+	if x != nil {
+		return x.Cert
+	}
+	return nil
+}
+
+type AdministrativelyRevokeCertificatesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Serial     *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	Code       *int64  `protobuf:"varint,2,opt,name=code" json:"code,omitempty"`
+	AdminName  *string `protobuf:"bytes,3,opt,name=adminName" json:"adminName,omitempty"`
+	IncidentID *int64  `protobuf:"varint,4,opt,name=incidentID" json:"incidentID,omitempty"`
+}
+
+func (x *AdministrativelyRevokeCertificatesRequest) Reset() {
+	*x = AdministrativelyRevokeCertificatesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ra_proto_ra_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AdministrativelyRevokeCertificatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdministrativelyRevokeCertificatesRequest) ProtoMessage() {}
+
+func (x *AdministrativelyRevokeCertificatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ra_proto_ra_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdministrativelyRevokeCertificatesRequest.ProtoReflect.Descriptor instead.
+func (*AdministrativelyRevokeCertificatesRequest) Descriptor() ([]byte, []int) {
+	return file_ra_proto_ra_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *AdministrativelyRevokeCertificatesRequest) GetSerial() string {
+	if x != nil && x.Serial != nil {
+		return *x.Serial
+	}
+	return ""
+}
+
+func (x *AdministrativelyRevokeCertificatesRequest) GetCode() int64 {
+	if x != nil && x.Code != nil {
+		return *x.Code
+	}
+	return 0
+}
+
+func (x *AdministrativelyRevokeCertificatesRequest) GetAdminName() string {
+	if x != nil && x.AdminName != nil {
+		return *x.AdminName
+	}
+	return ""
+}
+
+func (x *AdministrativelyRevokeCertificatesRequest) GetIncidentID() int64 {
+	if x != nil && x.IncidentID != nil {
+		return *x.IncidentID
+	}
+	return 0
+}
+
+type AdministrativelyRevokeCertificatesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Revoked        *int64   `protobuf:"varint,1,opt,name=revoked" json:"revoked,omitempty"`
+	AlreadyRevoked *int64   `protobuf:"varint,2,opt,name=alreadyRevoked" json:"alreadyRevoked,omitempty"`
+	FailedSerials  []string `protobuf:"bytes,3,rep,name=failedSerials" json:"failedSerials,omitempty"`
+}
+
+func (x *AdministrativelyRevokeCertificatesResponse) Reset() {
+	*x = AdministrativelyRevokeCertificatesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ra_proto_ra_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AdministrativelyRevokeCertificatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdministrativelyRevokeCertificatesResponse) ProtoMessage() {}
+
+func (x *AdministrativelyRevokeCertificatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ra_proto_ra_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdministrativelyRevokeCertificatesResponse.ProtoReflect.Descriptor instead.
+func (*AdministrativelyRevokeCertificatesResponse) Descriptor() ([]byte, []int) {
+	return file_ra_proto_ra_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AdministrativelyRevokeCertificatesResponse) GetRevoked() int64 {
+	if x != nil && x.Revoked != nil {
+		return *x.Revoked
+	}
+	return 0
+}
+
+func (x *AdministrativelyRevokeCertificatesResponse) GetAlreadyRevoked() int64 {
+	if x != nil && x.AlreadyRevoked != nil {
+		return *x.AlreadyRevoked
+	}
+	return 0
+}
+
+func (x *AdministrativelyRevokeCertificatesResponse) GetFailedSerials() []string {
+	if x != nil {
+		return x.FailedSerials
+	}
+	return nil
+}
+
 var File_ra_proto_ra_proto protoreflect.FileDescriptor
 
 var file_ra_proto_ra_proto_rawDesc = []byte{
@@ -601,69 +925,132 @@ var file_ra_proto_ra_proto_rawDesc = []byte{
 	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x63, 0x65, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x63,
 	0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12,
 	0x1c, 0x0a, 0x09, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x4f, 0x0a,
-	0x0f, 0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xdd, 0x01,
+	0x0a, 0x0f, 0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6d,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x12,
+	0x36, 0x0a, 0x16, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72,
+	0x6f, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x16, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x61,
+	0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x61,
+	0x63, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x6e, 0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x22, 0x4b, 0x0a,
+	0x14, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x52, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x73, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x63, 0x73, 0x72, 0x22, 0x7b, 0x0a, 0x1b, 0x53, 0x65,
+	0x74, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68, 0x6f,
+	0x6f, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x44, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x75, 0x72, 0x6c, 0x12, 0x22, 0x0a, 0x0c, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x4f,
+	0x6e, 0x6c, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x76, 0x61, 0x6c, 0x69, 0x64,
+	0x61, 0x74, 0x65, 0x4f, 0x6e, 0x6c, 0x79, 0x22, 0x3f, 0x0a, 0x15, 0x55, 0x6e, 0x70, 0x61, 0x75,
+	0x73, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
 	0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
 	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65,
-	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x4b,
-	0x0a, 0x14, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64,
-	0x65, 0x72, 0x52, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x73, 0x72,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x63, 0x73, 0x72, 0x32, 0x8b, 0x06, 0x0a, 0x15,
-	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x75, 0x74, 0x68,
-	0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x3b, 0x0a, 0x0f, 0x4e, 0x65, 0x77, 0x52, 0x65, 0x67, 0x69,
-	0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
-	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x12, 0x2e, 0x63,
-	0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x22, 0x00, 0x12, 0x46, 0x0a, 0x10, 0x4e, 0x65, 0x77, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
-	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x2e, 0x72, 0x61, 0x2e, 0x4e, 0x65, 0x77, 0x41,
-	0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f,
-	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0e, 0x4e, 0x65,
-	0x77, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x72,
-	0x61, 0x2e, 0x4e, 0x65, 0x77, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x43,
-	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x12,
-	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x12, 0x1d, 0x2e, 0x72, 0x61, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x48, 0x0a, 0x11, 0x50, 0x65, 0x72, 0x66, 0x6f,
-	0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x2e, 0x72,
-	0x61, 0x2e, 0x50, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x63, 0x6f, 0x72,
-	0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22,
-	0x00, 0x12, 0x4e, 0x0a, 0x18, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69,
-	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x57, 0x69, 0x74, 0x68, 0x52, 0x65, 0x67, 0x12, 0x23, 0x2e,
-	0x72, 0x61, 0x2e, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
-	0x63, 0x61, 0x74, 0x65, 0x57, 0x69, 0x74, 0x68, 0x52, 0x65, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0x00, 0x12, 0x3b, 0x0a, 0x16, 0x44, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x52,
-	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f,
-	0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a,
-	0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3d,
-	0x0a, 0x17, 0x44, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x41, 0x75, 0x74, 0x68,
-	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65,
-	0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0b,
-	0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x60, 0x0a,
-	0x21, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76, 0x65, 0x6c,
-	0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
-	0x74, 0x65, 0x12, 0x2c, 0x2e, 0x72, 0x61, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x69, 0x73, 0x74,
-	0x72, 0x61, 0x74, 0x69, 0x76, 0x65, 0x6c, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65,
-	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12,
-	0x2e, 0x0a, 0x08, 0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x72, 0x61,
-	0x2e, 0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12,
-	0x38, 0x0a, 0x0d, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72,
-	0x12, 0x18, 0x2e, 0x72, 0x61, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x4f, 0x72,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x22, 0x2c, 0x0a, 0x16, 0x52, 0x65, 0x76, 0x6f,
+	0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x42, 0x79, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x65, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x63, 0x65, 0x72, 0x74, 0x22, 0x95, 0x01, 0x0a, 0x29, 0x41, 0x64, 0x6d, 0x69, 0x6e,
+	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76, 0x65, 0x6c, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b,
+	0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1e,
+	0x0a, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x22, 0x94,
+	0x01, 0x0a, 0x2a, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76,
+	0x65, 0x6c, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07,
+	0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x12, 0x26, 0x0a, 0x0e, 0x61, 0x6c, 0x72, 0x65, 0x61,
+	0x64, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x61, 0x6c, 0x72, 0x65, 0x61, 0x64, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x12,
+	0x24, 0x0a, 0x0d, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x53, 0x65,
+	0x72, 0x69, 0x61, 0x6c, 0x73, 0x32, 0xd7, 0x08, 0x0a, 0x15, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12,
+	0x3b, 0x0a, 0x0f, 0x4e, 0x65, 0x77, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x10,
+	0x4e, 0x65, 0x77, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x1b, 0x2e, 0x72, 0x61, 0x2e, 0x4e, 0x65, 0x77, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
+	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e,
+	0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0e, 0x4e, 0x65, 0x77, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x72, 0x61, 0x2e, 0x4e, 0x65, 0x77, 0x43,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x11, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x12, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x2e, 0x72,
+	0x61, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x63, 0x6f,
+	0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22,
+	0x00, 0x12, 0x48, 0x0a, 0x11, 0x50, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x2e, 0x72, 0x61, 0x2e, 0x50, 0x65, 0x72, 0x66,
+	0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68,
+	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x18, 0x52,
+	0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x57, 0x69, 0x74, 0x68, 0x52, 0x65, 0x67, 0x12, 0x23, 0x2e, 0x72, 0x61, 0x2e, 0x52, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x57, 0x69,
+	0x74, 0x68, 0x52, 0x65, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3b, 0x0a, 0x16, 0x44,
+	0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3d, 0x0a, 0x17, 0x44, 0x65, 0x61, 0x63,
+	0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f,
+	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x60, 0x0a, 0x21, 0x41, 0x64, 0x6d, 0x69, 0x6e,
+	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76, 0x65, 0x6c, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b,
+	0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x2c, 0x2e, 0x72,
+	0x61, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76, 0x65,
+	0x6c, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x2e, 0x0a, 0x08, 0x4e, 0x65, 0x77,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x72, 0x61, 0x2e, 0x4e, 0x65, 0x77, 0x4f, 0x72,
 	0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72,
-	0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x74, 0x73, 0x65, 0x6e, 0x63, 0x72,
-	0x79, 0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65, 0x72, 0x2f, 0x72, 0x61, 0x2f, 0x70,
-	0x72, 0x6f, 0x74, 0x6f,
+	0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x0d, 0x46, 0x69, 0x6e,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x18, 0x2e, 0x72, 0x61, 0x2e,
+	0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x14, 0x53, 0x65, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x12, 0x1f, 0x2e, 0x72, 0x61,
+	0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65,
+	0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3a, 0x0a, 0x0e, 0x55,
+	0x6e, 0x70, 0x61, 0x75, 0x73, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x19, 0x2e,
+	0x72, 0x61, 0x2e, 0x55, 0x6e, 0x70, 0x61, 0x75, 0x73, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x0f, 0x52, 0x65, 0x76, 0x6f, 0x6b,
+	0x65, 0x43, 0x65, 0x72, 0x74, 0x42, 0x79, 0x4b, 0x65, 0x79, 0x12, 0x1a, 0x2e, 0x72, 0x61, 0x2e,
+	0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x42, 0x79, 0x4b, 0x65, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x87, 0x01, 0x0a, 0x22, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x69,
+	0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76, 0x65, 0x6c, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65,
+	0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x2d, 0x2e, 0x72,
+	0x61, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76, 0x65,
+	0x6c, 0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2e, 0x2e, 0x72, 0x61,
+	0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x76, 0x65, 0x6c,
+	0x79, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x42,
+	0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65,
+	0x74, 0x73, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65,
+	0x72, 0x2f, 0x72, 0x61, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 }
 
 var (
@@ -678,56 +1065,69 @@ func file_ra_proto_ra_proto_rawDescGZIP() []byte {
 	return file_ra_proto_ra_proto_rawDescData
 }
 
-var file_ra_proto_ra_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_ra_proto_ra_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_ra_proto_ra_proto_goTypes = []interface{}{
-	(*NewAuthorizationRequest)(nil),                  // 0: ra.NewAuthorizationRequest
-	(*NewCertificateRequest)(nil),                    // 1: ra.NewCertificateRequest
-	(*UpdateRegistrationRequest)(nil),                // 2: ra.UpdateRegistrationRequest
-	(*UpdateAuthorizationRequest)(nil),               // 3: ra.UpdateAuthorizationRequest
-	(*PerformValidationRequest)(nil),                 // 4: ra.PerformValidationRequest
-	(*RevokeCertificateWithRegRequest)(nil),          // 5: ra.RevokeCertificateWithRegRequest
-	(*AdministrativelyRevokeCertificateRequest)(nil), // 6: ra.AdministrativelyRevokeCertificateRequest
-	(*NewOrderRequest)(nil),                          // 7: ra.NewOrderRequest
-	(*FinalizeOrderRequest)(nil),                     // 8: ra.FinalizeOrderRequest
-	(*proto1.Authorization)(nil),                     // 9: core.Authorization
-	(*proto1.Registration)(nil),                      // 10: core.Registration
-	(*proto1.Challenge)(nil),                         // 11: core.Challenge
-	(*proto1.Order)(nil),                             // 12: core.Order
-	(*proto1.Certificate)(nil),                       // 13: core.Certificate
-	(*proto1.Empty)(nil),                             // 14: core.Empty
+	(*NewAuthorizationRequest)(nil),                    // 0: ra.NewAuthorizationRequest
+	(*NewCertificateRequest)(nil),                      // 1: ra.NewCertificateRequest
+	(*UpdateRegistrationRequest)(nil),                  // 2: ra.UpdateRegistrationRequest
+	(*UpdateAuthorizationRequest)(nil),                 // 3: ra.UpdateAuthorizationRequest
+	(*PerformValidationRequest)(nil),                   // 4: ra.PerformValidationRequest
+	(*RevokeCertificateWithRegRequest)(nil),            // 5: ra.RevokeCertificateWithRegRequest
+	(*AdministrativelyRevokeCertificateRequest)(nil),   // 6: ra.AdministrativelyRevokeCertificateRequest
+	(*NewOrderRequest)(nil),                            // 7: ra.NewOrderRequest
+	(*FinalizeOrderRequest)(nil),                       // 8: ra.FinalizeOrderRequest
+	(*SetRevocationWebhookRequest)(nil),                // 9: ra.SetRevocationWebhookRequest
+	(*UnpauseAccountRequest)(nil),                      // 10: ra.UnpauseAccountRequest
+	(*RevokeCertByKeyRequest)(nil),                     // 11: ra.RevokeCertByKeyRequest
+	(*AdministrativelyRevokeCertificatesRequest)(nil),  // 12: ra.AdministrativelyRevokeCertificatesRequest
+	(*AdministrativelyRevokeCertificatesResponse)(nil), // 13: ra.AdministrativelyRevokeCertificatesResponse
+	(*proto1.Authorization)(nil),                       // 14: core.Authorization
+	(*proto1.Registration)(nil),                        // 15: core.Registration
+	(*proto1.Challenge)(nil),                           // 16: core.Challenge
+	(*proto1.Order)(nil),                               // 17: core.Order
+	(*proto1.Certificate)(nil),                         // 18: core.Certificate
+	(*proto1.Empty)(nil),                               // 19: core.Empty
 }
 var file_ra_proto_ra_proto_depIdxs = []int32{
-	9,  // 0: ra.NewAuthorizationRequest.authz:type_name -> core.Authorization
-	10, // 1: ra.UpdateRegistrationRequest.base:type_name -> core.Registration
-	10, // 2: ra.UpdateRegistrationRequest.update:type_name -> core.Registration
-	9,  // 3: ra.UpdateAuthorizationRequest.authz:type_name -> core.Authorization
-	11, // 4: ra.UpdateAuthorizationRequest.response:type_name -> core.Challenge
-	9,  // 5: ra.PerformValidationRequest.authz:type_name -> core.Authorization
-	12, // 6: ra.FinalizeOrderRequest.order:type_name -> core.Order
-	10, // 7: ra.RegistrationAuthority.NewRegistration:input_type -> core.Registration
+	14, // 0: ra.NewAuthorizationRequest.authz:type_name -> core.Authorization
+	15, // 1: ra.UpdateRegistrationRequest.base:type_name -> core.Registration
+	15, // 2: ra.UpdateRegistrationRequest.update:type_name -> core.Registration
+	14, // 3: ra.UpdateAuthorizationRequest.authz:type_name -> core.Authorization
+	16, // 4: ra.UpdateAuthorizationRequest.response:type_name -> core.Challenge
+	14, // 5: ra.PerformValidationRequest.authz:type_name -> core.Authorization
+	17, // 6: ra.FinalizeOrderRequest.order:type_name -> core.Order
+	15, // 7: ra.RegistrationAuthority.NewRegistration:input_type -> core.Registration
 	0,  // 8: ra.RegistrationAuthority.NewAuthorization:input_type -> ra.NewAuthorizationRequest
 	1,  // 9: ra.RegistrationAuthority.NewCertificate:input_type -> ra.NewCertificateRequest
 	2,  // 10: ra.RegistrationAuthority.UpdateRegistration:input_type -> ra.UpdateRegistrationRequest
 	4,  // 11: ra.RegistrationAuthority.PerformValidation:input_type -> ra.PerformValidationRequest
 	5,  // 12: ra.RegistrationAuthority.RevokeCertificateWithReg:input_type -> ra.RevokeCertificateWithRegRequest
-	10, // 13: ra.RegistrationAuthority.DeactivateRegistration:input_type -> core.Registration
-	9,  // 14: ra.RegistrationAuthority.DeactivateAuthorization:input_type -> core.Authorization
+	15, // 13: ra.RegistrationAuthority.DeactivateRegistration:input_type -> core.Registration
+	14, // 14: ra.RegistrationAuthority.DeactivateAuthorization:input_type -> core.Authorization
 	6,  // 15: ra.RegistrationAuthority.AdministrativelyRevokeCertificate:input_type -> ra.AdministrativelyRevokeCertificateRequest
 	7,  // 16: ra.RegistrationAuthority.NewOrder:input_type -> ra.NewOrderRequest
 	8,  // 17: ra.RegistrationAuthority.FinalizeOrder:input_type -> ra.FinalizeOrderRequest
-	10, // 18: ra.RegistrationAuthority.NewRegistration:output_type -> core.Registration
-	9,  // 19: ra.RegistrationAuthority.NewAuthorization:output_type -> core.Authorization
-	13, // 20: ra.RegistrationAuthority.NewCertificate:output_type -> core.Certificate
-	10, // 21: ra.RegistrationAuthority.UpdateRegistration:output_type -> core.Registration
-	9,  // 22: ra.RegistrationAuthority.PerformValidation:output_type -> core.Authorization
-	14, // 23: ra.RegistrationAuthority.RevokeCertificateWithReg:output_type -> core.Empty
-	14, // 24: ra.RegistrationAuthority.DeactivateRegistration:output_type -> core.Empty
-	14, // 25: ra.RegistrationAuthority.DeactivateAuthorization:output_type -> core.Empty
-	14, // 26: ra.RegistrationAuthority.AdministrativelyRevokeCertificate:output_type -> core.Empty
-	12, // 27: ra.RegistrationAuthority.NewOrder:output_type -> core.Order
-	12, // 28: ra.RegistrationAuthority.FinalizeOrder:output_type -> core.Order
-	18, // [18:29] is the sub-list for method output_type
-	7,  // [7:18] is the sub-list for method input_type
+	9,  // 18: ra.RegistrationAuthority.SetRevocationWebhook:input_type -> ra.SetRevocationWebhookRequest
+	10, // 19: ra.RegistrationAuthority.UnpauseAccount:input_type -> ra.UnpauseAccountRequest
+	11, // 20: ra.RegistrationAuthority.RevokeCertByKey:input_type -> ra.RevokeCertByKeyRequest
+	12, // 21: ra.RegistrationAuthority.AdministrativelyRevokeCertificates:input_type -> ra.AdministrativelyRevokeCertificatesRequest
+	15, // 22: ra.RegistrationAuthority.NewRegistration:output_type -> core.Registration
+	14, // 23: ra.RegistrationAuthority.NewAuthorization:output_type -> core.Authorization
+	18, // 24: ra.RegistrationAuthority.NewCertificate:output_type -> core.Certificate
+	15, // 25: ra.RegistrationAuthority.UpdateRegistration:output_type -> core.Registration
+	14, // 26: ra.RegistrationAuthority.PerformValidation:output_type -> core.Authorization
+	19, // 27: ra.RegistrationAuthority.RevokeCertificateWithReg:output_type -> core.Empty
+	19, // 28: ra.RegistrationAuthority.DeactivateRegistration:output_type -> core.Empty
+	19, // 29: ra.RegistrationAuthority.DeactivateAuthorization:output_type -> core.Empty
+	19, // 30: ra.RegistrationAuthority.AdministrativelyRevokeCertificate:output_type -> core.Empty
+	17, // 31: ra.RegistrationAuthority.NewOrder:output_type -> core.Order
+	17, // 32: ra.RegistrationAuthority.FinalizeOrder:output_type -> core.Order
+	19, // 33: ra.RegistrationAuthority.SetRevocationWebhook:output_type -> core.Empty
+	19, // 34: ra.RegistrationAuthority.UnpauseAccount:output_type -> core.Empty
+	19, // 35: ra.RegistrationAuthority.RevokeCertByKey:output_type -> core.Empty
+	13, // 36: ra.RegistrationAuthority.AdministrativelyRevokeCertificates:output_type -> ra.AdministrativelyRevokeCertificatesResponse
+	22, // [22:37] is the sub-list for method output_type
+	7,  // [7:22] is the sub-list for method input_type
 	7,  // [7:7] is the sub-list for extension type_name
 	7,  // [7:7] is the sub-list for extension extendee
 	0,  // [0:7] is the sub-list for field type_name
@@ -847,6 +1247,66 @@ func file_ra_proto_ra_proto_init() {
 				return nil
 			}
 		}
+		file_ra_proto_ra_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetRevocationWebhookRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ra_proto_ra_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UnpauseAccountRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ra_proto_ra_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokeCertByKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ra_proto_ra_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AdministrativelyRevokeCertificatesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ra_proto_ra_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AdministrativelyRevokeCertificatesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -854,7 +1314,7 @@ func file_ra_proto_ra_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_ra_proto_ra_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -891,6 +1351,14 @@ type RegistrationAuthorityClient interface {
 	AdministrativelyRevokeCertificate(ctx context.Context, in *AdministrativelyRevokeCertificateRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
 	NewOrder(ctx context.Context, in *NewOrderRequest, opts ...grpc.CallOption) (*proto1.Order, error)
 	FinalizeOrder(ctx context.Context, in *FinalizeOrderRequest, opts ...grpc.CallOption) (*proto1.Order, error)
+	SetRevocationWebhook(ctx context.Context, in *SetRevocationWebhookRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	UnpauseAccount(ctx context.Context, in *UnpauseAccountRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	RevokeCertByKey(ctx context.Context, in *RevokeCertByKeyRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	// AdministrativelyRevokeCertificates revokes the certificate with each
+	// serial sent on the stream, at a bounded rate, for mass revocations. The
+	// code, adminName and incidentID are taken from the first request. Failing
+	// to revoke one certificate doesn't end the stream.
+	AdministrativelyRevokeCertificates(ctx context.Context, opts ...grpc.CallOption) (RegistrationAuthority_AdministrativelyRevokeCertificatesClient, error)
 }
 
 type registrationAuthorityClient struct {
@@ -1000,6 +1468,67 @@ func (c *registrationAuthorityClient) FinalizeOrder(ctx context.Context, in *Fin
 	return out, nil
 }
 
+func (c *registrationAuthorityClient) SetRevocationWebhook(ctx context.Context, in *SetRevocationWebhookRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/ra.RegistrationAuthority/SetRevocationWebhook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registrationAuthorityClient) UnpauseAccount(ctx context.Context, in *UnpauseAccountRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/ra.RegistrationAuthority/UnpauseAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registrationAuthorityClient) RevokeCertByKey(ctx context.Context, in *RevokeCertByKeyRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/ra.RegistrationAuthority/RevokeCertByKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registrationAuthorityClient) AdministrativelyRevokeCertificates(ctx context.Context, opts ...grpc.CallOption) (RegistrationAuthority_AdministrativelyRevokeCertificatesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_RegistrationAuthority_serviceDesc.Streams[0], "/ra.RegistrationAuthority/AdministrativelyRevokeCertificates", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registrationAuthorityAdministrativelyRevokeCertificatesClient{stream}
+	return x, nil
+}
+
+type RegistrationAuthority_AdministrativelyRevokeCertificatesClient interface {
+	Send(*AdministrativelyRevokeCertificatesRequest) error
+	CloseAndRecv() (*AdministrativelyRevokeCertificatesResponse, error)
+	grpc.ClientStream
+}
+
+type registrationAuthorityAdministrativelyRevokeCertificatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *registrationAuthorityAdministrativelyRevokeCertificatesClient) Send(m *AdministrativelyRevokeCertificatesRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *registrationAuthorityAdministrativelyRevokeCertificatesClient) CloseAndRecv() (*AdministrativelyRevokeCertificatesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AdministrativelyRevokeCertificatesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // RegistrationAuthorityServer is the server API for RegistrationAuthority service.
 type RegistrationAuthorityServer interface {
 	NewRegistration(context.Context, *proto1.Registration) (*proto1.Registration, error)
@@ -1013,6 +1542,14 @@ type RegistrationAuthorityServer interface {
 	AdministrativelyRevokeCertificate(context.Context, *AdministrativelyRevokeCertificateRequest) (*proto1.Empty, error)
 	NewOrder(context.Context, *NewOrderRequest) (*proto1.Order, error)
 	FinalizeOrder(context.Context, *FinalizeOrderRequest) (*proto1.Order, error)
+	SetRevocationWebhook(context.Context, *SetRevocationWebhookRequest) (*proto1.Empty, error)
+	UnpauseAccount(context.Context, *UnpauseAccountRequest) (*proto1.Empty, error)
+	RevokeCertByKey(context.Context, *RevokeCertByKeyRequest) (*proto1.Empty, error)
+	// AdministrativelyRevokeCertificates revokes the certificate with each
+	// serial sent on the stream, at a bounded rate, for mass revocations. The
+	// code, adminName and incidentID are taken from the first request. Failing
+	// to revoke one certificate doesn't end the stream.
+	AdministrativelyRevokeCertificates(RegistrationAuthority_AdministrativelyRevokeCertificatesServer) error
 }
 
 // UnimplementedRegistrationAuthorityServer can be embedded to have forward compatible implementations.
@@ -1052,6 +1589,18 @@ func (*UnimplementedRegistrationAuthorityServer) NewOrder(context.Context, *NewO
 func (*UnimplementedRegistrationAuthorityServer) FinalizeOrder(context.Context, *FinalizeOrderRequest) (*proto1.Order, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FinalizeOrder not implemented")
 }
+func (*UnimplementedRegistrationAuthorityServer) SetRevocationWebhook(context.Context, *SetRevocationWebhookRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRevocationWebhook not implemented")
+}
+func (*UnimplementedRegistrationAuthorityServer) UnpauseAccount(context.Context, *UnpauseAccountRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnpauseAccount not implemented")
+}
+func (*UnimplementedRegistrationAuthorityServer) RevokeCertByKey(context.Context, *RevokeCertByKeyRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeCertByKey not implemented")
+}
+func (*UnimplementedRegistrationAuthorityServer) AdministrativelyRevokeCertificates(RegistrationAuthority_AdministrativelyRevokeCertificatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method AdministrativelyRevokeCertificates not implemented")
+}
 
 func RegisterRegistrationAuthorityServer(s *grpc.Server, srv RegistrationAuthorityServer) {
 	s.RegisterService(&_RegistrationAuthority_serviceDesc, srv)
@@ -1255,6 +1804,86 @@ func _RegistrationAuthority_FinalizeOrder_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RegistrationAuthority_SetRevocationWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRevocationWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistrationAuthorityServer).SetRevocationWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ra.RegistrationAuthority/SetRevocationWebhook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistrationAuthorityServer).SetRevocationWebhook(ctx, req.(*SetRevocationWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistrationAuthority_UnpauseAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnpauseAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistrationAuthorityServer).UnpauseAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ra.RegistrationAuthority/UnpauseAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistrationAuthorityServer).UnpauseAccount(ctx, req.(*UnpauseAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistrationAuthority_RevokeCertByKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeCertByKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistrationAuthorityServer).RevokeCertByKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ra.RegistrationAuthority/RevokeCertByKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistrationAuthorityServer).RevokeCertByKey(ctx, req.(*RevokeCertByKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistrationAuthority_AdministrativelyRevokeCertificates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RegistrationAuthorityServer).AdministrativelyRevokeCertificates(&registrationAuthorityAdministrativelyRevokeCertificatesServer{stream})
+}
+
+type RegistrationAuthority_AdministrativelyRevokeCertificatesServer interface {
+	SendAndClose(*AdministrativelyRevokeCertificatesResponse) error
+	Recv() (*AdministrativelyRevokeCertificatesRequest, error)
+	grpc.ServerStream
+}
+
+type registrationAuthorityAdministrativelyRevokeCertificatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *registrationAuthorityAdministrativelyRevokeCertificatesServer) SendAndClose(m *AdministrativelyRevokeCertificatesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *registrationAuthorityAdministrativelyRevokeCertificatesServer) Recv() (*AdministrativelyRevokeCertificatesRequest, error) {
+	m := new(AdministrativelyRevokeCertificatesRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _RegistrationAuthority_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ra.RegistrationAuthority",
 	HandlerType: (*RegistrationAuthorityServer)(nil),
@@ -1303,7 +1932,25 @@ var _RegistrationAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FinalizeOrder",
 			Handler:    _RegistrationAuthority_FinalizeOrder_Handler,
 		},
+		{
+			MethodName: "SetRevocationWebhook",
+			Handler:    _RegistrationAuthority_SetRevocationWebhook_Handler,
+		},
+		{
+			MethodName: "UnpauseAccount",
+			Handler:    _RegistrationAuthority_UnpauseAccount_Handler,
+		},
+		{
+			MethodName: "RevokeCertByKey",
+			Handler:    _RegistrationAuthority_RevokeCertByKey_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AdministrativelyRevokeCertificates",
+			Handler:       _RegistrationAuthority_AdministrativelyRevokeCertificates_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "ra/proto/ra.proto",
 }
@@ -1,21 +1,30 @@
 package ra
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"net/url"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/jmhodges/clock"
+	abusepb "github.com/letsencrypt/boulder/abuse/proto"
 	"github.com/letsencrypt/boulder/akamai"
 	akamaipb "github.com/letsencrypt/boulder/akamai/proto"
+	"github.com/letsencrypt/boulder/bdns"
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
@@ -32,7 +41,9 @@ import (
 	"github.com/letsencrypt/boulder/probs"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
 	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/ratelimits"
 	"github.com/letsencrypt/boulder/reloader"
+	"github.com/letsencrypt/boulder/requestid"
 	"github.com/letsencrypt/boulder/revocation"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	vapb "github.com/letsencrypt/boulder/va/proto"
@@ -43,12 +54,43 @@ import (
 	grpc "google.golang.org/grpc"
 )
 
+// defaultFinalizeTimeout bounds the background issuance of orders finalized
+// while the AsyncFinalize feature is enabled, if SetFinalizeTimeout isn't
+// called.
+const defaultFinalizeTimeout = 5 * time.Minute
+
+// failOrderTimeout bounds persisting an order's failure. It's given its own
+// deadline so that an order whose issuance ran out of time is still failed,
+// rather than left processing.
+const failOrderTimeout = 15 * time.Second
+
+// detachedContext carries the values of the context it wraps, such as the
+// request ID used in log lines, but not its deadline or cancellation. It's used
+// for work which carries on in the background after the RPC which started it
+// has returned.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// defaultAdminRevocationsPerSecond bounds the rate of mass revocations by
+// AdministrativelyRevokeCertificates, if SetAdminRevocationRate isn't called.
+const defaultAdminRevocationsPerSecond = 10
+
 type caaChecker interface {
 	IsCAAValid(
 		ctx context.Context,
 		in *vapb.IsCAAValidRequest,
 		opts ...grpc.CallOption,
 	) (*vapb.IsCAAValidResponse, error)
+	IsCAAValidForNames(
+		ctx context.Context,
+		in *vapb.IsCAAValidForNamesRequest,
+		opts ...grpc.CallOption,
+	) (*vapb.IsCAAValidForNamesResponse, error)
 }
 
 // RegistrationAuthorityImpl defines an RA.
@@ -79,15 +121,82 @@ type RegistrationAuthorityImpl struct {
 	purger akamaipb.AkamaiPurgerClient
 
 	ctpolicy *ctpolicy.CTPolicy
-
-	ctpolicyResults         *prometheus.HistogramVec
-	rateLimitCounter        *prometheus.CounterVec
-	revocationReasonCounter *prometheus.CounterVec
-	namesPerCert            *prometheus.HistogramVec
-	newRegCounter           prometheus.Counter
-	reusedValidAuthzCounter prometheus.Counter
-	recheckCAACounter       prometheus.Counter
-	newCertCounter          prometheus.Counter
+	// finalSigningReserve is how much of an issuance request's deadline is
+	// kept back for signing the final certificate. Collecting SCTs is abandoned
+	// once less than this much time remains.
+	finalSigningReserve time.Duration
+
+	// antiAbuse, if non-nil, is consulted before each new order is created.
+	antiAbuse              abusepb.AntiAbuseClient
+	antiAbuseFailClosed    bool
+	antiAbuseFailureWindow time.Duration
+
+	// revocationWebhookClient, if non-nil, is used to notify subscribers'
+	// revocation webhooks when their certificates are administratively
+	// revoked. Notifications are sent in the background, each bounded by
+	// revocationWebhookTimeout, and revocationNotifications tracks those
+	// still in progress.
+	revocationWebhookClient        *http.Client
+	revocationWebhookTimeout       time.Duration
+	revocationWebhookReplaceWithin time.Duration
+	revocationNotifications        sync.WaitGroup
+
+	// autoPauseThreshold, if non-zero, is how many failed authorizations for
+	// an identifier within autoPauseWindow cause the identifier to be paused
+	// for the account which requested them. If autoPauseDuration is non-zero
+	// the pause lapses after that long.
+	autoPauseThreshold int64
+	autoPauseWindow    time.Duration
+	autoPauseDuration  time.Duration
+	// autoPauseAllowedAccounts and autoPauseAllowedNames are exempt from
+	// automatic pausing, as are subdomains of autoPauseAllowedNames. They're
+	// set by SetAutoPauseAllowlist.
+	autoPauseAllowedAccounts map[int64]bool
+	autoPauseAllowedNames    map[string]bool
+
+	// finalizeTimeout bounds the background issuance of orders finalized
+	// while the AsyncFinalize feature is enabled, and finalizations tracks
+	// those still in progress.
+	finalizeTimeout time.Duration
+	finalizations   sync.WaitGroup
+
+	// maxRequestedValidity is the longest validity period new orders may
+	// request for certificates of each certificate profile, keyed by profile
+	// name, while the OrderValidityWindows feature is enabled. Longer requests
+	// are shortened if clampRequestedValidity is true, and refused otherwise.
+	maxRequestedValidity   map[string]time.Duration
+	clampRequestedValidity bool
+
+	// csrPolicies are the checks CSRs for each certificate profile must pass,
+	// keyed by profile name. Those of profiles it doesn't list are checked by
+	// csrlib.VerifyCSR.
+	csrPolicies map[string]*csrlib.Policy
+
+	// adminRevocationsPerSecond bounds the rate at which
+	// AdministrativelyRevokeCertificates revokes certificates, so that a mass
+	// revocation doesn't overwhelm the CA, SA and OCSP cache purger.
+	adminRevocationsPerSecond int
+
+	// limiter, if non-nil, is a key-value rate limiter for the
+	// certificatesPerName and certificatesPerFQDNSet limits, used while the
+	// KeyValueRateLimits or KeyValueRateLimitsCutover feature is enabled. It's
+	// set by SetKeyValueRateLimiter.
+	limiter *ratelimits.Limiter
+
+	ctpolicyResults          *prometheus.HistogramVec
+	rateLimitCounter         *prometheus.CounterVec
+	revocationReasonCounter  *prometheus.CounterVec
+	namesPerCert             *prometheus.HistogramVec
+	newRegCounter            prometheus.Counter
+	reusedValidAuthzCounter  prometheus.Counter
+	recheckCAACounter        prometheus.Counter
+	newCertCounter           prometheus.Counter
+	antiAbuseCounter         *prometheus.CounterVec
+	revocationWebhookCounter *prometheus.CounterVec
+	pausedIdentifiersCounter prometheus.Counter
+	autoPauseAllowedCounter  prometheus.Counter
+	rateLimitOverridesGauge  prometheus.Gauge
+	rateLimitComparisons     *prometheus.CounterVec
 }
 
 // NewRegistrationAuthorityImpl constructs a new RA object.
@@ -111,7 +220,7 @@ func NewRegistrationAuthorityImpl(
 	ctpolicyResults := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "ctpolicy_results",
-			Help:    "Histogram of latencies of ctpolicy.GetSCTs calls with success/failure/deadlineExceeded labels",
+			Help:    "Histogram of latencies of ctpolicy.GetSCTs calls with success/failure/deadlineExceeded/signingDeadline labels",
 			Buckets: metrics.InternetFacingBuckets,
 		},
 		[]string{"result"},
@@ -167,6 +276,42 @@ func NewRegistrationAuthorityImpl(
 	}, []string{"reason"})
 	stats.MustRegister(revocationReasonCounter)
 
+	antiAbuseCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ra_anti_abuse_decisions",
+		Help: "A counter of anti-abuse hook decisions for new orders labelled by decision",
+	}, []string{"decision"})
+	stats.MustRegister(antiAbuseCounter)
+
+	revocationWebhookCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ra_revocation_webhooks",
+		Help: "A counter of revocation webhook notifications labelled by result",
+	}, []string{"result"})
+	stats.MustRegister(revocationWebhookCounter)
+
+	pausedIdentifiersCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ra_paused_identifiers",
+		Help: "A counter of identifiers paused for an account after repeatedly failing validation",
+	})
+	stats.MustRegister(pausedIdentifiersCounter)
+
+	autoPauseAllowedCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ra_auto_pause_allowlisted",
+		Help: "A counter of failed validations not considered for automatic pausing because their account or identifier is allowlisted",
+	})
+	stats.MustRegister(autoPauseAllowedCounter)
+
+	rateLimitOverridesGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ra_rate_limit_overrides",
+		Help: "The number of rate limit overrides loaded from the SA and applied on top of the policy file's",
+	})
+	stats.MustRegister(rateLimitOverridesGauge)
+
+	rateLimitComparisons := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ra_key_value_rate_limit_comparisons",
+		Help: "A counter of comparisons between the database's and the key-value rate limiter's decisions labelled by limit and result",
+	}, []string{"limit", "result"})
+	stats.MustRegister(rateLimitComparisons)
+
 	ra := &RegistrationAuthorityImpl{
 		clk:                          clk,
 		log:                          logger,
@@ -191,10 +336,183 @@ func NewRegistrationAuthorityImpl(
 		recheckCAACounter:            recheckCAACounter,
 		newCertCounter:               newCertCounter,
 		revocationReasonCounter:      revocationReasonCounter,
+		antiAbuseCounter:             antiAbuseCounter,
+		revocationWebhookCounter:     revocationWebhookCounter,
+		pausedIdentifiersCounter:     pausedIdentifiersCounter,
+		autoPauseAllowedCounter:      autoPauseAllowedCounter,
+		rateLimitOverridesGauge:      rateLimitOverridesGauge,
+		rateLimitComparisons:         rateLimitComparisons,
 	}
 	return ra
 }
 
+// SetAntiAbuseHook configures an external anti-abuse service which is
+// consulted before each new order is created. If failClosed is true, new
+// orders are refused when the service cannot be reached or does not respond
+// in time; otherwise they are allowed. failureWindow controls how far back
+// failed authorizations are counted when describing the order to the service.
+func (ra *RegistrationAuthorityImpl) SetAntiAbuseHook(client abusepb.AntiAbuseClient, failClosed bool, failureWindow time.Duration) {
+	ra.antiAbuse = client
+	ra.antiAbuseFailClosed = failClosed
+	ra.antiAbuseFailureWindow = failureWindow
+}
+
+// SetFinalSigningReserve sets how much of an issuance request's deadline is
+// kept back for signing the final certificate. If SCTs can't be collected
+// before then, issuance is abandoned without asking the CA to sign a final
+// certificate, rather than risking a final certificate being signed after the
+// request has already timed out.
+func (ra *RegistrationAuthorityImpl) SetFinalSigningReserve(reserve time.Duration) {
+	ra.finalSigningReserve = reserve
+}
+
+// SetFinalizeTimeout sets how long the background issuance of an order
+// finalized while the AsyncFinalize feature is enabled may take before it's
+// abandoned and the order is failed. If it's never set, defaultFinalizeTimeout
+// is used.
+func (ra *RegistrationAuthorityImpl) SetFinalizeTimeout(timeout time.Duration) {
+	ra.finalizeTimeout = timeout
+}
+
+// WaitForFinalizations blocks until the background issuance of every order
+// finalized while the AsyncFinalize feature is enabled has completed, so that
+// shutting down the RA doesn't leave orders stuck processing.
+func (ra *RegistrationAuthorityImpl) WaitForFinalizations() {
+	ra.finalizations.Wait()
+}
+
+// SetRequestedValidity configures the longest validity period which new orders
+// may request for certificates of each certificate profile, keyed by profile
+// name with "" naming the CA's default profile. Orders for profiles it doesn't
+// list can't request a notBefore or notAfter. If clamp is true, requested
+// validity periods which are too long are shortened rather than refused.
+func (ra *RegistrationAuthorityImpl) SetRequestedValidity(maxValidity map[string]time.Duration, clamp bool) {
+	ra.maxRequestedValidity = maxValidity
+	ra.clampRequestedValidity = clamp
+}
+
+// SetAdminRevocationRate sets the most certificates per second which
+// AdministrativelyRevokeCertificates revokes. If it's never set,
+// defaultAdminRevocationsPerSecond is used.
+func (ra *RegistrationAuthorityImpl) SetAdminRevocationRate(perSecond int) {
+	ra.adminRevocationsPerSecond = perSecond
+}
+
+// SetCSRPolicies configures additional checks which CSRs for each certificate
+// profile must pass, keyed by profile name with "" naming the CA's default
+// profile. It must be called after the RA's PA is set, which the policies use.
+func (ra *RegistrationAuthorityImpl) SetCSRPolicies(configs map[string]csrlib.PolicyConfig) error {
+	policies := make(map[string]*csrlib.Policy, len(configs))
+	for profile, config := range configs {
+		policy, err := csrlib.NewPolicy(config, ra.maxNames, &ra.keyPolicy, ra.PA)
+		if err != nil {
+			return fmt.Errorf("CSR policy for profile %q: %s", profile, err)
+		}
+		policies[profile] = policy
+	}
+	ra.csrPolicies = policies
+	return nil
+}
+
+// verifyCSR checks csr against the CSR policy of the given certificate
+// profile, normalizing it as csrlib.VerifyCSR does.
+func (ra *RegistrationAuthorityImpl) verifyCSR(ctx context.Context, csr *x509.CertificateRequest, profile string, regID int64) error {
+	policy, ok := ra.csrPolicies[profile]
+	if !ok {
+		return csrlib.VerifyCSR(ctx, csr, ra.maxNames, &ra.keyPolicy, ra.PA, regID)
+	}
+	return policy.Verify(ctx, csr)
+}
+
+// SetKeyValueRateLimiter configures a key-value rate limiter for the
+// certificatesPerName and certificatesPerFQDNSet limits. It's written to and
+// compared with the database's counts while the KeyValueRateLimits feature is
+// enabled, and enforced in their place while KeyValueRateLimitsCutover is.
+func (ra *RegistrationAuthorityImpl) SetKeyValueRateLimiter(limiter *ratelimits.Limiter) {
+	ra.limiter = limiter
+}
+
+// SetRevocationWebhooks enables notifying subscribers who have configured a
+// revocation webhook when one of their certificates is administratively
+// revoked. Notifications are sent in the background, and timeout bounds each
+// one, including looking up the subscriber's webhook. replaceWithin is how
+// long after the revocation subscribers are told they must have replaced the
+// certificate by.
+func (ra *RegistrationAuthorityImpl) SetRevocationWebhooks(timeout, replaceWithin time.Duration) {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// Webhook URLs are provided by subscribers, so never connect to
+		// addresses in private or reserved ranges.
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || bdns.IsReservedIP(ip) {
+				return fmt.Errorf("refusing to connect to reserved address %s", host)
+			}
+			return nil
+		},
+	}
+	ra.revocationWebhookClient = &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	ra.revocationWebhookTimeout = timeout
+	ra.revocationWebhookReplaceWithin = replaceWithin
+}
+
+// WaitForRevocationNotifications blocks until every revocation webhook
+// notification being sent in the background has completed.
+func (ra *RegistrationAuthorityImpl) WaitForRevocationNotifications() {
+	ra.revocationNotifications.Wait()
+}
+
+// SetAutoPause enables pausing an identifier for an account once threshold of
+// the account's authorizations for it have failed validation within window.
+// Paused identifiers are refused in new orders until the account is unpaused
+// or, if duration is non-zero, until duration has passed. It has no effect
+// unless the PausedIdentifiers feature is enabled.
+func (ra *RegistrationAuthorityImpl) SetAutoPause(threshold int64, window, duration time.Duration) {
+	ra.autoPauseThreshold = threshold
+	ra.autoPauseWindow = window
+	ra.autoPauseDuration = duration
+}
+
+// SetAutoPauseAllowlist exempts the given accounts from automatic pausing, for
+// any identifier, along with the given names and their subdomains, for any
+// account. Identifiers can still be paused for them by other means.
+func (ra *RegistrationAuthorityImpl) SetAutoPauseAllowlist(regIDs []int64, names []string) {
+	ra.autoPauseAllowedAccounts = make(map[int64]bool, len(regIDs))
+	for _, regID := range regIDs {
+		ra.autoPauseAllowedAccounts[regID] = true
+	}
+	ra.autoPauseAllowedNames = make(map[string]bool, len(names))
+	for _, name := range names {
+		ra.autoPauseAllowedNames[strings.ToLower(name)] = true
+	}
+}
+
+// autoPauseAllowed returns whether the allowlist exempts name from being
+// automatically paused for the account regID: either the account is listed, or
+// name or one of its parent domains is.
+func (ra *RegistrationAuthorityImpl) autoPauseAllowed(regID int64, name string) bool {
+	if ra.autoPauseAllowedAccounts[regID] {
+		return true
+	}
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := range labels {
+		if ra.autoPauseAllowedNames[strings.Join(labels[i:], ".")] {
+			return true
+		}
+	}
+	return false
+}
+
 func (ra *RegistrationAuthorityImpl) SetRateLimitPoliciesFile(filename string) error {
 	_, err := reloader.New(filename, ra.rlPolicies.LoadPolicies, ra.rateLimitPoliciesLoadError)
 	if err != nil {
@@ -208,6 +526,62 @@ func (ra *RegistrationAuthorityImpl) rateLimitPoliciesLoadError(err error) {
 	ra.log.Errf("error reloading rate limit policy: %s", err)
 }
 
+// StartRateLimitOverrides makes the RA load the unexpired rate limit overrides
+// stored by the SA, and reload them every interval, so that overrides added or
+// expired with admin-revoker take effect without a redeploy. It returns an
+// error if the first load fails; later failures are logged, and the previously
+// loaded overrides kept. Each load is given at most interval to complete, so a
+// stuck SA can't stop later reloads.
+func (ra *RegistrationAuthorityImpl) StartRateLimitOverrides(interval time.Duration) error {
+	err := ra.reloadRateLimitOverrides(interval)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for range ticker.C {
+			err := ra.reloadRateLimitOverrides(interval)
+			if err != nil {
+				ra.log.Errf("error reloading rate limit overrides: %s", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reloadRateLimitOverrides calls loadRateLimitOverrides with a context that
+// times out after timeout.
+func (ra *RegistrationAuthorityImpl) reloadRateLimitOverrides(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return ra.loadRateLimitOverrides(ctx)
+}
+
+// loadRateLimitOverrides replaces the rate limit overrides applied on top of
+// the policy file's with those currently stored by the SA.
+func (ra *RegistrationAuthorityImpl) loadRateLimitOverrides(ctx context.Context) error {
+	resp, err := ra.SA.GetRateLimitOverrides(ctx, &sapb.GetRateLimitOverridesRequest{})
+	if err != nil {
+		return err
+	}
+	overrides := make([]ratelimit.Override, len(resp.Overrides))
+	for i, o := range resp.Overrides {
+		overrides[i] = ratelimit.Override{
+			LimitName:      o.GetLimitName(),
+			RegistrationID: o.GetRegistrationID(),
+			Key:            o.GetKey(),
+			Threshold:      int(o.GetThreshold()),
+		}
+	}
+	err = ra.rlPolicies.SetOverrides(overrides)
+	if err != nil {
+		return err
+	}
+	ra.rateLimitOverridesGauge.Set(float64(len(overrides)))
+	return nil
+}
+
 // certificateRequestAuthz is a struct for holding information about a valid
 // authz referenced during a certificateRequestEvent. It holds both the
 // authorization ID and the challenge type that made the authorization valid. We
@@ -226,6 +600,12 @@ type certificateRequestEvent struct {
 	Requester int64 `json:",omitempty"`
 	// OrderID is the associated order ID (may be empty for an ACME v1 issuance)
 	OrderID int64 `json:",omitempty"`
+	// CertificateProfileName is the name of the certificate profile requested
+	// by the order (empty if the default profile was used)
+	CertificateProfileName string `json:",omitempty"`
+	// Replaces is the serial of the certificate the order claimed to replace
+	// (empty if it didn't claim to replace one)
+	Replaces string `json:",omitempty"`
 	// SerialNumber is the string representation of the issued certificate's
 	// serial number
 	SerialNumber string `json:",omitempty"`
@@ -261,9 +641,12 @@ type registrationCounter func(context.Context, net.IP, time.Time, time.Time) (in
 
 // checkRegistrationIPLimit checks a specific registraton limit by using the
 // provided registrationCounter function to determine if the limit has been
-// exceeded for a given IP or IP range
+// exceeded for a given IP or IP range. The limit is described by the given
+// name and msg if so.
 func (ra *RegistrationAuthorityImpl) checkRegistrationIPLimit(
 	ctx context.Context,
+	name string,
+	msg string,
 	limit ratelimit.RateLimitPolicy,
 	ip net.IP,
 	counter registrationCounter) error {
@@ -279,8 +662,14 @@ func (ra *RegistrationAuthorityImpl) checkRegistrationIPLimit(
 		return err
 	}
 
-	if count >= limit.GetThreshold(ip.String(), noRegistrationID) {
-		return berrors.RateLimitError("too many registrations for this IP")
+	threshold := limit.GetThreshold(ip.String(), noRegistrationID)
+	if count >= threshold {
+		return berrors.RateLimitExceededError(berrors.RateLimitDetails{
+			Name:       name,
+			Usage:      int64(count),
+			Threshold:  int64(threshold),
+			RetryAfter: limit.Window.Duration,
+		}, msg)
 	}
 
 	return nil
@@ -292,7 +681,8 @@ func (ra *RegistrationAuthorityImpl) checkRegistrationLimits(ctx context.Context
 	// Check the registrations per IP limit using the CountRegistrationsByIP SA
 	// function that matches IP addresses exactly
 	exactRegLimit := ra.rlPolicies.RegistrationsPerIP()
-	err := ra.checkRegistrationIPLimit(ctx, exactRegLimit, ip, ra.SA.CountRegistrationsByIP)
+	err := ra.checkRegistrationIPLimit(ctx, "registrationsPerIP", "too many registrations for this IP",
+		exactRegLimit, ip, ra.SA.CountRegistrationsByIP)
 	if err != nil {
 		ra.rateLimitCounter.WithLabelValues("registrations_by_ip", "exceeded").Inc()
 		ra.log.Infof("Rate limit exceeded, RegistrationsByIP, IP: %s", ip)
@@ -311,13 +701,14 @@ func (ra *RegistrationAuthorityImpl) checkRegistrationLimits(ctx context.Context
 	// CountRegistrationsByIPRange SA function that fuzzy-matches IPv6 addresses
 	// within a larger address range
 	fuzzyRegLimit := ra.rlPolicies.RegistrationsPerIPRange()
-	err = ra.checkRegistrationIPLimit(ctx, fuzzyRegLimit, ip, ra.SA.CountRegistrationsByIPRange)
+	// For the fuzzyRegLimit we use a new error message that specifically
+	// mentions that the limit being exceeded is applied to a *range* of IPs
+	err = ra.checkRegistrationIPLimit(ctx, "registrationsPerIPRange", "too many registrations for this IP range",
+		fuzzyRegLimit, ip, ra.SA.CountRegistrationsByIPRange)
 	if err != nil {
 		ra.rateLimitCounter.WithLabelValues("registrations_by_ip_range", "exceeded").Inc()
 		ra.log.Infof("Rate limit exceeded, RegistrationsByIPRange, IP: %s", ip)
-		// For the fuzzyRegLimit we use a new error message that specifically
-		// mentions that the limit being exceeded is applied to a *range* of IPs
-		return berrors.RateLimitError("too many registrations for this IP range")
+		return err
 	}
 	ra.rateLimitCounter.WithLabelValues("registrations_by_ip_range", "pass").Inc()
 
@@ -434,10 +825,16 @@ func (ra *RegistrationAuthorityImpl) checkPendingAuthorizationLimit(ctx context.
 		// Most rate limits have a key for overrides, but there is no meaningful key
 		// here.
 		noKey := ""
-		if int(*countPB.Count) >= limit.GetThreshold(noKey, regID) {
+		threshold := limit.GetThreshold(noKey, regID)
+		if int(*countPB.Count) >= threshold {
 			ra.rateLimitCounter.WithLabelValues("pending_authorizations_by_registration_id", "exceeded").Inc()
 			ra.log.Infof("Rate limit exceeded, PendingAuthorizationsByRegID, regID: %d", regID)
-			return berrors.RateLimitError("too many currently pending authorizations")
+			return berrors.RateLimitExceededError(berrors.RateLimitDetails{
+				Name:       "pendingAuthorizationsPerAccount",
+				Usage:      *countPB.Count,
+				Threshold:  int64(threshold),
+				RetryAfter: limit.Window.Duration,
+			}, "too many currently pending authorizations")
 		}
 		ra.rateLimitCounter.WithLabelValues("pending_authorizations_by_registration_id", "pass").Inc()
 	}
@@ -471,15 +868,10 @@ func (ra *RegistrationAuthorityImpl) checkInvalidAuthorizationLimit(ctx context.
 	}
 	latest := ra.clk.Now().Add(ra.pendingAuthorizationLifetime)
 	earliest := latest.Add(-limit.Window.Duration)
-	latestNanos := latest.UnixNano()
-	earliestNanos := earliest.UnixNano()
 	req := &sapb.CountInvalidAuthorizationsRequest{
 		RegistrationID: &regID,
 		Hostname:       &hostname,
-		Range: &sapb.Range{
-			Earliest: &earliestNanos,
-			Latest:   &latestNanos,
-		},
+		Range:          bgrpc.RangeToPB(earliest, latest),
 	}
 	count, err := ra.SA.CountInvalidAuthorizations2(ctx, req)
 	if err != nil {
@@ -488,9 +880,15 @@ func (ra *RegistrationAuthorityImpl) checkInvalidAuthorizationLimit(ctx context.
 	// Most rate limits have a key for overrides, but there is no meaningful key
 	// here.
 	noKey := ""
-	if *count.Count >= int64(limit.GetThreshold(noKey, regID)) {
+	threshold := int64(limit.GetThreshold(noKey, regID))
+	if *count.Count >= threshold {
 		ra.log.Infof("Rate limit exceeded, InvalidAuthorizationsByRegID, regID: %d", regID)
-		return berrors.RateLimitError("too many failed authorizations recently")
+		return berrors.RateLimitExceededError(berrors.RateLimitDetails{
+			Name:       "invalidAuthorizationsPerAccount",
+			Usage:      *count.Count,
+			Threshold:  threshold,
+			RetryAfter: limit.Window.Duration,
+		}, "too many failed authorizations recently")
 	}
 	return nil
 }
@@ -511,17 +909,127 @@ func (ra *RegistrationAuthorityImpl) checkNewOrdersPerAccountLimit(ctx context.C
 	}
 	// There is no meaningful override key to use for this rate limit
 	noKey := ""
-	if count >= limit.GetThreshold(noKey, acctID) {
+	threshold := limit.GetThreshold(noKey, acctID)
+	if count >= threshold {
 		ra.rateLimitCounter.WithLabelValues("new_order_by_registration_id", "exceeded").Inc()
-		return berrors.RateLimitError("too many new orders recently")
+		return berrors.RateLimitExceededError(berrors.RateLimitDetails{
+			Name:       "newOrdersPerAccount",
+			Usage:      int64(count),
+			Threshold:  int64(threshold),
+			RetryAfter: limit.Window.Duration,
+		}, "too many new orders recently")
 	}
 	ra.rateLimitCounter.WithLabelValues("new_order_by_registration_id", "pass").Inc()
 	return nil
 }
 
+// maxAntiAbuseLookups bounds how many failed authorization counts are looked
+// up from the SA at once when building an anti-abuse request.
+const maxAntiAbuseLookups = 5
+
+// antiAbuseRequest gathers the metadata sent to the anti-abuse hook for a new
+// order: the account's creation time and the number of failed authorizations
+// for each name by the account within the configured failure window. It
+// returns the first error.
+func (ra *RegistrationAuthorityImpl) antiAbuseRequest(ctx context.Context, regID int64, names []string) (*abusepb.ScoreOrderRequest, error) {
+	reg, err := ra.SA.GetRegistration(ctx, regID)
+	if err != nil {
+		return nil, err
+	}
+	req := &abusepb.ScoreOrderRequest{
+		RegistrationID:   regID,
+		Names:            names,
+		AccountCreatedAt: reg.CreatedAt.UnixNano(),
+		RecentFailures:   make(map[string]int64, len(names)),
+		FailureWindow:    ra.antiAbuseFailureWindow.Nanoseconds(),
+	}
+	latest := ra.clk.Now()
+	earliest := latest.Add(-ra.antiAbuseFailureWindow)
+	type failureCount struct {
+		name  string
+		count *sapb.Count
+		err   error
+	}
+	results := make(chan failureCount, len(names))
+	lookups := make(chan struct{}, maxAntiAbuseLookups)
+	for _, name := range names {
+		go func(name string) {
+			lookups <- struct{}{}
+			defer func() { <-lookups }()
+			count, err := ra.SA.CountInvalidAuthorizations2(ctx, &sapb.CountInvalidAuthorizationsRequest{
+				RegistrationID: &regID,
+				Hostname:       &name,
+				Range:          bgrpc.RangeToPB(earliest, latest),
+			})
+			results <- failureCount{name, count, err}
+		}(name)
+	}
+	// As in checkInvalidAuthorizationLimits, there's enough capacity in the
+	// chan for every goroutine to write its result even if we stop reading.
+	for i := 0; i < len(names); i++ {
+		result := <-results
+		if result.err != nil {
+			return nil, result.err
+		}
+		req.RecentFailures[result.name] = result.count.GetCount()
+	}
+	return req, nil
+}
+
+// checkAntiAbuse consults the anti-abuse hook, if one is configured, about a
+// new order. It returns an error if the hook denies or delays the order, or if
+// the hook fails and the RA is configured to fail closed.
+func (ra *RegistrationAuthorityImpl) checkAntiAbuse(ctx context.Context, regID int64, names []string) error {
+	if ra.antiAbuse == nil {
+		return nil
+	}
+	req, err := ra.antiAbuseRequest(ctx, regID, names)
+	if err != nil {
+		return ra.antiAbuseFailure(regID, err)
+	}
+	resp, err := ra.antiAbuse.ScoreOrder(ctx, req)
+	if err != nil {
+		return ra.antiAbuseFailure(regID, err)
+	}
+	switch resp.Decision {
+	case abusepb.Decision_ALLOW:
+		ra.antiAbuseCounter.WithLabelValues("allow").Inc()
+		return nil
+	case abusepb.Decision_DENY:
+		ra.antiAbuseCounter.WithLabelValues("deny").Inc()
+		ra.log.Infof("Anti-abuse hook denied order for regID %d: %s", regID, resp.Reason)
+		return berrors.UnauthorizedError("new order denied: %s", resp.Reason)
+	case abusepb.Decision_DELAY:
+		ra.antiAbuseCounter.WithLabelValues("delay").Inc()
+		retryAfter := time.Duration(resp.RetryAfter)
+		ra.log.Infof("Anti-abuse hook delayed order for regID %d by %s: %s", regID, retryAfter, resp.Reason)
+		return berrors.RateLimitExceededError(berrors.RateLimitDetails{
+			RetryAfter: retryAfter,
+		}, "new order delayed, retry after %s: %s", retryAfter, resp.Reason)
+	default:
+		return ra.antiAbuseFailure(regID, fmt.Errorf("unknown decision %d", resp.Decision))
+	}
+}
+
+// antiAbuseFailure handles an anti-abuse hook which could not be consulted or
+// returned an unusable response, according to the configured failure mode.
+func (ra *RegistrationAuthorityImpl) antiAbuseFailure(regID int64, err error) error {
+	ra.antiAbuseCounter.WithLabelValues("error").Inc()
+	if ra.antiAbuseFailClosed {
+		ra.log.Errf("Anti-abuse hook failed, refusing order for regID %d: %s", regID, err)
+		return berrors.InternalServerError("unable to create new order at this time")
+	}
+	ra.log.Warningf("Anti-abuse hook failed, allowing order for regID %d: %s", regID, err)
+	return nil
+}
+
 // NewAuthorization constructs a new Authz from a request. Values (domains) in
 // request.Identifier will be lowercased before storage.
 func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, request core.Authorization, regID int64) (core.Authorization, error) {
+	// IP address identifiers are only supported for ACME v2 orders.
+	if request.Identifier.Type == identifier.IP {
+		return core.Authorization{}, berrors.MalformedError("IP address identifiers are only supported for ACME v2 orders")
+	}
 	identifier := request.Identifier
 	identifier.Value = strings.ToLower(identifier.Value)
 
@@ -539,11 +1047,13 @@ func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, reque
 	}
 
 	if ra.reuseValidAuthz {
-		now := ra.clk.Now().UnixNano()
+		now := ra.clk.Now()
+		nowNanos := now.UnixNano()
 		authzMapPB, err := ra.SA.GetValidAuthorizations2(ctx, &sapb.GetValidAuthorizationsRequest{
 			RegistrationID: &regID,
 			Domains:        []string{identifier.Value},
-			Now:            &now,
+			Now:            &nowNanos,
+			NowTime:        bgrpc.TimeToPB(now),
 		})
 		if err != nil {
 			outErr := berrors.InternalServerError(
@@ -573,13 +1083,15 @@ func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, reque
 		}
 	}
 
-	nowishNano := ra.clk.Now().Add(time.Hour).UnixNano()
+	nowish := ra.clk.Now().Add(time.Hour)
+	nowishNano := nowish.UnixNano()
 	identifierTypeString := string(identifier.Type)
 	req := &sapb.GetPendingAuthorizationRequest{
 		RegistrationID:  &regID,
 		IdentifierType:  &identifierTypeString,
 		IdentifierValue: &identifier.Value,
 		ValidUntil:      &nowishNano,
+		ValidUntilTime:  bgrpc.TimeToPB(nowish),
 	}
 	pendingPB, err := ra.SA.GetPendingAuthorization2(ctx, req)
 	if err != nil && !berrors.Is(err, berrors.NotFound) {
@@ -648,7 +1160,8 @@ func (ra *RegistrationAuthorityImpl) MatchesCSR(parsedCertificate *x509.Certific
 	if !core.KeyDigestEquals(parsedCertificate.PublicKey, csr.PublicKey) {
 		return berrors.InternalServerError("generated certificate public key doesn't match CSR public key")
 	}
-	if parsedCertificate.Subject.CommonName != strings.ToLower(csr.Subject.CommonName) {
+	// The CA may omit the CommonName, depending on the certificate profile.
+	if parsedCertificate.Subject.CommonName != "" && parsedCertificate.Subject.CommonName != strings.ToLower(csr.Subject.CommonName) {
 		return berrors.InternalServerError("generated certificate CommonName doesn't match CSR CommonName")
 	}
 	// Sort both slices of names before comparison.
@@ -737,6 +1250,7 @@ func (ra *RegistrationAuthorityImpl) checkAuthorizations(ctx context.Context, na
 		RegistrationID: &regID,
 		Domains:        names,
 		Now:            &nowUnix,
+		NowTime:        bgrpc.TimeToPB(now),
 	})
 	if err != nil {
 		return nil, err
@@ -785,8 +1299,9 @@ func (ra *RegistrationAuthorityImpl) checkAuthorizationsCAA(
 			return berrors.InternalServerError("found an authorization with a nil Expires field: id %s", authz.ID)
 		} else if authz.Expires.Before(now) {
 			badNames = append(badNames, name)
-		} else if authz.Expires.Before(caaRecheckTime) {
-			// Ensure that CAA is rechecked for this name
+		} else if authz.Expires.Before(caaRecheckTime) && authz.Identifier.Type == identifier.DNS {
+			// Ensure that CAA is rechecked for this name. CAA doesn't apply
+			// to IP addresses.
 			recheckAuthzs = append(recheckAuthzs, authz)
 		}
 	}
@@ -807,66 +1322,131 @@ func (ra *RegistrationAuthorityImpl) checkAuthorizationsCAA(
 	return nil
 }
 
-// recheckCAA accepts a list of of names that need to have their CAA records
-// rechecked because their associated authorizations are sufficiently old and
-// performs the CAA checks required for each. If any of the rechecks fail an
-// error is returned.
-func (ra *RegistrationAuthorityImpl) recheckCAA(ctx context.Context, authzs []*core.Authorization) error {
-	ra.recheckCAACounter.Add(float64(len(authzs)))
+// authzCAAResult is the result of rechecking CAA for an authorization.
+type authzCAAResult struct {
+	authz *core.Authorization
+	err   error
+}
 
-	type authzCAAResult struct {
-		authz *core.Authorization
-		err   error
+// caaRecheckRequest returns the IsCAAValidRequest used to recheck CAA for the
+// given authorization.
+func caaRecheckRequest(authz *core.Authorization) (*vapb.IsCAAValidRequest, error) {
+	name := authz.Identifier.Value
+
+	// If an authorization has multiple valid challenges,
+	// the type of the first valid challenge is used for
+	// the purposes of CAA rechecking.
+	var method string
+	for _, challenge := range authz.Challenges {
+		if challenge.Status == core.StatusValid {
+			method = string(challenge.Type)
+			break
+		}
+	}
+	if method == "" {
+		return nil, berrors.InternalServerError(
+			"Internal error determining validation method for authorization ID %v (%v)",
+			authz.ID, name)
+	}
+	regID := authz.RegistrationID
+	return &vapb.IsCAAValidRequest{
+		Domain:           &name,
+		ValidationMethod: &method,
+		AccountURIID:     &regID,
+	}, nil
+}
+
+// caaRecheckError converts the response to a CAA recheck for the given
+// authorization into an error, which is nil if the recheck succeeded.
+func (ra *RegistrationAuthorityImpl) caaRecheckError(authz *core.Authorization, resp *vapb.IsCAAValidResponse, err error) error {
+	if err != nil {
+		ra.log.AuditErrf("Rechecking CAA: %s", err)
+		return berrors.InternalServerError(
+			"Internal error rechecking CAA for authorization ID %v (%v)",
+			authz.ID, authz.Identifier.Value,
+		)
+	} else if resp.Problem != nil {
+		return berrors.CAAError(*resp.Problem.Detail)
 	}
+	return nil
+}
+
+// recheckCAAIndividually rechecks CAA for each authorization with a separate,
+// concurrent, RPC.
+func (ra *RegistrationAuthorityImpl) recheckCAAIndividually(ctx context.Context, authzs []*core.Authorization) []authzCAAResult {
 	ch := make(chan authzCAAResult, len(authzs))
 	for _, authz := range authzs {
 		go func(authz *core.Authorization) {
-			name := authz.Identifier.Value
-
-			// If an authorization has multiple valid challenges,
-			// the type of the first valid challenge is used for
-			// the purposes of CAA rechecking.
-			var method string
-			for _, challenge := range authz.Challenges {
-				if challenge.Status == core.StatusValid {
-					method = string(challenge.Type)
-					break
-				}
-			}
-			if method == "" {
-				ch <- authzCAAResult{
-					authz: authz,
-					err: berrors.InternalServerError(
-						"Internal error determining validation method for authorization ID %v (%v)",
-						authz.ID, name),
-				}
-				return
-			}
-
-			resp, err := ra.caa.IsCAAValid(ctx, &vapb.IsCAAValidRequest{
-				Domain:           &name,
-				ValidationMethod: &method,
-				AccountURIID:     &authz.RegistrationID,
-			})
+			req, err := caaRecheckRequest(authz)
 			if err != nil {
-				ra.log.AuditErrf("Rechecking CAA: %s", err)
-				err = berrors.InternalServerError(
-					"Internal error rechecking CAA for authorization ID %v (%v)",
-					authz.ID, name,
-				)
-			} else if resp.Problem != nil {
-				err = berrors.CAAError(*resp.Problem.Detail)
+				ch <- authzCAAResult{authz: authz, err: err}
+				return
 			}
+			resp, err := ra.caa.IsCAAValid(ctx, req)
 			ch <- authzCAAResult{
 				authz: authz,
-				err:   err,
+				err:   ra.caaRecheckError(authz, resp, err),
 			}
 		}(authz)
 	}
+	results := make([]authzCAAResult, len(authzs))
+	for i := range results {
+		results[i] = <-ch
+	}
+	return results
+}
+
+// recheckCAABatch rechecks CAA for all of the authorizations with a single
+// RPC, so that the VA can share the lookups for parent domains the names have
+// in common.
+func (ra *RegistrationAuthorityImpl) recheckCAABatch(ctx context.Context, authzs []*core.Authorization) []authzCAAResult {
+	var results []authzCAAResult
+	var checked []*core.Authorization
+	var checks []*vapb.IsCAAValidRequest
+	for _, authz := range authzs {
+		req, err := caaRecheckRequest(authz)
+		if err != nil {
+			results = append(results, authzCAAResult{authz: authz, err: err})
+			continue
+		}
+		checked = append(checked, authz)
+		checks = append(checks, req)
+	}
+	if len(checks) == 0 {
+		return results
+	}
+	resp, err := ra.caa.IsCAAValidForNames(ctx, &vapb.IsCAAValidForNamesRequest{Checks: checks})
+	if err == nil && len(resp.Results) != len(checks) {
+		err = fmt.Errorf("IsCAAValidForNames returned %d results for %d checks", len(resp.Results), len(checks))
+	}
+	for i, authz := range checked {
+		var checkResp *vapb.IsCAAValidResponse
+		if err == nil {
+			checkResp = resp.Results[i]
+		}
+		results = append(results, authzCAAResult{
+			authz: authz,
+			err:   ra.caaRecheckError(authz, checkResp, err),
+		})
+	}
+	return results
+}
+
+// recheckCAA accepts a list of of names that need to have their CAA records
+// rechecked because their associated authorizations are sufficiently old and
+// performs the CAA checks required for each. If any of the rechecks fail an
+// error is returned.
+func (ra *RegistrationAuthorityImpl) recheckCAA(ctx context.Context, authzs []*core.Authorization) error {
+	ra.recheckCAACounter.Add(float64(len(authzs)))
+
+	var results []authzCAAResult
+	if features.Enabled(features.BatchCAARecheck) {
+		results = ra.recheckCAABatch(ctx, authzs)
+	} else {
+		results = ra.recheckCAAIndividually(ctx, authzs)
+	}
 	var subErrors []berrors.SubBoulderError
-	// Read a recheckResult for each authz from the results channel
-	for i := 0; i < len(authzs); i++ {
-		recheckResult := <-ch
+	for _, recheckResult := range results {
 		// If the result had a CAA boulder error, construct a suberror with the
 		// identifier from the authorization that was checked.
 		if err := recheckResult.err; err != nil {
@@ -911,14 +1491,17 @@ func (ra *RegistrationAuthorityImpl) failOrder(
 	// Convert the problem to a protobuf problem for the *corepb.Order field
 	pbProb, err := bgrpc.ProblemDetailsToPB(prob)
 	if err != nil {
-		ra.log.AuditErrf("Could not convert order error problem to PB: %q", err)
+		blog.ForContext(ctx, ra.log).AuditErrf("Could not convert order error problem to PB: %q", err)
 		return order
 	}
 
-	// Assign the protobuf problem to the field and save it via the SA
+	// Assign the protobuf problem to the field and save it via the SA, even if
+	// the failure was ctx's deadline passing.
 	order.Error = pbProb
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, failOrderTimeout)
+	defer cancel()
 	if err := ra.SA.SetOrderError(ctx, order); err != nil {
-		ra.log.AuditErrf("Could not persist order error: %q", err)
+		blog.ForContext(ctx, ra.log).AuditErrf("Could not persist order error: %q", err)
 	}
 	return order
 }
@@ -952,7 +1535,7 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		return nil, err
 	}
 
-	if err := csrlib.VerifyCSR(ctx, csrOb, ra.maxNames, &ra.keyPolicy, ra.PA, *req.Order.RegistrationID); err != nil {
+	if err := ra.verifyCSR(ctx, csrOb, order.GetCertificateProfileName(), *req.Order.RegistrationID); err != nil {
 		// VerifyCSR returns berror instances that can be passed through as-is
 		// without wrapping.
 		return nil, err
@@ -960,7 +1543,7 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 
 	// Dedupe, lowercase and sort both the names from the CSR and the names in the
 	// order.
-	csrNames := core.UniqueLowerNames(csrOb.DNSNames)
+	csrNames := core.UniqueLowerNames(csrlib.NamesFromCSR(csrOb))
 	orderNames := core.UniqueLowerNames(order.Names)
 
 	// Immediately reject the request if the number of names differ
@@ -975,9 +1558,9 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		}
 	}
 
-	// Update the order to be status processing - we issue synchronously at the
-	// present time so this is somewhat artificial/unnecessary but allows planning
-	// for the future.
+	// Update the order to be status processing. Unless the AsyncFinalize
+	// feature is enabled we issue synchronously, so clients never see this
+	// state.
 	//
 	// NOTE(@cpu): After this point any errors that are encountered must update
 	// the state of the order to invalid by setting the order's error field.
@@ -991,13 +1574,50 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		return nil, err
 	}
 
-	// Attempt issuance for the order. If the order isn't fully authorized this
-	// will return an error.
 	issueReq := core.CertificateRequest{
 		Bytes: req.Csr,
 		CSR:   csrOb,
 	}
-	cert, err := ra.issueCertificate(ctx, issueReq, accountID(*order.RegistrationID), orderID(*order.Id))
+
+	if features.Enabled(features.AsyncFinalize) {
+		// Issue in the background, with a context that carries this RPC's
+		// values but isn't cancelled when it returns, and return the order as
+		// processing. The client polls the order until it's valid or invalid.
+		// The goroutine gets its own copy of the order, since the one returned
+		// is marshaled concurrently.
+		issueOrder := proto.Clone(order).(*corepb.Order)
+		ra.finalizations.Add(1)
+		go func() {
+			defer ra.finalizations.Done()
+			timeout := ra.finalizeTimeout
+			if timeout <= 0 {
+				timeout = defaultFinalizeTimeout
+			}
+			issueCtx, cancel := context.WithTimeout(detachedContext{ctx}, timeout)
+			defer cancel()
+			_, err := ra.issueCertificateForOrder(issueCtx, issueOrder, issueReq)
+			if err != nil {
+				blog.ForContext(issueCtx, ra.log).Warningf("Asynchronous finalization of order %d failed: %s", *issueOrder.Id, err)
+			}
+		}()
+
+		processingStatus := string(core.StatusProcessing)
+		order.Status = &processingStatus
+		return order, nil
+	}
+
+	return ra.issueCertificateForOrder(ctx, order, issueReq)
+}
+
+// issueCertificateForOrder issues a certificate for the given CSR and the
+// processing order, and records the certificate's serial on the order,
+// returning it as valid. If issuance fails the order is failed with a problem
+// describing why.
+func (ra *RegistrationAuthorityImpl) issueCertificateForOrder(ctx context.Context, order *corepb.Order, issueReq core.CertificateRequest) (*corepb.Order, error) {
+	// Attempt issuance for the order. If the order isn't fully authorized this
+	// will return an error.
+	window := validityWindow{notBefore: order.GetNotBefore(), notAfter: order.GetNotAfter()}
+	cert, err := ra.issueCertificate(ctx, issueReq, accountID(*order.RegistrationID), orderID(*order.Id), order.GetCertificateProfileName(), order.GetReplaces(), window)
 	if err != nil {
 		// Fail the order. The problem is computed using
 		// `web.ProblemDetailsForError`, the same function the WFE uses to convert
@@ -1043,13 +1663,14 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 // NewCertificate requests the issuance of a certificate.
 func (ra *RegistrationAuthorityImpl) NewCertificate(ctx context.Context, req core.CertificateRequest, regID int64) (core.Certificate, error) {
 	// Verify the CSR
-	if err := csrlib.VerifyCSR(ctx, req.CSR, ra.maxNames, &ra.keyPolicy, ra.PA, regID); err != nil {
+	if err := ra.verifyCSR(ctx, req.CSR, "", regID); err != nil {
 		return core.Certificate{}, berrors.MalformedError(err.Error())
 	}
 	// NewCertificate provides an order ID of 0, indicating this is a classic ACME
 	// v1 issuance request from the new certificate endpoint that is not
-	// associated with an ACME v2 order.
-	return ra.issueCertificate(ctx, req, accountID(regID), orderID(0))
+	// associated with an ACME v2 order, and therefore always uses the default
+	// certificate profile and never replaces another certificate.
+	return ra.issueCertificate(ctx, req, accountID(regID), orderID(0), "", "", validityWindow{})
 }
 
 // To help minimize the chance that an accountID would be used as an order ID
@@ -1058,22 +1679,34 @@ func (ra *RegistrationAuthorityImpl) NewCertificate(ctx context.Context, req cor
 type accountID int64
 type orderID int64
 
+// validityWindow is the notBefore and notAfter, in nanoseconds since the
+// epoch, requested for an order's certificate. Zero values weren't requested.
+type validityWindow struct {
+	notBefore int64
+	notAfter  int64
+}
+
 // issueCertificate sets up a log event structure and captures any errors
 // encountered during issuance, then calls issueCertificateInner.
 func (ra *RegistrationAuthorityImpl) issueCertificate(
 	ctx context.Context,
 	req core.CertificateRequest,
 	acctID accountID,
-	oID orderID) (core.Certificate, error) {
+	oID orderID,
+	profileName string,
+	replaces string,
+	window validityWindow) (core.Certificate, error) {
 	// Construct the log event
 	logEvent := certificateRequestEvent{
-		ID:          core.NewToken(),
-		OrderID:     int64(oID),
-		Requester:   int64(acctID),
-		RequestTime: ra.clk.Now(),
+		ID:                     core.NewToken(),
+		OrderID:                int64(oID),
+		CertificateProfileName: profileName,
+		Replaces:               replaces,
+		Requester:              int64(acctID),
+		RequestTime:            ra.clk.Now(),
 	}
 	var result string
-	cert, err := ra.issueCertificateInner(ctx, req, acctID, oID, &logEvent)
+	cert, err := ra.issueCertificateInner(ctx, req, acctID, oID, profileName, replaces, window, &logEvent)
 	if err != nil {
 		logEvent.Error = err.Error()
 		result = "error"
@@ -1081,7 +1714,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificate(
 		result = "successful"
 	}
 	logEvent.ResponseTime = ra.clk.Now()
-	ra.log.AuditObject(fmt.Sprintf("Certificate request - %s", result), logEvent)
+	blog.ForContext(ctx, ra.log).AuditObject(fmt.Sprintf("Certificate request - %s", result), logEvent)
 	return cert, err
 }
 
@@ -1104,6 +1737,9 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	req core.CertificateRequest,
 	acctID accountID,
 	oID orderID,
+	profileName string,
+	replaces string,
+	window validityWindow,
 	logEvent *certificateRequestEvent) (core.Certificate, error) {
 	emptyCert := core.Certificate{}
 	if acctID <= 0 {
@@ -1123,11 +1759,10 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 
 	csr := req.CSR
 	logEvent.CommonName = csr.Subject.CommonName
-	logEvent.Names = csr.DNSNames
-
-	// Validate that authorization key is authorized for all domains in the CSR
-	names := make([]string, len(csr.DNSNames))
-	copy(names, csr.DNSNames)
+	// Validate that authorization key is authorized for all domains and IP
+	// addresses in the CSR
+	names := csrlib.NamesFromCSR(csr)
+	logEvent.Names = names
 
 	if core.KeyDigestEquals(csr.PublicKey, account.Key) {
 		return emptyCert, berrors.MalformedError("certificate public key must be different than account key")
@@ -1136,7 +1771,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	// Check rate limits before checking authorizations. If someone is unable to
 	// issue a cert due to rate limiting, we don't want to tell them to go get the
 	// necessary authorizations, only to later fail the rate limit check.
-	err = ra.checkLimits(ctx, names, account.ID)
+	isRenewal, err := ra.checkLimits(ctx, names, account.ID, replaces != "")
 	if err != nil {
 		return emptyCert, err
 	}
@@ -1179,11 +1814,21 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	// Mark that we verified the CN and SANs
 	logEvent.VerifiedFields = []string{"subject.commonName", "subjectAltName"}
 
+	// The order may not have been finalized until after the notAfter it
+	// requested. A requested notBefore which has passed is fine: the CA
+	// doesn't backdate certificates any further than usual to honor it.
+	if window.notAfter != 0 && !time.Unix(0, window.notAfter).After(ra.clk.Now()) {
+		return emptyCert, berrors.MalformedError("the order's requested notAfter has passed")
+	}
+
 	// Create the certificate and log the result
 	issueReq := &capb.IssueCertificateRequest{
-		Csr:            csr.Raw,
-		RegistrationID: int64(acctID),
-		OrderID:        int64(oID),
+		Csr:                    csr.Raw,
+		RegistrationID:         int64(acctID),
+		OrderID:                int64(oID),
+		CertificateProfileName: profileName,
+		NotBefore:              window.notBefore,
+		NotAfter:               window.notAfter,
 	}
 
 	// wrapError adds a prefix to an error. If the error is a boulder error then
@@ -1205,8 +1850,13 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	if err != nil {
 		return emptyCert, wrapError(err, "parsing precertificate")
 	}
-	scts, err := ra.getSCTs(ctx, precert.DER, parsedPrecert.NotAfter)
+	scts, err := ra.getSCTs(ctx, precert.DER, parsedPrecert.NotAfter, parsedPrecert.NotAfter.Sub(parsedPrecert.NotBefore))
 	if err != nil {
+		// No final certificate will ever be signed for this precertificate, so
+		// record why it was abandoned.
+		blog.ForContext(ctx, ra.log).AuditErrf("Abandoning precertificate without SCTs: serial=[%s] regID=[%d] orderID=[%d] err=[%s]",
+			core.SerialToString(parsedPrecert.SerialNumber), acctID, oID, err)
+		ra.recordIssuanceFailure(ctx, parsedPrecert, "getting SCTs", err)
 		return emptyCert, wrapError(err, "getting SCTs")
 	}
 	cert, err := ra.CA.IssueCertificateForPrecertificate(ctx, &capb.IssueCertificateForPrecertificateRequest{
@@ -1216,6 +1866,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 		OrderID:        int64(oID),
 	})
 	if err != nil {
+		ra.recordIssuanceFailure(ctx, parsedPrecert, "issuing certificate for precertificate", err)
 		return emptyCert, wrapError(err, "issuing certificate for precertificate")
 	}
 
@@ -1240,6 +1891,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	logEvent.NotAfter = parsedCertificate.NotAfter
 
 	ra.newCertCounter.Inc()
+	ra.spendKeyValueLimits(ctx, names, account.ID, isRenewal)
 	res, err := bgrpc.PBToCert(cert)
 	if err != nil {
 		return emptyCert, nil
@@ -1247,20 +1899,48 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	return res, nil
 }
 
-func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte, expiration time.Time) (core.SCTDERs, error) {
+// recordIssuanceFailure records with the SA that no final certificate will be
+// issued for precert, because of an error during the given step. Failing to
+// record it is only logged, leaving the precertificate's outcome pending. If
+// the CA did store the final certificate before erroring, the SA leaves its
+// outcome as issued.
+func (ra *RegistrationAuthorityImpl) recordIssuanceFailure(ctx context.Context, precert *x509.Certificate, step string, cause error) {
+	if !features.Enabled(features.StoreIssuanceOutcomes) {
+		return
+	}
+	serial := core.SerialToString(precert.SerialNumber)
+	detail := fmt.Sprintf("%s: %s", step, cause)
+	_, err := ra.SA.RecordIssuanceFailure(ctx, &sapb.IssuanceFailure{
+		Serial: &serial,
+		Detail: &detail,
+	})
+	if err != nil {
+		blog.ForContext(ctx, ra.log).Warningf("Failed to record issuance failure for serial %s: %s", serial, err)
+	}
+}
+
+func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte, expiration time.Time, validity time.Duration) (core.SCTDERs, error) {
+	// Stop collecting SCTs early enough to leave time for the final
+	// certificate to be signed within the request's deadline.
+	sctCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok && ra.finalSigningReserve > 0 {
+		var cancel context.CancelFunc
+		sctCtx, cancel = context.WithDeadline(ctx, deadline.Add(-ra.finalSigningReserve))
+		defer cancel()
+	}
 	started := ra.clk.Now()
-	scts, err := ra.ctpolicy.GetSCTs(ctx, cert, expiration)
+	scts, err := ra.ctpolicy.GetSCTs(sctCtx, cert, expiration, validity)
 	took := ra.clk.Since(started)
-	// The final cert has already been issued so actually return it to the
-	// user even if this fails since we aren't actually doing anything with
-	// the SCTs yet.
 	if err != nil {
 		state := "failure"
-		if err == context.DeadlineExceeded {
+		if sctCtx.Err() == context.DeadlineExceeded {
 			state = "deadlineExceeded"
+			if ctx.Err() == nil {
+				state = "signingDeadline"
+			}
 			// Convert the error to a missingSCTsError to communicate the timeout,
-			// otherwise it will be a generic serverInternalError
-			err = berrors.MissingSCTsError(err.Error())
+			// otherwise it may be a generic serverInternalError
+			err = berrors.MissingSCTsError("unable to get SCTs before signing deadline: %s", err)
 		}
 		ra.log.Warningf("ctpolicy.GetSCTs failed: %s", err)
 		ra.ctpolicyResults.With(prometheus.Labels{"result": state}).Observe(took.Seconds())
@@ -1276,6 +1956,12 @@ func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte, e
 func domainsForRateLimiting(names []string) ([]string, error) {
 	var domains []string
 	for _, name := range names {
+		// IP addresses have no registered domain, so each is limited on its
+		// own.
+		if net.ParseIP(name) != nil {
+			domains = append(domains, name)
+			continue
+		}
 		domain, err := publicsuffix.Domain(name)
 		if err != nil {
 			// The only possible errors are:
@@ -1292,13 +1978,13 @@ func domainsForRateLimiting(names []string) ([]string, error) {
 
 // enforceNameCounts uses the provided count RPC to find a count of certificates
 // for each of the names. If the count for any of the names exceeds the limit
-// for the given registration then the names out of policy are returned to be
-// used for a rate limit error.
+// for the given registration then the counts of the names out of policy are
+// returned to be used for a rate limit error.
 func (ra *RegistrationAuthorityImpl) enforceNameCounts(
 	ctx context.Context,
 	names []string,
 	limit ratelimit.RateLimitPolicy,
-	regID int64) ([]string, error) {
+	regID int64) ([]*sapb.CountByNames_MapElement, error) {
 
 	now := ra.clk.Now()
 	windowBegin := limit.WindowBegin(now)
@@ -1307,28 +1993,35 @@ func (ra *RegistrationAuthorityImpl) enforceNameCounts(
 		return nil, err
 	}
 
-	var badNames []string
+	var badCounts []*sapb.CountByNames_MapElement
 	for _, entry := range counts {
 		// Should not happen, but be defensive.
 		if entry.Count == nil || entry.Name == nil {
 			return nil, fmt.Errorf("CountByNames_MapElement had nil Count or Name")
 		}
 		if int(*entry.Count) >= limit.GetThreshold(*entry.Name, regID) {
-			badNames = append(badNames, *entry.Name)
+			badCounts = append(badCounts, entry)
 		}
 	}
-	return badNames, nil
+	return badCounts, nil
 }
 
-func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64) error {
-	// check if there is already an existing certificate for
-	// the exact name set we are issuing for. If so bypass the
-	// the certificatesPerName limit.
+// isRenewal returns true if a certificate has previously been issued for
+// exactly the given set of names, as recorded in the fqdnSets table. The SA
+// makes the same determination when adding a certificate, and doesn't count
+// renewals towards the certificatesPerName limit.
+func (ra *RegistrationAuthorityImpl) isRenewal(ctx context.Context, names []string) (bool, error) {
 	exists, err := ra.SA.FQDNSetExists(ctx, names)
 	if err != nil {
-		return fmt.Errorf("checking renewal exemption for %q: %s", names, err)
+		return false, fmt.Errorf("checking renewal exemption for %q: %s", names, err)
 	}
-	if exists {
+	return exists, nil
+}
+
+// checkCertificatesPerNameLimit enforces the certificatesPerName limit, from
+// which renewals are exempt.
+func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64, isRenewal bool) error {
+	if isRenewal {
 		ra.rateLimitCounter.WithLabelValues("certificates_for_domain", "FQDN set bypass").Inc()
 		return nil
 	}
@@ -1338,52 +2031,139 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.C
 		return err
 	}
 
-	namesOutOfLimit, err := ra.enforceNameCounts(ctx, tldNames, limit, regID)
-	if err != nil {
-		return fmt.Errorf("checking certificates per name limit for %q: %s",
-			names, err)
-	}
-
-	if len(namesOutOfLimit) > 0 {
-		// check if there is already an existing certificate for
-		// the exact name set we are issuing for. If so bypass the
-		// the certificatesPerName limit.
-		exists, err := ra.SA.FQDNSetExists(ctx, names)
+	err = ra.enforceLimit("certificatesPerName", func() error {
+		countsOutOfLimit, err := ra.enforceNameCounts(ctx, tldNames, limit, regID)
 		if err != nil {
-			return fmt.Errorf("checking renewal exemption for %q: %s", names, err)
+			return fmt.Errorf("checking certificates per name limit for %q: %s",
+				names, err)
 		}
-		if exists {
-			ra.rateLimitCounter.WithLabelValues("certificates_for_domain", "FQDN set bypass").Inc()
-			return nil
+		var namesOutOfLimit []string
+		var details []berrors.RateLimitDetails
+		for _, entry := range countsOutOfLimit {
+			namesOutOfLimit = append(namesOutOfLimit, *entry.Name)
+			details = append(details, berrors.RateLimitDetails{
+				Name:       "certificatesPerName",
+				Usage:      *entry.Count,
+				Threshold:  int64(limit.GetThreshold(*entry.Name, regID)),
+				RetryAfter: limit.Window.Duration,
+			})
 		}
-
-		ra.log.Infof("Rate limit exceeded, CertificatesForDomain, regID: %d, domains: %s", regID, strings.Join(namesOutOfLimit, ", "))
-		ra.rateLimitCounter.WithLabelValues("certificates_for_domain", "exceeded").Inc()
-		if len(namesOutOfLimit) > 1 {
-			var subErrors []berrors.SubBoulderError
-			for _, name := range namesOutOfLimit {
-				subErrors = append(subErrors, berrors.SubBoulderError{
-					Identifier:   identifier.DNSIdentifier(name),
-					BoulderError: berrors.RateLimitError("too many certificates already issued").(*berrors.BoulderError),
-				})
+		return certificatesPerNameError(namesOutOfLimit, details)
+	}, func() error {
+		var namesOutOfLimit []string
+		var details []berrors.RateLimitDetails
+		for _, name := range tldNames {
+			d, err := ra.limiter.Check(ctx, "certificatesPerName", limit, name, regID, 1)
+			if err != nil {
+				return fmt.Errorf("checking key-value certificates per name limit for %q: %s",
+					names, err)
+			}
+			if d.Allowed {
+				continue
 			}
-			return berrors.RateLimitError("too many certificates already issued for multiple names (%s and %d others)", namesOutOfLimit[0], len(namesOutOfLimit)).(*berrors.BoulderError).WithSubErrors(subErrors)
+			threshold := int64(limit.GetThreshold(name, regID))
+			namesOutOfLimit = append(namesOutOfLimit, name)
+			details = append(details, berrors.RateLimitDetails{
+				Name:       "certificatesPerName",
+				Usage:      threshold - d.Remaining,
+				Threshold:  threshold,
+				RetryAfter: d.RetryIn,
+			})
 		}
-		return berrors.RateLimitError("too many certificates already issued for: %s", namesOutOfLimit[0])
+		return certificatesPerNameError(namesOutOfLimit, details)
+	})
+	if berrors.Is(err, berrors.RateLimit) {
+		ra.log.Infof("Rate limit exceeded, CertificatesForDomain, regID: %d, %s", regID, err)
+		ra.rateLimitCounter.WithLabelValues("certificates_for_domain", "exceeded").Inc()
+		return err
+	} else if err != nil {
+		return err
 	}
 	ra.rateLimitCounter.WithLabelValues("certificates_for_domain", "pass").Inc()
 
 	return nil
 }
 
-func (ra *RegistrationAuthorityImpl) checkCertificatesPerFQDNSetLimit(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64) error {
+// certificatesPerNameError returns the error for a certificate which would
+// exceed the certificatesPerName limit for namesOutOfLimit, or nil if there
+// are none. Each name's details describe its own usage and threshold. Those of
+// the error as a whole are the first name's.
+func certificatesPerNameError(namesOutOfLimit []string, details []berrors.RateLimitDetails) error {
+	if len(namesOutOfLimit) == 0 {
+		return nil
+	}
+	if len(namesOutOfLimit) > 1 {
+		var subErrors []berrors.SubBoulderError
+		for i, name := range namesOutOfLimit {
+			subErrors = append(subErrors, berrors.SubBoulderError{
+				Identifier:   identifier.FromValue(name),
+				BoulderError: berrors.RateLimitExceededError(details[i], "too many certificates already issued").(*berrors.BoulderError),
+			})
+		}
+		return berrors.RateLimitExceededError(details[0], "too many certificates already issued for multiple names (%s and %d others)", namesOutOfLimit[0], len(namesOutOfLimit)).(*berrors.BoulderError).WithSubErrors(subErrors)
+	}
+	return berrors.RateLimitExceededError(details[0], "too many certificates already issued for: %s", namesOutOfLimit[0])
+}
+
+// checkCertificatesPerFQDNSetLimit enforces the certificatesPerFQDNSet
+// (duplicate certificate) limit. Only renewals can have been issued within the
+// window, so for any other set of names there's nothing to count.
+func (ra *RegistrationAuthorityImpl) checkCertificatesPerFQDNSetLimit(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64, isRenewal bool) error {
+	if !isRenewal && limit.GetThreshold(strings.Join(core.UniqueLowerNames(names), ","), regID) > 0 {
+		ra.rateLimitCounter.WithLabelValues("certificates_for_fqdn_set", "new FQDN set").Inc()
+		return nil
+	}
+	return ra.enforceLimit("certificatesPerFQDNSet", func() error {
+		return ra.countCertificatesPerFQDNSet(ctx, names, limit, regID)
+	}, func() error {
+		key := strings.Join(core.UniqueLowerNames(names), ",")
+		d, err := ra.limiter.Check(ctx, "certificatesPerFQDNSet", limit, key, regID, 1)
+		if err != nil {
+			return fmt.Errorf("checking key-value duplicate certificate limit for %q: %s", names, err)
+		}
+		if d.Allowed {
+			return nil
+		}
+		threshold := int64(limit.GetThreshold(key, regID))
+		return berrors.RateLimitExceededError(berrors.RateLimitDetails{
+			Name:       "certificatesPerFQDNSet",
+			Usage:      threshold - d.Remaining,
+			Threshold:  threshold,
+			RetryAfter: d.RetryIn,
+		},
+			"too many certificates already issued for exact set of domains: %s",
+			key,
+		)
+	})
+}
+
+// countCertificatesPerFQDNSet enforces the certificatesPerFQDNSet limit by
+// counting the certificates for names stored by the SA.
+func (ra *RegistrationAuthorityImpl) countCertificatesPerFQDNSet(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64) error {
+	if features.Enabled(features.FasterFQDNSetRateLimit) {
+		// Most new certificates aren't for a set of names issued within the
+		// window, and for those there's nothing to count.
+		issued, err := ra.SA.FQDNSetIssuedWithin(ctx, limit.Window.Duration, names)
+		if err != nil {
+			return fmt.Errorf("checking duplicate certificate limit for %q: %s", names, err)
+		}
+		if !issued && limit.GetThreshold(strings.Join(core.UniqueLowerNames(names), ","), regID) > 0 {
+			return nil
+		}
+	}
 	count, err := ra.SA.CountFQDNSets(ctx, limit.Window.Duration, names)
 	if err != nil {
 		return fmt.Errorf("checking duplicate certificate limit for %q: %s", names, err)
 	}
 	names = core.UniqueLowerNames(names)
-	if int(count) >= limit.GetThreshold(strings.Join(names, ","), regID) {
-		return berrors.RateLimitError(
+	threshold := limit.GetThreshold(strings.Join(names, ","), regID)
+	if int(count) >= threshold {
+		return berrors.RateLimitExceededError(berrors.RateLimitDetails{
+			Name:       "certificatesPerFQDNSet",
+			Usage:      count,
+			Threshold:  int64(threshold),
+			RetryAfter: limit.Window.Duration,
+		},
 			"too many certificates already issued for exact set of domains: %s",
 			strings.Join(names, ","),
 		)
@@ -1391,23 +2171,122 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerFQDNSetLimit(ctx contex
 	return nil
 }
 
-func (ra *RegistrationAuthorityImpl) checkLimits(ctx context.Context, names []string, regID int64) error {
+// checkLimits enforces the rate limits on issuing certificates for names. An
+// ARI renewal, which the WFE has verified replaces an earlier certificate of
+// the account's for at least one of the names, is exempt from the
+// certificatesPerName and certificatesPerFQDNSet limits. Since a certificate
+// can only be replaced by one order which hasn't failed or expired, the
+// exemption can only be used once for each replaced certificate. Whether names
+// is otherwise a renewal is looked up once, shared by both limits, and
+// returned for spendKeyValueLimits.
+func (ra *RegistrationAuthorityImpl) checkLimits(ctx context.Context, names []string, regID int64, isARIRenewal bool) (bool, error) {
 	certNameLimits := ra.rlPolicies.CertificatesPerName()
-	if certNameLimits.Enabled() {
-		err := ra.checkCertificatesPerNameLimit(ctx, names, certNameLimits, regID)
+	fqdnLimits := ra.rlPolicies.CertificatesPerFQDNSet()
+
+	// ARI renewals are still spent against the key-value limits, as the SA
+	// still counts them, so need to know whether they're renewals too.
+	var isRenewal bool
+	if (!isARIRenewal || ra.keyValueRateLimits()) && (certNameLimits.Enabled() || fqdnLimits.Enabled()) {
+		var err error
+		isRenewal, err = ra.isRenewal(ctx, names)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
+	if certNameLimits.Enabled() && isARIRenewal {
+		ra.rateLimitCounter.WithLabelValues("certificates_for_domain", "ARI renewal bypass").Inc()
+	} else if certNameLimits.Enabled() {
+		err := ra.checkCertificatesPerNameLimit(ctx, names, certNameLimits, regID, isRenewal)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if fqdnLimits.Enabled() && isARIRenewal {
+		ra.rateLimitCounter.WithLabelValues("certificates_for_fqdn_set", "ARI renewal bypass").Inc()
+	} else if fqdnLimits.Enabled() {
+		err := ra.checkCertificatesPerFQDNSetLimit(ctx, names, fqdnLimits, regID, isRenewal)
+		if err != nil {
+			return false, err
+		}
+	}
+	return isRenewal, nil
+}
+
+// keyValueRateLimits returns whether the key-value rate limiter is in use,
+// either alongside the database's counts or in place of them.
+func (ra *RegistrationAuthorityImpl) keyValueRateLimits() bool {
+	return ra.limiter != nil &&
+		(features.Enabled(features.KeyValueRateLimits) || features.Enabled(features.KeyValueRateLimitsCutover))
+}
+
+// enforceLimit enforces the named limit, deciding whether it's exceeded with
+// dbCheck, which counts rows stored by the SA, or kvCheck, which checks the
+// key-value rate limiter. Each returns a RateLimit error if the limit is
+// exceeded. Until the KeyValueRateLimitsCutover feature is enabled, the
+// database's decision is enforced, and the key-value rate limiter's is only
+// compared with it.
+func (ra *RegistrationAuthorityImpl) enforceLimit(limit string, dbCheck, kvCheck func() error) error {
+	if !ra.keyValueRateLimits() {
+		return dbCheck()
+	}
+	if features.Enabled(features.KeyValueRateLimitsCutover) {
+		return kvCheck()
+	}
+	dbErr := dbCheck()
+	kvErr := kvCheck()
+
+	dbDenied := berrors.Is(dbErr, berrors.RateLimit)
+	kvDenied := berrors.Is(kvErr, berrors.RateLimit)
+	var result string
+	switch {
+	case (dbErr != nil && !dbDenied) || (kvErr != nil && !kvDenied):
+		result = "error"
+	case dbDenied == kvDenied:
+		result = "agree"
+	case dbDenied:
+		result = "database_denied"
+	default:
+		result = "key_value_denied"
+	}
+	ra.rateLimitComparisons.WithLabelValues(limit, result).Inc()
+	if kvErr != nil && !kvDenied {
+		ra.log.Warningf("Key-value rate limit check failed: %s", kvErr)
+	}
+	return dbErr
+}
+
+// spendKeyValueLimits spends a newly issued certificate for names against the
+// key-value rate limiter's certificatesPerName and certificatesPerFQDNSet
+// limits, mirroring the rows the SA stores for it, which the database's
+// limits count. Renewals aren't spent against certificatesPerName. Failures
+// are only logged, since the certificate has already been issued.
+func (ra *RegistrationAuthorityImpl) spendKeyValueLimits(ctx context.Context, names []string, regID int64, isRenewal bool) {
+	if !ra.keyValueRateLimits() {
+		return
+	}
+	certNameLimits := ra.rlPolicies.CertificatesPerName()
+	if certNameLimits.Enabled() && !isRenewal {
+		tldNames, err := domainsForRateLimiting(names)
+		if err != nil {
+			ra.log.Warningf("Spending certificates per name limit for %q: %s", names, err)
+		}
+		for _, name := range tldNames {
+			_, err := ra.limiter.Spend(ctx, "certificatesPerName", certNameLimits, name, regID, 1)
+			if err != nil {
+				ra.log.Warningf("Spending certificates per name limit for %q: %s", name, err)
+			}
+		}
+	}
 	fqdnLimits := ra.rlPolicies.CertificatesPerFQDNSet()
 	if fqdnLimits.Enabled() {
-		err := ra.checkCertificatesPerFQDNSetLimit(ctx, names, fqdnLimits, regID)
+		key := strings.Join(core.UniqueLowerNames(names), ",")
+		_, err := ra.limiter.Spend(ctx, "certificatesPerFQDNSet", fqdnLimits, key, regID, 1)
 		if err != nil {
-			return err
+			ra.log.Warningf("Spending duplicate certificate limit for %q: %s", names, err)
 		}
 	}
-	return nil
 }
 
 // UpdateRegistration updates an existing Registration with new values. Caller
@@ -1428,6 +2307,12 @@ func (ra *RegistrationAuthorityImpl) UpdateRegistration(ctx context.Context, bas
 
 	err = ra.SA.UpdateRegistration(ctx, base)
 	if err != nil {
+		// A new key may have been claimed by another account since the WFE
+		// checked it, which the WFE reports as a conflict, so that error is
+		// passed through as it is.
+		if berrors.Is(err, berrors.Duplicate) {
+			return core.Registration{}, err
+		}
 		// berrors.InternalServerError since the user-data was validated before being
 		// passed to the SA.
 		err = berrors.InternalServerError("Could not update registration: %s", err)
@@ -1509,12 +2394,13 @@ func (ra *RegistrationAuthorityImpl) recordValidation(ctx context.Context, authI
 	}
 	status := string(challenge.Status)
 	ctype := string(challenge.Type)
-	var expires int64
+	var expires time.Time
 	if challenge.Status == core.StatusInvalid {
-		expires = authExpires.UnixNano()
+		expires = *authExpires
 	} else {
-		expires = ra.clk.Now().Add(ra.authorizationLifetime).UnixNano()
+		expires = ra.clk.Now().Add(ra.authorizationLifetime)
 	}
+	expiresNanos := expires.UnixNano()
 	vr, err := bgrpc.ValidationResultToPB(challenge.ValidationRecord, challenge.Error)
 	if err != nil {
 		return err
@@ -1522,7 +2408,8 @@ func (ra *RegistrationAuthorityImpl) recordValidation(ctx context.Context, authI
 	err = ra.SA.FinalizeAuthorization2(ctx, &sapb.FinalizeAuthorizationRequest{
 		Id:                &authzID,
 		Status:            &status,
-		Expires:           &expires,
+		Expires:           &expiresNanos,
+		ExpiresTime:       bgrpc.TimeToPB(expires),
 		Attempted:         &ctype,
 		ValidationRecords: vr.Records,
 		ValidationError:   vr.Problems,
@@ -1602,7 +2489,9 @@ func (ra *RegistrationAuthorityImpl) PerformValidation(
 	}
 
 	// Dispatch to the VA for service
-	vaCtx := context.Background()
+	// The validation outlives this request, but keeps its request ID so that
+	// the VA's and SA's logs of it can be correlated with the request.
+	vaCtx := requestid.WithID(context.Background(), requestid.FromContext(ctx))
 	go func(authz core.Authorization) {
 		// We will mutate challenges later in this goroutine to change status and
 		// add error, but we also return a copy of authz immediately. To avoid a
@@ -1627,13 +2516,13 @@ func (ra *RegistrationAuthorityImpl) PerformValidation(
 
 		if err != nil {
 			prob = probs.ServerInternal("Could not communicate with VA")
-			ra.log.AuditErrf("Could not communicate with VA: %s", err)
+			blog.ForContext(vaCtx, ra.log).AuditErrf("Could not communicate with VA: %s", err)
 		} else {
 			if res.Problems != nil {
 				prob, err = bgrpc.PBToProblemDetails(res.Problems)
 				if err != nil {
 					prob = probs.ServerInternal("Could not communicate with VA")
-					ra.log.AuditErrf("Could not communicate with VA: %s", err)
+					blog.ForContext(vaCtx, ra.log).AuditErrf("Could not communicate with VA: %s", err)
 				}
 			}
 
@@ -1661,11 +2550,89 @@ func (ra *RegistrationAuthorityImpl) PerformValidation(
 		authz.Challenges[challIndex] = *challenge
 
 		if err := ra.recordValidation(vaCtx, authz.ID, authz.Expires, challenge); err != nil {
-			ra.log.AuditErrf("Could not record updated validation: err=[%s] regID=[%d] authzID=[%s]",
+			blog.ForContext(vaCtx, ra.log).AuditErrf("Could not record updated validation: err=[%s] regID=[%d] authzID=[%s]",
 				err, authz.RegistrationID, authz.ID)
+		} else if challenge.Status == core.StatusInvalid {
+			ra.maybePauseIdentifier(vaCtx, authz.RegistrationID, authz.Identifier.Value)
 		}
 	}(authz)
-	return bgrpc.AuthzToPB(authz)
+
+	// The validation is now in flight, so the challenge is returned as
+	// processing (RFC 8555 Section 7.1.6). Its status isn't stored: the SA
+	// only records the challenge once its validation has completed.
+	authzPB, err := bgrpc.AuthzToPB(authz)
+	if err != nil {
+		return nil, err
+	}
+	processing := string(core.StatusProcessing)
+	authzPB.Challenges[challIndex].Status = &processing
+	return authzPB, nil
+}
+
+// maybePauseIdentifier pauses name for the account regID if the account has
+// accumulated at least autoPauseThreshold failed authorizations for it within
+// autoPauseWindow. Errors are logged rather than returned because this runs
+// after a validation has already completed.
+func (ra *RegistrationAuthorityImpl) maybePauseIdentifier(ctx context.Context, regID int64, name string) {
+	if !features.Enabled(features.PausedIdentifiers) || ra.autoPauseThreshold <= 0 {
+		return
+	}
+	if ra.autoPauseAllowed(regID, name) {
+		ra.autoPauseAllowedCounter.Inc()
+		return
+	}
+	latest := ra.clk.Now().Add(ra.pendingAuthorizationLifetime)
+	earliest := latest.Add(-ra.autoPauseWindow)
+	count, err := ra.SA.CountInvalidAuthorizations2(ctx, &sapb.CountInvalidAuthorizationsRequest{
+		RegistrationID: &regID,
+		Hostname:       &name,
+		Range:          bgrpc.RangeToPB(earliest, latest),
+	})
+	if err != nil {
+		ra.log.Errf("Counting failed authorizations to consider pausing: regID=[%d] name=[%s] err=[%s]", regID, name, err)
+		return
+	}
+	if *count.Count < ra.autoPauseThreshold {
+		return
+	}
+	pauseReq := &sapb.PauseRequest{
+		RegistrationID: &regID,
+		Names:          []string{name},
+	}
+	if ra.autoPauseDuration > 0 {
+		expires := ra.clk.Now().Add(ra.autoPauseDuration)
+		expiresNanos := expires.UnixNano()
+		pauseReq.Expires = &expiresNanos
+		pauseReq.ExpiresTime = bgrpc.TimeToPB(expires)
+	}
+	_, err = ra.SA.PauseIdentifiers(ctx, pauseReq)
+	if err != nil {
+		blog.ForContext(ctx, ra.log).AuditErrf("Could not pause identifier: regID=[%d] name=[%s] err=[%s]", regID, name, err)
+		return
+	}
+	ra.pausedIdentifiersCounter.Inc()
+	blog.ForContext(ctx, ra.log).AuditInfof("Paused identifier after %d failed authorizations: regID=[%d] name=[%s]", *count.Count, regID, name)
+}
+
+// checkPausedIdentifiers returns a paused error if any of names is paused for
+// the account regID.
+func (ra *RegistrationAuthorityImpl) checkPausedIdentifiers(ctx context.Context, regID int64, names []string) error {
+	if !features.Enabled(features.PausedIdentifiers) {
+		return nil
+	}
+	paused, err := ra.SA.GetPausedIdentifiers(ctx, &sapb.PauseRequest{
+		RegistrationID: &regID,
+		Names:          names,
+	})
+	if err != nil {
+		return err
+	}
+	if len(paused.Names) > 0 {
+		return berrors.PausedError(
+			"issuance for %s is paused for this account after repeated failed validations",
+			strings.Join(paused.Names, ", "))
+	}
+	return nil
 }
 
 func revokeEvent(state, serial, cn string, names []string, revocationCode revocation.Reason) string {
@@ -1679,11 +2646,30 @@ func revokeEvent(state, serial, cn string, names []string, revocationCode revoca
 	)
 }
 
+// crlShardIdx returns the index of the CRL shard which lists cert, as named by
+// its CRL distribution point, which the CA builds by appending "<index>.crl" to
+// the issuer's CRL URL base. A certificate without a sharded distribution
+// point, including those from issuers which don't shard their CRLs, is on
+// shard zero.
+func crlShardIdx(cert *x509.Certificate) int64 {
+	if len(cert.CRLDistributionPoints) == 0 {
+		return 0
+	}
+	dp := cert.CRLDistributionPoints[0]
+	name := strings.TrimSuffix(dp[strings.LastIndex(dp, "/")+1:], ".crl")
+	shardIdx, err := strconv.ParseInt(name, 10, 64)
+	if err != nil || shardIdx < 0 {
+		return 0
+	}
+	return shardIdx
+}
+
 // revokeCertificate generates a revoked OCSP response for the given certificate, stores
 // the revocation information, and purges OCSP request URLs from Akamai.
 func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, cert x509.Certificate, code revocation.Reason, revokedBy int64, source string, comment string) error {
 	reason := int32(code)
-	revokedAt := ra.clk.Now().UnixNano()
+	now := ra.clk.Now()
+	revokedAt := now.UnixNano()
 	ocspResponse, err := ra.CA.GenerateOCSP(ctx, &capb.GenerateOCSPRequest{
 		CertDER:   cert.Raw,
 		Status:    string(core.OCSPStatusRevoked),
@@ -1697,12 +2683,22 @@ func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, cert
 	// for some reason we use int32 and int64 for the reason in different
 	// protobuf messages, so we have to re-cast it here.
 	reason64 := int64(code)
-	err = ra.SA.RevokeCertificate(ctx, &sapb.RevokeCertificateRequest{
+	shardIdx := crlShardIdx(&cert)
+	revokeReq := &sapb.RevokeCertificateRequest{
 		Serial:   &serial,
 		Reason:   &reason64,
 		Date:     &revokedAt,
+		DateTime: bgrpc.TimeToPB(now),
 		Response: ocspResponse.Response,
-	})
+		ShardIdx: &shardIdx,
+	}
+	// The SA records the revocation against this issuer if the certificate's
+	// status predates issuer IDs being stored.
+	if ra.issuer != nil && cert.CheckSignatureFrom(ra.issuer) == nil {
+		issuerID := core.IssuerID(ra.issuer)
+		revokeReq.IssuerID = &issuerID
+	}
+	err = ra.SA.RevokeCertificate(ctx, revokeReq)
 	if err != nil {
 		return err
 	}
@@ -1712,9 +2708,10 @@ func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, cert
 			return err
 		}
 		req := &sapb.AddBlockedKeyRequest{
-			KeyHash: digest[:],
-			Added:   &revokedAt,
-			Source:  &source,
+			KeyHash:   digest[:],
+			Added:     &revokedAt,
+			AddedTime: bgrpc.TimeToPB(now),
+			Source:    &source,
 		}
 		if comment != "" {
 			req.Comment = &comment
@@ -1738,8 +2735,23 @@ func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, cert
 	return nil
 }
 
-// RevokeCertificateWithReg terminates trust in the certificate provided.
+// RevokeCertificateWithReg terminates trust in the certificate provided. If
+// it's revoked for keyCompromise and the PropagateKeyCompromise feature is
+// enabled, so is every other unexpired certificate with the same key.
 func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, regID int64) error {
+	err := ra.revokeCertificateWithReg(ctx, cert, revocationCode, regID)
+	if err != nil {
+		return err
+	}
+	if revocationCode == ocsp.KeyCompromise && features.Enabled(features.PropagateKeyCompromise) {
+		ra.revokeCertsSharingKey(ctx, &cert)
+	}
+	return nil
+}
+
+// revokeCertificateWithReg terminates trust in the certificate provided, on
+// behalf of the registration regID, and audit logs the result.
+func (ra *RegistrationAuthorityImpl) revokeCertificateWithReg(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, regID int64) error {
 	serialString := core.SerialToString(cert.SerialNumber)
 	err := ra.revokeCertificate(ctx, cert, revocationCode, regID, "API", "")
 
@@ -1752,7 +2764,7 @@ func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Contex
 		//   Revocation reason
 		//   Registration ID of requester
 		//   Error (if there was one)
-		ra.log.AuditInfof("%s, Request by registration ID: %d",
+		blog.ForContext(ctx, ra.log).AuditInfof("%s, Request by registration ID: %d",
 			revokeEvent(state, serialString, cert.Subject.CommonName, cert.DNSNames, revocationCode),
 			regID)
 	}()
@@ -1767,14 +2779,229 @@ func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Contex
 	return nil
 }
 
+// RevokeCertByKey revokes a certificate, with reason keyCompromise, on behalf
+// of a requester who has proven possession of its private key. The key is
+// added to the blocked keys list, and every other unexpired certificate with
+// the same key is revoked too. Failures to revoke those other certificates are
+// logged rather than returned; because the key is blocked, the bad-key-revoker
+// will still find them.
+func (ra *RegistrationAuthorityImpl) RevokeCertByKey(ctx context.Context, req *rapb.RevokeCertByKeyRequest) (*corepb.Empty, error) {
+	if req == nil || req.Cert == nil {
+		return nil, berrors.InternalServerError("incomplete revoke cert by key request")
+	}
+	cert, err := x509.ParseCertificate(req.Cert)
+	if err != nil {
+		return nil, berrors.MalformedError("unable to parse certificate: %s", err)
+	}
+	err = ra.revokeCertificateWithReg(ctx, *cert, ocsp.KeyCompromise, 0)
+	if err != nil {
+		return nil, err
+	}
+	ra.revokeCertsSharingKey(ctx, cert)
+	return &corepb.Empty{}, nil
+}
+
+// revokeCertsSharingKey revokes, with reason keyCompromise, every unexpired
+// and unrevoked certificate other than cert which has the same public key, and
+// audit logs which were revoked. Failures are logged rather than returned,
+// since the key has already been blocked.
+func (ra *RegistrationAuthorityImpl) revokeCertsSharingKey(ctx context.Context, cert *x509.Certificate) {
+	compromisedSerial := core.SerialToString(cert.SerialNumber)
+	var revoked []string
+	defer func() {
+		blog.ForContext(ctx, ra.log).AuditInfof("Revoked %d certificates sharing a compromised key: serial=[%s] revoked=[%s]",
+			len(revoked), compromisedSerial, strings.Join(revoked, ","))
+	}()
+	keyHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	var serials []string
+	err := ra.SA.GetSerialsByKeyHash(ctx, &sapb.SPKIHash{KeyHash: keyHash[:]}, func(serial string) error {
+		serials = append(serials, serial)
+		return nil
+	})
+	if err != nil {
+		blog.ForContext(ctx, ra.log).AuditErrf("Could not find certificates sharing a compromised key: serial=[%s] err=[%s]",
+			core.SerialToString(cert.SerialNumber), err)
+		return
+	}
+	for _, serial := range serials {
+		if serial == core.SerialToString(cert.SerialNumber) {
+			continue
+		}
+		status, err := ra.SA.GetCertificateStatus(ctx, serial)
+		if err != nil {
+			blog.ForContext(ctx, ra.log).AuditErrf("Could not get status of certificate sharing a compromised key: serial=[%s] err=[%s]", serial, err)
+			continue
+		}
+		if status.Status == core.OCSPStatusRevoked {
+			continue
+		}
+		other, err := ra.certificateForSerial(ctx, serial)
+		if err != nil {
+			blog.ForContext(ctx, ra.log).AuditErrf("Could not get certificate sharing a compromised key: serial=[%s] err=[%s]", serial, err)
+			continue
+		}
+		// revokeCertificateWithReg audit logs its own failures.
+		if ra.revokeCertificateWithReg(ctx, *other, ocsp.KeyCompromise, 0) == nil {
+			revoked = append(revoked, serial)
+		}
+	}
+}
+
+// certificateForSerial returns the certificate with the given serial, or its
+// precertificate if the certificate was only issued as a precertificate or its
+// DER has been pruned.
+func (ra *RegistrationAuthorityImpl) certificateForSerial(ctx context.Context, serial string) (*x509.Certificate, error) {
+	var der []byte
+	stored, err := ra.SA.GetCertificate(ctx, serial)
+	if err == nil && len(stored.DER) > 0 {
+		der = stored.DER
+	} else if err == nil || berrors.Is(err, berrors.NotFound) {
+		var precert *corepb.Certificate
+		precert, err = ra.SA.GetPrecertificate(ctx, &sapb.Serial{Serial: &serial})
+		if err == nil {
+			der = precert.Der
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
 // AdministrativelyRevokeCertificate terminates trust in the certificate provided and
 // does not require the registration ID of the requester since this method is only
 // called from the admin-revoker tool.
 func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, user string) error {
+	return ra.administrativelyRevokeCertificate(ctx, cert, revocationCode, user, 0)
+}
+
+// AdministrativelyRevokeCertificates revokes, on behalf of the admin-revoker,
+// the certificate with each serial returned by recv until it returns io.EOF,
+// no faster than the rate set by SetAdminRevocationRate. The reason code,
+// admin name and incident ID are taken from the first request. The incident
+// must already exist in the SA's incidents table, and each serial revoked, or
+// found to be already revoked, is recorded as affected by it. Certificates
+// which are already revoked are skipped, and those which can't be revoked are
+// audit logged and listed in the response rather than ending the stream, so
+// that one bad serial doesn't end a mass revocation.
+func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificates(ctx context.Context, recv func() (*rapb.AdministrativelyRevokeCertificatesRequest, error)) (*rapb.AdministrativelyRevokeCertificatesResponse, error) {
+	perSecond := ra.adminRevocationsPerSecond
+	if perSecond <= 0 {
+		perSecond = defaultAdminRevocationsPerSecond
+	}
+
+	var revoked, alreadyRevoked int64
+	var failed []string
+	req, err := recv()
+	if err == io.EOF {
+		return &rapb.AdministrativelyRevokeCertificatesResponse{
+			Revoked:        &revoked,
+			AlreadyRevoked: &alreadyRevoked,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	code := revocation.Reason(req.GetCode())
+	if _, ok := revocation.ReasonToString[code]; !ok {
+		return nil, berrors.MalformedError("invalid revocation reason code %d", req.GetCode())
+	}
+	adminName := req.GetAdminName()
+	incidentID := req.GetIncidentID()
+	if adminName == "" || incidentID <= 0 {
+		return nil, berrors.MalformedError("mass revocations must name the admin and incident responsible")
+	}
+	_, err = ra.SA.GetIncident(ctx, &sapb.GetIncidentRequest{Id: &incidentID})
+	if err != nil {
+		if berrors.Is(err, berrors.NotFound) {
+			return nil, berrors.MalformedError("unknown incident %d", incidentID)
+		}
+		return nil, err
+	}
+	defer func() {
+		blog.ForContext(ctx, ra.log).AuditInfof("Mass revocation for incident %d: reason=[%s] admin=[%s] revoked=[%d] alreadyRevoked=[%d] failed=[%s]",
+			incidentID, revocation.ReasonToString[code], adminName, revoked, alreadyRevoked, strings.Join(failed, ","))
+	}()
+
+	start := ra.clk.Now()
+	for n := int64(1); ; n++ {
+		serial := req.GetSerial()
+		wasRevoked, err := ra.administrativelyRevokeSerial(ctx, serial, code, adminName, incidentID)
+		if err != nil {
+			blog.ForContext(ctx, ra.log).AuditErrf("Could not revoke certificate for incident %d: serial=[%s] err=[%s]", incidentID, serial, err)
+			failed = append(failed, serial)
+		} else {
+			if wasRevoked {
+				alreadyRevoked++
+			} else {
+				revoked++
+			}
+			// A serial which is revoked but not recorded is listed as failed,
+			// so that rerunning the revocation records it.
+			_, err = ra.SA.AddIncidentSerial(ctx, &sapb.AddIncidentSerialRequest{
+				IncidentID: &incidentID,
+				Serial:     &serial,
+			})
+			if err != nil {
+				blog.ForContext(ctx, ra.log).AuditErrf("Could not record certificate as affected by incident %d: serial=[%s] err=[%s]", incidentID, serial, err)
+				failed = append(failed, serial)
+			}
+		}
+
+		req, err = recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Wait until the next serial is due, given how many have been handled
+		// since the stream started.
+		due := start.Add(time.Duration(n) * time.Second / time.Duration(perSecond))
+		if wait := due.Sub(ra.clk.Now()); wait > 0 {
+			ra.clk.Sleep(wait)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return &rapb.AdministrativelyRevokeCertificatesResponse{
+		Revoked:        &revoked,
+		AlreadyRevoked: &alreadyRevoked,
+		FailedSerials:  failed,
+	}, nil
+}
+
+// administrativelyRevokeSerial revokes the certificate with the given serial
+// for the given incident, unless it has already been revoked, in which case it
+// returns true.
+func (ra *RegistrationAuthorityImpl) administrativelyRevokeSerial(ctx context.Context, serial string, code revocation.Reason, user string, incidentID int64) (bool, error) {
+	status, err := ra.SA.GetCertificateStatus(ctx, serial)
+	if err != nil {
+		return false, err
+	}
+	if status.Status == core.OCSPStatusRevoked {
+		return true, nil
+	}
+	cert, err := ra.certificateForSerial(ctx, serial)
+	if err != nil {
+		return false, err
+	}
+	return false, ra.administrativelyRevokeCertificate(ctx, *cert, code, user, incidentID)
+}
+
+// administrativelyRevokeCertificate terminates trust in the certificate
+// provided on behalf of the admin-revoker user, recording the incident which
+// caused it unless incidentID is zero.
+func (ra *RegistrationAuthorityImpl) administrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, user string, incidentID int64) error {
 	serialString := core.SerialToString(cert.SerialNumber)
 	// TODO(#4774): allow setting the comment via the RPC, format should be:
 	// "revoked by %s: %s", user, comment
-	err := ra.revokeCertificate(ctx, cert, revocationCode, 0, "admin-revoker", fmt.Sprintf("revoked by %s", user))
+	comment := fmt.Sprintf("revoked by %s", user)
+	if incidentID != 0 {
+		comment += fmt.Sprintf(" for incident %d", incidentID)
+	}
+	err := ra.revokeCertificate(ctx, cert, revocationCode, 0, "admin-revoker", comment)
 
 	state := "Failure"
 	defer func() {
@@ -1785,9 +3012,13 @@ func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificate(ctx conte
 		//   Revocation reason
 		//   Name of admin-revoker user
 		//   Error (if there was one)
-		ra.log.AuditInfof("%s, admin-revoker user: %s",
+		event := fmt.Sprintf("%s, admin-revoker user: %s",
 			revokeEvent(state, serialString, cert.Subject.CommonName, cert.DNSNames, revocationCode),
 			user)
+		if incidentID != 0 {
+			event += fmt.Sprintf(", incident: %d", incidentID)
+		}
+		blog.ForContext(ctx, ra.log).AuditInfo(event)
 	}()
 
 	if err != nil {
@@ -1797,9 +3028,178 @@ func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificate(ctx conte
 
 	ra.revocationReasonCounter.WithLabelValues(revocation.ReasonToString[revocationCode]).Inc()
 	state = "Success"
+	if ra.revocationWebhookClient != nil {
+		// Subscribers' webhooks may be slow or unreachable, so notify them in
+		// the background rather than holding up the revocation.
+		notifyCtx, cancel := context.WithTimeout(detachedContext{ctx}, ra.revocationWebhookTimeout)
+		ra.revocationNotifications.Add(1)
+		go func() {
+			defer ra.revocationNotifications.Done()
+			defer cancel()
+			ra.notifyRevocationWebhook(notifyCtx, cert, revocationCode)
+		}()
+	}
+	if revocationCode == ocsp.KeyCompromise && features.Enabled(features.PropagateKeyCompromise) {
+		ra.revokeCertsSharingKey(ctx, &cert)
+	}
 	return nil
 }
 
+// maxRevocationWebhookLength is the longest revocation webhook URL that can be
+// stored by the SA.
+const maxRevocationWebhookLength = 255
+
+// SetRevocationWebhook validates and stores the URL that is notified when one
+// of the registration's certificates is administratively revoked. An empty URL
+// removes the registration's webhook. If ValidateOnly is set the URL is
+// validated but not stored, so that the WFE can reject a bad webhook before
+// committing the rest of an account update.
+func (ra *RegistrationAuthorityImpl) SetRevocationWebhook(ctx context.Context, req *rapb.SetRevocationWebhookRequest) (*corepb.Empty, error) {
+	if req == nil || req.RegistrationID == nil || req.Url == nil {
+		return nil, berrors.InternalServerError("incomplete revocation webhook request")
+	}
+	if ra.revocationWebhookClient == nil {
+		return nil, berrors.MalformedError("revocation webhooks are not supported")
+	}
+	if *req.Url != "" {
+		if err := ra.validateRevocationWebhook(*req.Url); err != nil {
+			return nil, err
+		}
+	}
+	if req.GetValidateOnly() {
+		return &corepb.Empty{}, nil
+	}
+	return ra.SA.SetRevocationWebhook(ctx, &sapb.RevocationWebhook{
+		RegistrationID: req.RegistrationID,
+		Url:            req.Url,
+	})
+}
+
+// UnpauseAccount unpauses all of the identifiers paused for an account, at the
+// request of the subscriber via the self-service frontend.
+func (ra *RegistrationAuthorityImpl) UnpauseAccount(ctx context.Context, req *rapb.UnpauseAccountRequest) (*corepb.Empty, error) {
+	if req == nil || req.RegistrationID == nil {
+		return nil, berrors.InternalServerError("incomplete unpause account request")
+	}
+	count, err := ra.SA.UnpauseAccount(ctx, &sapb.RegistrationID{Id: req.RegistrationID})
+	if err != nil {
+		return nil, err
+	}
+	blog.ForContext(ctx, ra.log).AuditInfof("Unpaused account: regID=[%d] identifiers=[%d]", *req.RegistrationID, *count.Count)
+	return &corepb.Empty{}, nil
+}
+
+// validateRevocationWebhook checks that a revocation webhook URL uses HTTPS on
+// the default port, and that its host is a public domain name we would be
+// willing to issue for.
+func (ra *RegistrationAuthorityImpl) validateRevocationWebhook(webhook string) error {
+	if len(webhook) > maxRevocationWebhookLength {
+		return berrors.MalformedError("revocation webhook is longer than %d characters", maxRevocationWebhookLength)
+	}
+	parsed, err := url.Parse(webhook)
+	if err != nil {
+		return berrors.MalformedError("revocation webhook is not a valid URL")
+	}
+	if parsed.Scheme != "https" {
+		return berrors.MalformedError("revocation webhook must use the https scheme")
+	}
+	if parsed.User != nil || parsed.Fragment != "" {
+		return berrors.MalformedError("revocation webhook must not contain userinfo or a fragment")
+	}
+	if parsed.Port() != "" && parsed.Port() != "443" {
+		return berrors.MalformedError("revocation webhook must use the default https port")
+	}
+	err = ra.PA.WillingToIssue(identifier.DNSIdentifier(parsed.Hostname()))
+	if err != nil {
+		return berrors.MalformedError("revocation webhook has an invalid host: %s", err)
+	}
+	return nil
+}
+
+// revocationNotification is the JSON body POSTed to a subscriber's revocation
+// webhook.
+type revocationNotification struct {
+	Serial    string    `json:"serial"`
+	Reason    string    `json:"reason"`
+	RevokedAt time.Time `json:"revokedAt"`
+	// ReplaceBy is the time by which the subscriber should have replaced the
+	// revoked certificate.
+	ReplaceBy time.Time `json:"replaceBy"`
+}
+
+// registrationForSerial returns the ID of the registration that requested the
+// certificate or precertificate with the given serial.
+func (ra *RegistrationAuthorityImpl) registrationForSerial(ctx context.Context, serial string) (int64, error) {
+	cert, err := ra.SA.GetCertificate(ctx, serial)
+	if err == nil {
+		return cert.RegistrationID, nil
+	}
+	if !berrors.Is(err, berrors.NotFound) {
+		return 0, err
+	}
+	precert, err := ra.SA.GetPrecertificate(ctx, &sapb.Serial{Serial: &serial})
+	if err != nil {
+		return 0, err
+	}
+	return precert.GetRegistrationID(), nil
+}
+
+// notifyRevocationWebhook POSTs a revocationNotification to the revocation
+// webhook of the subscriber who requested cert, if they have configured one.
+// Failures are logged but don't affect the revocation.
+func (ra *RegistrationAuthorityImpl) notifyRevocationWebhook(ctx context.Context, cert x509.Certificate, code revocation.Reason) {
+	if ra.revocationWebhookClient == nil {
+		return
+	}
+	serial := core.SerialToString(cert.SerialNumber)
+	regID, err := ra.registrationForSerial(ctx, serial)
+	if err != nil {
+		ra.log.Warningf("looking up registration for revocation webhook: serial=[%s] err=[%s]", serial, err)
+		ra.revocationWebhookCounter.WithLabelValues("failed").Inc()
+		return
+	}
+	webhook, err := ra.SA.GetRevocationWebhook(ctx, &sapb.RegistrationID{Id: &regID})
+	if err != nil {
+		if !berrors.Is(err, berrors.NotFound) {
+			ra.log.Warningf("looking up revocation webhook: regID=[%d] err=[%s]", regID, err)
+			ra.revocationWebhookCounter.WithLabelValues("failed").Inc()
+		}
+		return
+	}
+	revokedAt := ra.clk.Now().UTC()
+	body, err := json.Marshal(revocationNotification{
+		Serial:    serial,
+		Reason:    revocation.ReasonToString[code],
+		RevokedAt: revokedAt,
+		ReplaceBy: revokedAt.Add(ra.revocationWebhookReplaceWithin),
+	})
+	if err != nil {
+		ra.log.Warningf("marshaling revocation notification: serial=[%s] err=[%s]", serial, err)
+		ra.revocationWebhookCounter.WithLabelValues("failed").Inc()
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.GetUrl(), bytes.NewReader(body))
+	if err != nil {
+		ra.log.Warningf("creating revocation webhook request: regID=[%d] err=[%s]", regID, err)
+		ra.revocationWebhookCounter.WithLabelValues("failed").Inc()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := ra.revocationWebhookClient.Do(req.WithContext(ctx))
+	if err != nil {
+		ra.log.Infof("revocation webhook failed: regID=[%d] serial=[%s] err=[%s]", regID, serial, err)
+		ra.revocationWebhookCounter.WithLabelValues("failed").Inc()
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		ra.log.Infof("revocation webhook failed: regID=[%d] serial=[%s] status=[%d]", regID, serial, resp.StatusCode)
+		ra.revocationWebhookCounter.WithLabelValues("failed").Inc()
+		return
+	}
+	ra.revocationWebhookCounter.WithLabelValues("sent").Inc()
+}
+
 // DeactivateRegistration deactivates a valid registration
 func (ra *RegistrationAuthorityImpl) DeactivateRegistration(ctx context.Context, reg core.Registration) error {
 	if reg.Status != core.StatusValid {
@@ -1834,7 +3234,7 @@ func (ra *RegistrationAuthorityImpl) DeactivateAuthorization(ctx context.Context
 func (ra *RegistrationAuthorityImpl) checkOrderNames(names []string) error {
 	idents := make([]identifier.ACMEIdentifier, len(names))
 	for i, name := range names {
-		idents[i] = identifier.DNSIdentifier(name)
+		idents[i] = identifier.FromValue(name)
 	}
 	if err := ra.PA.WillingToIssueWildcards(idents); err != nil {
 		return err
@@ -1842,11 +3242,77 @@ func (ra *RegistrationAuthorityImpl) checkOrderNames(names []string) error {
 	return nil
 }
 
+// checkRequestedValidity checks the notBefore and notAfter, in nanoseconds
+// since the epoch, requested by a new order for a certificate of the given
+// profile, and returns the window to store with the order. Since the CA
+// doesn't backdate certificates any further than usual to honor a notBefore
+// which has passed, validity periods are measured from the later of notBefore
+// and now. Those longer than the profile's maximum are shortened if
+// clampRequestedValidity is set, and refused otherwise.
+func (ra *RegistrationAuthorityImpl) checkRequestedValidity(profile string, notBefore, notAfter int64) (validityWindow, error) {
+	maxValidity, ok := ra.maxRequestedValidity[profile]
+	if !ok {
+		return validityWindow{}, berrors.MalformedError("NotBefore and NotAfter are not supported for the requested certificate profile")
+	}
+
+	now := ra.clk.Now()
+	start := now
+	if notBefore != 0 {
+		requested := time.Unix(0, notBefore)
+		if requested.After(now.Add(ra.orderLifetime)) {
+			return validityWindow{}, berrors.MalformedError("NotBefore must be before the order expires")
+		}
+		if requested.After(now) {
+			start = requested
+		}
+	}
+	if notAfter == 0 {
+		return validityWindow{notBefore: notBefore}, nil
+	}
+
+	requested := time.Unix(0, notAfter)
+	if !requested.After(start) {
+		return validityWindow{}, berrors.MalformedError("NotAfter must be in the future and after NotBefore")
+	}
+	if validity := requested.Sub(start); validity > maxValidity {
+		if !ra.clampRequestedValidity {
+			return validityWindow{}, berrors.MalformedError(
+				"Requested validity period of %s exceeds the maximum of %s", validity, maxValidity)
+		}
+		notAfter = start.Add(maxValidity).UnixNano()
+	}
+	return validityWindow{notBefore: notBefore, notAfter: notAfter}, nil
+}
+
 // NewOrder creates a new order object
 func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.NewOrderRequest) (*corepb.Order, error) {
 	order := &corepb.Order{
-		RegistrationID: req.RegistrationID,
-		Names:          core.UniqueLowerNames(req.Names),
+		RegistrationID:         req.RegistrationID,
+		Names:                  core.UniqueLowerNames(req.Names),
+		CertificateProfileName: req.CertificateProfileName,
+		Replaces:               req.Replaces,
+	}
+
+	// The SA only stores an order's certificate profile when the feature is
+	// enabled, so without it the requested profile would be silently dropped.
+	if order.GetCertificateProfileName() != "" && !features.Enabled(features.CertificateProfiles) {
+		return nil, berrors.MalformedError("Certificate profiles are not supported")
+	}
+
+	if req.GetNotBefore() != 0 || req.GetNotAfter() != 0 {
+		if !features.Enabled(features.OrderValidityWindows) {
+			return nil, berrors.MalformedError("NotBefore and NotAfter are not supported")
+		}
+		window, err := ra.checkRequestedValidity(order.GetCertificateProfileName(), req.GetNotBefore(), req.GetNotAfter())
+		if err != nil {
+			return nil, err
+		}
+		if window.notBefore != 0 {
+			order.NotBefore = &window.notBefore
+		}
+		if window.notAfter != 0 {
+			order.NotAfter = &window.notAfter
+		}
 	}
 
 	if len(order.Names) > ra.maxNames {
@@ -1863,6 +3329,10 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 		return nil, err
 	}
 
+	if err := ra.checkPausedIdentifiers(ctx, *order.RegistrationID, order.Names); err != nil {
+		return nil, err
+	}
+
 	// See if there is an existing unexpired pending (or ready) order that can be reused
 	// for this account
 	useV2Authzs := true
@@ -1876,19 +3346,35 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	if err != nil && !berrors.Is(err, berrors.NotFound) {
 		return nil, err
 	}
-	// If there was an order, return it
-	if existingOrder != nil {
+	// If there was an order, return it, unless it was created for a different
+	// certificate profile, to replace a different certificate, or with a
+	// different validity window, than the one being requested now
+	if existingOrder != nil &&
+		existingOrder.GetCertificateProfileName() == order.GetCertificateProfileName() &&
+		existingOrder.GetReplaces() == order.GetReplaces() &&
+		existingOrder.GetNotBefore() == order.GetNotBefore() &&
+		existingOrder.GetNotAfter() == order.GetNotAfter() {
 		return existingOrder, nil
 	}
 
-	// Check if there is rate limit space for a new order within the current window
-	if err := ra.checkNewOrdersPerAccountLimit(ctx, *order.RegistrationID); err != nil {
+	// Check if there is rate limit space for a new order within the current
+	// window. ARI renewals, which the WFE has verified replace an earlier
+	// certificate of the account's, are exempt.
+	isARIRenewal := order.GetReplaces() != ""
+	if isARIRenewal {
+		ra.rateLimitCounter.WithLabelValues("new_order_by_registration_id", "ARI renewal bypass").Inc()
+	} else if err := ra.checkNewOrdersPerAccountLimit(ctx, *order.RegistrationID); err != nil {
 		return nil, err
 	}
 	// Check if there is rate limit space for issuing a certificate for the new
 	// order's names. If there isn't then it doesn't make sense to allow creating
 	// an order - it will just fail when finalization checks the same limits.
-	if err := ra.checkLimits(ctx, order.Names, *order.RegistrationID); err != nil {
+	if _, err := ra.checkLimits(ctx, order.Names, *order.RegistrationID, isARIRenewal); err != nil {
+		return nil, err
+	}
+	// Give the anti-abuse hook, if configured, the final say on whether the
+	// order may be created.
+	if err := ra.checkAntiAbuse(ctx, *order.RegistrationID, order.Names); err != nil {
 		return nil, err
 	}
 
@@ -1899,14 +3385,16 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	// expiring very soon.
 	// To prevent this we only return authorizations that are at least 1 day away
 	// from expiring.
-	authzExpiryCutoff := ra.clk.Now().AddDate(0, 0, 1).UnixNano()
+	authzExpiryCutoff := ra.clk.Now().AddDate(0, 0, 1)
+	authzExpiryCutoffNanos := authzExpiryCutoff.UnixNano()
 
 	// We do not want any legacy V1 API authorizations not associated with an
 	// order to be returned from the SA so we set requireV2Authzs to true
 	requireV2Authzs := true
 	getAuthReq := &sapb.GetAuthorizationsRequest{
 		RegistrationID:  order.RegistrationID,
-		Now:             &authzExpiryCutoff,
+		Now:             &authzExpiryCutoffNanos,
+		NowTime:         bgrpc.TimeToPB(authzExpiryCutoff),
 		Domains:         order.Names,
 		RequireV2Authzs: &requireV2Authzs,
 	}
@@ -1983,10 +3471,7 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	// authorization for each.
 	var newAuthzs []*corepb.Authorization
 	for _, name := range missingAuthzNames {
-		pb, err := ra.createPendingAuthz(ctx, *order.RegistrationID, identifier.ACMEIdentifier{
-			Type:  identifier.DNS,
-			Value: name,
-		})
+		pb, err := ra.createPendingAuthz(ctx, *order.RegistrationID, identifier.FromValue(name))
 		if err != nil {
 			return nil, err
 		}
@@ -2014,15 +3499,19 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 		}
 	}
 
-	// If new authorizations are needed, call AddPendingAuthorizations. Also check
-	// whether the newly created pending authz's have an expiry lower than minExpiry
+	// If new authorizations are needed, call AddPendingAuthorizations, unless
+	// they're to be created along with the order. Also check whether the newly
+	// created pending authz's have an expiry lower than minExpiry
+	streamline := features.Enabled(features.StreamlineOrderAndAuthzs)
 	if len(newAuthzs) > 0 {
-		req := sapb.AddPendingAuthorizationsRequest{Authz: newAuthzs}
-		authzIDs, err := ra.SA.NewAuthorizations2(ctx, &req)
-		if err != nil {
-			return nil, err
+		if !streamline {
+			req := sapb.AddPendingAuthorizationsRequest{Authz: newAuthzs}
+			authzIDs, err := ra.SA.NewAuthorizations2(ctx, &req)
+			if err != nil {
+				return nil, err
+			}
+			order.V2Authorizations = append(order.V2Authorizations, authzIDs.Ids...)
 		}
-		order.V2Authorizations = append(order.V2Authorizations, authzIDs.Ids...)
 		// If the newly created pending authz's have an expiry closer than the
 		// minExpiry the minExpiry is the pending authz expiry.
 		newPendingAuthzExpires := ra.clk.Now().Add(ra.pendingAuthorizationLifetime)
@@ -2039,7 +3528,15 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	// Set the order's expiry to the minimum expiry
 	minExpiryTS := minExpiry.UnixNano()
 	order.Expires = &minExpiryTS
-	storedOrder, err := ra.SA.NewOrder(ctx, order)
+	var storedOrder *corepb.Order
+	if streamline {
+		storedOrder, err = ra.SA.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{
+			NewOrder:  order,
+			NewAuthzs: newAuthzs,
+		})
+	} else {
+		storedOrder, err = ra.SA.NewOrder(ctx, order)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package ratelimit
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -21,6 +22,23 @@ type Limits interface {
 	PendingOrdersPerAccount() RateLimitPolicy
 	NewOrdersPerAccount() RateLimitPolicy
 	LoadPolicies(contents []byte) error
+	SetOverrides(overrides []Override) error
+}
+
+// Override sets the threshold of a single rate limit for one account or key,
+// in addition to the overrides in the policy file. Overrides are stored in the
+// database so that they can be added and expired without a redeploy.
+type Override struct {
+	// LimitName is the limit's name in the policy file, e.g.
+	// "certificatesPerName".
+	LimitName string
+	// RegistrationID is the account the override applies to, or zero if it
+	// applies to Key instead.
+	RegistrationID int64
+	// Key is the key the override applies to, such as a domain for
+	// certificatesPerName, if RegistrationID is zero.
+	Key       string
+	Threshold int
 }
 
 // limitsImpl is an unexported implementation of the Limits interface. It acts
@@ -29,7 +47,11 @@ type Limits interface {
 // changes (e.g. due to a reload of the policy file)
 type limitsImpl struct {
 	sync.RWMutex
-	rlPolicy *rateLimitConfig
+	// loaded is the policy file's contents, and rlPolicy is loaded with
+	// overrides applied, which is what the policies are read from.
+	rlPolicy  *rateLimitConfig
+	loaded    *rateLimitConfig
+	overrides []Override
 }
 
 func (r *limitsImpl) CertificatesPerName() RateLimitPolicy {
@@ -114,11 +136,73 @@ func (r *limitsImpl) LoadPolicies(contents []byte) error {
 	}
 
 	r.Lock()
-	r.rlPolicy = &newPolicy
+	r.loaded = &newPolicy
+	r.rlPolicy = applyOverrides(r.loaded, r.overrides)
+	r.Unlock()
+	return nil
+}
+
+// SetOverrides replaces the overrides applied on top of the policy file's,
+// which they take precedence over. If several overrides apply to the same
+// account or key, the last one given is used.
+func (r *limitsImpl) SetOverrides(overrides []Override) error {
+	for _, o := range overrides {
+		if !ValidLimitName(o.LimitName) {
+			return fmt.Errorf("override for unknown rate limit %q", o.LimitName)
+		}
+		if (o.RegistrationID == 0) == (o.Key == "") {
+			return fmt.Errorf("override for %q must have exactly one of a registration ID or a key", o.LimitName)
+		}
+	}
+
+	r.Lock()
+	r.overrides = overrides
+	r.rlPolicy = applyOverrides(r.loaded, r.overrides)
 	r.Unlock()
 	return nil
 }
 
+// applyOverrides returns a copy of policy with overrides added to its
+// policies' Overrides and RegistrationOverrides. The maps of policies with no
+// overrides are shared with policy, rather than copied.
+func applyOverrides(policy *rateLimitConfig, overrides []Override) *rateLimitConfig {
+	if policy == nil || len(overrides) == 0 {
+		return policy
+	}
+	merged := *policy
+	byName := merged.policiesByName()
+	copied := make(map[string]bool)
+	for _, o := range overrides {
+		rlp := byName[o.LimitName]
+		if !copied[o.LimitName] {
+			keyOverrides := make(map[string]int, len(rlp.Overrides))
+			for k, v := range rlp.Overrides {
+				keyOverrides[k] = v
+			}
+			regOverrides := make(map[int64]int, len(rlp.RegistrationOverrides))
+			for k, v := range rlp.RegistrationOverrides {
+				regOverrides[k] = v
+			}
+			rlp.Overrides = keyOverrides
+			rlp.RegistrationOverrides = regOverrides
+			copied[o.LimitName] = true
+		}
+		if o.RegistrationID != 0 {
+			rlp.RegistrationOverrides[o.RegistrationID] = o.Threshold
+		} else {
+			rlp.Overrides[o.Key] = o.Threshold
+		}
+	}
+	return &merged
+}
+
+// ValidLimitName returns true if name is the name of a rate limit in the
+// policy file.
+func ValidLimitName(name string) bool {
+	_, ok := (&rateLimitConfig{}).policiesByName()[name]
+	return ok
+}
+
 func New() Limits {
 	return &limitsImpl{}
 }
@@ -159,6 +243,21 @@ type rateLimitConfig struct {
 	CertificatesPerFQDNSet RateLimitPolicy `yaml:"certificatesPerFQDNSet"`
 }
 
+// policiesByName returns pointers to each of c's policies, keyed by their
+// names in the policy file.
+func (c *rateLimitConfig) policiesByName() map[string]*RateLimitPolicy {
+	return map[string]*RateLimitPolicy{
+		"certificatesPerName":             &c.CertificatesPerName,
+		"registrationsPerIP":              &c.RegistrationsPerIP,
+		"registrationsPerIPRange":         &c.RegistrationsPerIPRange,
+		"pendingAuthorizationsPerAccount": &c.PendingAuthorizationsPerAccount,
+		"invalidAuthorizationsPerAccount": &c.InvalidAuthorizationsPerAccount,
+		"pendingOrdersPerAccount":         &c.PendingOrdersPerAccount,
+		"newOrdersPerAccount":             &c.NewOrdersPerAccount,
+		"certificatesPerFQDNSet":          &c.CertificatesPerFQDNSet,
+	}
+}
+
 // RateLimitPolicy describes a general limiting policy
 type RateLimitPolicy struct {
 	// How long to count items for
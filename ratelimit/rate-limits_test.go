@@ -178,3 +178,53 @@ func TestLoadPolicies(t *testing.T) {
 	test.AssertEquals(t, emptyPolicy.PendingAuthorizationsPerAccount().Threshold, 0)
 	test.AssertEquals(t, emptyPolicy.CertificatesPerFQDNSet().Threshold, 0)
 }
+
+func TestSetOverrides(t *testing.T) {
+	policy := New()
+
+	// Overrides set before any policy is loaded are applied once it is.
+	err := policy.SetOverrides([]Override{
+		{LimitName: "certificatesPerName", Key: "ratelimit.me", Threshold: 50},
+		{LimitName: "certificatesPerName", RegistrationID: 202, Threshold: 20},
+		{LimitName: "newOrdersPerAccount", RegistrationID: 202, Threshold: 30},
+		{LimitName: "newOrdersPerAccount", RegistrationID: 202, Threshold: 40},
+	})
+	test.AssertNotError(t, err, "SetOverrides failed")
+	test.AssertEquals(t, policy.CertificatesPerName().Threshold, 0)
+
+	policyContent, err := ioutil.ReadFile("../test/rate-limit-policies.yml")
+	test.AssertNotError(t, err, "Failed to load rate-limit-policies.yml")
+	err = policy.LoadPolicies(policyContent)
+	test.AssertNotError(t, err, "Failed to parse rate-limit-policies.yml")
+
+	certsPerName := policy.CertificatesPerName()
+	test.AssertEquals(t, certsPerName.GetThreshold("ratelimit.me", 0), 50)
+	test.AssertEquals(t, certsPerName.GetThreshold("le.wtf", 0), 10000)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 202), 20)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 101), 1000)
+	// The last override for the same account is used.
+	newOrdersPerAccount := policy.NewOrdersPerAccount()
+	test.AssertEquals(t, newOrdersPerAccount.GetThreshold("", 202), 40)
+	// Limits without overrides are unaffected.
+	test.AssertDeepEquals(t, policy.RegistrationsPerIP().Overrides, map[string]int{
+		"127.0.0.1": 1000000,
+	})
+
+	// Replacing the overrides removes those no longer present, without
+	// affecting the policy file's.
+	err = policy.SetOverrides(nil)
+	test.AssertNotError(t, err, "SetOverrides failed")
+	certsPerName = policy.CertificatesPerName()
+	test.AssertEquals(t, certsPerName.GetThreshold("ratelimit.me", 0), 1)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 202), 2)
+
+	err = policy.SetOverrides([]Override{{LimitName: "certificatesPerFish", Key: "cod", Threshold: 1}})
+	test.AssertError(t, err, "SetOverrides accepted an unknown limit")
+	err = policy.SetOverrides([]Override{{LimitName: "certificatesPerName", Threshold: 1}})
+	test.AssertError(t, err, "SetOverrides accepted an override with no registration ID or key")
+	err = policy.SetOverrides([]Override{{LimitName: "certificatesPerName", RegistrationID: 1, Key: "example.com", Threshold: 1}})
+	test.AssertError(t, err, "SetOverrides accepted an override with both a registration ID and a key")
+
+	test.Assert(t, ValidLimitName("certificatesPerFQDNSet"), "certificatesPerFQDNSet isn't a valid limit name")
+	test.Assert(t, !ValidLimitName("CertificatesPerFQDNSet"), "CertificatesPerFQDNSet is a valid limit name")
+}
@@ -0,0 +1,103 @@
+package ratelimits
+
+import (
+	"time"
+
+	"github.com/jmhodges/clock"
+)
+
+// limit is a rate limit expressed in the terms the Generic Cell Rate
+// Algorithm uses: count requests are allowed per period, and up to burst of
+// them may be made at once.
+type limit struct {
+	burst  int64
+	count  int64
+	period time.Duration
+}
+
+// emissionInterval is the time it takes for one request's worth of capacity to
+// be restored.
+func (l limit) emissionInterval() time.Duration {
+	return l.period / time.Duration(l.count)
+}
+
+// burstOffset is the time it takes for the whole burst to be restored.
+func (l limit) burstOffset() time.Duration {
+	return l.emissionInterval() * time.Duration(l.burst)
+}
+
+// Decision is the result of checking or spending against a rate limit.
+type Decision struct {
+	// Allowed is whether the request was within the limit.
+	Allowed bool
+	// Remaining is the number of requests which could be made immediately
+	// after this one.
+	Remaining int64
+	// RetryIn is how long to wait before the request would be allowed. It is
+	// zero for allowed requests.
+	RetryIn time.Duration
+	// ResetIn is how long it will take for the full burst to be available
+	// again.
+	ResetIn time.Duration
+
+	// newTAT is the bucket's theoretical arrival time once the request has
+	// been made, which is what's stored for the bucket when spending.
+	newTAT time.Time
+}
+
+// maybeSpend decides whether a request of the given cost is allowed by l for
+// a bucket whose theoretical arrival time (TAT) is tat. The TAT is the time
+// at which the bucket would be full again were no further requests made.
+func maybeSpend(clk clock.Clock, l limit, tat time.Time, cost int64) *Decision {
+	now := clk.Now()
+	if tat.Before(now) {
+		tat = now
+	}
+	emissionInterval := l.emissionInterval()
+	burstOffset := l.burstOffset()
+
+	newTAT := tat.Add(emissionInterval * time.Duration(cost))
+	// The request is allowed if, having made it, the bucket would be no more
+	// than a burst's worth of time from being full.
+	diff := now.Sub(newTAT.Add(-burstOffset))
+	if diff < 0 {
+		return &Decision{
+			Allowed:   false,
+			Remaining: int64(now.Sub(tat.Add(-burstOffset)) / emissionInterval),
+			RetryIn:   -diff,
+			ResetIn:   tat.Sub(now),
+			newTAT:    tat,
+		}
+	}
+	return &Decision{
+		Allowed:   true,
+		Remaining: int64(diff / emissionInterval),
+		ResetIn:   newTAT.Sub(now),
+		newTAT:    newTAT,
+	}
+}
+
+// maybeRefund returns up to cost requests' worth of capacity to a bucket whose
+// TAT is tat, never making it more than full.
+func maybeRefund(clk clock.Clock, l limit, tat time.Time, cost int64) *Decision {
+	now := clk.Now()
+	if !tat.After(now) {
+		// The bucket is already full.
+		return &Decision{
+			Allowed:   true,
+			Remaining: l.burst,
+			newTAT:    tat,
+		}
+	}
+	emissionInterval := l.emissionInterval()
+	newTAT := tat.Add(-emissionInterval * time.Duration(cost))
+	if newTAT.Before(now) {
+		newTAT = now
+	}
+	return &Decision{
+		Allowed:   true,
+		Remaining: int64(now.Sub(newTAT.Add(-l.burstOffset())) / emissionInterval),
+		ResetIn:   newTAT.Sub(now),
+		newTAT:    newTAT,
+	}
+}
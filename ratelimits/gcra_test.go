@@ -0,0 +1,61 @@
+package ratelimits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestMaybeSpend(t *testing.T) {
+	clk := clock.NewFake()
+	// 10 requests per second, all of which may be made at once.
+	l := limit{burst: 10, count: 10, period: time.Second}
+
+	// A full bucket allows the whole burst.
+	d := maybeSpend(clk, l, clk.Now(), 1)
+	test.Assert(t, d.Allowed, "first request denied")
+	test.AssertEquals(t, d.Remaining, int64(9))
+	test.AssertEquals(t, d.ResetIn, 100*time.Millisecond)
+	d = maybeSpend(clk, l, d.newTAT, 9)
+	test.Assert(t, d.Allowed, "rest of burst denied")
+	test.AssertEquals(t, d.Remaining, int64(0))
+	test.AssertEquals(t, d.ResetIn, time.Second)
+
+	// Once the bucket is empty, requests are denied until capacity is
+	// restored, and denials don't change the bucket.
+	tat := d.newTAT
+	d = maybeSpend(clk, l, tat, 1)
+	test.Assert(t, !d.Allowed, "request allowed from empty bucket")
+	test.AssertEquals(t, d.RetryIn, 100*time.Millisecond)
+	test.AssertEquals(t, d.newTAT, tat)
+	clk.Add(100 * time.Millisecond)
+	d = maybeSpend(clk, l, tat, 1)
+	test.Assert(t, d.Allowed, "request denied after capacity was restored")
+	test.AssertEquals(t, d.Remaining, int64(0))
+
+	// A TAT in the past is a full bucket.
+	clk.Add(time.Hour)
+	d = maybeSpend(clk, l, tat, 1)
+	test.Assert(t, d.Allowed, "request from full bucket denied")
+	test.AssertEquals(t, d.Remaining, int64(9))
+}
+
+func TestMaybeRefund(t *testing.T) {
+	clk := clock.NewFake()
+	l := limit{burst: 10, count: 10, period: time.Second}
+
+	d := maybeSpend(clk, l, clk.Now(), 5)
+	test.AssertEquals(t, d.Remaining, int64(5))
+	d = maybeRefund(clk, l, d.newTAT, 2)
+	test.AssertEquals(t, d.Remaining, int64(7))
+
+	// Refunds can't overfill the bucket.
+	d = maybeRefund(clk, l, d.newTAT, 20)
+	test.AssertEquals(t, d.Remaining, int64(10))
+	test.AssertEquals(t, d.newTAT, clk.Now())
+	d = maybeRefund(clk, l, d.newTAT, 1)
+	test.AssertEquals(t, d.Remaining, int64(10))
+}
@@ -0,0 +1,171 @@
+// Package ratelimits enforces rate limits with the Generic Cell Rate
+// Algorithm (GCRA), storing only a single timestamp for each bucket in a
+// key-value Source, rather than counting rows in the database as the
+// ratelimit package's limits do.
+package ratelimits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/ratelimit"
+)
+
+// Limiter checks and spends requests against rate limits whose buckets are
+// stored in a Source.
+//
+// Spending reads a bucket's TAT and then writes the new one, so concurrent
+// spends against the same bucket may both be allowed where only one should
+// be. Limits are a defense against abuse rather than a precise quota, so this
+// is tolerated in exchange for not needing transactions from the Source.
+type Limiter struct {
+	source    Source
+	clk       clock.Clock
+	decisions *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+}
+
+// NewLimiter returns a Limiter which stores its buckets in source.
+func NewLimiter(clk clock.Clock, source Source, stats prometheus.Registerer) *Limiter {
+	decisions := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ratelimits_decisions",
+		Help: "Number of rate limit decisions made, by limit and whether the request was allowed",
+	}, []string{"limit", "decision"})
+	stats.MustRegister(decisions)
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "ratelimits_source_latency_seconds",
+		Help: "Latency of rate limit Source calls, by call and result",
+	}, []string{"call", "result"})
+	stats.MustRegister(latency)
+	return &Limiter{
+		source:    source,
+		clk:       clk,
+		decisions: decisions,
+		latency:   latency,
+	}
+}
+
+// limitFor converts a policy, with any override for key or regID, into a
+// GCRA limit which allows the policy's threshold of requests at once, and
+// restores that many over the policy's window.
+func limitFor(policy ratelimit.RateLimitPolicy, key string, regID int64) limit {
+	threshold := int64(policy.GetThreshold(key, regID))
+	return limit{burst: threshold, count: threshold, period: policy.Window.Duration}
+}
+
+// bucketKey is the Source key of the bucket for key under the named limit.
+func bucketKey(name, key string) string {
+	return name + ":" + key
+}
+
+// getTAT returns the stored TAT of a bucket, or the current time if the bucket
+// is full.
+func (l *Limiter) getTAT(ctx context.Context, bucket string) (time.Time, error) {
+	start := l.clk.Now()
+	tat, err := l.source.Get(ctx, bucket)
+	result := "success"
+	if err == ErrBucketNotFound {
+		tat, err = l.clk.Now(), nil
+		result = "notFound"
+	} else if err != nil {
+		result = "error"
+	}
+	l.latency.WithLabelValues("get", result).Observe(l.clk.Since(start).Seconds())
+	return tat, err
+}
+
+func (l *Limiter) setTAT(ctx context.Context, bucket string, tat time.Time) error {
+	start := l.clk.Now()
+	err := l.source.Set(ctx, bucket, tat)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	l.latency.WithLabelValues("set", result).Observe(l.clk.Since(start).Seconds())
+	return err
+}
+
+// decide applies the named limit's policy to a request of the given cost for
+// key, storing the bucket's new TAT if spend is true and the request is
+// allowed. Requests against a disabled policy are always allowed.
+func (l *Limiter) decide(ctx context.Context, name string, policy ratelimit.RateLimitPolicy, key string, regID int64, cost int64, spend bool) (*Decision, error) {
+	if cost <= 0 {
+		return nil, fmt.Errorf("invalid cost %d for %s limit", cost, name)
+	}
+	if !policy.Enabled() {
+		return &Decision{Allowed: true}, nil
+	}
+	rl := limitFor(policy, key, regID)
+	if rl.count <= 0 || cost > rl.burst {
+		// An override of zero, or a request larger than the whole burst, can
+		// never be allowed.
+		l.decisions.WithLabelValues(name, "denied").Inc()
+		return &Decision{Allowed: false}, nil
+	}
+
+	bucket := bucketKey(name, key)
+	tat, err := l.getTAT(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	d := maybeSpend(l.clk, rl, tat, cost)
+	if !d.Allowed {
+		l.decisions.WithLabelValues(name, "denied").Inc()
+		return d, nil
+	}
+	l.decisions.WithLabelValues(name, "allowed").Inc()
+	if spend {
+		err = l.setTAT(ctx, bucket, d.newTAT)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Check returns whether a request of the given cost for key would be allowed
+// by the named limit's policy, without spending anything.
+func (l *Limiter) Check(ctx context.Context, name string, policy ratelimit.RateLimitPolicy, key string, regID int64, cost int64) (*Decision, error) {
+	return l.decide(ctx, name, policy, key, regID, cost, false)
+}
+
+// Spend returns whether a request of the given cost for key is allowed by the
+// named limit's policy and, if it is, deducts the cost from key's bucket.
+func (l *Limiter) Spend(ctx context.Context, name string, policy ratelimit.RateLimitPolicy, key string, regID int64, cost int64) (*Decision, error) {
+	return l.decide(ctx, name, policy, key, regID, cost, true)
+}
+
+// Refund returns up to cost requests' worth of capacity to key's bucket under
+// the named limit, for when a request which was spent for didn't go ahead.
+func (l *Limiter) Refund(ctx context.Context, name string, policy ratelimit.RateLimitPolicy, key string, regID int64, cost int64) (*Decision, error) {
+	if cost <= 0 {
+		return nil, fmt.Errorf("invalid cost %d for %s limit", cost, name)
+	}
+	if !policy.Enabled() {
+		return &Decision{Allowed: true}, nil
+	}
+	rl := limitFor(policy, key, regID)
+	if rl.count <= 0 {
+		return &Decision{Allowed: true}, nil
+	}
+	bucket := bucketKey(name, key)
+	tat, err := l.getTAT(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	d := maybeRefund(l.clk, rl, tat, cost)
+	err = l.setTAT(ctx, bucket, d.newTAT)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Reset makes key's bucket under the named limit full again.
+func (l *Limiter) Reset(ctx context.Context, name, key string) error {
+	return l.source.Delete(ctx, bucketKey(name, key))
+}
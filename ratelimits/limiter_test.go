@@ -0,0 +1,78 @@
+package ratelimits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake()
+	limiter := NewLimiter(clk, NewInmemSource(), metrics.NoopRegisterer)
+	policy := ratelimit.RateLimitPolicy{
+		Threshold: 2,
+		Window:    cmd.ConfigDuration{Duration: time.Hour},
+		Overrides: map[string]int{"blocked.com": 0},
+	}
+
+	// Checking doesn't spend.
+	for i := 0; i < 3; i++ {
+		d, err := limiter.Check(ctx, "test", policy, "example.com", 1, 1)
+		test.AssertNotError(t, err, "Check failed")
+		test.Assert(t, d.Allowed, "check denied")
+		test.AssertEquals(t, d.Remaining, int64(1))
+	}
+
+	for i := 0; i < 2; i++ {
+		d, err := limiter.Spend(ctx, "test", policy, "example.com", 1, 1)
+		test.AssertNotError(t, err, "Spend failed")
+		test.Assert(t, d.Allowed, "spend denied")
+	}
+	d, err := limiter.Spend(ctx, "test", policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, !d.Allowed, "spend over the limit allowed")
+	test.AssertEquals(t, d.RetryIn, 30*time.Minute)
+
+	// Other keys and limits have their own buckets.
+	d, err = limiter.Spend(ctx, "test", policy, "example.net", 1, 1)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, d.Allowed, "spend for another key denied")
+	d, err = limiter.Spend(ctx, "other", policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, d.Allowed, "spend for another limit denied")
+
+	// Refunds restore capacity, as does a reset.
+	_, err = limiter.Refund(ctx, "test", policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Refund failed")
+	d, err = limiter.Spend(ctx, "test", policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, d.Allowed, "spend after refund denied")
+	err = limiter.Reset(ctx, "test", "example.com")
+	test.AssertNotError(t, err, "Reset failed")
+	d, err = limiter.Spend(ctx, "test", policy, "example.com", 1, 2)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, d.Allowed, "spend after reset denied")
+
+	// A zero override, or a cost larger than the limit, is always denied.
+	d, err = limiter.Spend(ctx, "test", policy, "blocked.com", 1, 1)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, !d.Allowed, "spend with zero override allowed")
+	d, err = limiter.Spend(ctx, "test", policy, "example.org", 1, 3)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, !d.Allowed, "spend larger than the limit allowed")
+	_, err = limiter.Spend(ctx, "test", policy, "example.org", 1, 0)
+	test.AssertError(t, err, "Spend with zero cost didn't fail")
+
+	// Disabled policies allow everything.
+	d, err = limiter.Spend(ctx, "test", ratelimit.RateLimitPolicy{}, "blocked.com", 1, 100)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, d.Allowed, "spend against disabled policy denied")
+}
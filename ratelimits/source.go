@@ -0,0 +1,61 @@
+package ratelimits
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBucketNotFound is returned by a Source which has no TAT stored for a
+// bucket, which means the bucket is full.
+var ErrBucketNotFound = errors.New("bucket not found")
+
+// Source is a key-value store of the theoretical arrival time (TAT) of each
+// rate limit bucket. In production it's backed by Redis, which is shared by
+// every instance enforcing the limits, so that their counts agree.
+type Source interface {
+	// Get returns the TAT stored for bucketKey, or ErrBucketNotFound.
+	Get(ctx context.Context, bucketKey string) (time.Time, error)
+	// Set stores tat as the TAT for bucketKey.
+	Set(ctx context.Context, bucketKey string, tat time.Time) error
+	// Delete removes the TAT for bucketKey, making the bucket full.
+	Delete(ctx context.Context, bucketKey string) error
+}
+
+// inmem is a Source which keeps TATs in memory. Its counts are neither shared
+// between processes nor persisted, so it's only suitable for tests and for
+// running a single instance.
+type inmem struct {
+	sync.RWMutex
+	m map[string]time.Time
+}
+
+// NewInmemSource returns a Source which keeps TATs in memory.
+func NewInmemSource() Source {
+	return &inmem{m: make(map[string]time.Time)}
+}
+
+func (in *inmem) Get(_ context.Context, bucketKey string) (time.Time, error) {
+	in.RLock()
+	defer in.RUnlock()
+	tat, ok := in.m[bucketKey]
+	if !ok {
+		return time.Time{}, ErrBucketNotFound
+	}
+	return tat, nil
+}
+
+func (in *inmem) Set(_ context.Context, bucketKey string, tat time.Time) error {
+	in.Lock()
+	defer in.Unlock()
+	in.m[bucketKey] = tat
+	return nil
+}
+
+func (in *inmem) Delete(_ context.Context, bucketKey string) error {
+	in.Lock()
+	defer in.Unlock()
+	delete(in.m, bucketKey)
+	return nil
+}
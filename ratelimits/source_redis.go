@@ -0,0 +1,47 @@
+package ratelimits
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/jmhodges/clock"
+)
+
+// redisSource is a Source which keeps TATs in a ring of Redis servers, shared
+// by every instance enforcing the limits. Each TAT is stored as nanoseconds
+// since the epoch, and expires once it has passed, since the bucket is then
+// full and needn't be stored at all.
+type redisSource struct {
+	client *redis.Ring
+	clk    clock.Clock
+}
+
+// NewRedisSource returns a Source which keeps TATs in the Redis servers of
+// client.
+func NewRedisSource(client *redis.Ring, clk clock.Clock) Source {
+	return &redisSource{client: client, clk: clk}
+}
+
+func (r *redisSource) Get(ctx context.Context, bucketKey string) (time.Time, error) {
+	tatNano, err := r.client.WithContext(ctx).Get(bucketKey).Int64()
+	if err == redis.Nil {
+		return time.Time{}, ErrBucketNotFound
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, tatNano).UTC(), nil
+}
+
+func (r *redisSource) Set(ctx context.Context, bucketKey string, tat time.Time) error {
+	ttl := tat.Sub(r.clk.Now())
+	if ttl <= 0 {
+		// The bucket is already full.
+		return r.Delete(ctx, bucketKey)
+	}
+	return r.client.WithContext(ctx).Set(bucketKey, tat.UnixNano(), ttl).Err()
+}
+
+func (r *redisSource) Delete(ctx context.Context, bucketKey string) error {
+	return r.client.WithContext(ctx).Del(bucketKey).Err()
+}
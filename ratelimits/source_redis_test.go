@@ -0,0 +1,49 @@
+package ratelimits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+func TestRedisSource(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.New()
+	ring := redis.NewRing(&redis.RingOptions{
+		Addrs: map[string]string{"shard1": vars.RedisAddr},
+	})
+	defer ring.Close()
+	source := NewRedisSource(ring, clk)
+
+	bucketKey := "test:" + t.Name()
+	err := source.Delete(ctx, bucketKey)
+	test.AssertNotError(t, err, "Delete failed")
+	_, err = source.Get(ctx, bucketKey)
+	test.AssertEquals(t, err, ErrBucketNotFound)
+
+	tat := clk.Now().Add(time.Hour).Truncate(time.Nanosecond).UTC()
+	err = source.Set(ctx, bucketKey, tat)
+	test.AssertNotError(t, err, "Set failed")
+	got, err := source.Get(ctx, bucketKey)
+	test.AssertNotError(t, err, "Get failed")
+	test.AssertEquals(t, got, tat)
+
+	// TATs which have already passed aren't stored, since the bucket is full.
+	err = source.Set(ctx, bucketKey, clk.Now().Add(-time.Second))
+	test.AssertNotError(t, err, "Set failed")
+	_, err = source.Get(ctx, bucketKey)
+	test.AssertEquals(t, err, ErrBucketNotFound)
+
+	err = source.Set(ctx, bucketKey, tat)
+	test.AssertNotError(t, err, "Set failed")
+	err = source.Delete(ctx, bucketKey)
+	test.AssertNotError(t, err, "Delete failed")
+	_, err = source.Get(ctx, bucketKey)
+	test.AssertEquals(t, err, ErrBucketNotFound)
+}
@@ -0,0 +1,42 @@
+// Package requestid generates the IDs which identify each ACME request as it
+// passes through Boulder's services. The WFE generates an ID for each request
+// it receives, which is carried in the context of every onward RPC and
+// included in audit log lines and the problem documents sent to clients, so
+// that an error reported by a subscriber can be found in every service's logs.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// New returns a random, hex encoded, request ID. It returns the empty string,
+// meaning no ID, if the system's randomness source fails.
+func New() string {
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// key is the context key under which a request ID is stored.
+type key struct{}
+
+// WithID returns a copy of ctx carrying the request ID id. If id is empty, ctx
+// is returned unchanged.
+func WithID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or the empty string if it
+// carries none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
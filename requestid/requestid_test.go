@@ -0,0 +1,22 @@
+package requestid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestRequestID(t *testing.T) {
+	id := New()
+	test.AssertEquals(t, len(id), 16)
+	test.AssertNotEquals(t, New(), id)
+
+	ctx := context.Background()
+	test.AssertEquals(t, FromContext(ctx), "")
+	test.AssertEquals(t, FromContext(WithID(ctx, id)), id)
+
+	// An empty ID leaves the context alone.
+	test.AssertEquals(t, WithID(ctx, ""), ctx)
+	test.AssertEquals(t, FromContext(WithID(WithID(ctx, id), "")), id)
+}
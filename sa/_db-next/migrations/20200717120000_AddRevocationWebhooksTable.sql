@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `revocationWebhooks` (
+    `registrationID` BIGINT(20) NOT NULL,
+    `url` VARCHAR(255) NOT NULL,
+    `updated` DATETIME NOT NULL,
+    PRIMARY KEY (`registrationID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `revocationWebhooks`;
@@ -0,0 +1,66 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- The archived tables hold copies of expired orders and authorizations after
+-- they have been removed from the tables used to serve requests, so that they
+-- remain available for investigations. Only the indexes needed to look rows up
+-- by account, name, or serial, and to purge old rows, are created.
+
+CREATE TABLE `archivedOrders` (
+    `id` BIGINT(20) NOT NULL,
+    `registrationID` BIGINT(20) NOT NULL,
+    `expires` DATETIME NOT NULL,
+    `error` MEDIUMBLOB DEFAULT NULL,
+    `certificateSerial` VARCHAR(255) DEFAULT NULL,
+    `beganProcessing` BOOL NOT NULL DEFAULT FALSE,
+    `created` DATETIME NOT NULL,
+    `certificateProfileName` VARCHAR(32) NOT NULL DEFAULT '',
+    `archived` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `regID_created_idx` (`registrationID`, `created`),
+    KEY `certificateSerial_idx` (`certificateSerial`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `archivedRequestedNames` (
+    `id` BIGINT(20) NOT NULL,
+    `orderID` BIGINT(20) NOT NULL,
+    `reversedName` VARCHAR(253) CHARACTER SET ascii NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `orderID_idx` (`orderID`),
+    KEY `reversedName_idx` (`reversedName`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `archivedOrderToAuthz2` (
+    `orderID` BIGINT(20) NOT NULL,
+    `authzID` BIGINT(20) NOT NULL,
+    PRIMARY KEY order_authz (`orderID`, `authzID`),
+    KEY `authzID` (`authzID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `archivedAuthz2` (
+    `id` BIGINT(20) NOT NULL,
+    `identifierType` TINYINT NOT NULL,
+    `identifierValue` VARCHAR(255) NOT NULL,
+    `registrationID` BIGINT(20) NOT NULL,
+    `status` TINYINT NOT NULL,
+    `expires` DATETIME NOT NULL,
+    `challenges` TINYINT NOT NULL,
+    `attempted` TINYINT DEFAULT NULL,
+    `attemptedAt` DATETIME DEFAULT NULL,
+    `token` BINARY(32) NOT NULL,
+    `validationError` MEDIUMBLOB DEFAULT NULL,
+    `validationRecord` MEDIUMBLOB DEFAULT NULL,
+    `archived` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `regID_identifier_idx` (`registrationID`, `identifierType`, `identifierValue`),
+    KEY `identifier_idx` (`identifierType`, `identifierValue`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `archivedOrders`;
+DROP TABLE `archivedRequestedNames`;
+DROP TABLE `archivedOrderToAuthz2`;
+DROP TABLE `archivedAuthz2`;
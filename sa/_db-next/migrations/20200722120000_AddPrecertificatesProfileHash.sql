@@ -0,0 +1,10 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE `precertificates` ADD COLUMN `profileHash` BINARY(32) DEFAULT NULL;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `precertificates` DROP COLUMN `profileHash`;
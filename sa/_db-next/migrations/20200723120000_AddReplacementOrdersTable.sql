@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `replacementOrders` (
+    `serial` VARCHAR(255) NOT NULL,
+    `orderID` BIGINT(20) NOT NULL,
+    PRIMARY KEY (`serial`),
+    KEY `orderID_idx` (`orderID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `replacementOrders`;
@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `externalAccountKeys` (
+    `keyID` VARCHAR(255) NOT NULL,
+    `hmacKey` VARBINARY(255) NOT NULL,
+    `created` DATETIME NOT NULL,
+    PRIMARY KEY (`keyID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `externalAccountKeys`;
@@ -0,0 +1,16 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `paused` (
+    `registrationID` BIGINT(20) NOT NULL,
+    `identifierValue` VARCHAR(255) NOT NULL,
+    `pausedAt` DATETIME NOT NULL,
+    `unpausedAt` DATETIME DEFAULT NULL,
+    PRIMARY KEY (`registrationID`, `identifierValue`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `paused`;
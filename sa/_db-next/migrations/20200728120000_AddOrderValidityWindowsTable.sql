@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `orderValidityWindows` (
+    `orderID` BIGINT(20) NOT NULL,
+    `notBefore` DATETIME DEFAULT NULL,
+    `notAfter` DATETIME DEFAULT NULL,
+    PRIMARY KEY (`orderID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `orderValidityWindows`;
@@ -0,0 +1,10 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE `replacementOrders` ADD COLUMN `replaced` BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `replacementOrders` DROP COLUMN `replaced`;
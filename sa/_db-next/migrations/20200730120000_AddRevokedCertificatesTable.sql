@@ -0,0 +1,21 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `revokedCertificates` (
+    `id` BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+    `issuerID` BIGINT(20) NOT NULL,
+    `serial` VARCHAR(255) NOT NULL,
+    `shardIdx` BIGINT(20) NOT NULL,
+    `notAfter` DATETIME NOT NULL,
+    `revokedDate` DATETIME NOT NULL,
+    `revokedReason` INT(11) NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `serial` (`serial`),
+    KEY `issuerID_shardIdx_notAfter_idx` (`issuerID`, `shardIdx`, `notAfter`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `revokedCertificates`;
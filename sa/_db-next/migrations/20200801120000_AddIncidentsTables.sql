@@ -0,0 +1,27 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `incidents` (
+    `id` BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+    `url` VARCHAR(1024) NOT NULL,
+    `renewBy` DATETIME NOT NULL,
+    `enabled` BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `incidentSerials` (
+    `incidentID` BIGINT(20) UNSIGNED NOT NULL,
+    `serial` VARCHAR(255) NOT NULL,
+    `registrationID` BIGINT(20) DEFAULT NULL,
+    `orderID` BIGINT(20) DEFAULT NULL,
+    `lastNoticeSent` DATETIME DEFAULT NULL,
+    PRIMARY KEY (`incidentID`, `serial`),
+    KEY `serial_idx` (`serial`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `incidentSerials`;
+DROP TABLE `incidents`;
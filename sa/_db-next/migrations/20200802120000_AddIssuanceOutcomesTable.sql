@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `issuanceOutcomes` (
+    `serial` VARCHAR(255) NOT NULL,
+    `registrationID` BIGINT(20) NOT NULL,
+    `precertIssued` DATETIME NOT NULL,
+    `outcome` TINYINT(4) NOT NULL,
+    `updated` DATETIME NOT NULL,
+    `detail` VARCHAR(255) DEFAULT NULL,
+    PRIMARY KEY (`serial`),
+    KEY `outcome_precertIssued_idx` (`outcome`, `precertIssued`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `issuanceOutcomes`;
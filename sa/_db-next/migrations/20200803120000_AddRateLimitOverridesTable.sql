@@ -0,0 +1,22 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `rateLimitOverrides` (
+    `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+    `limitName` VARCHAR(64) NOT NULL,
+    `registrationID` BIGINT(20) DEFAULT NULL,
+    `overrideKey` VARCHAR(255) DEFAULT NULL,
+    `threshold` INT(11) NOT NULL,
+    `reason` VARCHAR(255) NOT NULL,
+    `requester` VARCHAR(255) NOT NULL,
+    `created` DATETIME NOT NULL,
+    `expires` DATETIME NOT NULL,
+    `expiredBy` VARCHAR(255) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `expires_idx` (`expires`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `rateLimitOverrides`;
@@ -3,20 +3,54 @@ package sa
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/go-gorp/gorp/v3"
 	"github.com/go-sql-driver/mysql"
 
+	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	boulderDB "github.com/letsencrypt/boulder/db"
 	blog "github.com/letsencrypt/boulder/log"
 )
 
+// DbSettings holds the settings of a database connection pool.
+type DbSettings struct {
+	// MaxOpenConns is the maximum number of open connections. Zero means
+	// unlimited.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections kept open. Zero
+	// means database/sql's default of 2.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum amount of time a connection may be
+	// reused. Zero means forever.
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is the maximum amount of time a connection may be idle.
+	// Zero means forever.
+	ConnMaxIdleTime time.Duration
+}
+
+// DbSettingsFromDBConfig returns the connection pool settings of a DBConfig.
+func DbSettingsFromDBConfig(conf cmd.DBConfig) DbSettings {
+	return DbSettings{
+		MaxOpenConns:    conf.MaxDBConns,
+		MaxIdleConns:    conf.MaxIdleConns,
+		ConnMaxLifetime: conf.ConnMaxLifetime.Duration,
+		ConnMaxIdleTime: conf.ConnMaxIdleTime.Duration,
+	}
+}
+
 // NewDbMap creates a wrapped root gorp mapping object. Create one of these for
 // each database schema you wish to map. Each DbMap contains a list of mapped
 // tables. It automatically maps the tables for the primary parts of Boulder
 // around the Storage Authority.
 func NewDbMap(dbConnect string, maxOpenConns int) (*boulderDB.WrappedMap, error) {
+	return NewDbMapWithSettings(dbConnect, DbSettings{MaxOpenConns: maxOpenConns})
+}
+
+// NewDbMapWithSettings functions similarly to NewDbMap, but configures the
+// connection pool with all of the given settings.
+func NewDbMapWithSettings(dbConnect string, settings DbSettings) (*boulderDB.WrappedMap, error) {
 	var err error
 	var config *mysql.Config
 
@@ -25,7 +59,7 @@ func NewDbMap(dbConnect string, maxOpenConns int) (*boulderDB.WrappedMap, error)
 		return nil, err
 	}
 
-	return NewDbMapFromConfig(config, maxOpenConns)
+	return NewDbMapFromConfig(config, settings)
 }
 
 // sqlOpen is used in the tests to check that the arguments are properly
@@ -39,9 +73,9 @@ var setMaxOpenConns = func(db *sql.DB, maxOpenConns int) {
 	db.SetMaxOpenConns(maxOpenConns)
 }
 
-// NewDbMapFromConfig functions similarly to NewDbMap, but it takes the
-// decomposed form of the connection string, a *mysql.Config.
-func NewDbMapFromConfig(config *mysql.Config, maxOpenConns int) (*boulderDB.WrappedMap, error) {
+// NewDbMapFromConfig functions similarly to NewDbMapWithSettings, but it takes
+// the decomposed form of the connection string, a *mysql.Config.
+func NewDbMapFromConfig(config *mysql.Config, settings DbSettings) (*boulderDB.WrappedMap, error) {
 	adjustMySQLConfig(config)
 
 	db, err := sqlOpen("mysql", config.FormatDSN())
@@ -51,7 +85,12 @@ func NewDbMapFromConfig(config *mysql.Config, maxOpenConns int) (*boulderDB.Wrap
 	if err = db.Ping(); err != nil {
 		return nil, err
 	}
-	setMaxOpenConns(db, maxOpenConns)
+	setMaxOpenConns(db, settings.MaxOpenConns)
+	if settings.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(settings.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(settings.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(settings.ConnMaxIdleTime)
 
 	dialect := gorp.MySQLDialect{Engine: "InnoDB", Encoding: "UTF8"}
 	dbmap := &gorp.DbMap{Db: db, Dialect: dialect, TypeConverter: BoulderTypeConverter{}}
@@ -131,6 +170,7 @@ func initTables(dbMap *gorp.DbMap) {
 	dbMap.AddTableWithName(core.CRL{}, "crls").SetKeys(false, "Serial")
 	dbMap.AddTableWithName(core.FQDNSet{}, "fqdnSets").SetKeys(true, "ID")
 	dbMap.AddTableWithName(orderModel{}, "orders").SetKeys(true, "ID")
+	dbMap.AddTableWithName(orderModelv2{}, "orders").SetKeys(true, "ID")
 	dbMap.AddTableWithName(orderToAuthzModel{}, "orderToAuthz").SetKeys(false, "OrderID", "AuthzID")
 	dbMap.AddTableWithName(requestedNameModel{}, "requestedNames").SetKeys(false, "OrderID")
 	dbMap.AddTableWithName(orderFQDNSet{}, "orderFqdnSets").SetKeys(true, "ID")
@@ -138,5 +178,6 @@ func initTables(dbMap *gorp.DbMap) {
 	dbMap.AddTableWithName(orderToAuthzModel{}, "orderToAuthz2").SetKeys(false, "OrderID", "AuthzID")
 	dbMap.AddTableWithName(recordedSerialModel{}, "serials").SetKeys(true, "ID")
 	dbMap.AddTableWithName(precertificateModel{}, "precertificates").SetKeys(true, "ID")
+	dbMap.AddTableWithName(precertificateModelv2{}, "precertificates").SetKeys(true, "ID")
 	dbMap.AddTableWithName(keyHashModel{}, "keyHashToSerial").SetKeys(true, "ID")
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/letsencrypt/boulder/test"
 	"github.com/letsencrypt/boulder/test/vars"
@@ -36,6 +37,17 @@ func TestMaxOpenConns(t *testing.T) {
 	}
 }
 
+func TestDbSettings(t *testing.T) {
+	dbMap, err := NewDbMapWithSettings("sa@tcp(boulder-mysql:3306)/boulder_sa_integration", DbSettings{
+		MaxOpenConns:    100,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: time.Minute,
+		ConnMaxIdleTime: time.Second,
+	})
+	test.AssertNotError(t, err, "connecting to DB")
+	test.AssertEquals(t, dbMap.Db.Stats().MaxOpenConnections, 100)
+}
+
 func TestNewDbMap(t *testing.T) {
 	const mysqlConnectURL = "policy:password@tcp(boulder-mysql:3306)/boulder_policy_integration?readTimeout=800ms&writeTimeout=800ms"
 	const expected = "policy:password@tcp(boulder-mysql:3306)/boulder_policy_integration?clientFoundRows=true&parseTime=true&readTimeout=800ms&writeTimeout=800ms&long_query_time=0.6400000000000001&max_statement_time=0.76&sql_mode=STRICT_ALL_TABLES"
@@ -0,0 +1,84 @@
+package sa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// healthCheckInterval is how often the SA checks whether its databases
+	// can be used.
+	healthCheckInterval = 5 * time.Second
+	// healthCheckTimeout is how long each check may take before the database
+	// is considered unreachable.
+	healthCheckTimeout = 2 * time.Second
+)
+
+// HealthServices are the names under which the SA reports whether its primary
+// database is reachable and writable: the SA's gRPC service itself, and the
+// empty name for the server as a whole.
+var HealthServices = []string{"", "sa.StorageAuthority"}
+
+// ReplicasHealthService is the name under which the SA reports whether any of
+// its read replicas is reachable. Lag tolerant reads fall back to the primary
+// when none are, so it doesn't affect the SA's own status, but clients which
+// would rather not load the primary with them can check it.
+const ReplicasHealthService = "sa.ReadReplicas"
+
+// checkWritable returns an error if the database can't be reached, or is
+// read-only, as a primary demoted during a failover would be.
+func checkWritable(ctx context.Context, conn *sql.DB) error {
+	var readOnly bool
+	err := conn.QueryRowContext(ctx, "SELECT @@global.read_only").Scan(&readOnly)
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		return errors.New("database is read-only")
+	}
+	return nil
+}
+
+// checkHealth checks the SA's databases once, and sets the serving status of
+// its health services on srv accordingly.
+func (ssa *SQLStorageAuthority) checkHealth(srv *health.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	status := healthpb.HealthCheckResponse_SERVING
+	err := checkWritable(ctx, ssa.dbMap.Db)
+	if err != nil {
+		ssa.log.Warningf("Primary database failed health check: %s", err)
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	for _, service := range HealthServices {
+		srv.SetServingStatus(service, status)
+	}
+
+	if ssa.replicas == nil {
+		return
+	}
+	status = healthpb.HealthCheckResponse_NOT_SERVING
+	if ssa.replicas.anyReachable() {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	srv.SetServingStatus(ReplicasHealthService, status)
+}
+
+// StartHealthChecks makes the SA periodically check that its databases can be
+// used, and report the result through srv, an implementation of the gRPC
+// health checking protocol, so that clients can stop sending it RPCs while
+// they'd only fail or time out. It should be called after SetReadReplicas, if
+// that's called at all.
+func (ssa *SQLStorageAuthority) StartHealthChecks(srv *health.Server) {
+	ssa.checkHealth(srv)
+	go func() {
+		for range time.Tick(healthCheckInterval) {
+			ssa.checkHealth(srv)
+		}
+	}()
+}
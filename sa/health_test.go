@@ -0,0 +1,64 @@
+package sa
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	gorp "github.com/go-gorp/gorp/v3"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/letsencrypt/boulder/db"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// servingStatus returns the status srv reports for service.
+func servingStatus(t *testing.T, srv *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	test.AssertNotError(t, err, "checking health")
+	return resp.Status
+}
+
+func TestHealthUnreachable(t *testing.T) {
+	// Nothing listens on port 1, so the database can't be reached.
+	conn, err := sql.Open("mysql", "sa@tcp(127.0.0.1:1)/boulder_sa_integration")
+	test.AssertNotError(t, err, "opening DB")
+	defer conn.Close()
+	dbMap := &db.WrappedMap{DbMap: &gorp.DbMap{Db: conn}}
+	ssa := &SQLStorageAuthority{
+		dbMap:    dbMap,
+		log:      blog.NewMock(),
+		replicas: &replicaSet{primary: dbMap, replicas: []*replica{{dbMap: dbMap}}},
+	}
+
+	srv := health.NewServer()
+	ssa.checkHealth(srv)
+	for _, service := range HealthServices {
+		test.AssertEquals(t, servingStatus(t, srv, service), healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	test.AssertEquals(t, servingStatus(t, srv, ReplicasHealthService), healthpb.HealthCheckResponse_NOT_SERVING)
+
+	// A replica which can be reached is enough for the replicas to be serving,
+	// even while the primary isn't.
+	ssa.replicas.replicas = append(ssa.replicas.replicas, &replica{dbMap: dbMap, reachable: true})
+	ssa.checkHealth(srv)
+	test.AssertEquals(t, servingStatus(t, srv, "sa.StorageAuthority"), healthpb.HealthCheckResponse_NOT_SERVING)
+	test.AssertEquals(t, servingStatus(t, srv, ReplicasHealthService), healthpb.HealthCheckResponse_SERVING)
+}
+
+func TestHealthServing(t *testing.T) {
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	srv := health.NewServer()
+	sa.checkHealth(srv)
+	for _, service := range HealthServices {
+		test.AssertEquals(t, servingStatus(t, srv, service), healthpb.HealthCheckResponse_SERVING)
+	}
+	// Without replicas, their status isn't reported.
+	_, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ReplicasHealthService})
+	test.AssertError(t, err, "replicas' health reported without replicas")
+}
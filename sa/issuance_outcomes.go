@@ -0,0 +1,133 @@
+package sa
+
+import (
+	"context"
+	"time"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/db"
+	berrors "github.com/letsencrypt/boulder/errors"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+// The outcomes stored in the issuanceOutcomes table. A precertificate's row is
+// pending from its issuance until its final certificate is added, or
+// finalization is recorded as having failed.
+const (
+	outcomePending = 0
+	outcomeIssued  = 1
+	outcomeFailed  = 2
+)
+
+var outcomeNames = map[int64]string{
+	outcomePending: "pending",
+	outcomeIssued:  "issued",
+	outcomeFailed:  "failed",
+}
+
+// maxOutcomeDetailLength is the length of the issuanceOutcomes table's detail
+// column, in characters.
+const maxOutcomeDetailLength = 255
+
+// addPendingOutcome records that the precertificate with the given serial was
+// issued, and is awaiting its final certificate.
+func addPendingOutcome(tx db.Execer, serial string, regID int64, issued, now time.Time) error {
+	_, err := tx.Exec(
+		`INSERT INTO issuanceOutcomes (serial, registrationID, precertIssued, outcome, updated)
+		VALUES (?, ?, ?, ?, ?)`,
+		serial, regID, issued, outcomePending, now,
+	)
+	return err
+}
+
+// setIssuedOutcome records that the final certificate with the given serial
+// was issued. Precertificates added before outcomes were stored have no row to
+// update, which isn't an error.
+func setIssuedOutcome(tx db.Execer, serial string, now time.Time) error {
+	_, err := tx.Exec(
+		"UPDATE issuanceOutcomes SET outcome = ?, updated = ? WHERE serial = ?",
+		outcomeIssued, now, serial,
+	)
+	return err
+}
+
+// RecordIssuanceFailure records that no final certificate will be issued for
+// the precertificate with the given serial, along with why. Only a pending
+// outcome can be marked as failed, so a NotFound error is returned if the
+// precertificate has no outcome or its final certificate was already issued.
+func (ssa *SQLStorageAuthority) RecordIssuanceFailure(ctx context.Context, req *sapb.IssuanceFailure) (*corepb.Empty, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	detail := []rune(req.GetDetail())
+	if len(detail) > maxOutcomeDetailLength {
+		detail = detail[:maxOutcomeDetailLength]
+	}
+	result, err := ssa.dbMap.WithContext(ctx).Exec(
+		"UPDATE issuanceOutcomes SET outcome = ?, updated = ?, detail = ? WHERE serial = ? AND outcome = ?",
+		outcomeFailed, ssa.clk.Now(), string(detail), *req.Serial, outcomePending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, berrors.NotFoundError("no pending issuance outcome for serial %q", *req.Serial)
+	}
+	return &corepb.Empty{}, nil
+}
+
+// GetIssuanceOutcome returns the outcome recorded for the precertificate with
+// the given serial: whether its final certificate is still pending, was
+// issued, or failed, and if it failed, why. A NotFound error is returned if no
+// outcome was recorded.
+func (ssa *SQLStorageAuthority) GetIssuanceOutcome(ctx context.Context, req *sapb.Serial) (*sapb.IssuanceOutcome, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	var row struct {
+		Serial         string
+		RegistrationID int64
+		PrecertIssued  time.Time
+		Outcome        int64
+		Updated        time.Time
+		Detail         *string
+	}
+	err := ssa.dbMap.WithContext(ctx).SelectOne(
+		&row,
+		`SELECT serial, registrationID, precertIssued, outcome, updated, detail
+		FROM issuanceOutcomes
+		WHERE serial = ?`,
+		*req.Serial,
+	)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, berrors.NotFoundError("no issuance outcome for serial %q", *req.Serial)
+		}
+		return nil, err
+	}
+	outcome, ok := outcomeNames[row.Outcome]
+	if !ok {
+		return nil, berrors.InternalServerError("unknown issuance outcome %d for serial %q", row.Outcome, row.Serial)
+	}
+	precertIssued := row.PrecertIssued.UnixNano()
+	updated := row.Updated.UnixNano()
+	var detail string
+	if row.Detail != nil {
+		detail = *row.Detail
+	}
+	return &sapb.IssuanceOutcome{
+		Serial:            &row.Serial,
+		RegistrationID:    &row.RegistrationID,
+		PrecertIssued:     &precertIssued,
+		PrecertIssuedTime: bgrpc.TimeToPB(row.PrecertIssued),
+		Outcome:           &outcome,
+		Updated:           &updated,
+		UpdatedTime:       bgrpc.TimeToPB(row.Updated),
+		Detail:            &detail,
+	}, nil
+}
@@ -1,54 +1,67 @@
 package sa
 
 import (
+	"github.com/go-sql-driver/mysql"
 	"github.com/letsencrypt/boulder/db"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var (
-	maxOpenConns = prometheus.NewDesc(
-		"db_max_open_connections",
-		"Maximum number of DB connections allowed.",
-		nil, nil)
-
-	openConns = prometheus.NewDesc(
-		"db_open_connections",
-		"Number of established DB connections (in-use and idle).",
-		nil, nil)
-
-	inUse = prometheus.NewDesc(
-		"db_inuse",
-		"Number of DB connections currently in use.",
-		nil, nil)
-
-	idle = prometheus.NewDesc(
-		"db_idle",
-		"Number of idle DB connections.",
-		nil, nil)
-
-	waitCount = prometheus.NewDesc(
-		"db_wait_count",
-		"Total number of DB connections waited for.",
-		nil, nil)
-
-	waitDuration = prometheus.NewDesc(
-		"db_wait_duration_seconds",
-		"The total time blocked waiting for a new connection.",
-		nil, nil)
-
-	maxIdleClosed = prometheus.NewDesc(
-		"db_max_idle_closed",
-		"Total number of connections closed due to SetMaxIdleConns.",
-		nil, nil)
-
-	maxLifetimeClosed = prometheus.NewDesc(
-		"db_max_lifetime_closed",
-		"Total number of connections closed due to SetConnMaxLifetime.",
-		nil, nil)
-)
-
 type dbMetricsCollector struct {
 	dbMap *db.WrappedMap
+
+	maxOpenConns      *prometheus.Desc
+	openConns         *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+// newDBMetricsCollector returns a dbMetricsCollector whose metrics carry the
+// given constant labels, which identify the connection pool they describe.
+func newDBMetricsCollector(dbMap *db.WrappedMap, labels prometheus.Labels) dbMetricsCollector {
+	return dbMetricsCollector{
+		dbMap: dbMap,
+		maxOpenConns: prometheus.NewDesc(
+			"db_max_open_connections",
+			"Maximum number of DB connections allowed.",
+			nil, labels),
+		openConns: prometheus.NewDesc(
+			"db_open_connections",
+			"Number of established DB connections (in-use and idle).",
+			nil, labels),
+		inUse: prometheus.NewDesc(
+			"db_inuse",
+			"Number of DB connections currently in use.",
+			nil, labels),
+		idle: prometheus.NewDesc(
+			"db_idle",
+			"Number of idle DB connections.",
+			nil, labels),
+		waitCount: prometheus.NewDesc(
+			"db_wait_count",
+			"Total number of DB connections waited for.",
+			nil, labels),
+		waitDuration: prometheus.NewDesc(
+			"db_wait_duration_seconds",
+			"The total time blocked waiting for a new connection.",
+			nil, labels),
+		maxIdleClosed: prometheus.NewDesc(
+			"db_max_idle_closed",
+			"Total number of connections closed due to SetMaxIdleConns.",
+			nil, labels),
+		maxIdleTimeClosed: prometheus.NewDesc(
+			"db_max_idle_time_closed",
+			"Total number of connections closed due to SetConnMaxIdleTime.",
+			nil, labels),
+		maxLifetimeClosed: prometheus.NewDesc(
+			"db_max_lifetime_closed",
+			"Total number of connections closed due to SetConnMaxLifetime.",
+			nil, labels),
+	}
 }
 
 // Describe is implemented with DescribeByCollect. That's possible because the
@@ -76,21 +89,30 @@ func (dbc dbMetricsCollector) Collect(ch chan<- prometheus.Metric) {
 
 	// Translate the DBMap's db.DBStats counter values into Prometheus metrics.
 	dbMapStats := dbc.dbMap.Db.Stats()
-	writeGauge(maxOpenConns, float64(dbMapStats.MaxOpenConnections))
-	writeGauge(openConns, float64(dbMapStats.OpenConnections))
-	writeGauge(inUse, float64(dbMapStats.InUse))
-	writeGauge(idle, float64(dbMapStats.Idle))
-	writeCounter(waitCount, float64(dbMapStats.WaitCount))
-	writeCounter(waitDuration, dbMapStats.WaitDuration.Seconds())
-	writeCounter(maxIdleClosed, float64(dbMapStats.MaxIdleClosed))
-	writeCounter(maxLifetimeClosed, float64(dbMapStats.MaxLifetimeClosed))
+	writeGauge(dbc.maxOpenConns, float64(dbMapStats.MaxOpenConnections))
+	writeGauge(dbc.openConns, float64(dbMapStats.OpenConnections))
+	writeGauge(dbc.inUse, float64(dbMapStats.InUse))
+	writeGauge(dbc.idle, float64(dbMapStats.Idle))
+	writeCounter(dbc.waitCount, float64(dbMapStats.WaitCount))
+	writeCounter(dbc.waitDuration, dbMapStats.WaitDuration.Seconds())
+	writeCounter(dbc.maxIdleClosed, float64(dbMapStats.MaxIdleClosed))
+	writeCounter(dbc.maxIdleTimeClosed, float64(dbMapStats.MaxIdleTimeClosed))
+	writeCounter(dbc.maxLifetimeClosed, float64(dbMapStats.MaxLifetimeClosed))
 }
 
 // InitDBMetrics will register a Collector that translates the provided dbMap's
 // stats into Prometheus metrics on the fly. The stat values will be translated
-// from the gorp dbMap's inner sql.DBMap's DBStats structure values.
-func InitDBMetrics(dbMap *db.WrappedMap, stats prometheus.Registerer) {
+// from the gorp dbMap's inner sql.DBMap's DBStats structure values. The
+// metrics are labelled with the address, name and user of the database in
+// dbConnect, and with pool, so that the connection pools of a process with
+// several of them, like an SA with read replicas, can be told apart.
+func InitDBMetrics(dbMap *db.WrappedMap, stats prometheus.Registerer, dbConnect string, pool string) {
+	labels := prometheus.Labels{"address": "", "dbName": "", "user": "", "pool": pool}
+	if config, err := mysql.ParseDSN(dbConnect); err == nil {
+		labels["address"] = config.Addr
+		labels["dbName"] = config.DBName
+		labels["user"] = config.User
+	}
 	// Create a dbMetricsCollector and register it
-	dbc := dbMetricsCollector{dbMap}
-	stats.MustRegister(dbc)
+	stats.MustRegister(newDBMetricsCollector(dbMap, labels))
 }
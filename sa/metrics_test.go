@@ -0,0 +1,50 @@
+package sa
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/go-gorp/gorp/v3"
+	"github.com/letsencrypt/boulder/db"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestInitDBMetrics(t *testing.T) {
+	// sql.Open doesn't connect, so no database is needed to collect stats.
+	conn, err := sql.Open("mysql", "sa@tcp(boulder-mysql:3306)/boulder_sa_integration")
+	test.AssertNotError(t, err, "opening DB")
+	defer conn.Close()
+	conn.SetMaxOpenConns(7)
+	dbMap := &db.WrappedMap{DbMap: &gorp.DbMap{Db: conn}}
+
+	// The same database may be registered more than once, as long as each
+	// registration is for a different pool.
+	registry := prometheus.NewRegistry()
+	InitDBMetrics(dbMap, registry, "sa@tcp(boulder-mysql:3306)/boulder_sa_integration", "primary")
+	InitDBMetrics(dbMap, registry, "sa@tcp(boulder-mysql:3306)/boulder_sa_integration", "replica-0")
+
+	families, err := registry.Gather()
+	test.AssertNotError(t, err, "gathering metrics")
+	found := false
+	for _, family := range families {
+		if family.GetName() == "db_max_idle_time_closed" {
+			found = true
+		}
+		if family.GetName() != "db_max_open_connections" {
+			continue
+		}
+		test.AssertEquals(t, len(family.GetMetric()), 2)
+		for _, metric := range family.GetMetric() {
+			test.AssertEquals(t, metric.GetGauge().GetValue(), float64(7))
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			test.AssertEquals(t, labels["address"], "boulder-mysql:3306")
+			test.AssertEquals(t, labels["dbName"], "boulder_sa_integration")
+			test.AssertEquals(t, labels["user"], "sa")
+		}
+	}
+	test.Assert(t, found, "db_max_idle_time_closed wasn't collected")
+}
@@ -17,6 +17,7 @@ import (
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/db"
 	"github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/probs"
 )
 
@@ -76,6 +77,24 @@ func SelectCertificate(s db.OneSelector, serial string) (core.Certificate, error
 	return model, err
 }
 
+// SelectCertificateWithDER selects a certificate like SelectCertificate, but
+// if the certificate's DER has been pruned by the janitor's certificateDER
+// job, its precertificate's DER is returned in its place. The
+// precertificate has the same serial, names, key and validity period, so it
+// will do for anything other than serving the certificate to its subscriber.
+func SelectCertificateWithDER(s db.OneSelector, serial string) (core.Certificate, error) {
+	cert, err := SelectCertificate(s, serial)
+	if err != nil || len(cert.DER) > 0 {
+		return cert, err
+	}
+	precert, err := SelectPrecertificate(s, serial)
+	if err != nil {
+		return core.Certificate{}, err
+	}
+	cert.DER = precert.DER
+	return cert, nil
+}
+
 const precertFields = "registrationID, serial, der, issued, expires"
 
 // SelectPrecertificate selects all fields of one precertificate object
@@ -142,6 +161,41 @@ func SelectCertificateStatuses(s db.Selector, q string, args ...interface{}) ([]
 	return models, err
 }
 
+// ScanCertificateStatuses selects all fields of multiple certificate status
+// objects, like SelectCertificateStatuses, but calls send with each one as it
+// is scanned rather than reading them all first. It stops at the first error,
+// including one returned by send.
+func ScanCertificateStatuses(q db.Querier, send func(core.CertificateStatus) error, query string, args ...interface{}) error {
+	rows, err := q.Query(certStatusFieldsSelect(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status core.CertificateStatus
+		err := rows.Scan(
+			&status.Serial,
+			&status.Status,
+			&status.OCSPLastUpdated,
+			&status.RevokedDate,
+			&status.RevokedReason,
+			&status.LastExpirationNagSent,
+			&status.OCSPResponse,
+			&status.NotAfter,
+			&status.IsExpired,
+			&status.IssuerID,
+		)
+		if err != nil {
+			return err
+		}
+		err = send(status)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 var mediumBlobSize = int(math.Pow(2, 24))
 
 type issuedNameModel struct {
@@ -298,6 +352,19 @@ type precertificateModel struct {
 	Expires        time.Time
 }
 
+// precertificateModelv2 is identical to precertificateModel, but additionally
+// includes the ProfileHash column, which is only present in the database when
+// the StoreProfileHash feature is enabled.
+type precertificateModelv2 struct {
+	ID             int64
+	Serial         string
+	RegistrationID int64
+	DER            []byte
+	Issued         time.Time
+	Expires        time.Time
+	ProfileHash    []byte
+}
+
 type orderModel struct {
 	ID                int64
 	RegistrationID    int64
@@ -308,6 +375,20 @@ type orderModel struct {
 	BeganProcessing   bool
 }
 
+// orderModelv2 is identical to orderModel, but additionally includes the
+// CertificateProfileName column, which is only present in the database when
+// the CertificateProfiles feature is enabled.
+type orderModelv2 struct {
+	ID                     int64
+	RegistrationID         int64
+	Expires                time.Time
+	Created                time.Time
+	Error                  []byte
+	CertificateSerial      string
+	BeganProcessing        bool
+	CertificateProfileName string
+}
+
 type requestedNameModel struct {
 	ID           int64
 	OrderID      int64
@@ -369,24 +450,52 @@ func modelToOrder(om *orderModel) (*corepb.Order, error) {
 	return order, nil
 }
 
+func modelv2ToOrder(om *orderModelv2) (*corepb.Order, error) {
+	order, err := modelToOrder(&orderModel{
+		ID:                om.ID,
+		RegistrationID:    om.RegistrationID,
+		Expires:           om.Expires,
+		Created:           om.Created,
+		Error:             om.Error,
+		CertificateSerial: om.CertificateSerial,
+		BeganProcessing:   om.BeganProcessing,
+	})
+	if err != nil {
+		return nil, err
+	}
+	order.CertificateProfileName = &om.CertificateProfileName
+	return order, nil
+}
+
 var challTypeToUint = map[string]uint8{
-	"http-01":     0,
-	"dns-01":      1,
-	"tls-alpn-01": 2,
+	"http-01":      0,
+	"dns-01":       1,
+	"tls-alpn-01":  2,
+	"onion-csr-01": 3,
 }
 
 var uintToChallType = map[uint8]string{
 	0: "http-01",
 	1: "dns-01",
 	2: "tls-alpn-01",
+	3: "onion-csr-01",
 }
 
 var identifierTypeToUint = map[string]uint8{
 	"dns": 0,
+	"ip":  1,
 }
 
 var uintToIdentifierType = map[uint8]string{
 	0: "dns",
+	1: "ip",
+}
+
+// identifierTypeUint returns the stored identifier type for an identifier
+// value. Authorizations carry only their identifier's value outside of the
+// database, so the type is inferred from it.
+func identifierTypeUint(value string) uint8 {
+	return identifierTypeToUint[string(identifier.FromValue(value).Type)]
 }
 
 var statusToUint = map[string]uint8{
@@ -446,6 +555,7 @@ func hasMultipleNonPendingChallenges(challenges []*corepb.Challenge) bool {
 func authzPBToModel(authz *corepb.Authorization) (*authzModel, error) {
 	expires := time.Unix(0, *authz.Expires).UTC()
 	am := &authzModel{
+		IdentifierType:  identifierTypeUint(*authz.Identifier),
 		IdentifierValue: *authz.Identifier,
 		RegistrationID:  *authz.RegistrationID,
 		Status:          statusToUint[*authz.Status],
@@ -158,6 +158,27 @@ func TestAuthzModel(t *testing.T) {
 // TestModelToChallengeBadJSON tests that converting a challenge model with an
 // invalid validation error field or validation record field produces the
 // expected bad JSON error.
+func TestAuthzModelIdentifierType(t *testing.T) {
+	for ident, expected := range map[string]uint8{
+		"example.com":      0,
+		"64.112.117.1":     1,
+		"2602:80a:6000::1": 1,
+	} {
+		reg := int64(1)
+		status := string(core.StatusPending)
+		expires := int64(1234)
+		ident := ident
+		model, err := authzPBToModel(&corepb.Authorization{
+			Identifier:     &ident,
+			RegistrationID: &reg,
+			Status:         &status,
+			Expires:        &expires,
+		})
+		test.AssertNotError(t, err, "authzPBToModel failed")
+		test.AssertEquals(t, model.IdentifierType, expected)
+	}
+}
+
 func TestModelToChallengeBadJSON(t *testing.T) {
 	badJSON := []byte(`{`)
 
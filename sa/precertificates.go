@@ -12,6 +12,7 @@ import (
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/db"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -20,12 +21,22 @@ var errIncompleteRequest = errors.New("Incomplete gRPC request message")
 
 // AddSerial writes a record of a serial number generation to the DB.
 func (ssa *SQLStorageAuthority) AddSerial(ctx context.Context, req *sapb.AddSerialRequest) (*corepb.Empty, error) {
-	if req == nil || req.Created == nil || req.Expires == nil || req.Serial == nil || req.RegID == nil {
+	if req == nil || req.Serial == nil || req.RegID == nil {
 		return nil, errIncompleteRequest
 	}
-	created := time.Unix(0, *req.Created)
-	expires := time.Unix(0, *req.Expires)
-	err := ssa.dbMap.WithContext(ctx).Insert(&recordedSerialModel{
+	err := ssa.checkSerialPrefix(*req.Serial)
+	if err != nil {
+		return nil, err
+	}
+	created, err := bgrpc.PBOrNanosToTime(req.CreatedTime, req.Created)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created: %s", err)
+	}
+	expires, err := bgrpc.PBOrNanosToTime(req.ExpiresTime, req.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expires: %s", err)
+	}
+	err = ssa.dbMap.WithContext(ctx).Insert(&recordedSerialModel{
 		Serial:         *req.Serial,
 		RegistrationID: *req.RegID,
 		Created:        created,
@@ -37,6 +48,37 @@ func (ssa *SQLStorageAuthority) AddSerial(ctx context.Context, req *sapb.AddSeri
 	return &corepb.Empty{}, nil
 }
 
+// GetSerialMetadata returns the account ID, creation time and expiry recorded
+// for a serial number by AddSerial, or a NotFound error if the serial is
+// unknown.
+func (ssa *SQLStorageAuthority) GetSerialMetadata(ctx context.Context, req *sapb.Serial) (*sapb.SerialMetadata, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	var recordedSerial recordedSerialModel
+	err := ssa.dbMap.WithContext(ctx).SelectOne(
+		&recordedSerial,
+		"SELECT * FROM serials WHERE serial = ?",
+		*req.Serial,
+	)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, berrors.NotFoundError("serial %q not found", *req.Serial)
+		}
+		return nil, err
+	}
+	created := recordedSerial.Created.UnixNano()
+	expires := recordedSerial.Expires.UnixNano()
+	return &sapb.SerialMetadata{
+		Serial:         &recordedSerial.Serial,
+		RegistrationID: &recordedSerial.RegistrationID,
+		Created:        &created,
+		Expires:        &expires,
+		CreatedTime:    bgrpc.TimeToPB(recordedSerial.Created),
+		ExpiresTime:    bgrpc.TimeToPB(recordedSerial.Expires),
+	}, nil
+}
+
 // AddPrecertificate writes a record of a precertificate generation to the DB.
 func (ssa *SQLStorageAuthority) AddPrecertificate(ctx context.Context, req *sapb.AddCertificateRequest) (*corepb.Empty, error) {
 	if req == nil || req.Der == nil || req.Issued == nil || req.RegID == nil {
@@ -48,13 +90,29 @@ func (ssa *SQLStorageAuthority) AddPrecertificate(ctx context.Context, req *sapb
 	}
 	issued := time.Unix(0, *req.Issued)
 	serialHex := core.SerialToString(parsed.SerialNumber)
+	err = ssa.checkSerialPrefix(serialHex)
+	if err != nil {
+		return nil, err
+	}
 
-	preCertModel := &precertificateModel{
-		Serial:         serialHex,
-		RegistrationID: *req.RegID,
-		DER:            req.Der,
-		Issued:         issued,
-		Expires:        parsed.NotAfter,
+	var preCertModel interface{}
+	if features.Enabled(features.StoreProfileHash) {
+		preCertModel = &precertificateModelv2{
+			Serial:         serialHex,
+			RegistrationID: *req.RegID,
+			DER:            req.Der,
+			Issued:         issued,
+			Expires:        parsed.NotAfter,
+			ProfileHash:    req.ProfileHash,
+		}
+	} else {
+		preCertModel = &precertificateModel{
+			Serial:         serialHex,
+			RegistrationID: *req.RegID,
+			DER:            req.Der,
+			Issued:         issued,
+			Expires:        parsed.NotAfter,
+		}
 	}
 
 	_, overallError := db.WithTransaction(ctx, ssa.dbMap, func(txWithCtx db.Executor) (interface{}, error) {
@@ -113,6 +171,12 @@ func (ssa *SQLStorageAuthority) AddPrecertificate(ctx context.Context, req *sapb
 		if err := addKeyHash(txWithCtx, parsed); err != nil {
 			return nil, err
 		}
+		if features.Enabled(features.StoreIssuanceOutcomes) {
+			err = addPendingOutcome(txWithCtx, serialHex, *req.RegID, issued, ssa.clk.Now())
+			if err != nil {
+				return nil, err
+			}
+		}
 
 		return nil, nil
 	})
@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/db"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/sa/satest"
 	"github.com/letsencrypt/boulder/test"
@@ -127,3 +132,108 @@ func TestAddPrecertificateKeyHash(t *testing.T) {
 	spkiHash := sha256.Sum256(testCert.RawSubjectPublicKeyInfo)
 	test.Assert(t, bytes.Compare(keyHashes[0].KeyHash, spkiHash[:]) == 0, "spki hash mismatch")
 }
+
+func TestAddPrecertificateProfileHash(t *testing.T) {
+	// The profileHash column only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("profileHash column requires config-next database schema")
+	}
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	err := features.Set(map[string]bool{"StoreProfileHash": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	serial, testCert := test.ThrowAwayCert(t, 1)
+	issued := testCert.NotBefore.UnixNano()
+	profileHash := sha256.Sum256([]byte("profile"))
+	_, err = sa.AddPrecertificate(ctx, &sapb.AddCertificateRequest{
+		Der:         testCert.Raw,
+		RegID:       &reg.ID,
+		Ocsp:        []byte{1, 2, 3},
+		Issued:      &issued,
+		ProfileHash: profileHash[:],
+	})
+	test.AssertNotError(t, err, "failed to add precert")
+
+	var storedHash []byte
+	err = sa.dbMap.SelectOne(&storedHash, "SELECT profileHash FROM precertificates WHERE serial = ?", serial)
+	test.AssertNotError(t, err, "failed to select profile hash")
+	test.AssertByteEquals(t, storedHash, profileHash[:])
+}
+
+func TestGetSerialMetadata(t *testing.T) {
+	sa, clk, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+
+	serial := "000000000000000000000000000000001337"
+	_, err := sa.GetSerialMetadata(ctx, &sapb.Serial{Serial: &serial})
+	test.AssertError(t, err, "GetSerialMetadata succeeded for an unknown serial")
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "GetSerialMetadata error wasn't NotFound")
+
+	regID := reg.ID
+	created := clk.Now().UnixNano()
+	expires := clk.Now().Add(90 * 24 * time.Hour).UnixNano()
+	_, err = sa.AddSerial(ctx, &sapb.AddSerialRequest{
+		Serial:  &serial,
+		RegID:   &regID,
+		Created: &created,
+		Expires: &expires,
+	})
+	test.AssertNotError(t, err, "AddSerial failed")
+
+	metadata, err := sa.GetSerialMetadata(ctx, &sapb.Serial{Serial: &serial})
+	test.AssertNotError(t, err, "GetSerialMetadata failed")
+	test.AssertEquals(t, metadata.GetSerial(), serial)
+	test.AssertEquals(t, metadata.GetRegistrationID(), reg.ID)
+	test.AssertEquals(t, metadata.GetCreated(), created)
+	test.AssertEquals(t, metadata.GetExpires(), expires)
+	createdTime, err := bgrpc.PBToTime(metadata.CreatedTime)
+	test.AssertNotError(t, err, "invalid createdTime")
+	test.AssertEquals(t, createdTime.UnixNano(), created)
+	expiresTime, err := bgrpc.PBToTime(metadata.ExpiresTime)
+	test.AssertNotError(t, err, "invalid expiresTime")
+	test.AssertEquals(t, expiresTime.UnixNano(), expires)
+}
+
+func TestSelectCertificateWithPrunedDER(t *testing.T) {
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	serial, testCert := test.ThrowAwayCert(t, 1)
+	issued := testCert.NotBefore
+	issuedNano := issued.UnixNano()
+	_, err := sa.AddPrecertificate(ctx, &sapb.AddCertificateRequest{
+		Der:    testCert.Raw,
+		RegID:  &reg.ID,
+		Ocsp:   []byte{1, 2, 3},
+		Issued: &issuedNano,
+	})
+	test.AssertNotError(t, err, "failed to add precert")
+	_, err = sa.AddCertificate(ctx, testCert.Raw, reg.ID, nil, &issued)
+	test.AssertNotError(t, err, "failed to add cert")
+
+	// The final certificate is stored in full, so it can be served.
+	cert, err := sa.GetCertificate(ctx, serial)
+	test.AssertNotError(t, err, "failed to get cert")
+	test.AssertByteEquals(t, cert.DER, testCert.Raw)
+
+	// Once the janitor prunes its DER, its metadata remains.
+	_, err = sa.dbMap.Exec("UPDATE certificates SET der = '' WHERE serial = ?", serial)
+	test.AssertNotError(t, err, "failed to prune cert DER")
+	cert, err = sa.GetCertificate(ctx, serial)
+	test.AssertNotError(t, err, "failed to get cert")
+	test.AssertEquals(t, cert.Serial, serial)
+	test.AssertEquals(t, cert.Digest, core.Fingerprint256(testCert.Raw))
+	test.AssertEquals(t, len(cert.DER), 0)
+
+	// Callers which need the DER get the precertificate's instead.
+	cert, err = SelectCertificateWithDER(sa.dbMap, serial)
+	test.AssertNotError(t, err, "failed to select cert with DER")
+	test.AssertByteEquals(t, cert.DER, testCert.Raw)
+}
@@ -9,6 +9,7 @@ package proto
 import (
 	context "context"
 	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	proto1 "github.com/letsencrypt/boulder/core/proto"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -179,8 +180,10 @@ type GetPendingAuthorizationRequest struct {
 	RegistrationID  *int64  `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
 	IdentifierType  *string `protobuf:"bytes,2,opt,name=identifierType" json:"identifierType,omitempty"`
 	IdentifierValue *string `protobuf:"bytes,3,opt,name=identifierValue" json:"identifierValue,omitempty"`
+	// TODO: Remove validUntil once every client sets validUntilTime in its place.
 	// Result must be valid until at least this Unix timestamp (nanos)
-	ValidUntil *int64 `protobuf:"varint,4,opt,name=validUntil" json:"validUntil,omitempty"`
+	ValidUntil     *int64               `protobuf:"varint,4,opt,name=validUntil" json:"validUntil,omitempty"`
+	ValidUntilTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=validUntilTime" json:"validUntilTime,omitempty"`
 }
 
 func (x *GetPendingAuthorizationRequest) Reset() {
@@ -243,6 +246,13 @@ func (x *GetPendingAuthorizationRequest) GetValidUntil() int64 {
 	return 0
 }
 
+func (x *GetPendingAuthorizationRequest) GetValidUntilTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.ValidUntilTime
+	}
+	return nil
+}
+
 type GetValidAuthorizationsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -250,7 +260,9 @@ type GetValidAuthorizationsRequest struct {
 
 	RegistrationID *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
 	Domains        []string `protobuf:"bytes,2,rep,name=domains" json:"domains,omitempty"`
-	Now            *int64   `protobuf:"varint,3,opt,name=now" json:"now,omitempty"` // Unix timestamp (nanoseconds)
+	// TODO: Remove now once every client sets nowTime in its place.
+	Now     *int64               `protobuf:"varint,3,opt,name=now" json:"now,omitempty"` // Unix timestamp (nanoseconds)
+	NowTime *timestamp.Timestamp `protobuf:"bytes,4,opt,name=nowTime" json:"nowTime,omitempty"`
 }
 
 func (x *GetValidAuthorizationsRequest) Reset() {
@@ -306,6 +318,13 @@ func (x *GetValidAuthorizationsRequest) GetNow() int64 {
 	return 0
 }
 
+func (x *GetValidAuthorizationsRequest) GetNowTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.NowTime
+	}
+	return nil
+}
+
 type ValidAuthorizations struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -400,19 +419,112 @@ func (x *Serial) GetSerial() string {
 	return ""
 }
 
+type SerialMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Serial         *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	RegistrationID *int64  `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
+	// TODO: Remove created and expires once every client reads createdTime and
+	// expiresTime in their place.
+	Created     *int64               `protobuf:"varint,3,opt,name=created" json:"created,omitempty"` // Unix timestamp (nanoseconds)
+	Expires     *int64               `protobuf:"varint,4,opt,name=expires" json:"expires,omitempty"` // Unix timestamp (nanoseconds)
+	CreatedTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=createdTime" json:"createdTime,omitempty"`
+	ExpiresTime *timestamp.Timestamp `protobuf:"bytes,6,opt,name=expiresTime" json:"expiresTime,omitempty"`
+}
+
+func (x *SerialMetadata) Reset() {
+	*x = SerialMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SerialMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialMetadata) ProtoMessage() {}
+
+func (x *SerialMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialMetadata.ProtoReflect.Descriptor instead.
+func (*SerialMetadata) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SerialMetadata) GetSerial() string {
+	if x != nil && x.Serial != nil {
+		return *x.Serial
+	}
+	return ""
+}
+
+func (x *SerialMetadata) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
+	}
+	return 0
+}
+
+func (x *SerialMetadata) GetCreated() int64 {
+	if x != nil && x.Created != nil {
+		return *x.Created
+	}
+	return 0
+}
+
+func (x *SerialMetadata) GetExpires() int64 {
+	if x != nil && x.Expires != nil {
+		return *x.Expires
+	}
+	return 0
+}
+
+func (x *SerialMetadata) GetCreatedTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return nil
+}
+
+func (x *SerialMetadata) GetExpiresTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.ExpiresTime
+	}
+	return nil
+}
+
 type Range struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Earliest *int64 `protobuf:"varint,1,opt,name=earliest" json:"earliest,omitempty"` // Unix timestamp (nanoseconds)
-	Latest   *int64 `protobuf:"varint,2,opt,name=latest" json:"latest,omitempty"`     // Unix timestamp (nanoseconds)
+	// TODO: Remove earliest and latest once every client sets earliestTime and
+	// latestTime in their place.
+	Earliest     *int64               `protobuf:"varint,1,opt,name=earliest" json:"earliest,omitempty"` // Unix timestamp (nanoseconds)
+	Latest       *int64               `protobuf:"varint,2,opt,name=latest" json:"latest,omitempty"`     // Unix timestamp (nanoseconds)
+	EarliestTime *timestamp.Timestamp `protobuf:"bytes,3,opt,name=earliestTime" json:"earliestTime,omitempty"`
+	LatestTime   *timestamp.Timestamp `protobuf:"bytes,4,opt,name=latestTime" json:"latestTime,omitempty"`
 }
 
 func (x *Range) Reset() {
 	*x = Range{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[7]
+		mi := &file_sa_proto_sa_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -425,7 +537,7 @@ func (x *Range) String() string {
 func (*Range) ProtoMessage() {}
 
 func (x *Range) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[7]
+	mi := &file_sa_proto_sa_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -438,7 +550,7 @@ func (x *Range) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Range.ProtoReflect.Descriptor instead.
 func (*Range) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{7}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Range) GetEarliest() int64 {
@@ -455,6 +567,20 @@ func (x *Range) GetLatest() int64 {
 	return 0
 }
 
+func (x *Range) GetEarliestTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.EarliestTime
+	}
+	return nil
+}
+
+func (x *Range) GetLatestTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.LatestTime
+	}
+	return nil
+}
+
 type Count struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -466,7 +592,7 @@ type Count struct {
 func (x *Count) Reset() {
 	*x = Count{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[8]
+		mi := &file_sa_proto_sa_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -479,7 +605,7 @@ func (x *Count) String() string {
 func (*Count) ProtoMessage() {}
 
 func (x *Count) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[8]
+	mi := &file_sa_proto_sa_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -492,7 +618,7 @@ func (x *Count) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Count.ProtoReflect.Descriptor instead.
 func (*Count) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{8}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Count) GetCount() int64 {
@@ -514,7 +640,7 @@ type CountCertificatesByNamesRequest struct {
 func (x *CountCertificatesByNamesRequest) Reset() {
 	*x = CountCertificatesByNamesRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[9]
+		mi := &file_sa_proto_sa_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -527,7 +653,7 @@ func (x *CountCertificatesByNamesRequest) String() string {
 func (*CountCertificatesByNamesRequest) ProtoMessage() {}
 
 func (x *CountCertificatesByNamesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[9]
+	mi := &file_sa_proto_sa_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -540,7 +666,7 @@ func (x *CountCertificatesByNamesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CountCertificatesByNamesRequest.ProtoReflect.Descriptor instead.
 func (*CountCertificatesByNamesRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{9}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *CountCertificatesByNamesRequest) GetRange() *Range {
@@ -568,7 +694,7 @@ type CountByNames struct {
 func (x *CountByNames) Reset() {
 	*x = CountByNames{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[10]
+		mi := &file_sa_proto_sa_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -581,7 +707,7 @@ func (x *CountByNames) String() string {
 func (*CountByNames) ProtoMessage() {}
 
 func (x *CountByNames) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[10]
+	mi := &file_sa_proto_sa_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -594,7 +720,7 @@ func (x *CountByNames) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CountByNames.ProtoReflect.Descriptor instead.
 func (*CountByNames) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{10}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *CountByNames) GetCountByNames() []*CountByNames_MapElement {
@@ -616,7 +742,7 @@ type CountRegistrationsByIPRequest struct {
 func (x *CountRegistrationsByIPRequest) Reset() {
 	*x = CountRegistrationsByIPRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[11]
+		mi := &file_sa_proto_sa_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -629,7 +755,7 @@ func (x *CountRegistrationsByIPRequest) String() string {
 func (*CountRegistrationsByIPRequest) ProtoMessage() {}
 
 func (x *CountRegistrationsByIPRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[11]
+	mi := &file_sa_proto_sa_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -642,7 +768,7 @@ func (x *CountRegistrationsByIPRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CountRegistrationsByIPRequest.ProtoReflect.Descriptor instead.
 func (*CountRegistrationsByIPRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{11}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *CountRegistrationsByIPRequest) GetIp() []byte {
@@ -673,7 +799,7 @@ type CountInvalidAuthorizationsRequest struct {
 func (x *CountInvalidAuthorizationsRequest) Reset() {
 	*x = CountInvalidAuthorizationsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[12]
+		mi := &file_sa_proto_sa_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -686,7 +812,7 @@ func (x *CountInvalidAuthorizationsRequest) String() string {
 func (*CountInvalidAuthorizationsRequest) ProtoMessage() {}
 
 func (x *CountInvalidAuthorizationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[12]
+	mi := &file_sa_proto_sa_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -699,7 +825,7 @@ func (x *CountInvalidAuthorizationsRequest) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use CountInvalidAuthorizationsRequest.ProtoReflect.Descriptor instead.
 func (*CountInvalidAuthorizationsRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{12}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *CountInvalidAuthorizationsRequest) GetRegistrationID() int64 {
@@ -735,7 +861,7 @@ type CountOrdersRequest struct {
 func (x *CountOrdersRequest) Reset() {
 	*x = CountOrdersRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[13]
+		mi := &file_sa_proto_sa_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -748,7 +874,7 @@ func (x *CountOrdersRequest) String() string {
 func (*CountOrdersRequest) ProtoMessage() {}
 
 func (x *CountOrdersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[13]
+	mi := &file_sa_proto_sa_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -761,7 +887,7 @@ func (x *CountOrdersRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CountOrdersRequest.ProtoReflect.Descriptor instead.
 func (*CountOrdersRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{13}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *CountOrdersRequest) GetAccountID() int64 {
@@ -790,7 +916,7 @@ type CountFQDNSetsRequest struct {
 func (x *CountFQDNSetsRequest) Reset() {
 	*x = CountFQDNSetsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[14]
+		mi := &file_sa_proto_sa_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -803,7 +929,7 @@ func (x *CountFQDNSetsRequest) String() string {
 func (*CountFQDNSetsRequest) ProtoMessage() {}
 
 func (x *CountFQDNSetsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[14]
+	mi := &file_sa_proto_sa_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -816,7 +942,7 @@ func (x *CountFQDNSetsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CountFQDNSetsRequest.ProtoReflect.Descriptor instead.
 func (*CountFQDNSetsRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{14}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *CountFQDNSetsRequest) GetWindow() int64 {
@@ -844,7 +970,7 @@ type FQDNSetExistsRequest struct {
 func (x *FQDNSetExistsRequest) Reset() {
 	*x = FQDNSetExistsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[15]
+		mi := &file_sa_proto_sa_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -857,7 +983,7 @@ func (x *FQDNSetExistsRequest) String() string {
 func (*FQDNSetExistsRequest) ProtoMessage() {}
 
 func (x *FQDNSetExistsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[15]
+	mi := &file_sa_proto_sa_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -870,7 +996,7 @@ func (x *FQDNSetExistsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FQDNSetExistsRequest.ProtoReflect.Descriptor instead.
 func (*FQDNSetExistsRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{15}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *FQDNSetExistsRequest) GetDomains() []string {
@@ -892,7 +1018,7 @@ type PreviousCertificateExistsRequest struct {
 func (x *PreviousCertificateExistsRequest) Reset() {
 	*x = PreviousCertificateExistsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[16]
+		mi := &file_sa_proto_sa_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -905,7 +1031,7 @@ func (x *PreviousCertificateExistsRequest) String() string {
 func (*PreviousCertificateExistsRequest) ProtoMessage() {}
 
 func (x *PreviousCertificateExistsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[16]
+	mi := &file_sa_proto_sa_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -918,7 +1044,7 @@ func (x *PreviousCertificateExistsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreviousCertificateExistsRequest.ProtoReflect.Descriptor instead.
 func (*PreviousCertificateExistsRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{16}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *PreviousCertificateExistsRequest) GetDomain() string {
@@ -946,7 +1072,7 @@ type Exists struct {
 func (x *Exists) Reset() {
 	*x = Exists{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[17]
+		mi := &file_sa_proto_sa_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -959,7 +1085,7 @@ func (x *Exists) String() string {
 func (*Exists) ProtoMessage() {}
 
 func (x *Exists) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[17]
+	mi := &file_sa_proto_sa_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -972,7 +1098,7 @@ func (x *Exists) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Exists.ProtoReflect.Descriptor instead.
 func (*Exists) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{17}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *Exists) GetExists() bool {
@@ -987,16 +1113,20 @@ type AddSerialRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RegID   *int64  `protobuf:"varint,1,opt,name=regID" json:"regID,omitempty"`
-	Serial  *string `protobuf:"bytes,2,opt,name=serial" json:"serial,omitempty"`
-	Created *int64  `protobuf:"varint,3,opt,name=created" json:"created,omitempty"` // Unix timestamp (nanoseconds)
-	Expires *int64  `protobuf:"varint,4,opt,name=expires" json:"expires,omitempty"` // Unix timestamp (nanoseconds)
+	RegID  *int64  `protobuf:"varint,1,opt,name=regID" json:"regID,omitempty"`
+	Serial *string `protobuf:"bytes,2,opt,name=serial" json:"serial,omitempty"`
+	// TODO: Remove created and expires once every client sets createdTime and
+	// expiresTime in their place.
+	Created     *int64               `protobuf:"varint,3,opt,name=created" json:"created,omitempty"` // Unix timestamp (nanoseconds)
+	Expires     *int64               `protobuf:"varint,4,opt,name=expires" json:"expires,omitempty"` // Unix timestamp (nanoseconds)
+	CreatedTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=createdTime" json:"createdTime,omitempty"`
+	ExpiresTime *timestamp.Timestamp `protobuf:"bytes,6,opt,name=expiresTime" json:"expiresTime,omitempty"`
 }
 
 func (x *AddSerialRequest) Reset() {
 	*x = AddSerialRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[18]
+		mi := &file_sa_proto_sa_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1009,7 +1139,7 @@ func (x *AddSerialRequest) String() string {
 func (*AddSerialRequest) ProtoMessage() {}
 
 func (x *AddSerialRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[18]
+	mi := &file_sa_proto_sa_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1022,7 +1152,7 @@ func (x *AddSerialRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddSerialRequest.ProtoReflect.Descriptor instead.
 func (*AddSerialRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{18}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *AddSerialRequest) GetRegID() int64 {
@@ -1053,6 +1183,20 @@ func (x *AddSerialRequest) GetExpires() int64 {
 	return 0
 }
 
+func (x *AddSerialRequest) GetCreatedTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return nil
+}
+
+func (x *AddSerialRequest) GetExpiresTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.ExpiresTime
+	}
+	return nil
+}
+
 type AddCertificateRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1068,12 +1212,15 @@ type AddCertificateRequest struct {
 	// certificates with the correct historic issued date
 	Issued   *int64 `protobuf:"varint,4,opt,name=issued" json:"issued,omitempty"`
 	IssuerID *int64 `protobuf:"varint,5,opt,name=issuerID" json:"issuerID,omitempty"`
+	// The SHA-256 hash of the issuance profile the certificate was issued under,
+	// as computed by the CA. Only stored for precertificates.
+	ProfileHash []byte `protobuf:"bytes,6,opt,name=profileHash" json:"profileHash,omitempty"`
 }
 
 func (x *AddCertificateRequest) Reset() {
 	*x = AddCertificateRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[19]
+		mi := &file_sa_proto_sa_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1086,7 +1233,7 @@ func (x *AddCertificateRequest) String() string {
 func (*AddCertificateRequest) ProtoMessage() {}
 
 func (x *AddCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[19]
+	mi := &file_sa_proto_sa_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1099,7 +1246,7 @@ func (x *AddCertificateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddCertificateRequest.ProtoReflect.Descriptor instead.
 func (*AddCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{19}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *AddCertificateRequest) GetDer() []byte {
@@ -1137,6 +1284,13 @@ func (x *AddCertificateRequest) GetIssuerID() int64 {
 	return 0
 }
 
+func (x *AddCertificateRequest) GetProfileHash() []byte {
+	if x != nil {
+		return x.ProfileHash
+	}
+	return nil
+}
+
 type AddCertificateResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1148,7 +1302,7 @@ type AddCertificateResponse struct {
 func (x *AddCertificateResponse) Reset() {
 	*x = AddCertificateResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[20]
+		mi := &file_sa_proto_sa_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1161,7 +1315,7 @@ func (x *AddCertificateResponse) String() string {
 func (*AddCertificateResponse) ProtoMessage() {}
 
 func (x *AddCertificateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[20]
+	mi := &file_sa_proto_sa_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1174,7 +1328,7 @@ func (x *AddCertificateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddCertificateResponse.ProtoReflect.Descriptor instead.
 func (*AddCertificateResponse) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{20}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *AddCertificateResponse) GetDigest() string {
@@ -1196,7 +1350,7 @@ type OrderRequest struct {
 func (x *OrderRequest) Reset() {
 	*x = OrderRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[21]
+		mi := &file_sa_proto_sa_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1209,7 +1363,7 @@ func (x *OrderRequest) String() string {
 func (*OrderRequest) ProtoMessage() {}
 
 func (x *OrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[21]
+	mi := &file_sa_proto_sa_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1222,7 +1376,7 @@ func (x *OrderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderRequest.ProtoReflect.Descriptor instead.
 func (*OrderRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{21}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *OrderRequest) GetId() int64 {
@@ -1239,32 +1393,34 @@ func (x *OrderRequest) GetUseV2Authorizations() bool {
 	return false
 }
 
-type GetValidOrderAuthorizationsRequest struct {
+type GetOrdersForAccountRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id     *int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
-	AcctID *int64 `protobuf:"varint,2,opt,name=acctID" json:"acctID,omitempty"`
+	RegistrationID *int64 `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	// If non-zero, only orders with smaller IDs are returned
+	BeforeID *int64 `protobuf:"varint,2,opt,name=beforeID" json:"beforeID,omitempty"`
+	Limit    *int64 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
 }
 
-func (x *GetValidOrderAuthorizationsRequest) Reset() {
-	*x = GetValidOrderAuthorizationsRequest{}
+func (x *GetOrdersForAccountRequest) Reset() {
+	*x = GetOrdersForAccountRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[22]
+		mi := &file_sa_proto_sa_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetValidOrderAuthorizationsRequest) String() string {
+func (x *GetOrdersForAccountRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetValidOrderAuthorizationsRequest) ProtoMessage() {}
+func (*GetOrdersForAccountRequest) ProtoMessage() {}
 
-func (x *GetValidOrderAuthorizationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[22]
+func (x *GetOrdersForAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1275,52 +1431,57 @@ func (x *GetValidOrderAuthorizationsRequest) ProtoReflect() protoreflect.Message
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetValidOrderAuthorizationsRequest.ProtoReflect.Descriptor instead.
-func (*GetValidOrderAuthorizationsRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use GetOrdersForAccountRequest.ProtoReflect.Descriptor instead.
+func (*GetOrdersForAccountRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *GetValidOrderAuthorizationsRequest) GetId() int64 {
-	if x != nil && x.Id != nil {
-		return *x.Id
+func (x *GetOrdersForAccountRequest) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
 	}
 	return 0
 }
 
-func (x *GetValidOrderAuthorizationsRequest) GetAcctID() int64 {
-	if x != nil && x.AcctID != nil {
-		return *x.AcctID
+func (x *GetOrdersForAccountRequest) GetBeforeID() int64 {
+	if x != nil && x.BeforeID != nil {
+		return *x.BeforeID
 	}
 	return 0
 }
 
-type GetOrderForNamesRequest struct {
+func (x *GetOrdersForAccountRequest) GetLimit() int64 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
+	}
+	return 0
+}
+
+type OrderIDs struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	AcctID              *int64   `protobuf:"varint,1,opt,name=acctID" json:"acctID,omitempty"`
-	Names               []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
-	UseV2Authorizations *bool    `protobuf:"varint,3,opt,name=useV2Authorizations" json:"useV2Authorizations,omitempty"`
+	Ids []int64 `protobuf:"varint,1,rep,name=ids" json:"ids,omitempty"`
 }
 
-func (x *GetOrderForNamesRequest) Reset() {
-	*x = GetOrderForNamesRequest{}
+func (x *OrderIDs) Reset() {
+	*x = OrderIDs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[23]
+		mi := &file_sa_proto_sa_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetOrderForNamesRequest) String() string {
+func (x *OrderIDs) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetOrderForNamesRequest) ProtoMessage() {}
+func (*OrderIDs) ProtoMessage() {}
 
-func (x *GetOrderForNamesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[23]
+func (x *OrderIDs) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1331,47 +1492,153 @@ func (x *GetOrderForNamesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetOrderForNamesRequest.ProtoReflect.Descriptor instead.
-func (*GetOrderForNamesRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{23}
-}
-
-func (x *GetOrderForNamesRequest) GetAcctID() int64 {
-	if x != nil && x.AcctID != nil {
-		return *x.AcctID
-	}
-	return 0
+// Deprecated: Use OrderIDs.ProtoReflect.Descriptor instead.
+func (*OrderIDs) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *GetOrderForNamesRequest) GetNames() []string {
+func (x *OrderIDs) GetIds() []int64 {
 	if x != nil {
-		return x.Names
+		return x.Ids
 	}
 	return nil
 }
 
-func (x *GetOrderForNamesRequest) GetUseV2Authorizations() bool {
-	if x != nil && x.UseV2Authorizations != nil {
-		return *x.UseV2Authorizations
-	}
-	return false
-}
-
-type GetAuthorizationsRequest struct {
+type GetValidOrderAuthorizationsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RegistrationID  *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
-	Domains         []string `protobuf:"bytes,2,rep,name=domains" json:"domains,omitempty"`
-	Now             *int64   `protobuf:"varint,3,opt,name=now" json:"now,omitempty"`                         // Unix timestamp (nanoseconds)
-	RequireV2Authzs *bool    `protobuf:"varint,4,opt,name=requireV2Authzs" json:"requireV2Authzs,omitempty"` // Do not include legacy V1 authzs
-}
+	Id     *int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	AcctID *int64 `protobuf:"varint,2,opt,name=acctID" json:"acctID,omitempty"`
+}
+
+func (x *GetValidOrderAuthorizationsRequest) Reset() {
+	*x = GetValidOrderAuthorizationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetValidOrderAuthorizationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetValidOrderAuthorizationsRequest) ProtoMessage() {}
+
+func (x *GetValidOrderAuthorizationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetValidOrderAuthorizationsRequest.ProtoReflect.Descriptor instead.
+func (*GetValidOrderAuthorizationsRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetValidOrderAuthorizationsRequest) GetId() int64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *GetValidOrderAuthorizationsRequest) GetAcctID() int64 {
+	if x != nil && x.AcctID != nil {
+		return *x.AcctID
+	}
+	return 0
+}
+
+type GetOrderForNamesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AcctID              *int64   `protobuf:"varint,1,opt,name=acctID" json:"acctID,omitempty"`
+	Names               []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
+	UseV2Authorizations *bool    `protobuf:"varint,3,opt,name=useV2Authorizations" json:"useV2Authorizations,omitempty"`
+}
+
+func (x *GetOrderForNamesRequest) Reset() {
+	*x = GetOrderForNamesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetOrderForNamesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderForNamesRequest) ProtoMessage() {}
+
+func (x *GetOrderForNamesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderForNamesRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderForNamesRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetOrderForNamesRequest) GetAcctID() int64 {
+	if x != nil && x.AcctID != nil {
+		return *x.AcctID
+	}
+	return 0
+}
+
+func (x *GetOrderForNamesRequest) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+func (x *GetOrderForNamesRequest) GetUseV2Authorizations() bool {
+	if x != nil && x.UseV2Authorizations != nil {
+		return *x.UseV2Authorizations
+	}
+	return false
+}
+
+type GetAuthorizationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RegistrationID *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Domains        []string `protobuf:"bytes,2,rep,name=domains" json:"domains,omitempty"`
+	// TODO: Remove now once every client sets nowTime in its place.
+	Now             *int64               `protobuf:"varint,3,opt,name=now" json:"now,omitempty"`                         // Unix timestamp (nanoseconds)
+	RequireV2Authzs *bool                `protobuf:"varint,4,opt,name=requireV2Authzs" json:"requireV2Authzs,omitempty"` // Do not include legacy V1 authzs
+	NowTime         *timestamp.Timestamp `protobuf:"bytes,5,opt,name=nowTime" json:"nowTime,omitempty"`
+}
 
 func (x *GetAuthorizationsRequest) Reset() {
 	*x = GetAuthorizationsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[24]
+		mi := &file_sa_proto_sa_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1384,7 +1651,7 @@ func (x *GetAuthorizationsRequest) String() string {
 func (*GetAuthorizationsRequest) ProtoMessage() {}
 
 func (x *GetAuthorizationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[24]
+	mi := &file_sa_proto_sa_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1397,7 +1664,7 @@ func (x *GetAuthorizationsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAuthorizationsRequest.ProtoReflect.Descriptor instead.
 func (*GetAuthorizationsRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{24}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *GetAuthorizationsRequest) GetRegistrationID() int64 {
@@ -1428,6 +1695,13 @@ func (x *GetAuthorizationsRequest) GetRequireV2Authzs() bool {
 	return false
 }
 
+func (x *GetAuthorizationsRequest) GetNowTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.NowTime
+	}
+	return nil
+}
+
 type Authorizations struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1439,7 +1713,7 @@ type Authorizations struct {
 func (x *Authorizations) Reset() {
 	*x = Authorizations{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[25]
+		mi := &file_sa_proto_sa_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1452,7 +1726,7 @@ func (x *Authorizations) String() string {
 func (*Authorizations) ProtoMessage() {}
 
 func (x *Authorizations) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[25]
+	mi := &file_sa_proto_sa_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1465,7 +1739,7 @@ func (x *Authorizations) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Authorizations.ProtoReflect.Descriptor instead.
 func (*Authorizations) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{25}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *Authorizations) GetAuthz() []*Authorizations_MapElement {
@@ -1486,7 +1760,7 @@ type AddPendingAuthorizationsRequest struct {
 func (x *AddPendingAuthorizationsRequest) Reset() {
 	*x = AddPendingAuthorizationsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[26]
+		mi := &file_sa_proto_sa_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1499,7 +1773,7 @@ func (x *AddPendingAuthorizationsRequest) String() string {
 func (*AddPendingAuthorizationsRequest) ProtoMessage() {}
 
 func (x *AddPendingAuthorizationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[26]
+	mi := &file_sa_proto_sa_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1512,7 +1786,7 @@ func (x *AddPendingAuthorizationsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddPendingAuthorizationsRequest.ProtoReflect.Descriptor instead.
 func (*AddPendingAuthorizationsRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{26}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *AddPendingAuthorizationsRequest) GetAuthz() []*proto1.Authorization {
@@ -1522,6 +1796,61 @@ func (x *AddPendingAuthorizationsRequest) GetAuthz() []*proto1.Authorization {
 	return nil
 }
 
+type NewOrderAndAuthzsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	NewOrder  *proto1.Order           `protobuf:"bytes,1,opt,name=newOrder" json:"newOrder,omitempty"`
+	NewAuthzs []*proto1.Authorization `protobuf:"bytes,2,rep,name=newAuthzs" json:"newAuthzs,omitempty"`
+}
+
+func (x *NewOrderAndAuthzsRequest) Reset() {
+	*x = NewOrderAndAuthzsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NewOrderAndAuthzsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewOrderAndAuthzsRequest) ProtoMessage() {}
+
+func (x *NewOrderAndAuthzsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewOrderAndAuthzsRequest.ProtoReflect.Descriptor instead.
+func (*NewOrderAndAuthzsRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *NewOrderAndAuthzsRequest) GetNewOrder() *proto1.Order {
+	if x != nil {
+		return x.NewOrder
+	}
+	return nil
+}
+
+func (x *NewOrderAndAuthzsRequest) GetNewAuthzs() []*proto1.Authorization {
+	if x != nil {
+		return x.NewAuthzs
+	}
+	return nil
+}
+
 type AuthorizationIDs struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1533,7 +1862,7 @@ type AuthorizationIDs struct {
 func (x *AuthorizationIDs) Reset() {
 	*x = AuthorizationIDs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[27]
+		mi := &file_sa_proto_sa_proto_msgTypes[31]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1546,7 +1875,7 @@ func (x *AuthorizationIDs) String() string {
 func (*AuthorizationIDs) ProtoMessage() {}
 
 func (x *AuthorizationIDs) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[27]
+	mi := &file_sa_proto_sa_proto_msgTypes[31]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1559,7 +1888,7 @@ func (x *AuthorizationIDs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AuthorizationIDs.ProtoReflect.Descriptor instead.
 func (*AuthorizationIDs) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{27}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *AuthorizationIDs) GetIds() []string {
@@ -1580,7 +1909,7 @@ type AuthorizationID2 struct {
 func (x *AuthorizationID2) Reset() {
 	*x = AuthorizationID2{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[28]
+		mi := &file_sa_proto_sa_proto_msgTypes[32]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1593,7 +1922,7 @@ func (x *AuthorizationID2) String() string {
 func (*AuthorizationID2) ProtoMessage() {}
 
 func (x *AuthorizationID2) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[28]
+	mi := &file_sa_proto_sa_proto_msgTypes[32]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1606,7 +1935,7 @@ func (x *AuthorizationID2) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AuthorizationID2.ProtoReflect.Descriptor instead.
 func (*AuthorizationID2) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{28}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *AuthorizationID2) GetId() int64 {
@@ -1627,7 +1956,7 @@ type Authorization2IDs struct {
 func (x *Authorization2IDs) Reset() {
 	*x = Authorization2IDs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[29]
+		mi := &file_sa_proto_sa_proto_msgTypes[33]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1640,7 +1969,7 @@ func (x *Authorization2IDs) String() string {
 func (*Authorization2IDs) ProtoMessage() {}
 
 func (x *Authorization2IDs) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[29]
+	mi := &file_sa_proto_sa_proto_msgTypes[33]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1653,7 +1982,7 @@ func (x *Authorization2IDs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Authorization2IDs.ProtoReflect.Descriptor instead.
 func (*Authorization2IDs) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{29}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *Authorization2IDs) GetIds() []int64 {
@@ -1668,16 +1997,24 @@ type RevokeCertificateRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Serial   *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
-	Reason   *int64  `protobuf:"varint,2,opt,name=reason" json:"reason,omitempty"`
-	Date     *int64  `protobuf:"varint,3,opt,name=date" json:"date,omitempty"` // Unix timestamp (nanoseconds)
-	Response []byte  `protobuf:"bytes,4,opt,name=response" json:"response,omitempty"`
+	Serial *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	Reason *int64  `protobuf:"varint,2,opt,name=reason" json:"reason,omitempty"`
+	// TODO: Remove date once every client sets dateTime in its place.
+	Date     *int64 `protobuf:"varint,3,opt,name=date" json:"date,omitempty"` // Unix timestamp (nanoseconds)
+	Response []byte `protobuf:"bytes,4,opt,name=response" json:"response,omitempty"`
+	// The index of the CRL shard which lists the certificate, derived from its
+	// CRL distribution point, or zero if it doesn't have one.
+	ShardIdx *int64 `protobuf:"varint,5,opt,name=shardIdx" json:"shardIdx,omitempty"`
+	// The ID of the certificate's issuer, as derived by the RA. It's recorded
+	// in revokedCertificates if the certificate's status has no issuer ID.
+	IssuerID *int64               `protobuf:"varint,6,opt,name=issuerID" json:"issuerID,omitempty"`
+	DateTime *timestamp.Timestamp `protobuf:"bytes,7,opt,name=dateTime" json:"dateTime,omitempty"`
 }
 
 func (x *RevokeCertificateRequest) Reset() {
 	*x = RevokeCertificateRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[30]
+		mi := &file_sa_proto_sa_proto_msgTypes[34]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1690,7 +2027,7 @@ func (x *RevokeCertificateRequest) String() string {
 func (*RevokeCertificateRequest) ProtoMessage() {}
 
 func (x *RevokeCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[30]
+	mi := &file_sa_proto_sa_proto_msgTypes[34]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1703,7 +2040,7 @@ func (x *RevokeCertificateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RevokeCertificateRequest.ProtoReflect.Descriptor instead.
 func (*RevokeCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{30}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *RevokeCertificateRequest) GetSerial() string {
@@ -1734,23 +2071,46 @@ func (x *RevokeCertificateRequest) GetResponse() []byte {
 	return nil
 }
 
+func (x *RevokeCertificateRequest) GetShardIdx() int64 {
+	if x != nil && x.ShardIdx != nil {
+		return *x.ShardIdx
+	}
+	return 0
+}
+
+func (x *RevokeCertificateRequest) GetIssuerID() int64 {
+	if x != nil && x.IssuerID != nil {
+		return *x.IssuerID
+	}
+	return 0
+}
+
+func (x *RevokeCertificateRequest) GetDateTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.DateTime
+	}
+	return nil
+}
+
 type FinalizeAuthorizationRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id                *int64                     `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
-	Status            *string                    `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	Id     *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Status *string `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	// TODO: Remove expires once every client sets expiresTime in its place.
 	Expires           *int64                     `protobuf:"varint,3,opt,name=expires" json:"expires,omitempty"` // Unix timestamp (nanoseconds)
 	Attempted         *string                    `protobuf:"bytes,4,opt,name=attempted" json:"attempted,omitempty"`
 	ValidationRecords []*proto1.ValidationRecord `protobuf:"bytes,5,rep,name=validationRecords" json:"validationRecords,omitempty"`
 	ValidationError   *proto1.ProblemDetails     `protobuf:"bytes,6,opt,name=validationError" json:"validationError,omitempty"`
+	ExpiresTime       *timestamp.Timestamp       `protobuf:"bytes,7,opt,name=expiresTime" json:"expiresTime,omitempty"`
 }
 
 func (x *FinalizeAuthorizationRequest) Reset() {
 	*x = FinalizeAuthorizationRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[31]
+		mi := &file_sa_proto_sa_proto_msgTypes[35]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1763,7 +2123,7 @@ func (x *FinalizeAuthorizationRequest) String() string {
 func (*FinalizeAuthorizationRequest) ProtoMessage() {}
 
 func (x *FinalizeAuthorizationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[31]
+	mi := &file_sa_proto_sa_proto_msgTypes[35]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1776,7 +2136,7 @@ func (x *FinalizeAuthorizationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FinalizeAuthorizationRequest.ProtoReflect.Descriptor instead.
 func (*FinalizeAuthorizationRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{31}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *FinalizeAuthorizationRequest) GetId() int64 {
@@ -1821,22 +2181,31 @@ func (x *FinalizeAuthorizationRequest) GetValidationError() *proto1.ProblemDetai
 	return nil
 }
 
+func (x *FinalizeAuthorizationRequest) GetExpiresTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.ExpiresTime
+	}
+	return nil
+}
+
 type AddBlockedKeyRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	KeyHash   []byte  `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
-	Added     *int64  `protobuf:"varint,2,opt,name=added" json:"added,omitempty"` // Unix timestamp (nanoseconds)
-	Source    *string `protobuf:"bytes,3,opt,name=source" json:"source,omitempty"`
-	Comment   *string `protobuf:"bytes,4,opt,name=comment" json:"comment,omitempty"`
-	RevokedBy *int64  `protobuf:"varint,5,opt,name=revokedBy" json:"revokedBy,omitempty"`
+	KeyHash []byte `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	// TODO: Remove added once every client sets addedTime in its place.
+	Added     *int64               `protobuf:"varint,2,opt,name=added" json:"added,omitempty"` // Unix timestamp (nanoseconds)
+	Source    *string              `protobuf:"bytes,3,opt,name=source" json:"source,omitempty"`
+	Comment   *string              `protobuf:"bytes,4,opt,name=comment" json:"comment,omitempty"`
+	RevokedBy *int64               `protobuf:"varint,5,opt,name=revokedBy" json:"revokedBy,omitempty"`
+	AddedTime *timestamp.Timestamp `protobuf:"bytes,6,opt,name=addedTime" json:"addedTime,omitempty"`
 }
 
 func (x *AddBlockedKeyRequest) Reset() {
 	*x = AddBlockedKeyRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[32]
+		mi := &file_sa_proto_sa_proto_msgTypes[36]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1849,7 +2218,7 @@ func (x *AddBlockedKeyRequest) String() string {
 func (*AddBlockedKeyRequest) ProtoMessage() {}
 
 func (x *AddBlockedKeyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[32]
+	mi := &file_sa_proto_sa_proto_msgTypes[36]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1862,7 +2231,7 @@ func (x *AddBlockedKeyRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddBlockedKeyRequest.ProtoReflect.Descriptor instead.
 func (*AddBlockedKeyRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{32}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *AddBlockedKeyRequest) GetKeyHash() []byte {
@@ -1900,6 +2269,13 @@ func (x *AddBlockedKeyRequest) GetRevokedBy() int64 {
 	return 0
 }
 
+func (x *AddBlockedKeyRequest) GetAddedTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.AddedTime
+	}
+	return nil
+}
+
 type KeyBlockedRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1911,7 +2287,7 @@ type KeyBlockedRequest struct {
 func (x *KeyBlockedRequest) Reset() {
 	*x = KeyBlockedRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[33]
+		mi := &file_sa_proto_sa_proto_msgTypes[37]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1924,7 +2300,7 @@ func (x *KeyBlockedRequest) String() string {
 func (*KeyBlockedRequest) ProtoMessage() {}
 
 func (x *KeyBlockedRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[33]
+	mi := &file_sa_proto_sa_proto_msgTypes[37]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1937,7 +2313,7 @@ func (x *KeyBlockedRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyBlockedRequest.ProtoReflect.Descriptor instead.
 func (*KeyBlockedRequest) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{33}
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *KeyBlockedRequest) GetKeyHash() []byte {
@@ -1947,32 +2323,32 @@ func (x *KeyBlockedRequest) GetKeyHash() []byte {
 	return nil
 }
 
-type ValidAuthorizations_MapElement struct {
+type RevocationWebhook struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Domain *string               `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
-	Authz  *proto1.Authorization `protobuf:"bytes,2,opt,name=authz" json:"authz,omitempty"`
+	RegistrationID *int64  `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Url            *string `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
 }
 
-func (x *ValidAuthorizations_MapElement) Reset() {
-	*x = ValidAuthorizations_MapElement{}
+func (x *RevocationWebhook) Reset() {
+	*x = RevocationWebhook{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[34]
+		mi := &file_sa_proto_sa_proto_msgTypes[38]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ValidAuthorizations_MapElement) String() string {
+func (x *RevocationWebhook) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ValidAuthorizations_MapElement) ProtoMessage() {}
+func (*RevocationWebhook) ProtoMessage() {}
 
-func (x *ValidAuthorizations_MapElement) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[34]
+func (x *RevocationWebhook) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[38]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1983,51 +2359,55 @@ func (x *ValidAuthorizations_MapElement) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ValidAuthorizations_MapElement.ProtoReflect.Descriptor instead.
-func (*ValidAuthorizations_MapElement) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{5, 0}
+// Deprecated: Use RevocationWebhook.ProtoReflect.Descriptor instead.
+func (*RevocationWebhook) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *ValidAuthorizations_MapElement) GetDomain() string {
-	if x != nil && x.Domain != nil {
-		return *x.Domain
+func (x *RevocationWebhook) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
 	}
-	return ""
+	return 0
 }
 
-func (x *ValidAuthorizations_MapElement) GetAuthz() *proto1.Authorization {
-	if x != nil {
-		return x.Authz
+func (x *RevocationWebhook) GetUrl() string {
+	if x != nil && x.Url != nil {
+		return *x.Url
 	}
-	return nil
+	return ""
 }
 
-type CountByNames_MapElement struct {
+type PauseRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name  *string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	Count *int64  `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+	RegistrationID *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Names          []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
+	// TODO: Remove expires once every client sets expiresTime in its place.
+	// When the pause lapses, if it isn't unpaused first. Unset means never.
+	Expires     *int64               `protobuf:"varint,3,opt,name=expires" json:"expires,omitempty"` // Unix timestamp (nanoseconds)
+	ExpiresTime *timestamp.Timestamp `protobuf:"bytes,4,opt,name=expiresTime" json:"expiresTime,omitempty"`
 }
 
-func (x *CountByNames_MapElement) Reset() {
-	*x = CountByNames_MapElement{}
+func (x *PauseRequest) Reset() {
+	*x = PauseRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[35]
+		mi := &file_sa_proto_sa_proto_msgTypes[39]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CountByNames_MapElement) String() string {
+func (x *PauseRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CountByNames_MapElement) ProtoMessage() {}
+func (*PauseRequest) ProtoMessage() {}
 
-func (x *CountByNames_MapElement) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[35]
+func (x *PauseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[39]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2038,51 +2418,64 @@ func (x *CountByNames_MapElement) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CountByNames_MapElement.ProtoReflect.Descriptor instead.
-func (*CountByNames_MapElement) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{10, 0}
+// Deprecated: Use PauseRequest.ProtoReflect.Descriptor instead.
+func (*PauseRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{39}
 }
 
-func (x *CountByNames_MapElement) GetName() string {
-	if x != nil && x.Name != nil {
-		return *x.Name
+func (x *PauseRequest) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
 	}
-	return ""
+	return 0
 }
 
-func (x *CountByNames_MapElement) GetCount() int64 {
-	if x != nil && x.Count != nil {
-		return *x.Count
+func (x *PauseRequest) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+func (x *PauseRequest) GetExpires() int64 {
+	if x != nil && x.Expires != nil {
+		return *x.Expires
 	}
 	return 0
 }
 
-type Authorizations_MapElement struct {
+func (x *PauseRequest) GetExpiresTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.ExpiresTime
+	}
+	return nil
+}
+
+type PausedIdentifiers struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Domain *string               `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
-	Authz  *proto1.Authorization `protobuf:"bytes,2,opt,name=authz" json:"authz,omitempty"`
+	Names []string `protobuf:"bytes,1,rep,name=names" json:"names,omitempty"`
 }
 
-func (x *Authorizations_MapElement) Reset() {
-	*x = Authorizations_MapElement{}
+func (x *PausedIdentifiers) Reset() {
+	*x = PausedIdentifiers{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sa_proto_sa_proto_msgTypes[36]
+		mi := &file_sa_proto_sa_proto_msgTypes[40]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Authorizations_MapElement) String() string {
+func (x *PausedIdentifiers) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Authorizations_MapElement) ProtoMessage() {}
+func (*PausedIdentifiers) ProtoMessage() {}
 
-func (x *Authorizations_MapElement) ProtoReflect() protoreflect.Message {
-	mi := &file_sa_proto_sa_proto_msgTypes[36]
+func (x *PausedIdentifiers) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[40]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2093,581 +2486,2606 @@ func (x *Authorizations_MapElement) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Authorizations_MapElement.ProtoReflect.Descriptor instead.
-func (*Authorizations_MapElement) Descriptor() ([]byte, []int) {
-	return file_sa_proto_sa_proto_rawDescGZIP(), []int{25, 0}
+// Deprecated: Use PausedIdentifiers.ProtoReflect.Descriptor instead.
+func (*PausedIdentifiers) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{40}
 }
 
-func (x *Authorizations_MapElement) GetDomain() string {
-	if x != nil && x.Domain != nil {
-		return *x.Domain
+func (x *PausedIdentifiers) GetNames() []string {
+	if x != nil {
+		return x.Names
 	}
-	return ""
+	return nil
 }
 
-func (x *Authorizations_MapElement) GetAuthz() *proto1.Authorization {
+type SPKIHash struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	KeyHash []byte `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+}
+
+func (x *SPKIHash) Reset() {
+	*x = SPKIHash{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[41]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SPKIHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SPKIHash) ProtoMessage() {}
+
+func (x *SPKIHash) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[41]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SPKIHash.ProtoReflect.Descriptor instead.
+func (*SPKIHash) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *SPKIHash) GetKeyHash() []byte {
 	if x != nil {
-		return x.Authz
+		return x.KeyHash
 	}
 	return nil
 }
 
-var File_sa_proto_sa_proto protoreflect.FileDescriptor
+type Serials struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_sa_proto_sa_proto_rawDesc = []byte{
-	0x0a, 0x11, 0x73, 0x61, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x61, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x12, 0x02, 0x73, 0x61, 0x1a, 0x15, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x20,
-	0x0a, 0x0e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44,
-	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64,
-	0x22, 0x1e, 0x0a, 0x0a, 0x4a, 0x53, 0x4f, 0x4e, 0x57, 0x65, 0x62, 0x4b, 0x65, 0x79, 0x12, 0x10,
-	0x0a, 0x03, 0x6a, 0x77, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6a, 0x77, 0x6b,
-	0x22, 0x21, 0x0a, 0x0f, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x49, 0x44, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x02, 0x69, 0x64, 0x22, 0xba, 0x01, 0x0a, 0x1e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69,
-	0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e,
-	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x26,
-	0x0a, 0x0e, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69,
-	0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69,
-	0x66, 0x69, 0x65, 0x72, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x56, 0x61, 0x6c, 0x75, 0x65,
-	0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c,
-	0x22, 0x73, 0x0a, 0x1d, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68,
-	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73,
-	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x6f, 0x6d,
-	0x61, 0x69, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x64, 0x6f, 0x6d, 0x61,
-	0x69, 0x6e, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x6f, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x03, 0x6e, 0x6f, 0x77, 0x22, 0xa0, 0x01, 0x0a, 0x13, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x41,
-	0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x38, 0x0a,
-	0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x73,
-	0x61, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74,
-	0x52, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x1a, 0x4f, 0x0a, 0x0a, 0x4d, 0x61, 0x70, 0x45, 0x6c,
-	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x29, 0x0a,
-	0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x63,
-	0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x52, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x22, 0x20, 0x0a, 0x06, 0x53, 0x65, 0x72, 0x69,
-	0x61, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x22, 0x3b, 0x0a, 0x05, 0x52, 0x61,
-	0x6e, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65, 0x73, 0x74, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65, 0x73, 0x74, 0x12,
-	0x16, 0x0a, 0x06, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x06, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x22, 0x1d, 0x0a, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74,
-	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x58, 0x0a, 0x1f, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43,
-	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d,
-	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x05, 0x72, 0x61, 0x6e,
-	0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61,
-	0x6e, 0x67, 0x65, 0x52, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61,
-	0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73,
-	0x22, 0x87, 0x01, 0x0a, 0x0c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65,
-	0x73, 0x12, 0x3f, 0x0a, 0x0c, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65,
-	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75,
-	0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x2e, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65,
-	0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0c, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d,
-	0x65, 0x73, 0x1a, 0x36, 0x0a, 0x0a, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74,
-	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x50, 0x0a, 0x1d, 0x43, 0x6f,
-	0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x42, 0x79, 0x49, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69,
-	0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x69, 0x70, 0x12, 0x1f, 0x0a, 0x05, 0x72,
-	0x61, 0x6e, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e,
-	0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x22, 0x88, 0x01, 0x0a,
-	0x21, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74,
-	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69,
-	0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x6f,
-	0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68, 0x6f,
-	0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65,
-	0x52, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x22, 0x53, 0x0a, 0x12, 0x43, 0x6f, 0x75, 0x6e, 0x74,
-	0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a,
-	0x09, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x09, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x1f, 0x0a, 0x05, 0x72,
-	0x61, 0x6e, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e,
-	0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x22, 0x48, 0x0a, 0x14,
-	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65, 0x74, 0x73, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x18, 0x0a, 0x07,
-	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x64,
-	0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x22, 0x30, 0x0a, 0x14, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65,
-	0x74, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18,
-	0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
-	0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x22, 0x50, 0x0a, 0x20, 0x50, 0x72, 0x65, 0x76,
-	0x69, 0x6f, 0x75, 0x73, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x45,
-	0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06,
-	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f,
-	0x6d, 0x61, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x22, 0x20, 0x0a, 0x06, 0x45, 0x78,
-	0x69, 0x73, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x74, 0x0a, 0x10,
-	0x41, 0x64, 0x64, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x18,
-	0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69,
-	0x72, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72,
-	0x65, 0x73, 0x22, 0x87, 0x01, 0x0a, 0x15, 0x41, 0x64, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
-	0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03,
-	0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x64, 0x65, 0x72, 0x12, 0x14,
-	0x0a, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72,
-	0x65, 0x67, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x6f, 0x63, 0x73, 0x70, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x04, 0x6f, 0x63, 0x73, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x73, 0x73, 0x75,
-	0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x69, 0x73, 0x73, 0x75, 0x65, 0x64,
-	0x12, 0x1a, 0x0a, 0x08, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x49, 0x44, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x08, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x49, 0x44, 0x22, 0x30, 0x0a, 0x16,
-	0x41, 0x64, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x22, 0x50,
-	0x0a, 0x0c, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e,
-	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x30,
-	0x0a, 0x13, 0x75, 0x73, 0x65, 0x56, 0x32, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x75, 0x73, 0x65,
-	0x56, 0x32, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x22, 0x4c, 0x0a, 0x22, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x4f, 0x72, 0x64, 0x65,
-	0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x74, 0x49, 0x44,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x61, 0x63, 0x63, 0x74, 0x49, 0x44, 0x22, 0x79,
-	0x0a, 0x17, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x46, 0x6f, 0x72, 0x4e, 0x61, 0x6d,
-	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x63,
-	0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x61, 0x63, 0x63, 0x74, 0x49,
-	0x44, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x13, 0x75, 0x73, 0x65, 0x56, 0x32,
-	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x75, 0x73, 0x65, 0x56, 0x32, 0x41, 0x75, 0x74, 0x68, 0x6f,
-	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x98, 0x01, 0x0a, 0x18, 0x47, 0x65,
-	0x74, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e,
-	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x18,
-	0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52,
-	0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x6f, 0x77, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x6e, 0x6f, 0x77, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65,
-	0x71, 0x75, 0x69, 0x72, 0x65, 0x56, 0x32, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x73, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x0f, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x56, 0x32, 0x41, 0x75,
-	0x74, 0x68, 0x7a, 0x73, 0x22, 0x96, 0x01, 0x0a, 0x0e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
-	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x33, 0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a,
-	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68,
-	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x4d, 0x61, 0x70, 0x45, 0x6c,
-	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x1a, 0x4f, 0x0a, 0x0a,
-	0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f,
-	0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61,
-	0x69, 0x6e, 0x12, 0x29, 0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
-	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x22, 0x4c, 0x0a,
-	0x1f, 0x41, 0x64, 0x64, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f,
-	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x29, 0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x22, 0x24, 0x0a, 0x10, 0x41,
-	0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x73, 0x12,
-	0x10, 0x0a, 0x03, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x69, 0x64,
-	0x73, 0x22, 0x22, 0x0a, 0x10, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x49, 0x44, 0x32, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x02, 0x69, 0x64, 0x22, 0x25, 0x0a, 0x11, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
-	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x49, 0x44, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x64,
-	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x03, 0x52, 0x03, 0x69, 0x64, 0x73, 0x22, 0x7a, 0x0a, 0x18,
-	0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69,
-	0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c,
-	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x65,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x64, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08,
-	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
-	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x84, 0x02, 0x0a, 0x1c, 0x46, 0x69, 0x6e,
-	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x61,
-	0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x61, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x65, 0x64, 0x12, 0x44, 0x0a, 0x11, 0x76, 0x61, 0x6c,
-	0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x05,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x69,
-	0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x11, 0x76, 0x61,
-	0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12,
-	0x3e, 0x0a, 0x0f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x72, 0x72,
-	0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
-	0x50, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x0f,
-	0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22,
-	0x96, 0x01, 0x0a, 0x14, 0x41, 0x64, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x4b, 0x65,
-	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6b, 0x65, 0x79, 0x48,
-	0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x48, 0x61,
-	0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72,
-	0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
-	0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65,
-	0x76, 0x6f, 0x6b, 0x65, 0x64, 0x42, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72,
-	0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x42, 0x79, 0x22, 0x2d, 0x0a, 0x11, 0x4b, 0x65, 0x79, 0x42,
-	0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a,
-	0x07, 0x6b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07,
-	0x6b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x32, 0xe0, 0x12, 0x0a, 0x10, 0x53, 0x74, 0x6f, 0x72,
-	0x61, 0x67, 0x65, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x3b, 0x0a, 0x0f,
-	0x47, 0x65, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
-	0x12, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x49, 0x44, 0x1a, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
-	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x14, 0x47, 0x65, 0x74,
-	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x79, 0x4b, 0x65,
-	0x79, 0x12, 0x0e, 0x2e, 0x73, 0x61, 0x2e, 0x4a, 0x53, 0x4f, 0x4e, 0x57, 0x65, 0x62, 0x4b, 0x65,
-	0x79, 0x1a, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x31, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x43, 0x65,
-	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53,
-	0x65, 0x72, 0x69, 0x61, 0x6c, 0x1a, 0x11, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x65, 0x72,
-	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x34, 0x0a, 0x11, 0x47, 0x65,
-	0x74, 0x50, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12,
-	0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x1a, 0x11, 0x2e, 0x63, 0x6f,
-	0x72, 0x65, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x00,
-	0x12, 0x3d, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
-	0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65,
-	0x72, 0x69, 0x61, 0x6c, 0x1a, 0x17, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x65, 0x72, 0x74,
-	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12,
-	0x53, 0x0a, 0x18, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63,
-	0x61, 0x74, 0x65, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x23, 0x2e, 0x73, 0x61,
-	0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x65, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x10, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d,
-	0x65, 0x73, 0x22, 0x00, 0x12, 0x48, 0x0a, 0x16, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67,
-	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x12, 0x21,
-	0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x4d,
-	0x0a, 0x1b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x21, 0x2e,
-	0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x32, 0x0a,
-	0x0b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x12, 0x16, 0x2e, 0x73,
-	0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22,
-	0x00, 0x12, 0x36, 0x0a, 0x0d, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65,
-	0x74, 0x73, 0x12, 0x18, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x51, 0x44,
-	0x4e, 0x53, 0x65, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x73,
-	0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x37, 0x0a, 0x0d, 0x46, 0x51, 0x44,
-	0x4e, 0x53, 0x65, 0x74, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x12, 0x18, 0x2e, 0x73, 0x61, 0x2e,
-	0x46, 0x51, 0x44, 0x4e, 0x53, 0x65, 0x74, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73,
-	0x22, 0x00, 0x12, 0x4f, 0x0a, 0x19, 0x50, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x43, 0x65,
-	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x12,
-	0x24, 0x2e, 0x73, 0x61, 0x2e, 0x50, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x43, 0x65, 0x72,
-	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74,
-	0x73, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72,
-	0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12, 0x14, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x75,
-	0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x32, 0x1a, 0x13,
-	0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x48, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x41, 0x75, 0x74, 0x68,
-	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32, 0x12, 0x1c, 0x2e, 0x73, 0x61,
-	0x2e, 0x47, 0x65, 0x74, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x41,
-	0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x00, 0x12,
-	0x55, 0x0a, 0x18, 0x47, 0x65, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74,
-	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12, 0x22, 0x2e, 0x73, 0x61,
-	0x2e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f,
-	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x1b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x50,
-	0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x32, 0x12, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
-	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43,
-	0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x1c, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c,
-	0x69, 0x64, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32, 0x12, 0x26, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x56,
-	0x61, 0x6c, 0x69, 0x64, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
-	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12,
-	0x2e, 0x73, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x22, 0x00, 0x12, 0x51, 0x0a, 0x1b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x6e, 0x76,
-	0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x32, 0x12, 0x25, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x6e,
-	0x76, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e,
-	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x56, 0x61,
-	0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x32, 0x12, 0x21, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64,
-	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f,
-	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x00, 0x12, 0x31, 0x0a, 0x0a, 0x4b,
-	0x65, 0x79, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x12, 0x15, 0x2e, 0x73, 0x61, 0x2e, 0x4b,
-	0x65, 0x79, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x00, 0x12, 0x3b,
-	0x0a, 0x0f, 0x4e, 0x65, 0x77, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67,
-	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x37, 0x0a, 0x12, 0x55,
-	0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70,
-	0x74, 0x79, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69,
-	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x43,
-	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1a, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
-	0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
-	0x3d, 0x0a, 0x11, 0x41, 0x64, 0x64, 0x50, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
-	0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x43, 0x65, 0x72,
-	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x30,
-	0x0a, 0x09, 0x41, 0x64, 0x64, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x14, 0x2e, 0x73, 0x61,
-	0x2e, 0x41, 0x64, 0x64, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
-	0x12, 0x3b, 0x0a, 0x16, 0x44, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x52, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x73, 0x61, 0x2e,
-	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x0b,
-	0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x26, 0x0a,
-	0x08, 0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65,
-	0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72,
-	0x64, 0x65, 0x72, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x12, 0x53, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65,
-	0x72, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x12, 0x0b, 0x2e, 0x63, 0x6f,
-	0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x0d, 0x53, 0x65, 0x74, 0x4f, 0x72,
-	0x64, 0x65, 0x72, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
-	0x4f, 0x72, 0x64, 0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70,
-	0x74, 0x79, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x0d, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65,
-	0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64,
-	0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0x00, 0x12, 0x2b, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x10, 0x2e,
-	0x73, 0x61, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12, 0x3e,
-	0x0a, 0x10, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x46, 0x6f, 0x72, 0x4e, 0x61, 0x6d,
-	0x65, 0x73, 0x12, 0x1b, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72,
-	0x46, 0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12, 0x40,
-	0x0a, 0x11, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63,
-	0x61, 0x74, 0x65, 0x12, 0x1c, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43,
-	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
-	0x12, 0x52, 0x0a, 0x12, 0x4e, 0x65, 0x77, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32, 0x12, 0x23, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x50,
-	0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x73, 0x61,
-	0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x49,
-	0x44, 0x73, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x16, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65,
-	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12, 0x20,
-	0x2e, 0x73, 0x61, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x41, 0x75, 0x74, 0x68,
-	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12,
-	0x3f, 0x0a, 0x18, 0x44, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x41, 0x75, 0x74,
-	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12, 0x14, 0x2e, 0x73, 0x61,
-	0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44,
-	0x32, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
-	0x12, 0x38, 0x0a, 0x0d, 0x41, 0x64, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x4b, 0x65,
-	0x79, 0x12, 0x18, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65,
-	0x64, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f,
-	0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69,
-	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x74, 0x73, 0x65, 0x6e, 0x63,
-	0x72, 0x79, 0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65, 0x72, 0x2f, 0x73, 0x61, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f,
+	Serials []string `protobuf:"bytes,1,rep,name=serials" json:"serials,omitempty"`
 }
 
-var (
-	file_sa_proto_sa_proto_rawDescOnce sync.Once
-	file_sa_proto_sa_proto_rawDescData = file_sa_proto_sa_proto_rawDesc
-)
+func (x *Serials) Reset() {
+	*x = Serials{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[42]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
 
-func file_sa_proto_sa_proto_rawDescGZIP() []byte {
-	file_sa_proto_sa_proto_rawDescOnce.Do(func() {
-		file_sa_proto_sa_proto_rawDescData = protoimpl.X.CompressGZIP(file_sa_proto_sa_proto_rawDescData)
-	})
-	return file_sa_proto_sa_proto_rawDescData
+func (x *Serials) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_sa_proto_sa_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
-var file_sa_proto_sa_proto_goTypes = []interface{}{
-	(*RegistrationID)(nil),                     // 0: sa.RegistrationID
-	(*JSONWebKey)(nil),                         // 1: sa.JSONWebKey
-	(*AuthorizationID)(nil),                    // 2: sa.AuthorizationID
-	(*GetPendingAuthorizationRequest)(nil),     // 3: sa.GetPendingAuthorizationRequest
-	(*GetValidAuthorizationsRequest)(nil),      // 4: sa.GetValidAuthorizationsRequest
-	(*ValidAuthorizations)(nil),                // 5: sa.ValidAuthorizations
-	(*Serial)(nil),                             // 6: sa.Serial
-	(*Range)(nil),                              // 7: sa.Range
-	(*Count)(nil),                              // 8: sa.Count
-	(*CountCertificatesByNamesRequest)(nil),    // 9: sa.CountCertificatesByNamesRequest
-	(*CountByNames)(nil),                       // 10: sa.CountByNames
-	(*CountRegistrationsByIPRequest)(nil),      // 11: sa.CountRegistrationsByIPRequest
-	(*CountInvalidAuthorizationsRequest)(nil),  // 12: sa.CountInvalidAuthorizationsRequest
-	(*CountOrdersRequest)(nil),                 // 13: sa.CountOrdersRequest
-	(*CountFQDNSetsRequest)(nil),               // 14: sa.CountFQDNSetsRequest
-	(*FQDNSetExistsRequest)(nil),               // 15: sa.FQDNSetExistsRequest
-	(*PreviousCertificateExistsRequest)(nil),   // 16: sa.PreviousCertificateExistsRequest
-	(*Exists)(nil),                             // 17: sa.Exists
-	(*AddSerialRequest)(nil),                   // 18: sa.AddSerialRequest
-	(*AddCertificateRequest)(nil),              // 19: sa.AddCertificateRequest
-	(*AddCertificateResponse)(nil),             // 20: sa.AddCertificateResponse
-	(*OrderRequest)(nil),                       // 21: sa.OrderRequest
-	(*GetValidOrderAuthorizationsRequest)(nil), // 22: sa.GetValidOrderAuthorizationsRequest
-	(*GetOrderForNamesRequest)(nil),            // 23: sa.GetOrderForNamesRequest
-	(*GetAuthorizationsRequest)(nil),           // 24: sa.GetAuthorizationsRequest
-	(*Authorizations)(nil),                     // 25: sa.Authorizations
-	(*AddPendingAuthorizationsRequest)(nil),    // 26: sa.AddPendingAuthorizationsRequest
-	(*AuthorizationIDs)(nil),                   // 27: sa.AuthorizationIDs
-	(*AuthorizationID2)(nil),                   // 28: sa.AuthorizationID2
-	(*Authorization2IDs)(nil),                  // 29: sa.Authorization2IDs
-	(*RevokeCertificateRequest)(nil),           // 30: sa.RevokeCertificateRequest
-	(*FinalizeAuthorizationRequest)(nil),       // 31: sa.FinalizeAuthorizationRequest
-	(*AddBlockedKeyRequest)(nil),               // 32: sa.AddBlockedKeyRequest
-	(*KeyBlockedRequest)(nil),                  // 33: sa.KeyBlockedRequest
-	(*ValidAuthorizations_MapElement)(nil),     // 34: sa.ValidAuthorizations.MapElement
-	(*CountByNames_MapElement)(nil),            // 35: sa.CountByNames.MapElement
-	(*Authorizations_MapElement)(nil),          // 36: sa.Authorizations.MapElement
-	(*proto1.Authorization)(nil),               // 37: core.Authorization
-	(*proto1.ValidationRecord)(nil),            // 38: core.ValidationRecord
-	(*proto1.ProblemDetails)(nil),              // 39: core.ProblemDetails
-	(*proto1.Registration)(nil),                // 40: core.Registration
-	(*proto1.Order)(nil),                       // 41: core.Order
-	(*proto1.Certificate)(nil),                 // 42: core.Certificate
-	(*proto1.CertificateStatus)(nil),           // 43: core.CertificateStatus
-	(*proto1.Empty)(nil),                       // 44: core.Empty
+func (*Serials) ProtoMessage() {}
+
+func (x *Serials) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[42]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_sa_proto_sa_proto_depIdxs = []int32{
-	34, // 0: sa.ValidAuthorizations.valid:type_name -> sa.ValidAuthorizations.MapElement
-	7,  // 1: sa.CountCertificatesByNamesRequest.range:type_name -> sa.Range
-	35, // 2: sa.CountByNames.countByNames:type_name -> sa.CountByNames.MapElement
-	7,  // 3: sa.CountRegistrationsByIPRequest.range:type_name -> sa.Range
-	7,  // 4: sa.CountInvalidAuthorizationsRequest.range:type_name -> sa.Range
-	7,  // 5: sa.CountOrdersRequest.range:type_name -> sa.Range
-	36, // 6: sa.Authorizations.authz:type_name -> sa.Authorizations.MapElement
-	37, // 7: sa.AddPendingAuthorizationsRequest.authz:type_name -> core.Authorization
-	38, // 8: sa.FinalizeAuthorizationRequest.validationRecords:type_name -> core.ValidationRecord
-	39, // 9: sa.FinalizeAuthorizationRequest.validationError:type_name -> core.ProblemDetails
-	37, // 10: sa.ValidAuthorizations.MapElement.authz:type_name -> core.Authorization
-	37, // 11: sa.Authorizations.MapElement.authz:type_name -> core.Authorization
-	0,  // 12: sa.StorageAuthority.GetRegistration:input_type -> sa.RegistrationID
-	1,  // 13: sa.StorageAuthority.GetRegistrationByKey:input_type -> sa.JSONWebKey
-	6,  // 14: sa.StorageAuthority.GetCertificate:input_type -> sa.Serial
-	6,  // 15: sa.StorageAuthority.GetPrecertificate:input_type -> sa.Serial
-	6,  // 16: sa.StorageAuthority.GetCertificateStatus:input_type -> sa.Serial
-	9,  // 17: sa.StorageAuthority.CountCertificatesByNames:input_type -> sa.CountCertificatesByNamesRequest
-	11, // 18: sa.StorageAuthority.CountRegistrationsByIP:input_type -> sa.CountRegistrationsByIPRequest
-	11, // 19: sa.StorageAuthority.CountRegistrationsByIPRange:input_type -> sa.CountRegistrationsByIPRequest
-	13, // 20: sa.StorageAuthority.CountOrders:input_type -> sa.CountOrdersRequest
-	14, // 21: sa.StorageAuthority.CountFQDNSets:input_type -> sa.CountFQDNSetsRequest
-	15, // 22: sa.StorageAuthority.FQDNSetExists:input_type -> sa.FQDNSetExistsRequest
-	16, // 23: sa.StorageAuthority.PreviousCertificateExists:input_type -> sa.PreviousCertificateExistsRequest
-	28, // 24: sa.StorageAuthority.GetAuthorization2:input_type -> sa.AuthorizationID2
-	24, // 25: sa.StorageAuthority.GetAuthorizations2:input_type -> sa.GetAuthorizationsRequest
-	3,  // 26: sa.StorageAuthority.GetPendingAuthorization2:input_type -> sa.GetPendingAuthorizationRequest
-	0,  // 27: sa.StorageAuthority.CountPendingAuthorizations2:input_type -> sa.RegistrationID
-	22, // 28: sa.StorageAuthority.GetValidOrderAuthorizations2:input_type -> sa.GetValidOrderAuthorizationsRequest
-	12, // 29: sa.StorageAuthority.CountInvalidAuthorizations2:input_type -> sa.CountInvalidAuthorizationsRequest
-	4,  // 30: sa.StorageAuthority.GetValidAuthorizations2:input_type -> sa.GetValidAuthorizationsRequest
-	33, // 31: sa.StorageAuthority.KeyBlocked:input_type -> sa.KeyBlockedRequest
-	40, // 32: sa.StorageAuthority.NewRegistration:input_type -> core.Registration
-	40, // 33: sa.StorageAuthority.UpdateRegistration:input_type -> core.Registration
-	19, // 34: sa.StorageAuthority.AddCertificate:input_type -> sa.AddCertificateRequest
-	19, // 35: sa.StorageAuthority.AddPrecertificate:input_type -> sa.AddCertificateRequest
-	18, // 36: sa.StorageAuthority.AddSerial:input_type -> sa.AddSerialRequest
-	0,  // 37: sa.StorageAuthority.DeactivateRegistration:input_type -> sa.RegistrationID
-	41, // 38: sa.StorageAuthority.NewOrder:input_type -> core.Order
-	41, // 39: sa.StorageAuthority.SetOrderProcessing:input_type -> core.Order
-	41, // 40: sa.StorageAuthority.SetOrderError:input_type -> core.Order
-	41, // 41: sa.StorageAuthority.FinalizeOrder:input_type -> core.Order
-	21, // 42: sa.StorageAuthority.GetOrder:input_type -> sa.OrderRequest
-	23, // 43: sa.StorageAuthority.GetOrderForNames:input_type -> sa.GetOrderForNamesRequest
-	30, // 44: sa.StorageAuthority.RevokeCertificate:input_type -> sa.RevokeCertificateRequest
-	26, // 45: sa.StorageAuthority.NewAuthorizations2:input_type -> sa.AddPendingAuthorizationsRequest
-	31, // 46: sa.StorageAuthority.FinalizeAuthorization2:input_type -> sa.FinalizeAuthorizationRequest
-	28, // 47: sa.StorageAuthority.DeactivateAuthorization2:input_type -> sa.AuthorizationID2
-	32, // 48: sa.StorageAuthority.AddBlockedKey:input_type -> sa.AddBlockedKeyRequest
-	40, // 49: sa.StorageAuthority.GetRegistration:output_type -> core.Registration
-	40, // 50: sa.StorageAuthority.GetRegistrationByKey:output_type -> core.Registration
-	42, // 51: sa.StorageAuthority.GetCertificate:output_type -> core.Certificate
-	42, // 52: sa.StorageAuthority.GetPrecertificate:output_type -> core.Certificate
-	43, // 53: sa.StorageAuthority.GetCertificateStatus:output_type -> core.CertificateStatus
-	10, // 54: sa.StorageAuthority.CountCertificatesByNames:output_type -> sa.CountByNames
-	8,  // 55: sa.StorageAuthority.CountRegistrationsByIP:output_type -> sa.Count
-	8,  // 56: sa.StorageAuthority.CountRegistrationsByIPRange:output_type -> sa.Count
-	8,  // 57: sa.StorageAuthority.CountOrders:output_type -> sa.Count
-	8,  // 58: sa.StorageAuthority.CountFQDNSets:output_type -> sa.Count
-	17, // 59: sa.StorageAuthority.FQDNSetExists:output_type -> sa.Exists
-	17, // 60: sa.StorageAuthority.PreviousCertificateExists:output_type -> sa.Exists
-	37, // 61: sa.StorageAuthority.GetAuthorization2:output_type -> core.Authorization
-	25, // 62: sa.StorageAuthority.GetAuthorizations2:output_type -> sa.Authorizations
-	37, // 63: sa.StorageAuthority.GetPendingAuthorization2:output_type -> core.Authorization
-	8,  // 64: sa.StorageAuthority.CountPendingAuthorizations2:output_type -> sa.Count
-	25, // 65: sa.StorageAuthority.GetValidOrderAuthorizations2:output_type -> sa.Authorizations
-	8,  // 66: sa.StorageAuthority.CountInvalidAuthorizations2:output_type -> sa.Count
-	25, // 67: sa.StorageAuthority.GetValidAuthorizations2:output_type -> sa.Authorizations
-	17, // 68: sa.StorageAuthority.KeyBlocked:output_type -> sa.Exists
-	40, // 69: sa.StorageAuthority.NewRegistration:output_type -> core.Registration
-	44, // 70: sa.StorageAuthority.UpdateRegistration:output_type -> core.Empty
-	20, // 71: sa.StorageAuthority.AddCertificate:output_type -> sa.AddCertificateResponse
-	44, // 72: sa.StorageAuthority.AddPrecertificate:output_type -> core.Empty
-	44, // 73: sa.StorageAuthority.AddSerial:output_type -> core.Empty
-	44, // 74: sa.StorageAuthority.DeactivateRegistration:output_type -> core.Empty
-	41, // 75: sa.StorageAuthority.NewOrder:output_type -> core.Order
-	44, // 76: sa.StorageAuthority.SetOrderProcessing:output_type -> core.Empty
-	44, // 77: sa.StorageAuthority.SetOrderError:output_type -> core.Empty
-	44, // 78: sa.StorageAuthority.FinalizeOrder:output_type -> core.Empty
-	41, // 79: sa.StorageAuthority.GetOrder:output_type -> core.Order
-	41, // 80: sa.StorageAuthority.GetOrderForNames:output_type -> core.Order
-	44, // 81: sa.StorageAuthority.RevokeCertificate:output_type -> core.Empty
-	29, // 82: sa.StorageAuthority.NewAuthorizations2:output_type -> sa.Authorization2IDs
-	44, // 83: sa.StorageAuthority.FinalizeAuthorization2:output_type -> core.Empty
-	44, // 84: sa.StorageAuthority.DeactivateAuthorization2:output_type -> core.Empty
-	44, // 85: sa.StorageAuthority.AddBlockedKey:output_type -> core.Empty
-	49, // [49:86] is the sub-list for method output_type
-	12, // [12:49] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+
+// Deprecated: Use Serials.ProtoReflect.Descriptor instead.
+func (*Serials) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{42}
 }
 
-func init() { file_sa_proto_sa_proto_init() }
-func file_sa_proto_sa_proto_init() {
-	if File_sa_proto_sa_proto != nil {
-		return
+func (x *Serials) GetSerials() []string {
+	if x != nil {
+		return x.Serials
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_sa_proto_sa_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RegistrationID); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_sa_proto_sa_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*JSONWebKey); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_sa_proto_sa_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AuthorizationID); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
+	return nil
+}
+
+type GetRevokedCertsByShardRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IssuerID *int64 `protobuf:"varint,1,opt,name=issuerID" json:"issuerID,omitempty"`
+	ShardIdx *int64 `protobuf:"varint,2,opt,name=shardIdx" json:"shardIdx,omitempty"`
+	// TODO: Remove expiresAfter and revokedBefore once every client sets
+	// expiresAfterTime and revokedBeforeTime in their place.
+	ExpiresAfter      *int64               `protobuf:"varint,3,opt,name=expiresAfter" json:"expiresAfter,omitempty"`   // Unix timestamp (nanoseconds)
+	RevokedBefore     *int64               `protobuf:"varint,4,opt,name=revokedBefore" json:"revokedBefore,omitempty"` // Unix timestamp (nanoseconds)
+	ExpiresAfterTime  *timestamp.Timestamp `protobuf:"bytes,5,opt,name=expiresAfterTime" json:"expiresAfterTime,omitempty"`
+	RevokedBeforeTime *timestamp.Timestamp `protobuf:"bytes,6,opt,name=revokedBeforeTime" json:"revokedBeforeTime,omitempty"`
+}
+
+func (x *GetRevokedCertsByShardRequest) Reset() {
+	*x = GetRevokedCertsByShardRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[43]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRevokedCertsByShardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRevokedCertsByShardRequest) ProtoMessage() {}
+
+func (x *GetRevokedCertsByShardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[43]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
-		file_sa_proto_sa_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetPendingAuthorizationRequest); i {
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRevokedCertsByShardRequest.ProtoReflect.Descriptor instead.
+func (*GetRevokedCertsByShardRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *GetRevokedCertsByShardRequest) GetIssuerID() int64 {
+	if x != nil && x.IssuerID != nil {
+		return *x.IssuerID
+	}
+	return 0
+}
+
+func (x *GetRevokedCertsByShardRequest) GetShardIdx() int64 {
+	if x != nil && x.ShardIdx != nil {
+		return *x.ShardIdx
+	}
+	return 0
+}
+
+func (x *GetRevokedCertsByShardRequest) GetExpiresAfter() int64 {
+	if x != nil && x.ExpiresAfter != nil {
+		return *x.ExpiresAfter
+	}
+	return 0
+}
+
+func (x *GetRevokedCertsByShardRequest) GetRevokedBefore() int64 {
+	if x != nil && x.RevokedBefore != nil {
+		return *x.RevokedBefore
+	}
+	return 0
+}
+
+func (x *GetRevokedCertsByShardRequest) GetExpiresAfterTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.ExpiresAfterTime
+	}
+	return nil
+}
+
+func (x *GetRevokedCertsByShardRequest) GetRevokedBeforeTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.RevokedBeforeTime
+	}
+	return nil
+}
+
+type RevokedCert struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Serial *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	Reason *int64  `protobuf:"varint,2,opt,name=reason" json:"reason,omitempty"`
+	// TODO: Remove revokedDate and notAfter once every client reads
+	// revokedDateTime and notAfterTime in their place.
+	RevokedDate     *int64               `protobuf:"varint,3,opt,name=revokedDate" json:"revokedDate,omitempty"` // Unix timestamp (nanoseconds)
+	NotAfter        *int64               `protobuf:"varint,4,opt,name=notAfter" json:"notAfter,omitempty"`       // Unix timestamp (nanoseconds)
+	RevokedDateTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=revokedDateTime" json:"revokedDateTime,omitempty"`
+	NotAfterTime    *timestamp.Timestamp `protobuf:"bytes,6,opt,name=notAfterTime" json:"notAfterTime,omitempty"`
+}
+
+func (x *RevokedCert) Reset() {
+	*x = RevokedCert{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[44]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokedCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokedCert) ProtoMessage() {}
+
+func (x *RevokedCert) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[44]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokedCert.ProtoReflect.Descriptor instead.
+func (*RevokedCert) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *RevokedCert) GetSerial() string {
+	if x != nil && x.Serial != nil {
+		return *x.Serial
+	}
+	return ""
+}
+
+func (x *RevokedCert) GetReason() int64 {
+	if x != nil && x.Reason != nil {
+		return *x.Reason
+	}
+	return 0
+}
+
+func (x *RevokedCert) GetRevokedDate() int64 {
+	if x != nil && x.RevokedDate != nil {
+		return *x.RevokedDate
+	}
+	return 0
+}
+
+func (x *RevokedCert) GetNotAfter() int64 {
+	if x != nil && x.NotAfter != nil {
+		return *x.NotAfter
+	}
+	return 0
+}
+
+func (x *RevokedCert) GetRevokedDateTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.RevokedDateTime
+	}
+	return nil
+}
+
+func (x *RevokedCert) GetNotAfterTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.NotAfterTime
+	}
+	return nil
+}
+
+type RevokedCerts struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Certs []*RevokedCert `protobuf:"bytes,1,rep,name=certs" json:"certs,omitempty"`
+}
+
+func (x *RevokedCerts) Reset() {
+	*x = RevokedCerts{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[45]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokedCerts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokedCerts) ProtoMessage() {}
+
+func (x *RevokedCerts) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[45]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokedCerts.ProtoReflect.Descriptor instead.
+func (*RevokedCerts) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *RevokedCerts) GetCerts() []*RevokedCert {
+	if x != nil {
+		return x.Certs
+	}
+	return nil
+}
+
+type Incident struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id  *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Url *string `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
+	// TODO: Remove renewBy once every client reads renewByTime in its place.
+	RenewBy     *int64               `protobuf:"varint,3,opt,name=renewBy" json:"renewBy,omitempty"` // Unix timestamp (nanoseconds)
+	Enabled     *bool                `protobuf:"varint,4,opt,name=enabled" json:"enabled,omitempty"`
+	RenewByTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=renewByTime" json:"renewByTime,omitempty"`
+}
+
+func (x *Incident) Reset() {
+	*x = Incident{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[46]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Incident) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Incident) ProtoMessage() {}
+
+func (x *Incident) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[46]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Incident.ProtoReflect.Descriptor instead.
+func (*Incident) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *Incident) GetId() int64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *Incident) GetUrl() string {
+	if x != nil && x.Url != nil {
+		return *x.Url
+	}
+	return ""
+}
+
+func (x *Incident) GetRenewBy() int64 {
+	if x != nil && x.RenewBy != nil {
+		return *x.RenewBy
+	}
+	return 0
+}
+
+func (x *Incident) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return false
+}
+
+func (x *Incident) GetRenewByTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.RenewByTime
+	}
+	return nil
+}
+
+type Incidents struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Incidents []*Incident `protobuf:"bytes,1,rep,name=incidents" json:"incidents,omitempty"`
+}
+
+func (x *Incidents) Reset() {
+	*x = Incidents{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[47]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Incidents) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Incidents) ProtoMessage() {}
+
+func (x *Incidents) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[47]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Incidents.ProtoReflect.Descriptor instead.
+func (*Incidents) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *Incidents) GetIncidents() []*Incident {
+	if x != nil {
+		return x.Incidents
+	}
+	return nil
+}
+
+type SerialsForIncidentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IncidentID *int64 `protobuf:"varint,1,opt,name=incidentID" json:"incidentID,omitempty"`
+}
+
+func (x *SerialsForIncidentRequest) Reset() {
+	*x = SerialsForIncidentRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[48]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SerialsForIncidentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialsForIncidentRequest) ProtoMessage() {}
+
+func (x *SerialsForIncidentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[48]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialsForIncidentRequest.ProtoReflect.Descriptor instead.
+func (*SerialsForIncidentRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *SerialsForIncidentRequest) GetIncidentID() int64 {
+	if x != nil && x.IncidentID != nil {
+		return *x.IncidentID
+	}
+	return 0
+}
+
+type GetIncidentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id *int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (x *GetIncidentRequest) Reset() {
+	*x = GetIncidentRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[49]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetIncidentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIncidentRequest) ProtoMessage() {}
+
+func (x *GetIncidentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[49]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIncidentRequest.ProtoReflect.Descriptor instead.
+func (*GetIncidentRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *GetIncidentRequest) GetId() int64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+type AddIncidentSerialRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IncidentID     *int64  `protobuf:"varint,1,opt,name=incidentID" json:"incidentID,omitempty"`
+	Serial         *string `protobuf:"bytes,2,opt,name=serial" json:"serial,omitempty"`
+	RegistrationID *int64  `protobuf:"varint,3,opt,name=registrationID" json:"registrationID,omitempty"` // May be 0 (unknown)
+	OrderID        *int64  `protobuf:"varint,4,opt,name=orderID" json:"orderID,omitempty"`               // May be 0 (unknown)
+}
+
+func (x *AddIncidentSerialRequest) Reset() {
+	*x = AddIncidentSerialRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[50]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddIncidentSerialRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddIncidentSerialRequest) ProtoMessage() {}
+
+func (x *AddIncidentSerialRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[50]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddIncidentSerialRequest.ProtoReflect.Descriptor instead.
+func (*AddIncidentSerialRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *AddIncidentSerialRequest) GetIncidentID() int64 {
+	if x != nil && x.IncidentID != nil {
+		return *x.IncidentID
+	}
+	return 0
+}
+
+func (x *AddIncidentSerialRequest) GetSerial() string {
+	if x != nil && x.Serial != nil {
+		return *x.Serial
+	}
+	return ""
+}
+
+func (x *AddIncidentSerialRequest) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
+	}
+	return 0
+}
+
+func (x *AddIncidentSerialRequest) GetOrderID() int64 {
+	if x != nil && x.OrderID != nil {
+		return *x.OrderID
+	}
+	return 0
+}
+
+type IncidentSerial struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Serial         *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	RegistrationID *int64  `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"` // May be 0 (unknown)
+	OrderID        *int64  `protobuf:"varint,3,opt,name=orderID" json:"orderID,omitempty"`               // May be 0 (unknown)
+	// TODO: Remove lastNoticeSent once every client reads lastNoticeSentTime in
+	// its place.
+	LastNoticeSent     *int64               `protobuf:"varint,4,opt,name=lastNoticeSent" json:"lastNoticeSent,omitempty"` // Unix timestamp (nanoseconds), may be 0 (never)
+	LastNoticeSentTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=lastNoticeSentTime" json:"lastNoticeSentTime,omitempty"`
+}
+
+func (x *IncidentSerial) Reset() {
+	*x = IncidentSerial{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[51]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IncidentSerial) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncidentSerial) ProtoMessage() {}
+
+func (x *IncidentSerial) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[51]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncidentSerial.ProtoReflect.Descriptor instead.
+func (*IncidentSerial) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *IncidentSerial) GetSerial() string {
+	if x != nil && x.Serial != nil {
+		return *x.Serial
+	}
+	return ""
+}
+
+func (x *IncidentSerial) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
+	}
+	return 0
+}
+
+func (x *IncidentSerial) GetOrderID() int64 {
+	if x != nil && x.OrderID != nil {
+		return *x.OrderID
+	}
+	return 0
+}
+
+func (x *IncidentSerial) GetLastNoticeSent() int64 {
+	if x != nil && x.LastNoticeSent != nil {
+		return *x.LastNoticeSent
+	}
+	return 0
+}
+
+func (x *IncidentSerial) GetLastNoticeSentTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.LastNoticeSentTime
+	}
+	return nil
+}
+
+type IssuanceFailure struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Serial *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	Detail *string `protobuf:"bytes,2,opt,name=detail" json:"detail,omitempty"`
+}
+
+func (x *IssuanceFailure) Reset() {
+	*x = IssuanceFailure{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[52]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IssuanceFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuanceFailure) ProtoMessage() {}
+
+func (x *IssuanceFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[52]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuanceFailure.ProtoReflect.Descriptor instead.
+func (*IssuanceFailure) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *IssuanceFailure) GetSerial() string {
+	if x != nil && x.Serial != nil {
+		return *x.Serial
+	}
+	return ""
+}
+
+func (x *IssuanceFailure) GetDetail() string {
+	if x != nil && x.Detail != nil {
+		return *x.Detail
+	}
+	return ""
+}
+
+type IssuanceOutcome struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Serial         *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	RegistrationID *int64  `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
+	// TODO: Remove precertIssued and updated once every client reads
+	// precertIssuedTime and updatedTime in their place.
+	PrecertIssued     *int64               `protobuf:"varint,3,opt,name=precertIssued" json:"precertIssued,omitempty"` // Unix timestamp (nanoseconds)
+	Outcome           *string              `protobuf:"bytes,4,opt,name=outcome" json:"outcome,omitempty"`              // "pending", "issued" or "failed"
+	Updated           *int64               `protobuf:"varint,5,opt,name=updated" json:"updated,omitempty"`             // Unix timestamp (nanoseconds)
+	Detail            *string              `protobuf:"bytes,6,opt,name=detail" json:"detail,omitempty"`                // Why finalization failed, may be empty
+	PrecertIssuedTime *timestamp.Timestamp `protobuf:"bytes,7,opt,name=precertIssuedTime" json:"precertIssuedTime,omitempty"`
+	UpdatedTime       *timestamp.Timestamp `protobuf:"bytes,8,opt,name=updatedTime" json:"updatedTime,omitempty"`
+}
+
+func (x *IssuanceOutcome) Reset() {
+	*x = IssuanceOutcome{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[53]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IssuanceOutcome) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuanceOutcome) ProtoMessage() {}
+
+func (x *IssuanceOutcome) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[53]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuanceOutcome.ProtoReflect.Descriptor instead.
+func (*IssuanceOutcome) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *IssuanceOutcome) GetSerial() string {
+	if x != nil && x.Serial != nil {
+		return *x.Serial
+	}
+	return ""
+}
+
+func (x *IssuanceOutcome) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
+	}
+	return 0
+}
+
+func (x *IssuanceOutcome) GetPrecertIssued() int64 {
+	if x != nil && x.PrecertIssued != nil {
+		return *x.PrecertIssued
+	}
+	return 0
+}
+
+func (x *IssuanceOutcome) GetOutcome() string {
+	if x != nil && x.Outcome != nil {
+		return *x.Outcome
+	}
+	return ""
+}
+
+func (x *IssuanceOutcome) GetUpdated() int64 {
+	if x != nil && x.Updated != nil {
+		return *x.Updated
+	}
+	return 0
+}
+
+func (x *IssuanceOutcome) GetDetail() string {
+	if x != nil && x.Detail != nil {
+		return *x.Detail
+	}
+	return ""
+}
+
+func (x *IssuanceOutcome) GetPrecertIssuedTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.PrecertIssuedTime
+	}
+	return nil
+}
+
+func (x *IssuanceOutcome) GetUpdatedTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.UpdatedTime
+	}
+	return nil
+}
+
+type RateLimitOverride struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id        *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`              // Assigned by the SA when the override is added
+	LimitName *string `protobuf:"bytes,2,opt,name=limitName" json:"limitName,omitempty"` // As named in the rate limit policy file
+	// Exactly one of registrationID and key is set, depending on whether the
+	// override applies to an account or to a limit's key, such as a domain.
+	RegistrationID *int64  `protobuf:"varint,3,opt,name=registrationID" json:"registrationID,omitempty"`
+	Key            *string `protobuf:"bytes,4,opt,name=key" json:"key,omitempty"`
+	Threshold      *int64  `protobuf:"varint,5,opt,name=threshold" json:"threshold,omitempty"`
+	Reason         *string `protobuf:"bytes,6,opt,name=reason" json:"reason,omitempty"`
+	Requester      *string `protobuf:"bytes,7,opt,name=requester" json:"requester,omitempty"`
+	// TODO: Remove created and expires once every client uses createdTime and
+	// expiresTime in their place.
+	Created     *int64               `protobuf:"varint,8,opt,name=created" json:"created,omitempty"`     // Unix timestamp (nanoseconds)
+	Expires     *int64               `protobuf:"varint,9,opt,name=expires" json:"expires,omitempty"`     // Unix timestamp (nanoseconds)
+	ExpiredBy   *string              `protobuf:"bytes,10,opt,name=expiredBy" json:"expiredBy,omitempty"` // Set if expired early, may be empty
+	CreatedTime *timestamp.Timestamp `protobuf:"bytes,11,opt,name=createdTime" json:"createdTime,omitempty"`
+	ExpiresTime *timestamp.Timestamp `protobuf:"bytes,12,opt,name=expiresTime" json:"expiresTime,omitempty"`
+}
+
+func (x *RateLimitOverride) Reset() {
+	*x = RateLimitOverride{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[54]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RateLimitOverride) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitOverride) ProtoMessage() {}
+
+func (x *RateLimitOverride) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[54]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitOverride.ProtoReflect.Descriptor instead.
+func (*RateLimitOverride) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *RateLimitOverride) GetId() int64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *RateLimitOverride) GetLimitName() string {
+	if x != nil && x.LimitName != nil {
+		return *x.LimitName
+	}
+	return ""
+}
+
+func (x *RateLimitOverride) GetRegistrationID() int64 {
+	if x != nil && x.RegistrationID != nil {
+		return *x.RegistrationID
+	}
+	return 0
+}
+
+func (x *RateLimitOverride) GetKey() string {
+	if x != nil && x.Key != nil {
+		return *x.Key
+	}
+	return ""
+}
+
+func (x *RateLimitOverride) GetThreshold() int64 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+func (x *RateLimitOverride) GetReason() string {
+	if x != nil && x.Reason != nil {
+		return *x.Reason
+	}
+	return ""
+}
+
+func (x *RateLimitOverride) GetRequester() string {
+	if x != nil && x.Requester != nil {
+		return *x.Requester
+	}
+	return ""
+}
+
+func (x *RateLimitOverride) GetCreated() int64 {
+	if x != nil && x.Created != nil {
+		return *x.Created
+	}
+	return 0
+}
+
+func (x *RateLimitOverride) GetExpires() int64 {
+	if x != nil && x.Expires != nil {
+		return *x.Expires
+	}
+	return 0
+}
+
+func (x *RateLimitOverride) GetExpiredBy() string {
+	if x != nil && x.ExpiredBy != nil {
+		return *x.ExpiredBy
+	}
+	return ""
+}
+
+func (x *RateLimitOverride) GetCreatedTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return nil
+}
+
+func (x *RateLimitOverride) GetExpiresTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.ExpiresTime
+	}
+	return nil
+}
+
+type RateLimitOverrides struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Overrides []*RateLimitOverride `protobuf:"bytes,1,rep,name=overrides" json:"overrides,omitempty"`
+}
+
+func (x *RateLimitOverrides) Reset() {
+	*x = RateLimitOverrides{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[55]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RateLimitOverrides) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitOverrides) ProtoMessage() {}
+
+func (x *RateLimitOverrides) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[55]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitOverrides.ProtoReflect.Descriptor instead.
+func (*RateLimitOverrides) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *RateLimitOverrides) GetOverrides() []*RateLimitOverride {
+	if x != nil {
+		return x.Overrides
+	}
+	return nil
+}
+
+type GetRateLimitOverridesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Whether to include overrides which have expired, for auditing.
+	IncludeExpired *bool `protobuf:"varint,1,opt,name=includeExpired" json:"includeExpired,omitempty"`
+}
+
+func (x *GetRateLimitOverridesRequest) Reset() {
+	*x = GetRateLimitOverridesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[56]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRateLimitOverridesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRateLimitOverridesRequest) ProtoMessage() {}
+
+func (x *GetRateLimitOverridesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[56]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRateLimitOverridesRequest.ProtoReflect.Descriptor instead.
+func (*GetRateLimitOverridesRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *GetRateLimitOverridesRequest) GetIncludeExpired() bool {
+	if x != nil && x.IncludeExpired != nil {
+		return *x.IncludeExpired
+	}
+	return false
+}
+
+type ExpireRateLimitOverrideRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id        *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Requester *string `protobuf:"bytes,2,opt,name=requester" json:"requester,omitempty"`
+}
+
+func (x *ExpireRateLimitOverrideRequest) Reset() {
+	*x = ExpireRateLimitOverrideRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[57]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpireRateLimitOverrideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireRateLimitOverrideRequest) ProtoMessage() {}
+
+func (x *ExpireRateLimitOverrideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[57]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireRateLimitOverrideRequest.ProtoReflect.Descriptor instead.
+func (*ExpireRateLimitOverrideRequest) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *ExpireRateLimitOverrideRequest) GetId() int64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *ExpireRateLimitOverrideRequest) GetRequester() string {
+	if x != nil && x.Requester != nil {
+		return *x.Requester
+	}
+	return ""
+}
+
+type ValidAuthorizations_MapElement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Domain *string               `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
+	Authz  *proto1.Authorization `protobuf:"bytes,2,opt,name=authz" json:"authz,omitempty"`
+}
+
+func (x *ValidAuthorizations_MapElement) Reset() {
+	*x = ValidAuthorizations_MapElement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[58]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidAuthorizations_MapElement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidAuthorizations_MapElement) ProtoMessage() {}
+
+func (x *ValidAuthorizations_MapElement) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[58]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidAuthorizations_MapElement.ProtoReflect.Descriptor instead.
+func (*ValidAuthorizations_MapElement) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{5, 0}
+}
+
+func (x *ValidAuthorizations_MapElement) GetDomain() string {
+	if x != nil && x.Domain != nil {
+		return *x.Domain
+	}
+	return ""
+}
+
+func (x *ValidAuthorizations_MapElement) GetAuthz() *proto1.Authorization {
+	if x != nil {
+		return x.Authz
+	}
+	return nil
+}
+
+type CountByNames_MapElement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  *string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Count *int64  `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (x *CountByNames_MapElement) Reset() {
+	*x = CountByNames_MapElement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[59]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountByNames_MapElement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountByNames_MapElement) ProtoMessage() {}
+
+func (x *CountByNames_MapElement) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[59]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountByNames_MapElement.ProtoReflect.Descriptor instead.
+func (*CountByNames_MapElement) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{11, 0}
+}
+
+func (x *CountByNames_MapElement) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *CountByNames_MapElement) GetCount() int64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+type Authorizations_MapElement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Domain *string               `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
+	Authz  *proto1.Authorization `protobuf:"bytes,2,opt,name=authz" json:"authz,omitempty"`
+}
+
+func (x *Authorizations_MapElement) Reset() {
+	*x = Authorizations_MapElement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sa_proto_sa_proto_msgTypes[60]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Authorizations_MapElement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Authorizations_MapElement) ProtoMessage() {}
+
+func (x *Authorizations_MapElement) ProtoReflect() protoreflect.Message {
+	mi := &file_sa_proto_sa_proto_msgTypes[60]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Authorizations_MapElement.ProtoReflect.Descriptor instead.
+func (*Authorizations_MapElement) Descriptor() ([]byte, []int) {
+	return file_sa_proto_sa_proto_rawDescGZIP(), []int{28, 0}
+}
+
+func (x *Authorizations_MapElement) GetDomain() string {
+	if x != nil && x.Domain != nil {
+		return *x.Domain
+	}
+	return ""
+}
+
+func (x *Authorizations_MapElement) GetAuthz() *proto1.Authorization {
+	if x != nil {
+		return x.Authz
+	}
+	return nil
+}
+
+var File_sa_proto_sa_proto protoreflect.FileDescriptor
+
+var file_sa_proto_sa_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x73, 0x61, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x61, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x02, 0x73, 0x61, 0x1a, 0x15, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0x20, 0x0a, 0x0e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x44, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69,
+	0x64, 0x22, 0x1e, 0x0a, 0x0a, 0x4a, 0x53, 0x4f, 0x4e, 0x57, 0x65, 0x62, 0x4b, 0x65, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x6a, 0x77, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6a, 0x77,
+	0x6b, 0x22, 0x21, 0x0a, 0x0f, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x49, 0x44, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x69, 0x64, 0x22, 0xfe, 0x01, 0x0a, 0x1e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x6e, 0x64,
+	0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12,
+	0x26, 0x0a, 0x0e, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x54, 0x79, 0x70,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66,
+	0x69, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x69, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x66, 0x69, 0x65, 0x72, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69,
+	0x6c, 0x12, 0x42, 0x0a, 0x0e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69, 0x6c, 0x54,
+	0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69,
+	0x6c, 0x54, 0x69, 0x6d, 0x65, 0x22, 0xa9, 0x01, 0x0a, 0x1d, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c,
+	0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12,
+	0x18, 0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x6f, 0x77,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x6e, 0x6f, 0x77, 0x12, 0x34, 0x0a, 0x07, 0x6e,
+	0x6f, 0x77, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x6e, 0x6f, 0x77, 0x54, 0x69, 0x6d,
+	0x65, 0x22, 0xa0, 0x01, 0x0a, 0x13, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f,
+	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x38, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x73, 0x61, 0x2e, 0x56, 0x61,
+	0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x2e, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x69, 0x64, 0x1a, 0x4f, 0x0a, 0x0a, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x29, 0x0a, 0x05, 0x61, 0x75, 0x74,
+	0x68, 0x7a, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x61,
+	0x75, 0x74, 0x68, 0x7a, 0x22, 0x20, 0x0a, 0x06, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x22, 0x80, 0x02, 0x0a, 0x0e, 0x53, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72,
+	0x69, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x12, 0x3c, 0x0a,
+	0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3c, 0x0a, 0x0b, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x22, 0xb7, 0x01, 0x0a, 0x05, 0x52, 0x61,
+	0x6e, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65, 0x73, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x12, 0x3e, 0x0a, 0x0c, 0x65, 0x61, 0x72, 0x6c, 0x69,
+	0x65, 0x73, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0c, 0x65, 0x61, 0x72, 0x6c, 0x69,
+	0x65, 0x73, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3a, 0x0a, 0x0a, 0x6c, 0x61, 0x74, 0x65, 0x73,
+	0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x54,
+	0x69, 0x6d, 0x65, 0x22, 0x1d, 0x0a, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x22, 0x58, 0x0a, 0x1f, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52,
+	0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x87, 0x01, 0x0a,
+	0x0c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x3f, 0x0a,
+	0x0c, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79,
+	0x4e, 0x61, 0x6d, 0x65, 0x73, 0x2e, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x52, 0x0c, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x1a, 0x36,
+	0x0a, 0x0a, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x50, 0x0a, 0x1d, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x02, 0x69, 0x70, 0x12, 0x1f, 0x0a, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61, 0x6e, 0x67,
+	0x65, 0x52, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x22, 0x88, 0x01, 0x0a, 0x21, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
+	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26,
+	0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x05, 0x72, 0x61,
+	0x6e, 0x67, 0x65, 0x22, 0x53, 0x0a, 0x12, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x1f, 0x0a, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61, 0x6e, 0x67,
+	0x65, 0x52, 0x05, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x22, 0x48, 0x0a, 0x14, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61,
+	0x69, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69,
+	0x6e, 0x73, 0x22, 0x30, 0x0a, 0x14, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65, 0x74, 0x45, 0x78, 0x69,
+	0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x6f,
+	0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x64, 0x6f, 0x6d,
+	0x61, 0x69, 0x6e, 0x73, 0x22, 0x50, 0x0a, 0x20, 0x50, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73,
+	0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x45, 0x78, 0x69, 0x73, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61,
+	0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e,
+	0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x22, 0x20, 0x0a, 0x06, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0xf0, 0x01, 0x0a, 0x10, 0x41, 0x64, 0x64,
+	0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x65,
+	0x67, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x63, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x12,
+	0x3c, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3c, 0x0a,
+	0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b,
+	0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x22, 0xa9, 0x01, 0x0a, 0x15,
+	0x41, 0x64, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x03, 0x64, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x12, 0x12, 0x0a,
+	0x04, 0x6f, 0x63, 0x73, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x6f, 0x63, 0x73,
+	0x70, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x73, 0x73, 0x75, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x06, 0x69, 0x73, 0x73, 0x75, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x73, 0x73,
+	0x75, 0x65, 0x72, 0x49, 0x44, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x69, 0x73, 0x73,
+	0x75, 0x65, 0x72, 0x49, 0x44, 0x12, 0x20, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65,
+	0x48, 0x61, 0x73, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x48, 0x61, 0x73, 0x68, 0x22, 0x30, 0x0a, 0x16, 0x41, 0x64, 0x64, 0x43, 0x65,
+	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x22, 0x50, 0x0a, 0x0c, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x30, 0x0a, 0x13, 0x75, 0x73, 0x65,
+	0x56, 0x32, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x75, 0x73, 0x65, 0x56, 0x32, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x76, 0x0a, 0x1a, 0x47,
+	0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x46, 0x6f, 0x72, 0x41, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x44, 0x12, 0x1a, 0x0a, 0x08, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x49, 0x44, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x49, 0x44, 0x12, 0x14, 0x0a,
+	0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6c, 0x69,
+	0x6d, 0x69, 0x74, 0x22, 0x1c, 0x0a, 0x08, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x44, 0x73, 0x12,
+	0x10, 0x0a, 0x03, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x03, 0x52, 0x03, 0x69, 0x64,
+	0x73, 0x22, 0x4c, 0x0a, 0x22, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x74, 0x49,
+	0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x61, 0x63, 0x63, 0x74, 0x49, 0x44, 0x22,
+	0x79, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x46, 0x6f, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63,
+	0x63, 0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x61, 0x63, 0x63, 0x74,
+	0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x13, 0x75, 0x73, 0x65, 0x56,
+	0x32, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x75, 0x73, 0x65, 0x56, 0x32, 0x41, 0x75, 0x74, 0x68,
+	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xce, 0x01, 0x0a, 0x18, 0x47,
+	0x65, 0x74, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12,
+	0x18, 0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x6f, 0x77,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x6e, 0x6f, 0x77, 0x12, 0x28, 0x0a, 0x0f, 0x72,
+	0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x56, 0x32, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x56, 0x32, 0x41,
+	0x75, 0x74, 0x68, 0x7a, 0x73, 0x12, 0x34, 0x0a, 0x07, 0x6e, 0x6f, 0x77, 0x54, 0x69, 0x6d, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x07, 0x6e, 0x6f, 0x77, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x96, 0x01, 0x0a, 0x0e,
+	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x33,
+	0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x73, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x2e, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x61, 0x75,
+	0x74, 0x68, 0x7a, 0x1a, 0x4f, 0x0a, 0x0a, 0x4d, 0x61, 0x70, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x29, 0x0a, 0x05, 0x61, 0x75, 0x74,
+	0x68, 0x7a, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x61,
+	0x75, 0x74, 0x68, 0x7a, 0x22, 0x4c, 0x0a, 0x1f, 0x41, 0x64, 0x64, 0x50, 0x65, 0x6e, 0x64, 0x69,
+	0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75,
+	0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x61, 0x75, 0x74,
+	0x68, 0x7a, 0x22, 0x76, 0x0a, 0x18, 0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x41, 0x6e,
+	0x64, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27,
+	0x0a, 0x08, 0x6e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x08, 0x6e,
+	0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x31, 0x0a, 0x09, 0x6e, 0x65, 0x77, 0x41, 0x75,
+	0x74, 0x68, 0x7a, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x09, 0x6e, 0x65, 0x77, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x73, 0x22, 0x24, 0x0a, 0x10, 0x41, 0x75,
+	0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x73, 0x12, 0x10,
+	0x0a, 0x03, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x69, 0x64, 0x73,
+	0x22, 0x22, 0x0a, 0x10, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x49, 0x44, 0x32, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x02, 0x69, 0x64, 0x22, 0x25, 0x0a, 0x11, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x49, 0x44, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x64, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x03, 0x52, 0x03, 0x69, 0x64, 0x73, 0x22, 0xea, 0x01, 0x0a, 0x18,
+	0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x64, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
+	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x68, 0x61, 0x72,
+	0x64, 0x49, 0x64, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x68, 0x61, 0x72,
+	0x64, 0x49, 0x64, 0x78, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x49, 0x44,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x49, 0x44,
+	0x12, 0x36, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08,
+	0x64, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x22, 0xc2, 0x02, 0x0a, 0x1c, 0x46, 0x69, 0x6e,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x61,
+	0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x61, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x65, 0x64, 0x12, 0x44, 0x0a, 0x11, 0x76, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x11, 0x76, 0x61,
+	0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12,
+	0x3e, 0x0a, 0x0f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x50, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x0f,
+	0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12,
+	0x3c, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x22, 0xd0, 0x01,
+	0x0a, 0x14, 0x41, 0x64, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6b, 0x65, 0x79, 0x48, 0x61, 0x73,
+	0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68,
+	0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x76, 0x6f,
+	0x6b, 0x65, 0x64, 0x42, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x64, 0x42, 0x79, 0x12, 0x38, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x65, 0x64, 0x54,
+	0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x61, 0x64, 0x64, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65,
+	0x22, 0x2d, 0x0a, 0x11, 0x4b, 0x65, 0x79, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x22,
+	0x4d, 0x0a, 0x11, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62,
+	0x68, 0x6f, 0x6f, 0x6b, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x10, 0x0a, 0x03,
+	0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x22, 0xa4,
+	0x01, 0x0a, 0x0c, 0x50, 0x61, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07,
+	0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x12, 0x3c, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72,
+	0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65,
+	0x73, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x29, 0x0a, 0x11, 0x50, 0x61, 0x75, 0x73, 0x65, 0x64, 0x49,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61,
+	0x6d, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73,
+	0x22, 0x24, 0x0a, 0x08, 0x53, 0x50, 0x4b, 0x49, 0x48, 0x61, 0x73, 0x68, 0x12, 0x18, 0x0a, 0x07,
+	0x6b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6b,
+	0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x22, 0x23, 0x0a, 0x07, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x22, 0xb3, 0x02, 0x0a, 0x1d,
+	0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x73, 0x42,
+	0x79, 0x53, 0x68, 0x61, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a,
+	0x08, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x08, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x49, 0x44, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x68, 0x61,
+	0x72, 0x64, 0x49, 0x64, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x68, 0x61,
+	0x72, 0x64, 0x49, 0x64, 0x78, 0x12, 0x22, 0x0a, 0x0c, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73,
+	0x41, 0x66, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x73, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x24, 0x0a, 0x0d, 0x72, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x64, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0d, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12,
+	0x46, 0x0a, 0x10, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x66, 0x74, 0x65, 0x72, 0x54,
+	0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x10, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x66,
+	0x74, 0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x48, 0x0a, 0x11, 0x72, 0x65, 0x76, 0x6f, 0x6b,
+	0x65, 0x64, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x11,
+	0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x54, 0x69, 0x6d,
+	0x65, 0x22, 0x81, 0x02, 0x0a, 0x0b, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x43, 0x65, 0x72,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61,
+	0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f,
+	0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x44, 0x61, 0x74, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x44,
+	0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12,
+	0x44, 0x0a, 0x0f, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x44, 0x61, 0x74, 0x65, 0x54, 0x69,
+	0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x0f, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x44, 0x61, 0x74,
+	0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3e, 0x0a, 0x0c, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65,
+	0x72, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0c, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65,
+	0x72, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x35, 0x0a, 0x0c, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64,
+	0x43, 0x65, 0x72, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x05, 0x63, 0x65, 0x72, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65,
+	0x64, 0x43, 0x65, 0x72, 0x74, 0x52, 0x05, 0x63, 0x65, 0x72, 0x74, 0x73, 0x22, 0x9e, 0x01, 0x0a,
+	0x08, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x72,
+	0x65, 0x6e, 0x65, 0x77, 0x42, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x72, 0x65,
+	0x6e, 0x65, 0x77, 0x42, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12,
+	0x3c, 0x0a, 0x0b, 0x72, 0x65, 0x6e, 0x65, 0x77, 0x42, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x0b, 0x72, 0x65, 0x6e, 0x65, 0x77, 0x42, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x37, 0x0a,
+	0x09, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x2a, 0x0a, 0x09, 0x69, 0x6e,
+	0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x73, 0x61, 0x2e, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x09, 0x69, 0x6e, 0x63,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x3b, 0x0a, 0x19, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c,
+	0x73, 0x46, 0x6f, 0x72, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x49,
+	0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e,
+	0x74, 0x49, 0x44, 0x22, 0x24, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x22, 0x94, 0x01, 0x0a, 0x18, 0x41, 0x64,
+	0x64, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x69, 0x6e, 0x63, 0x69,
+	0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x26,
+	0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49,
+	0x44, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x44,
+	0x22, 0xde, 0x01, 0x0a, 0x0e, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72,
+	0x69, 0x61, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x26, 0x0a, 0x0e, 0x72,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x44, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x44, 0x12, 0x26, 0x0a,
+	0x0e, 0x6c, 0x61, 0x73, 0x74, 0x4e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x53, 0x65, 0x6e, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x6c, 0x61, 0x73, 0x74, 0x4e, 0x6f, 0x74, 0x69, 0x63,
+	0x65, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x4a, 0x0a, 0x12, 0x6c, 0x61, 0x73, 0x74, 0x4e, 0x6f, 0x74,
+	0x69, 0x63, 0x65, 0x53, 0x65, 0x6e, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x12, 0x6c,
+	0x61, 0x73, 0x74, 0x4e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x53, 0x65, 0x6e, 0x74, 0x54, 0x69, 0x6d,
+	0x65, 0x22, 0x41, 0x0a, 0x0f, 0x49, 0x73, 0x73, 0x75, 0x61, 0x6e, 0x63, 0x65, 0x46, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x16, 0x0a, 0x06,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65,
+	0x74, 0x61, 0x69, 0x6c, 0x22, 0xcb, 0x02, 0x0a, 0x0f, 0x49, 0x73, 0x73, 0x75, 0x61, 0x6e, 0x63,
+	0x65, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c,
+	0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x24, 0x0a, 0x0d, 0x70, 0x72, 0x65, 0x63,
+	0x65, 0x72, 0x74, 0x49, 0x73, 0x73, 0x75, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0d, 0x70, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x49, 0x73, 0x73, 0x75, 0x65, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x75, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x48, 0x0a, 0x11, 0x70, 0x72,
+	0x65, 0x63, 0x65, 0x72, 0x74, 0x49, 0x73, 0x73, 0x75, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x11, 0x70, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x49, 0x73, 0x73, 0x75, 0x65, 0x64,
+	0x54, 0x69, 0x6d, 0x65, 0x12, 0x3c, 0x0a, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54,
+	0x69, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69,
+	0x6d, 0x65, 0x22, 0x9d, 0x03, 0x0a, 0x11, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e,
+	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x78, 0x70, 0x69,
+	0x72, 0x65, 0x64, 0x42, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x64, 0x42, 0x79, 0x12, 0x3c, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x54, 0x69, 0x6d, 0x65, 0x12, 0x3c, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x54,
+	0x69, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x54, 0x69,
+	0x6d, 0x65, 0x22, 0x49, 0x0a, 0x12, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f,
+	0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x73, 0x12, 0x33, 0x0a, 0x09, 0x6f, 0x76, 0x65, 0x72,
+	0x72, 0x69, 0x64, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x61,
+	0x2e, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69,
+	0x64, 0x65, 0x52, 0x09, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x73, 0x22, 0x46, 0x0a,
+	0x1c, 0x47, 0x65, 0x74, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65,
+	0x72, 0x72, 0x69, 0x64, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a,
+	0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x45, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x64, 0x22, 0x4e, 0x0a, 0x1e, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x52,
+	0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x65, 0x72, 0x32, 0x81, 0x1e, 0x0a, 0x10, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x3b, 0x0a, 0x0f, 0x47, 0x65,
+	0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e,
+	0x73, 0x61, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x44, 0x1a, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x52, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x79, 0x4b, 0x65, 0x79, 0x12,
+	0x0e, 0x2e, 0x73, 0x61, 0x2e, 0x4a, 0x53, 0x4f, 0x4e, 0x57, 0x65, 0x62, 0x4b, 0x65, 0x79, 0x1a,
+	0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x31, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72,
+	0x69, 0x61, 0x6c, 0x1a, 0x11, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x34, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x50,
+	0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x0a, 0x2e,
+	0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x1a, 0x11, 0x2e, 0x63, 0x6f, 0x72, 0x65,
+	0x2e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x3d,
+	0x0a, 0x14, 0x47, 0x65, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x1a, 0x17, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12, 0x53, 0x0a,
+	0x18, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x23, 0x2e, 0x73, 0x61, 0x2e, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73,
+	0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10,
+	0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73,
+	0x22, 0x00, 0x12, 0x48, 0x0a, 0x16, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x12, 0x21, 0x2e, 0x73,
+	0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x1b,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x21, 0x2e, 0x73, 0x61,
+	0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x42, 0x79, 0x49, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x09,
+	0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x0b, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x12, 0x16, 0x2e, 0x73, 0x61, 0x2e,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12,
+	0x36, 0x0a, 0x0d, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65, 0x74, 0x73,
+	0x12, 0x18, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x51, 0x44, 0x4e, 0x53,
+	0x65, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x37, 0x0a, 0x0d, 0x46, 0x51, 0x44, 0x4e, 0x53,
+	0x65, 0x74, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x12, 0x18, 0x2e, 0x73, 0x61, 0x2e, 0x46, 0x51,
+	0x44, 0x4e, 0x53, 0x65, 0x74, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x00,
+	0x12, 0x3d, 0x0a, 0x13, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65, 0x74, 0x49, 0x73, 0x73, 0x75, 0x65,
+	0x64, 0x57, 0x69, 0x74, 0x68, 0x69, 0x6e, 0x12, 0x18, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x46, 0x51, 0x44, 0x4e, 0x53, 0x65, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x00, 0x12,
+	0x4f, 0x0a, 0x19, 0x50, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x12, 0x24, 0x2e, 0x73,
+	0x61, 0x2e, 0x50, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x00,
+	0x12, 0x40, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12, 0x14, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f,
+	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x32, 0x1a, 0x13, 0x2e, 0x63, 0x6f,
+	0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0x00, 0x12, 0x48, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
+	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32, 0x12, 0x1c, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65,
+	0x74, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68,
+	0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x00, 0x12, 0x55, 0x0a, 0x18,
+	0x47, 0x65, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72,
+	0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12, 0x22, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65,
+	0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x1b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x50, 0x65, 0x6e, 0x64,
+	0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x32, 0x12, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x1c, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x32, 0x12, 0x26, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69,
+	0x64, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x61,
+	0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22,
+	0x00, 0x12, 0x51, 0x0a, 0x1b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32,
+	0x12, 0x25, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x6e, 0x76, 0x61, 0x6c,
+	0x69, 0x64, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64,
+	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32, 0x12,
+	0x21, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x00, 0x12, 0x31, 0x0a, 0x0a, 0x4b, 0x65, 0x79, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x12, 0x15, 0x2e, 0x73, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0a, 0x2e,
+	0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x14, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68,
+	0x6f, 0x6f, 0x6b, 0x12, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x15, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x76,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x22, 0x00,
+	0x12, 0x35, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x1a, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x16, 0x52, 0x65, 0x70, 0x6c, 0x61,
+	0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x45, 0x78, 0x69, 0x73, 0x74,
+	0x73, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x1a, 0x0a, 0x2e,
+	0x73, 0x61, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x00, 0x12, 0x45, 0x0a, 0x13, 0x47,
+	0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x46, 0x6f, 0x72, 0x41, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x1e, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72,
+	0x73, 0x46, 0x6f, 0x72, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x73, 0x61, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x44, 0x73,
+	0x22, 0x00, 0x12, 0x41, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x50, 0x61, 0x75, 0x73, 0x65, 0x64, 0x49,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x73, 0x12, 0x10, 0x2e, 0x73, 0x61, 0x2e,
+	0x50, 0x61, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x73,
+	0x61, 0x2e, 0x50, 0x61, 0x75, 0x73, 0x65, 0x64, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69,
+	0x65, 0x72, 0x73, 0x22, 0x00, 0x12, 0x2e, 0x0a, 0x0b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x50, 0x61,
+	0x75, 0x73, 0x65, 0x64, 0x12, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x2e, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x53, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x73, 0x42, 0x79, 0x4b, 0x65, 0x79, 0x12, 0x0c, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x50,
+	0x4b, 0x49, 0x48, 0x61, 0x73, 0x68, 0x1a, 0x0b, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x73, 0x22, 0x00, 0x12, 0x33, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x53, 0x65, 0x72, 0x69,
+	0x61, 0x6c, 0x73, 0x42, 0x79, 0x4b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x12, 0x0c, 0x2e, 0x73,
+	0x61, 0x2e, 0x53, 0x50, 0x4b, 0x49, 0x48, 0x61, 0x73, 0x68, 0x1a, 0x0a, 0x2e, 0x73, 0x61, 0x2e,
+	0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x22, 0x00, 0x30, 0x01, 0x12, 0x4f, 0x0a, 0x16, 0x47, 0x65,
+	0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x73, 0x42, 0x79, 0x53,
+	0x68, 0x61, 0x72, 0x64, 0x12, 0x21, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x73, 0x42, 0x79, 0x53, 0x68, 0x61, 0x72, 0x64,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x73, 0x22, 0x00, 0x12, 0x31, 0x0a, 0x12, 0x49,
+	0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x46, 0x6f, 0x72, 0x53, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x1a, 0x0d, 0x2e,
+	0x73, 0x61, 0x2e, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x00, 0x12, 0x4b,
+	0x0a, 0x12, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x46, 0x6f, 0x72, 0x49, 0x6e, 0x63, 0x69,
+	0x64, 0x65, 0x6e, 0x74, 0x12, 0x1d, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c,
+	0x73, 0x46, 0x6f, 0x72, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e,
+	0x74, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x22, 0x00, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x0b, 0x47,
+	0x65, 0x74, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x2e, 0x73, 0x61, 0x2e,
+	0x47, 0x65, 0x74, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x73, 0x61, 0x2e, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74,
+	0x22, 0x00, 0x12, 0x37, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x49, 0x73, 0x73, 0x75, 0x61, 0x6e, 0x63,
+	0x65, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x12, 0x0a, 0x2e, 0x73, 0x61, 0x2e, 0x53, 0x65,
+	0x72, 0x69, 0x61, 0x6c, 0x1a, 0x13, 0x2e, 0x73, 0x61, 0x2e, 0x49, 0x73, 0x73, 0x75, 0x61, 0x6e,
+	0x63, 0x65, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x22, 0x00, 0x12, 0x53, 0x0a, 0x15, 0x47,
+	0x65, 0x74, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72,
+	0x69, 0x64, 0x65, 0x73, 0x12, 0x20, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x61, 0x74,
+	0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61, 0x74, 0x65,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x73, 0x22, 0x00,
+	0x12, 0x3b, 0x0a, 0x0f, 0x4e, 0x65, 0x77, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x37, 0x0a,
+	0x12, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64,
+	0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x12, 0x3d, 0x0a, 0x11, 0x41, 0x64, 0x64, 0x50, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x43,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
+	0x12, 0x30, 0x0a, 0x09, 0x41, 0x64, 0x64, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x14, 0x2e,
+	0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x22, 0x00, 0x12, 0x3b, 0x0a, 0x15, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x49, 0x73, 0x73, 0x75,
+	0x61, 0x6e, 0x63, 0x65, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x12, 0x13, 0x2e, 0x73, 0x61,
+	0x2e, 0x49, 0x73, 0x73, 0x75, 0x61, 0x6e, 0x63, 0x65, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65,
+	0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12,
+	0x3b, 0x0a, 0x16, 0x44, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x0b, 0x2e,
+	0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x26, 0x0a, 0x08,
+	0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x11, 0x4e, 0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72,
+	0x41, 0x6e, 0x64, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x73, 0x12, 0x1c, 0x2e, 0x73, 0x61, 0x2e, 0x4e,
+	0x65, 0x77, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x41, 0x6e, 0x64, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x12, 0x53, 0x65, 0x74, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x12, 0x0b, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x0d, 0x53, 0x65, 0x74, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65,
+	0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x0d, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a,
+	0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72,
+	0x64, 0x65, 0x72, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x22, 0x00, 0x12, 0x2b, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x10,
+	0x2e, 0x73, 0x61, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12,
+	0x3e, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x46, 0x6f, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x73, 0x12, 0x1b, 0x2e, 0x73, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x46, 0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12,
+	0x40, 0x0a, 0x11, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x12, 0x1c, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65,
+	0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
+	0x00, 0x12, 0x52, 0x0a, 0x12, 0x4e, 0x65, 0x77, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32, 0x12, 0x23, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64,
+	0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x73,
+	0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32,
+	0x49, 0x44, 0x73, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x16, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a,
+	0x65, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12,
+	0x20, 0x2e, 0x73, 0x61, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
+	0x12, 0x3f, 0x0a, 0x18, 0x44, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x41, 0x75,
+	0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x32, 0x12, 0x14, 0x2e, 0x73,
+	0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x44, 0x32, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
+	0x00, 0x12, 0x38, 0x0a, 0x0d, 0x41, 0x64, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x4b,
+	0x65, 0x79, 0x12, 0x18, 0x2e, 0x73, 0x61, 0x2e, 0x41, 0x64, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x65, 0x64, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x14, 0x53,
+	0x65, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68,
+	0x6f, 0x6f, 0x6b, 0x12, 0x15, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x33, 0x0a, 0x10, 0x50, 0x61, 0x75,
+	0x73, 0x65, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x73, 0x12, 0x10, 0x2e,
+	0x73, 0x61, 0x2e, 0x50, 0x61, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x31,
+	0x0a, 0x0e, 0x55, 0x6e, 0x70, 0x61, 0x75, 0x73, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x12, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x49, 0x44, 0x1a, 0x09, 0x2e, 0x73, 0x61, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22,
+	0x00, 0x12, 0x46, 0x0a, 0x14, 0x41, 0x64, 0x64, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69,
+	0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x12, 0x15, 0x2e, 0x73, 0x61, 0x2e, 0x52,
+	0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65,
+	0x1a, 0x15, 0x2e, 0x73, 0x61, 0x2e, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f,
+	0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x22, 0x00, 0x12, 0x4c, 0x0a, 0x17, 0x45, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72,
+	0x72, 0x69, 0x64, 0x65, 0x12, 0x22, 0x2e, 0x73, 0x61, 0x2e, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65,
+	0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x11, 0x41, 0x64, 0x64, 0x49, 0x6e,
+	0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x1c, 0x2e, 0x73,
+	0x61, 0x2e, 0x41, 0x64, 0x64, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72,
+	0x69, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x74, 0x73, 0x65, 0x6e, 0x63, 0x72,
+	0x79, 0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65, 0x72, 0x2f, 0x73, 0x61, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f,
+}
+
+var (
+	file_sa_proto_sa_proto_rawDescOnce sync.Once
+	file_sa_proto_sa_proto_rawDescData = file_sa_proto_sa_proto_rawDesc
+)
+
+func file_sa_proto_sa_proto_rawDescGZIP() []byte {
+	file_sa_proto_sa_proto_rawDescOnce.Do(func() {
+		file_sa_proto_sa_proto_rawDescData = protoimpl.X.CompressGZIP(file_sa_proto_sa_proto_rawDescData)
+	})
+	return file_sa_proto_sa_proto_rawDescData
+}
+
+var file_sa_proto_sa_proto_msgTypes = make([]protoimpl.MessageInfo, 61)
+var file_sa_proto_sa_proto_goTypes = []interface{}{
+	(*RegistrationID)(nil),                     // 0: sa.RegistrationID
+	(*JSONWebKey)(nil),                         // 1: sa.JSONWebKey
+	(*AuthorizationID)(nil),                    // 2: sa.AuthorizationID
+	(*GetPendingAuthorizationRequest)(nil),     // 3: sa.GetPendingAuthorizationRequest
+	(*GetValidAuthorizationsRequest)(nil),      // 4: sa.GetValidAuthorizationsRequest
+	(*ValidAuthorizations)(nil),                // 5: sa.ValidAuthorizations
+	(*Serial)(nil),                             // 6: sa.Serial
+	(*SerialMetadata)(nil),                     // 7: sa.SerialMetadata
+	(*Range)(nil),                              // 8: sa.Range
+	(*Count)(nil),                              // 9: sa.Count
+	(*CountCertificatesByNamesRequest)(nil),    // 10: sa.CountCertificatesByNamesRequest
+	(*CountByNames)(nil),                       // 11: sa.CountByNames
+	(*CountRegistrationsByIPRequest)(nil),      // 12: sa.CountRegistrationsByIPRequest
+	(*CountInvalidAuthorizationsRequest)(nil),  // 13: sa.CountInvalidAuthorizationsRequest
+	(*CountOrdersRequest)(nil),                 // 14: sa.CountOrdersRequest
+	(*CountFQDNSetsRequest)(nil),               // 15: sa.CountFQDNSetsRequest
+	(*FQDNSetExistsRequest)(nil),               // 16: sa.FQDNSetExistsRequest
+	(*PreviousCertificateExistsRequest)(nil),   // 17: sa.PreviousCertificateExistsRequest
+	(*Exists)(nil),                             // 18: sa.Exists
+	(*AddSerialRequest)(nil),                   // 19: sa.AddSerialRequest
+	(*AddCertificateRequest)(nil),              // 20: sa.AddCertificateRequest
+	(*AddCertificateResponse)(nil),             // 21: sa.AddCertificateResponse
+	(*OrderRequest)(nil),                       // 22: sa.OrderRequest
+	(*GetOrdersForAccountRequest)(nil),         // 23: sa.GetOrdersForAccountRequest
+	(*OrderIDs)(nil),                           // 24: sa.OrderIDs
+	(*GetValidOrderAuthorizationsRequest)(nil), // 25: sa.GetValidOrderAuthorizationsRequest
+	(*GetOrderForNamesRequest)(nil),            // 26: sa.GetOrderForNamesRequest
+	(*GetAuthorizationsRequest)(nil),           // 27: sa.GetAuthorizationsRequest
+	(*Authorizations)(nil),                     // 28: sa.Authorizations
+	(*AddPendingAuthorizationsRequest)(nil),    // 29: sa.AddPendingAuthorizationsRequest
+	(*NewOrderAndAuthzsRequest)(nil),           // 30: sa.NewOrderAndAuthzsRequest
+	(*AuthorizationIDs)(nil),                   // 31: sa.AuthorizationIDs
+	(*AuthorizationID2)(nil),                   // 32: sa.AuthorizationID2
+	(*Authorization2IDs)(nil),                  // 33: sa.Authorization2IDs
+	(*RevokeCertificateRequest)(nil),           // 34: sa.RevokeCertificateRequest
+	(*FinalizeAuthorizationRequest)(nil),       // 35: sa.FinalizeAuthorizationRequest
+	(*AddBlockedKeyRequest)(nil),               // 36: sa.AddBlockedKeyRequest
+	(*KeyBlockedRequest)(nil),                  // 37: sa.KeyBlockedRequest
+	(*RevocationWebhook)(nil),                  // 38: sa.RevocationWebhook
+	(*PauseRequest)(nil),                       // 39: sa.PauseRequest
+	(*PausedIdentifiers)(nil),                  // 40: sa.PausedIdentifiers
+	(*SPKIHash)(nil),                           // 41: sa.SPKIHash
+	(*Serials)(nil),                            // 42: sa.Serials
+	(*GetRevokedCertsByShardRequest)(nil),      // 43: sa.GetRevokedCertsByShardRequest
+	(*RevokedCert)(nil),                        // 44: sa.RevokedCert
+	(*RevokedCerts)(nil),                       // 45: sa.RevokedCerts
+	(*Incident)(nil),                           // 46: sa.Incident
+	(*Incidents)(nil),                          // 47: sa.Incidents
+	(*SerialsForIncidentRequest)(nil),          // 48: sa.SerialsForIncidentRequest
+	(*GetIncidentRequest)(nil),                 // 49: sa.GetIncidentRequest
+	(*AddIncidentSerialRequest)(nil),           // 50: sa.AddIncidentSerialRequest
+	(*IncidentSerial)(nil),                     // 51: sa.IncidentSerial
+	(*IssuanceFailure)(nil),                    // 52: sa.IssuanceFailure
+	(*IssuanceOutcome)(nil),                    // 53: sa.IssuanceOutcome
+	(*RateLimitOverride)(nil),                  // 54: sa.RateLimitOverride
+	(*RateLimitOverrides)(nil),                 // 55: sa.RateLimitOverrides
+	(*GetRateLimitOverridesRequest)(nil),       // 56: sa.GetRateLimitOverridesRequest
+	(*ExpireRateLimitOverrideRequest)(nil),     // 57: sa.ExpireRateLimitOverrideRequest
+	(*ValidAuthorizations_MapElement)(nil),     // 58: sa.ValidAuthorizations.MapElement
+	(*CountByNames_MapElement)(nil),            // 59: sa.CountByNames.MapElement
+	(*Authorizations_MapElement)(nil),          // 60: sa.Authorizations.MapElement
+	(*timestamp.Timestamp)(nil),                // 61: google.protobuf.Timestamp
+	(*proto1.Authorization)(nil),               // 62: core.Authorization
+	(*proto1.Order)(nil),                       // 63: core.Order
+	(*proto1.ValidationRecord)(nil),            // 64: core.ValidationRecord
+	(*proto1.ProblemDetails)(nil),              // 65: core.ProblemDetails
+	(*proto1.Registration)(nil),                // 66: core.Registration
+	(*proto1.Certificate)(nil),                 // 67: core.Certificate
+	(*proto1.CertificateStatus)(nil),           // 68: core.CertificateStatus
+	(*proto1.Empty)(nil),                       // 69: core.Empty
+}
+var file_sa_proto_sa_proto_depIdxs = []int32{
+	61,  // 0: sa.GetPendingAuthorizationRequest.validUntilTime:type_name -> google.protobuf.Timestamp
+	61,  // 1: sa.GetValidAuthorizationsRequest.nowTime:type_name -> google.protobuf.Timestamp
+	58,  // 2: sa.ValidAuthorizations.valid:type_name -> sa.ValidAuthorizations.MapElement
+	61,  // 3: sa.SerialMetadata.createdTime:type_name -> google.protobuf.Timestamp
+	61,  // 4: sa.SerialMetadata.expiresTime:type_name -> google.protobuf.Timestamp
+	61,  // 5: sa.Range.earliestTime:type_name -> google.protobuf.Timestamp
+	61,  // 6: sa.Range.latestTime:type_name -> google.protobuf.Timestamp
+	8,   // 7: sa.CountCertificatesByNamesRequest.range:type_name -> sa.Range
+	59,  // 8: sa.CountByNames.countByNames:type_name -> sa.CountByNames.MapElement
+	8,   // 9: sa.CountRegistrationsByIPRequest.range:type_name -> sa.Range
+	8,   // 10: sa.CountInvalidAuthorizationsRequest.range:type_name -> sa.Range
+	8,   // 11: sa.CountOrdersRequest.range:type_name -> sa.Range
+	61,  // 12: sa.AddSerialRequest.createdTime:type_name -> google.protobuf.Timestamp
+	61,  // 13: sa.AddSerialRequest.expiresTime:type_name -> google.protobuf.Timestamp
+	61,  // 14: sa.GetAuthorizationsRequest.nowTime:type_name -> google.protobuf.Timestamp
+	60,  // 15: sa.Authorizations.authz:type_name -> sa.Authorizations.MapElement
+	62,  // 16: sa.AddPendingAuthorizationsRequest.authz:type_name -> core.Authorization
+	63,  // 17: sa.NewOrderAndAuthzsRequest.newOrder:type_name -> core.Order
+	62,  // 18: sa.NewOrderAndAuthzsRequest.newAuthzs:type_name -> core.Authorization
+	61,  // 19: sa.RevokeCertificateRequest.dateTime:type_name -> google.protobuf.Timestamp
+	64,  // 20: sa.FinalizeAuthorizationRequest.validationRecords:type_name -> core.ValidationRecord
+	65,  // 21: sa.FinalizeAuthorizationRequest.validationError:type_name -> core.ProblemDetails
+	61,  // 22: sa.FinalizeAuthorizationRequest.expiresTime:type_name -> google.protobuf.Timestamp
+	61,  // 23: sa.AddBlockedKeyRequest.addedTime:type_name -> google.protobuf.Timestamp
+	61,  // 24: sa.PauseRequest.expiresTime:type_name -> google.protobuf.Timestamp
+	61,  // 25: sa.GetRevokedCertsByShardRequest.expiresAfterTime:type_name -> google.protobuf.Timestamp
+	61,  // 26: sa.GetRevokedCertsByShardRequest.revokedBeforeTime:type_name -> google.protobuf.Timestamp
+	61,  // 27: sa.RevokedCert.revokedDateTime:type_name -> google.protobuf.Timestamp
+	61,  // 28: sa.RevokedCert.notAfterTime:type_name -> google.protobuf.Timestamp
+	44,  // 29: sa.RevokedCerts.certs:type_name -> sa.RevokedCert
+	61,  // 30: sa.Incident.renewByTime:type_name -> google.protobuf.Timestamp
+	46,  // 31: sa.Incidents.incidents:type_name -> sa.Incident
+	61,  // 32: sa.IncidentSerial.lastNoticeSentTime:type_name -> google.protobuf.Timestamp
+	61,  // 33: sa.IssuanceOutcome.precertIssuedTime:type_name -> google.protobuf.Timestamp
+	61,  // 34: sa.IssuanceOutcome.updatedTime:type_name -> google.protobuf.Timestamp
+	61,  // 35: sa.RateLimitOverride.createdTime:type_name -> google.protobuf.Timestamp
+	61,  // 36: sa.RateLimitOverride.expiresTime:type_name -> google.protobuf.Timestamp
+	54,  // 37: sa.RateLimitOverrides.overrides:type_name -> sa.RateLimitOverride
+	62,  // 38: sa.ValidAuthorizations.MapElement.authz:type_name -> core.Authorization
+	62,  // 39: sa.Authorizations.MapElement.authz:type_name -> core.Authorization
+	0,   // 40: sa.StorageAuthority.GetRegistration:input_type -> sa.RegistrationID
+	1,   // 41: sa.StorageAuthority.GetRegistrationByKey:input_type -> sa.JSONWebKey
+	6,   // 42: sa.StorageAuthority.GetCertificate:input_type -> sa.Serial
+	6,   // 43: sa.StorageAuthority.GetPrecertificate:input_type -> sa.Serial
+	6,   // 44: sa.StorageAuthority.GetCertificateStatus:input_type -> sa.Serial
+	10,  // 45: sa.StorageAuthority.CountCertificatesByNames:input_type -> sa.CountCertificatesByNamesRequest
+	12,  // 46: sa.StorageAuthority.CountRegistrationsByIP:input_type -> sa.CountRegistrationsByIPRequest
+	12,  // 47: sa.StorageAuthority.CountRegistrationsByIPRange:input_type -> sa.CountRegistrationsByIPRequest
+	14,  // 48: sa.StorageAuthority.CountOrders:input_type -> sa.CountOrdersRequest
+	15,  // 49: sa.StorageAuthority.CountFQDNSets:input_type -> sa.CountFQDNSetsRequest
+	16,  // 50: sa.StorageAuthority.FQDNSetExists:input_type -> sa.FQDNSetExistsRequest
+	15,  // 51: sa.StorageAuthority.FQDNSetIssuedWithin:input_type -> sa.CountFQDNSetsRequest
+	17,  // 52: sa.StorageAuthority.PreviousCertificateExists:input_type -> sa.PreviousCertificateExistsRequest
+	32,  // 53: sa.StorageAuthority.GetAuthorization2:input_type -> sa.AuthorizationID2
+	27,  // 54: sa.StorageAuthority.GetAuthorizations2:input_type -> sa.GetAuthorizationsRequest
+	3,   // 55: sa.StorageAuthority.GetPendingAuthorization2:input_type -> sa.GetPendingAuthorizationRequest
+	0,   // 56: sa.StorageAuthority.CountPendingAuthorizations2:input_type -> sa.RegistrationID
+	25,  // 57: sa.StorageAuthority.GetValidOrderAuthorizations2:input_type -> sa.GetValidOrderAuthorizationsRequest
+	13,  // 58: sa.StorageAuthority.CountInvalidAuthorizations2:input_type -> sa.CountInvalidAuthorizationsRequest
+	4,   // 59: sa.StorageAuthority.GetValidAuthorizations2:input_type -> sa.GetValidAuthorizationsRequest
+	37,  // 60: sa.StorageAuthority.KeyBlocked:input_type -> sa.KeyBlockedRequest
+	0,   // 61: sa.StorageAuthority.GetRevocationWebhook:input_type -> sa.RegistrationID
+	6,   // 62: sa.StorageAuthority.GetSerialMetadata:input_type -> sa.Serial
+	6,   // 63: sa.StorageAuthority.ReplacementOrderExists:input_type -> sa.Serial
+	23,  // 64: sa.StorageAuthority.GetOrdersForAccount:input_type -> sa.GetOrdersForAccountRequest
+	39,  // 65: sa.StorageAuthority.GetPausedIdentifiers:input_type -> sa.PauseRequest
+	0,   // 66: sa.StorageAuthority.CountPaused:input_type -> sa.RegistrationID
+	41,  // 67: sa.StorageAuthority.GetSerialsByKey:input_type -> sa.SPKIHash
+	41,  // 68: sa.StorageAuthority.GetSerialsByKeyHash:input_type -> sa.SPKIHash
+	43,  // 69: sa.StorageAuthority.GetRevokedCertsByShard:input_type -> sa.GetRevokedCertsByShardRequest
+	6,   // 70: sa.StorageAuthority.IncidentsForSerial:input_type -> sa.Serial
+	48,  // 71: sa.StorageAuthority.SerialsForIncident:input_type -> sa.SerialsForIncidentRequest
+	49,  // 72: sa.StorageAuthority.GetIncident:input_type -> sa.GetIncidentRequest
+	6,   // 73: sa.StorageAuthority.GetIssuanceOutcome:input_type -> sa.Serial
+	56,  // 74: sa.StorageAuthority.GetRateLimitOverrides:input_type -> sa.GetRateLimitOverridesRequest
+	66,  // 75: sa.StorageAuthority.NewRegistration:input_type -> core.Registration
+	66,  // 76: sa.StorageAuthority.UpdateRegistration:input_type -> core.Registration
+	20,  // 77: sa.StorageAuthority.AddCertificate:input_type -> sa.AddCertificateRequest
+	20,  // 78: sa.StorageAuthority.AddPrecertificate:input_type -> sa.AddCertificateRequest
+	19,  // 79: sa.StorageAuthority.AddSerial:input_type -> sa.AddSerialRequest
+	52,  // 80: sa.StorageAuthority.RecordIssuanceFailure:input_type -> sa.IssuanceFailure
+	0,   // 81: sa.StorageAuthority.DeactivateRegistration:input_type -> sa.RegistrationID
+	63,  // 82: sa.StorageAuthority.NewOrder:input_type -> core.Order
+	30,  // 83: sa.StorageAuthority.NewOrderAndAuthzs:input_type -> sa.NewOrderAndAuthzsRequest
+	63,  // 84: sa.StorageAuthority.SetOrderProcessing:input_type -> core.Order
+	63,  // 85: sa.StorageAuthority.SetOrderError:input_type -> core.Order
+	63,  // 86: sa.StorageAuthority.FinalizeOrder:input_type -> core.Order
+	22,  // 87: sa.StorageAuthority.GetOrder:input_type -> sa.OrderRequest
+	26,  // 88: sa.StorageAuthority.GetOrderForNames:input_type -> sa.GetOrderForNamesRequest
+	34,  // 89: sa.StorageAuthority.RevokeCertificate:input_type -> sa.RevokeCertificateRequest
+	29,  // 90: sa.StorageAuthority.NewAuthorizations2:input_type -> sa.AddPendingAuthorizationsRequest
+	35,  // 91: sa.StorageAuthority.FinalizeAuthorization2:input_type -> sa.FinalizeAuthorizationRequest
+	32,  // 92: sa.StorageAuthority.DeactivateAuthorization2:input_type -> sa.AuthorizationID2
+	36,  // 93: sa.StorageAuthority.AddBlockedKey:input_type -> sa.AddBlockedKeyRequest
+	38,  // 94: sa.StorageAuthority.SetRevocationWebhook:input_type -> sa.RevocationWebhook
+	39,  // 95: sa.StorageAuthority.PauseIdentifiers:input_type -> sa.PauseRequest
+	0,   // 96: sa.StorageAuthority.UnpauseAccount:input_type -> sa.RegistrationID
+	54,  // 97: sa.StorageAuthority.AddRateLimitOverride:input_type -> sa.RateLimitOverride
+	57,  // 98: sa.StorageAuthority.ExpireRateLimitOverride:input_type -> sa.ExpireRateLimitOverrideRequest
+	50,  // 99: sa.StorageAuthority.AddIncidentSerial:input_type -> sa.AddIncidentSerialRequest
+	66,  // 100: sa.StorageAuthority.GetRegistration:output_type -> core.Registration
+	66,  // 101: sa.StorageAuthority.GetRegistrationByKey:output_type -> core.Registration
+	67,  // 102: sa.StorageAuthority.GetCertificate:output_type -> core.Certificate
+	67,  // 103: sa.StorageAuthority.GetPrecertificate:output_type -> core.Certificate
+	68,  // 104: sa.StorageAuthority.GetCertificateStatus:output_type -> core.CertificateStatus
+	11,  // 105: sa.StorageAuthority.CountCertificatesByNames:output_type -> sa.CountByNames
+	9,   // 106: sa.StorageAuthority.CountRegistrationsByIP:output_type -> sa.Count
+	9,   // 107: sa.StorageAuthority.CountRegistrationsByIPRange:output_type -> sa.Count
+	9,   // 108: sa.StorageAuthority.CountOrders:output_type -> sa.Count
+	9,   // 109: sa.StorageAuthority.CountFQDNSets:output_type -> sa.Count
+	18,  // 110: sa.StorageAuthority.FQDNSetExists:output_type -> sa.Exists
+	18,  // 111: sa.StorageAuthority.FQDNSetIssuedWithin:output_type -> sa.Exists
+	18,  // 112: sa.StorageAuthority.PreviousCertificateExists:output_type -> sa.Exists
+	62,  // 113: sa.StorageAuthority.GetAuthorization2:output_type -> core.Authorization
+	28,  // 114: sa.StorageAuthority.GetAuthorizations2:output_type -> sa.Authorizations
+	62,  // 115: sa.StorageAuthority.GetPendingAuthorization2:output_type -> core.Authorization
+	9,   // 116: sa.StorageAuthority.CountPendingAuthorizations2:output_type -> sa.Count
+	28,  // 117: sa.StorageAuthority.GetValidOrderAuthorizations2:output_type -> sa.Authorizations
+	9,   // 118: sa.StorageAuthority.CountInvalidAuthorizations2:output_type -> sa.Count
+	28,  // 119: sa.StorageAuthority.GetValidAuthorizations2:output_type -> sa.Authorizations
+	18,  // 120: sa.StorageAuthority.KeyBlocked:output_type -> sa.Exists
+	38,  // 121: sa.StorageAuthority.GetRevocationWebhook:output_type -> sa.RevocationWebhook
+	7,   // 122: sa.StorageAuthority.GetSerialMetadata:output_type -> sa.SerialMetadata
+	18,  // 123: sa.StorageAuthority.ReplacementOrderExists:output_type -> sa.Exists
+	24,  // 124: sa.StorageAuthority.GetOrdersForAccount:output_type -> sa.OrderIDs
+	40,  // 125: sa.StorageAuthority.GetPausedIdentifiers:output_type -> sa.PausedIdentifiers
+	9,   // 126: sa.StorageAuthority.CountPaused:output_type -> sa.Count
+	42,  // 127: sa.StorageAuthority.GetSerialsByKey:output_type -> sa.Serials
+	6,   // 128: sa.StorageAuthority.GetSerialsByKeyHash:output_type -> sa.Serial
+	45,  // 129: sa.StorageAuthority.GetRevokedCertsByShard:output_type -> sa.RevokedCerts
+	47,  // 130: sa.StorageAuthority.IncidentsForSerial:output_type -> sa.Incidents
+	51,  // 131: sa.StorageAuthority.SerialsForIncident:output_type -> sa.IncidentSerial
+	46,  // 132: sa.StorageAuthority.GetIncident:output_type -> sa.Incident
+	53,  // 133: sa.StorageAuthority.GetIssuanceOutcome:output_type -> sa.IssuanceOutcome
+	55,  // 134: sa.StorageAuthority.GetRateLimitOverrides:output_type -> sa.RateLimitOverrides
+	66,  // 135: sa.StorageAuthority.NewRegistration:output_type -> core.Registration
+	69,  // 136: sa.StorageAuthority.UpdateRegistration:output_type -> core.Empty
+	21,  // 137: sa.StorageAuthority.AddCertificate:output_type -> sa.AddCertificateResponse
+	69,  // 138: sa.StorageAuthority.AddPrecertificate:output_type -> core.Empty
+	69,  // 139: sa.StorageAuthority.AddSerial:output_type -> core.Empty
+	69,  // 140: sa.StorageAuthority.RecordIssuanceFailure:output_type -> core.Empty
+	69,  // 141: sa.StorageAuthority.DeactivateRegistration:output_type -> core.Empty
+	63,  // 142: sa.StorageAuthority.NewOrder:output_type -> core.Order
+	63,  // 143: sa.StorageAuthority.NewOrderAndAuthzs:output_type -> core.Order
+	69,  // 144: sa.StorageAuthority.SetOrderProcessing:output_type -> core.Empty
+	69,  // 145: sa.StorageAuthority.SetOrderError:output_type -> core.Empty
+	69,  // 146: sa.StorageAuthority.FinalizeOrder:output_type -> core.Empty
+	63,  // 147: sa.StorageAuthority.GetOrder:output_type -> core.Order
+	63,  // 148: sa.StorageAuthority.GetOrderForNames:output_type -> core.Order
+	69,  // 149: sa.StorageAuthority.RevokeCertificate:output_type -> core.Empty
+	33,  // 150: sa.StorageAuthority.NewAuthorizations2:output_type -> sa.Authorization2IDs
+	69,  // 151: sa.StorageAuthority.FinalizeAuthorization2:output_type -> core.Empty
+	69,  // 152: sa.StorageAuthority.DeactivateAuthorization2:output_type -> core.Empty
+	69,  // 153: sa.StorageAuthority.AddBlockedKey:output_type -> core.Empty
+	69,  // 154: sa.StorageAuthority.SetRevocationWebhook:output_type -> core.Empty
+	69,  // 155: sa.StorageAuthority.PauseIdentifiers:output_type -> core.Empty
+	9,   // 156: sa.StorageAuthority.UnpauseAccount:output_type -> sa.Count
+	54,  // 157: sa.StorageAuthority.AddRateLimitOverride:output_type -> sa.RateLimitOverride
+	69,  // 158: sa.StorageAuthority.ExpireRateLimitOverride:output_type -> core.Empty
+	69,  // 159: sa.StorageAuthority.AddIncidentSerial:output_type -> core.Empty
+	100, // [100:160] is the sub-list for method output_type
+	40,  // [40:100] is the sub-list for method input_type
+	40,  // [40:40] is the sub-list for extension type_name
+	40,  // [40:40] is the sub-list for extension extendee
+	0,   // [0:40] is the sub-list for field type_name
+}
+
+func init() { file_sa_proto_sa_proto_init() }
+func file_sa_proto_sa_proto_init() {
+	if File_sa_proto_sa_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_sa_proto_sa_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegistrationID); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JSONWebKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthorizationID); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPendingAuthorizationRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetValidAuthorizationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidAuthorizations); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Serial); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SerialMetadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Range); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Count); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountCertificatesByNamesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountByNames); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountRegistrationsByIPRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountInvalidAuthorizationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountOrdersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountFQDNSetsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FQDNSetExistsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PreviousCertificateExistsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Exists); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddSerialRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddCertificateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddCertificateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OrderRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetOrdersForAccountRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OrderIDs); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetValidOrderAuthorizationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetOrderForNamesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sa_proto_sa_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetAuthorizationsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2678,8 +5096,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetValidAuthorizationsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Authorizations); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2690,8 +5108,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ValidAuthorizations); i {
+		file_sa_proto_sa_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddPendingAuthorizationsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2702,8 +5120,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Serial); i {
+		file_sa_proto_sa_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NewOrderAndAuthzsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2714,8 +5132,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Range); i {
+		file_sa_proto_sa_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthorizationIDs); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2726,8 +5144,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Count); i {
+		file_sa_proto_sa_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthorizationID2); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2738,8 +5156,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CountCertificatesByNamesRequest); i {
+		file_sa_proto_sa_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Authorization2IDs); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2750,8 +5168,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CountByNames); i {
+		file_sa_proto_sa_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokeCertificateRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2762,8 +5180,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CountRegistrationsByIPRequest); i {
+		file_sa_proto_sa_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FinalizeAuthorizationRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2774,8 +5192,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CountInvalidAuthorizationsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddBlockedKeyRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2786,8 +5204,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CountOrdersRequest); i {
+		file_sa_proto_sa_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyBlockedRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2798,8 +5216,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CountFQDNSetsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevocationWebhook); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2810,8 +5228,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FQDNSetExistsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PauseRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2822,8 +5240,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PreviousCertificateExistsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PausedIdentifiers); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2834,8 +5252,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Exists); i {
+		file_sa_proto_sa_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SPKIHash); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2846,8 +5264,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AddSerialRequest); i {
+		file_sa_proto_sa_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Serials); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2858,8 +5276,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AddCertificateRequest); i {
+		file_sa_proto_sa_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetRevokedCertsByShardRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2870,8 +5288,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AddCertificateResponse); i {
+		file_sa_proto_sa_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokedCert); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2882,8 +5300,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*OrderRequest); i {
+		file_sa_proto_sa_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokedCerts); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2894,8 +5312,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetValidOrderAuthorizationsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Incident); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2906,8 +5324,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetOrderForNamesRequest); i {
+		file_sa_proto_sa_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Incidents); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2918,8 +5336,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetAuthorizationsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SerialsForIncidentRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2930,8 +5348,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Authorizations); i {
+		file_sa_proto_sa_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetIncidentRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2942,8 +5360,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AddPendingAuthorizationsRequest); i {
+		file_sa_proto_sa_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddIncidentSerialRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2954,8 +5372,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AuthorizationIDs); i {
+		file_sa_proto_sa_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IncidentSerial); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2966,8 +5384,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AuthorizationID2); i {
+		file_sa_proto_sa_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IssuanceFailure); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2978,8 +5396,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Authorization2IDs); i {
+		file_sa_proto_sa_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IssuanceOutcome); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2990,8 +5408,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RevokeCertificateRequest); i {
+		file_sa_proto_sa_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RateLimitOverride); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -3002,8 +5420,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FinalizeAuthorizationRequest); i {
+		file_sa_proto_sa_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RateLimitOverrides); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -3014,8 +5432,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AddBlockedKeyRequest); i {
+		file_sa_proto_sa_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetRateLimitOverridesRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -3026,8 +5444,8 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KeyBlockedRequest); i {
+		file_sa_proto_sa_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpireRateLimitOverrideRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -3038,7 +5456,7 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+		file_sa_proto_sa_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ValidAuthorizations_MapElement); i {
 			case 0:
 				return &v.state
@@ -3050,7 +5468,7 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+		file_sa_proto_sa_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*CountByNames_MapElement); i {
 			case 0:
 				return &v.state
@@ -3062,7 +5480,7 @@ func file_sa_proto_sa_proto_init() {
 				return nil
 			}
 		}
-		file_sa_proto_sa_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+		file_sa_proto_sa_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Authorizations_MapElement); i {
 			case 0:
 				return &v.state
@@ -3081,7 +5499,7 @@ func file_sa_proto_sa_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_sa_proto_sa_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   37,
+			NumMessages:   61,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -3121,6 +5539,9 @@ type StorageAuthorityClient interface {
 	// a given registration ID and expire in the given time range.
 	CountFQDNSets(ctx context.Context, in *CountFQDNSetsRequest, opts ...grpc.CallOption) (*Count, error)
 	FQDNSetExists(ctx context.Context, in *FQDNSetExistsRequest, opts ...grpc.CallOption) (*Exists, error)
+	// Return whether a certificate for exactly the given set of names was
+	// issued within the window.
+	FQDNSetIssuedWithin(ctx context.Context, in *CountFQDNSetsRequest, opts ...grpc.CallOption) (*Exists, error)
 	PreviousCertificateExists(ctx context.Context, in *PreviousCertificateExistsRequest, opts ...grpc.CallOption) (*Exists, error)
 	GetAuthorization2(ctx context.Context, in *AuthorizationID2, opts ...grpc.CallOption) (*proto1.Authorization, error)
 	GetAuthorizations2(ctx context.Context, in *GetAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
@@ -3130,14 +5551,30 @@ type StorageAuthorityClient interface {
 	CountInvalidAuthorizations2(ctx context.Context, in *CountInvalidAuthorizationsRequest, opts ...grpc.CallOption) (*Count, error)
 	GetValidAuthorizations2(ctx context.Context, in *GetValidAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
 	KeyBlocked(ctx context.Context, in *KeyBlockedRequest, opts ...grpc.CallOption) (*Exists, error)
+	GetRevocationWebhook(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*RevocationWebhook, error)
+	GetSerialMetadata(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SerialMetadata, error)
+	ReplacementOrderExists(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*Exists, error)
+	GetOrdersForAccount(ctx context.Context, in *GetOrdersForAccountRequest, opts ...grpc.CallOption) (*OrderIDs, error)
+	GetPausedIdentifiers(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PausedIdentifiers, error)
+	CountPaused(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*Count, error)
+	GetSerialsByKey(ctx context.Context, in *SPKIHash, opts ...grpc.CallOption) (*Serials, error)
+	GetSerialsByKeyHash(ctx context.Context, in *SPKIHash, opts ...grpc.CallOption) (StorageAuthority_GetSerialsByKeyHashClient, error)
+	GetRevokedCertsByShard(ctx context.Context, in *GetRevokedCertsByShardRequest, opts ...grpc.CallOption) (*RevokedCerts, error)
+	IncidentsForSerial(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*Incidents, error)
+	SerialsForIncident(ctx context.Context, in *SerialsForIncidentRequest, opts ...grpc.CallOption) (StorageAuthority_SerialsForIncidentClient, error)
+	GetIncident(ctx context.Context, in *GetIncidentRequest, opts ...grpc.CallOption) (*Incident, error)
+	GetIssuanceOutcome(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*IssuanceOutcome, error)
+	GetRateLimitOverrides(ctx context.Context, in *GetRateLimitOverridesRequest, opts ...grpc.CallOption) (*RateLimitOverrides, error)
 	// Adders
 	NewRegistration(ctx context.Context, in *proto1.Registration, opts ...grpc.CallOption) (*proto1.Registration, error)
 	UpdateRegistration(ctx context.Context, in *proto1.Registration, opts ...grpc.CallOption) (*proto1.Empty, error)
 	AddCertificate(ctx context.Context, in *AddCertificateRequest, opts ...grpc.CallOption) (*AddCertificateResponse, error)
 	AddPrecertificate(ctx context.Context, in *AddCertificateRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
 	AddSerial(ctx context.Context, in *AddSerialRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	RecordIssuanceFailure(ctx context.Context, in *IssuanceFailure, opts ...grpc.CallOption) (*proto1.Empty, error)
 	DeactivateRegistration(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*proto1.Empty, error)
 	NewOrder(ctx context.Context, in *proto1.Order, opts ...grpc.CallOption) (*proto1.Order, error)
+	NewOrderAndAuthzs(ctx context.Context, in *NewOrderAndAuthzsRequest, opts ...grpc.CallOption) (*proto1.Order, error)
 	SetOrderProcessing(ctx context.Context, in *proto1.Order, opts ...grpc.CallOption) (*proto1.Empty, error)
 	SetOrderError(ctx context.Context, in *proto1.Order, opts ...grpc.CallOption) (*proto1.Empty, error)
 	FinalizeOrder(ctx context.Context, in *proto1.Order, opts ...grpc.CallOption) (*proto1.Empty, error)
@@ -3148,6 +5585,12 @@ type StorageAuthorityClient interface {
 	FinalizeAuthorization2(ctx context.Context, in *FinalizeAuthorizationRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
 	DeactivateAuthorization2(ctx context.Context, in *AuthorizationID2, opts ...grpc.CallOption) (*proto1.Empty, error)
 	AddBlockedKey(ctx context.Context, in *AddBlockedKeyRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	SetRevocationWebhook(ctx context.Context, in *RevocationWebhook, opts ...grpc.CallOption) (*proto1.Empty, error)
+	PauseIdentifiers(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	UnpauseAccount(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*Count, error)
+	AddRateLimitOverride(ctx context.Context, in *RateLimitOverride, opts ...grpc.CallOption) (*RateLimitOverride, error)
+	ExpireRateLimitOverride(ctx context.Context, in *ExpireRateLimitOverrideRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	AddIncidentSerial(ctx context.Context, in *AddIncidentSerialRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
 }
 
 type storageAuthorityClient struct {
@@ -3257,6 +5700,15 @@ func (c *storageAuthorityClient) FQDNSetExists(ctx context.Context, in *FQDNSetE
 	return out, nil
 }
 
+func (c *storageAuthorityClient) FQDNSetIssuedWithin(ctx context.Context, in *CountFQDNSetsRequest, opts ...grpc.CallOption) (*Exists, error) {
+	out := new(Exists)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/FQDNSetIssuedWithin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageAuthorityClient) PreviousCertificateExists(ctx context.Context, in *PreviousCertificateExistsRequest, opts ...grpc.CallOption) (*Exists, error) {
 	out := new(Exists)
 	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/PreviousCertificateExists", in, out, opts...)
@@ -3338,6 +5790,178 @@ func (c *storageAuthorityClient) KeyBlocked(ctx context.Context, in *KeyBlockedR
 	return out, nil
 }
 
+func (c *storageAuthorityClient) GetRevocationWebhook(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*RevocationWebhook, error) {
+	out := new(RevocationWebhook)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetRevocationWebhook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetSerialMetadata(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SerialMetadata, error) {
+	out := new(SerialMetadata)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetSerialMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) ReplacementOrderExists(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*Exists, error) {
+	out := new(Exists)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/ReplacementOrderExists", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetOrdersForAccount(ctx context.Context, in *GetOrdersForAccountRequest, opts ...grpc.CallOption) (*OrderIDs, error) {
+	out := new(OrderIDs)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetOrdersForAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetPausedIdentifiers(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PausedIdentifiers, error) {
+	out := new(PausedIdentifiers)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetPausedIdentifiers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) CountPaused(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*Count, error) {
+	out := new(Count)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/CountPaused", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetSerialsByKey(ctx context.Context, in *SPKIHash, opts ...grpc.CallOption) (*Serials, error) {
+	out := new(Serials)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetSerialsByKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetSerialsByKeyHash(ctx context.Context, in *SPKIHash, opts ...grpc.CallOption) (StorageAuthority_GetSerialsByKeyHashClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_StorageAuthority_serviceDesc.Streams[0], "/sa.StorageAuthority/GetSerialsByKeyHash", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageAuthorityGetSerialsByKeyHashClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StorageAuthority_GetSerialsByKeyHashClient interface {
+	Recv() (*Serial, error)
+	grpc.ClientStream
+}
+
+type storageAuthorityGetSerialsByKeyHashClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageAuthorityGetSerialsByKeyHashClient) Recv() (*Serial, error) {
+	m := new(Serial)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *storageAuthorityClient) GetRevokedCertsByShard(ctx context.Context, in *GetRevokedCertsByShardRequest, opts ...grpc.CallOption) (*RevokedCerts, error) {
+	out := new(RevokedCerts)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetRevokedCertsByShard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) IncidentsForSerial(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*Incidents, error) {
+	out := new(Incidents)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/IncidentsForSerial", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) SerialsForIncident(ctx context.Context, in *SerialsForIncidentRequest, opts ...grpc.CallOption) (StorageAuthority_SerialsForIncidentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_StorageAuthority_serviceDesc.Streams[1], "/sa.StorageAuthority/SerialsForIncident", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageAuthoritySerialsForIncidentClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StorageAuthority_SerialsForIncidentClient interface {
+	Recv() (*IncidentSerial, error)
+	grpc.ClientStream
+}
+
+type storageAuthoritySerialsForIncidentClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageAuthoritySerialsForIncidentClient) Recv() (*IncidentSerial, error) {
+	m := new(IncidentSerial)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *storageAuthorityClient) GetIncident(ctx context.Context, in *GetIncidentRequest, opts ...grpc.CallOption) (*Incident, error) {
+	out := new(Incident)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetIncident", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetIssuanceOutcome(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*IssuanceOutcome, error) {
+	out := new(IssuanceOutcome)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetIssuanceOutcome", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetRateLimitOverrides(ctx context.Context, in *GetRateLimitOverridesRequest, opts ...grpc.CallOption) (*RateLimitOverrides, error) {
+	out := new(RateLimitOverrides)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetRateLimitOverrides", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageAuthorityClient) NewRegistration(ctx context.Context, in *proto1.Registration, opts ...grpc.CallOption) (*proto1.Registration, error) {
 	out := new(proto1.Registration)
 	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/NewRegistration", in, out, opts...)
@@ -3383,6 +6007,15 @@ func (c *storageAuthorityClient) AddSerial(ctx context.Context, in *AddSerialReq
 	return out, nil
 }
 
+func (c *storageAuthorityClient) RecordIssuanceFailure(ctx context.Context, in *IssuanceFailure, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/RecordIssuanceFailure", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageAuthorityClient) DeactivateRegistration(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*proto1.Empty, error) {
 	out := new(proto1.Empty)
 	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/DeactivateRegistration", in, out, opts...)
@@ -3401,6 +6034,15 @@ func (c *storageAuthorityClient) NewOrder(ctx context.Context, in *proto1.Order,
 	return out, nil
 }
 
+func (c *storageAuthorityClient) NewOrderAndAuthzs(ctx context.Context, in *NewOrderAndAuthzsRequest, opts ...grpc.CallOption) (*proto1.Order, error) {
+	out := new(proto1.Order)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/NewOrderAndAuthzs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageAuthorityClient) SetOrderProcessing(ctx context.Context, in *proto1.Order, opts ...grpc.CallOption) (*proto1.Empty, error) {
 	out := new(proto1.Empty)
 	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/SetOrderProcessing", in, out, opts...)
@@ -3475,16 +6117,70 @@ func (c *storageAuthorityClient) FinalizeAuthorization2(ctx context.Context, in
 
 func (c *storageAuthorityClient) DeactivateAuthorization2(ctx context.Context, in *AuthorizationID2, opts ...grpc.CallOption) (*proto1.Empty, error) {
 	out := new(proto1.Empty)
-	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/DeactivateAuthorization2", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/DeactivateAuthorization2", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) AddBlockedKey(ctx context.Context, in *AddBlockedKeyRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/AddBlockedKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) SetRevocationWebhook(ctx context.Context, in *RevocationWebhook, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/SetRevocationWebhook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) PauseIdentifiers(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/PauseIdentifiers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) UnpauseAccount(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*Count, error) {
+	out := new(Count)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/UnpauseAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) AddRateLimitOverride(ctx context.Context, in *RateLimitOverride, opts ...grpc.CallOption) (*RateLimitOverride, error) {
+	out := new(RateLimitOverride)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/AddRateLimitOverride", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) ExpireRateLimitOverride(ctx context.Context, in *ExpireRateLimitOverrideRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/ExpireRateLimitOverride", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) AddBlockedKey(ctx context.Context, in *AddBlockedKeyRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+func (c *storageAuthorityClient) AddIncidentSerial(ctx context.Context, in *AddIncidentSerialRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
 	out := new(proto1.Empty)
-	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/AddBlockedKey", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/AddIncidentSerial", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -3507,6 +6203,9 @@ type StorageAuthorityServer interface {
 	// a given registration ID and expire in the given time range.
 	CountFQDNSets(context.Context, *CountFQDNSetsRequest) (*Count, error)
 	FQDNSetExists(context.Context, *FQDNSetExistsRequest) (*Exists, error)
+	// Return whether a certificate for exactly the given set of names was
+	// issued within the window.
+	FQDNSetIssuedWithin(context.Context, *CountFQDNSetsRequest) (*Exists, error)
 	PreviousCertificateExists(context.Context, *PreviousCertificateExistsRequest) (*Exists, error)
 	GetAuthorization2(context.Context, *AuthorizationID2) (*proto1.Authorization, error)
 	GetAuthorizations2(context.Context, *GetAuthorizationsRequest) (*Authorizations, error)
@@ -3516,14 +6215,30 @@ type StorageAuthorityServer interface {
 	CountInvalidAuthorizations2(context.Context, *CountInvalidAuthorizationsRequest) (*Count, error)
 	GetValidAuthorizations2(context.Context, *GetValidAuthorizationsRequest) (*Authorizations, error)
 	KeyBlocked(context.Context, *KeyBlockedRequest) (*Exists, error)
+	GetRevocationWebhook(context.Context, *RegistrationID) (*RevocationWebhook, error)
+	GetSerialMetadata(context.Context, *Serial) (*SerialMetadata, error)
+	ReplacementOrderExists(context.Context, *Serial) (*Exists, error)
+	GetOrdersForAccount(context.Context, *GetOrdersForAccountRequest) (*OrderIDs, error)
+	GetPausedIdentifiers(context.Context, *PauseRequest) (*PausedIdentifiers, error)
+	CountPaused(context.Context, *RegistrationID) (*Count, error)
+	GetSerialsByKey(context.Context, *SPKIHash) (*Serials, error)
+	GetSerialsByKeyHash(*SPKIHash, StorageAuthority_GetSerialsByKeyHashServer) error
+	GetRevokedCertsByShard(context.Context, *GetRevokedCertsByShardRequest) (*RevokedCerts, error)
+	IncidentsForSerial(context.Context, *Serial) (*Incidents, error)
+	SerialsForIncident(*SerialsForIncidentRequest, StorageAuthority_SerialsForIncidentServer) error
+	GetIncident(context.Context, *GetIncidentRequest) (*Incident, error)
+	GetIssuanceOutcome(context.Context, *Serial) (*IssuanceOutcome, error)
+	GetRateLimitOverrides(context.Context, *GetRateLimitOverridesRequest) (*RateLimitOverrides, error)
 	// Adders
 	NewRegistration(context.Context, *proto1.Registration) (*proto1.Registration, error)
 	UpdateRegistration(context.Context, *proto1.Registration) (*proto1.Empty, error)
 	AddCertificate(context.Context, *AddCertificateRequest) (*AddCertificateResponse, error)
 	AddPrecertificate(context.Context, *AddCertificateRequest) (*proto1.Empty, error)
 	AddSerial(context.Context, *AddSerialRequest) (*proto1.Empty, error)
+	RecordIssuanceFailure(context.Context, *IssuanceFailure) (*proto1.Empty, error)
 	DeactivateRegistration(context.Context, *RegistrationID) (*proto1.Empty, error)
 	NewOrder(context.Context, *proto1.Order) (*proto1.Order, error)
+	NewOrderAndAuthzs(context.Context, *NewOrderAndAuthzsRequest) (*proto1.Order, error)
 	SetOrderProcessing(context.Context, *proto1.Order) (*proto1.Empty, error)
 	SetOrderError(context.Context, *proto1.Order) (*proto1.Empty, error)
 	FinalizeOrder(context.Context, *proto1.Order) (*proto1.Empty, error)
@@ -3534,6 +6249,12 @@ type StorageAuthorityServer interface {
 	FinalizeAuthorization2(context.Context, *FinalizeAuthorizationRequest) (*proto1.Empty, error)
 	DeactivateAuthorization2(context.Context, *AuthorizationID2) (*proto1.Empty, error)
 	AddBlockedKey(context.Context, *AddBlockedKeyRequest) (*proto1.Empty, error)
+	SetRevocationWebhook(context.Context, *RevocationWebhook) (*proto1.Empty, error)
+	PauseIdentifiers(context.Context, *PauseRequest) (*proto1.Empty, error)
+	UnpauseAccount(context.Context, *RegistrationID) (*Count, error)
+	AddRateLimitOverride(context.Context, *RateLimitOverride) (*RateLimitOverride, error)
+	ExpireRateLimitOverride(context.Context, *ExpireRateLimitOverrideRequest) (*proto1.Empty, error)
+	AddIncidentSerial(context.Context, *AddIncidentSerialRequest) (*proto1.Empty, error)
 }
 
 // UnimplementedStorageAuthorityServer can be embedded to have forward compatible implementations.
@@ -3573,6 +6294,9 @@ func (*UnimplementedStorageAuthorityServer) CountFQDNSets(context.Context, *Coun
 func (*UnimplementedStorageAuthorityServer) FQDNSetExists(context.Context, *FQDNSetExistsRequest) (*Exists, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FQDNSetExists not implemented")
 }
+func (*UnimplementedStorageAuthorityServer) FQDNSetIssuedWithin(context.Context, *CountFQDNSetsRequest) (*Exists, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FQDNSetIssuedWithin not implemented")
+}
 func (*UnimplementedStorageAuthorityServer) PreviousCertificateExists(context.Context, *PreviousCertificateExistsRequest) (*Exists, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PreviousCertificateExists not implemented")
 }
@@ -3600,6 +6324,48 @@ func (*UnimplementedStorageAuthorityServer) GetValidAuthorizations2(context.Cont
 func (*UnimplementedStorageAuthorityServer) KeyBlocked(context.Context, *KeyBlockedRequest) (*Exists, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method KeyBlocked not implemented")
 }
+func (*UnimplementedStorageAuthorityServer) GetRevocationWebhook(context.Context, *RegistrationID) (*RevocationWebhook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRevocationWebhook not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetSerialMetadata(context.Context, *Serial) (*SerialMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSerialMetadata not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) ReplacementOrderExists(context.Context, *Serial) (*Exists, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplacementOrderExists not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetOrdersForAccount(context.Context, *GetOrdersForAccountRequest) (*OrderIDs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrdersForAccount not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetPausedIdentifiers(context.Context, *PauseRequest) (*PausedIdentifiers, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPausedIdentifiers not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) CountPaused(context.Context, *RegistrationID) (*Count, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountPaused not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetSerialsByKey(context.Context, *SPKIHash) (*Serials, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSerialsByKey not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetSerialsByKeyHash(*SPKIHash, StorageAuthority_GetSerialsByKeyHashServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetSerialsByKeyHash not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetRevokedCertsByShard(context.Context, *GetRevokedCertsByShardRequest) (*RevokedCerts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRevokedCertsByShard not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) IncidentsForSerial(context.Context, *Serial) (*Incidents, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IncidentsForSerial not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) SerialsForIncident(*SerialsForIncidentRequest, StorageAuthority_SerialsForIncidentServer) error {
+	return status.Errorf(codes.Unimplemented, "method SerialsForIncident not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetIncident(context.Context, *GetIncidentRequest) (*Incident, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIncident not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetIssuanceOutcome(context.Context, *Serial) (*IssuanceOutcome, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIssuanceOutcome not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetRateLimitOverrides(context.Context, *GetRateLimitOverridesRequest) (*RateLimitOverrides, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRateLimitOverrides not implemented")
+}
 func (*UnimplementedStorageAuthorityServer) NewRegistration(context.Context, *proto1.Registration) (*proto1.Registration, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NewRegistration not implemented")
 }
@@ -3615,12 +6381,18 @@ func (*UnimplementedStorageAuthorityServer) AddPrecertificate(context.Context, *
 func (*UnimplementedStorageAuthorityServer) AddSerial(context.Context, *AddSerialRequest) (*proto1.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddSerial not implemented")
 }
+func (*UnimplementedStorageAuthorityServer) RecordIssuanceFailure(context.Context, *IssuanceFailure) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordIssuanceFailure not implemented")
+}
 func (*UnimplementedStorageAuthorityServer) DeactivateRegistration(context.Context, *RegistrationID) (*proto1.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeactivateRegistration not implemented")
 }
 func (*UnimplementedStorageAuthorityServer) NewOrder(context.Context, *proto1.Order) (*proto1.Order, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NewOrder not implemented")
 }
+func (*UnimplementedStorageAuthorityServer) NewOrderAndAuthzs(context.Context, *NewOrderAndAuthzsRequest) (*proto1.Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewOrderAndAuthzs not implemented")
+}
 func (*UnimplementedStorageAuthorityServer) SetOrderProcessing(context.Context, *proto1.Order) (*proto1.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetOrderProcessing not implemented")
 }
@@ -3651,6 +6423,24 @@ func (*UnimplementedStorageAuthorityServer) DeactivateAuthorization2(context.Con
 func (*UnimplementedStorageAuthorityServer) AddBlockedKey(context.Context, *AddBlockedKeyRequest) (*proto1.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddBlockedKey not implemented")
 }
+func (*UnimplementedStorageAuthorityServer) SetRevocationWebhook(context.Context, *RevocationWebhook) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRevocationWebhook not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) PauseIdentifiers(context.Context, *PauseRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseIdentifiers not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) UnpauseAccount(context.Context, *RegistrationID) (*Count, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnpauseAccount not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) AddRateLimitOverride(context.Context, *RateLimitOverride) (*RateLimitOverride, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddRateLimitOverride not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) ExpireRateLimitOverride(context.Context, *ExpireRateLimitOverrideRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExpireRateLimitOverride not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) AddIncidentSerial(context.Context, *AddIncidentSerialRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddIncidentSerial not implemented")
+}
 
 func RegisterStorageAuthorityServer(s *grpc.Server, srv StorageAuthorityServer) {
 	s.RegisterService(&_StorageAuthority_serviceDesc, srv)
@@ -3764,254 +6554,530 @@ func _StorageAuthority_CountCertificatesByNames_Handler(srv interface{}, ctx con
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_CountRegistrationsByIP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CountRegistrationsByIPRequest)
+func _StorageAuthority_CountRegistrationsByIP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountRegistrationsByIPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).CountRegistrationsByIP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/CountRegistrationsByIP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).CountRegistrationsByIP(ctx, req.(*CountRegistrationsByIPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_CountRegistrationsByIPRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountRegistrationsByIPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).CountRegistrationsByIPRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/CountRegistrationsByIPRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).CountRegistrationsByIPRange(ctx, req.(*CountRegistrationsByIPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_CountOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).CountOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/CountOrders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).CountOrders(ctx, req.(*CountOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_CountFQDNSets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountFQDNSetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).CountFQDNSets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/CountFQDNSets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).CountFQDNSets(ctx, req.(*CountFQDNSetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_FQDNSetExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FQDNSetExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).FQDNSetExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/FQDNSetExists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).FQDNSetExists(ctx, req.(*FQDNSetExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_FQDNSetIssuedWithin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountFQDNSetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).FQDNSetIssuedWithin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/FQDNSetIssuedWithin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).FQDNSetIssuedWithin(ctx, req.(*CountFQDNSetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_PreviousCertificateExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviousCertificateExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).PreviousCertificateExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/PreviousCertificateExists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).PreviousCertificateExists(ctx, req.(*PreviousCertificateExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetAuthorization2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizationID2)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetAuthorization2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetAuthorization2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetAuthorization2(ctx, req.(*AuthorizationID2))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAuthorizationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetAuthorizations2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetAuthorizations2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetAuthorizations2(ctx, req.(*GetAuthorizationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetPendingAuthorization2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPendingAuthorizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetPendingAuthorization2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetPendingAuthorization2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetPendingAuthorization2(ctx, req.(*GetPendingAuthorizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_CountPendingAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistrationID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).CountPendingAuthorizations2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/CountPendingAuthorizations2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).CountPendingAuthorizations2(ctx, req.(*RegistrationID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetValidOrderAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetValidOrderAuthorizationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetValidOrderAuthorizations2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetValidOrderAuthorizations2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetValidOrderAuthorizations2(ctx, req.(*GetValidOrderAuthorizationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_CountInvalidAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountInvalidAuthorizationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).CountInvalidAuthorizations2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/CountInvalidAuthorizations2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).CountInvalidAuthorizations2(ctx, req.(*CountInvalidAuthorizationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetValidAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetValidAuthorizationsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).CountRegistrationsByIP(ctx, in)
+		return srv.(StorageAuthorityServer).GetValidAuthorizations2(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/CountRegistrationsByIP",
+		FullMethod: "/sa.StorageAuthority/GetValidAuthorizations2",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).CountRegistrationsByIP(ctx, req.(*CountRegistrationsByIPRequest))
+		return srv.(StorageAuthorityServer).GetValidAuthorizations2(ctx, req.(*GetValidAuthorizationsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_CountRegistrationsByIPRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CountRegistrationsByIPRequest)
+func _StorageAuthority_KeyBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyBlockedRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).CountRegistrationsByIPRange(ctx, in)
+		return srv.(StorageAuthorityServer).KeyBlocked(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/CountRegistrationsByIPRange",
+		FullMethod: "/sa.StorageAuthority/KeyBlocked",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).CountRegistrationsByIPRange(ctx, req.(*CountRegistrationsByIPRequest))
+		return srv.(StorageAuthorityServer).KeyBlocked(ctx, req.(*KeyBlockedRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_CountOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CountOrdersRequest)
+func _StorageAuthority_GetRevocationWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistrationID)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).CountOrders(ctx, in)
+		return srv.(StorageAuthorityServer).GetRevocationWebhook(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/CountOrders",
+		FullMethod: "/sa.StorageAuthority/GetRevocationWebhook",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).CountOrders(ctx, req.(*CountOrdersRequest))
+		return srv.(StorageAuthorityServer).GetRevocationWebhook(ctx, req.(*RegistrationID))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_CountFQDNSets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CountFQDNSetsRequest)
+func _StorageAuthority_GetSerialMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Serial)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).CountFQDNSets(ctx, in)
+		return srv.(StorageAuthorityServer).GetSerialMetadata(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/CountFQDNSets",
+		FullMethod: "/sa.StorageAuthority/GetSerialMetadata",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).CountFQDNSets(ctx, req.(*CountFQDNSetsRequest))
+		return srv.(StorageAuthorityServer).GetSerialMetadata(ctx, req.(*Serial))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_FQDNSetExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(FQDNSetExistsRequest)
+func _StorageAuthority_ReplacementOrderExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Serial)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).FQDNSetExists(ctx, in)
+		return srv.(StorageAuthorityServer).ReplacementOrderExists(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/FQDNSetExists",
+		FullMethod: "/sa.StorageAuthority/ReplacementOrderExists",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).FQDNSetExists(ctx, req.(*FQDNSetExistsRequest))
+		return srv.(StorageAuthorityServer).ReplacementOrderExists(ctx, req.(*Serial))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_PreviousCertificateExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PreviousCertificateExistsRequest)
+func _StorageAuthority_GetOrdersForAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrdersForAccountRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).PreviousCertificateExists(ctx, in)
+		return srv.(StorageAuthorityServer).GetOrdersForAccount(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/PreviousCertificateExists",
+		FullMethod: "/sa.StorageAuthority/GetOrdersForAccount",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).PreviousCertificateExists(ctx, req.(*PreviousCertificateExistsRequest))
+		return srv.(StorageAuthorityServer).GetOrdersForAccount(ctx, req.(*GetOrdersForAccountRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_GetAuthorization2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AuthorizationID2)
+func _StorageAuthority_GetPausedIdentifiers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).GetAuthorization2(ctx, in)
+		return srv.(StorageAuthorityServer).GetPausedIdentifiers(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/GetAuthorization2",
+		FullMethod: "/sa.StorageAuthority/GetPausedIdentifiers",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).GetAuthorization2(ctx, req.(*AuthorizationID2))
+		return srv.(StorageAuthorityServer).GetPausedIdentifiers(ctx, req.(*PauseRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_GetAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetAuthorizationsRequest)
+func _StorageAuthority_CountPaused_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistrationID)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).GetAuthorizations2(ctx, in)
+		return srv.(StorageAuthorityServer).CountPaused(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/GetAuthorizations2",
+		FullMethod: "/sa.StorageAuthority/CountPaused",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).GetAuthorizations2(ctx, req.(*GetAuthorizationsRequest))
+		return srv.(StorageAuthorityServer).CountPaused(ctx, req.(*RegistrationID))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_GetPendingAuthorization2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetPendingAuthorizationRequest)
+func _StorageAuthority_GetSerialsByKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SPKIHash)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).GetPendingAuthorization2(ctx, in)
+		return srv.(StorageAuthorityServer).GetSerialsByKey(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/GetPendingAuthorization2",
+		FullMethod: "/sa.StorageAuthority/GetSerialsByKey",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).GetPendingAuthorization2(ctx, req.(*GetPendingAuthorizationRequest))
+		return srv.(StorageAuthorityServer).GetSerialsByKey(ctx, req.(*SPKIHash))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_CountPendingAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RegistrationID)
+func _StorageAuthority_GetSerialsByKeyHash_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SPKIHash)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageAuthorityServer).GetSerialsByKeyHash(m, &storageAuthorityGetSerialsByKeyHashServer{stream})
+}
+
+type StorageAuthority_GetSerialsByKeyHashServer interface {
+	Send(*Serial) error
+	grpc.ServerStream
+}
+
+type storageAuthorityGetSerialsByKeyHashServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageAuthorityGetSerialsByKeyHashServer) Send(m *Serial) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StorageAuthority_GetRevokedCertsByShard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRevokedCertsByShardRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).CountPendingAuthorizations2(ctx, in)
+		return srv.(StorageAuthorityServer).GetRevokedCertsByShard(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/CountPendingAuthorizations2",
+		FullMethod: "/sa.StorageAuthority/GetRevokedCertsByShard",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).CountPendingAuthorizations2(ctx, req.(*RegistrationID))
+		return srv.(StorageAuthorityServer).GetRevokedCertsByShard(ctx, req.(*GetRevokedCertsByShardRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_GetValidOrderAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetValidOrderAuthorizationsRequest)
+func _StorageAuthority_IncidentsForSerial_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Serial)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).GetValidOrderAuthorizations2(ctx, in)
+		return srv.(StorageAuthorityServer).IncidentsForSerial(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/GetValidOrderAuthorizations2",
+		FullMethod: "/sa.StorageAuthority/IncidentsForSerial",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).GetValidOrderAuthorizations2(ctx, req.(*GetValidOrderAuthorizationsRequest))
+		return srv.(StorageAuthorityServer).IncidentsForSerial(ctx, req.(*Serial))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_CountInvalidAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CountInvalidAuthorizationsRequest)
+func _StorageAuthority_SerialsForIncident_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SerialsForIncidentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageAuthorityServer).SerialsForIncident(m, &storageAuthoritySerialsForIncidentServer{stream})
+}
+
+type StorageAuthority_SerialsForIncidentServer interface {
+	Send(*IncidentSerial) error
+	grpc.ServerStream
+}
+
+type storageAuthoritySerialsForIncidentServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageAuthoritySerialsForIncidentServer) Send(m *IncidentSerial) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StorageAuthority_GetIncident_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIncidentRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).CountInvalidAuthorizations2(ctx, in)
+		return srv.(StorageAuthorityServer).GetIncident(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/CountInvalidAuthorizations2",
+		FullMethod: "/sa.StorageAuthority/GetIncident",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).CountInvalidAuthorizations2(ctx, req.(*CountInvalidAuthorizationsRequest))
+		return srv.(StorageAuthorityServer).GetIncident(ctx, req.(*GetIncidentRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_GetValidAuthorizations2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetValidAuthorizationsRequest)
+func _StorageAuthority_GetIssuanceOutcome_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Serial)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).GetValidAuthorizations2(ctx, in)
+		return srv.(StorageAuthorityServer).GetIssuanceOutcome(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/GetValidAuthorizations2",
+		FullMethod: "/sa.StorageAuthority/GetIssuanceOutcome",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).GetValidAuthorizations2(ctx, req.(*GetValidAuthorizationsRequest))
+		return srv.(StorageAuthorityServer).GetIssuanceOutcome(ctx, req.(*Serial))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StorageAuthority_KeyBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(KeyBlockedRequest)
+func _StorageAuthority_GetRateLimitOverrides_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRateLimitOverridesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageAuthorityServer).KeyBlocked(ctx, in)
+		return srv.(StorageAuthorityServer).GetRateLimitOverrides(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/sa.StorageAuthority/KeyBlocked",
+		FullMethod: "/sa.StorageAuthority/GetRateLimitOverrides",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageAuthorityServer).KeyBlocked(ctx, req.(*KeyBlockedRequest))
+		return srv.(StorageAuthorityServer).GetRateLimitOverrides(ctx, req.(*GetRateLimitOverridesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -4106,6 +7172,24 @@ func _StorageAuthority_AddSerial_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_RecordIssuanceFailure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssuanceFailure)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).RecordIssuanceFailure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/RecordIssuanceFailure",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).RecordIssuanceFailure(ctx, req.(*IssuanceFailure))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageAuthority_DeactivateRegistration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RegistrationID)
 	if err := dec(in); err != nil {
@@ -4142,6 +7226,24 @@ func _StorageAuthority_NewOrder_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_NewOrderAndAuthzs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewOrderAndAuthzsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).NewOrderAndAuthzs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/NewOrderAndAuthzs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).NewOrderAndAuthzs(ctx, req.(*NewOrderAndAuthzsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageAuthority_SetOrderProcessing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(proto1.Order)
 	if err := dec(in); err != nil {
@@ -4322,6 +7424,114 @@ func _StorageAuthority_AddBlockedKey_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_SetRevocationWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevocationWebhook)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).SetRevocationWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/SetRevocationWebhook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).SetRevocationWebhook(ctx, req.(*RevocationWebhook))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_PauseIdentifiers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).PauseIdentifiers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/PauseIdentifiers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).PauseIdentifiers(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_UnpauseAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistrationID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).UnpauseAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/UnpauseAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).UnpauseAccount(ctx, req.(*RegistrationID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddRateLimitOverride_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RateLimitOverride)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddRateLimitOverride(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddRateLimitOverride",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddRateLimitOverride(ctx, req.(*RateLimitOverride))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_ExpireRateLimitOverride_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpireRateLimitOverrideRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).ExpireRateLimitOverride(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/ExpireRateLimitOverride",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).ExpireRateLimitOverride(ctx, req.(*ExpireRateLimitOverrideRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddIncidentSerial_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddIncidentSerialRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddIncidentSerial(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddIncidentSerial",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddIncidentSerial(ctx, req.(*AddIncidentSerialRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "sa.StorageAuthority",
 	HandlerType: (*StorageAuthorityServer)(nil),
@@ -4370,6 +7580,10 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FQDNSetExists",
 			Handler:    _StorageAuthority_FQDNSetExists_Handler,
 		},
+		{
+			MethodName: "FQDNSetIssuedWithin",
+			Handler:    _StorageAuthority_FQDNSetIssuedWithin_Handler,
+		},
 		{
 			MethodName: "PreviousCertificateExists",
 			Handler:    _StorageAuthority_PreviousCertificateExists_Handler,
@@ -4406,6 +7620,54 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "KeyBlocked",
 			Handler:    _StorageAuthority_KeyBlocked_Handler,
 		},
+		{
+			MethodName: "GetRevocationWebhook",
+			Handler:    _StorageAuthority_GetRevocationWebhook_Handler,
+		},
+		{
+			MethodName: "GetSerialMetadata",
+			Handler:    _StorageAuthority_GetSerialMetadata_Handler,
+		},
+		{
+			MethodName: "ReplacementOrderExists",
+			Handler:    _StorageAuthority_ReplacementOrderExists_Handler,
+		},
+		{
+			MethodName: "GetOrdersForAccount",
+			Handler:    _StorageAuthority_GetOrdersForAccount_Handler,
+		},
+		{
+			MethodName: "GetPausedIdentifiers",
+			Handler:    _StorageAuthority_GetPausedIdentifiers_Handler,
+		},
+		{
+			MethodName: "CountPaused",
+			Handler:    _StorageAuthority_CountPaused_Handler,
+		},
+		{
+			MethodName: "GetSerialsByKey",
+			Handler:    _StorageAuthority_GetSerialsByKey_Handler,
+		},
+		{
+			MethodName: "GetRevokedCertsByShard",
+			Handler:    _StorageAuthority_GetRevokedCertsByShard_Handler,
+		},
+		{
+			MethodName: "IncidentsForSerial",
+			Handler:    _StorageAuthority_IncidentsForSerial_Handler,
+		},
+		{
+			MethodName: "GetIncident",
+			Handler:    _StorageAuthority_GetIncident_Handler,
+		},
+		{
+			MethodName: "GetIssuanceOutcome",
+			Handler:    _StorageAuthority_GetIssuanceOutcome_Handler,
+		},
+		{
+			MethodName: "GetRateLimitOverrides",
+			Handler:    _StorageAuthority_GetRateLimitOverrides_Handler,
+		},
 		{
 			MethodName: "NewRegistration",
 			Handler:    _StorageAuthority_NewRegistration_Handler,
@@ -4426,6 +7688,10 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AddSerial",
 			Handler:    _StorageAuthority_AddSerial_Handler,
 		},
+		{
+			MethodName: "RecordIssuanceFailure",
+			Handler:    _StorageAuthority_RecordIssuanceFailure_Handler,
+		},
 		{
 			MethodName: "DeactivateRegistration",
 			Handler:    _StorageAuthority_DeactivateRegistration_Handler,
@@ -4434,6 +7700,10 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "NewOrder",
 			Handler:    _StorageAuthority_NewOrder_Handler,
 		},
+		{
+			MethodName: "NewOrderAndAuthzs",
+			Handler:    _StorageAuthority_NewOrderAndAuthzs_Handler,
+		},
 		{
 			MethodName: "SetOrderProcessing",
 			Handler:    _StorageAuthority_SetOrderProcessing_Handler,
@@ -4474,7 +7744,42 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AddBlockedKey",
 			Handler:    _StorageAuthority_AddBlockedKey_Handler,
 		},
+		{
+			MethodName: "SetRevocationWebhook",
+			Handler:    _StorageAuthority_SetRevocationWebhook_Handler,
+		},
+		{
+			MethodName: "PauseIdentifiers",
+			Handler:    _StorageAuthority_PauseIdentifiers_Handler,
+		},
+		{
+			MethodName: "UnpauseAccount",
+			Handler:    _StorageAuthority_UnpauseAccount_Handler,
+		},
+		{
+			MethodName: "AddRateLimitOverride",
+			Handler:    _StorageAuthority_AddRateLimitOverride_Handler,
+		},
+		{
+			MethodName: "ExpireRateLimitOverride",
+			Handler:    _StorageAuthority_ExpireRateLimitOverride_Handler,
+		},
+		{
+			MethodName: "AddIncidentSerial",
+			Handler:    _StorageAuthority_AddIncidentSerial_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetSerialsByKeyHash",
+			Handler:       _StorageAuthority_GetSerialsByKeyHash_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SerialsForIncident",
+			Handler:       _StorageAuthority_SerialsForIncident_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "sa/proto/sa.proto",
 }
@@ -0,0 +1,166 @@
+package sa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	berrors "github.com/letsencrypt/boulder/errors"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/ratelimit"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+// AddRateLimitOverride stores an override of a rate limit's threshold for an
+// account or key, along with why it was granted and by whom, and returns it
+// with its ID and creation time. It applies until it expires or is expired
+// early with ExpireRateLimitOverride.
+func (ssa *SQLStorageAuthority) AddRateLimitOverride(ctx context.Context, req *sapb.RateLimitOverride) (*sapb.RateLimitOverride, error) {
+	if req == nil || req.LimitName == nil || req.Threshold == nil || req.Reason == nil || req.Requester == nil || (req.Expires == nil && req.ExpiresTime == nil) {
+		return nil, errIncompleteRequest
+	}
+	if !ratelimit.ValidLimitName(*req.LimitName) {
+		return nil, berrors.MalformedError("unknown rate limit %q", *req.LimitName)
+	}
+	if (req.GetRegistrationID() == 0) == (req.GetKey() == "") {
+		return nil, berrors.MalformedError("rate limit override must have exactly one of a registration ID or a key")
+	}
+	if *req.Reason == "" || *req.Requester == "" {
+		return nil, berrors.MalformedError("rate limit override must have a reason and a requester")
+	}
+	expires, err := bgrpc.PBOrNanosToTime(req.ExpiresTime, req.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expires: %s", err)
+	}
+	now := ssa.clk.Now()
+	if !expires.After(now) {
+		return nil, berrors.MalformedError("rate limit override expiry %s is not in the future", expires)
+	}
+
+	var regID *int64
+	var key *string
+	if req.GetRegistrationID() != 0 {
+		regID = req.RegistrationID
+	} else {
+		key = req.Key
+	}
+	result, err := ssa.dbMap.WithContext(ctx).Exec(
+		`INSERT INTO rateLimitOverrides (limitName, registrationID, overrideKey, threshold, reason, requester, created, expires)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		*req.LimitName, regID, key, *req.Threshold, *req.Reason, *req.Requester, now, expires,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	created := now.UnixNano()
+	expiresNanos := expires.UnixNano()
+	return &sapb.RateLimitOverride{
+		Id:             &id,
+		LimitName:      req.LimitName,
+		RegistrationID: req.RegistrationID,
+		Key:            req.Key,
+		Threshold:      req.Threshold,
+		Reason:         req.Reason,
+		Requester:      req.Requester,
+		Created:        &created,
+		CreatedTime:    bgrpc.TimeToPB(now),
+		Expires:        &expiresNanos,
+		ExpiresTime:    bgrpc.TimeToPB(expires),
+	}, nil
+}
+
+// ExpireRateLimitOverride makes the override with the given ID expire now,
+// recording who expired it. A NotFound error is returned if there's no such
+// override, or it has already expired.
+func (ssa *SQLStorageAuthority) ExpireRateLimitOverride(ctx context.Context, req *sapb.ExpireRateLimitOverrideRequest) (*corepb.Empty, error) {
+	if req == nil || req.Id == nil || req.Requester == nil {
+		return nil, errIncompleteRequest
+	}
+	now := ssa.clk.Now()
+	result, err := ssa.dbMap.WithContext(ctx).Exec(
+		"UPDATE rateLimitOverrides SET expires = ?, expiredBy = ? WHERE id = ? AND expires > ?",
+		now, *req.Requester, *req.Id, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, berrors.NotFoundError("no unexpired rate limit override with ID %d", *req.Id)
+	}
+	return &corepb.Empty{}, nil
+}
+
+// GetRateLimitOverrides returns the rate limit overrides which haven't
+// expired, or every override if includeExpired is set, in the order they were
+// added.
+func (ssa *SQLStorageAuthority) GetRateLimitOverrides(ctx context.Context, req *sapb.GetRateLimitOverridesRequest) (*sapb.RateLimitOverrides, error) {
+	if req == nil {
+		return nil, errIncompleteRequest
+	}
+	query := `SELECT id, limitName, registrationID, overrideKey, threshold, reason, requester, created, expires, expiredBy
+		FROM rateLimitOverrides`
+	var params []interface{}
+	if !req.GetIncludeExpired() {
+		query += " WHERE expires > ?"
+		params = append(params, ssa.clk.Now())
+	}
+	query += " ORDER BY id"
+
+	var rows []struct {
+		ID             int64
+		LimitName      string
+		RegistrationID *int64
+		OverrideKey    *string
+		Threshold      int64
+		Reason         string
+		Requester      string
+		Created        time.Time
+		Expires        time.Time
+		ExpiredBy      *string
+	}
+	_, err := ssa.dbMap.WithContext(ctx).Select(&rows, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make([]*sapb.RateLimitOverride, len(rows))
+	for i, row := range rows {
+		row := row
+		var regID int64
+		if row.RegistrationID != nil {
+			regID = *row.RegistrationID
+		}
+		var key, expiredBy string
+		if row.OverrideKey != nil {
+			key = *row.OverrideKey
+		}
+		if row.ExpiredBy != nil {
+			expiredBy = *row.ExpiredBy
+		}
+		created := row.Created.UnixNano()
+		expires := row.Expires.UnixNano()
+		overrides[i] = &sapb.RateLimitOverride{
+			Id:             &row.ID,
+			LimitName:      &row.LimitName,
+			RegistrationID: &regID,
+			Key:            &key,
+			Threshold:      &row.Threshold,
+			Reason:         &row.Reason,
+			Requester:      &row.Requester,
+			Created:        &created,
+			CreatedTime:    bgrpc.TimeToPB(row.Created),
+			Expires:        &expires,
+			ExpiresTime:    bgrpc.TimeToPB(row.Expires),
+			ExpiredBy:      &expiredBy,
+		}
+	}
+	return &sapb.RateLimitOverrides{Overrides: overrides}, nil
+}
@@ -0,0 +1,173 @@
+package sa
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/letsencrypt/boulder/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// replicaLagCheckInterval is how often the SA checks how far behind the
+// primary each of its read replicas is.
+const replicaLagCheckInterval = time.Second
+
+// replica is a read-only copy of the database, and whether it could be reached
+// and was close enough to the primary to be read from when last checked.
+type replica struct {
+	dbMap *db.WrappedMap
+
+	sync.Mutex
+	reachable bool
+	usable    bool
+}
+
+// replicaSet routes reads which tolerate replication lag to whichever of its
+// replicas are within maxLag of the primary, in turn, falling back to the
+// primary when none are.
+type replicaSet struct {
+	primary  *db.WrappedMap
+	replicas []*replica
+	maxLag   time.Duration
+
+	sync.Mutex
+	next int
+}
+
+// pick returns the database to send the next lag tolerant read to.
+func (rs *replicaSet) pick() *db.WrappedMap {
+	if rs == nil || len(rs.replicas) == 0 {
+		return nil
+	}
+	rs.Lock()
+	start := rs.next
+	rs.next = (rs.next + 1) % len(rs.replicas)
+	rs.Unlock()
+	for i := range rs.replicas {
+		r := rs.replicas[(start+i)%len(rs.replicas)]
+		r.Lock()
+		usable := r.usable
+		r.Unlock()
+		if usable {
+			return r.dbMap
+		}
+	}
+	return rs.primary
+}
+
+// checkLag records whether each replica is currently within maxLag of the
+// primary. A replica whose lag can't be determined isn't used.
+func (rs *replicaSet) checkLag(lagGauge *prometheus.GaugeVec, lagErrors prometheus.Counter) {
+	for i, r := range rs.replicas {
+		lag, err := replicationLag(r.dbMap.Db)
+		if err != nil {
+			lagErrors.Inc()
+		} else {
+			lagGauge.WithLabelValues(strconv.Itoa(i)).Set(lag.Seconds())
+		}
+		r.Lock()
+		r.reachable = err == nil
+		r.usable = err == nil && lag <= rs.maxLag
+		r.Unlock()
+	}
+}
+
+// anyReachable returns whether any replica's lag could be determined when last
+// checked, whether or not it was too far behind to be used.
+func (rs *replicaSet) anyReachable() bool {
+	for _, r := range rs.replicas {
+		r.Lock()
+		reachable := r.reachable
+		r.Unlock()
+		if reachable {
+			return true
+		}
+	}
+	return false
+}
+
+// replicationLag returns how far behind its primary the database is, as
+// reported by SHOW SLAVE STATUS. A database which isn't replicating from
+// anything has no lag, which lets the primary itself stand in for a replica
+// in development environments.
+func replicationLag(conn *sql.DB) (time.Duration, error) {
+	rows, err := conn.Query("SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	err = rows.Scan(dest...)
+	if err != nil {
+		return 0, err
+	}
+	for i, column := range columns {
+		if column != "Seconds_Behind_Master" {
+			continue
+		}
+		if !values[i].Valid {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("SHOW SLAVE STATUS has no Seconds_Behind_Master column")
+}
+
+// SetReadReplicas makes the SA send reads which tolerate replication lag, like
+// the heavy listings used to build CRLs, to the given read-only replicas of
+// its database rather than competing with issuance for the primary. Replicas
+// more than maxLag behind the primary aren't used, and when none are usable
+// those reads go to the primary.
+func (ssa *SQLStorageAuthority) SetReadReplicas(dbMaps []*db.WrappedMap, maxLag time.Duration, stats prometheus.Registerer) {
+	rs := &replicaSet{primary: ssa.dbMap, maxLag: maxLag}
+	for _, dbMap := range dbMaps {
+		SetSQLDebug(dbMap, ssa.log)
+		rs.replicas = append(rs.replicas, &replica{dbMap: dbMap})
+	}
+
+	lagGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_replica_lag_seconds",
+		Help: "How far each read replica was behind the primary when last checked",
+	}, []string{"replica"})
+	stats.MustRegister(lagGauge)
+	lagErrors := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "db_replica_lag_check_errors",
+		Help: "number of times a read replica's replication lag couldn't be determined",
+	})
+	stats.MustRegister(lagErrors)
+
+	rs.checkLag(lagGauge, lagErrors)
+	go func() {
+		for range time.Tick(replicaLagCheckInterval) {
+			rs.checkLag(lagGauge, lagErrors)
+		}
+	}()
+	ssa.replicas = rs
+}
+
+// readDB returns the database to send a read which tolerates replication lag
+// to: a usable read replica if the SA has any, and otherwise the primary.
+func (ssa *SQLStorageAuthority) readDB() *db.WrappedMap {
+	if dbMap := ssa.replicas.pick(); dbMap != nil {
+		return dbMap
+	}
+	return ssa.dbMap
+}
@@ -0,0 +1,32 @@
+package sa
+
+import (
+	"testing"
+
+	"github.com/letsencrypt/boulder/db"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestReplicaSetPick(t *testing.T) {
+	var rs *replicaSet
+	test.Assert(t, rs.pick() == nil, "nil replicaSet picked a database")
+
+	primary := &db.WrappedMap{}
+	a := &replica{dbMap: &db.WrappedMap{}, usable: true}
+	b := &replica{dbMap: &db.WrappedMap{}, usable: true}
+	rs = &replicaSet{primary: primary, replicas: []*replica{a, b}}
+
+	// Usable replicas are used in turn.
+	test.Assert(t, rs.pick() == a.dbMap, "first replica wasn't picked")
+	test.Assert(t, rs.pick() == b.dbMap, "second replica wasn't picked")
+	test.Assert(t, rs.pick() == a.dbMap, "first replica wasn't picked again")
+
+	// Lagging replicas are skipped.
+	a.usable = false
+	test.Assert(t, rs.pick() == b.dbMap, "usable replica wasn't picked")
+	test.Assert(t, rs.pick() == b.dbMap, "usable replica wasn't picked")
+
+	// And when none are usable, reads go to the primary.
+	b.usable = false
+	test.Assert(t, rs.pick() == primary, "primary wasn't picked")
+}
@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"net"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
 	jose "gopkg.in/square/go-jose.v2"
@@ -20,6 +22,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/db"
+	eabpb "github.com/letsencrypt/boulder/eab/proto"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
@@ -52,6 +55,29 @@ type SQLStorageAuthority struct {
 	// transactions fail and so use this stat to maintain visibility into the rate
 	// this occurs.
 	rateLimitWriteErrors prometheus.Counter
+
+	// unattributedRevocations counts revocations which couldn't be recorded in
+	// the revokedCertificates table because neither the certificate's status
+	// nor the request named its issuer. See addRevokedCertificate.
+	unattributedRevocations prometheus.Counter
+
+	// replicas are read-only copies of the database, set by SetReadReplicas,
+	// which serve reads that tolerate replication lag. See readDB.
+	replicas *replicaSet
+
+	// streamLimits overrides the default StreamLimits of streaming RPCs, by
+	// RPC name. It's set by SetStreamLimits.
+	streamLimits map[string]StreamLimits
+
+	// rateLimitsDBMap and incidentsDBMap are separate databases for rate limit
+	// counters and incidents, set by SetRateLimitsDB and SetIncidentsDB. See
+	// rateLimitsDB and incidentsDB.
+	rateLimitsDBMap *db.WrappedMap
+	incidentsDBMap  *db.WrappedMap
+
+	// serialPrefixes are the prefixes which every stored serial must start
+	// with, set by SetSerialPrefixes. See checkSerialPrefix.
+	serialPrefixes []string
 }
 
 // orderFQDNSet contains the SHA256 hash of the lowercased, comma joined names
@@ -83,12 +109,19 @@ func NewSQLStorageAuthority(
 	})
 	stats.MustRegister(rateLimitWriteErrors)
 
+	unattributedRevocations := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "unattributed_revocations",
+		Help: "number of revocations left out of the revokedCertificates table because their issuer is unknown",
+	})
+	stats.MustRegister(unattributedRevocations)
+
 	ssa := &SQLStorageAuthority{
-		dbMap:                dbMap,
-		clk:                  clk,
-		log:                  logger,
-		parallelismPerRPC:    parallelismPerRPC,
-		rateLimitWriteErrors: rateLimitWriteErrors,
+		dbMap:                   dbMap,
+		clk:                     clk,
+		log:                     logger,
+		parallelismPerRPC:       parallelismPerRPC,
+		rateLimitWriteErrors:    rateLimitWriteErrors,
+		unattributedRevocations: unattributedRevocations,
 	}
 
 	ssa.countCertificatesByName = ssa.countCertificates
@@ -262,7 +295,7 @@ func (ssa *SQLStorageAuthority) CountCertificatesByNames(ctx context.Context, do
 				default:
 				}
 				currentCount, err := ssa.countCertificatesByName(
-					ssa.dbMap.WithContext(ctx), domain, earliest, latest)
+					ssa.rateLimitsDB().WithContext(ctx), domain, earliest, latest)
 				if err != nil {
 					results <- result{err: err}
 					// Skip any further work
@@ -404,6 +437,10 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 	}
 	digest := core.Fingerprint256(certDER)
 	serial := core.SerialToString(parsedCertificate.SerialNumber)
+	err = ssa.checkSerialPrefix(serial)
+	if err != nil {
+		return "", err
+	}
 
 	cert := &core.Certificate{
 		RegistrationID: regID,
@@ -437,6 +474,13 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 			return nil, err
 		}
 
+		if features.Enabled(features.StoreIssuanceOutcomes) {
+			err = setIssuedOutcome(txWithCtx, serial, ssa.clk.Now())
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		return isRenewal, err
 	})
 	if overallError != nil {
@@ -463,7 +507,7 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 		// don't count against the certificatesPerName limit.
 		if !isRenewal {
 			timeToTheHour := parsedCertificate.NotBefore.Round(time.Hour)
-			if err := ssa.addCertificatesPerName(ctx, txWithCtx, parsedCertificate.DNSNames, timeToTheHour); err != nil {
+			if err := ssa.addCertificatesPerName(ctx, ssa.rateLimitsWriter(ctx, txWithCtx), parsedCertificate.DNSNames, timeToTheHour); err != nil {
 				return nil, err
 			}
 		}
@@ -486,7 +530,7 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 	// but don't return an error from AddCertificate.
 	if rlTransactionErr != nil {
 		ssa.rateLimitWriteErrors.Inc()
-		ssa.log.AuditErrf("failed AddCertificate ratelimit update transaction: %v", rlTransactionErr)
+		blog.ForContext(ctx, ssa.log).AuditErrf("failed AddCertificate ratelimit update transaction: %v", rlTransactionErr)
 	}
 
 	return digest, nil
@@ -494,7 +538,7 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 
 func (ssa *SQLStorageAuthority) CountOrders(ctx context.Context, acctID int64, earliest, latest time.Time) (int, error) {
 	if features.Enabled(features.FasterNewOrdersRateLimit) {
-		return countNewOrders(ctx, ssa.dbMap, acctID, earliest, latest)
+		return countNewOrders(ctx, ssa.rateLimitsDB().WithContext(ctx), acctID, earliest, latest)
 	}
 
 	var count int
@@ -609,6 +653,27 @@ func (ssa *SQLStorageAuthority) CountFQDNSets(ctx context.Context, window time.D
 	return count, err
 }
 
+// FQDNSetIssuedWithin returns whether a certificate for exactly the given
+// names was issued within window. Unlike CountFQDNSets it stops at the first
+// matching row of the fqdnSets table's setHash_issued_idx index, so it's cheap
+// regardless of how many certificates have been issued for the names.
+func (ssa *SQLStorageAuthority) FQDNSetIssuedWithin(ctx context.Context, window time.Duration, names []string) (bool, error) {
+	var issued []int64
+	_, err := ssa.dbMap.WithContext(ctx).Select(
+		&issued,
+		`SELECT 1 FROM fqdnSets
+		WHERE setHash = ?
+		AND issued > ?
+		LIMIT 1`,
+		hashNames(names),
+		ssa.clk.Now().Add(-window),
+	)
+	if err != nil {
+		return false, err
+	}
+	return len(issued) > 0, nil
+}
+
 // setHash is a []byte representing the hash of an FQDN Set
 type setHash []byte
 
@@ -742,17 +807,25 @@ func (ssa *SQLStorageAuthority) FQDNSetExists(ctx context.Context, names []strin
 type oneSelectorFunc func(holder interface{}, query string, args ...interface{}) error
 
 // checkFQDNSetExists uses the given oneSelectorFunc to check whether an fqdnSet
-// for the given names exists.
+// for the given names exists. It reads at most one row of the
+// setHash_issued_idx index, rather than counting every certificate issued for
+// the names.
 func (ssa *SQLStorageAuthority) checkFQDNSetExists(selector oneSelectorFunc, names []string) (bool, error) {
-	var count int64
+	var exists int64
 	err := selector(
-		&count,
-		`SELECT COUNT(1) FROM fqdnSets
+		&exists,
+		`SELECT 1 FROM fqdnSets
 		WHERE setHash = ?
 		LIMIT 1`,
 		hashNames(names),
 	)
-	return count > 0, err
+	if err != nil {
+		if db.IsNoRows(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 // PreviousCertificateExists returns true iff there was at least one certificate
@@ -844,6 +917,21 @@ func (ssa *SQLStorageAuthority) DeactivateAuthorization2(ctx context.Context, re
 
 // NewOrder adds a new v2 style order to the database
 func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order) (*corepb.Order, error) {
+	return ssa.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{NewOrder: req})
+}
+
+// NewOrderAndAuthzs adds a new v2 style order to the database along with any
+// new pending authorizations it needs, in a single transaction. The new
+// authorizations, the order's links to its authorizations, and its requested
+// names are each written with one multi-row insert, so the number of round
+// trips doesn't grow with the number of names in the order.
+func (ssa *SQLStorageAuthority) NewOrderAndAuthzs(ctx context.Context, nReq *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	req := nReq.NewOrder
+	for _, authz := range nReq.NewAuthzs {
+		if *authz.Status != string(core.StatusPending) {
+			return nil, berrors.InternalServerError("authorization must be pending")
+		}
+	}
 	order := &orderModel{
 		RegistrationID: *req.RegistrationID,
 		Expires:        time.Unix(0, *req.Expires),
@@ -851,28 +939,35 @@ func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order)
 	}
 
 	output, overallError := db.WithTransaction(ctx, ssa.dbMap, func(txWithCtx db.Executor) (interface{}, error) {
-		if err := txWithCtx.Insert(order); err != nil {
-			return nil, err
-		}
-
-		for _, id := range req.V2Authorizations {
-			otoa := &orderToAuthzModel{
-				OrderID: order.ID,
-				AuthzID: id,
+		if features.Enabled(features.CertificateProfiles) {
+			omv2 := &orderModelv2{
+				RegistrationID:         order.RegistrationID,
+				Expires:                order.Expires,
+				Created:                order.Created,
+				CertificateProfileName: req.GetCertificateProfileName(),
 			}
-			if err := txWithCtx.Insert(otoa); err != nil {
+			if err := txWithCtx.Insert(omv2); err != nil {
 				return nil, err
 			}
+			order.ID = omv2.ID
+		} else if err := txWithCtx.Insert(order); err != nil {
+			return nil, err
 		}
 
-		for _, name := range req.Names {
-			reqdName := &requestedNameModel{
-				OrderID:      order.ID,
-				ReversedName: ReverseName(name),
-			}
-			if err := txWithCtx.Insert(reqdName); err != nil {
+		if len(nReq.NewAuthzs) > 0 {
+			newAuthzIDs, err := insertAuthzs(txWithCtx, nReq.NewAuthzs)
+			if err != nil {
 				return nil, err
 			}
+			req.V2Authorizations = append(req.V2Authorizations, newAuthzIDs...)
+		}
+
+		if err := insertOrderToAuthzs(txWithCtx, order.ID, req.V2Authorizations); err != nil {
+			return nil, err
+		}
+
+		if err := insertRequestedNames(txWithCtx, order.ID, req.Names); err != nil {
+			return nil, err
 		}
 
 		// Add an FQDNSet entry for the order
@@ -881,9 +976,28 @@ func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order)
 			return nil, err
 		}
 
+		if req.GetReplaces() != "" && features.Enabled(features.RenewalInfo) {
+			err := insertReplacementOrder(txWithCtx, req.GetReplaces(), order.ID, ssa.clk.Now())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if (req.GetNotBefore() != 0 || req.GetNotAfter() != 0) && features.Enabled(features.OrderValidityWindows) {
+			_, err := txWithCtx.Exec(
+				"INSERT INTO orderValidityWindows (orderID, notBefore, notAfter) VALUES (?, ?, ?)",
+				order.ID,
+				optionalTime(req.GetNotBefore()),
+				optionalTime(req.GetNotAfter()),
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		if features.Enabled(features.FasterNewOrdersRateLimit) {
 			// Increment the order creation count
-			if err := addNewOrdersRateLimit(ctx, txWithCtx, *req.RegistrationID, ssa.clk.Now().Truncate(time.Minute)); err != nil {
+			if err := addNewOrdersRateLimit(ctx, ssa.rateLimitsWriter(ctx, txWithCtx), *req.RegistrationID, ssa.clk.Now().Truncate(time.Minute)); err != nil {
 				return nil, err
 			}
 		}
@@ -896,7 +1010,7 @@ func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order)
 	var outputOrder *corepb.Order
 	var ok bool
 	if outputOrder, ok = output.(*corepb.Order); !ok {
-		return nil, fmt.Errorf("shouldn't happen: casting error in NewOrder")
+		return nil, fmt.Errorf("shouldn't happen: casting error in NewOrderAndAuthzs")
 	}
 	// Update the output with the ID that the order received
 	outputOrder.Id = &order.ID
@@ -917,6 +1031,132 @@ func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order)
 	return outputOrder, nil
 }
 
+// insertReplacementOrder records that the order with the given ID replaces
+// the certificate with the given serial. If another order already claimed to
+// replace it, its claim is only taken over if it expired or failed without
+// being finalized, and otherwise an AlreadyReplaced error is returned. Both
+// happen in the caller's transaction, so of two orders racing to replace the
+// same certificate, only one can succeed.
+func insertReplacementOrder(tx db.Executor, serial string, orderID int64, now time.Time) error {
+	_, err := tx.Exec(
+		"INSERT INTO replacementOrders (serial, orderID) VALUES (?, ?)",
+		serial,
+		orderID,
+	)
+	if err == nil || !db.IsDuplicate(err) {
+		return err
+	}
+	var existing orderModel
+	err = tx.SelectOne(
+		&existing,
+		`SELECT o.id, o.expires, o.error, o.certificateSerial FROM replacementOrders AS ro
+		JOIN orders AS o ON o.id = ro.orderID
+		WHERE ro.serial = ? FOR UPDATE`,
+		serial,
+	)
+	if err != nil && !db.IsNoRows(err) {
+		return err
+	}
+	if err == nil && replacesCertificate(existing, now) {
+		return berrors.AlreadyReplacedError("certificate %s has already been replaced by another order", serial)
+	}
+	_, err = tx.Exec(
+		"UPDATE replacementOrders SET orderID = ? WHERE serial = ?",
+		orderID,
+		serial,
+	)
+	return err
+}
+
+// insertAuthzs adds pending authorizations to the authz2 table with a single
+// multi-row insert and returns their IDs, in the same order as authzs.
+func insertAuthzs(tx db.Executor, authzs []*corepb.Authorization) ([]int64, error) {
+	values := make([]string, len(authzs))
+	params := []interface{}{}
+	for i, authz := range authzs {
+		am, err := authzPBToModel(authz)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = "(?, ?, ?, ?, ?, ?, ?)"
+		params = append(params,
+			am.IdentifierType,
+			am.IdentifierValue,
+			am.RegistrationID,
+			am.Status,
+			am.Expires,
+			am.Challenges,
+			am.Token,
+		)
+	}
+	result, err := tx.Exec(
+		fmt.Sprintf(`INSERT INTO authz2
+			(identifierType, identifierValue, registrationID, status, expires, challenges, token)
+			VALUES %s`,
+			strings.Join(values, ", "),
+		),
+		params...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// A multi-row insert reports the ID of its first row. InnoDB allocates IDs
+	// for a single statement with a known number of rows consecutively, spaced
+	// by the server's auto_increment_increment.
+	firstID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	var increment int64
+	err = tx.SelectOne(&increment, "SELECT @@auto_increment_increment")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(authzs))
+	for i := range authzs {
+		ids[i] = firstID + int64(i)*increment
+	}
+	return ids, nil
+}
+
+// insertOrderToAuthzs links an order to its authorizations with a single
+// multi-row insert.
+func insertOrderToAuthzs(tx db.Execer, orderID int64, authzIDs []int64) error {
+	if len(authzIDs) == 0 {
+		return nil
+	}
+	values := make([]string, len(authzIDs))
+	params := []interface{}{}
+	for i, authzID := range authzIDs {
+		values[i] = "(?, ?)"
+		params = append(params, orderID, authzID)
+	}
+	_, err := tx.Exec(
+		fmt.Sprintf("INSERT INTO orderToAuthz2 (orderID, authzID) VALUES %s", strings.Join(values, ", ")),
+		params...,
+	)
+	return err
+}
+
+// insertRequestedNames records the names an order requested with a single
+// multi-row insert.
+func insertRequestedNames(tx db.Execer, orderID int64, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	values := make([]string, len(names))
+	params := []interface{}{}
+	for i, name := range names {
+		values[i] = "(?, ?)"
+		params = append(params, orderID, ReverseName(name))
+	}
+	_, err := tx.Exec(
+		fmt.Sprintf("INSERT INTO requestedNames (orderID, reversedName) VALUES %s", strings.Join(values, ", ")),
+		params...,
+	)
+	return err
+}
+
 // SetOrderProcessing updates a provided *corepb.Order in pending status to be
 // in processing status by updating the `beganProcessing` field of the
 // corresponding Order table row in the DB.
@@ -1000,6 +1240,19 @@ func (ssa *SQLStorageAuthority) FinalizeOrder(ctx context.Context, req *corepb.O
 			return nil, err
 		}
 
+		// If the order replaced a certificate, mark that certificate as
+		// superseded, so that reports can tell which certificates were renewed
+		// ahead of their expiry through ARI.
+		if features.Enabled(features.RenewalInfo) {
+			_, err := txWithCtx.Exec(
+				"UPDATE replacementOrders SET replaced = true WHERE orderID = ?",
+				*req.Id,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		return nil, nil
 	})
 	return overallError
@@ -1036,7 +1289,13 @@ func (ssa *SQLStorageAuthority) namesForOrder(ctx context.Context, orderID int64
 
 // GetOrder is used to retrieve an already existing order object
 func (ssa *SQLStorageAuthority) GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error) {
-	omObj, err := ssa.dbMap.WithContext(ctx).Get(orderModel{}, *req.Id)
+	var omObj interface{}
+	var err error
+	if features.Enabled(features.CertificateProfiles) {
+		omObj, err = ssa.dbMap.WithContext(ctx).Get(orderModelv2{}, *req.Id)
+	} else {
+		omObj, err = ssa.dbMap.WithContext(ctx).Get(orderModel{}, *req.Id)
+	}
 	if err != nil {
 		if db.IsNoRows(err) {
 			return nil, berrors.NotFoundError("no order found for ID %d", *req.Id)
@@ -1046,7 +1305,12 @@ func (ssa *SQLStorageAuthority) GetOrder(ctx context.Context, req *sapb.OrderReq
 	if omObj == nil {
 		return nil, berrors.NotFoundError("no order found for ID %d", *req.Id)
 	}
-	order, err := modelToOrder(omObj.(*orderModel))
+	var order *corepb.Order
+	if omv2, ok := omObj.(*orderModelv2); ok {
+		order, err = modelv2ToOrder(omv2)
+	} else {
+		order, err = modelToOrder(omObj.(*orderModel))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1074,6 +1338,44 @@ func (ssa *SQLStorageAuthority) GetOrder(ctx context.Context, req *sapb.OrderReq
 	}
 	order.Names = reversedNames
 
+	if features.Enabled(features.RenewalInfo) {
+		var replaces string
+		err := ssa.dbMap.WithContext(ctx).SelectOne(
+			&replaces,
+			"SELECT serial FROM replacementOrders WHERE orderID = ?",
+			*order.Id,
+		)
+		if err != nil && !db.IsNoRows(err) {
+			return nil, err
+		}
+		if replaces != "" {
+			order.Replaces = &replaces
+		}
+	}
+
+	if features.Enabled(features.OrderValidityWindows) {
+		var window struct {
+			NotBefore *time.Time
+			NotAfter  *time.Time
+		}
+		err := ssa.dbMap.WithContext(ctx).SelectOne(
+			&window,
+			"SELECT notBefore, notAfter FROM orderValidityWindows WHERE orderID = ?",
+			*order.Id,
+		)
+		if err != nil && !db.IsNoRows(err) {
+			return nil, err
+		}
+		if window.NotBefore != nil {
+			notBefore := window.NotBefore.UnixNano()
+			order.NotBefore = &notBefore
+		}
+		if window.NotAfter != nil {
+			notAfter := window.NotAfter.UnixNano()
+			order.NotAfter = &notAfter
+		}
+	}
+
 	// Calculate the status for the order
 	status, err := ssa.statusForOrder(ctx, order)
 	if err != nil {
@@ -1084,6 +1386,16 @@ func (ssa *SQLStorageAuthority) GetOrder(ctx context.Context, req *sapb.OrderReq
 	return order, nil
 }
 
+// optionalTime converts nanoseconds since the epoch into a time for storage in
+// a nullable DATETIME column, which is NULL if nanos is zero.
+func optionalTime(nanos int64) *time.Time {
+	if nanos == 0 {
+		return nil
+	}
+	t := time.Unix(0, nanos)
+	return &t
+}
+
 // statusForOrder examines the status of a provided order's authorizations to
 // determine what the overall status of the order should be. In summary:
 //   * If the order has an error, the order is invalid
@@ -1310,6 +1622,38 @@ func (ssa *SQLStorageAuthority) GetOrderForNames(
 	return order, nil
 }
 
+// GetOrdersForAccount returns the IDs of up to req.Limit of the given
+// account's unexpired orders, newest first. If req.BeforeID is non-zero only
+// orders older than that order are returned, so that the IDs may be paged
+// through.
+func (ssa *SQLStorageAuthority) GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	if req == nil || req.RegistrationID == nil || req.Limit == nil || *req.Limit <= 0 {
+		return nil, errIncompleteRequest
+	}
+	beforeID := req.GetBeforeID()
+	if beforeID <= 0 {
+		beforeID = math.MaxInt64
+	}
+	var ids []int64
+	_, err := ssa.dbMap.WithContext(ctx).Select(
+		&ids,
+		`SELECT id FROM orders
+		WHERE registrationID = ?
+		AND expires > ?
+		AND id < ?
+		ORDER BY id DESC
+		LIMIT ?`,
+		*req.RegistrationID,
+		ssa.clk.Now(),
+		beforeID,
+		*req.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sapb.OrderIDs{Ids: ids}, nil
+}
+
 func AuthzMapToPB(m map[string]*core.Authorization) (*sapb.Authorizations, error) {
 	resp := &sapb.Authorizations{}
 	for k, v := range m {
@@ -1381,15 +1725,21 @@ func authzModelMapToPB(m map[string]authzModel) (*sapb.Authorizations, error) {
 // This method will look in both the v2 and v1 authorizations tables for authorizations but will
 // always prefer v2 authorizations. This method will only return authorizations created using the
 // WFE v2 API (in GetAuthorizations this feature was, now somewhat confusingly, called RequireV2Authzs).
-// This method is intended to deprecate GetAuthorizations. This method only supports DNS identifier types.
+// This method is intended to deprecate GetAuthorizations. This method supports DNS and IP identifier
+// types; the type of each identifier is inferred from its value.
 func (ssa *SQLStorageAuthority) GetAuthorizations2(ctx context.Context, req *sapb.GetAuthorizationsRequest) (*sapb.Authorizations, error) {
+	now, err := bgrpc.PBOrNanosToTime(req.NowTime, req.Now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid now: %s", err)
+	}
 	var authzModels []authzModel
 	params := []interface{}{
 		*req.RegistrationID,
 		statusUint(core.StatusValid),
 		statusUint(core.StatusPending),
-		time.Unix(0, *req.Now),
+		now,
 		identifierTypeToUint[string(identifier.DNS)],
+		identifierTypeToUint[string(identifier.IP)],
 	}
 	qmarks := make([]string, len(req.Domains))
 	for i, n := range req.Domains {
@@ -1402,12 +1752,12 @@ func (ssa *SQLStorageAuthority) GetAuthorizations2(ctx context.Context, req *sap
 			WHERE registrationID = ? AND
 			status IN (?,?) AND
 			expires > ? AND
-			identifierType = ? AND
+			identifierType IN (?,?) AND
 			identifierValue IN (%s)`,
 		authzFields,
 		strings.Join(qmarks, ","),
 	)
-	_, err := ssa.dbMap.Select(
+	_, err = ssa.dbMap.Select(
 		&authzModels,
 		query,
 		params...,
@@ -1477,6 +1827,10 @@ func (ssa *SQLStorageAuthority) FinalizeAuthorization2(ctx context.Context, req
 	if *req.Status != string(core.StatusValid) && *req.Status != string(core.StatusInvalid) {
 		return berrors.InternalServerError("authorization must have status valid or invalid")
 	}
+	expires, err := bgrpc.PBOrNanosToTime(req.ExpiresTime, req.Expires)
+	if err != nil {
+		return fmt.Errorf("invalid expires: %s", err)
+	}
 	query := `UPDATE authz2 SET
 		status = :status,
 		attempted = :attempted,
@@ -1514,7 +1868,7 @@ func (ssa *SQLStorageAuthority) FinalizeAuthorization2(ctx context.Context, req
 		"validationRecord": vrJSON,
 		"id":               *req.Id,
 		"pending":          statusUint(core.StatusPending),
-		"expires":          time.Unix(0, *req.Expires).UTC(),
+		"expires":          expires.UTC(),
 		// if req.ValidationError is nil veJSON should also be nil
 		// which should result in a NULL field
 		"validationError": veJSON,
@@ -1539,58 +1893,351 @@ func (ssa *SQLStorageAuthority) FinalizeAuthorization2(ctx context.Context, req
 // RevokeCertificate stores revocation information about a certificate. It will only store this
 // information if the certificate is not already marked as revoked.
 func (ssa *SQLStorageAuthority) RevokeCertificate(ctx context.Context, req *sapb.RevokeCertificateRequest) error {
-	revokedDate := time.Unix(0, *req.Date)
-	res, err := ssa.dbMap.Exec(
-		`UPDATE certificateStatus SET
+	revokedDate, err := bgrpc.PBOrNanosToTime(req.DateTime, req.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date: %s", err)
+	}
+	_, overallError := db.WithTransaction(ctx, ssa.dbMap, func(txWithCtx db.Executor) (interface{}, error) {
+		res, err := txWithCtx.Exec(
+			`UPDATE certificateStatus SET
 				status = ?,
 				revokedReason = ?,
 				revokedDate = ?,
 				ocspLastUpdated = ?,
 				ocspResponse = ?
 			WHERE serial = ? AND status != ?`,
-		string(core.OCSPStatusRevoked),
-		revocation.Reason(*req.Reason),
-		revokedDate,
-		revokedDate,
-		req.Response,
+			string(core.OCSPStatusRevoked),
+			revocation.Reason(*req.Reason),
+			revokedDate,
+			revokedDate,
+			req.Response,
+			*req.Serial,
+			string(core.OCSPStatusRevoked),
+		)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if rows == 0 {
+			// InternalServerError because we expected this certificate status to exist and
+			// not be revoked.
+			return nil, berrors.InternalServerError("no certificate with serial %s and status %s", *req.Serial, string(core.OCSPStatusRevoked))
+		}
+
+		if features.Enabled(features.StoreRevokedCertificates) {
+			err = ssa.addRevokedCertificate(txWithCtx, req, revokedDate)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return overallError
+}
+
+// addRevokedCertificate records a revocation in the revokedCertificates
+// table, which lets the CRLs for a shard be built from only the certificates
+// revoked on it. The issuer and expiry are copied from the certificate's
+// status. Certificates whose status predates issuer IDs being stored are
+// attributed to the issuer named in the request instead. If neither names an
+// issuer the certificate can't be listed on any CRL, so rather than fail the
+// revocation it's audit logged and counted, for the certificate to be added by
+// hand.
+func (ssa *SQLStorageAuthority) addRevokedCertificate(tx db.Executor, req *sapb.RevokeCertificateRequest, revokedDate time.Time) error {
+	var status struct {
+		IssuerID *int64
+		NotAfter time.Time
+	}
+	err := tx.SelectOne(
+		&status,
+		"SELECT issuerID, notAfter FROM certificateStatus WHERE serial = ?",
 		*req.Serial,
-		string(core.OCSPStatusRevoked),
 	)
 	if err != nil {
 		return err
 	}
-	rows, err := res.RowsAffected()
+	if status.IssuerID == nil {
+		if req.IssuerID == nil {
+			ssa.unattributedRevocations.Inc()
+			ssa.log.AuditErrf("Revoked certificate %s has no known issuer, so was not added to revokedCertificates", *req.Serial)
+			return nil
+		}
+		status.IssuerID = req.IssuerID
+	}
+	_, err = tx.Exec(
+		`INSERT INTO revokedCertificates
+			(issuerID, serial, shardIdx, notAfter, revokedDate, revokedReason)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+		*status.IssuerID,
+		*req.Serial,
+		req.GetShardIdx(),
+		status.NotAfter,
+		revokedDate,
+		*req.Reason,
+	)
+	return err
+}
+
+// GetRevokedCertsByShard returns the certificates revoked before
+// RevokedBefore which are listed on the given issuer's CRL shard and expire
+// after ExpiresAfter, as recorded in the revokedCertificates table. It may be
+// served by a read replica, so very recent revocations can be missing. The
+// request's times may be sent as either Timestamps or UnixNano, and the
+// response's are sent as both.
+func (ssa *SQLStorageAuthority) GetRevokedCertsByShard(ctx context.Context, req *sapb.GetRevokedCertsByShardRequest) (*sapb.RevokedCerts, error) {
+	if req == nil || req.IssuerID == nil || req.ShardIdx == nil {
+		return nil, errIncompleteRequest
+	}
+	expiresAfter, err := bgrpc.PBOrNanosToTime(req.ExpiresAfterTime, req.ExpiresAfter)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("invalid expiresAfter: %s", err)
 	}
-	if rows == 0 {
-		// InternalServerError because we expected this certificate status to exist and
-		// not be revoked.
-		return berrors.InternalServerError("no certificate with serial %s and status %s", *req.Serial, string(core.OCSPStatusRevoked))
+	revokedBefore, err := bgrpc.PBOrNanosToTime(req.RevokedBeforeTime, req.RevokedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revokedBefore: %s", err)
+	}
+	var rows []struct {
+		Serial        string
+		NotAfter      time.Time
+		RevokedDate   time.Time
+		RevokedReason int64
+	}
+	_, err = ssa.readDB().WithContext(ctx).Select(
+		&rows,
+		`SELECT serial, notAfter, revokedDate, revokedReason
+			FROM revokedCertificates
+			WHERE issuerID = ? AND shardIdx = ? AND notAfter >= ? AND revokedDate < ?`,
+		*req.IssuerID,
+		*req.ShardIdx,
+		expiresAfter,
+		revokedBefore,
+	)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	resp := &sapb.RevokedCerts{}
+	for _, row := range rows {
+		serial := row.Serial
+		reason := row.RevokedReason
+		revokedDate := row.RevokedDate.UnixNano()
+		notAfter := row.NotAfter.UnixNano()
+		resp.Certs = append(resp.Certs, &sapb.RevokedCert{
+			Serial:          &serial,
+			Reason:          &reason,
+			RevokedDate:     &revokedDate,
+			NotAfter:        &notAfter,
+			RevokedDateTime: bgrpc.TimeToPB(row.RevokedDate),
+			NotAfterTime:    bgrpc.TimeToPB(row.NotAfter),
+		})
+	}
+	return resp, nil
+}
+
+// IncidentsForSerial returns the enabled incidents, from the incidents table,
+// which affected the certificate with the given serial.
+func (ssa *SQLStorageAuthority) IncidentsForSerial(ctx context.Context, req *sapb.Serial) (*sapb.Incidents, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	var rows []struct {
+		ID      int64
+		URL     string
+		RenewBy time.Time
+		Enabled bool
+	}
+	_, err := ssa.incidentsDB().WithContext(ctx).Select(
+		&rows,
+		`SELECT i.id, i.url, i.renewBy, i.enabled
+			FROM incidents AS i
+			JOIN incidentSerials AS s ON s.incidentID = i.id
+			WHERE s.serial = ? AND i.enabled = true
+			ORDER BY i.id`,
+		*req.Serial,
+	)
+	if err != nil {
+		return nil, err
+	}
+	resp := &sapb.Incidents{}
+	for _, row := range rows {
+		id := row.ID
+		url := row.URL
+		renewBy := row.RenewBy.UnixNano()
+		enabled := row.Enabled
+		resp.Incidents = append(resp.Incidents, &sapb.Incident{
+			Id:          &id,
+			Url:         &url,
+			RenewBy:     &renewBy,
+			RenewByTime: bgrpc.TimeToPB(row.RenewBy),
+			Enabled:     &enabled,
+		})
+	}
+	return resp, nil
+}
+
+// GetIncident returns the incident with the given ID from the incidents table,
+// whether or not it's enabled, or a NotFound error if there is none.
+func (ssa *SQLStorageAuthority) GetIncident(ctx context.Context, req *sapb.GetIncidentRequest) (*sapb.Incident, error) {
+	if req == nil || req.Id == nil {
+		return nil, errIncompleteRequest
+	}
+	var row struct {
+		ID      int64
+		URL     string
+		RenewBy time.Time
+		Enabled bool
+	}
+	err := ssa.incidentsDB().WithContext(ctx).SelectOne(
+		&row,
+		"SELECT id, url, renewBy, enabled FROM incidents WHERE id = ?",
+		*req.Id,
+	)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, berrors.NotFoundError("no incident with ID %d", *req.Id)
+		}
+		return nil, err
+	}
+	renewBy := row.RenewBy.UnixNano()
+	return &sapb.Incident{
+		Id:          &row.ID,
+		Url:         &row.URL,
+		RenewBy:     &renewBy,
+		RenewByTime: bgrpc.TimeToPB(row.RenewBy),
+		Enabled:     &row.Enabled,
+	}, nil
+}
+
+// AddIncidentSerial records, in the incidentSerials table, that the
+// certificate with the given serial was affected by the given incident, along
+// with the registration and order it was issued for if they're known.
+// Recording a serial which is already recorded for the incident does nothing,
+// so that an interrupted mass revocation can be rerun.
+func (ssa *SQLStorageAuthority) AddIncidentSerial(ctx context.Context, req *sapb.AddIncidentSerialRequest) (*corepb.Empty, error) {
+	if req == nil || req.IncidentID == nil || req.Serial == nil || *req.Serial == "" {
+		return nil, errIncompleteRequest
+	}
+	var regID, orderID *int64
+	if req.GetRegistrationID() != 0 {
+		regID = req.RegistrationID
+	}
+	if req.GetOrderID() != 0 {
+		orderID = req.OrderID
+	}
+	_, err := ssa.incidentsDB().WithContext(ctx).Exec(
+		`INSERT INTO incidentSerials (incidentID, serial, registrationID, orderID)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE incidentID = incidentID`,
+		*req.IncidentID,
+		*req.Serial,
+		regID,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+// serialsForIncidentBatchSize is the default number of incidentSerials rows
+// SerialsForIncident reads at a time. It's a variable so that the tests can
+// exercise paging.
+var serialsForIncidentBatchSize = 1000
+
+// incidentSerialRow is a row of the incidentSerials table.
+type incidentSerialRow struct {
+	Serial         string
+	RegistrationID *int64
+	OrderID        *int64
+	LastNoticeSent *time.Time
+}
+
+// SerialsForIncident calls send with each serial affected by the given
+// incident, as recorded in the incidentSerials table, along with whatever is
+// known of the registration and order it was issued for and when its
+// subscriber was last notified. The rows are read in batches, in serial order,
+// so that an incident affecting very many certificates needn't be held in
+// memory at once, and are sent within the RPC's StreamLimits.
+func (ssa *SQLStorageAuthority) SerialsForIncident(ctx context.Context, req *sapb.SerialsForIncidentRequest, send func(*sapb.IncidentSerial) error) error {
+	if req == nil || req.IncidentID == nil {
+		return errIncompleteRequest
+	}
+	var afterSerial string
+	readBatch := func(ctx context.Context, limit int) ([]interface{}, error) {
+		var batch []incidentSerialRow
+		_, err := ssa.incidentsDB().WithContext(ctx).Select(
+			&batch,
+			`SELECT serial, registrationID, orderID, lastNoticeSent
+			FROM incidentSerials
+			WHERE incidentID = ? AND serial > ?
+			ORDER BY serial LIMIT ?`,
+			*req.IncidentID,
+			afterSerial,
+			limit,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]interface{}, len(batch))
+		for i, row := range batch {
+			rows[i] = row
+		}
+		if len(batch) > 0 {
+			afterSerial = batch[len(batch)-1].Serial
+		}
+		return rows, nil
+	}
+	return ssa.streamRows(ctx, ssa.limitsFor("SerialsForIncident", serialsForIncidentBatchSize), readBatch, func(r interface{}) error {
+		row := r.(incidentSerialRow)
+		serial := row.Serial
+		var regID, orderID, lastNoticeSent int64
+		if row.RegistrationID != nil {
+			regID = *row.RegistrationID
+		}
+		if row.OrderID != nil {
+			orderID = *row.OrderID
+		}
+		var lastNoticeSentTime *timestamp.Timestamp
+		if row.LastNoticeSent != nil {
+			lastNoticeSent = row.LastNoticeSent.UnixNano()
+			lastNoticeSentTime = bgrpc.TimeToPB(*row.LastNoticeSent)
+		}
+		return send(&sapb.IncidentSerial{
+			Serial:             &serial,
+			RegistrationID:     &regID,
+			OrderID:            &orderID,
+			LastNoticeSent:     &lastNoticeSent,
+			LastNoticeSentTime: lastNoticeSentTime,
+		})
+	})
 }
 
 // GetPendingAuthorization2 returns the most recent Pending authorization with
 // the given identifier, if available. This method is intended to deprecate
-// GetPendingAuthorization. This method only supports DNS identifier types.
+// GetPendingAuthorization. The identifier's type is inferred from its value.
 func (ssa *SQLStorageAuthority) GetPendingAuthorization2(ctx context.Context, req *sapb.GetPendingAuthorizationRequest) (*corepb.Authorization, error) {
+	validUntil, err := bgrpc.PBOrNanosToTime(req.ValidUntilTime, req.ValidUntil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validUntil: %s", err)
+	}
 	var am authzModel
-	err := ssa.dbMap.WithContext(ctx).SelectOne(
+	err = ssa.dbMap.WithContext(ctx).SelectOne(
 		&am,
 		fmt.Sprintf(`SELECT %s FROM authz2 WHERE
 			registrationID = :regID AND
 			status = :status AND
 			expires > :validUntil AND
-			identifierType = :dnsType AND
+			identifierType = :identType AND
 			identifierValue = :ident
 			ORDER BY expires ASC
 			LIMIT 1 `, authzFields),
 		map[string]interface{}{
 			"regID":      *req.RegistrationID,
 			"status":     statusUint(core.StatusPending),
-			"validUntil": time.Unix(0, *req.ValidUntil),
-			"dnsType":    identifierTypeToUint[string(identifier.DNS)],
+			"validUntil": validUntil,
+			"identType":  identifierTypeUint(*req.IdentifierValue),
 			"ident":      *req.IdentifierValue,
 		},
 	)
@@ -1652,7 +2299,7 @@ func (ssa *SQLStorageAuthority) GetValidOrderAuthorizations2(ctx context.Context
 
 	byName := make(map[string]authzModel)
 	for _, am := range ams {
-		if uintToIdentifierType[am.IdentifierType] != string(identifier.DNS) {
+		if _, ok := uintToIdentifierType[am.IdentifierType]; !ok {
 			return nil, fmt.Errorf("unknown identifier type: %q on authz id %d", am.IdentifierType, am.ID)
 		}
 		existing, present := byName[am.IdentifierValue]
@@ -1666,24 +2313,28 @@ func (ssa *SQLStorageAuthority) GetValidOrderAuthorizations2(ctx context.Context
 
 // CountInvalidAuthorizations2 counts invalid authorizations for a user expiring
 // in a given time range. This method is intended to deprecate CountInvalidAuthorizations.
-// This method only supports DNS identifier types.
+// The identifier's type is inferred from its value.
 func (ssa *SQLStorageAuthority) CountInvalidAuthorizations2(ctx context.Context, req *sapb.CountInvalidAuthorizationsRequest) (*sapb.Count, error) {
+	earliest, latest, err := bgrpc.PBToRange(req.Range)
+	if err != nil {
+		return nil, err
+	}
 	var count int64
-	err := ssa.dbMap.WithContext(ctx).SelectOne(
+	err = ssa.dbMap.WithContext(ctx).SelectOne(
 		&count,
 		`SELECT COUNT(1) FROM authz2 WHERE
 		registrationID = :regID AND
 		status = :status AND
 		expires > :expiresEarliest AND
 		expires <= :expiresLatest AND
-		identifierType = :dnsType AND
+		identifierType = :identType AND
 		identifierValue = :ident`,
 		map[string]interface{}{
 			"regID":           *req.RegistrationID,
-			"dnsType":         identifierTypeToUint[string(identifier.DNS)],
+			"identType":       identifierTypeUint(*req.Hostname),
 			"ident":           *req.Hostname,
-			"expiresEarliest": time.Unix(0, *req.Range.Earliest),
-			"expiresLatest":   time.Unix(0, *req.Range.Latest),
+			"expiresEarliest": earliest,
+			"expiresLatest":   latest,
 			"status":          statusUint(core.StatusInvalid),
 		},
 	)
@@ -1695,43 +2346,69 @@ func (ssa *SQLStorageAuthority) CountInvalidAuthorizations2(ctx context.Context,
 
 // GetValidAuthorizations2 returns the latest authorization for all
 // domain names that the account has authorizations for. This method is
-// intended to deprecate GetValidAuthorizations. This method only supports
-// DNS identifier types.
+// intended to deprecate GetValidAuthorizations. This method supports DNS and
+// IP identifier types.
 func (ssa *SQLStorageAuthority) GetValidAuthorizations2(ctx context.Context, req *sapb.GetValidAuthorizationsRequest) (*sapb.Authorizations, error) {
+	now, err := bgrpc.PBOrNanosToTime(req.NowTime, req.Now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid now: %s", err)
+	}
 	var authzModels []authzModel
 	params := []interface{}{
 		*req.RegistrationID,
 		statusUint(core.StatusValid),
-		time.Unix(0, *req.Now),
+		now,
 		identifierTypeToUint[string(identifier.DNS)],
+		identifierTypeToUint[string(identifier.IP)],
 	}
 	qmarks := make([]string, len(req.Domains))
 	for i, n := range req.Domains {
 		qmarks[i] = "?"
 		params = append(params, n)
 	}
-	_, err := ssa.dbMap.Select(
-		&authzModels,
-		fmt.Sprintf(
-			`SELECT %s FROM authz2 WHERE
+	query := fmt.Sprintf(
+		`SELECT %s FROM authz2 WHERE
 			registrationID = ? AND
 			status = ? AND
 			expires > ? AND
-			identifierType = ? AND
+			identifierType IN (?,?) AND
 			identifierValue IN (%s)`,
+		authzFields,
+		strings.Join(qmarks, ","),
+	)
+	if features.Enabled(features.FasterGetValidAuthorizations) {
+		// Rather than reading every valid authorization for the names, of which
+		// an account which frequently reissues may have very many, find the
+		// latest expiry for each name using only the
+		// regID_identifier_status_expires_idx index, and read just the
+		// authorizations with those expiries.
+		query = fmt.Sprintf(
+			`SELECT %s FROM authz2
+			JOIN (
+				SELECT identifierType, identifierValue, MAX(expires) AS expires
+				FROM authz2 WHERE
+				registrationID = ? AND
+				status = ? AND
+				expires > ? AND
+				identifierType IN (?,?) AND
+				identifierValue IN (%s)
+				GROUP BY identifierType, identifierValue
+			) AS latest USING (identifierType, identifierValue, expires)
+			WHERE registrationID = ? AND status = ?`,
 			authzFields,
 			strings.Join(qmarks, ","),
-		),
-		params...,
-	)
+		)
+		params = append(params, *req.RegistrationID, statusUint(core.StatusValid))
+	}
+	_, err = ssa.dbMap.WithContext(ctx).Select(&authzModels, query, params...)
 	if err != nil {
 		return nil, err
 	}
 
 	authzMap := make(map[string]authzModel, len(authzModels))
 	for _, am := range authzModels {
-		// Only allow DNS identifiers
-		if uintToIdentifierType[am.IdentifierType] != string(identifier.DNS) {
+		// Only allow known identifier types
+		if _, ok := uintToIdentifierType[am.IdentifierType]; !ok {
 			continue
 		}
 		// If there is an existing authorization in the map only replace it with one
@@ -1761,9 +2438,13 @@ var blockedKeysColumns = "keyHash, added, source, comment"
 
 // AddBlockedKey adds a key hash to the blockedKeys table
 func (ssa *SQLStorageAuthority) AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) (*corepb.Empty, error) {
-	if req == nil || req.KeyHash == nil || req.Added == nil || req.Source == nil {
+	if req == nil || req.KeyHash == nil || req.Source == nil {
 		return nil, errIncompleteRequest
 	}
+	added, err := bgrpc.PBOrNanosToTime(req.AddedTime, req.Added)
+	if err != nil {
+		return nil, fmt.Errorf("invalid added: %s", err)
+	}
 	sourceInt, ok := stringToSourceInt[*req.Source]
 	if !ok {
 		return nil, errors.New("unknown source")
@@ -1771,7 +2452,7 @@ func (ssa *SQLStorageAuthority) AddBlockedKey(ctx context.Context, req *sapb.Add
 	cols, qs := blockedKeysColumns, "?, ?, ?, ?"
 	vals := []interface{}{
 		req.KeyHash,
-		time.Unix(0, *req.Added),
+		added,
 		sourceInt,
 		req.Comment,
 	}
@@ -1780,7 +2461,7 @@ func (ssa *SQLStorageAuthority) AddBlockedKey(ctx context.Context, req *sapb.Add
 		qs += ", ?"
 		vals = append(vals, *req.RevokedBy)
 	}
-	_, err := ssa.dbMap.Exec(
+	_, err = ssa.dbMap.Exec(
 		fmt.Sprintf("INSERT INTO blockedKeys (%s) VALUES (%s)", cols, qs),
 		vals...,
 	)
@@ -1811,3 +2492,311 @@ func (ssa *SQLStorageAuthority) KeyBlocked(ctx context.Context, req *sapb.KeyBlo
 	exists = true
 	return &sapb.Exists{Exists: &exists}, nil
 }
+
+// GetSerialsByKey returns the serials of the unexpired certificates whose
+// public key has the given SPKI hash, as recorded in the keyHashToSerial table.
+func (ssa *SQLStorageAuthority) GetSerialsByKey(ctx context.Context, req *sapb.SPKIHash) (*sapb.Serials, error) {
+	if req == nil || req.KeyHash == nil {
+		return nil, errIncompleteRequest
+	}
+	var serials []string
+	_, err := ssa.dbMap.Select(
+		&serials,
+		`SELECT certSerial FROM keyHashToSerial WHERE keyHash = ? AND certNotAfter > ?`,
+		req.KeyHash,
+		ssa.clk.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sapb.Serials{Serials: serials}, nil
+}
+
+// serialsByKeyHashBatchSize is the default number of keyHashToSerial rows
+// GetSerialsByKeyHash reads at a time. It's a variable so that the tests can
+// exercise paging.
+var serialsByKeyHashBatchSize = 1000
+
+// GetSerialsByKeyHash calls send with the serial of each unexpired certificate
+// whose public key has the given SPKI hash, as recorded in the
+// keyHashToSerial table. The rows are read in batches, using the table's
+// keyHash index, so that a key shared by very many certificates needn't be
+// held in memory at once, and are sent within the RPC's StreamLimits.
+func (ssa *SQLStorageAuthority) GetSerialsByKeyHash(ctx context.Context, req *sapb.SPKIHash, send func(serial string) error) error {
+	if req == nil || req.KeyHash == nil {
+		return errIncompleteRequest
+	}
+	now := ssa.clk.Now()
+	var afterID int64
+	readBatch := func(ctx context.Context, limit int) ([]interface{}, error) {
+		var batch []struct {
+			ID         int64
+			CertSerial string
+		}
+		_, err := ssa.dbMap.WithContext(ctx).Select(
+			&batch,
+			`SELECT id, certSerial FROM keyHashToSerial
+			WHERE keyHash = ? AND certNotAfter > ? AND id > ?
+			ORDER BY id LIMIT ?`,
+			req.KeyHash,
+			now,
+			afterID,
+			limit,
+		)
+		if err != nil {
+			return nil, err
+		}
+		serials := make([]interface{}, len(batch))
+		for i, row := range batch {
+			serials[i] = row.CertSerial
+		}
+		if len(batch) > 0 {
+			afterID = batch[len(batch)-1].ID
+		}
+		return serials, nil
+	}
+	return ssa.streamRows(ctx, ssa.limitsFor("GetSerialsByKeyHash", serialsByKeyHashBatchSize), readBatch, func(serial interface{}) error {
+		return send(serial.(string))
+	})
+}
+
+// SetRevocationWebhook stores the URL that should be notified when one of the
+// given registration's certificates is administratively revoked, replacing any
+// existing URL. An empty URL removes the registration's webhook.
+func (ssa *SQLStorageAuthority) SetRevocationWebhook(ctx context.Context, req *sapb.RevocationWebhook) (*corepb.Empty, error) {
+	if req == nil || req.RegistrationID == nil || req.Url == nil {
+		return nil, errIncompleteRequest
+	}
+	if *req.Url == "" {
+		_, err := ssa.dbMap.Exec(
+			"DELETE FROM revocationWebhooks WHERE registrationID = ?",
+			*req.RegistrationID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &corepb.Empty{}, nil
+	}
+	now := ssa.clk.Now()
+	_, err := ssa.dbMap.Exec(
+		`INSERT INTO revocationWebhooks (registrationID, url, updated) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE url = ?, updated = ?`,
+		*req.RegistrationID,
+		*req.Url,
+		now,
+		*req.Url,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+// GetRevocationWebhook returns the revocation webhook URL configured for the
+// given registration, or a NotFound error if there isn't one.
+func (ssa *SQLStorageAuthority) GetRevocationWebhook(ctx context.Context, req *sapb.RegistrationID) (*sapb.RevocationWebhook, error) {
+	if req == nil || req.Id == nil {
+		return nil, errIncompleteRequest
+	}
+	var url string
+	err := ssa.dbMap.SelectOne(
+		&url,
+		"SELECT url FROM revocationWebhooks WHERE registrationID = ?",
+		*req.Id,
+	)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, berrors.NotFoundError("no revocation webhook for registration ID %d", *req.Id)
+		}
+		return nil, err
+	}
+	return &sapb.RevocationWebhook{RegistrationID: req.Id, Url: &url}, nil
+}
+
+// PauseIdentifiers pauses the given names for the given registration, so that
+// it can't create new orders for them until it is unpaused or, if the request
+// has an expiry, until the pause expires. Names which are already paused stay
+// paused with the new expiry, and those which were previously unpaused or
+// whose pause expired are paused again. All of the names are paused in a
+// single statement.
+func (ssa *SQLStorageAuthority) PauseIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*corepb.Empty, error) {
+	if req == nil || req.RegistrationID == nil || len(req.Names) == 0 {
+		return nil, errIncompleteRequest
+	}
+	t, err := bgrpc.OptionalPBOrNanosToTime(req.ExpiresTime, req.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expires: %s", err)
+	}
+	now := ssa.clk.Now()
+	var expires *time.Time
+	if !t.IsZero() {
+		if !t.After(now) {
+			return nil, berrors.InternalServerError("pause expiry %s is not in the future", t)
+		}
+		expires = &t
+	}
+	values := make([]string, len(req.Names))
+	params := []interface{}{}
+	for i, name := range req.Names {
+		values[i] = "(?, ?, ?, NULL, ?)"
+		params = append(params, *req.RegistrationID, name, now, expires)
+	}
+	params = append(params, now, now)
+	_, err = ssa.dbMap.WithContext(ctx).Exec(
+		fmt.Sprintf(`INSERT INTO paused (registrationID, identifierValue, pausedAt, unpausedAt, expires)
+			VALUES %s
+			ON DUPLICATE KEY UPDATE
+			pausedAt = IF(unpausedAt IS NULL AND (expires IS NULL OR expires > ?), pausedAt, ?),
+			unpausedAt = NULL,
+			expires = VALUES(expires)`,
+			strings.Join(values, ", "),
+		),
+		params...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+// GetPausedIdentifiers returns those of the given names which are currently
+// paused for the given registration.
+func (ssa *SQLStorageAuthority) GetPausedIdentifiers(ctx context.Context, req *sapb.PauseRequest) (*sapb.PausedIdentifiers, error) {
+	if req == nil || req.RegistrationID == nil {
+		return nil, errIncompleteRequest
+	}
+	if len(req.Names) == 0 {
+		return &sapb.PausedIdentifiers{}, nil
+	}
+	params := []interface{}{*req.RegistrationID, ssa.clk.Now()}
+	qmarks := make([]string, len(req.Names))
+	for i, name := range req.Names {
+		qmarks[i] = "?"
+		params = append(params, name)
+	}
+	var names []string
+	_, err := ssa.dbMap.WithContext(ctx).Select(
+		&names,
+		fmt.Sprintf(`SELECT identifierValue FROM paused
+			WHERE registrationID = ? AND
+			unpausedAt IS NULL AND
+			(expires IS NULL OR expires > ?) AND
+			identifierValue IN (%s)`,
+			strings.Join(qmarks, ","),
+		),
+		params...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sapb.PausedIdentifiers{Names: names}, nil
+}
+
+// CountPaused returns how many names are currently paused for the given
+// registration.
+func (ssa *SQLStorageAuthority) CountPaused(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error) {
+	if req == nil || req.Id == nil {
+		return nil, errIncompleteRequest
+	}
+	var count int64
+	err := ssa.dbMap.WithContext(ctx).SelectOne(
+		&count,
+		`SELECT COUNT(*) FROM paused
+		WHERE registrationID = ? AND
+		unpausedAt IS NULL AND
+		(expires IS NULL OR expires > ?)`,
+		*req.Id,
+		ssa.clk.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sapb.Count{Count: &count}, nil
+}
+
+// UnpauseAccount unpauses all of the given registration's paused names,
+// returning how many were unpaused. Names whose pause has already expired
+// aren't counted.
+func (ssa *SQLStorageAuthority) UnpauseAccount(ctx context.Context, req *sapb.RegistrationID) (*sapb.Count, error) {
+	if req == nil || req.Id == nil {
+		return nil, errIncompleteRequest
+	}
+	now := ssa.clk.Now()
+	result, err := ssa.dbMap.WithContext(ctx).Exec(
+		`UPDATE paused SET unpausedAt = ?
+		WHERE registrationID = ? AND
+		unpausedAt IS NULL AND
+		(expires IS NULL OR expires > ?)`,
+		now,
+		*req.Id,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	return &sapb.Count{Count: &count}, nil
+}
+
+// ReplacementOrderExists returns whether the certificate with the given serial
+// has already been replaced by a finalized order, or is being replaced by an
+// unexpired order which hasn't failed. A new order may only claim to replace
+// a certificate if it hasn't.
+func (ssa *SQLStorageAuthority) ReplacementOrderExists(ctx context.Context, req *sapb.Serial) (*sapb.Exists, error) {
+	if req == nil || req.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+	var replacement orderModel
+	err := ssa.dbMap.WithContext(ctx).SelectOne(
+		&replacement,
+		`SELECT o.expires, o.error, o.certificateSerial FROM replacementOrders AS ro
+		JOIN orders AS o ON o.id = ro.orderID
+		WHERE ro.serial = ?`,
+		*req.Serial,
+	)
+	exists := false
+	if err != nil {
+		if db.IsNoRows(err) {
+			return &sapb.Exists{Exists: &exists}, nil
+		}
+		return nil, err
+	}
+	exists = replacesCertificate(replacement, ssa.clk.Now())
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// replacesCertificate returns whether a replacement order has replaced its
+// certificate, by being finalized, or may still do so, by being unexpired and
+// not having failed.
+func replacesCertificate(replacement orderModel, now time.Time) bool {
+	if replacement.CertificateSerial != "" {
+		return true
+	}
+	return len(replacement.Error) == 0 && replacement.Expires.After(now)
+}
+
+// GetExternalAccountKey implements eabpb.ExternalAccountKeysServer, returning
+// the MAC key with the given key ID from the externalAccountKeys table. If
+// there is no such key the response's HmacKey is empty.
+func (ssa *SQLStorageAuthority) GetExternalAccountKey(ctx context.Context, req *eabpb.ExternalAccountKeyRequest) (*eabpb.ExternalAccountKey, error) {
+	if req == nil || req.KeyID == "" {
+		return nil, errIncompleteRequest
+	}
+	var hmacKey []byte
+	err := ssa.dbMap.WithContext(ctx).SelectOne(
+		&hmacKey,
+		"SELECT hmacKey FROM externalAccountKeys WHERE keyID = ?",
+		req.KeyID,
+	)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return &eabpb.ExternalAccountKey{}, nil
+		}
+		return nil, err
+	}
+	return &eabpb.ExternalAccountKey{HmacKey: hmacKey}, nil
+}
@@ -7,12 +7,16 @@ import (
 	"crypto/x509"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
 	"math/bits"
 	"net"
+	"os"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -21,6 +25,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/db"
+	eabpb "github.com/letsencrypt/boulder/eab/proto"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
@@ -41,7 +46,7 @@ var ctx = context.Background()
 
 // initSA constructs a SQLStorageAuthority and a clean up function
 // that should be defer'ed to the end of the test.
-func initSA(t *testing.T) (*SQLStorageAuthority, clock.FakeClock, func()) {
+func initSA(t testing.TB) (*SQLStorageAuthority, clock.FakeClock, func()) {
 	features.Reset()
 
 	dbMap, err := NewDbMap(vars.DBConnSA, 0)
@@ -519,6 +524,31 @@ func TestFQDNSetsExists(t *testing.T) {
 	test.Assert(t, exists, "FQDN set does exist")
 }
 
+func TestFQDNSetIssuedWithin(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	names := []string{"a.example.com", "B.example.com"}
+	issued, err := sa.FQDNSetIssuedWithin(ctx, 3*time.Hour, names)
+	test.AssertNotError(t, err, "Failed to check FQDN set issuance")
+	test.Assert(t, !issued, "FQDN set shouldn't have been issued")
+
+	tx, err := sa.dbMap.Begin()
+	test.AssertNotError(t, err, "Failed to open transaction")
+	err = addFQDNSet(tx, names, "serial", fc.Now().Add(-2*time.Hour), fc.Now().Add(time.Hour))
+	test.AssertNotError(t, err, "Failed to add name set")
+	test.AssertNotError(t, tx.Commit(), "Failed to commit transaction")
+
+	// The set was issued within the last three hours, regardless of the order
+	// and case of its names, but not within the last hour.
+	issued, err = sa.FQDNSetIssuedWithin(ctx, 3*time.Hour, []string{"b.example.com", "A.example.COM"})
+	test.AssertNotError(t, err, "Failed to check FQDN set issuance")
+	test.Assert(t, issued, "FQDN set should have been issued within three hours")
+	issued, err = sa.FQDNSetIssuedWithin(ctx, time.Hour, names)
+	test.AssertNotError(t, err, "Failed to check FQDN set issuance")
+	test.Assert(t, !issued, "FQDN set shouldn't have been issued within an hour")
+}
+
 type execRecorder struct {
 	query string
 	args  []interface{}
@@ -954,6 +984,464 @@ func TestNewOrder(t *testing.T) {
 	test.AssertDeepEquals(t, names, []string{"com.example", "com.example.another.just"})
 }
 
+// newPendingAuthzPBs returns a pending authorization for each of names, ready
+// to be stored with NewAuthorizations2 or NewOrderAndAuthzs.
+func newPendingAuthzPBs(regID int64, names []string, expires time.Time) []*corepb.Authorization {
+	pending := string(core.StatusPending)
+	expiresNano := expires.UnixNano()
+	challType := string(core.ChallengeTypeHTTP01)
+	var authzs []*corepb.Authorization
+	for _, name := range names {
+		name := name
+		token := core.NewToken()
+		authzs = append(authzs, &corepb.Authorization{
+			Identifier:     &name,
+			RegistrationID: &regID,
+			Status:         &pending,
+			Expires:        &expiresNano,
+			Challenges: []*corepb.Challenge{
+				{Status: &pending, Type: &challType, Token: &token},
+			},
+		})
+	}
+	return authzs
+}
+
+func TestNewOrderAndAuthzs(t *testing.T) {
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+
+	// One of the order's authorizations already exists, and the other two are
+	// created along with it.
+	existingID := createPendingAuthorization(t, sa, "a.example.com", fc.Now().Add(time.Hour))
+	names := []string{"a.example.com", "b.example.com", "c.example.com"}
+	newAuthzs := newPendingAuthzPBs(reg.ID, names[1:], fc.Now().Add(time.Hour))
+	expires := fc.Now().Add(time.Hour).UnixNano()
+	status := string(core.StatusPending)
+	order, err := sa.NewOrderAndAuthzs(context.Background(), &sapb.NewOrderAndAuthzsRequest{
+		NewOrder: &corepb.Order{
+			RegistrationID:   &reg.ID,
+			Expires:          &expires,
+			Names:            names,
+			V2Authorizations: []int64{existingID},
+			Status:           &status,
+		},
+		NewAuthzs: newAuthzs,
+	})
+	test.AssertNotError(t, err, "sa.NewOrderAndAuthzs failed")
+	test.AssertEquals(t, len(order.V2Authorizations), 3)
+	test.AssertEquals(t, order.V2Authorizations[0], existingID)
+
+	var authzIDs []int64
+	_, err = sa.dbMap.Select(&authzIDs, "SELECT authzID FROM orderToAuthz2 WHERE orderID = ? ORDER BY authzID", *order.Id)
+	test.AssertNotError(t, err, "Failed to select orderToAuthz entries")
+	test.AssertDeepEquals(t, authzIDs, order.V2Authorizations)
+
+	// The IDs returned for the new authorizations are those they were stored
+	// with.
+	for i, id := range order.V2Authorizations[1:] {
+		id := id
+		authz, err := sa.GetAuthorization2(context.Background(), &sapb.AuthorizationID2{Id: &id})
+		test.AssertNotError(t, err, "sa.GetAuthorization2 failed")
+		test.AssertEquals(t, *authz.Identifier, names[i+1])
+		test.AssertEquals(t, *authz.Challenges[0].Token, *newAuthzs[i].Challenges[0].Token)
+	}
+
+	orderNames, err := sa.namesForOrder(context.Background(), *order.Id)
+	test.AssertNotError(t, err, "namesForOrder errored")
+	test.AssertDeepEquals(t, orderNames, []string{"com.example.a", "com.example.b", "com.example.c"})
+
+	// Authorizations which aren't pending can't be created.
+	valid := string(core.StatusValid)
+	newAuthzs = newPendingAuthzPBs(reg.ID, []string{"d.example.com"}, fc.Now().Add(time.Hour))
+	newAuthzs[0].Status = &valid
+	_, err = sa.NewOrderAndAuthzs(context.Background(), &sapb.NewOrderAndAuthzsRequest{
+		NewOrder: &corepb.Order{
+			RegistrationID: &reg.ID,
+			Expires:        &expires,
+			Names:          []string{"d.example.com"},
+			Status:         &status,
+		},
+		NewAuthzs: newAuthzs,
+	})
+	test.AssertError(t, err, "sa.NewOrderAndAuthzs stored a valid authorization")
+}
+
+// BenchmarkNewOrder compares creating an order for 100 names, each needing a
+// new authorization, with separate calls to NewAuthorizations2 and NewOrder
+// against a single call to NewOrderAndAuthzs.
+func BenchmarkNewOrder(b *testing.B) {
+	sa, fc, cleanup := initSA(b)
+	defer cleanup()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       &jose.JSONWebKey{Key: &rsa.PublicKey{N: big.NewInt(1), E: 1}},
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	if err != nil {
+		b.Fatalf("Couldn't create test registration: %s", err)
+	}
+
+	var names []string
+	for i := 0; i < 100; i++ {
+		names = append(names, fmt.Sprintf("%d.example.com", i))
+	}
+	expires := fc.Now().Add(time.Hour)
+	expiresNano := expires.UnixNano()
+	status := string(core.StatusPending)
+	newOrder := func() *corepb.Order {
+		return &corepb.Order{
+			RegistrationID: &reg.ID,
+			Expires:        &expiresNano,
+			Names:          names,
+			Status:         &status,
+		}
+	}
+
+	b.Run("NewAuthorizations2+NewOrder", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			authzs := newPendingAuthzPBs(reg.ID, names, expires)
+			b.StartTimer()
+			ids, err := sa.NewAuthorizations2(ctx, &sapb.AddPendingAuthorizationsRequest{Authz: authzs})
+			if err != nil {
+				b.Fatalf("sa.NewAuthorizations2 failed: %s", err)
+			}
+			order := newOrder()
+			order.V2Authorizations = ids.Ids
+			_, err = sa.NewOrder(ctx, order)
+			if err != nil {
+				b.Fatalf("sa.NewOrder failed: %s", err)
+			}
+		}
+	})
+
+	b.Run("NewOrderAndAuthzs", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			authzs := newPendingAuthzPBs(reg.ID, names, expires)
+			b.StartTimer()
+			_, err := sa.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{
+				NewOrder:  newOrder(),
+				NewAuthzs: authzs,
+			})
+			if err != nil {
+				b.Fatalf("sa.NewOrderAndAuthzs failed: %s", err)
+			}
+		}
+	})
+}
+
+func TestNewOrderCertificateProfile(t *testing.T) {
+	// The certificateProfileName column only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("certificateProfileName column requires config-next database schema")
+	}
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	err := features.Set(map[string]bool{"CertificateProfiles": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       &jose.JSONWebKey{Key: &rsa.PublicKey{N: big.NewInt(1), E: 1}},
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	test.AssertNotError(t, err, "Couldn't create test registration")
+
+	authzID := createPendingAuthorization(t, sa, "example.com", fc.Now().Add(time.Hour))
+	expires := fc.Now().Add(time.Hour).UnixNano()
+	status := string(core.StatusPending)
+	profile := "shortlived"
+	order, err := sa.NewOrder(context.Background(), &corepb.Order{
+		RegistrationID:         &reg.ID,
+		Expires:                &expires,
+		Names:                  []string{"example.com"},
+		V2Authorizations:       []int64{authzID},
+		Status:                 &status,
+		CertificateProfileName: &profile,
+	})
+	test.AssertNotError(t, err, "sa.NewOrder failed")
+
+	storedOrder, err := sa.GetOrder(context.Background(), &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "sa.GetOrder failed")
+	test.AssertEquals(t, storedOrder.GetCertificateProfileName(), profile)
+}
+
+func TestReplacementOrders(t *testing.T) {
+	// The replacementOrders table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("replacementOrders table requires config-next database schema")
+	}
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	err := features.Set(map[string]bool{"RenewalInfo": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       &jose.JSONWebKey{Key: &rsa.PublicKey{N: big.NewInt(1), E: 1}},
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	test.AssertNotError(t, err, "Couldn't create test registration")
+
+	replaced := "000000000000000000000000000000001337"
+	replacementOrderExists := func() bool {
+		t.Helper()
+		exists, err := sa.ReplacementOrderExists(ctx, &sapb.Serial{Serial: &replaced})
+		test.AssertNotError(t, err, "sa.ReplacementOrderExists failed")
+		return exists.GetExists()
+	}
+	replacementOrder := func() *corepb.Order {
+		t.Helper()
+		authzID := createPendingAuthorization(t, sa, "example.com", fc.Now().Add(time.Hour))
+		expires := fc.Now().Add(time.Hour).UnixNano()
+		return &corepb.Order{
+			RegistrationID:   &reg.ID,
+			Expires:          &expires,
+			Names:            []string{"example.com"},
+			V2Authorizations: []int64{authzID},
+			Replaces:         &replaced,
+		}
+	}
+	newReplacementOrder := func() *corepb.Order {
+		t.Helper()
+		order, err := sa.NewOrder(ctx, replacementOrder())
+		test.AssertNotError(t, err, "sa.NewOrder failed")
+		return order
+	}
+
+	test.Assert(t, !replacementOrderExists(), "replacement order exists before one was created")
+
+	// A pending replacement order is tracked, and returned with the serial it
+	// replaces.
+	order := newReplacementOrder()
+	test.Assert(t, replacementOrderExists(), "pending replacement order doesn't exist")
+	storedOrder, err := sa.GetOrder(ctx, &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "sa.GetOrder failed")
+	test.AssertEquals(t, storedOrder.GetReplaces(), replaced)
+
+	// A second order can't replace the same certificate while the first is
+	// pending, and the first keeps its claim.
+	_, err = sa.NewOrder(ctx, replacementOrder())
+	test.AssertError(t, err, "second replacement order was created")
+	test.Assert(t, berrors.Is(err, berrors.AlreadyReplaced), "error wasn't AlreadyReplaced")
+	storedOrder, err = sa.GetOrder(ctx, &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "sa.GetOrder failed")
+	test.AssertEquals(t, storedOrder.GetReplaces(), replaced)
+
+	// A replacement order which failed no longer counts.
+	order.Error, err = bgrpc.ProblemDetailsToPB(probs.ServerInternal("oops"))
+	test.AssertNotError(t, err, "ProblemDetailsToPB failed")
+	err = sa.SetOrderError(ctx, order)
+	test.AssertNotError(t, err, "sa.SetOrderError failed")
+	test.Assert(t, !replacementOrderExists(), "failed replacement order exists")
+
+	// Nor does one which expired, and of two orders racing to take over from
+	// it only one succeeds.
+	newReplacementOrder()
+	test.Assert(t, replacementOrderExists(), "pending replacement order doesn't exist")
+	fc.Add(2 * time.Hour)
+	test.Assert(t, !replacementOrderExists(), "expired replacement order exists")
+	errs := make(chan error, 2)
+	for _, req := range []*corepb.Order{replacementOrder(), replacementOrder()} {
+		go func(req *corepb.Order) {
+			_, err := sa.NewOrder(ctx, req)
+			errs <- err
+		}(req)
+	}
+	var created int
+	for i := 0; i < 2; i++ {
+		err := <-errs
+		if err == nil {
+			created++
+		} else {
+			test.Assert(t, berrors.Is(err, berrors.AlreadyReplaced), "error wasn't AlreadyReplaced")
+		}
+	}
+	test.AssertEquals(t, created, 1)
+	fc.Add(2 * time.Hour)
+
+	// A finalized replacement order counts even once it has expired, and marks
+	// the certificate it replaced as superseded.
+	order = newReplacementOrder()
+	err = sa.SetOrderProcessing(ctx, order)
+	test.AssertNotError(t, err, "sa.SetOrderProcessing failed")
+	serial := "000000000000000000000000000000001338"
+	order.CertificateSerial = &serial
+	err = sa.FinalizeOrder(ctx, order)
+	test.AssertNotError(t, err, "sa.FinalizeOrder failed")
+	fc.Add(2 * time.Hour)
+	test.Assert(t, replacementOrderExists(), "finalized replacement order doesn't exist")
+	var superseded bool
+	err = sa.dbMap.SelectOne(
+		&superseded,
+		"SELECT replaced FROM replacementOrders WHERE orderID = ?",
+		*order.Id,
+	)
+	test.AssertNotError(t, err, "selecting replaced failed")
+	test.Assert(t, superseded, "replaced certificate wasn't marked as superseded")
+}
+
+func TestOrderValidityWindows(t *testing.T) {
+	// The orderValidityWindows table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("orderValidityWindows table requires config-next database schema")
+	}
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	err := features.Set(map[string]bool{"OrderValidityWindows": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       &jose.JSONWebKey{Key: &rsa.PublicKey{N: big.NewInt(1), E: 1}},
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	test.AssertNotError(t, err, "Couldn't create test registration")
+
+	newOrder := func(notBefore, notAfter int64) *corepb.Order {
+		t.Helper()
+		authzID := createPendingAuthorization(t, sa, "example.com", fc.Now().Add(time.Hour))
+		expires := fc.Now().Add(time.Hour).UnixNano()
+		order, err := sa.NewOrder(ctx, &corepb.Order{
+			RegistrationID:   &reg.ID,
+			Expires:          &expires,
+			Names:            []string{"example.com"},
+			V2Authorizations: []int64{authzID},
+			NotBefore:        &notBefore,
+			NotAfter:         &notAfter,
+		})
+		test.AssertNotError(t, err, "sa.NewOrder failed")
+		storedOrder, err := sa.GetOrder(ctx, &sapb.OrderRequest{Id: order.Id})
+		test.AssertNotError(t, err, "sa.GetOrder failed")
+		return storedOrder
+	}
+
+	notBefore := fc.Now().Add(time.Hour).Truncate(time.Second).UnixNano()
+	notAfter := fc.Now().Add(48 * time.Hour).Truncate(time.Second).UnixNano()
+	order := newOrder(notBefore, notAfter)
+	test.AssertEquals(t, order.GetNotBefore(), notBefore)
+	test.AssertEquals(t, order.GetNotAfter(), notAfter)
+
+	// Either may be requested without the other.
+	order = newOrder(0, notAfter)
+	test.Assert(t, order.NotBefore == nil, "order has a notBefore")
+	test.AssertEquals(t, order.GetNotAfter(), notAfter)
+
+	// An order which requested neither has neither.
+	order = newOrder(0, 0)
+	test.Assert(t, order.NotBefore == nil, "order has a notBefore")
+	test.Assert(t, order.NotAfter == nil, "order has a notAfter")
+}
+
+func TestGetExternalAccountKey(t *testing.T) {
+	// The externalAccountKeys table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("externalAccountKeys table requires config-next database schema")
+	}
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	// The SA can only read external account keys, so insert one with full
+	// permissions.
+	setupDBMap, err := NewDbMap(vars.DBConnSAFullPerms, 0)
+	test.AssertNotError(t, err, "Couldn't create setup dbMap")
+	_, err = setupDBMap.Exec(
+		"INSERT INTO externalAccountKeys (keyID, hmacKey, created) VALUES (?, ?, ?)",
+		"kid-1", []byte("secret"), fc.Now())
+	test.AssertNotError(t, err, "Couldn't insert external account key")
+
+	key, err := sa.GetExternalAccountKey(ctx, &eabpb.ExternalAccountKeyRequest{KeyID: "kid-1"})
+	test.AssertNotError(t, err, "sa.GetExternalAccountKey failed")
+	test.AssertByteEquals(t, key.HmacKey, []byte("secret"))
+
+	// Unknown keys are returned empty, rather than as an error.
+	key, err = sa.GetExternalAccountKey(ctx, &eabpb.ExternalAccountKeyRequest{KeyID: "kid-2"})
+	test.AssertNotError(t, err, "sa.GetExternalAccountKey failed")
+	test.AssertEquals(t, len(key.HmacKey), 0)
+
+	_, err = sa.GetExternalAccountKey(ctx, &eabpb.ExternalAccountKeyRequest{})
+	test.AssertError(t, err, "sa.GetExternalAccountKey without a key ID didn't fail")
+}
+
+func TestPauseIdentifiers(t *testing.T) {
+	// The paused table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("paused table requires config-next database schema")
+	}
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	regID := int64(1)
+	otherRegID := int64(2)
+	names := []string{"example.com", "example.net", "example.org"}
+	getPaused := func(regID int64) []string {
+		paused, err := sa.GetPausedIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID, Names: names})
+		test.AssertNotError(t, err, "sa.GetPausedIdentifiers failed")
+		return paused.Names
+	}
+
+	test.AssertEquals(t, len(getPaused(regID)), 0)
+
+	_, err := sa.PauseIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID, Names: names[:2]})
+	test.AssertNotError(t, err, "sa.PauseIdentifiers failed")
+	// Pausing an already paused name is harmless.
+	_, err = sa.PauseIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID, Names: names[:1]})
+	test.AssertNotError(t, err, "sa.PauseIdentifiers failed for already paused name")
+
+	paused := getPaused(regID)
+	sort.Strings(paused)
+	test.AssertDeepEquals(t, paused, names[:2])
+	// Pauses only apply to the account they were made for.
+	test.AssertEquals(t, len(getPaused(otherRegID)), 0)
+
+	fc.Add(time.Hour)
+	count, err := sa.UnpauseAccount(ctx, &sapb.RegistrationID{Id: &regID})
+	test.AssertNotError(t, err, "sa.UnpauseAccount failed")
+	test.AssertEquals(t, *count.Count, int64(2))
+	test.AssertEquals(t, len(getPaused(regID)), 0)
+
+	// Names can be paused again after being unpaused.
+	_, err = sa.PauseIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID, Names: names[2:]})
+	test.AssertNotError(t, err, "sa.PauseIdentifiers failed for unpaused name")
+	test.AssertDeepEquals(t, getPaused(regID), names[2:])
+	countPaused := func(regID int64) int64 {
+		count, err := sa.CountPaused(ctx, &sapb.RegistrationID{Id: &regID})
+		test.AssertNotError(t, err, "sa.CountPaused failed")
+		return *count.Count
+	}
+	test.AssertEquals(t, countPaused(regID), int64(1))
+	test.AssertEquals(t, countPaused(otherRegID), int64(0))
+
+	// Pauses with an expiry lapse once it has passed, and aren't counted as
+	// unpaused afterwards.
+	expires := fc.Now().Add(time.Hour).UnixNano()
+	_, err = sa.PauseIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID, Names: names[:1], Expires: &expires})
+	test.AssertNotError(t, err, "sa.PauseIdentifiers failed with an expiry")
+	test.AssertEquals(t, countPaused(regID), int64(2))
+	fc.Add(2 * time.Hour)
+	test.AssertDeepEquals(t, getPaused(regID), names[2:])
+	test.AssertEquals(t, countPaused(regID), int64(1))
+	count, err = sa.UnpauseAccount(ctx, &sapb.RegistrationID{Id: &regID})
+	test.AssertNotError(t, err, "sa.UnpauseAccount failed")
+	test.AssertEquals(t, *count.Count, int64(1))
+
+	_, err = sa.PauseIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID, Names: names, Expires: &expires})
+	test.AssertError(t, err, "sa.PauseIdentifiers with an expiry in the past didn't fail")
+	_, err = sa.PauseIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID, Names: names, ExpiresTime: bgrpc.TimeToPB(time.Unix(0, expires))})
+	test.AssertError(t, err, "sa.PauseIdentifiers with an expiry Timestamp in the past didn't fail")
+	_, err = sa.PauseIdentifiers(ctx, &sapb.PauseRequest{RegistrationID: &regID})
+	test.AssertError(t, err, "sa.PauseIdentifiers without names didn't fail")
+	_, err = sa.UnpauseAccount(ctx, &sapb.RegistrationID{})
+	test.AssertError(t, err, "sa.UnpauseAccount without a registration ID didn't fail")
+}
+
 func TestSetOrderProcessing(t *testing.T) {
 	sa, fc, cleanup := initSA(t)
 	defer cleanup()
@@ -1414,6 +1902,70 @@ func TestGetOrderForNames(t *testing.T) {
 	test.Assert(t, result == nil, "sa.GetOrderForNames returned non-nil result for finalized order case")
 }
 
+func TestGetOrdersForAccount(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       satest.GoodJWK(),
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	test.AssertNotError(t, err, "Couldn't create test registration")
+
+	// Create five orders for the registration, the first of which expires
+	// much sooner than the rest
+	var orderIDs []int64
+	for i := 0; i < 5; i++ {
+		expires := fc.Now().Add(24 * time.Hour).UnixNano()
+		if i == 0 {
+			expires = fc.Now().Add(time.Hour).UnixNano()
+		}
+		authzID := createPendingAuthorization(t, sa, "example.com", fc.Now().Add(time.Hour))
+		order, err := sa.NewOrder(ctx, &corepb.Order{
+			RegistrationID:   &reg.ID,
+			Expires:          &expires,
+			V2Authorizations: []int64{authzID},
+			Names:            []string{"example.com"},
+		})
+		test.AssertNotError(t, err, "sa.NewOrder failed")
+		orderIDs = append(orderIDs, *order.Id)
+	}
+
+	getOrders := func(beforeID, limit int64) []int64 {
+		result, err := sa.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{
+			RegistrationID: &reg.ID,
+			BeforeID:       &beforeID,
+			Limit:          &limit,
+		})
+		test.AssertNotError(t, err, "sa.GetOrdersForAccount failed")
+		return result.Ids
+	}
+
+	// Orders are returned newest first, limit at a time
+	test.AssertDeepEquals(t, getOrders(0, 2), []int64{orderIDs[4], orderIDs[3]})
+	test.AssertDeepEquals(t, getOrders(orderIDs[3], 2), []int64{orderIDs[2], orderIDs[1]})
+	test.AssertDeepEquals(t, getOrders(orderIDs[1], 2), []int64{orderIDs[0]})
+	test.AssertDeepEquals(t, getOrders(0, 10), []int64{orderIDs[4], orderIDs[3], orderIDs[2], orderIDs[1], orderIDs[0]})
+
+	// Expired orders aren't returned
+	fc.Add(2 * time.Hour)
+	test.AssertDeepEquals(t, getOrders(0, 10), []int64{orderIDs[4], orderIDs[3], orderIDs[2], orderIDs[1]})
+
+	// Other accounts' orders aren't returned
+	otherRegID := int64(1337)
+	limit := int64(10)
+	result, err := sa.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{
+		RegistrationID: &otherRegID,
+		Limit:          &limit,
+	})
+	test.AssertNotError(t, err, "sa.GetOrdersForAccount failed")
+	test.AssertEquals(t, len(result.Ids), 0)
+
+	// A limit is required
+	_, err = sa.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{RegistrationID: &reg.ID})
+	test.AssertError(t, err, "sa.GetOrdersForAccount without a limit didn't fail")
+}
+
 func TestStatusForOrder(t *testing.T) {
 	sa, fc, cleanUp := initSA(t)
 	defer cleanUp()
@@ -1625,6 +2177,256 @@ func TestRevokeCertificate(t *testing.T) {
 	test.AssertError(t, err, "RevokeCertificate should've failed when certificate already revoked")
 }
 
+func TestIssuanceOutcomes(t *testing.T) {
+	// The issuanceOutcomes table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("issuanceOutcomes table requires config-next database schema")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	err := features.Set(map[string]bool{"StoreIssuanceOutcomes": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	addPrecert := func(filename string) string {
+		certDER, err := ioutil.ReadFile(filename)
+		test.AssertNotError(t, err, "Couldn't read example cert DER")
+		cert, err := x509.ParseCertificate(certDER)
+		test.AssertNotError(t, err, "Couldn't parse example cert DER")
+		issued := fc.Now().UnixNano()
+		_, err = sa.AddPrecertificate(ctx, &sapb.AddCertificateRequest{
+			Der:    certDER,
+			RegID:  &reg.ID,
+			Issued: &issued,
+		})
+		test.AssertNotError(t, err, "Couldn't add precertificate")
+		return core.SerialToString(cert.SerialNumber)
+	}
+
+	// A precertificate's outcome is pending until its final certificate is
+	// added.
+	serial := addPrecert("www.eff.org.der")
+	outcome, err := sa.GetIssuanceOutcome(ctx, &sapb.Serial{Serial: &serial})
+	test.AssertNotError(t, err, "sa.GetIssuanceOutcome failed")
+	test.AssertEquals(t, outcome.GetOutcome(), "pending")
+	test.AssertEquals(t, outcome.GetRegistrationID(), reg.ID)
+	test.AssertEquals(t, outcome.GetPrecertIssued(), fc.Now().UnixNano())
+	precertIssuedTime, err := bgrpc.PBToTime(outcome.PrecertIssuedTime)
+	test.AssertNotError(t, err, "invalid precertIssuedTime")
+	test.AssertEquals(t, precertIssuedTime.UnixNano(), fc.Now().UnixNano())
+
+	fc.Add(time.Hour)
+	certDER, err := ioutil.ReadFile("www.eff.org.der")
+	test.AssertNotError(t, err, "Couldn't read example cert DER")
+	issued := fc.Now()
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, &issued)
+	test.AssertNotError(t, err, "Couldn't add certificate")
+	outcome, err = sa.GetIssuanceOutcome(ctx, &sapb.Serial{Serial: &serial})
+	test.AssertNotError(t, err, "sa.GetIssuanceOutcome failed")
+	test.AssertEquals(t, outcome.GetOutcome(), "issued")
+	test.AssertEquals(t, outcome.GetUpdated(), fc.Now().UnixNano())
+	updatedTime, err := bgrpc.PBToTime(outcome.UpdatedTime)
+	test.AssertNotError(t, err, "invalid updatedTime")
+	test.AssertEquals(t, updatedTime.UnixNano(), fc.Now().UnixNano())
+
+	// An issued outcome can't be marked as failed.
+	detail := "issuing certificate for precertificate: timed out"
+	_, err = sa.RecordIssuanceFailure(ctx, &sapb.IssuanceFailure{Serial: &serial, Detail: &detail})
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "issued outcome was marked as failed")
+
+	// But a pending one can, along with why.
+	serial = addPrecert("test-cert.der")
+	_, err = sa.RecordIssuanceFailure(ctx, &sapb.IssuanceFailure{Serial: &serial, Detail: &detail})
+	test.AssertNotError(t, err, "sa.RecordIssuanceFailure failed")
+	outcome, err = sa.GetIssuanceOutcome(ctx, &sapb.Serial{Serial: &serial})
+	test.AssertNotError(t, err, "sa.GetIssuanceOutcome failed")
+	test.AssertEquals(t, outcome.GetOutcome(), "failed")
+	test.AssertEquals(t, outcome.GetDetail(), detail)
+
+	// Serials without a precertificate have no outcome.
+	serial = "0000000000000000000000000000000000ff"
+	_, err = sa.GetIssuanceOutcome(ctx, &sapb.Serial{Serial: &serial})
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "outcome found for unknown serial")
+	_, err = sa.RecordIssuanceFailure(ctx, &sapb.IssuanceFailure{Serial: &serial})
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "failure recorded for unknown serial")
+}
+
+func TestGetRevokedCertsByShard(t *testing.T) {
+	// The revokedCertificates table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("revokedCertificates table requires config-next database schema")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	err := features.Set(map[string]bool{"StoreRevokedCertificates": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	certDER, err := ioutil.ReadFile("www.eff.org.der")
+	test.AssertNotError(t, err, "Couldn't read example cert DER")
+	cert, err := x509.ParseCertificate(certDER)
+	test.AssertNotError(t, err, "Couldn't parse example cert DER")
+	issued := sa.clk.Now().UnixNano()
+	issuerID := int64(1234)
+	_, err = sa.AddPrecertificate(ctx, &sapb.AddCertificateRequest{
+		Der:      certDER,
+		RegID:    &reg.ID,
+		Issued:   &issued,
+		IssuerID: &issuerID,
+	})
+	test.AssertNotError(t, err, "Couldn't add www.eff.org.der")
+
+	fc.Add(time.Hour)
+	revokedDate := fc.Now()
+	dateUnix := revokedDate.UnixNano()
+	serial := "000000000000000000000000000000021bd4"
+	reason := int64(1)
+	shardIdx := int64(3)
+	err = sa.RevokeCertificate(ctx, &sapb.RevokeCertificateRequest{
+		Serial:   &serial,
+		Date:     &dateUnix,
+		Reason:   &reason,
+		Response: []byte{1, 2, 3},
+		ShardIdx: &shardIdx,
+	})
+	test.AssertNotError(t, err, "RevokeCertificate failed")
+
+	getRevoked := func(issuerID, shardIdx int64, expiresAfter, revokedBefore time.Time) []*sapb.RevokedCert {
+		t.Helper()
+		expiresAfterUnix := expiresAfter.UnixNano()
+		revokedBeforeUnix := revokedBefore.UnixNano()
+		resp, err := sa.GetRevokedCertsByShard(ctx, &sapb.GetRevokedCertsByShardRequest{
+			IssuerID:      &issuerID,
+			ShardIdx:      &shardIdx,
+			ExpiresAfter:  &expiresAfterUnix,
+			RevokedBefore: &revokedBeforeUnix,
+		})
+		test.AssertNotError(t, err, "GetRevokedCertsByShard failed")
+		return resp.Certs
+	}
+
+	now := fc.Now()
+	certs := getRevoked(issuerID, shardIdx, now, now.Add(time.Second))
+	test.AssertEquals(t, len(certs), 1)
+	test.AssertEquals(t, certs[0].GetSerial(), serial)
+	test.AssertEquals(t, certs[0].GetReason(), reason)
+	test.AssertEquals(t, certs[0].GetRevokedDate(), dateUnix)
+	test.AssertEquals(t, certs[0].GetNotAfter(), cert.NotAfter.UnixNano())
+	revokedDateTime, err := bgrpc.PBToTime(certs[0].RevokedDateTime)
+	test.AssertNotError(t, err, "invalid revokedDateTime")
+	test.AssertEquals(t, revokedDateTime.UnixNano(), dateUnix)
+	notAfterTime, err := bgrpc.PBToTime(certs[0].NotAfterTime)
+	test.AssertNotError(t, err, "invalid notAfterTime")
+	test.AssertEquals(t, notAfterTime.UnixNano(), cert.NotAfter.UnixNano())
+
+	// The times may also be requested as Timestamps, which take precedence.
+	zero := int64(0)
+	resp, err := sa.GetRevokedCertsByShard(ctx, &sapb.GetRevokedCertsByShardRequest{
+		IssuerID:          &issuerID,
+		ShardIdx:          &shardIdx,
+		ExpiresAfter:      &zero,
+		ExpiresAfterTime:  bgrpc.TimeToPB(now),
+		RevokedBeforeTime: bgrpc.TimeToPB(now.Add(time.Second)),
+	})
+	test.AssertNotError(t, err, "GetRevokedCertsByShard with Timestamps failed")
+	test.AssertEquals(t, len(resp.Certs), 1)
+
+	// But not left unset.
+	_, err = sa.GetRevokedCertsByShard(ctx, &sapb.GetRevokedCertsByShardRequest{
+		IssuerID:         &issuerID,
+		ShardIdx:         &shardIdx,
+		ExpiresAfterTime: bgrpc.TimeToPB(now),
+	})
+	test.AssertError(t, err, "GetRevokedCertsByShard succeeded without revokedBefore")
+
+	// The certificate isn't listed for other issuers or shards, once it has
+	// expired, or before it was revoked.
+	test.AssertEquals(t, len(getRevoked(issuerID+1, shardIdx, now, now.Add(time.Second))), 0)
+	test.AssertEquals(t, len(getRevoked(issuerID, shardIdx+1, now, now.Add(time.Second))), 0)
+	test.AssertEquals(t, len(getRevoked(issuerID, shardIdx, cert.NotAfter.Add(time.Second), now.Add(time.Second))), 0)
+	test.AssertEquals(t, len(getRevoked(issuerID, shardIdx, now, revokedDate)), 0)
+}
+
+func TestRevokeCertificateWithoutStatusIssuer(t *testing.T) {
+	// The revokedCertificates table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("revokedCertificates table requires config-next database schema")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	err := features.Set(map[string]bool{"StoreRevokedCertificates": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	// Neither certificate's status has an issuer ID.
+	reg := satest.CreateWorkingRegistration(t, sa)
+	issued := sa.clk.Now().UnixNano()
+	var serials []string
+	for _, file := range []string{"www.eff.org.der", "test-cert.der"} {
+		certDER, err := ioutil.ReadFile(file)
+		test.AssertNotError(t, err, "Couldn't read example cert DER")
+		cert, err := x509.ParseCertificate(certDER)
+		test.AssertNotError(t, err, "Couldn't parse example cert DER")
+		_, err = sa.AddPrecertificate(ctx, &sapb.AddCertificateRequest{
+			Der:    certDER,
+			RegID:  &reg.ID,
+			Issued: &issued,
+		})
+		test.AssertNotError(t, err, "Couldn't add example cert")
+		serials = append(serials, core.SerialToString(cert.SerialNumber))
+	}
+
+	fc.Add(time.Hour)
+	dateUnix := fc.Now().UnixNano()
+	reason := int64(1)
+	shardIdx := int64(3)
+	revoke := func(serial string, issuerID *int64) {
+		t.Helper()
+		err := sa.RevokeCertificate(ctx, &sapb.RevokeCertificateRequest{
+			Serial:   &serial,
+			Date:     &dateUnix,
+			Reason:   &reason,
+			Response: []byte{1, 2, 3},
+			ShardIdx: &shardIdx,
+			IssuerID: issuerID,
+		})
+		test.AssertNotError(t, err, "RevokeCertificate failed")
+	}
+
+	// The first is attributed to the issuer named in the request.
+	issuerID := int64(1234)
+	revoke(serials[0], &issuerID)
+	now := fc.Now()
+	nowUnix := now.UnixNano()
+	revokedBeforeUnix := now.Add(time.Second).UnixNano()
+	resp, err := sa.GetRevokedCertsByShard(ctx, &sapb.GetRevokedCertsByShardRequest{
+		IssuerID:      &issuerID,
+		ShardIdx:      &shardIdx,
+		ExpiresAfter:  &nowUnix,
+		RevokedBefore: &revokedBeforeUnix,
+	})
+	test.AssertNotError(t, err, "GetRevokedCertsByShard failed")
+	test.AssertEquals(t, len(resp.Certs), 1)
+	test.AssertEquals(t, resp.Certs[0].GetSerial(), serials[0])
+	test.AssertEquals(t, test.CountCounter(sa.unattributedRevocations), 0)
+
+	// The second can't be attributed to any issuer, so is still revoked, but
+	// counted and audit logged.
+	log.Clear()
+	revoke(serials[1], nil)
+	status, err := sa.GetCertificateStatus(ctx, serials[1])
+	test.AssertNotError(t, err, "GetCertificateStatus failed")
+	test.AssertEquals(t, status.Status, core.OCSPStatusRevoked)
+	test.AssertEquals(t, test.CountCounter(sa.unattributedRevocations), 1)
+	logLines := log.GetAllMatching(`ERR: \[AUDIT\] Revoked certificate ` + serials[1] + ` has no known issuer`)
+	test.AssertEquals(t, len(logLines), 1)
+}
+
 func TestAddCertificateRenewalBit(t *testing.T) {
 	sa, fc, cleanUp := initSA(t)
 	defer cleanUp()
@@ -2205,6 +3007,107 @@ func TestGetValidAuthorizations2(t *testing.T) {
 	test.AssertEquals(t, *authzs.Authz[0].Authz.Id, fmt.Sprintf("%d", authzID))
 }
 
+func TestGetValidAuthorizations2Faster(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	err := features.Set(map[string]bool{"FasterGetValidAuthorizations": true})
+	test.AssertNotError(t, err, "setting features")
+	defer features.Reset()
+
+	// "aaa" has several valid authorizations, of which the one expiring last
+	// is returned. "bbb" has only an invalid one, and "ccc" only an expired
+	// one, so neither is returned.
+	createFinalizedAuthorization(t, sa, "aaa", fc.Now().Add(time.Hour).UTC(), "valid")
+	latestID := createFinalizedAuthorization(t, sa, "aaa", fc.Now().Add(3*time.Hour).UTC(), "valid")
+	createFinalizedAuthorization(t, sa, "aaa", fc.Now().Add(2*time.Hour).UTC(), "valid")
+	createFinalizedAuthorization(t, sa, "aaa", fc.Now().Add(4*time.Hour).UTC(), "invalid")
+	createFinalizedAuthorization(t, sa, "bbb", fc.Now().Add(time.Hour).UTC(), "invalid")
+	createFinalizedAuthorization(t, sa, "ccc", fc.Now().Add(-time.Hour).UTC(), "valid")
+
+	now := fc.Now().UTC().UnixNano()
+	regID := int64(1)
+	authzs, err := sa.GetValidAuthorizations2(context.Background(), &sapb.GetValidAuthorizationsRequest{
+		Domains:        []string{"aaa", "bbb", "ccc"},
+		RegistrationID: &regID,
+		Now:            &now,
+	})
+	test.AssertNotError(t, err, "sa.GetValidAuthorizations2 failed")
+	test.AssertEquals(t, len(authzs.Authz), 1)
+	test.AssertEquals(t, *authzs.Authz[0].Domain, "aaa")
+	test.AssertEquals(t, *authzs.Authz[0].Authz.Id, fmt.Sprintf("%d", latestID))
+}
+
+// BenchmarkGetValidAuthorizations2 compares GetValidAuthorizations2 with and
+// without FasterGetValidAuthorizations for a 100 name order, from an account
+// which has authorized each of the names several times.
+func BenchmarkGetValidAuthorizations2(b *testing.B) {
+	sa, fc, cleanup := initSA(b)
+	defer cleanup()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       &jose.JSONWebKey{Key: &rsa.PublicKey{N: big.NewInt(1), E: 1}},
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	if err != nil {
+		b.Fatalf("Couldn't create test registration: %s", err)
+	}
+
+	var names []string
+	for i := 0; i < 100; i++ {
+		names = append(names, fmt.Sprintf("%d.example.com", i))
+	}
+	valid := string(core.StatusValid)
+	attempted := string(core.ChallengeTypeHTTP01)
+	for i := 0; i < 10; i++ {
+		expires := fc.Now().Add(time.Duration(i+1) * time.Hour)
+		ids, err := sa.NewAuthorizations2(ctx, &sapb.AddPendingAuthorizationsRequest{
+			Authz: newPendingAuthzPBs(reg.ID, names, expires),
+		})
+		if err != nil {
+			b.Fatalf("sa.NewAuthorizations2 failed: %s", err)
+		}
+		expiresNano := expires.UnixNano()
+		for _, id := range ids.Ids {
+			id := id
+			err = sa.FinalizeAuthorization2(ctx, &sapb.FinalizeAuthorizationRequest{
+				Id:        &id,
+				Status:    &valid,
+				Expires:   &expiresNano,
+				Attempted: &attempted,
+			})
+			if err != nil {
+				b.Fatalf("sa.FinalizeAuthorization2 failed: %s", err)
+			}
+		}
+	}
+
+	now := fc.Now().UnixNano()
+	req := &sapb.GetValidAuthorizationsRequest{
+		Domains:        names,
+		RegistrationID: &reg.ID,
+		Now:            &now,
+	}
+	for _, faster := range []bool{false, true} {
+		b.Run(fmt.Sprintf("FasterGetValidAuthorizations=%t", faster), func(b *testing.B) {
+			err := features.Set(map[string]bool{"FasterGetValidAuthorizations": faster})
+			if err != nil {
+				b.Fatalf("setting features: %s", err)
+			}
+			defer features.Reset()
+			for i := 0; i < b.N; i++ {
+				authzs, err := sa.GetValidAuthorizations2(ctx, req)
+				if err != nil {
+					b.Fatalf("sa.GetValidAuthorizations2 failed: %s", err)
+				}
+				if len(authzs.Authz) != len(names) {
+					b.Fatalf("expected %d authorizations, got %d", len(names), len(authzs.Authz))
+				}
+			}
+		})
+	}
+}
+
 func TestGetOrderExpired(t *testing.T) {
 	sa, fc, cleanUp := initSA(t)
 	defer cleanUp()
@@ -2319,3 +3222,273 @@ func TestBlockedKeyRevokedBy(t *testing.T) {
 	})
 	test.AssertNotError(t, err, "AddBlockedKey failed")
 }
+
+func TestGetSerialsByKey(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	hashA := make([]byte, 32)
+	hashA[0] = 1
+	hashB := make([]byte, 32)
+	hashB[0] = 2
+	for _, khm := range []*keyHashModel{
+		{KeyHash: hashA, CertNotAfter: fc.Now().Add(time.Hour), CertSerial: "1"},
+		{KeyHash: hashA, CertNotAfter: fc.Now().Add(2 * time.Hour), CertSerial: "2"},
+		{KeyHash: hashA, CertNotAfter: fc.Now().Add(-time.Hour), CertSerial: "3"},
+		{KeyHash: hashB, CertNotAfter: fc.Now().Add(time.Hour), CertSerial: "4"},
+	} {
+		err := sa.dbMap.Insert(khm)
+		test.AssertNotError(t, err, "inserting keyHashToSerial row")
+	}
+
+	serials, err := sa.GetSerialsByKey(context.Background(), &sapb.SPKIHash{KeyHash: hashA})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	sort.Strings(serials.Serials)
+	test.AssertDeepEquals(t, serials.Serials, []string{"1", "2"})
+
+	serials, err = sa.GetSerialsByKey(context.Background(), &sapb.SPKIHash{KeyHash: []byte{5}})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	test.AssertEquals(t, len(serials.Serials), 0)
+
+	_, err = sa.GetSerialsByKey(context.Background(), &sapb.SPKIHash{})
+	test.AssertError(t, err, "GetSerialsByKey didn't fail without a key hash")
+}
+
+func TestGetSerialsByKeyHash(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	// Read one row at a time, so that paging is exercised.
+	defer func(batchSize int) { serialsByKeyHashBatchSize = batchSize }(serialsByKeyHashBatchSize)
+	serialsByKeyHashBatchSize = 1
+
+	hashA := make([]byte, 32)
+	hashA[0] = 1
+	hashB := make([]byte, 32)
+	hashB[0] = 2
+	for _, khm := range []*keyHashModel{
+		{KeyHash: hashA, CertNotAfter: fc.Now().Add(time.Hour), CertSerial: "1"},
+		{KeyHash: hashA, CertNotAfter: fc.Now().Add(-time.Hour), CertSerial: "2"},
+		{KeyHash: hashA, CertNotAfter: fc.Now().Add(2 * time.Hour), CertSerial: "3"},
+		{KeyHash: hashB, CertNotAfter: fc.Now().Add(time.Hour), CertSerial: "4"},
+	} {
+		err := sa.dbMap.Insert(khm)
+		test.AssertNotError(t, err, "inserting keyHashToSerial row")
+	}
+
+	var serials []string
+	collect := func(serial string) error {
+		serials = append(serials, serial)
+		return nil
+	}
+	err := sa.GetSerialsByKeyHash(ctx, &sapb.SPKIHash{KeyHash: hashA}, collect)
+	test.AssertNotError(t, err, "GetSerialsByKeyHash failed")
+	test.AssertDeepEquals(t, serials, []string{"1", "3"})
+
+	// An error from send ends the listing.
+	err = sa.GetSerialsByKeyHash(ctx, &sapb.SPKIHash{KeyHash: hashA}, func(string) error {
+		return errors.New("oops")
+	})
+	test.AssertError(t, err, "GetSerialsByKeyHash didn't return send's error")
+
+	err = sa.GetSerialsByKeyHash(ctx, &sapb.SPKIHash{}, collect)
+	test.AssertError(t, err, "GetSerialsByKeyHash didn't fail without a key hash")
+}
+
+func TestIncidents(t *testing.T) {
+	// The incidents tables only exist in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("incidents tables require config-next database schema")
+	}
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	// Read one row at a time, so that paging is exercised.
+	defer func(batchSize int) { serialsForIncidentBatchSize = batchSize }(serialsForIncidentBatchSize)
+	serialsForIncidentBatchSize = 1
+
+	// The SA can't add incidents, so insert them with full permissions.
+	setupDBMap, err := NewDbMap(vars.DBConnSAFullPerms, 0)
+	test.AssertNotError(t, err, "Couldn't create setup dbMap")
+	renewBy := fc.Now().Add(24 * time.Hour)
+	_, err = setupDBMap.Exec(
+		`INSERT INTO incidents (id, url, renewBy, enabled) VALUES
+		(1, "https://example.com/incident/1", ?, true),
+		(2, "https://example.com/incident/2", ?, false)`,
+		renewBy, renewBy)
+	test.AssertNotError(t, err, "Couldn't insert incidents")
+	_, err = setupDBMap.Exec(
+		`INSERT INTO incidentSerials (incidentID, serial, registrationID, orderID, lastNoticeSent) VALUES
+		(1, "aa", 1, 10, NULL),
+		(1, "bb", NULL, NULL, ?),
+		(2, "aa", 1, 10, NULL)`,
+		fc.Now())
+	test.AssertNotError(t, err, "Couldn't insert incident serials")
+
+	// Only enabled incidents are returned for a serial.
+	serial := "aa"
+	incidents, err := sa.IncidentsForSerial(ctx, &sapb.Serial{Serial: &serial})
+	test.AssertNotError(t, err, "sa.IncidentsForSerial failed")
+	test.AssertEquals(t, len(incidents.Incidents), 1)
+	test.AssertEquals(t, incidents.Incidents[0].GetId(), int64(1))
+	test.AssertEquals(t, incidents.Incidents[0].GetUrl(), "https://example.com/incident/1")
+	test.AssertEquals(t, incidents.Incidents[0].GetRenewBy(), renewBy.UnixNano())
+	renewByTime, err := bgrpc.PBToTime(incidents.Incidents[0].RenewByTime)
+	test.AssertNotError(t, err, "invalid renewByTime")
+	test.AssertEquals(t, renewByTime.UnixNano(), renewBy.UnixNano())
+
+	serial = "cc"
+	incidents, err = sa.IncidentsForSerial(ctx, &sapb.Serial{Serial: &serial})
+	test.AssertNotError(t, err, "sa.IncidentsForSerial failed")
+	test.AssertEquals(t, len(incidents.Incidents), 0)
+
+	var serials []*sapb.IncidentSerial
+	collect := func(serial *sapb.IncidentSerial) error {
+		serials = append(serials, serial)
+		return nil
+	}
+	incidentID := int64(1)
+	err = sa.SerialsForIncident(ctx, &sapb.SerialsForIncidentRequest{IncidentID: &incidentID}, collect)
+	test.AssertNotError(t, err, "sa.SerialsForIncident failed")
+	test.AssertEquals(t, len(serials), 2)
+	test.AssertEquals(t, serials[0].GetSerial(), "aa")
+	test.AssertEquals(t, serials[0].GetRegistrationID(), int64(1))
+	test.AssertEquals(t, serials[0].GetOrderID(), int64(10))
+	test.AssertEquals(t, serials[0].GetLastNoticeSent(), int64(0))
+	test.Assert(t, serials[0].LastNoticeSentTime == nil, "lastNoticeSentTime set for a serial never noticed")
+	test.AssertEquals(t, serials[1].GetSerial(), "bb")
+	test.AssertEquals(t, serials[1].GetRegistrationID(), int64(0))
+	test.AssertEquals(t, serials[1].GetLastNoticeSent(), fc.Now().UnixNano())
+	lastNoticeSentTime, err := bgrpc.PBToTime(serials[1].LastNoticeSentTime)
+	test.AssertNotError(t, err, "invalid lastNoticeSentTime")
+	test.AssertEquals(t, lastNoticeSentTime.UnixNano(), fc.Now().UnixNano())
+
+	err = sa.SerialsForIncident(ctx, &sapb.SerialsForIncidentRequest{}, collect)
+	test.AssertError(t, err, "SerialsForIncident didn't fail without an incident ID")
+
+	// Disabled incidents can be looked up by ID.
+	incidentID = 2
+	incident, err := sa.GetIncident(ctx, &sapb.GetIncidentRequest{Id: &incidentID})
+	test.AssertNotError(t, err, "sa.GetIncident failed")
+	test.AssertEquals(t, incident.GetUrl(), "https://example.com/incident/2")
+	test.AssertEquals(t, incident.GetEnabled(), false)
+	incidentID = 3
+	_, err = sa.GetIncident(ctx, &sapb.GetIncidentRequest{Id: &incidentID})
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "GetIncident didn't return NotFound for a missing incident")
+
+	// Adding a serial twice records it once.
+	incidentID = 2
+	for i := 0; i < 2; i++ {
+		serial = "cc"
+		_, err = sa.AddIncidentSerial(ctx, &sapb.AddIncidentSerialRequest{IncidentID: &incidentID, Serial: &serial})
+		test.AssertNotError(t, err, "sa.AddIncidentSerial failed")
+	}
+	serials = nil
+	err = sa.SerialsForIncident(ctx, &sapb.SerialsForIncidentRequest{IncidentID: &incidentID}, collect)
+	test.AssertNotError(t, err, "sa.SerialsForIncident failed")
+	test.AssertEquals(t, len(serials), 2)
+	test.AssertEquals(t, serials[1].GetSerial(), "cc")
+	test.AssertEquals(t, serials[1].GetRegistrationID(), int64(0))
+	test.AssertEquals(t, serials[1].GetOrderID(), int64(0))
+}
+
+func TestRateLimitOverrides(t *testing.T) {
+	// The rateLimitOverrides table only exists in the config-next schema.
+	if !strings.HasSuffix(os.Getenv("BOULDER_CONFIG_DIR"), "config-next") {
+		t.Skip("rateLimitOverrides table requires config-next database schema")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	limitName, key, regID := "certificatesPerName", "example.com", int64(101)
+	threshold := int64(100)
+	reason, requester := "hosting provider", "root"
+	expires := fc.Now().Add(time.Hour).UnixNano()
+	keyOverride, err := sa.AddRateLimitOverride(ctx, &sapb.RateLimitOverride{
+		LimitName: &limitName,
+		Key:       &key,
+		Threshold: &threshold,
+		Reason:    &reason,
+		Requester: &requester,
+		Expires:   &expires,
+	})
+	test.AssertNotError(t, err, "AddRateLimitOverride failed")
+	regOverride, err := sa.AddRateLimitOverride(ctx, &sapb.RateLimitOverride{
+		LimitName:      &limitName,
+		RegistrationID: &regID,
+		Threshold:      &threshold,
+		Reason:         &reason,
+		Requester:      &requester,
+		ExpiresTime:    bgrpc.TimeToPB(time.Unix(0, expires)),
+	})
+	test.AssertNotError(t, err, "AddRateLimitOverride failed")
+	test.AssertEquals(t, regOverride.GetExpires(), expires)
+
+	resp, err := sa.GetRateLimitOverrides(ctx, &sapb.GetRateLimitOverridesRequest{})
+	test.AssertNotError(t, err, "GetRateLimitOverrides failed")
+	test.AssertEquals(t, len(resp.Overrides), 2)
+	test.AssertEquals(t, resp.Overrides[0].GetId(), keyOverride.GetId())
+	test.AssertEquals(t, resp.Overrides[0].GetKey(), key)
+	test.AssertEquals(t, resp.Overrides[0].GetRegistrationID(), int64(0))
+	test.AssertEquals(t, resp.Overrides[0].GetThreshold(), threshold)
+	test.AssertEquals(t, resp.Overrides[0].GetReason(), reason)
+	test.AssertEquals(t, resp.Overrides[0].GetRequester(), requester)
+	test.AssertEquals(t, resp.Overrides[0].GetCreated(), fc.Now().UnixNano())
+	test.AssertEquals(t, resp.Overrides[0].GetExpires(), expires)
+	expiresTime, err := bgrpc.PBToTime(resp.Overrides[0].ExpiresTime)
+	test.AssertNotError(t, err, "invalid expiresTime")
+	test.AssertEquals(t, expiresTime.UnixNano(), expires)
+	test.AssertEquals(t, resp.Overrides[1].GetId(), regOverride.GetId())
+	test.AssertEquals(t, resp.Overrides[1].GetKey(), "")
+	test.AssertEquals(t, resp.Overrides[1].GetRegistrationID(), regID)
+
+	// Expiring an override early removes it from the unexpired overrides,
+	// and records who expired it.
+	expiredBy := "admin"
+	_, err = sa.ExpireRateLimitOverride(ctx, &sapb.ExpireRateLimitOverrideRequest{Id: keyOverride.Id, Requester: &expiredBy})
+	test.AssertNotError(t, err, "ExpireRateLimitOverride failed")
+	_, err = sa.ExpireRateLimitOverride(ctx, &sapb.ExpireRateLimitOverrideRequest{Id: keyOverride.Id, Requester: &expiredBy})
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "expiring an expired override didn't return NotFound")
+	fc.Add(time.Second)
+	resp, err = sa.GetRateLimitOverrides(ctx, &sapb.GetRateLimitOverridesRequest{})
+	test.AssertNotError(t, err, "GetRateLimitOverrides failed")
+	test.AssertEquals(t, len(resp.Overrides), 1)
+	test.AssertEquals(t, resp.Overrides[0].GetId(), regOverride.GetId())
+
+	// Overrides expire on their own, too, but are still listed for auditing.
+	fc.Add(time.Hour)
+	resp, err = sa.GetRateLimitOverrides(ctx, &sapb.GetRateLimitOverridesRequest{})
+	test.AssertNotError(t, err, "GetRateLimitOverrides failed")
+	test.AssertEquals(t, len(resp.Overrides), 0)
+	includeExpired := true
+	resp, err = sa.GetRateLimitOverrides(ctx, &sapb.GetRateLimitOverridesRequest{IncludeExpired: &includeExpired})
+	test.AssertNotError(t, err, "GetRateLimitOverrides failed")
+	test.AssertEquals(t, len(resp.Overrides), 2)
+	test.AssertEquals(t, resp.Overrides[0].GetExpiredBy(), expiredBy)
+	test.AssertEquals(t, resp.Overrides[1].GetExpiredBy(), "")
+
+	// Overrides for unknown limits, with both or neither of a key and
+	// registration ID, or which have already expired are rejected.
+	unknown := "certificatesPerFish"
+	_, err = sa.AddRateLimitOverride(ctx, &sapb.RateLimitOverride{
+		LimitName: &unknown, Key: &key, Threshold: &threshold, Reason: &reason, Requester: &requester, Expires: &expires,
+	})
+	test.AssertError(t, err, "AddRateLimitOverride accepted an unknown limit")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "AddRateLimitOverride didn't return a Malformed error")
+	_, err = sa.AddRateLimitOverride(ctx, &sapb.RateLimitOverride{
+		LimitName: &limitName, Key: &key, RegistrationID: &regID, Threshold: &threshold, Reason: &reason, Requester: &requester, Expires: &expires,
+	})
+	test.AssertError(t, err, "AddRateLimitOverride accepted both a key and a registration ID")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "AddRateLimitOverride didn't return a Malformed error")
+	future := fc.Now().Add(time.Hour).UnixNano()
+	_, err = sa.AddRateLimitOverride(ctx, &sapb.RateLimitOverride{
+		LimitName: &limitName, Threshold: &threshold, Reason: &reason, Requester: &requester, Expires: &future,
+	})
+	test.AssertError(t, err, "AddRateLimitOverride accepted neither a key nor a registration ID")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "AddRateLimitOverride didn't return a Malformed error")
+	_, err = sa.AddRateLimitOverride(ctx, &sapb.RateLimitOverride{
+		LimitName: &limitName, Key: &key, Threshold: &threshold, Reason: &reason, Requester: &requester, Expires: &expires,
+	})
+	test.AssertError(t, err, "AddRateLimitOverride accepted an expiry in the past")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "AddRateLimitOverride didn't return a Malformed error")
+}
@@ -0,0 +1,38 @@
+package sa
+
+import (
+	"fmt"
+	"strings"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+)
+
+// SetSerialPrefixes makes the SA refuse to store serials, precertificates and
+// certificates whose serial doesn't start with one of the given prefixes, in
+// the lowercase hex of core.SerialToString. Each CA instance's configured
+// serial prefix should be listed, so that a misconfigured CA can't store
+// serials which may collide with another's. If none are set, every serial is
+// accepted.
+func (ssa *SQLStorageAuthority) SetSerialPrefixes(prefixes []string) error {
+	for _, prefix := range prefixes {
+		if prefix == "" || strings.Trim(prefix, "0123456789abcdef") != "" {
+			return fmt.Errorf("serial prefix %q isn't lowercase hex", prefix)
+		}
+	}
+	ssa.serialPrefixes = prefixes
+	return nil
+}
+
+// checkSerialPrefix returns an error if serial doesn't start with any of the
+// prefixes set by SetSerialPrefixes.
+func (ssa *SQLStorageAuthority) checkSerialPrefix(serial string) error {
+	if len(ssa.serialPrefixes) == 0 {
+		return nil
+	}
+	for _, prefix := range ssa.serialPrefixes {
+		if strings.HasPrefix(serial, prefix) {
+			return nil
+		}
+	}
+	return berrors.InternalServerError("serial %q doesn't start with any expected prefix", serial)
+}
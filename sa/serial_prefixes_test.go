@@ -0,0 +1,53 @@
+package sa
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSerialPrefixes(t *testing.T) {
+	ssa := &SQLStorageAuthority{}
+
+	// Without prefixes, every serial is accepted.
+	test.AssertNotError(t, ssa.checkSerialPrefix("00000000000000000000000000000000001b"), "serial rejected without prefixes")
+
+	err := ssa.SetSerialPrefixes([]string{"FF"})
+	test.AssertError(t, err, "uppercase prefix was accepted")
+	err = ssa.SetSerialPrefixes([]string{""})
+	test.AssertError(t, err, "empty prefix was accepted")
+
+	err = ssa.SetSerialPrefixes([]string{"ff", "7f01"})
+	test.AssertNotError(t, err, "setting serial prefixes")
+	test.AssertNotError(t, ssa.checkSerialPrefix("ff000000000000000000000000000000001b"), "serial with first prefix rejected")
+	test.AssertNotError(t, ssa.checkSerialPrefix("7f010000000000000000000000000000000000ab"), "serial with second prefix rejected")
+	err = ssa.checkSerialPrefix("7f020000000000000000000000000000001b")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "serial without prefix accepted")
+
+	// Prefixes below 0x10 match the serials of every length which start with
+	// them.
+	err = ssa.SetSerialPrefixes([]string{"01"})
+	test.AssertNotError(t, err, "setting serial prefixes")
+	for _, length := range []int{18, 19, 20} {
+		serialBytes := make([]byte, length)
+		serialBytes[0] = 0x01
+		serialBytes[length-1] = 0x1b
+		serial := core.SerialToString(new(big.Int).SetBytes(serialBytes))
+		test.AssertNotError(t, ssa.checkSerialPrefix(serial), "serial with low prefix rejected")
+	}
+
+	// Serials are checked before they're stored.
+	serial := "00000000000000000000000000000000001b"
+	var regID, created, expires int64 = 1, 1, 2
+	_, err = ssa.AddSerial(ctx, &sapb.AddSerialRequest{
+		Serial:  &serial,
+		RegID:   &regID,
+		Created: &created,
+		Expires: &expires,
+	})
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "serial without prefix stored")
+}
@@ -0,0 +1,53 @@
+package sa
+
+import (
+	"context"
+
+	"github.com/letsencrypt/boulder/db"
+)
+
+// SetRateLimitsDB makes the SA keep its rate limit counters, the
+// certificatesPerName and newOrdersRL tables, in the given database rather than
+// its primary, so that load on them can't starve issuance of connections and
+// vice versa. Since the counters are then written outside of the issuance
+// transactions which previously included them, a failure to write them can no
+// longer be rolled back along with the rest of the transaction.
+func (ssa *SQLStorageAuthority) SetRateLimitsDB(dbMap *db.WrappedMap) {
+	SetSQLDebug(dbMap, ssa.log)
+	ssa.rateLimitsDBMap = dbMap
+}
+
+// SetIncidentsDB makes the SA read incidents, from the incidents and
+// incidentSerials tables, from the given database rather than its primary.
+func (ssa *SQLStorageAuthority) SetIncidentsDB(dbMap *db.WrappedMap) {
+	SetSQLDebug(dbMap, ssa.log)
+	ssa.incidentsDBMap = dbMap
+}
+
+// rateLimitsDB returns the database holding the rate limit counters: the one
+// set by SetRateLimitsDB if any, and otherwise the primary.
+func (ssa *SQLStorageAuthority) rateLimitsDB() *db.WrappedMap {
+	if ssa.rateLimitsDBMap != nil {
+		return ssa.rateLimitsDBMap
+	}
+	return ssa.dbMap
+}
+
+// rateLimitsWriter returns what to write rate limit counters with, as part of
+// the work done in tx: tx itself if the counters are in the primary database,
+// and otherwise the rate limits database.
+func (ssa *SQLStorageAuthority) rateLimitsWriter(ctx context.Context, tx db.Executor) db.SelectExecer {
+	if ssa.rateLimitsDBMap != nil {
+		return ssa.rateLimitsDBMap.WithContext(ctx)
+	}
+	return tx
+}
+
+// incidentsDB returns the database holding incidents: the one set by
+// SetIncidentsDB if any, and otherwise the primary.
+func (ssa *SQLStorageAuthority) incidentsDB() *db.WrappedMap {
+	if ssa.incidentsDBMap != nil {
+		return ssa.incidentsDBMap
+	}
+	return ssa.dbMap
+}
@@ -0,0 +1,39 @@
+package sa
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	gorp "github.com/go-gorp/gorp/v3"
+
+	"github.com/letsencrypt/boulder/db"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSplitDBs(t *testing.T) {
+	primary := &db.WrappedMap{DbMap: &gorp.DbMap{}}
+	ssa := &SQLStorageAuthority{dbMap: primary, log: blog.NewMock()}
+	tx := db.WrappedTransaction{}
+
+	// Without separate databases, everything uses the primary, and rate limit
+	// counters are written as part of the caller's transaction.
+	test.Assert(t, ssa.rateLimitsDB() == primary, "rate limits didn't use the primary")
+	test.Assert(t, ssa.incidentsDB() == primary, "incidents didn't use the primary")
+	test.AssertEquals(t, ssa.rateLimitsWriter(context.Background(), tx), db.SelectExecer(tx))
+
+	// sql.Open doesn't connect, so no database is needed.
+	conn, err := sql.Open("mysql", "sa@tcp(boulder-mysql:3306)/boulder_sa_integration")
+	test.AssertNotError(t, err, "opening DB")
+	defer conn.Close()
+	rateLimits := &db.WrappedMap{DbMap: &gorp.DbMap{Db: conn}}
+	incidents := &db.WrappedMap{DbMap: &gorp.DbMap{}}
+	ssa.SetRateLimitsDB(rateLimits)
+	ssa.SetIncidentsDB(incidents)
+	test.Assert(t, ssa.rateLimitsDB() == rateLimits, "rate limits didn't use their database")
+	test.Assert(t, ssa.incidentsDB() == incidents, "incidents didn't use their database")
+	writer, ok := ssa.rateLimitsWriter(context.Background(), tx).(db.WrappedExecutor)
+	test.Assert(t, ok, "rate limit counters weren't written outside of the transaction")
+	test.Assert(t, writer.SqlExecutor.(*gorp.DbMap).Db == conn, "rate limit counters weren't written to their database")
+}
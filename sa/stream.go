@@ -0,0 +1,103 @@
+package sa
+
+import (
+	"context"
+	"time"
+)
+
+// StreamLimits controls how quickly a streaming RPC reads rows from the
+// database and sends them to its client.
+type StreamLimits struct {
+	// BatchSize is the number of rows read by each query. If zero, the RPC's
+	// default batch size is used.
+	BatchSize int
+	// MaxInFlight is the number of rows which may be read ahead of the client,
+	// waiting to be sent. Reading stops while that many are waiting, so that
+	// a slow client doesn't cause the SA to buffer all of its rows. At most
+	// MaxInFlight plus BatchSize rows are waiting at once. If zero, it
+	// defaults to BatchSize.
+	MaxInFlight int
+	// RowsPerSecond is the maximum rate at which rows are sent. If zero, rows
+	// are sent as fast as the client will receive them.
+	RowsPerSecond int
+}
+
+// SetStreamLimits configures the streaming RPCs named as the keys of limits,
+// e.g. "GetSerialsByKeyHash", to use the given StreamLimits in place of their
+// defaults.
+func (ssa *SQLStorageAuthority) SetStreamLimits(limits map[string]StreamLimits) {
+	ssa.streamLimits = limits
+}
+
+// limitsFor returns the StreamLimits for the named RPC, filling in any unset
+// values from its defaultBatchSize.
+func (ssa *SQLStorageAuthority) limitsFor(rpc string, defaultBatchSize int) StreamLimits {
+	limits := ssa.streamLimits[rpc]
+	if limits.BatchSize <= 0 {
+		limits.BatchSize = defaultBatchSize
+	}
+	if limits.MaxInFlight <= 0 {
+		limits.MaxInFlight = limits.BatchSize
+	}
+	return limits
+}
+
+// streamRows calls send with each of the rows returned by readBatch, which is
+// called with the maximum number of rows to return until it returns fewer, and
+// is responsible for picking up where its previous batch left off. Batches are
+// read concurrently with sending, but no more than limits.MaxInFlight rows are
+// read ahead of send, and rows are sent no faster than limits.RowsPerSecond.
+func (ssa *SQLStorageAuthority) streamRows(
+	ctx context.Context,
+	limits StreamLimits,
+	readBatch func(ctx context.Context, limit int) ([]interface{}, error),
+	send func(row interface{}) error,
+) error {
+	// Cancelling the context stops the reader if sending fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rows := make(chan interface{}, limits.MaxInFlight)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(rows)
+		for {
+			batch, err := readBatch(ctx, limits.BatchSize)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			for _, row := range batch {
+				select {
+				case rows <- row:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+			}
+			if len(batch) < limits.BatchSize {
+				readErr <- nil
+				return
+			}
+		}
+	}()
+
+	start := ssa.clk.Now()
+	var sent int64
+	for row := range rows {
+		if limits.RowsPerSecond > 0 {
+			// Wait until the row is due, given how many rows have been sent
+			// since the stream started.
+			due := start.Add(time.Duration(sent) * time.Second / time.Duration(limits.RowsPerSecond))
+			if wait := due.Sub(ssa.clk.Now()); wait > 0 {
+				ssa.clk.Sleep(wait)
+			}
+		}
+		err := send(row)
+		if err != nil {
+			return err
+		}
+		sent++
+	}
+	return <-readErr
+}
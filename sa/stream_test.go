@@ -0,0 +1,101 @@
+package sa
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// countingReader returns a readBatch function which returns the integers from
+// zero to total, and counts how many it has read.
+func countingReader(total int, read *int64) func(context.Context, int) ([]interface{}, error) {
+	next := 0
+	return func(_ context.Context, limit int) ([]interface{}, error) {
+		var batch []interface{}
+		for ; next < total && len(batch) < limit; next++ {
+			batch = append(batch, next)
+		}
+		atomic.AddInt64(read, int64(len(batch)))
+		return batch, nil
+	}
+}
+
+func TestStreamRows(t *testing.T) {
+	ssa := &SQLStorageAuthority{clk: clock.NewFake()}
+	limits := StreamLimits{BatchSize: 3, MaxInFlight: 2}
+
+	// Every row is sent, in order, across several batches, including when the
+	// last batch is full.
+	for _, total := range []int{0, 7, 9} {
+		var read int64
+		var sent []int
+		err := ssa.streamRows(context.Background(), limits, countingReader(total, &read), func(row interface{}) error {
+			// The reader never gets more than MaxInFlight rows, plus the
+			// batch it's holding and this row, ahead of the client.
+			test.Assert(t, atomic.LoadInt64(&read)-int64(len(sent)) <= int64(limits.MaxInFlight+limits.BatchSize+1),
+				"reader got too far ahead of the client")
+			sent = append(sent, row.(int))
+			return nil
+		})
+		test.AssertNotError(t, err, "streaming rows")
+		test.AssertEquals(t, len(sent), total)
+		for i, row := range sent {
+			test.AssertEquals(t, row, i)
+		}
+	}
+
+	// A failure to send is returned.
+	sendErr := errors.New("client went away")
+	var read int64
+	err := ssa.streamRows(context.Background(), limits, countingReader(100, &read), func(interface{}) error {
+		return sendErr
+	})
+	test.AssertEquals(t, err, sendErr)
+
+	// As is a failure to read.
+	readErr := errors.New("database went away")
+	err = ssa.streamRows(context.Background(), limits, func(context.Context, int) ([]interface{}, error) {
+		return nil, readErr
+	}, func(interface{}) error {
+		return nil
+	})
+	test.AssertEquals(t, err, readErr)
+}
+
+func TestStreamRowsRateLimited(t *testing.T) {
+	fc := clock.NewFake()
+	ssa := &SQLStorageAuthority{clk: fc}
+	limits := StreamLimits{BatchSize: 10, MaxInFlight: 10, RowsPerSecond: 4}
+
+	start := fc.Now()
+	var read int64
+	var sentAt []time.Duration
+	err := ssa.streamRows(context.Background(), limits, countingReader(9, &read), func(interface{}) error {
+		sentAt = append(sentAt, fc.Now().Sub(start))
+		return nil
+	})
+	test.AssertNotError(t, err, "streaming rows")
+	test.AssertEquals(t, len(sentAt), 9)
+	// The first row is sent immediately, and each after it a quarter of a
+	// second later.
+	for i, at := range sentAt {
+		test.AssertEquals(t, at, time.Duration(i)*250*time.Millisecond)
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	ssa := &SQLStorageAuthority{}
+	test.AssertEquals(t, ssa.limitsFor("GetSerialsByKeyHash", 1000), StreamLimits{BatchSize: 1000, MaxInFlight: 1000})
+
+	ssa.SetStreamLimits(map[string]StreamLimits{
+		"GetSerialsByKeyHash": {BatchSize: 50, RowsPerSecond: 100},
+	})
+	test.AssertEquals(t, ssa.limitsFor("GetSerialsByKeyHash", 1000), StreamLimits{BatchSize: 50, MaxInFlight: 50, RowsPerSecond: 100})
+	test.AssertEquals(t, ssa.limitsFor("SerialsForIncident", 1000), StreamLimits{BatchSize: 1000, MaxInFlight: 1000})
+}
@@ -0,0 +1,174 @@
+// Package sfe implements the self-service frontend, a small web frontend at
+// which subscribers can unpause accounts which the RA has paused after
+// repeated failed validations. Subscribers reach it through the unpause URL
+// the WFE includes in paused errors.
+package sfe
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	blog "github.com/letsencrypt/boulder/log"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/unpause"
+	"github.com/letsencrypt/boulder/web"
+)
+
+const (
+	// UnpausePath is the path of the unpause page. A GET request, with the
+	// token from an unpause URL, shows a form which the subscriber submits to
+	// unpause their account.
+	UnpausePath = "/sfe/v1/unpause"
+
+	// maxFormSize bounds the size of the unpause form, which carries nothing
+	// but a token.
+	maxFormSize = 4096
+)
+
+// RegistrationAuthority is the subset of the RA's methods used by the
+// self-service frontend.
+type RegistrationAuthority interface {
+	UnpauseAccount(ctx context.Context, req *rapb.UnpauseAccountRequest) (*corepb.Empty, error)
+}
+
+// SelfServiceFrontEndImpl serves the self-service frontend's pages.
+type SelfServiceFrontEndImpl struct {
+	ra  RegistrationAuthority
+	log blog.Logger
+	clk clock.Clock
+
+	// unpauseKey is the key unpause tokens are MACed with. It must match the
+	// WFE's.
+	unpauseKey []byte
+
+	// Maximum duration of a request
+	RequestTimeout time.Duration
+}
+
+// NewSelfServiceFrontEndImpl constructs a self-service frontend which unpauses
+// accounts through ra once the subscriber presents a token MACed with
+// unpauseKey.
+func NewSelfServiceFrontEndImpl(
+	clk clock.Clock,
+	logger blog.Logger,
+	ra RegistrationAuthority,
+	unpauseKey []byte,
+) *SelfServiceFrontEndImpl {
+	return &SelfServiceFrontEndImpl{
+		ra:         ra,
+		log:        logger,
+		clk:        clk,
+		unpauseKey: unpauseKey,
+	}
+}
+
+// Handler returns an http.Handler serving the self-service frontend's pages.
+func (sfe *SelfServiceFrontEndImpl) Handler() http.Handler {
+	m := http.NewServeMux()
+	m.Handle(UnpausePath, web.NewTopHandler(sfe.log, web.WFEHandlerFunc(sfe.Unpause)))
+	return m
+}
+
+var (
+	unpauseFormTemplate = template.Must(template.New("form").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Unpause your account</title></head>
+<body>
+<h1>Unpause your account</h1>
+<p>Issuance for some of your account's identifiers was paused because their
+validations failed repeatedly. Before unpausing, check that your client is
+configured to complete validation for each of them, and stop requesting
+certificates for any you no longer control.</p>
+<form method="POST" action="{{.Path}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Unpause my account</button>
+</form>
+</body>
+</html>
+`))
+
+	unpauseResultTemplate = template.Must(template.New("result").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+)
+
+// renderResult writes a page with the given status code, title and message.
+func (sfe *SelfServiceFrontEndImpl) renderResult(response http.ResponseWriter, logEvent *web.RequestEvent, status int, title, message string) {
+	response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	response.WriteHeader(status)
+	err := unpauseResultTemplate.Execute(response, struct{ Title, Message string }{title, message})
+	if err != nil {
+		logEvent.AddError("rendering result page: %s", err)
+	}
+}
+
+// Unpause shows the unpause form for GET requests, and unpauses the account
+// named by the submitted token for POST requests.
+func (sfe *SelfServiceFrontEndImpl) Unpause(
+	ctx context.Context,
+	logEvent *web.RequestEvent,
+	response http.ResponseWriter,
+	request *http.Request) {
+	logEvent.Endpoint = UnpausePath
+
+	var token string
+	switch request.Method {
+	case http.MethodGet, http.MethodHead:
+		token = request.URL.Query().Get("token")
+	case http.MethodPost:
+		request.Body = http.MaxBytesReader(response, request.Body, maxFormSize)
+		if err := request.ParseForm(); err != nil {
+			sfe.renderResult(response, logEvent, http.StatusBadRequest, "Invalid request", "The unpause form couldn't be read.")
+			return
+		}
+		token = request.PostForm.Get("token")
+	default:
+		response.Header().Set("Allow", "GET, HEAD, POST")
+		sfe.renderResult(response, logEvent, http.StatusMethodNotAllowed, "Method not allowed", "Use the link from your ACME client's error message.")
+		return
+	}
+
+	regID, err := unpause.ValidateToken(sfe.unpauseKey, token, sfe.clk.Now())
+	if err != nil {
+		logEvent.AddError("validating unpause token: %s", err)
+		sfe.renderResult(response, logEvent, http.StatusBadRequest, "Invalid link",
+			"This unpause link is invalid or has expired. Request a new certificate to get a new one.")
+		return
+	}
+	logEvent.Requester = regID
+
+	if request.Method != http.MethodPost {
+		response.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := unpauseFormTemplate.Execute(response, struct{ Path, Token string }{UnpausePath, token})
+		if err != nil {
+			logEvent.AddError("rendering unpause form: %s", err)
+		}
+		return
+	}
+
+	if sfe.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sfe.RequestTimeout)
+		defer cancel()
+	}
+	_, err = sfe.ra.UnpauseAccount(ctx, &rapb.UnpauseAccountRequest{RegistrationID: &regID})
+	if err != nil {
+		logEvent.AddError("unpausing account: %s", err)
+		sfe.renderResult(response, logEvent, http.StatusInternalServerError, "Something went wrong",
+			"Your account couldn't be unpaused. Please try again later.")
+		return
+	}
+	sfe.renderResult(response, logEvent, http.StatusOK, "Account unpaused",
+		"Your account has been unpaused. You can request certificates for its identifiers again.")
+}
@@ -0,0 +1,86 @@
+package sfe
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	blog "github.com/letsencrypt/boulder/log"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/unpause"
+)
+
+type mockRA struct {
+	unpaused []int64
+	err      error
+}
+
+func (ra *mockRA) UnpauseAccount(_ context.Context, req *rapb.UnpauseAccountRequest) (*corepb.Empty, error) {
+	if ra.err != nil {
+		return nil, ra.err
+	}
+	ra.unpaused = append(ra.unpaused, *req.RegistrationID)
+	return &corepb.Empty{}, nil
+}
+
+func TestUnpause(t *testing.T) {
+	key := []byte("an unpause key")
+	fc := clock.NewFake()
+	ra := &mockRA{}
+	sfe := NewSelfServiceFrontEndImpl(fc, blog.NewMock(), ra, key)
+	handler := sfe.Handler()
+
+	token, err := unpause.GenerateToken(key, 1234, fc.Now().Add(time.Hour))
+	test.AssertNotError(t, err, "GenerateToken failed")
+	expired, err := unpause.GenerateToken(key, 1234, fc.Now().Add(-time.Second))
+	test.AssertNotError(t, err, "GenerateToken failed")
+
+	get := func(token string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		handler.ServeHTTP(responseWriter, httptest.NewRequest(http.MethodGet, UnpausePath+"?"+url.Values{"token": {token}}.Encode(), nil))
+		return responseWriter
+	}
+	post := func(token string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, UnpausePath, strings.NewReader(url.Values{"token": {token}}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(responseWriter, request)
+		return responseWriter
+	}
+
+	// A valid token shows the form, which carries the token, without
+	// unpausing anything.
+	responseWriter := get(token)
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertContains(t, responseWriter.Body.String(), `value="`+token+`"`)
+	test.AssertEquals(t, len(ra.unpaused), 0)
+
+	test.AssertEquals(t, get(expired).Code, http.StatusBadRequest)
+	test.AssertEquals(t, get("").Code, http.StatusBadRequest)
+
+	// Submitting the form unpauses the account named by the token.
+	responseWriter = post(token)
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertContains(t, responseWriter.Body.String(), "Account unpaused")
+	test.AssertDeepEquals(t, ra.unpaused, []int64{1234})
+
+	test.AssertEquals(t, post(expired).Code, http.StatusBadRequest)
+	test.AssertDeepEquals(t, ra.unpaused, []int64{1234})
+
+	ra.err = errors.New("oops")
+	test.AssertEquals(t, post(token).Code, http.StatusInternalServerError)
+
+	responseWriter = httptest.NewRecorder()
+	handler.ServeHTTP(responseWriter, httptest.NewRequest(http.MethodPut, UnpausePath, nil))
+	test.AssertEquals(t, responseWriter.Code, http.StatusMethodNotAllowed)
+	test.AssertEquals(t, responseWriter.Header().Get("Allow"), "GET, HEAD, POST")
+}
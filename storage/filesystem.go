@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FilesystemBackend is a Backend which stores objects as files beneath a
+// directory. It is meant for development and testing, where no blob store is
+// available. Parts of incomplete uploads are kept in a ".uploads"
+// subdirectory.
+type FilesystemBackend struct {
+	dir string
+}
+
+// NewFilesystemBackend returns a FilesystemBackend storing objects in dir,
+// which must already exist.
+func NewFilesystemBackend(dir string) (*FilesystemBackend, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%q is not a directory", dir)
+	}
+	return &FilesystemBackend{dir: dir}, nil
+}
+
+// objectPath returns the path of the file holding the object with the given
+// key, refusing keys which would escape the backend's directory.
+func (fb *FilesystemBackend) objectPath(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean == "/" || strings.HasPrefix(clean, "/.uploads") {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(fb.dir, clean), nil
+}
+
+// uploadPath returns the directory holding the parts of an upload.
+func (fb *FilesystemBackend) uploadPath(uploadID string) (string, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return "", fmt.Errorf("invalid upload ID %q", uploadID)
+	}
+	return filepath.Join(fb.dir, ".uploads", uploadID), nil
+}
+
+func (fb *FilesystemBackend) CreateUpload(ctx context.Context, key string) (string, error) {
+	if _, err := fb.objectPath(key); err != nil {
+		return "", err
+	}
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(idBytes)
+	path, err := fb.uploadPath(uploadID)
+	if err != nil {
+		return "", err
+	}
+	return uploadID, os.MkdirAll(path, 0700)
+}
+
+func (fb *FilesystemBackend) UploadPart(ctx context.Context, key, uploadID string, part Part, data []byte) error {
+	path, err := fb.uploadPath(uploadID)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(path, strconv.Itoa(part.Number)), data, 0600)
+}
+
+func (fb *FilesystemBackend) ListParts(ctx context.Context, key, uploadID string) ([]Part, error) {
+	path, err := fb.uploadPath(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var parts []Part
+	for _, f := range files {
+		number, err := strconv.Atoi(f.Name())
+		if err != nil {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(path, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		parts = append(parts, Part{Number: number, Size: len(data), SHA256: sum[:]})
+	}
+	return parts, nil
+}
+
+func (fb *FilesystemBackend) CompleteUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	objPath, err := fb.objectPath(key)
+	if err != nil {
+		return err
+	}
+	path, err := fb.uploadPath(uploadID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(objPath), 0700); err != nil {
+		return err
+	}
+	// Assemble the object in the uploads directory and rename it into place,
+	// so that a partially written object is never visible.
+	tmp, err := ioutil.TempFile(path, "object")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	for _, part := range parts {
+		data, err := ioutil.ReadFile(filepath.Join(path, strconv.Itoa(part.Number)))
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if len(data) != part.Size {
+			tmp.Close()
+			return fmt.Errorf("part %d is %d bytes, expected %d", part.Number, len(data), part.Size)
+		}
+		if _, err := tmp.Write(data); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), objPath); err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+func (fb *FilesystemBackend) AbortUpload(ctx context.Context, key, uploadID string) error {
+	path, err := fb.uploadPath(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+func (fb *FilesystemBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	objPath, err := fb.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(objPath)
+}
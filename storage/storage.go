@@ -0,0 +1,226 @@
+// Package storage uploads objects to a blob store such as S3, GCS or Azure
+// Blob Storage. Provider specifics live behind the Backend interface; the
+// Client on top of it handles splitting objects into parts, retrying and
+// resuming failed uploads, and verifying what was stored.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// Part describes one part of a multipart upload.
+type Part struct {
+	// Number is the part's 1-based position within the object.
+	Number int
+	// Size is the length of the part in bytes.
+	Size int
+	// SHA256 is the SHA-256 digest of the part's contents.
+	SHA256 []byte
+}
+
+// Backend is implemented for each storage provider. Objects are always
+// written with a multipart upload: CreateUpload starts one, UploadPart stores
+// each part, and CompleteUpload assembles the parts, in the order given, into
+// the object. Uploading a part with the same number twice replaces it.
+type Backend interface {
+	CreateUpload(ctx context.Context, key string) (uploadID string, err error)
+	UploadPart(ctx context.Context, key, uploadID string, part Part, data []byte) error
+	// ListParts returns the parts of an incomplete upload which have been
+	// stored so far. The Client uses it to resume uploads without resending
+	// parts.
+	ListParts(ctx context.Context, key, uploadID string) ([]Part, error)
+	CompleteUpload(ctx context.Context, key, uploadID string, parts []Part) error
+	AbortUpload(ctx context.Context, key, uploadID string) error
+	// Get returns the contents of a stored object.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// ErrIntegrity is returned by Put if an object was stored but its contents,
+// read back from the backend, don't match what was uploaded.
+var ErrIntegrity = errors.New("stored object does not match uploaded data")
+
+// Client uploads objects through a Backend. It is safe for concurrent use.
+type Client struct {
+	backend      Backend
+	partSize     int
+	attempts     int
+	retryBackoff time.Duration
+	clk          clock.Clock
+	log          blog.Logger
+	uploads      *prometheus.CounterVec
+	resumedParts prometheus.Counter
+}
+
+// NewClient constructs a Client. Objects are uploaded in parts of partSize
+// bytes (the last part may be smaller). Each upload is attempted up to
+// attempts times, backing off exponentially from retryBackoff between
+// attempts; every attempt after the first resumes the same multipart upload.
+func NewClient(
+	backend Backend,
+	partSize int,
+	attempts int,
+	retryBackoff time.Duration,
+	clk clock.Clock,
+	log blog.Logger,
+	stats prometheus.Registerer,
+) (*Client, error) {
+	if partSize <= 0 {
+		return nil, errors.New("partSize must be positive")
+	}
+	if attempts <= 0 {
+		return nil, errors.New("attempts must be positive")
+	}
+	uploads := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_uploads",
+		Help: "A counter of object uploads labelled by result",
+	}, []string{"result"})
+	stats.MustRegister(uploads)
+	resumedParts := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "storage_resumed_parts",
+		Help: "A counter of upload parts which didn't need to be resent when retrying an upload",
+	})
+	stats.MustRegister(resumedParts)
+	return &Client{
+		backend:      backend,
+		partSize:     partSize,
+		attempts:     attempts,
+		retryBackoff: retryBackoff,
+		clk:          clk,
+		log:          log,
+		uploads:      uploads,
+		resumedParts: resumedParts,
+	}, nil
+}
+
+// split divides data into parts of at most partSize bytes. An empty object
+// is a single empty part.
+func (c *Client) split(data []byte) ([]Part, [][]byte) {
+	var parts []Part
+	var chunks [][]byte
+	for offset := 0; offset == 0 || offset < len(data); offset += c.partSize {
+		end := offset + c.partSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := data[offset:end]
+		sum := sha256.Sum256(chunk)
+		parts = append(parts, Part{Number: len(parts) + 1, Size: len(chunk), SHA256: sum[:]})
+		chunks = append(chunks, chunk)
+	}
+	return parts, chunks
+}
+
+// Put stores data under key. It returns once the object has been written and
+// its contents, read back from the backend, have been checked against the
+// SHA-256 digest of data.
+func (c *Client) Put(ctx context.Context, key string, data []byte) error {
+	parts, chunks := c.split(data)
+	var uploadID string
+	var err error
+	for attempt := 1; attempt <= c.attempts; attempt++ {
+		if attempt > 1 {
+			c.clk.Sleep(core.RetryBackoff(attempt-1, c.retryBackoff, c.retryBackoff*10, 2))
+		}
+		uploadID, err = c.upload(ctx, key, uploadID, parts, chunks)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		c.log.Warningf("uploading %q (attempt %d of %d): %s", key, attempt, c.attempts, err)
+	}
+	if err != nil {
+		if uploadID != "" {
+			abortErr := c.backend.AbortUpload(ctx, key, uploadID)
+			if abortErr != nil {
+				c.log.Warningf("aborting upload of %q: %s", key, abortErr)
+			}
+		}
+		c.uploads.WithLabelValues("failed").Inc()
+		return fmt.Errorf("uploading %q: %s", key, err)
+	}
+
+	err = c.verify(ctx, key, data)
+	if err != nil {
+		c.uploads.WithLabelValues("unverified").Inc()
+		return err
+	}
+	c.uploads.WithLabelValues("success").Inc()
+	return nil
+}
+
+// upload makes one attempt at a multipart upload. If uploadID is empty it
+// starts a new upload; otherwise it resumes that one, skipping any parts
+// which the backend already holds. It returns the ID of the upload it used,
+// so that a failed attempt can be resumed.
+func (c *Client) upload(ctx context.Context, key, uploadID string, parts []Part, chunks [][]byte) (string, error) {
+	stored := make(map[int][]byte)
+	if uploadID == "" {
+		var err error
+		uploadID, err = c.backend.CreateUpload(ctx, key)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		existing, err := c.backend.ListParts(ctx, key, uploadID)
+		if err != nil {
+			return uploadID, err
+		}
+		for _, p := range existing {
+			stored[p.Number] = p.SHA256
+		}
+	}
+
+	for i, part := range parts {
+		if sum, ok := stored[part.Number]; ok && bytes.Equal(sum, part.SHA256) {
+			c.resumedParts.Inc()
+			continue
+		}
+		err := c.backend.UploadPart(ctx, key, uploadID, part, chunks[i])
+		if err != nil {
+			return uploadID, fmt.Errorf("part %d: %s", part.Number, err)
+		}
+	}
+	return uploadID, c.backend.CompleteUpload(ctx, key, uploadID, parts)
+}
+
+// verify reads back the object stored under key and checks that it matches
+// data.
+func (c *Client) verify(ctx context.Context, key string, data []byte) error {
+	obj, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("reading back %q: %s", key, err)
+	}
+	defer obj.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, obj)
+	if err != nil {
+		return fmt.Errorf("reading back %q: %s", key, err)
+	}
+	expected := sha256.Sum256(data)
+	if !bytes.Equal(h.Sum(nil), expected[:]) {
+		return ErrIntegrity
+	}
+	return nil
+}
+
+// Get returns the contents of the object stored under key.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	obj, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return ioutil.ReadAll(obj)
+}
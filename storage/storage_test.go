@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func setup(t *testing.T, wrap func(Backend) Backend) (*Client, *FilesystemBackend, func()) {
+	dir, err := ioutil.TempDir("", "storage-test")
+	test.AssertNotError(t, err, "creating temp dir")
+	fb, err := NewFilesystemBackend(dir)
+	test.AssertNotError(t, err, "creating filesystem backend")
+	var backend Backend = fb
+	if wrap != nil {
+		backend = wrap(fb)
+	}
+	client, err := NewClient(backend, 4, 3, time.Second, clock.NewFake(), blog.NewMock(), prometheus.NewRegistry())
+	test.AssertNotError(t, err, "creating client")
+	return client, fb, func() { os.RemoveAll(dir) }
+}
+
+// flakyBackend fails the first failures attempts to upload partNumber, and
+// counts how many times each part was uploaded.
+type flakyBackend struct {
+	Backend
+	partNumber int
+	failures   int
+	uploads    map[int]int
+}
+
+func (fb *flakyBackend) UploadPart(ctx context.Context, key, uploadID string, part Part, data []byte) error {
+	if part.Number == fb.partNumber && fb.failures > 0 {
+		fb.failures--
+		return errors.New("connection reset by peer")
+	}
+	fb.uploads[part.Number]++
+	return fb.Backend.UploadPart(ctx, key, uploadID, part, data)
+}
+
+// corruptBackend flips a byte of every object it returns.
+type corruptBackend struct {
+	Backend
+}
+
+func (cb corruptBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := cb.Backend.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	data, err := ioutil.ReadAll(obj)
+	if err != nil {
+		return nil, err
+	}
+	data[0] ^= 0xff
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestPut(t *testing.T) {
+	client, _, cleanUp := setup(t, nil)
+	defer cleanUp()
+
+	for _, data := range []string{"", "abc", "abcd", "hello, world"} {
+		err := client.Put(context.Background(), "crls/1.crl", []byte(data))
+		test.AssertNotError(t, err, "Put failed")
+		stored, err := client.Get(context.Background(), "crls/1.crl")
+		test.AssertNotError(t, err, "Get failed")
+		test.AssertEquals(t, string(stored), data)
+	}
+	test.AssertEquals(t, test.CountCounter(client.uploads.WithLabelValues("success")), 4)
+}
+
+func TestPutResumes(t *testing.T) {
+	flaky := &flakyBackend{partNumber: 2, failures: 2, uploads: make(map[int]int)}
+	client, _, cleanUp := setup(t, func(b Backend) Backend {
+		flaky.Backend = b
+		return flaky
+	})
+	defer cleanUp()
+
+	err := client.Put(context.Background(), "object", []byte("hello, world"))
+	test.AssertNotError(t, err, "Put failed")
+	// Part 1 was stored by the first attempt and not sent again.
+	test.AssertEquals(t, flaky.uploads[1], 1)
+	test.AssertEquals(t, flaky.uploads[2], 1)
+	test.AssertEquals(t, flaky.uploads[3], 1)
+	test.AssertEquals(t, test.CountCounter(client.resumedParts), 2)
+	stored, err := client.Get(context.Background(), "object")
+	test.AssertNotError(t, err, "Get failed")
+	test.AssertEquals(t, string(stored), "hello, world")
+}
+
+func TestPutGivesUp(t *testing.T) {
+	flaky := &flakyBackend{partNumber: 2, failures: 3, uploads: make(map[int]int)}
+	client, fb, cleanUp := setup(t, func(b Backend) Backend {
+		flaky.Backend = b
+		return flaky
+	})
+	defer cleanUp()
+
+	err := client.Put(context.Background(), "object", []byte("hello, world"))
+	test.AssertError(t, err, "Put succeeded despite failing uploads")
+	test.AssertEquals(t, test.CountCounter(client.uploads.WithLabelValues("failed")), 1)
+	// The incomplete upload was aborted.
+	uploads, err := ioutil.ReadDir(filepath.Join(fb.dir, ".uploads"))
+	test.AssertNotError(t, err, "reading uploads directory")
+	test.AssertEquals(t, len(uploads), 0)
+}
+
+func TestPutVerifies(t *testing.T) {
+	client, _, cleanUp := setup(t, func(b Backend) Backend {
+		return corruptBackend{b}
+	})
+	defer cleanUp()
+
+	err := client.Put(context.Background(), "object", []byte("hello, world"))
+	test.AssertEquals(t, err, ErrIntegrity)
+	test.AssertEquals(t, test.CountCounter(client.uploads.WithLabelValues("unverified")), 1)
+}
+
+func TestFilesystemBackendKeys(t *testing.T) {
+	_, fb, cleanUp := setup(t, nil)
+	defer cleanUp()
+
+	for _, key := range []string{"", "/", ".uploads/x", "../../.uploads/x"} {
+		_, err := fb.CreateUpload(context.Background(), key)
+		test.AssertError(t, err, "CreateUpload accepted invalid key")
+	}
+	// Keys can't escape the backend's directory.
+	path, err := fb.objectPath("../../etc/passwd")
+	test.AssertNotError(t, err, "objectPath failed")
+	test.Assert(t, strings.HasPrefix(path, fb.dir), "object path escaped backend directory")
+}
@@ -4,6 +4,7 @@ package integration
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -94,7 +95,7 @@ type issuanceResult struct {
 	certs []*x509.Certificate
 }
 
-func authAndIssue(c *client, csrKey *ecdsa.PrivateKey, domains []string) (*issuanceResult, error) {
+func authAndIssue(c *client, csrKey crypto.Signer, domains []string) (*issuanceResult, error) {
 	var err error
 	if c == nil {
 		c, err = makeClient()
@@ -151,9 +152,11 @@ func authAndIssue(c *client, csrKey *ecdsa.PrivateKey, domains []string) (*issua
 	return &issuanceResult{order, certs}, nil
 }
 
-func makeCSR(k *ecdsa.PrivateKey, domains []string) (*x509.CertificateRequest, error) {
-	var err error
+// makeCSR creates a CSR for domains signed by k. If k is nil a new ECDSA P-256
+// key is generated. The signature algorithm is chosen to match the key type.
+func makeCSR(k crypto.Signer, domains []string) (*x509.CertificateRequest, error) {
 	if k == nil {
+		var err error
 		k, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 		if err != nil {
 			return nil, fmt.Errorf("generating certificate key: %s", err)
@@ -161,11 +164,9 @@ func makeCSR(k *ecdsa.PrivateKey, domains []string) (*x509.CertificateRequest, e
 	}
 
 	csrDer, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
-		SignatureAlgorithm: x509.ECDSAWithSHA256,
-		PublicKeyAlgorithm: x509.ECDSA,
-		PublicKey:          k.Public(),
-		Subject:            pkix.Name{CommonName: domains[0]},
-		DNSNames:           domains,
+		PublicKey: k.Public(),
+		Subject:   pkix.Name{CommonName: domains[0]},
+		DNSNames:  domains,
 	}, k)
 	if err != nil {
 		return nil, fmt.Errorf("making csr: %s", err)
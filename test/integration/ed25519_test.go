@@ -0,0 +1,39 @@
+// +build integration
+
+package integration
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestEd25519Issuance checks that a certificate can be issued for an Ed25519
+// subscriber key. Ed25519 issuance is only enabled in config-next.
+func TestEd25519Issuance(t *testing.T) {
+	t.Parallel()
+	if !strings.Contains(os.Getenv("BOULDER_CONFIG_DIR"), "test/config-next") {
+		return
+	}
+
+	os.Setenv("DIRECTORY", "http://boulder:4001/directory")
+	_, certKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating Ed25519 key: %s", err)
+	}
+
+	res, err := authAndIssue(nil, certKey, []string{random_domain()})
+	if err != nil {
+		t.Fatalf("issuing certificate for Ed25519 key: %s", err)
+	}
+	cert := res.certs[0]
+	if cert.PublicKeyAlgorithm != x509.Ed25519 {
+		t.Errorf("expected certificate public key algorithm Ed25519, got %s", cert.PublicKeyAlgorithm)
+	}
+	if cert.KeyUsage != x509.KeyUsageDigitalSignature {
+		t.Errorf("expected certificate key usage digitalSignature only, got %d", cert.KeyUsage)
+	}
+}
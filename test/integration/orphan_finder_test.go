@@ -68,6 +68,8 @@ func makeFakeCert(precert bool) (*x509.Certificate, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Use the test CAs' serial prefix, which the SA may require.
+	serialBytes[0] = 0xff
 	serial := big.NewInt(0)
 	serial.SetBytes(serialBytes)
 	key, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -14,6 +14,7 @@ CREATE USER IF NOT EXISTS 'test_setup'@'localhost';
 CREATE USER IF NOT EXISTS 'purger'@'localhost';
 CREATE USER IF NOT EXISTS 'janitor'@'localhost';
 CREATE USER IF NOT EXISTS 'badkeyrevoker'@'localhost';
+CREATE USER IF NOT EXISTS 'weakkeyscanner'@'localhost';
 
 -- Storage Authority
 GRANT SELECT,INSERT ON certificates TO 'sa'@'localhost';
@@ -33,6 +34,18 @@ GRANT SELECT,INSERT ON precertificates TO 'sa'@'localhost';
 GRANT SELECT,INSERT ON keyHashToSerial TO 'sa'@'localhost';
 GRANT SELECT,INSERT ON blockedKeys TO 'sa'@'localhost';
 GRANT SELECT,INSERT,UPDATE ON newOrdersRL TO 'sa'@'localhost';
+GRANT SELECT,INSERT,UPDATE,DELETE ON revocationWebhooks TO 'sa'@'localhost';
+GRANT SELECT,INSERT,UPDATE ON replacementOrders TO 'sa'@'localhost';
+GRANT SELECT ON externalAccountKeys TO 'sa'@'localhost';
+GRANT SELECT,INSERT,UPDATE ON paused TO 'sa'@'localhost';
+GRANT SELECT,INSERT ON orderValidityWindows TO 'sa'@'localhost';
+GRANT SELECT,INSERT ON revokedCertificates TO 'sa'@'localhost';
+GRANT SELECT ON incidents TO 'sa'@'localhost';
+GRANT SELECT,INSERT ON incidentSerials TO 'sa'@'localhost';
+GRANT SELECT,INSERT,UPDATE ON issuanceOutcomes TO 'sa'@'localhost';
+GRANT SELECT,INSERT,UPDATE ON rateLimitOverrides TO 'sa'@'localhost';
+-- Lets the SA check how far behind its read replicas are.
+GRANT REPLICATION CLIENT ON *.* TO 'sa'@'localhost';
 
 -- OCSP Responder
 GRANT SELECT ON certificateStatus TO 'ocsp_resp'@'localhost';
@@ -58,15 +71,25 @@ GRANT SELECT ON certificates TO 'cert_checker'@'localhost';
 -- Expired authorization purger
 GRANT SELECT,DELETE ON challenges TO 'purger'@'localhost';
 GRANT SELECT,DELETE ON authz2 TO 'purger'@'localhost';
+GRANT INSERT ON archivedAuthz2 TO 'purger'@'localhost';
 
 -- Janitor
-GRANT SELECT,DELETE ON certificates TO 'janitor'@'localhost';
+GRANT SELECT,UPDATE,DELETE ON certificates TO 'janitor'@'localhost';
 GRANT SELECT,DELETE ON certificateStatus TO 'janitor'@'localhost';
 GRANT SELECT,DELETE ON certificatesPerName TO 'janitor'@'localhost';
 GRANT SELECT,DELETE ON orders TO 'janitor'@'localhost';
 GRANT SELECT,DELETE ON requestedNames TO 'janitor'@'localhost';
 GRANT SELECT,DELETE ON orderFqdnSets TO 'janitor'@'localhost';
 GRANT SELECT,DELETE ON orderToAuthz2 TO 'janitor'@'localhost';
+GRANT SELECT,INSERT,DELETE ON archivedOrders TO 'janitor'@'localhost';
+GRANT SELECT,INSERT,DELETE ON archivedRequestedNames TO 'janitor'@'localhost';
+GRANT SELECT,INSERT,DELETE ON archivedOrderToAuthz2 TO 'janitor'@'localhost';
+GRANT SELECT,DELETE ON archivedAuthz2 TO 'janitor'@'localhost';
+GRANT SELECT,DELETE ON authz2 TO 'janitor'@'localhost';
+GRANT SELECT,DELETE ON fqdnSets TO 'janitor'@'localhost';
+GRANT SELECT,DELETE ON keyHashToSerial TO 'janitor'@'localhost';
+GRANT SELECT,DELETE ON orderValidityWindows TO 'janitor'@'localhost';
+GRANT SELECT,DELETE ON replacementOrders TO 'janitor'@'localhost';
 
 -- Bad Key Revoker
 GRANT SELECT,UPDATE ON blockedKeys TO 'badkeyrevoker'@'localhost';
@@ -75,5 +98,11 @@ GRANT SELECT ON certificateStatus TO 'badkeyrevoker'@'localhost';
 GRANT SELECT ON precertificates TO 'badkeyrevoker'@'localhost';
 GRANT SELECT ON registrations TO 'badkeyrevoker'@'localhost';
 
+-- Weak Key Scanner
+GRANT SELECT ON precertificates TO 'weakkeyscanner'@'localhost';
+GRANT SELECT ON blockedKeys TO 'weakkeyscanner'@'localhost';
+GRANT SELECT ON incidents TO 'weakkeyscanner'@'localhost';
+GRANT SELECT,INSERT ON incidentSerials TO 'weakkeyscanner'@'localhost';
+
 -- Test setup and teardown
 GRANT ALL PRIVILEGES ON * to 'test_setup'@'localhost';
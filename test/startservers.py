@@ -116,6 +116,14 @@ SERVICES = (
         None),
 )
 
+if CONFIG_NEXT:
+    SERVICES += (
+        Service('boulder-sfe',
+            4004,
+            ('./bin/boulder-sfe', '--config', os.path.join(config_dir, 'sfe.json')),
+            ('sd-test-srv', 'boulder-ra-1', 'boulder-ra-2')),
+    )
+
 def _service_toposort(services):
     """Yields Service objects in topologically sorted order.
 
@@ -23,4 +23,6 @@ var (
 	DBConnSAOcspResp = fmt.Sprintf(dbURL, "ocsp_resp", "boulder_sa_test")
 	// DBInfoSchemaRoot is the root user and the information_schema connection.
 	DBInfoSchemaRoot = fmt.Sprintf(dbURL, "root", "information_schema")
+	// RedisAddr is the address of the Redis server
+	RedisAddr = "boulder-redis:6379"
 )
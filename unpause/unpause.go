@@ -0,0 +1,60 @@
+// Package unpause implements the tokens which link a paused account to the
+// self-service frontend, where the subscriber can unpause it. The WFE includes
+// a token in the unpause URL it returns with a paused error, and the
+// self-service frontend validates it before unpausing the account it names.
+package unpause
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned by ValidateToken for a token which is malformed,
+// wasn't produced with the expected key, or has expired.
+var ErrInvalidToken = errors.New("invalid or expired unpause token")
+
+// mac returns the base64url encoded HMAC-SHA256 of payload under key.
+func mac(key []byte, payload string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// GenerateToken returns a token authorizing the account regID to be unpaused
+// until expires. It has the form "<regID>.<expiry unix time>.<MAC>".
+func GenerateToken(key []byte, regID int64, expires time.Time) (string, error) {
+	if len(key) == 0 {
+		return "", errors.New("unpause key must not be empty")
+	}
+	payload := fmt.Sprintf("%d.%d", regID, expires.Unix())
+	return payload + "." + mac(key, payload), nil
+}
+
+// ValidateToken checks that token was produced by GenerateToken with key and
+// hasn't expired as of now, and returns the account ID it authorizes
+// unpausing.
+func ValidateToken(key []byte, token string, now time.Time) (int64, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrInvalidToken
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(mac(key, payload)), []byte(parts[2])) {
+		return 0, ErrInvalidToken
+	}
+	regID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || regID <= 0 {
+		return 0, ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || !now.Before(time.Unix(expires, 0)) {
+		return 0, ErrInvalidToken
+	}
+	return regID, nil
+}
@@ -0,0 +1,39 @@
+package unpause
+
+import (
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestTokens(t *testing.T) {
+	key := []byte("an unpause key")
+	now := time.Date(2020, 7, 27, 12, 0, 0, 0, time.UTC)
+
+	token, err := GenerateToken(key, 1234, now.Add(time.Hour))
+	test.AssertNotError(t, err, "GenerateToken failed")
+	regID, err := ValidateToken(key, token, now)
+	test.AssertNotError(t, err, "ValidateToken failed for a fresh token")
+	test.AssertEquals(t, regID, int64(1234))
+
+	_, err = ValidateToken(key, token, now.Add(time.Hour))
+	test.AssertEquals(t, err, ErrInvalidToken)
+
+	_, err = ValidateToken([]byte("another key"), token, now)
+	test.AssertEquals(t, err, ErrInvalidToken)
+
+	other, err := GenerateToken(key, 5678, now.Add(time.Hour))
+	test.AssertNotError(t, err, "GenerateToken failed")
+	otherMAC := other[len(other)-43:]
+	_, err = ValidateToken(key, token[:len(token)-43]+otherMAC, now)
+	test.AssertEquals(t, err, ErrInvalidToken)
+
+	for _, malformed := range []string{"", "1234", "1234.5678", "a.b.c.d"} {
+		_, err = ValidateToken(key, malformed, now)
+		test.AssertEquals(t, err, ErrInvalidToken)
+	}
+
+	_, err = GenerateToken(nil, 1234, now)
+	test.AssertError(t, err, "GenerateToken accepted an empty key")
+}
@@ -3,13 +3,16 @@ package va
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
+	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/identifier"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/probs"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 	"github.com/miekg/dns"
@@ -18,9 +21,21 @@ import (
 type caaParams struct {
 	accountURIID     *int64
 	validationMethod *string
+	// lookups, if non-nil, is shared with other checks made for the same
+	// request so that their common CAA lookups are only performed once.
+	lookups *caaLookupCache
+	// onionCAA holds the CAA records provided by the client for a .onion name,
+	// which are checked instead of looking up CAA records in the DNS.
+	onionCAA *core.OnionCAA
 }
 
 func (va *ValidationAuthorityImpl) IsCAAValid(ctx context.Context, req *vapb.IsCAAValidRequest) (*vapb.IsCAAValidResponse, error) {
+	return va.isCAAValid(ctx, req, nil), nil
+}
+
+// isCAAValid performs the CAA check for a single IsCAAValidRequest, sharing
+// lookups with other checks through the provided cache if it is non-nil.
+func (va *ValidationAuthorityImpl) isCAAValid(ctx context.Context, req *vapb.IsCAAValidRequest, lookups *caaLookupCache) *vapb.IsCAAValidResponse {
 	acmeID := identifier.ACMEIdentifier{
 		Type:  identifier.DNS,
 		Value: *req.Domain,
@@ -28,8 +43,17 @@ func (va *ValidationAuthorityImpl) IsCAAValid(ctx context.Context, req *vapb.IsC
 	params := &caaParams{
 		accountURIID:     req.AccountURIID,
 		validationMethod: req.ValidationMethod,
+		lookups:          lookups,
+	}
+	var prob *probs.ProblemDetails
+	if core.IsOnionName(acmeID.Value) {
+		// The CAA records of a .onion name are provided with the onion-csr-01
+		// challenge response, so they can't be checked again afterwards.
+		prob = probs.CAA("CAA records for .onion names can only be checked during validation")
+	} else {
+		prob = va.checkCAA(ctx, acmeID, params)
 	}
-	if prob := va.checkCAA(ctx, acmeID, params); prob != nil {
+	if prob != nil {
 		typ := string(prob.Type)
 		detail := fmt.Sprintf("While processing CAA for %s: %s", *req.Domain, prob.Detail)
 		return &vapb.IsCAAValidResponse{
@@ -37,9 +61,33 @@ func (va *ValidationAuthorityImpl) IsCAAValid(ctx context.Context, req *vapb.IsC
 				ProblemType: &typ,
 				Detail:      &detail,
 			},
-		}, nil
+		}
 	}
-	return &vapb.IsCAAValidResponse{}, nil
+	return &vapb.IsCAAValidResponse{}
+}
+
+// IsCAAValidForNames performs each of the requested CAA checks concurrently.
+// The checks share their CAA lookups, so when many names in one request have
+// the same parent domains, each parent domain is only looked up once. Each
+// check is otherwise performed, and audit logged, exactly as by IsCAAValid.
+func (va *ValidationAuthorityImpl) IsCAAValidForNames(ctx context.Context, req *vapb.IsCAAValidForNamesRequest) (*vapb.IsCAAValidForNamesResponse, error) {
+	for _, check := range req.Checks {
+		if check == nil || check.Domain == nil {
+			return nil, errors.New("incomplete IsCAAValidForNames request")
+		}
+	}
+	lookups := newCAALookupCache()
+	results := make([]*vapb.IsCAAValidResponse, len(req.Checks))
+	var wg sync.WaitGroup
+	for i, check := range req.Checks {
+		wg.Add(1)
+		go func(i int, check *vapb.IsCAAValidRequest) {
+			defer wg.Done()
+			results[i] = va.isCAAValid(ctx, check, lookups)
+		}(i, check)
+	}
+	wg.Wait()
+	return &vapb.IsCAAValidForNamesResponse{Results: results}, nil
 }
 
 // checkCAA performs a CAA lookup & validation for the provided identifier. If
@@ -50,7 +98,10 @@ func (va *ValidationAuthorityImpl) checkCAA(
 	params *caaParams) *probs.ProblemDetails {
 	present, valid, records, err := va.checkCAARecords(ctx, identifier, params)
 	if err != nil {
-		return probs.DNS(err.Error())
+		if core.IsOnionName(identifier.Value) {
+			return probs.CAA(err.Error())
+		}
+		return dnsProblem(err)
 	}
 
 	recordsStr, err := json.Marshal(&records)
@@ -66,7 +117,7 @@ func (va *ValidationAuthorityImpl) checkCAA(
 		validationMethod = *params.validationMethod
 	}
 
-	va.log.AuditInfof("Checked CAA records for %s, [Present: %t, Account ID: %s, Challenge: %s, Valid for issuance: %t] Records=%s",
+	blog.ForContext(ctx, va.log).AuditInfof("Checked CAA records for %s, [Present: %t, Account ID: %s, Challenge: %s, Valid for issuance: %t] Records=%s",
 		identifier.Value, present, accountID, validationMethod, valid, recordsStr)
 	if !valid {
 		return probs.CAA(fmt.Sprintf("CAA record for %s prevents issuance", identifier.Value))
@@ -139,7 +190,54 @@ func parseResults(results []caaResult) (*CAASet, []*dns.CAA, error) {
 	return nil, nil, nil
 }
 
-func (va *ValidationAuthorityImpl) parallelCAALookup(ctx context.Context, name string) []caaResult {
+// caaLookupCache holds the results of CAA lookups so that they can be shared
+// between the checks made for a single request. It is never shared between
+// requests, so every check still uses records fetched while it was being made.
+type caaLookupCache struct {
+	mu      sync.Mutex
+	lookups map[string]*caaLookup
+}
+
+// caaLookup is a CAA lookup which is in progress, or finished once done is
+// closed.
+type caaLookup struct {
+	done chan struct{}
+	caaResult
+}
+
+func newCAALookupCache() *caaLookupCache {
+	return &caaLookupCache{lookups: make(map[string]*caaLookup)}
+}
+
+// lookupCAA looks up the CAA records for name. If lookups is non-nil and
+// another check has already looked up, or is looking up, the same name, its
+// result is used instead of making another query.
+func (va *ValidationAuthorityImpl) lookupCAA(ctx context.Context, lookups *caaLookupCache, name string) caaResult {
+	if lookups == nil {
+		var r caaResult
+		r.records, r.err = va.dnsClient.LookupCAA(ctx, name)
+		return r
+	}
+	lookups.mu.Lock()
+	l, ok := lookups.lookups[name]
+	if !ok {
+		l = &caaLookup{done: make(chan struct{})}
+		lookups.lookups[name] = l
+	}
+	lookups.mu.Unlock()
+	if !ok {
+		l.records, l.err = va.dnsClient.LookupCAA(ctx, name)
+		close(l.done)
+	}
+	select {
+	case <-l.done:
+		return l.caaResult
+	case <-ctx.Done():
+		return caaResult{err: ctx.Err()}
+	}
+}
+
+func (va *ValidationAuthorityImpl) parallelCAALookup(ctx context.Context, name string, lookups *caaLookupCache) []caaResult {
 	labels := strings.Split(name, ".")
 	results := make([]caaResult, len(labels))
 	var wg sync.WaitGroup
@@ -148,7 +246,7 @@ func (va *ValidationAuthorityImpl) parallelCAALookup(ctx context.Context, name s
 		// Start the concurrent DNS lookup.
 		wg.Add(1)
 		go func(name string, r *caaResult) {
-			r.records, r.err = va.dnsClient.LookupCAA(ctx, name)
+			*r = va.lookupCAA(ctx, lookups, name)
 			wg.Done()
 		}(strings.Join(labels[i:], "."), &results[i])
 	}
@@ -157,7 +255,7 @@ func (va *ValidationAuthorityImpl) parallelCAALookup(ctx context.Context, name s
 	return results
 }
 
-func (va *ValidationAuthorityImpl) getCAASet(ctx context.Context, hostname string) (*CAASet, []*dns.CAA, error) {
+func (va *ValidationAuthorityImpl) getCAASet(ctx context.Context, hostname string, lookups *caaLookupCache) (*CAASet, []*dns.CAA, error) {
 	hostname = strings.TrimRight(hostname, ".")
 
 	// See RFC 6844 "Certification Authority Processing" for pseudocode, as
@@ -169,7 +267,7 @@ func (va *ValidationAuthorityImpl) getCAASet(ctx context.Context, hostname strin
 	// the RPC call.
 	//
 	// We depend on our resolver to snap CNAME and DNAME records.
-	results := va.parallelCAALookup(ctx, hostname)
+	results := va.parallelCAALookup(ctx, hostname, lookups)
 	return parseResults(results)
 }
 
@@ -194,7 +292,20 @@ func (va *ValidationAuthorityImpl) checkCAARecords(
 		hostname = strings.TrimPrefix(identifier.Value, `*.`)
 		wildcard = true
 	}
-	caaSet, records, err := va.getCAASet(ctx, hostname)
+	var lookups *caaLookupCache
+	var onionCAA *core.OnionCAA
+	if params != nil {
+		lookups = params.lookups
+		onionCAA = params.onionCAA
+	}
+	var caaSet *CAASet
+	var records []*dns.CAA
+	var err error
+	if core.IsOnionName(hostname) {
+		caaSet, records, err = onionCAASet(hostname, onionCAA, va.clk.Now())
+	} else {
+		caaSet, records, err = va.getCAASet(ctx, hostname, lookups)
+	}
 	if err != nil {
 		return false, false, nil, err
 	}
@@ -252,10 +363,10 @@ func (va *ValidationAuthorityImpl) validateCAASet(caaSet *CAASet, wildcard bool,
 	// includes the case of the unsatisfiable CAA record value ";", used to
 	// prevent issuance by any CA under any circumstance.
 	//
-	// Our CAA identity must be found in the chosen checkSet.
+	// One of our CAA identities must be found in the chosen checkSet.
 	for _, caa := range records {
 		caaIssuerDomain, caaParameters, caaValid := extractIssuerDomainAndParameters(caa)
-		if !caaValid || caaIssuerDomain != va.issuerDomain {
+		if !caaValid || !va.isIssuerDomain(caaIssuerDomain) {
 			continue
 		}
 
@@ -308,6 +419,17 @@ func checkAccountURI(accountURI string, accountURIPrefixes []string, accountID i
 	return false
 }
 
+// isIssuerDomain returns true if domain is one of the CAA identities the VA
+// issues under.
+func (va *ValidationAuthorityImpl) isIssuerDomain(domain string) bool {
+	for _, issuerDomain := range va.issuerDomains {
+		if domain == issuerDomain {
+			return true
+		}
+	}
+	return false
+}
+
 // extractIssuerDomainAndParameters extracts the domain and parameters (if any)
 // from a issue/issuewild CAA record. This follows sections 5.2 and 5.3 of the
 // RFC 6844bis draft (https://tools.ietf.org/html/draft-ietf-lamps-rfc6844bis-00),
@@ -7,6 +7,7 @@ import (
 	"net"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/miekg/dns"
@@ -589,6 +590,34 @@ func TestCAAFailure(t *testing.T) {
 	test.AssertEquals(t, prob.Type, probs.CAAProblem)
 }
 
+func TestCAAMultipleIssuerDomains(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+	va.dnsClient = caaMockDNS{}
+	va.issuerDomains = []string{"letsencrypt.org", "ca.com"}
+
+	// Records naming any of the VA's issuer domains permit issuance.
+	for _, domain := range []string{"present.com", "reserved.com", "satisfiable-wildcard-override.com"} {
+		present, valid, _, err := va.checkCAARecords(ctx, dnsi(domain), nil)
+		test.AssertNotError(t, err, domain)
+		test.Assert(t, present, fmt.Sprintf("Present should be true for %s", domain))
+		test.Assert(t, valid, fmt.Sprintf("Valid should be true for %s", domain))
+	}
+
+	// Records naming none of them still forbid it.
+	present, valid, _, err := va.checkCAARecords(ctx, dnsi("unsatisfiable.com"), nil)
+	test.AssertNotError(t, err, "unsatisfiable.com")
+	test.Assert(t, present, "Present should be true")
+	test.Assert(t, !valid, "Valid should be false")
+}
+
+func TestCAADNSSECFailure(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+
+	prob := va.checkCAA(ctx, dnsi("dnssec-bogus.com"), nil)
+	test.AssertNotNil(t, prob, "Expected a problem for a CAA lookup with bogus DNSSEC")
+	test.AssertEquals(t, prob.Type, probs.DNSSECProblem)
+}
+
 func TestParseResults(t *testing.T) {
 	r := []caaResult{}
 	s, records, err := parseResults(r)
@@ -821,3 +850,48 @@ func TestExtractIssuerDomainAndParameters(t *testing.T) {
 		})
 	}
 }
+
+// countingCAADNS wraps caaMockDNS, counting the CAA lookups for each name.
+type countingCAADNS struct {
+	caaMockDNS
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+func (mock *countingCAADNS) LookupCAA(ctx context.Context, domain string) ([]*dns.CAA, error) {
+	mock.mu.Lock()
+	mock.lookups[domain]++
+	mock.mu.Unlock()
+	return mock.caaMockDNS.LookupCAA(ctx, domain)
+}
+
+func TestIsCAAValidForNames(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+	dnsClient := &countingCAADNS{lookups: make(map[string]int)}
+	va.dnsClient = dnsClient
+
+	domains := []string{"present.com", "www.present.com", "*.present.com", "reserved.com"}
+	var checks []*vapb.IsCAAValidRequest
+	for i := range domains {
+		checks = append(checks, &vapb.IsCAAValidRequest{Domain: &domains[i]})
+	}
+	resp, err := va.IsCAAValidForNames(ctx, &vapb.IsCAAValidForNamesRequest{Checks: checks})
+	test.AssertNotError(t, err, "IsCAAValidForNames failed")
+	test.AssertEquals(t, len(resp.Results), len(domains))
+	for i, result := range resp.Results[:3] {
+		test.Assert(t, result.Problem == nil, fmt.Sprintf("unexpected problem for %s", domains[i]))
+	}
+	test.AssertNotNil(t, resp.Results[3].Problem, "reserved.com was allowed")
+	test.AssertEquals(t, *resp.Results[3].Problem.Detail, "While processing CAA for reserved.com: CAA record for reserved.com prevents issuance")
+
+	// Each name should only have been looked up once.
+	test.AssertDeepEquals(t, dnsClient.lookups, map[string]int{
+		"present.com":     1,
+		"www.present.com": 1,
+		"reserved.com":    1,
+		"com":             1,
+	})
+
+	_, err = va.IsCAAValidForNames(ctx, &vapb.IsCAAValidForNamesRequest{Checks: []*vapb.IsCAAValidRequest{{}}})
+	test.AssertError(t, err, "IsCAAValidForNames accepted a check without a domain")
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net"
 
+	"github.com/letsencrypt/boulder/bdns"
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/identifier"
@@ -33,6 +35,16 @@ func (va ValidationAuthorityImpl) getAddrs(ctx context.Context, hostname string)
 	return addrs, nil
 }
 
+// addrsForHost returns the addresses at which to validate host: the address
+// itself if host is an IP address identifier's value, and the addresses it
+// resolves to otherwise.
+func (va ValidationAuthorityImpl) addrsForHost(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	return va.getAddrs(ctx, host)
+}
+
 // availableAddresses takes a ValidationRecord and splits the AddressesResolved
 // into a list of IPv4 and IPv6 addresses.
 func availableAddresses(allAddrs []net.IP) (v4 []net.IP, v6 []net.IP) {
@@ -61,7 +73,7 @@ func (va *ValidationAuthorityImpl) validateDNS01(ctx context.Context, ident iden
 	challengeSubdomain := fmt.Sprintf("%s.%s", core.DNSPrefix, ident.Value)
 	txts, err := va.dnsClient.LookupTXT(ctx, challengeSubdomain)
 	if err != nil {
-		return nil, probs.DNS(err.Error())
+		return nil, dnsProblem(err)
 	}
 
 	// If there weren't any TXT records return a distinct error message to allow
@@ -71,10 +83,13 @@ func (va *ValidationAuthorityImpl) validateDNS01(ctx context.Context, ident iden
 		return nil, probs.Unauthorized(fmt.Sprintf("No TXT record found at %s", challengeSubdomain))
 	}
 
+	// Record the TXT records found, so that subscribers can see what was
+	// compared against the expected digest.
+	records := []core.ValidationRecord{{Hostname: ident.Value, DNSAnswers: recordedAnswers(txts)}}
 	for _, element := range txts {
 		if subtle.ConstantTimeCompare([]byte(element), []byte(authorizedKeysDigest)) == 1 {
 			// Successful challenge validation
-			return []core.ValidationRecord{{Hostname: ident.Value}}, nil
+			return records, nil
 		}
 	}
 
@@ -86,6 +101,40 @@ func (va *ValidationAuthorityImpl) validateDNS01(ctx context.Context, ident iden
 	if len(txts) > 1 {
 		andMore = fmt.Sprintf(" (and %d more)", len(txts)-1)
 	}
-	return nil, probs.Unauthorized(fmt.Sprintf("Incorrect TXT record %q%s found at %s",
+	return records, probs.Unauthorized(fmt.Sprintf("Incorrect TXT record %q%s found at %s",
 		replaceInvalidUTF8([]byte(invalidRecord)), andMore, challengeSubdomain))
 }
+
+// maxRecordedAnswers is the number of TXT records recorded in a DNS-01
+// validation record. Each is truncated to maxRecordedAnswerLength bytes.
+const (
+	maxRecordedAnswers      = 10
+	maxRecordedAnswerLength = 100
+)
+
+// recordedAnswers returns the TXT records to include in a DNS-01 validation
+// record, limiting how many there are and how long each is.
+func recordedAnswers(txts []string) []string {
+	if len(txts) > maxRecordedAnswers {
+		txts = txts[:maxRecordedAnswers]
+	}
+	answers := make([]string, len(txts))
+	for i, txt := range txts {
+		if len(txt) > maxRecordedAnswerLength {
+			txt = txt[:maxRecordedAnswerLength] + "..."
+		}
+		answers[i] = replaceInvalidUTF8([]byte(txt))
+	}
+	return answers
+}
+
+// dnsProblem returns a ProblemDetails for an error looking up TXT or CAA
+// records, distinguishing lookups which failed because DNSSEC was required
+// but the answer was bogus or unauthenticated.
+func dnsProblem(err error) *probs.ProblemDetails {
+	var dnsErr *bdns.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.DNSSECFailure() {
+		return probs.DNSSEC(err.Error())
+	}
+	return probs.DNS(err.Error())
+}
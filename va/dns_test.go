@@ -55,20 +55,25 @@ func TestDNSValidationWrong(t *testing.T) {
 func TestDNSValidationWrongMany(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
-	_, prob := va.validateDNS01(context.Background(), dnsi("wrong-many-dns01.com"), dnsChallenge())
+	records, prob := va.validateDNS01(context.Background(), dnsi("wrong-many-dns01.com"), dnsChallenge())
 	if prob == nil {
 		t.Fatalf("Successful DNS validation with wrong TXT record")
 	}
 	test.AssertEquals(t, prob.Error(), "unauthorized :: Incorrect TXT record \"a\" (and 4 more) found at _acme-challenge.wrong-many-dns01.com")
+	// Every TXT record found is recorded, so the subscriber can see them.
+	test.AssertEquals(t, len(records), 1)
+	test.AssertDeepEquals(t, records[0].DNSAnswers, []string{"a", "b", "c", "d", "e"})
 }
 
 func TestDNSValidationWrongLong(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
-	_, prob := va.validateDNS01(context.Background(), dnsi("long-dns01.com"), dnsChallenge())
+	records, prob := va.validateDNS01(context.Background(), dnsi("long-dns01.com"), dnsChallenge())
 	if prob == nil {
 		t.Fatalf("Successful DNS validation with wrong TXT record")
 	}
+	test.AssertEquals(t, len(records), 1)
+	test.AssertEquals(t, records[0].DNSAnswers[0], strings.Repeat("a", maxRecordedAnswerLength)+"...")
 	test.AssertEquals(t, prob.Error(), "unauthorized :: Incorrect TXT record \"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa...\" found at _acme-challenge.long-dns01.com")
 }
 
@@ -137,6 +142,15 @@ func TestDNSValidationServFail(t *testing.T) {
 	test.AssertEquals(t, prob.Type, probs.DNSProblem)
 }
 
+func TestDNSValidationDNSSECBogus(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+
+	_, prob := va.validateChallenge(ctx, dnsi("dnssec-bogus.com"), dnsChallenge())
+
+	test.AssertEquals(t, prob.Type, probs.DNSSECProblem)
+	test.AssertEquals(t, prob.Detail, "DNS problem: DNSSEC validation failure looking up TXT for _acme-challenge.dnssec-bogus.com")
+}
+
 func TestDNSValidationNoServer(t *testing.T) {
 	va, log := setup(nil, 0, "", nil)
 	va.dnsClient = bdns.NewTestDNSClientImpl(
@@ -155,9 +169,11 @@ func TestDNSValidationNoServer(t *testing.T) {
 func TestDNSValidationOK(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
-	_, prob := va.validateChallenge(ctx, dnsi("good-dns01.com"), dnsChallenge())
+	records, prob := va.validateChallenge(ctx, dnsi("good-dns01.com"), dnsChallenge())
 
 	test.Assert(t, prob == nil, "Should be valid.")
+	test.AssertEquals(t, len(records), 1)
+	test.AssertDeepEquals(t, records[0].DNSAnswers, []string{"LPsIwTo7o8BoG0-vjCyGQGBWSVIPxI-i_X336eUOQZo"})
 }
 
 func TestDNSValidationNoAuthorityOK(t *testing.T) {
@@ -17,13 +17,21 @@ import (
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/iana"
 	"github.com/letsencrypt/boulder/identifier"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/probs"
 )
 
 const (
 	// maxRedirect is the maximum number of redirects the VA will follow
-	// processing an HTTP-01 challenge.
+	// processing an HTTP-01 challenge, unless its ChallengePolicy says
+	// otherwise.
 	maxRedirect = 10
+	// defaultAccept is the Accept header sent with HTTP-01 requests, unless
+	// their ChallengePolicy says otherwise.
+	defaultAccept = "*/*"
+	// maxRecordedHeaderLength is the length to which the values of
+	// recordedResponseHeaders are truncated in validation records.
+	maxRecordedHeaderLength = 100
 	// maxResponseSize holds the maximum number of bytes that will be read from an
 	// HTTP-01 challenge response. The expected payload should be ~87 bytes. Since
 	// it may be padded by whitespace which we previously allowed accept up to 128
@@ -47,6 +55,10 @@ type preresolvedDialer struct {
 	port     int
 	hostname string
 	timeout  time.Duration
+	// proxy, if set, is used to connect to the pre-resolved IP and port.
+	proxy *proxyDialer
+	// limiter, if set, limits how often the pre-resolved IP is connected to.
+	limiter *targetLimiter
 }
 
 // a dialerMismatchError is produced when a preresolvedDialer is used to dial
@@ -122,11 +134,21 @@ func (d *preresolvedDialer) DialContext(
 		}
 	}
 
+	if err := d.limiter.allow(d.ip.String()); err != nil {
+		return nil, err
+	}
+
 	// Make a new dial address using the pre-resolved IP and port.
 	targetAddr := net.JoinHostPort(d.ip.String(), strconv.Itoa(d.port))
 
+	if d.proxy != nil {
+		ctx, cancel := context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+		return d.proxy.DialContext(ctx, network, targetAddr)
+	}
+
 	// Create a throw-away dialer using default values and the dialer timeout
-	// (populated from the VA singleDialTimeout).
+	// (populated from the VA's HTTP-01 connect timeout).
 	throwAwayDialer := &net.Dialer{
 		Timeout: d.timeout,
 		// Default KeepAlive - see Golang src/net/http/transport.go DefaultTransport
@@ -191,7 +213,9 @@ func (vt *httpValidationTarget) nextIP() error {
 			"host %q has no IP addresses remaining to use",
 			vt.host)
 	}
-	vt.tried = append(vt.tried, vt.cur)
+	if vt.cur != nil {
+		vt.tried = append(vt.tried, vt.cur)
+	}
 	vt.cur = vt.next[0]
 	vt.next = vt.next[1:]
 	return nil
@@ -214,7 +238,7 @@ func (va *ValidationAuthorityImpl) newHTTPValidationTarget(
 	path string,
 	query string) (*httpValidationTarget, error) {
 	// Resolve IP addresses for the hostname
-	addrs, err := va.getAddrs(ctx, host)
+	addrs, err := va.addrsForHost(ctx, host)
 	if err != nil {
 		return nil, err
 	}
@@ -385,7 +409,9 @@ func (va *ValidationAuthorityImpl) setupHTTPValidation(
 		ip:       targetIP,
 		port:     target.port,
 		hostname: target.host,
-		timeout:  va.singleDialTimeout,
+		timeout:  va.connectTimeout(core.ChallengeTypeHTTP01),
+		proxy:    va.proxy,
+		limiter:  va.targetLimiter,
 	}
 	return dialer, record, nil
 }
@@ -443,10 +469,15 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 		return nil, nil, err
 	}
 
-	// Create an initial GET Request
+	// Create an initial GET Request. An IPv6 address identifier must be
+	// bracketed in the URL's host.
+	urlHost := host
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		urlHost = "[" + host + "]"
+	}
 	initialURL := url.URL{
 		Scheme: "http",
-		Host:   host,
+		Host:   urlHost,
 		Path:   path,
 	}
 	initialReq, err := http.NewRequest("GET", initialURL.String(), nil)
@@ -471,8 +502,13 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 	ctx, cancel := context.WithDeadline(ctx, deadline)
 	defer cancel()
 	initialReq = initialReq.WithContext(ctx)
-	if va.userAgent != "" {
-		initialReq.Header.Set("User-Agent", va.userAgent)
+	policy := va.challengePolicies[core.ChallengeTypeHTTP01]
+	userAgent := va.userAgent
+	if policy.UserAgent != "" {
+		userAgent = policy.UserAgent
+	}
+	if userAgent != "" {
+		initialReq.Header.Set("User-Agent", userAgent)
 	}
 	// Some of our users use mod_security. Mod_security sees a lack of Accept
 	// headers as bot behavior and rejects requests. While this is a bug in
@@ -483,7 +519,15 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 	// <https://github.com/letsencrypt/boulder/issues/1019>. This was done
 	// because it's a one-line fix with no downside. We're not likely to want to
 	// do many more things to satisfy misunderstandings around HTTP.
-	initialReq.Header.Set("Accept", "*/*")
+	accept := defaultAccept
+	if policy.Accept != "" {
+		accept = policy.Accept
+	}
+	initialReq.Header.Set("Accept", accept)
+	maxRedirects := maxRedirect
+	if policy.MaxRedirects > 0 {
+		maxRedirects = policy.MaxRedirects
+	}
 
 	// Set up the initial validation request and a base validation record
 	dialer, baseRecord, err := va.setupHTTPValidation(ctx, initialReq.URL.String(), target)
@@ -494,8 +538,9 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 	// Build a transport for this validation that will use the preresolvedDialer's
 	// DialContext function
 	transport := httpTransport(dialer.DialContext)
+	transport.ResponseHeaderTimeout = va.readTimeout(core.ChallengeTypeHTTP01)
 
-	va.log.AuditInfof("Attempting to validate HTTP-01 for %q with GET to %q",
+	blog.ForContext(ctx, va.log).AuditInfof("Attempting to validate HTTP-01 for %q with GET to %q",
 		initialReq.Host, initialReq.URL.String())
 
 	// Create a closure around records & numRedirects we can use with a HTTP
@@ -505,8 +550,14 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 	numRedirects := 0
 	processRedirect := func(req *http.Request, via []*http.Request) error {
 		va.log.Debugf("processing a HTTP redirect from the server to %q", req.URL.String())
-		// Only process up to maxRedirect redirects
-		if numRedirects > maxRedirect {
+		// Record the status and headers of interest of the redirect response
+		// on the record for the request it answered.
+		if req.Response != nil {
+			records[len(records)-1].ResponseStatus = req.Response.Status
+			records[len(records)-1].ResponseHeaders = recordedHeaders(req.Response.Header)
+		}
+		// Only process up to maxRedirects redirects
+		if numRedirects > maxRedirects {
 			return berrors.ConnectionFailureError("Too many redirects")
 		}
 		numRedirects++
@@ -568,8 +619,10 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 	// followed.
 	httpResponse, err := client.Do(initialReq)
 	// If there was an error and its a kind of error we consider a fallback error,
-	// then try to fallback.
-	if err != nil && fallbackErr(err) {
+	// then try to fallback. Only a failure to connect to the initial target is
+	// retried: once a redirect has been followed the failed connection was to
+	// the redirect target, and retrying the initial target wouldn't change that.
+	if err != nil && fallbackErr(err) && numRedirects == 0 && !policy.DisableIPv4Fallback {
 		// Try to advance to another IP. If there was an error advancing we don't
 		// have a fallback address to use and must return the original error.
 		if ipErr := target.nextIP(); ipErr != nil {
@@ -579,6 +632,7 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 		// setup another validation to retry the target with the new IP and append
 		// the retry record.
 		retryDialer, retryRecord, err := va.setupHTTPValidation(ctx, initialReq.URL.String(), target)
+		retryRecord.AddressesTried = append([]net.IP{}, target.tried...)
 		records = append(records, retryRecord)
 		if err != nil {
 			return nil, records, err
@@ -602,6 +656,8 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 
 	// At this point we've made a successful request (be it from a retry or
 	// otherwise) and can read and process the response body.
+	records[len(records)-1].ResponseStatus = httpResponse.Status
+	records[len(records)-1].ResponseHeaders = recordedHeaders(httpResponse.Header)
 	body, err := ioutil.ReadAll(&io.LimitedReader{R: httpResponse.Body, N: maxResponseSize})
 	closeErr := httpResponse.Body.Close()
 	if err == nil {
@@ -623,10 +679,37 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 	return body, records, nil
 }
 
+// recordedResponseHeaders are the HTTP-01 response headers whose values are
+// recorded in validation records. They identify the web server and any proxies
+// which answered, to help investigate abuse.
+var recordedResponseHeaders = []string{"Server", "Via"}
+
+// recordedHeaders returns the values of the recordedResponseHeaders present in
+// header, or nil if there are none. Repeated headers are joined with commas,
+// and long values are truncated.
+func recordedHeaders(header http.Header) map[string]string {
+	var recorded map[string]string
+	for _, name := range recordedResponseHeaders {
+		values := header[name]
+		if len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ", ")
+		if len(value) > maxRecordedHeaderLength {
+			value = value[:maxRecordedHeaderLength] + "..."
+		}
+		if recorded == nil {
+			recorded = make(map[string]string)
+		}
+		recorded[name] = replaceInvalidUTF8([]byte(value))
+	}
+	return recorded
+}
+
 func (va *ValidationAuthorityImpl) validateHTTP01(ctx context.Context, ident identifier.ACMEIdentifier, challenge core.Challenge) ([]core.ValidationRecord, *probs.ProblemDetails) {
-	if ident.Type != identifier.DNS {
-		va.log.Infof("Got non-DNS identifier for HTTP validation: %s", ident)
-		return nil, probs.Malformed("Identifier type for HTTP validation was not DNS")
+	if ident.Type != identifier.DNS && ident.Type != identifier.IP {
+		va.log.Infof("Got non-DNS, non-IP identifier for HTTP validation: %s", ident)
+		return nil, probs.Malformed("Identifier type for HTTP validation was not DNS or IP")
 	}
 
 	// Perform the fetch
@@ -726,6 +726,7 @@ func TestFetchHTTP(t *testing.T) {
 				URL:               url,
 				AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 				AddressUsed:       net.ParseIP("127.0.0.1"),
+				ResponseStatus:    "301 Moved Permanently",
 			})
 	}
 
@@ -792,6 +793,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/redir-bad-proto",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "301 Moved Permanently",
 				},
 			},
 		},
@@ -809,6 +811,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/redir-bad-port",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "301 Moved Permanently",
 				},
 			},
 		},
@@ -826,6 +829,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/redir-bad-host",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "301 Moved Permanently",
 				},
 			},
 		},
@@ -842,6 +846,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/redir-path-too-long",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "301 Moved Permanently",
 				},
 			},
 		},
@@ -859,6 +864,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/bad-status-code",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "410 Gone",
 				},
 			},
 		},
@@ -877,6 +883,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/resp-too-big",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "200 OK",
 				},
 			},
 		},
@@ -917,6 +924,9 @@ func TestFetchHTTP(t *testing.T) {
 					AddressesResolved: []net.IP{net.ParseIP("::1"), net.ParseIP("127.0.0.1")},
 					// The second validation record should have used the IPv4 addr as a fallback
 					AddressUsed: net.ParseIP("127.0.0.1"),
+					// and recorded that the IPv6 addr was tried first
+					AddressesTried: []net.IP{net.ParseIP("::1")},
+					ResponseStatus: "200 OK",
 				},
 			},
 		},
@@ -932,6 +942,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/ok",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "200 OK",
 				},
 			},
 		},
@@ -947,6 +958,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/redir-uppercase-publicsuffix",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "301 Moved Permanently",
 				},
 				{
 					Hostname:          "example.com",
@@ -954,6 +966,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/ok",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "200 OK",
 				},
 			},
 		},
@@ -975,6 +988,7 @@ func TestFetchHTTP(t *testing.T) {
 					URL:               "http://example.com/printf-verbs",
 					AddressesResolved: []net.IP{net.ParseIP("127.0.0.1")},
 					AddressUsed:       net.ParseIP("127.0.0.1"),
+					ResponseStatus:    "200 OK",
 				},
 			},
 		},
@@ -1090,6 +1104,81 @@ func httpSrv(t *testing.T, token string) *httptest.Server {
 	return server
 }
 
+func TestFetchHTTPDisableIPv4Fallback(t *testing.T) {
+	testSrv := httpTestSrv(t)
+	defer testSrv.Close()
+	va, _ := setup(testSrv, 0, "", nil)
+	va.challengePolicies = map[core.AcmeChallenge]ChallengePolicy{
+		core.ChallengeTypeHTTP01: {DisableIPv4Fallback: true},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	_, records, prob := va.fetchHTTP(ctx, "ipv4.and.ipv6.localhost", "/ok")
+	test.AssertNotNil(t, prob, "Expected the broken IPv6 address to fail validation")
+	test.AssertEquals(t, prob.Type, probs.ConnectionProblem)
+	// Only the IPv6 address was tried
+	test.AssertEquals(t, len(records), 1)
+	test.AssertEquals(t, records[0].AddressUsed.String(), "::1")
+	test.AssertEquals(t, len(records[0].AddressesTried), 0)
+}
+
+func TestFetchHTTPRequestPolicy(t *testing.T) {
+	mux := http.NewServeMux()
+	testSrv := httptest.NewServer(mux)
+	defer testSrv.Close()
+	httpPort := getPort(testSrv)
+
+	// A path which echoes the request's User-Agent and Accept headers, and
+	// answers with headers of interest.
+	mux.HandleFunc("/headers", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Server", "nginx/1.18.0")
+		resp.Header().Add("Via", "1.1 proxy-a")
+		resp.Header().Add("Via", "1.1 proxy-b")
+		resp.Header().Set("X-Powered-By", "PHP")
+		fmt.Fprintf(resp, "%s|%s", req.Header.Get("User-Agent"), req.Header.Get("Accept"))
+	})
+	mux.HandleFunc("/loop", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Server", "loop-server")
+		http.Redirect(resp, req, fmt.Sprintf("http://example.com:%d/loop", httpPort), http.StatusMovedPermanently)
+	})
+
+	va, _ := setup(testSrv, 0, "", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	body, records, prob := va.fetchHTTP(ctx, "example.com", "/headers")
+	test.Assert(t, prob == nil, "fetch failed")
+	test.AssertEquals(t, string(body), "user agent 1.0|*/*")
+	test.AssertDeepEquals(t, records[0].ResponseHeaders, map[string]string{
+		"Server": "nginx/1.18.0",
+		"Via":    "1.1 proxy-a, 1.1 proxy-b",
+	})
+
+	va.challengePolicies = map[core.AcmeChallenge]ChallengePolicy{
+		core.ChallengeTypeHTTP01: {UserAgent: "custom agent", Accept: "text/plain", MaxRedirects: 2},
+	}
+	body, _, prob = va.fetchHTTP(ctx, "example.com", "/headers")
+	test.Assert(t, prob == nil, "fetch failed")
+	test.AssertEquals(t, string(body), "custom agent|text/plain")
+
+	// Like maxRedirect, the redirect that exceeds MaxRedirects is still
+	// recorded before the loop is ended.
+	_, records, prob = va.fetchHTTP(ctx, "example.com", "/loop")
+	test.AssertNotNil(t, prob, "redirect loop was followed")
+	test.AssertContains(t, prob.Detail, "Too many redirects")
+	test.AssertEquals(t, len(records), 4)
+	test.AssertDeepEquals(t, records[0].ResponseHeaders, map[string]string{"Server": "loop-server"})
+}
+
+func TestRecordedHeaders(t *testing.T) {
+	test.Assert(t, recordedHeaders(http.Header{"Content-Type": {"text/plain"}}) == nil, "headers recorded")
+	long := strings.Repeat("a", maxRecordedHeaderLength+1)
+	test.AssertDeepEquals(t, recordedHeaders(http.Header{"Server": {long}}), map[string]string{
+		"Server": long[:maxRecordedHeaderLength] + "...",
+	})
+}
+
 func TestHTTPBadPort(t *testing.T) {
 	hs := httpSrv(t, expectedToken)
 	defer hs.Close()
@@ -1194,10 +1283,18 @@ func TestHTTP(t *testing.T) {
 	test.AssertEquals(t, len(matchedValidRedirect), 1)
 	test.AssertEquals(t, len(matchedMovedRedirect), 1)
 
-	ipIdentifier := identifier.ACMEIdentifier{Type: identifier.IdentifierType("ip"), Value: "127.0.0.1"}
-	_, prob = va.validateHTTP01(ctx, ipIdentifier, chall)
+	// IP identifiers are validated by connecting to the address itself.
+	ipIdentifier := identifier.ACMEIdentifier{Type: identifier.IP, Value: "127.0.0.1"}
+	records, prob := va.validateHTTP01(ctx, ipIdentifier, httpChallenge())
+	if prob != nil {
+		t.Fatalf("Failed to validate IP identifier: %s", prob)
+	}
+	test.AssertEquals(t, records[0].AddressUsed.String(), "127.0.0.1")
+
+	emailIdentifier := identifier.ACMEIdentifier{Type: identifier.IdentifierType("email"), Value: "test@example.com"}
+	_, prob = va.validateHTTP01(ctx, emailIdentifier, chall)
 	if prob == nil {
-		t.Fatalf("IdentifierType IP shouldn't have worked.")
+		t.Fatalf("IdentifierType email shouldn't have worked.")
 	}
 	test.AssertEquals(t, prob.Type, probs.MalformedProblem)
 
@@ -0,0 +1,201 @@
+package va
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/identifier"
+	"github.com/letsencrypt/boulder/probs"
+)
+
+var (
+	// The CSR attributes defined by Appendix B of the CA/Browser Forum
+	// Baseline Requirements for proving control of a .onion name.
+	// cabforum OID + 41 (caSigningNonce)
+	idCASigningNonce = asn1.ObjectIdentifier{2, 23, 140, 41}
+	// cabforum OID + 42 (applicantSigningNonce)
+	idApplicantSigningNonce = asn1.ObjectIdentifier{2, 23, 140, 42}
+)
+
+// minApplicantNonceLength is the minimum length of the applicantSigningNonce,
+// which must contain at least 64 bits of entropy.
+const minApplicantNonceLength = 8
+
+// tbsCertificateRequest is the part of a PKCS #10 CSR covered by its
+// signature. crypto/x509 only parses attributes whose values are sets of
+// name attributes, so the onion-csr-01 nonces are read from the raw
+// attributes.
+type tbsCertificateRequest struct {
+	Raw           asn1.RawContent
+	Version       int
+	Subject       asn1.RawValue
+	PublicKey     asn1.RawValue
+	RawAttributes []asn1.RawValue `asn1:"tag:0"`
+}
+
+// csrAttribute is an Attribute of a CSR, which has one or more values.
+type csrAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// csrNonces returns the caSigningNonce and applicantSigningNonce attributes of
+// csr, which must each have a single OCTET STRING value.
+func csrNonces(csr *x509.CertificateRequest) (caNonce, applicantNonce []byte, err error) {
+	var tbs tbsCertificateRequest
+	rest, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) != 0 {
+		return nil, nil, errors.New("trailing data after CSR")
+	}
+	for _, rawAttr := range tbs.RawAttributes {
+		var attr csrAttribute
+		_, err := asn1.Unmarshal(rawAttr.FullBytes, &attr)
+		if err != nil {
+			return nil, nil, err
+		}
+		var dest *[]byte
+		switch {
+		case attr.Type.Equal(idCASigningNonce):
+			dest = &caNonce
+		case attr.Type.Equal(idApplicantSigningNonce):
+			dest = &applicantNonce
+		default:
+			continue
+		}
+		if *dest != nil || len(attr.Values) != 1 {
+			return nil, nil, fmt.Errorf("CSR must have exactly one %s attribute value", attr.Type)
+		}
+		_, err = asn1.Unmarshal(attr.Values[0].FullBytes, dest)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s attribute: %s", attr.Type, err)
+		}
+	}
+	return caNonce, applicantNonce, nil
+}
+
+// validateOnionCSR01 checks the CSR of an onion-csr-01 challenge response. It
+// must be signed by the hidden service key encoded in the .onion name, request
+// the name, and carry the challenge's nonce as its caSigningNonce.
+func (va *ValidationAuthorityImpl) validateOnionCSR01(ctx context.Context, ident identifier.ACMEIdentifier, challenge core.Challenge) ([]core.ValidationRecord, *probs.ProblemDetails) {
+	if ident.Type != identifier.DNS || !core.IsOnionName(ident.Value) {
+		va.log.Infof("Got non-onion identifier for onion-csr-01: %s", ident.Value)
+		return nil, probs.Malformed("Identifier type for onion-csr-01 was not a .onion name")
+	}
+	validationRecords := []core.ValidationRecord{{Hostname: ident.Value}}
+
+	if challenge.OnionCSRResponse == nil {
+		return validationRecords, probs.Malformed("No CSR was provided in the onion-csr-01 challenge response")
+	}
+	der, err := base64.RawURLEncoding.DecodeString(challenge.OnionCSRResponse.CSR)
+	if err != nil {
+		return validationRecords, probs.Malformed("onion-csr-01 CSR is not valid base64url: %s", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return validationRecords, probs.Malformed("Error parsing onion-csr-01 CSR: %s", err)
+	}
+	err = csr.CheckSignature()
+	if err != nil {
+		return validationRecords, probs.Unauthorized(fmt.Sprintf("onion-csr-01 CSR has an invalid signature: %s", err))
+	}
+
+	onionKey, err := core.OnionPublicKey(ident.Value)
+	if err != nil {
+		return validationRecords, probs.Malformed("Invalid .onion name %q: %s", ident.Value, err)
+	}
+	csrKey, ok := csr.PublicKey.(ed25519.PublicKey)
+	if !ok || !bytes.Equal(csrKey, onionKey) {
+		return validationRecords, probs.Unauthorized(fmt.Sprintf("onion-csr-01 CSR was not signed by the hidden service key of %s", ident.Value))
+	}
+
+	var requested bool
+	for _, name := range csr.DNSNames {
+		if strings.EqualFold(name, ident.Value) {
+			requested = true
+			break
+		}
+	}
+	if !requested {
+		return validationRecords, probs.Unauthorized(fmt.Sprintf("onion-csr-01 CSR does not request %s", ident.Value))
+	}
+
+	caNonce, applicantNonce, err := csrNonces(csr)
+	if err != nil {
+		return validationRecords, probs.Malformed("Error parsing onion-csr-01 CSR attributes: %s", err)
+	}
+	expectedNonce, err := base64.RawURLEncoding.DecodeString(challenge.Token)
+	if err != nil {
+		return validationRecords, probs.Malformed("Invalid challenge nonce")
+	}
+	if !bytes.Equal(caNonce, expectedNonce) {
+		return validationRecords, probs.Unauthorized("onion-csr-01 CSR's caSigningNonce does not match the challenge nonce")
+	}
+	if len(applicantNonce) < minApplicantNonceLength {
+		return validationRecords, probs.Unauthorized(fmt.Sprintf(
+			"onion-csr-01 CSR's applicantSigningNonce must be at least %d bytes", minApplicantNonceLength))
+	}
+	return validationRecords, nil
+}
+
+// onionCAASet returns the CAA records a client provided for the .onion name
+// hostname, after checking that they're signed by its hidden service key and
+// haven't expired. .onion names aren't in the DNS, so these take the place of
+// a CAA lookup. A nil CAASet is returned if no records were provided, which,
+// like an empty lookup, permits issuance.
+func onionCAASet(hostname string, onionCAA *core.OnionCAA, now time.Time) (*CAASet, []*dns.CAA, error) {
+	if onionCAA == nil {
+		return nil, nil, nil
+	}
+	onionKey, err := core.OnionPublicKey(hostname)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(onionCAA.Signature)
+	if err != nil {
+		return nil, nil, fmt.Errorf("CAA signature for %s is not valid base64url", hostname)
+	}
+	if !ed25519.Verify(onionKey, onionCAA.SignedData(), sig) {
+		return nil, nil, fmt.Errorf("CAA signature for %s is invalid", hostname)
+	}
+	if now.After(time.Unix(onionCAA.Expiry, 0)) {
+		return nil, nil, fmt.Errorf("CAA records for %s expired at %s", hostname, time.Unix(onionCAA.Expiry, 0).UTC())
+	}
+
+	var records []*dns.CAA
+	for _, line := range strings.Split(onionCAA.CAA, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(line) > 4 && strings.EqualFold(line[:4], "caa ") {
+			line = line[4:]
+		}
+		rr, err := dns.NewRR(fmt.Sprintf("%s. CAA %s", hostname, line))
+		if err != nil {
+			return nil, nil, fmt.Errorf("CAA records for %s were malformed: %s", hostname, err)
+		}
+		caa, ok := rr.(*dns.CAA)
+		if !ok {
+			return nil, nil, fmt.Errorf("CAA records for %s were malformed", hostname)
+		}
+		records = append(records, caa)
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return newCAASet(records), records, nil
+}
@@ -0,0 +1,179 @@
+package va
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/test"
+	vapb "github.com/letsencrypt/boulder/va/proto"
+)
+
+// onionCSR returns a base64url encoded CSR for names, signed by key, with
+// the given caSigningNonce and applicantSigningNonce attributes.
+func onionCSR(t *testing.T, key ed25519.PrivateKey, names []string, caNonce, applicantNonce []byte) string {
+	t.Helper()
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: names}, key)
+	test.AssertNotError(t, err, "creating CSR")
+
+	// crypto/x509 can't add attributes with OCTET STRING values, so they're
+	// added to the TBS CSR, which is then signed again.
+	var csr struct {
+		TBSCSR             asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		SignatureValue     asn1.BitString
+	}
+	_, err = asn1.Unmarshal(der, &csr)
+	test.AssertNotError(t, err, "parsing CSR")
+	var tbs tbsCertificateRequest
+	_, err = asn1.Unmarshal(csr.TBSCSR.FullBytes, &tbs)
+	test.AssertNotError(t, err, "parsing TBS CSR")
+	tbs.Raw = nil
+	for _, nonce := range []struct {
+		oid   asn1.ObjectIdentifier
+		value []byte
+	}{
+		{idCASigningNonce, caNonce},
+		{idApplicantSigningNonce, applicantNonce},
+	} {
+		value, err := asn1.Marshal(nonce.value)
+		test.AssertNotError(t, err, "marshaling nonce")
+		attr, err := asn1.Marshal(csrAttribute{Type: nonce.oid, Values: []asn1.RawValue{{FullBytes: value}}})
+		test.AssertNotError(t, err, "marshaling attribute")
+		tbs.RawAttributes = append(tbs.RawAttributes, asn1.RawValue{FullBytes: attr})
+	}
+	tbsDER, err := asn1.Marshal(tbs)
+	test.AssertNotError(t, err, "marshaling TBS CSR")
+	sig := ed25519.Sign(key, tbsDER)
+	csr.TBSCSR = asn1.RawValue{FullBytes: tbsDER}
+	csr.SignatureValue = asn1.BitString{Bytes: sig, BitLength: len(sig) * 8}
+	der, err = asn1.Marshal(csr)
+	test.AssertNotError(t, err, "marshaling CSR")
+	return base64.RawURLEncoding.EncodeToString(der)
+}
+
+func TestValidateOnionCSR01(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	onion := core.OnionAddress(pub)
+
+	chall := core.OnionCSRChallenge01(core.NewToken())
+	nonce, err := base64.RawURLEncoding.DecodeString(chall.Token)
+	test.AssertNotError(t, err, "decoding token")
+	applicantNonce := []byte("0123456789")
+
+	testCases := []struct {
+		name     string
+		response *core.OnionCSRResponse
+		prob     probs.ProblemType
+	}{
+		{
+			name:     "valid",
+			response: &core.OnionCSRResponse{CSR: onionCSR(t, key, []string{onion}, nonce, applicantNonce)},
+		},
+		{
+			name: "no response",
+			prob: probs.MalformedProblem,
+		},
+		{
+			name:     "not base64url",
+			response: &core.OnionCSRResponse{CSR: "!!"},
+			prob:     probs.MalformedProblem,
+		},
+		{
+			name:     "wrong key",
+			response: &core.OnionCSRResponse{CSR: onionCSR(t, otherKey, []string{onion}, nonce, applicantNonce)},
+			prob:     probs.UnauthorizedProblem,
+		},
+		{
+			name:     "name not requested",
+			response: &core.OnionCSRResponse{CSR: onionCSR(t, key, []string{"www." + onion}, nonce, applicantNonce)},
+			prob:     probs.UnauthorizedProblem,
+		},
+		{
+			name:     "wrong nonce",
+			response: &core.OnionCSRResponse{CSR: onionCSR(t, key, []string{onion}, []byte("wrong"), applicantNonce)},
+			prob:     probs.UnauthorizedProblem,
+		},
+		{
+			name:     "short applicant nonce",
+			response: &core.OnionCSRResponse{CSR: onionCSR(t, key, []string{onion}, nonce, []byte("short"))},
+			prob:     probs.UnauthorizedProblem,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chall := chall
+			chall.OnionCSRResponse = tc.response
+			records, prob := va.validateOnionCSR01(ctx, dnsi(onion), chall)
+			if tc.prob == "" {
+				test.Assert(t, prob == nil, "validation failed")
+				test.AssertDeepEquals(t, records, []core.ValidationRecord{{Hostname: onion}})
+			} else {
+				test.Assert(t, prob != nil, "validation succeeded")
+				test.AssertEquals(t, prob.Type, tc.prob)
+			}
+		})
+	}
+
+	_, prob := va.validateOnionCSR01(ctx, dnsi("example.com"), chall)
+	test.AssertEquals(t, prob.Type, probs.MalformedProblem)
+}
+
+func TestOnionCAA(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	onion := core.OnionAddress(pub)
+
+	signed := func(caa string, expiry time.Time, key ed25519.PrivateKey) *core.OnionCAA {
+		onionCAA := &core.OnionCAA{CAA: caa, Expiry: expiry.Unix()}
+		onionCAA.Signature = base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, onionCAA.SignedData()))
+		return onionCAA
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	future := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name     string
+		ident    string
+		onionCAA *core.OnionCAA
+		valid    bool
+	}{
+		{"no records", onion, nil, true},
+		{"permitted", onion, signed("caa 0 issue \"letsencrypt.org\"\ncaa 0 iodef \"mailto:admin@example.com\"", future, key), true},
+		{"forbidden", onion, signed(`caa 0 issue "example.com"`, future, key), false},
+		{"wildcard forbidden", "*." + onion, signed("caa 0 issue \"letsencrypt.org\"\ncaa 0 issuewild \";\"", future, key), false},
+		{"expired", onion, signed(`caa 0 issue "letsencrypt.org"`, time.Now().Add(-time.Hour), key), false},
+		{"bad signature", onion, signed(`caa 0 issue "letsencrypt.org"`, future, otherKey), false},
+		{"malformed", onion, signed("caa issue", future, key), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prob := va.checkCAA(ctx, dnsi(tc.ident), &caaParams{onionCAA: tc.onionCAA})
+			if tc.valid {
+				test.Assert(t, prob == nil, "CAA check failed")
+			} else {
+				test.Assert(t, prob != nil, "CAA check succeeded")
+				test.AssertEquals(t, prob.Type, probs.CAAProblem)
+			}
+		})
+	}
+
+	// Without the client's records, CAA can't be checked again later.
+	resp, err := va.IsCAAValid(ctx, &vapb.IsCAAValidRequest{Domain: &onion})
+	test.AssertNotError(t, err, "IsCAAValid failed")
+	test.Assert(t, resp.Problem != nil, "IsCAAValid permitted issuance for a .onion name")
+	test.AssertEquals(t, *resp.Problem.ProblemType, string(probs.CAAProblem))
+}
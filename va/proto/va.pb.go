@@ -142,6 +142,101 @@ func (x *IsCAAValidResponse) GetProblem() *proto1.ProblemDetails {
 	return nil
 }
 
+type IsCAAValidForNamesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Checks []*IsCAAValidRequest `protobuf:"bytes,1,rep,name=checks" json:"checks,omitempty"`
+}
+
+func (x *IsCAAValidForNamesRequest) Reset() {
+	*x = IsCAAValidForNamesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_va_proto_va_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IsCAAValidForNamesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IsCAAValidForNamesRequest) ProtoMessage() {}
+
+func (x *IsCAAValidForNamesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_va_proto_va_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IsCAAValidForNamesRequest.ProtoReflect.Descriptor instead.
+func (*IsCAAValidForNamesRequest) Descriptor() ([]byte, []int) {
+	return file_va_proto_va_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *IsCAAValidForNamesRequest) GetChecks() []*IsCAAValidRequest {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+// The results are in the same order as the checks in the request
+type IsCAAValidForNamesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*IsCAAValidResponse `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+}
+
+func (x *IsCAAValidForNamesResponse) Reset() {
+	*x = IsCAAValidForNamesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_va_proto_va_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IsCAAValidForNamesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IsCAAValidForNamesResponse) ProtoMessage() {}
+
+func (x *IsCAAValidForNamesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_va_proto_va_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IsCAAValidForNamesResponse.ProtoReflect.Descriptor instead.
+func (*IsCAAValidForNamesResponse) Descriptor() ([]byte, []int) {
+	return file_va_proto_va_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *IsCAAValidForNamesResponse) GetResults() []*IsCAAValidResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
 type PerformValidationRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -155,7 +250,7 @@ type PerformValidationRequest struct {
 func (x *PerformValidationRequest) Reset() {
 	*x = PerformValidationRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_va_proto_va_proto_msgTypes[2]
+		mi := &file_va_proto_va_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -168,7 +263,7 @@ func (x *PerformValidationRequest) String() string {
 func (*PerformValidationRequest) ProtoMessage() {}
 
 func (x *PerformValidationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_va_proto_va_proto_msgTypes[2]
+	mi := &file_va_proto_va_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -181,7 +276,7 @@ func (x *PerformValidationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PerformValidationRequest.ProtoReflect.Descriptor instead.
 func (*PerformValidationRequest) Descriptor() ([]byte, []int) {
-	return file_va_proto_va_proto_rawDescGZIP(), []int{2}
+	return file_va_proto_va_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *PerformValidationRequest) GetDomain() string {
@@ -217,7 +312,7 @@ type AuthzMeta struct {
 func (x *AuthzMeta) Reset() {
 	*x = AuthzMeta{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_va_proto_va_proto_msgTypes[3]
+		mi := &file_va_proto_va_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -230,7 +325,7 @@ func (x *AuthzMeta) String() string {
 func (*AuthzMeta) ProtoMessage() {}
 
 func (x *AuthzMeta) ProtoReflect() protoreflect.Message {
-	mi := &file_va_proto_va_proto_msgTypes[3]
+	mi := &file_va_proto_va_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -243,7 +338,7 @@ func (x *AuthzMeta) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AuthzMeta.ProtoReflect.Descriptor instead.
 func (*AuthzMeta) Descriptor() ([]byte, []int) {
-	return file_va_proto_va_proto_rawDescGZIP(), []int{3}
+	return file_va_proto_va_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *AuthzMeta) GetId() string {
@@ -272,7 +367,7 @@ type ValidationResult struct {
 func (x *ValidationResult) Reset() {
 	*x = ValidationResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_va_proto_va_proto_msgTypes[4]
+		mi := &file_va_proto_va_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -285,7 +380,7 @@ func (x *ValidationResult) String() string {
 func (*ValidationResult) ProtoMessage() {}
 
 func (x *ValidationResult) ProtoReflect() protoreflect.Message {
-	mi := &file_va_proto_va_proto_msgTypes[4]
+	mi := &file_va_proto_va_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -298,7 +393,7 @@ func (x *ValidationResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ValidationResult.ProtoReflect.Descriptor instead.
 func (*ValidationResult) Descriptor() ([]byte, []int) {
-	return file_va_proto_va_proto_rawDescGZIP(), []int{4}
+	return file_va_proto_va_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ValidationResult) GetRecords() []*proto1.ValidationRecord {
@@ -333,38 +428,53 @@ var file_va_proto_va_proto_rawDesc = []byte{
 	0x65, 0x12, 0x2e, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x6c, 0x65,
 	0x6d, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65,
-	0x6d, 0x22, 0x86, 0x01, 0x0a, 0x18, 0x50, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c,
-	0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16,
-	0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
-	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x2d, 0x0a, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65,
-	0x6e, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x63, 0x6f, 0x72, 0x65,
-	0x2e, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6c,
-	0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x23, 0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x76, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x4d,
-	0x65, 0x74, 0x61, 0x52, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x22, 0x31, 0x0a, 0x09, 0x41, 0x75,
-	0x74, 0x68, 0x7a, 0x4d, 0x65, 0x74, 0x61, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x22, 0x76, 0x0a,
-	0x10, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x12, 0x30, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03,
-	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65, 0x63, 0x6f,
-	0x72, 0x64, 0x73, 0x12, 0x30, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x73, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x50, 0x72, 0x6f,
-	0x62, 0x6c, 0x65, 0x6d, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x08, 0x70, 0x72, 0x6f,
-	0x62, 0x6c, 0x65, 0x6d, 0x73, 0x32, 0x4f, 0x0a, 0x02, 0x56, 0x41, 0x12, 0x49, 0x0a, 0x11, 0x50,
-	0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x1c, 0x2e, 0x76, 0x61, 0x2e, 0x50, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c,
-	0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14,
-	0x2e, 0x76, 0x61, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
-	0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x32, 0x44, 0x0a, 0x03, 0x43, 0x41, 0x41, 0x12, 0x3d, 0x0a,
-	0x0a, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x12, 0x15, 0x2e, 0x76, 0x61,
-	0x2e, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x16, 0x2e, 0x76, 0x61, 0x2e, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c,
-	0x69, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x29, 0x5a, 0x27,
-	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x74, 0x73, 0x65,
-	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64, 0x65, 0x72, 0x2f, 0x76,
-	0x61, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x6d, 0x22, 0x4a, 0x0a, 0x19, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x46,
+	0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d,
+	0x0a, 0x06, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x76, 0x61, 0x2e, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x06, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x22, 0x4e, 0x0a,
+	0x1a, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x6f, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x07, 0x72,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x76,
+	0x61, 0x2e, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x86, 0x01,
+	0x0a, 0x18, 0x50, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f,
+	0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61,
+	0x69, 0x6e, 0x12, 0x2d, 0x0a, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x68, 0x61,
+	0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67,
+	0x65, 0x12, 0x23, 0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0d, 0x2e, 0x76, 0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x4d, 0x65, 0x74, 0x61, 0x52,
+	0x05, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x22, 0x31, 0x0a, 0x09, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x4d,
+	0x65, 0x74, 0x61, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x72, 0x65, 0x67, 0x49, 0x44, 0x22, 0x76, 0x0a, 0x10, 0x56, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x30, 0x0a,
+	0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12,
+	0x30, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d,
+	0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d,
+	0x73, 0x32, 0x4f, 0x0a, 0x02, 0x56, 0x41, 0x12, 0x49, 0x0a, 0x11, 0x50, 0x65, 0x72, 0x66, 0x6f,
+	0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x2e, 0x76,
+	0x61, 0x2e, 0x50, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x76, 0x61, 0x2e,
+	0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x22, 0x00, 0x32, 0x9b, 0x01, 0x0a, 0x03, 0x43, 0x41, 0x41, 0x12, 0x3d, 0x0a, 0x0a, 0x49, 0x73,
+	0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x12, 0x15, 0x2e, 0x76, 0x61, 0x2e, 0x49, 0x73,
+	0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x16, 0x2e, 0x76, 0x61, 0x2e, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x55, 0x0a, 0x12, 0x49, 0x73, 0x43,
+	0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12,
+	0x1d, 0x2e, 0x76, 0x61, 0x2e, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x46,
+	0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e,
+	0x2e, 0x76, 0x61, 0x2e, 0x49, 0x73, 0x43, 0x41, 0x41, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x46, 0x6f,
+	0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c,
+	0x65, 0x74, 0x73, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x2f, 0x62, 0x6f, 0x75, 0x6c, 0x64,
+	0x65, 0x72, 0x2f, 0x76, 0x61, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 }
 
 var (
@@ -379,32 +489,38 @@ func file_va_proto_va_proto_rawDescGZIP() []byte {
 	return file_va_proto_va_proto_rawDescData
 }
 
-var file_va_proto_va_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_va_proto_va_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_va_proto_va_proto_goTypes = []interface{}{
-	(*IsCAAValidRequest)(nil),        // 0: va.IsCAAValidRequest
-	(*IsCAAValidResponse)(nil),       // 1: va.IsCAAValidResponse
-	(*PerformValidationRequest)(nil), // 2: va.PerformValidationRequest
-	(*AuthzMeta)(nil),                // 3: va.AuthzMeta
-	(*ValidationResult)(nil),         // 4: va.ValidationResult
-	(*proto1.ProblemDetails)(nil),    // 5: core.ProblemDetails
-	(*proto1.Challenge)(nil),         // 6: core.Challenge
-	(*proto1.ValidationRecord)(nil),  // 7: core.ValidationRecord
+	(*IsCAAValidRequest)(nil),          // 0: va.IsCAAValidRequest
+	(*IsCAAValidResponse)(nil),         // 1: va.IsCAAValidResponse
+	(*IsCAAValidForNamesRequest)(nil),  // 2: va.IsCAAValidForNamesRequest
+	(*IsCAAValidForNamesResponse)(nil), // 3: va.IsCAAValidForNamesResponse
+	(*PerformValidationRequest)(nil),   // 4: va.PerformValidationRequest
+	(*AuthzMeta)(nil),                  // 5: va.AuthzMeta
+	(*ValidationResult)(nil),           // 6: va.ValidationResult
+	(*proto1.ProblemDetails)(nil),      // 7: core.ProblemDetails
+	(*proto1.Challenge)(nil),           // 8: core.Challenge
+	(*proto1.ValidationRecord)(nil),    // 9: core.ValidationRecord
 }
 var file_va_proto_va_proto_depIdxs = []int32{
-	5, // 0: va.IsCAAValidResponse.problem:type_name -> core.ProblemDetails
-	6, // 1: va.PerformValidationRequest.challenge:type_name -> core.Challenge
-	3, // 2: va.PerformValidationRequest.authz:type_name -> va.AuthzMeta
-	7, // 3: va.ValidationResult.records:type_name -> core.ValidationRecord
-	5, // 4: va.ValidationResult.problems:type_name -> core.ProblemDetails
-	2, // 5: va.VA.PerformValidation:input_type -> va.PerformValidationRequest
-	0, // 6: va.CAA.IsCAAValid:input_type -> va.IsCAAValidRequest
-	4, // 7: va.VA.PerformValidation:output_type -> va.ValidationResult
-	1, // 8: va.CAA.IsCAAValid:output_type -> va.IsCAAValidResponse
-	7, // [7:9] is the sub-list for method output_type
-	5, // [5:7] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	7,  // 0: va.IsCAAValidResponse.problem:type_name -> core.ProblemDetails
+	0,  // 1: va.IsCAAValidForNamesRequest.checks:type_name -> va.IsCAAValidRequest
+	1,  // 2: va.IsCAAValidForNamesResponse.results:type_name -> va.IsCAAValidResponse
+	8,  // 3: va.PerformValidationRequest.challenge:type_name -> core.Challenge
+	5,  // 4: va.PerformValidationRequest.authz:type_name -> va.AuthzMeta
+	9,  // 5: va.ValidationResult.records:type_name -> core.ValidationRecord
+	7,  // 6: va.ValidationResult.problems:type_name -> core.ProblemDetails
+	4,  // 7: va.VA.PerformValidation:input_type -> va.PerformValidationRequest
+	0,  // 8: va.CAA.IsCAAValid:input_type -> va.IsCAAValidRequest
+	2,  // 9: va.CAA.IsCAAValidForNames:input_type -> va.IsCAAValidForNamesRequest
+	6,  // 10: va.VA.PerformValidation:output_type -> va.ValidationResult
+	1,  // 11: va.CAA.IsCAAValid:output_type -> va.IsCAAValidResponse
+	3,  // 12: va.CAA.IsCAAValidForNames:output_type -> va.IsCAAValidForNamesResponse
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_va_proto_va_proto_init() }
@@ -438,7 +554,7 @@ func file_va_proto_va_proto_init() {
 			}
 		}
 		file_va_proto_va_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PerformValidationRequest); i {
+			switch v := v.(*IsCAAValidForNamesRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -450,7 +566,7 @@ func file_va_proto_va_proto_init() {
 			}
 		}
 		file_va_proto_va_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AuthzMeta); i {
+			switch v := v.(*IsCAAValidForNamesResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -462,6 +578,30 @@ func file_va_proto_va_proto_init() {
 			}
 		}
 		file_va_proto_va_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PerformValidationRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_va_proto_va_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthzMeta); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_va_proto_va_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ValidationResult); i {
 			case 0:
 				return &v.state
@@ -480,7 +620,7 @@ func file_va_proto_va_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_va_proto_va_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -579,6 +719,9 @@ var _VA_serviceDesc = grpc.ServiceDesc{
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type CAAClient interface {
 	IsCAAValid(ctx context.Context, in *IsCAAValidRequest, opts ...grpc.CallOption) (*IsCAAValidResponse, error)
+	// IsCAAValidForNames performs several CAA checks at once, sharing the DNS
+	// lookups for names they have in common.
+	IsCAAValidForNames(ctx context.Context, in *IsCAAValidForNamesRequest, opts ...grpc.CallOption) (*IsCAAValidForNamesResponse, error)
 }
 
 type cAAClient struct {
@@ -598,9 +741,21 @@ func (c *cAAClient) IsCAAValid(ctx context.Context, in *IsCAAValidRequest, opts
 	return out, nil
 }
 
+func (c *cAAClient) IsCAAValidForNames(ctx context.Context, in *IsCAAValidForNamesRequest, opts ...grpc.CallOption) (*IsCAAValidForNamesResponse, error) {
+	out := new(IsCAAValidForNamesResponse)
+	err := c.cc.Invoke(ctx, "/va.CAA/IsCAAValidForNames", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CAAServer is the server API for CAA service.
 type CAAServer interface {
 	IsCAAValid(context.Context, *IsCAAValidRequest) (*IsCAAValidResponse, error)
+	// IsCAAValidForNames performs several CAA checks at once, sharing the DNS
+	// lookups for names they have in common.
+	IsCAAValidForNames(context.Context, *IsCAAValidForNamesRequest) (*IsCAAValidForNamesResponse, error)
 }
 
 // UnimplementedCAAServer can be embedded to have forward compatible implementations.
@@ -610,6 +765,9 @@ type UnimplementedCAAServer struct {
 func (*UnimplementedCAAServer) IsCAAValid(context.Context, *IsCAAValidRequest) (*IsCAAValidResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IsCAAValid not implemented")
 }
+func (*UnimplementedCAAServer) IsCAAValidForNames(context.Context, *IsCAAValidForNamesRequest) (*IsCAAValidForNamesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsCAAValidForNames not implemented")
+}
 
 func RegisterCAAServer(s *grpc.Server, srv CAAServer) {
 	s.RegisterService(&_CAA_serviceDesc, srv)
@@ -633,6 +791,24 @@ func _CAA_IsCAAValid_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CAA_IsCAAValidForNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsCAAValidForNamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CAAServer).IsCAAValidForNames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/va.CAA/IsCAAValidForNames",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CAAServer).IsCAAValidForNames(ctx, req.(*IsCAAValidForNamesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _CAA_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "va.CAA",
 	HandlerType: (*CAAServer)(nil),
@@ -641,6 +817,10 @@ var _CAA_serviceDesc = grpc.ServiceDesc{
 			MethodName: "IsCAAValid",
 			Handler:    _CAA_IsCAAValid_Handler,
 		},
+		{
+			MethodName: "IsCAAValidForNames",
+			Handler:    _CAA_IsCAAValidForNames_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "va/proto/va.proto",
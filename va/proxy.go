@@ -0,0 +1,324 @@
+package va
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// proxyError is returned when a validation proxy fails to connect to the
+// validation server on the VA's behalf. It's wrapped in a "dial" net.OpError
+// so that it's treated like any other failure to connect, e.g. by the HTTP-01
+// IPv4 fallback.
+type proxyError struct {
+	detail string
+}
+
+func (e *proxyError) Error() string {
+	return fmt.Sprintf("Validation proxy failed to connect: %s", e.detail)
+}
+
+// proxyDialer connects to validation servers through an HTTP(S) proxy, using
+// the CONNECT method, or through a SOCKS5 proxy. Proxies are given the
+// address the VA resolved for the validation server, so DNS lookups are still
+// made by the VA rather than from the proxy's network perspective.
+type proxyDialer struct {
+	proxyURL *url.URL
+	// proxyAddr is the host:port of the proxy, including the default port for
+	// its scheme if the URL didn't specify one.
+	proxyAddr string
+}
+
+// newProxyDialer returns a proxyDialer for the proxy at proxyURL, which must
+// have an "http", "https" or "socks5" scheme and may contain credentials to
+// authenticate to the proxy with.
+func newProxyDialer(proxyURL string) (*proxyDialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing validation proxy URL: %s", err)
+	}
+	var defaultPort string
+	switch u.Scheme {
+	case "http":
+		defaultPort = "80"
+	case "https":
+		defaultPort = "443"
+	case "socks5":
+		defaultPort = "1080"
+	default:
+		return nil, fmt.Errorf("validation proxy URL %q has unsupported scheme %q", redactURL(u), u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("validation proxy URL %q has no host", redactURL(u))
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return &proxyDialer{
+		proxyURL:  u,
+		proxyAddr: net.JoinHostPort(u.Hostname(), port),
+	}, nil
+}
+
+// String returns the proxy's URL without its credentials.
+func (p *proxyDialer) String() string {
+	return redactURL(p.proxyURL)
+}
+
+// redactURL returns u as a string, without any credentials it contains.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	return redacted.String()
+}
+
+// DialContext connects to the proxy and has it open a connection to addr,
+// which must be an IP address and port. The returned connection is tunneled
+// to addr through the proxy.
+func (p *proxyDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		// Default KeepAlive - see Golang src/net/http/transport.go DefaultTransport
+		KeepAlive: 30 * time.Second,
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", p.proxyAddr)
+	if err != nil {
+		// Failing to reach the proxy isn't the validation server's fault, so
+		// don't report it as though it were.
+		return nil, p.dialError(network, fmt.Errorf("reaching proxy: %s", err))
+	}
+
+	// The handshakes with the proxy are bounded by the dial's deadline.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	conn, err = p.handshake(conn, addr)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// handshake asks the proxy connected to by conn to open a tunnel to addr. If
+// it fails, conn is closed.
+func (p *proxyDialer) handshake(conn net.Conn, addr string) (net.Conn, error) {
+	tunnel := conn
+	var err error
+	switch p.proxyURL.Scheme {
+	case "https":
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: p.proxyURL.Hostname()})
+		err = tlsConn.Handshake()
+		if err == nil {
+			tunnel, err = p.connect(tlsConn, addr)
+		}
+	case "http":
+		tunnel, err = p.connect(conn, addr)
+	case "socks5":
+		err = p.socks5(conn, addr)
+	}
+	if err != nil {
+		_ = conn.Close()
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: netErr}
+		}
+		return nil, p.dialError("tcp", err)
+	}
+	return tunnel, nil
+}
+
+func (p *proxyDialer) dialError(network string, err error) error {
+	return &net.OpError{Op: "dial", Net: network, Err: &proxyError{detail: err.Error()}}
+}
+
+// bufferedConn is a net.Conn whose reads are served from a bufio.Reader,
+// which may hold data the proxy sent after its response to a CONNECT request.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// connect sends an HTTP CONNECT request for addr to the proxy connected to by
+// conn, and returns a connection to read from and write to addr through.
+func (p *proxyDialer) connect(conn net.Conn, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := p.proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	err := req.Write(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	// A successful response to a CONNECT request has no body, and the tunnel
+	// begins immediately after it.
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, errors.New(resp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// SOCKS5 protocol constants, from RFC 1928 and RFC 1929.
+const (
+	socks5Version         = 0x05
+	socks5AuthNone        = 0x00
+	socks5AuthPassword    = 0x02
+	socks5CmdConnect      = 0x01
+	socks5AddrIPv4        = 0x01
+	socks5AddrDomain      = 0x03
+	socks5AddrIPv6        = 0x04
+	socks5PasswordVersion = 0x01
+)
+
+// socks5Replies describes the failures a SOCKS5 proxy may reply with.
+var socks5Replies = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// socks5 asks the SOCKS5 proxy connected to by conn to connect to addr,
+// authenticating with a username and password if the proxy URL has them.
+func (p *proxyDialer) socks5(conn net.Conn, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", portStr)
+	}
+
+	method := byte(socks5AuthNone)
+	if p.proxyURL.User != nil {
+		method = socks5AuthPassword
+	}
+	_, err = conn.Write([]byte{socks5Version, 1, method})
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(conn, buf)
+	if err != nil {
+		return err
+	}
+	if buf[0] != socks5Version {
+		return fmt.Errorf("unexpected SOCKS version %d", buf[0])
+	}
+	if buf[1] != method {
+		return errors.New("no acceptable SOCKS authentication method")
+	}
+
+	if method == socks5AuthPassword {
+		username := p.proxyURL.User.Username()
+		password, _ := p.proxyURL.User.Password()
+		if len(username) > 255 || len(password) > 255 {
+			return errors.New("SOCKS username or password too long")
+		}
+		auth := []byte{socks5PasswordVersion, byte(len(username))}
+		auth = append(auth, username...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		_, err = conn.Write(auth)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadFull(conn, buf)
+		if err != nil {
+			return err
+		}
+		if buf[1] != 0 {
+			return errors.New("SOCKS authentication failed")
+		}
+	}
+
+	req := []byte{socks5Version, socks5CmdConnect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return fmt.Errorf("hostname %q too long", host)
+		}
+		req = append(req, socks5AddrDomain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socks5AddrIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socks5AddrIPv6)
+		req = append(req, ip.To16()...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+	_, err = conn.Write(req)
+	if err != nil {
+		return err
+	}
+
+	// The reply is the version, reply code, a reserved byte and the address
+	// the proxy bound, which is discarded.
+	header := make([]byte, 4)
+	_, err = io.ReadFull(conn, header)
+	if err != nil {
+		return err
+	}
+	if header[0] != socks5Version {
+		return fmt.Errorf("unexpected SOCKS version %d", header[0])
+	}
+	if header[1] != 0 {
+		if reply, ok := socks5Replies[header[1]]; ok {
+			return errors.New(reply)
+		}
+		return fmt.Errorf("unknown SOCKS failure %d", header[1])
+	}
+	var addrLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		addrLen = net.IPv4len
+	case socks5AddrIPv6:
+		addrLen = net.IPv6len
+	case socks5AddrDomain:
+		_, err = io.ReadFull(conn, buf[:1])
+		if err != nil {
+			return err
+		}
+		addrLen = int(buf[0])
+	default:
+		return fmt.Errorf("unknown SOCKS address type %d", header[3])
+	}
+	// Discard the bound address and port.
+	_, err = io.ReadFull(conn, make([]byte, addrLen+2))
+	return err
+}
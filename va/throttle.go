@@ -0,0 +1,103 @@
+package va
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TargetRateLimit limits how often the VA contacts each validation target, so
+// that a misbehaving client can't use it to hammer one host with validation
+// attempts. Targets are identified both by hostname and by the IP addresses
+// the VA connects to. A zero TargetRateLimit places no limit.
+type TargetRateLimit struct {
+	// Burst is the number of validation attempts a target may receive in
+	// quick succession.
+	Burst int
+	// Interval is how long it takes a target to earn back one validation
+	// attempt after its burst has been used.
+	Interval time.Duration
+}
+
+// throttledError is returned when a validation attempt would exceed the
+// TargetRateLimit of its target.
+type throttledError struct {
+	target string
+}
+
+func (e *throttledError) Error() string {
+	return fmt.Sprintf("Validation throttled: too many recent validation attempts for %s", e.target)
+}
+
+// targetLimiter implements a TargetRateLimit with a token bucket per target.
+// Each bucket is represented by the time at which it'll be full again, so
+// buckets which are full don't need to be stored. A nil *targetLimiter places
+// no limit.
+type targetLimiter struct {
+	clk       clock.Clock
+	burst     int
+	interval  time.Duration
+	throttled prometheus.Counter
+
+	mu sync.Mutex
+	// full maps targets to the time their bucket will be full again.
+	full      map[string]time.Time
+	lastSweep time.Time
+}
+
+// newTargetLimiter returns a targetLimiter implementing limit, or nil if the
+// limit doesn't restrict anything. throttled is incremented for every refused
+// validation attempt.
+func newTargetLimiter(limit TargetRateLimit, clk clock.Clock, throttled prometheus.Counter) *targetLimiter {
+	if limit.Burst <= 0 || limit.Interval <= 0 {
+		return nil
+	}
+	return &targetLimiter{
+		clk:       clk,
+		burst:     limit.Burst,
+		interval:  limit.Interval,
+		throttled: throttled,
+		full:      make(map[string]time.Time),
+		lastSweep: clk.Now(),
+	}
+}
+
+// allow takes a token from target's bucket, returning a throttledError if
+// the bucket is empty.
+func (l *targetLimiter) allow(target string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clk.Now()
+	window := l.interval * time.Duration(l.burst)
+	if now.Sub(l.lastSweep) >= window {
+		// Forget the targets whose buckets have refilled since they were last
+		// used, so that the map only holds recently contacted targets.
+		for t, full := range l.full {
+			if !full.After(now) {
+				delete(l.full, t)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	full, ok := l.full[target]
+	if !ok || full.Before(now) {
+		full = now
+	}
+	// Taking a token pushes back the time the bucket will be full by one
+	// interval, and the bucket is empty if that's more than a window away.
+	full = full.Add(l.interval)
+	if full.Sub(now) > window {
+		l.throttled.Inc()
+		return &throttledError{target: target}
+	}
+	l.full[target] = full
+	return nil
+}
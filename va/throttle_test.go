@@ -0,0 +1,70 @@
+package va
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestTargetLimiter(t *testing.T) {
+	test.Assert(t, newTargetLimiter(TargetRateLimit{}, clock.NewFake(), nil) == nil, "zero limit created a limiter")
+
+	fc := clock.NewFake()
+	throttled := prometheus.NewCounter(prometheus.CounterOpts{Name: "throttled"})
+	l := newTargetLimiter(TargetRateLimit{Burst: 2, Interval: time.Minute}, fc, throttled)
+
+	test.AssertNotError(t, l.allow("example.com"), "first attempt throttled")
+	test.AssertNotError(t, l.allow("example.com"), "second attempt throttled")
+	err := l.allow("example.com")
+	test.AssertError(t, err, "attempt beyond burst allowed")
+	test.AssertEquals(t, err.Error(), "Validation throttled: too many recent validation attempts for example.com")
+	test.AssertEquals(t, test.CountCounter(throttled), 1)
+
+	// Other targets have their own buckets.
+	test.AssertNotError(t, l.allow("127.0.0.1"), "attempt for another target throttled")
+
+	// One attempt is earned back every interval.
+	fc.Add(time.Minute)
+	test.AssertNotError(t, l.allow("example.com"), "attempt after an interval throttled")
+	test.AssertError(t, l.allow("example.com"), "second attempt after an interval allowed")
+
+	// Targets whose buckets have refilled are forgotten.
+	fc.Add(time.Hour)
+	test.AssertNotError(t, l.allow("example.net"), "attempt for a new target throttled")
+	test.AssertDeepEquals(t, l.full, map[string]time.Time{"example.net": fc.Now().Add(time.Minute)})
+
+	var nilLimiter *targetLimiter
+	test.AssertNotError(t, nilLimiter.allow("example.com"), "nil limiter throttled an attempt")
+}
+
+func TestValidationThrottled(t *testing.T) {
+	chall := core.HTTPChallenge01("")
+	setChallengeToken(&chall, core.NewToken())
+	hs := httpSrv(t, chall.Token)
+	defer hs.Close()
+
+	va, _ := setup(hs, 0, "", nil)
+	va.targetLimiter = newTargetLimiter(TargetRateLimit{Burst: 1, Interval: time.Hour}, clock.NewFake(), va.metrics.validationsThrottled)
+
+	_, prob := va.validate(ctx, dnsi("localhost"), 1, chall)
+	test.Assert(t, prob == nil, "first validation failed")
+
+	// The hostname's attempt has been used.
+	_, prob = va.validate(ctx, dnsi("localhost"), 1, chall)
+	test.Assert(t, prob != nil, "second validation of the hostname succeeded")
+	test.AssertEquals(t, prob.Type, probs.RateLimitedProblem)
+	test.AssertEquals(t, prob.Detail, "Validation throttled: too many recent validation attempts for localhost")
+
+	// So has the attempt of the IP address every other hostname resolves to.
+	_, prob = va.validate(ctx, dnsi("other.localhost"), 1, chall)
+	test.Assert(t, prob != nil, "validation of another hostname with the same IP succeeded")
+	test.AssertEquals(t, prob.Type, probs.RateLimitedProblem)
+	test.AssertContains(t, prob.Detail, "Validation throttled: too many recent validation attempts for 127.0.0.1")
+	test.AssertEquals(t, test.CountCounter(va.metrics.validationsThrottled), 2)
+}
@@ -12,9 +12,13 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/miekg/dns"
 
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/identifier"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/probs"
 )
 
@@ -47,6 +51,9 @@ func certNames(cert *x509.Certificate) []string {
 		names = append(names, cert.Subject.CommonName)
 	}
 	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
 	names = core.UniqueLowerNames(names)
 	for i, n := range names {
 		names[i] = replaceInvalidUTF8([]byte(n))
@@ -54,11 +61,25 @@ func certNames(cert *x509.Certificate) []string {
 	return names
 }
 
+// tlsCertCheck examines the certificates and connection state returned by a
+// successful TLS handshake with hostPort, and returns a problem if they do not
+// satisfy the challenge.
+type tlsCertCheck func(certs []*x509.Certificate, cs *tls.ConnectionState, hostPort string) *probs.ProblemDetails
+
+// tryGetTLSCerts connects to the first IPv6 address for the identifier, if
+// there is one, and then to the first IPv4 address, and runs check against the
+// certificates presented. If connecting to the IPv6 address fails, the IPv4
+// address is always tried as a fallback. If the connection succeeds but the
+// TLS handshake or check fails, the IPv4 address is only tried if
+// va.tlsALPNRetryOnCheckFailure is set. If the TLS-ALPN-01 challenge policy
+// disables the IPv4 fallback, only the first address is tried. Every address
+// tried before the final one is recorded in the validation record's
+// AddressesTried.
 func (va *ValidationAuthorityImpl) tryGetTLSCerts(ctx context.Context,
 	identifier identifier.ACMEIdentifier, challenge core.Challenge,
-	tlsConfig *tls.Config) ([]*x509.Certificate, *tls.ConnectionState, []core.ValidationRecord, *probs.ProblemDetails) {
+	tlsConfig *tls.Config, check tlsCertCheck) ([]core.ValidationRecord, *probs.ProblemDetails) {
 
-	allAddrs, err := va.getAddrs(ctx, identifier.Value)
+	allAddrs, err := va.addrsForHost(ctx, identifier.Value)
 	validationRecords := []core.ValidationRecord{
 		{
 			Hostname:          identifier.Value,
@@ -67,53 +88,55 @@ func (va *ValidationAuthorityImpl) tryGetTLSCerts(ctx context.Context,
 		},
 	}
 	if err != nil {
-		return nil, nil, validationRecords, detailedError(err)
+		return validationRecords, detailedError(err)
 	}
 	thisRecord := &validationRecords[0]
 
 	// Split the available addresses into v4 and v6 addresses
 	v4, v6 := availableAddresses(allAddrs)
-	addresses := append(v4, v6...)
-
-	// This shouldn't happen, but be defensive about it anyway
-	if len(addresses) < 1 {
-		return nil, nil, validationRecords, probs.Malformed("no IP addresses found for %q", identifier.Value)
-	}
 
-	// If there is at least one IPv6 address then try it first
+	// If there is at least one IPv6 address then try it first, followed by the
+	// first IPv4 address.
+	var candidates []net.IP
 	if len(v6) > 0 {
-		address := net.JoinHostPort(v6[0].String(), thisRecord.Port)
-		thisRecord.AddressUsed = v6[0]
+		candidates = append(candidates, v6[0])
+	}
+	if len(v4) > 0 {
+		candidates = append(candidates, v4[0])
+	}
+	if len(candidates) > 1 && va.challengePolicies[core.ChallengeTypeTLSALPN01].DisableIPv4Fallback {
+		candidates = candidates[:1]
+	}
 
-		certs, cs, prob := va.getTLSCerts(ctx, address, identifier, challenge, tlsConfig)
+	// This shouldn't happen, but be defensive about it anyway
+	if len(candidates) < 1 {
+		return validationRecords, probs.Malformed("no IP addresses found for %q", identifier.Value)
+	}
 
-		// If there is no problem, return immediately
-		if err == nil {
-			return certs, cs, validationRecords, prob
+	var prob *probs.ProblemDetails
+	for i, address := range candidates {
+		if i > 0 {
+			// Note that we tried an address and fall back to the next one
+			thisRecord.AddressesTried = append(thisRecord.AddressesTried, thisRecord.AddressUsed)
+			va.metrics.ipv4FallbackCounter.Inc()
 		}
+		thisRecord.AddressUsed = address
+		hostPort := net.JoinHostPort(address.String(), thisRecord.Port)
 
-		// Otherwise, we note that we tried an address and fall back to trying IPv4
-		thisRecord.AddressesTried = append(thisRecord.AddressesTried, thisRecord.AddressUsed)
-		va.metrics.ipv4FallbackCounter.Inc()
-	}
-
-	// If there are no IPv4 addresses and we tried an IPv6 address return
-	// an error - there's nothing left to try
-	if len(v4) == 0 && len(thisRecord.AddressesTried) > 0 {
-		return nil, nil, validationRecords, probs.Malformed("Unable to contact %q at %q, no IPv4 addresses to try as fallback",
-			thisRecord.Hostname, thisRecord.AddressesTried[0])
-	} else if len(v4) == 0 && len(thisRecord.AddressesTried) == 0 {
-		// It shouldn't be possible that there are no IPv4 addresses and no previous
-		// attempts at an IPv6 address connection but be defensive about it anyway
-		return nil, nil, validationRecords, probs.Malformed("No IP addresses found for %q", thisRecord.Hostname)
+		var certs []*x509.Certificate
+		var cs *tls.ConnectionState
+		certs, cs, prob = va.getTLSCerts(ctx, hostPort, identifier, challenge, tlsConfig)
+		if prob == nil {
+			prob = check(certs, cs, hostPort)
+			if prob == nil {
+				return validationRecords, nil
+			}
+		}
+		if prob.Type != probs.ConnectionProblem && !va.tlsALPNRetryOnCheckFailure {
+			return validationRecords, prob
+		}
 	}
-
-	// Otherwise if there are no IPv6 addresses, or there was an error
-	// talking to the first IPv6 address, try the first IPv4 address
-	thisRecord.AddressUsed = v4[0]
-	certs, cs, prob := va.getTLSCerts(ctx, net.JoinHostPort(v4[0].String(), thisRecord.Port),
-		identifier, challenge, tlsConfig)
-	return certs, cs, validationRecords, prob
+	return validationRecords, prob
 }
 
 func (va *ValidationAuthorityImpl) getTLSCerts(
@@ -144,27 +167,48 @@ func (va *ValidationAuthorityImpl) getTLSCerts(
 		return nil, nil, probs.Unauthorized(fmt.Sprintf("No certs presented for %s challenge", challenge.Type))
 	}
 	for i, cert := range certs {
-		va.log.AuditInfof("%s challenge for %s received certificate (%d of %d): cert=[%s]",
+		blog.ForContext(ctx, va.log).AuditInfof("%s challenge for %s received certificate (%d of %d): cert=[%s]",
 			challenge.Type, identifier.Value, i+1, len(certs), hex.EncodeToString(cert.Raw))
 	}
 	return certs, &cs, nil
 }
 
 // tlsDial does the equivalent of tls.Dial, but obeying a context. Once
-// tls.DialContextWithDialer is available, switch to that.
+// tls.DialContextWithDialer is available, switch to that. Unless a TLS-ALPN-01
+// read timeout is configured, the connect timeout bounds the handshake too. If
+// the VA has a validation proxy, the connection is made through it.
 func (va *ValidationAuthorityImpl) tlsDial(ctx context.Context, hostPort string, config *tls.Config) (*tls.Conn, error) {
-	ctx, cancel := context.WithTimeout(ctx, va.singleDialTimeout)
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return nil, err
+	}
+	err = va.targetLimiter.allow(host)
+	if err != nil {
+		return nil, err
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, va.connectTimeout(core.ChallengeTypeTLSALPN01))
 	defer cancel()
-	dialer := &net.Dialer{}
-	netConn, err := dialer.DialContext(ctx, "tcp", hostPort)
+	var netConn net.Conn
+	if va.proxy != nil {
+		netConn, err = va.proxy.DialContext(dialCtx, "tcp", hostPort)
+	} else {
+		dialer := &net.Dialer{}
+		netConn, err = dialer.DialContext(dialCtx, "tcp", hostPort)
+	}
 	if err != nil {
 		return nil, err
 	}
-	deadline, ok := ctx.Deadline()
+	deadline, ok := dialCtx.Deadline()
 	if !ok {
-		va.log.AuditErr("tlsDial was called without a deadline")
+		blog.ForContext(ctx, va.log).AuditErr("tlsDial was called without a deadline")
 		return nil, fmt.Errorf("tlsDial was called without a deadline")
 	}
+	if readTimeout := va.readTimeout(core.ChallengeTypeTLSALPN01); readTimeout > 0 {
+		deadline = time.Now().Add(readTimeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+	}
 	_ = netConn.SetDeadline(deadline)
 	conn := tls.Client(netConn, config)
 	err = conn.Handshake()
@@ -174,41 +218,87 @@ func (va *ValidationAuthorityImpl) tlsDial(ctx context.Context, hostPort string,
 	return conn, nil
 }
 
+// tlsALPN01ServerName returns the SNI value to send when validating
+// identifier. It's empty if no SNI should be sent.
+func (va *ValidationAuthorityImpl) tlsALPN01ServerName(identifier identifier.ACMEIdentifier) (string, *probs.ProblemDetails) {
+	switch identifier.Type {
+	case "dns":
+		return identifier.Value, nil
+	case "ip":
+		if net.ParseIP(identifier.Value) == nil {
+			return "", probs.Malformed("Invalid IP address identifier %q", identifier.Value)
+		}
+		if va.challengePolicies[core.ChallengeTypeTLSALPN01].OmitIPSNI {
+			return "", nil
+		}
+		// An IP address can't be sent as an SNI value, so RFC 8738 Section 6
+		// uses the address's reverse mapping domain name instead.
+		reverse, err := dns.ReverseAddr(identifier.Value)
+		if err != nil {
+			return "", probs.Malformed("Invalid IP address identifier %q", identifier.Value)
+		}
+		return strings.TrimSuffix(reverse, "."), nil
+	default:
+		va.log.Info(fmt.Sprintf("Identifier type for TLS-ALPN-01 was not DNS or IP: %s", identifier))
+		return "", probs.Malformed("Identifier type for TLS-ALPN-01 was not DNS or IP")
+	}
+}
+
 func (va *ValidationAuthorityImpl) validateTLSALPN01(ctx context.Context, identifier identifier.ACMEIdentifier, challenge core.Challenge) ([]core.ValidationRecord, *probs.ProblemDetails) {
-	if identifier.Type != "dns" {
-		va.log.Info(fmt.Sprintf("Identifier type for TLS-ALPN-01 was not DNS: %s", identifier))
-		return nil, probs.Malformed("Identifier type for TLS-ALPN-01 was not DNS")
+	serverName, prob := va.tlsALPN01ServerName(identifier)
+	if prob != nil {
+		return nil, prob
 	}
 
-	certs, cs, validationRecords, problem := va.tryGetTLSCerts(ctx, identifier, challenge, &tls.Config{
+	return va.tryGetTLSCerts(ctx, identifier, challenge, &tls.Config{
 		NextProtos: []string{ACMETLS1Protocol},
-		ServerName: identifier.Value,
+		ServerName: serverName,
+	}, func(certs []*x509.Certificate, cs *tls.ConnectionState, hostPort string) *probs.ProblemDetails {
+		return va.checkTLSALPN01Certs(identifier, challenge, certs, cs, hostPort)
 	})
-	if problem != nil {
-		return validationRecords, problem
+}
+
+// tlsALPN01CertMatches returns true if cert's subject alternative names are
+// exactly the identifier: a single dNSName for a DNS identifier, or a single
+// iPAddress for an IP identifier.
+func tlsALPN01CertMatches(cert *x509.Certificate, ident identifier.ACMEIdentifier) bool {
+	if ident.Type == "ip" {
+		return len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 1 &&
+			cert.IPAddresses[0].Equal(net.ParseIP(ident.Value))
 	}
+	return len(cert.DNSNames) == 1 && strings.EqualFold(cert.DNSNames[0], ident.Value)
+}
 
+// checkTLSALPN01Certs verifies that the ALPN protocol negotiated with hostPort
+// and the certificate it presented satisfy the TLS-ALPN-01 challenge.
+func (va *ValidationAuthorityImpl) checkTLSALPN01Certs(
+	identifier identifier.ACMEIdentifier,
+	challenge core.Challenge,
+	certs []*x509.Certificate,
+	cs *tls.ConnectionState,
+	hostPort string,
+) *probs.ProblemDetails {
 	if !cs.NegotiatedProtocolIsMutual || cs.NegotiatedProtocol != ACMETLS1Protocol {
 		errText := fmt.Sprintf(
 			"Cannot negotiate ALPN protocol %q for %s challenge",
 			ACMETLS1Protocol,
 			core.ChallengeTypeTLSALPN01,
 		)
-		return validationRecords, probs.Unauthorized(errText)
+		return probs.Unauthorized(errText)
 	}
 
 	leafCert := certs[0]
 
-	// Verify SNI - certificate returned must be issued only for the domain we are verifying.
-	if len(leafCert.DNSNames) != 1 || !strings.EqualFold(leafCert.DNSNames[0], identifier.Value) {
-		hostPort := net.JoinHostPort(validationRecords[0].AddressUsed.String(), validationRecords[0].Port)
+	// Verify SNI - certificate returned must be issued only for the domain or
+	// IP address we are verifying.
+	if !tlsALPN01CertMatches(leafCert, identifier) {
 		names := certNames(leafCert)
 		errText := fmt.Sprintf(
 			"Incorrect validation certificate for %s challenge. "+
 				"Requested %s from %s. Received %d certificate(s), "+
 				"first certificate had names %q",
 			challenge.Type, identifier.Value, hostPort, len(certs), strings.Join(names, ", "))
-		return validationRecords, probs.Unauthorized(errText)
+		return probs.Unauthorized(errText)
 	}
 
 	// Verify key authorization in acmeValidation extension
@@ -223,22 +313,22 @@ func (va *ValidationAuthorityImpl) validateTLSALPN01(ctx context.Context, identi
 			if !ext.Critical {
 				errText := fmt.Sprintf("Incorrect validation certificate for %s challenge. "+
 					"acmeValidationV1 extension not critical", core.ChallengeTypeTLSALPN01)
-				return validationRecords, probs.Unauthorized(errText)
+				return probs.Unauthorized(errText)
 			}
 			var extValue []byte
 			rest, err := asn1.Unmarshal(ext.Value, &extValue)
 			if err != nil || len(rest) > 0 || len(h) != len(extValue) {
 				errText := fmt.Sprintf("Incorrect validation certificate for %s challenge. "+
 					"Malformed acmeValidationV1 extension value", core.ChallengeTypeTLSALPN01)
-				return validationRecords, probs.Unauthorized(errText)
+				return probs.Unauthorized(errText)
 			}
 			if subtle.ConstantTimeCompare(h[:], extValue) != 1 {
 				errText := fmt.Sprintf("Incorrect validation certificate for %s challenge. "+
 					"Expected acmeValidationV1 extension value %s for this challenge but got %s",
 					core.ChallengeTypeTLSALPN01, hex.EncodeToString(h[:]), hex.EncodeToString(extValue))
-				return validationRecords, probs.Unauthorized(errText)
+				return probs.Unauthorized(errText)
 			}
-			return validationRecords, nil
+			return nil
 		}
 	}
 
@@ -246,5 +336,5 @@ func (va *ValidationAuthorityImpl) validateTLSALPN01(ctx context.Context, identi
 		"Incorrect validation certificate for %s challenge. "+
 			"Missing acmeValidationV1 extension.",
 		core.ChallengeTypeTLSALPN01)
-	return validationRecords, probs.Unauthorized(errText)
+	return probs.Unauthorized(errText)
 }
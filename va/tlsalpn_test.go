@@ -155,6 +155,106 @@ func TestTLSALPN01FailIP(t *testing.T) {
 	test.AssertEquals(t, prob.Type, probs.MalformedProblem)
 }
 
+func TestTLSALPN01IP(t *testing.T) {
+	chall := tlsalpnChallenge()
+	shasum := sha256.Sum256([]byte(chall.ProvidedKeyAuthorization))
+	encHash, _ := asn1.Marshal(shasum[:])
+	template := tlsCertTemplate(nil)
+	template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	template.ExtraExtensions = []pkix.Extension{{Id: IdPeAcmeIdentifier, Critical: true, Value: encHash}}
+	certBytes, _ := x509.CreateCertificate(rand.Reader, template, template, &TheKey.PublicKey, &TheKey)
+	acmeCert := &tls.Certificate{Certificate: [][]byte{certBytes}, PrivateKey: &TheKey}
+	// The server only presents the certificate to clients sending the reverse
+	// mapping name of the address as their SNI.
+	hs := tlsalpn01SrvWithCert(t, chall, IdPeAcmeIdentifier, []string{"1.0.0.127.in-addr.arpa"}, acmeCert, acmeCert, 0)
+	defer hs.Close()
+	va, _ := setup(hs, 0, "", nil)
+
+	records, prob := va.validateChallenge(ctx, identifier.ACMEIdentifier{Type: identifier.IP, Value: "127.0.0.1"}, chall)
+	if prob != nil {
+		t.Fatalf("Validation failed: %v", prob)
+	}
+	test.AssertEquals(t, records[0].AddressUsed.String(), "127.0.0.1")
+
+	// A certificate for the address which also has a DNS name doesn't match.
+	template.DNSNames = []string{"localhost"}
+	certBytes, _ = x509.CreateCertificate(rand.Reader, template, template, &TheKey.PublicKey, &TheKey)
+	test.Assert(t, !tlsALPN01CertMatches(mustParseCert(t, certBytes), identifier.ACMEIdentifier{Type: identifier.IP, Value: "127.0.0.1"}),
+		"certificate with an extra DNS name matched IP identifier")
+
+	// When IP SNI is omitted, the server must present the certificate to
+	// clients sending no SNI.
+	template.DNSNames = nil
+	certBytes, _ = x509.CreateCertificate(rand.Reader, template, template, &TheKey.PublicKey, &TheKey)
+	acmeCert = &tls.Certificate{Certificate: [][]byte{certBytes}, PrivateKey: &TheKey}
+	va.challengePolicies = map[core.AcmeChallenge]ChallengePolicy{
+		core.ChallengeTypeTLSALPN01: {OmitIPSNI: true},
+	}
+	_, prob = va.validateChallenge(ctx, identifier.ACMEIdentifier{Type: identifier.IP, Value: "127.0.0.1"}, chall)
+	test.AssertNotNil(t, prob, "validation sent the reverse mapping name as SNI")
+	noSNI := httptest.NewUnstartedServer(http.DefaultServeMux)
+	noSNI.TLS = &tls.Config{
+		Certificates: []tls.Certificate{*acmeCert},
+		GetConfigForClient: func(clientHello *tls.ClientHelloInfo) (*tls.Config, error) {
+			if clientHello.ServerName != "" {
+				return nil, fmt.Errorf("unexpected SNI %q", clientHello.ServerName)
+			}
+			return nil, nil
+		},
+		NextProtos: []string{ACMETLS1Protocol},
+	}
+	noSNI.Config.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){
+		ACMETLS1Protocol: func(_ *http.Server, conn *tls.Conn, _ http.Handler) {
+			_ = conn.Close()
+		},
+	}
+	noSNI.StartTLS()
+	defer noSNI.Close()
+	va.tlsPort = getPort(noSNI)
+	_, prob = va.validateChallenge(ctx, identifier.ACMEIdentifier{Type: identifier.IP, Value: "127.0.0.1"}, chall)
+	if prob != nil {
+		t.Fatalf("Validation without SNI failed: %v", prob)
+	}
+}
+
+func TestTLSALPN01ServerName(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+	testCases := []struct {
+		ident     identifier.ACMEIdentifier
+		omitIPSNI bool
+		expected  string
+		valid     bool
+	}{
+		{identifier.DNSIdentifier("example.com"), false, "example.com", true},
+		{identifier.DNSIdentifier("example.com"), true, "example.com", true},
+		{identifier.ACMEIdentifier{Type: identifier.IP, Value: "127.0.0.1"}, false, "1.0.0.127.in-addr.arpa", true},
+		{identifier.ACMEIdentifier{Type: identifier.IP, Value: "::1"}, false, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa", true},
+		{identifier.ACMEIdentifier{Type: identifier.IP, Value: "127.0.0.1"}, true, "", true},
+		{identifier.ACMEIdentifier{Type: identifier.IP, Value: "not an address"}, true, "", false},
+		{identifier.ACMEIdentifier{Type: "email", Value: "a@example.com"}, false, "", false},
+	}
+	for _, tc := range testCases {
+		va.challengePolicies = map[core.AcmeChallenge]ChallengePolicy{
+			core.ChallengeTypeTLSALPN01: {OmitIPSNI: tc.omitIPSNI},
+		}
+		serverName, prob := va.tlsALPN01ServerName(tc.ident)
+		if !tc.valid {
+			test.AssertNotNil(t, prob, fmt.Sprintf("server name for %s", tc.ident.Value))
+			test.AssertEquals(t, prob.Type, probs.MalformedProblem)
+			continue
+		}
+		test.Assert(t, prob == nil, fmt.Sprintf("server name for %s failed", tc.ident.Value))
+		test.AssertEquals(t, serverName, tc.expected)
+	}
+}
+
+func mustParseCert(t *testing.T, der []byte) *x509.Certificate {
+	t.Helper()
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing certificate")
+	return cert
+}
+
 func slowTLSSrv() *httptest.Server {
 	server := httptest.NewUnstartedServer(http.DefaultServeMux)
 	server.TLS = &tls.Config{
@@ -203,6 +303,42 @@ func TestTLSALPNTimeoutAfterConnect(t *testing.T) {
 	}
 }
 
+func TestTLSALPN01ReadTimeout(t *testing.T) {
+	chall := tlsalpnChallenge()
+	// A server which accepts connections but never completes a handshake.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	test.AssertNotError(t, err, "listening")
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	va, _ := setup(nil, 0, "", nil)
+	va.tlsPort = l.Addr().(*net.TCPAddr).Port
+	va.challengePolicies = map[core.AcmeChallenge]ChallengePolicy{
+		core.ChallengeTypeTLSALPN01: {ReadTimeout: 50 * time.Millisecond},
+	}
+
+	// The configured read timeout applies well before the context's deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	started := time.Now()
+	_, prob := va.validateTLSALPN01(ctx, dnsi("slow.server"), chall)
+	took := time.Since(started)
+	test.AssertNotNil(t, prob, "Validation should've failed")
+	if took > time.Second {
+		t.Fatalf("TLS-ALPN-01 didn't time out after the read timeout (took %s)", took)
+	}
+	test.AssertEquals(t, prob.Detail, "Timeout during read (your server may be slow or overloaded)")
+	test.AssertEquals(t, failureMode(prob), "read_timeout")
+}
+
 func TestTLSALPN01DialTimeout(t *testing.T) {
 	chall := tlsalpnChallenge()
 	hs := slowTLSSrv()
@@ -389,6 +525,74 @@ func TestTLSALPN01Success(t *testing.T) {
 	test.AssertEquals(t, test.CountCounterVec("oid", IdPeAcmeIdentifierV1Obsolete.String(), va.metrics.tlsALPNOIDCounter), 1)
 }
 
+// ipv6TLSALPN01Srv listens on the IPv6 loopback address at the given port and
+// completes an acme-tls/1 handshake presenting cert with every client. It
+// returns a function which stops the server. The test is skipped if IPv6 is
+// unavailable.
+func ipv6TLSALPN01Srv(t *testing.T, port int, cert *tls.Certificate) func() {
+	t.Helper()
+	l, err := tls.Listen("tcp", net.JoinHostPort("::1", strconv.Itoa(port)), &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{ACMETLS1Protocol},
+	})
+	if err != nil {
+		t.Skipf("unable to listen on IPv6 loopback: %s", err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+	return func() { _ = l.Close() }
+}
+
+func TestTLSALPN01DualStackFallback(t *testing.T) {
+	chall := tlsalpnChallenge()
+	hs := tlsalpn01Srv(t, chall, IdPeAcmeIdentifier, 0, "ipv4.and.ipv6.localhost")
+	defer hs.Close()
+	va, _ := setup(hs, 0, "", nil)
+
+	// Nothing is listening on the IPv6 address, so the validation should fall
+	// back to the IPv4 address and succeed.
+	records, prob := va.validateTLSALPN01(ctx, dnsi("ipv4.and.ipv6.localhost"), chall)
+	test.Assert(t, prob == nil, fmt.Sprintf("validation failed: %v", prob))
+	test.AssertEquals(t, len(records), 1)
+	test.AssertEquals(t, records[0].AddressUsed.String(), "127.0.0.1")
+	test.AssertEquals(t, len(records[0].AddressesTried), 1)
+	test.AssertEquals(t, records[0].AddressesTried[0].String(), "::1")
+	test.AssertEquals(t, test.CountCounter(va.metrics.ipv4FallbackCounter), 1)
+}
+
+func TestTLSALPN01RetryOnCheckFailure(t *testing.T) {
+	chall := tlsalpnChallenge()
+	hs := tlsalpn01Srv(t, chall, IdPeAcmeIdentifier, 0, "ipv4.and.ipv6.localhost")
+	defer hs.Close()
+	// The IPv6 address accepts connections but presents a certificate without
+	// the acmeValidation extension.
+	stop := ipv6TLSALPN01Srv(t, getPort(hs), makeACert([]string{"ipv4.and.ipv6.localhost"}))
+	defer stop()
+
+	va, _ := setup(hs, 0, "", nil)
+	records, prob := va.validateTLSALPN01(ctx, dnsi("ipv4.and.ipv6.localhost"), chall)
+	test.Assert(t, prob != nil, "validation succeeded despite bad IPv6 certificate")
+	test.AssertEquals(t, prob.Type, probs.UnauthorizedProblem)
+	test.AssertEquals(t, records[0].AddressUsed.String(), "::1")
+	test.AssertEquals(t, len(records[0].AddressesTried), 0)
+
+	va, _ = setup(hs, 0, "", nil)
+	va.tlsALPNRetryOnCheckFailure = true
+	records, prob = va.validateTLSALPN01(ctx, dnsi("ipv4.and.ipv6.localhost"), chall)
+	test.Assert(t, prob == nil, fmt.Sprintf("validation failed: %v", prob))
+	test.AssertEquals(t, records[0].AddressUsed.String(), "127.0.0.1")
+	test.AssertEquals(t, len(records[0].AddressesTried), 1)
+	test.AssertEquals(t, records[0].AddressesTried[0].String(), "::1")
+}
+
 func TestValidateTLSALPN01BadChallenge(t *testing.T) {
 	chall := tlsalpnChallenge()
 	chall2 := chall
@@ -21,6 +21,7 @@ import (
 	"github.com/letsencrypt/boulder/canceled"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
@@ -30,6 +31,7 @@ import (
 	"github.com/letsencrypt/boulder/probs"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -72,6 +74,54 @@ var (
 type RemoteVA struct {
 	vapb.VAClient
 	Address string
+	// Perspective names the network perspective the remote VA validates from,
+	// and RIR is the Regional Internet Registry that perspective's addresses
+	// are allocated by. Both are optional unless a minimum number of distinct
+	// RIRs is required.
+	Perspective string
+	RIR         string
+	// Proxy, if set on a RemoteVA without a VAClient, is the URL of an HTTP(S)
+	// or SOCKS5 proxy to route validation traffic through. The perspective is
+	// then validated from in-process, connecting to validation servers through
+	// the proxy, rather than by a separate remote VA deployment. DNS lookups
+	// are still made by this VA.
+	Proxy string
+}
+
+// ChallengePolicy configures the timeouts, DNS retries and HTTP requests used
+// when validating one type of challenge. Zero values leave the VA's defaults in
+// place.
+type ChallengePolicy struct {
+	// ConnectTimeout bounds each connection made to the validation server by
+	// HTTP-01 and TLS-ALPN-01 challenges.
+	ConnectTimeout time.Duration
+	// ReadTimeout bounds how long the validation server has to respond once
+	// connected to: to send its response headers for HTTP-01, or to complete
+	// the TLS handshake for TLS-ALPN-01.
+	ReadTimeout time.Duration
+	// DNSTries is the number of times the challenge's DNS queries are tried if
+	// they fail with a temporary error.
+	DNSTries int
+	// DNSBackoff is how long to wait before retrying a DNS query. It doubles
+	// after every try.
+	DNSBackoff time.Duration
+	// DisableIPv4Fallback stops HTTP-01 and TLS-ALPN-01 challenges from
+	// retrying a host's IPv4 address when connecting to its IPv6 address
+	// fails, so that breakage specific to one address family isn't masked.
+	DisableIPv4Fallback bool
+	// UserAgent replaces the VA's User-Agent in HTTP-01 requests.
+	UserAgent string
+	// Accept is the Accept header sent with HTTP-01 requests, which is "*/*"
+	// by default.
+	Accept string
+	// MaxRedirects is the number of redirects HTTP-01 requests may follow,
+	// which is 10 by default.
+	MaxRedirects int
+	// OmitIPSNI stops TLS-ALPN-01 validations of IP identifiers from sending
+	// the address's reverse mapping name as their SNI, so that no SNI is sent
+	// at all, as earlier drafts of RFC 8738 specified. The certificate must
+	// contain the address as its only iPAddress SAN either way.
+	OmitIPSNI bool
 }
 
 type vaMetrics struct {
@@ -80,11 +130,14 @@ type vaMetrics struct {
 	remoteValidationTime                *prometheus.HistogramVec
 	remoteValidationFailures            prometheus.Counter
 	prospectiveRemoteValidationFailures prometheus.Counter
+	remoteValidationDisagreements       *prometheus.CounterVec
 	tlsALPNOIDCounter                   *prometheus.CounterVec
 	http01Fallbacks                     prometheus.Counter
 	http01Redirects                     prometheus.Counter
 	caaCounter                          *prometheus.CounterVec
 	ipv4FallbackCounter                 prometheus.Counter
+	validationFailures                  *prometheus.CounterVec
+	validationsThrottled                prometheus.Counter
 }
 
 func initMetrics(stats prometheus.Registerer) *vaMetrics {
@@ -124,6 +177,13 @@ func initMetrics(stats prometheus.Registerer) *vaMetrics {
 			Help: "Number of validations that would have failed due to remote VAs returning failure if consesus were enforced",
 		})
 	stats.MustRegister(prospectiveRemoteValidationFailures)
+	remoteValidationDisagreements := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "remote_validation_disagreements",
+			Help: "Number of remote VA results which disagreed with the primary VA's, by perspective, whether the remote VA succeeded or failed, and the failure mode of the VA which failed",
+		},
+		[]string{"perspective", "result", "failure_mode"})
+	stats.MustRegister(remoteValidationDisagreements)
 	tlsALPNOIDCounter := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "tls_alpn_oid_usage",
@@ -154,6 +214,16 @@ func initMetrics(stats prometheus.Registerer) *vaMetrics {
 		Help: "A counter of IPv4 fallbacks during TLS ALPN validation",
 	})
 	stats.MustRegister(ipv4FallbackCounter)
+	validationFailures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "local_validation_failures",
+		Help: "A counter of failed local validations labelled by challenge type and failure mode",
+	}, []string{"type", "failure_mode"})
+	stats.MustRegister(validationFailures)
+	validationsThrottled := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "validations_throttled",
+		Help: "A counter of validation attempts refused by the per-target rate limit",
+	})
+	stats.MustRegister(validationsThrottled)
 
 	return &vaMetrics{
 		validationTime:                      validationTime,
@@ -161,11 +231,14 @@ func initMetrics(stats prometheus.Registerer) *vaMetrics {
 		localValidationTime:                 localValidationTime,
 		remoteValidationFailures:            remoteValidationFailures,
 		prospectiveRemoteValidationFailures: prospectiveRemoteValidationFailures,
+		remoteValidationDisagreements:       remoteValidationDisagreements,
 		tlsALPNOIDCounter:                   tlsALPNOIDCounter,
 		http01Fallbacks:                     http01Fallbacks,
 		http01Redirects:                     http01Redirects,
 		caaCounter:                          caaCounter,
 		ipv4FallbackCounter:                 ipv4FallbackCounter,
+		validationFailures:                  validationFailures,
+		validationsThrottled:                validationsThrottled,
 	}
 }
 
@@ -173,7 +246,7 @@ func initMetrics(stats prometheus.Registerer) *vaMetrics {
 type ValidationAuthorityImpl struct {
 	log                blog.Logger
 	dnsClient          bdns.DNSClient
-	issuerDomain       string
+	issuerDomains      []string
 	httpPort           int
 	httpsPort          int
 	tlsPort            int
@@ -181,8 +254,16 @@ type ValidationAuthorityImpl struct {
 	clk                clock.Clock
 	remoteVAs          []RemoteVA
 	maxRemoteFailures  int
+	minRemoteRIRs      int
 	accountURIPrefixes []string
 	singleDialTimeout  time.Duration
+	challengePolicies  map[core.AcmeChallenge]ChallengePolicy
+	proxy              *proxyDialer
+	targetLimiter      *targetLimiter
+	// tlsALPNRetryOnCheckFailure controls whether a TLS-ALPN-01 validation
+	// that connects to a host's IPv6 address but fails the handshake, ALPN
+	// negotiation or certificate check is retried against its IPv4 address.
+	tlsALPNRetryOnCheckFailure bool
 
 	metrics *vaMetrics
 }
@@ -193,12 +274,17 @@ func NewValidationAuthorityImpl(
 	resolver bdns.DNSClient,
 	remoteVAs []RemoteVA,
 	maxRemoteFailures int,
+	minRemoteRIRs int,
 	userAgent string,
-	issuerDomain string,
+	issuerDomains []string,
 	stats prometheus.Registerer,
 	clk clock.Clock,
 	logger blog.Logger,
 	accountURIPrefixes []string,
+	tlsALPNRetryOnCheckFailure bool,
+	challengePolicies map[core.AcmeChallenge]ChallengePolicy,
+	validationProxy string,
+	targetRateLimit TargetRateLimit,
 ) (*ValidationAuthorityImpl, error) {
 	if pc.HTTPPort == 0 {
 		pc.HTTPPort = 80
@@ -210,33 +296,132 @@ func NewValidationAuthorityImpl(
 		pc.TLSPort = 443
 	}
 
+	if len(issuerDomains) == 0 {
+		return nil, errors.New("no CAA issuer domains configured")
+	}
+
 	if features.Enabled(features.CAAAccountURI) && len(accountURIPrefixes) == 0 {
 		return nil, errors.New("no account URI prefixes configured")
 	}
 
+	for challType := range challengePolicies {
+		if !challType.IsValid() {
+			return nil, fmt.Errorf("challenge policy configured for unknown challenge type %q", challType)
+		}
+	}
+
+	if minRemoteRIRs > 0 {
+		rirs := make(map[string]bool)
+		for _, rva := range remoteVAs {
+			if rva.RIR == "" {
+				return nil, fmt.Errorf("remote VA %q has no RIR, but %d distinct RIRs are required", rva.Address, minRemoteRIRs)
+			}
+			rirs[rva.RIR] = true
+		}
+		if len(rirs) < minRemoteRIRs {
+			return nil, fmt.Errorf("remote VAs span %d distinct RIRs, but %d are required", len(rirs), minRemoteRIRs)
+		}
+	}
+
 	va := &ValidationAuthorityImpl{
 		log:                logger,
 		dnsClient:          resolver,
-		issuerDomain:       issuerDomain,
+		issuerDomains:      issuerDomains,
 		httpPort:           pc.HTTPPort,
 		httpsPort:          pc.HTTPSPort,
 		tlsPort:            pc.TLSPort,
 		userAgent:          userAgent,
 		clk:                clk,
 		metrics:            initMetrics(stats),
-		remoteVAs:          remoteVAs,
 		maxRemoteFailures:  maxRemoteFailures,
+		minRemoteRIRs:      minRemoteRIRs,
 		accountURIPrefixes: accountURIPrefixes,
 		// singleDialTimeout specifies how long an individual `DialContext` operation may take
 		// before timing out. This timeout ignores the base RPC timeout and is strictly
 		// used for the DialContext operations that take place during an
 		// HTTP-01 challenge validation.
-		singleDialTimeout: 10 * time.Second,
+		singleDialTimeout:          10 * time.Second,
+		challengePolicies:          challengePolicies,
+		tlsALPNRetryOnCheckFailure: tlsALPNRetryOnCheckFailure,
+	}
+	va.targetLimiter = newTargetLimiter(targetRateLimit, clk, va.metrics.validationsThrottled)
+
+	if validationProxy != "" {
+		proxy, err := newProxyDialer(validationProxy)
+		if err != nil {
+			return nil, err
+		}
+		va.proxy = proxy
+	}
+
+	// Remote VAs configured with a proxy, rather than a client for a remote VA
+	// deployment, are validated from by a copy of this VA which connects to
+	// validation servers through the proxy.
+	va.remoteVAs = make([]RemoteVA, len(remoteVAs))
+	for i, rva := range remoteVAs {
+		if rva.VAClient == nil && rva.Proxy != "" {
+			proxy, err := newProxyDialer(rva.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("remote VA %q: %s", rva.Address, err)
+			}
+			perspective := *va
+			perspective.proxy = proxy
+			// Like a remote VA, each perspective limits its own validation
+			// attempts.
+			perspective.targetLimiter = newTargetLimiter(targetRateLimit, clk, va.metrics.validationsThrottled)
+			perspective.remoteVAs = nil
+			rva.VAClient = proxiedVA{&perspective}
+			if rva.Address == "" {
+				rva.Address = proxy.String()
+			}
+		}
+		va.remoteVAs[i] = rva
 	}
 
 	return va, nil
 }
 
+// proxiedVA adapts a VA which validates through a proxy to the vapb.VAClient
+// interface, so that it can be used as a remote VA.
+type proxiedVA struct {
+	va *ValidationAuthorityImpl
+}
+
+func (p proxiedVA) PerformValidation(ctx context.Context, req *vapb.PerformValidationRequest, _ ...grpc.CallOption) (*vapb.ValidationResult, error) {
+	return p.va.PerformValidation(ctx, req)
+}
+
+// connectTimeout returns how long each connection to the validation server
+// made by challenges of the given type may take.
+func (va *ValidationAuthorityImpl) connectTimeout(challType core.AcmeChallenge) time.Duration {
+	if timeout := va.challengePolicies[challType].ConnectTimeout; timeout > 0 {
+		return timeout
+	}
+	return va.singleDialTimeout
+}
+
+// readTimeout returns how long the validation server has to respond to
+// challenges of the given type once connected to, or zero if that's only
+// bounded by the validation's deadline.
+func (va *ValidationAuthorityImpl) readTimeout(challType core.AcmeChallenge) time.Duration {
+	return va.challengePolicies[challType].ReadTimeout
+}
+
+// failureMode classifies a local validation problem for the
+// local_validation_failures metric.
+func failureMode(prob *probs.ProblemDetails) string {
+	switch {
+	case strings.Contains(prob.Detail, "Timeout during connect"):
+		return "connect_timeout"
+	case strings.Contains(prob.Detail, "Timeout during") || strings.Contains(prob.Detail, "Timeout after connect"):
+		return "read_timeout"
+	case strings.Contains(prob.Detail, "query timed out"):
+		return "dns_timeout"
+	default:
+		return string(prob.Type)
+	}
+}
+
 // Used for audit logging
 type verificationRequestEvent struct {
 	ID                string         `json:",omitempty"`
@@ -264,9 +449,16 @@ func detailedError(err error) *probs.ProblemDetails {
 		return probs.Malformed("Server only speaks HTTP, not TLS")
 	}
 
+	var throttledErr *throttledError
+	if errors.As(err, &throttledErr) {
+		return probs.RateLimited(throttledErr.Error())
+	}
+
 	var netErr *net.OpError
 	if errors.As(err, &netErr) {
-		if fmt.Sprintf("%T", netErr.Err) == "tls.alert" {
+		if proxyErr, ok := netErr.Err.(*proxyError); ok {
+			return probs.ConnectionFailure(proxyErr.Error())
+		} else if fmt.Sprintf("%T", netErr.Err) == "tls.alert" {
 			// All the tls.alert error strings are reasonable to hand back to a
 			// user. Confirmed against Go 1.8.
 			return probs.TLSError(netErr.Error())
@@ -330,14 +522,30 @@ func (va *ValidationAuthorityImpl) validate(
 	// `baseIdentifier`
 	ch := make(chan *probs.ProblemDetails, 1)
 	go func() {
+		// CAA only applies to domain names (RFC 8738 Section 8).
+		if identifier.Type != "dns" {
+			ch <- nil
+			return
+		}
 		validationMethod := string(challenge.Type)
 		params := &caaParams{
 			accountURIID:     &regid,
 			validationMethod: &validationMethod,
 		}
+		if challenge.OnionCSRResponse != nil {
+			params.onionCAA = challenge.OnionCSRResponse.OnionCAA
+		}
 		ch <- va.checkCAA(ctx, identifier, params)
 	}()
 
+	// Challenges which connect to the validation server count against its
+	// hostname's rate limit, and each IP address connected to against its own.
+	if challenge.Type == core.ChallengeTypeHTTP01 || challenge.Type == core.ChallengeTypeTLSALPN01 {
+		if err := va.targetLimiter.allow(baseIdentifier.Value); err != nil {
+			return nil, detailedError(err)
+		}
+	}
+
 	// TODO(#1292): send into another goroutine
 	validationRecords, err := va.validateChallenge(ctx, baseIdentifier, challenge)
 	if err != nil {
@@ -356,6 +564,9 @@ func (va *ValidationAuthorityImpl) validateChallenge(ctx context.Context, identi
 	if err := challenge.CheckConsistencyForValidation(); err != nil {
 		return nil, probs.Malformed("Challenge failed consistency check: %s", err)
 	}
+	if policy := va.challengePolicies[challenge.Type]; policy.DNSTries > 0 {
+		ctx = bdns.WithRetryPolicy(ctx, policy.DNSTries, policy.DNSBackoff)
+	}
 	switch challenge.Type {
 	case core.ChallengeTypeHTTP01:
 		return va.validateHTTP01(ctx, identifier, challenge)
@@ -363,6 +574,8 @@ func (va *ValidationAuthorityImpl) validateChallenge(ctx context.Context, identi
 		return va.validateDNS01(ctx, identifier, challenge)
 	case core.ChallengeTypeTLSALPN01:
 		return va.validateTLSALPN01(ctx, identifier, challenge)
+	case core.ChallengeTypeOnionCSR01:
+		return va.validateOnionCSR01(ctx, identifier, challenge)
 	}
 	return nil, probs.Malformed("invalid challenge type %s", challenge.Type)
 }
@@ -383,7 +596,9 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(
 		remoteVA := va.remoteVAs[i]
 		go func(rva RemoteVA, index int) {
 			result := &remoteValidationResult{
-				VAHostname: rva.Address,
+				VAHostname:  rva.Address,
+				Perspective: rva.Perspective,
+				RIR:         rva.RIR,
 			}
 			res, err := rva.PerformValidation(ctx, req)
 			if err != nil && canceled.Is(err) {
@@ -407,6 +622,9 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(
 					result.Problem = prob
 				}
 			}
+			if err == nil {
+				result.Records = remoteValidationRecords(res.Records)
+			}
 			results <- result
 		}(remoteVA, i)
 	}
@@ -415,7 +633,10 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(
 // processRemoteResults evaluates a primary VA result, and a channel of remote
 // VA problems to produce a single overall validation result based on configured
 // feature flags. The overall result is calculated based on the VA's configured
-// `maxRemoteFailures` value.
+// `maxRemoteFailures` value, and, if `minRemoteRIRs` is set, on the successful
+// remote VAs spanning at least that many distinct RIRs. The result of every
+// remote VA which responded before the overall result was decided is audit
+// logged, and those which disagreed with the primary VA are counted.
 //
 // If the `MultiVAFullResults` feature is enabled then `processRemoteResults`
 // will expect to read a result from the `remoteErrors` channel for each VA and
@@ -433,24 +654,44 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 	acctID int64,
 	challengeType string,
 	primaryResult *probs.ProblemDetails,
+	primaryRecords []core.ValidationRecord,
 	remoteResultsChan chan *remoteValidationResult,
 	numRemoteVAs int) *probs.ProblemDetails {
 
 	state := "failure"
 	start := va.clk.Now()
 
+	required := numRemoteVAs - va.maxRemoteFailures
+	good := 0
+	bad := 0
+	goodRIRs := make(map[string]bool)
+
+	var remoteResults []*remoteValidationResult
+
 	defer func() {
 		va.metrics.remoteValidationTime.With(prometheus.Labels{
 			"type":   challengeType,
 			"result": state,
 		}).Observe(va.clk.Since(start).Seconds())
+		va.log.AuditObject("Multi-perspective validation result", multiPerspectiveEvent{
+			Domain:          domain,
+			AccountID:       acctID,
+			ChallengeType:   challengeType,
+			PrimaryResult:   primaryResult,
+			RemoteVAs:       numRemoteVAs,
+			RequiredSuccess: required,
+			RequiredRIRs:    va.minRemoteRIRs,
+			Perspectives:    remoteResults,
+			Result:          state,
+		})
 	}()
 
-	required := numRemoteVAs - va.maxRemoteFailures
-	good := 0
-	bad := 0
+	// quorum returns true if enough remote VAs, from enough distinct RIRs,
+	// have succeeded.
+	quorum := func() bool {
+		return good >= required && len(goodRIRs) >= va.minRemoteRIRs
+	}
 
-	var remoteResults []*remoteValidationResult
 	var firstProb *probs.ProblemDetails
 	// Due to channel behavior this could block indefinitely and we rely on gRPC
 	// honoring the context deadline used in client calls to prevent that from
@@ -458,8 +699,10 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 	for result := range remoteResultsChan {
 		// Add the result to the slice
 		remoteResults = append(remoteResults, result)
+		va.countRemoteDisagreement(primaryResult, result)
 		if result.Problem == nil {
 			good++
+			goodRIRs[result.RIR] = true
 		} else {
 			bad++
 		}
@@ -473,7 +716,7 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 		// If MultiVAFullResults isn't enabled then return early whenever the
 		// success or failure threshold is met.
 		if !features.Enabled(features.MultiVAFullResults) {
-			if good >= required {
+			if quorum() {
 				state = "success"
 				return nil
 			} else if bad > va.maxRemoteFailures {
@@ -498,13 +741,16 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 		acctID,
 		challengeType,
 		primaryResult,
+		primaryRecords,
 		remoteResults)
 
 	// Based on the threshold of good/bad return nil or a problem.
-	if good >= required {
+	if quorum() {
 		state = "success"
 		return nil
-	} else if bad > va.maxRemoteFailures {
+	} else if bad > va.maxRemoteFailures || firstProb != nil {
+		// If there were few enough failures, the successes didn't span enough
+		// RIRs, so the failures are still why validation wasn't corroborated.
 		modifiedProblem := *firstProb
 		modifiedProblem.Detail = "During secondary validation: " + firstProb.Detail
 		return &modifiedProblem
@@ -515,18 +761,41 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 	return probs.ServerInternal("Too few remote PerformValidation RPC results")
 }
 
+// countRemoteDisagreement increments the remote_validation_disagreements
+// metric if the remote VA result succeeded where the primary VA failed, or
+// vice versa.
+func (va *ValidationAuthorityImpl) countRemoteDisagreement(primaryResult *probs.ProblemDetails, result *remoteValidationResult) {
+	if (primaryResult == nil) == (result.Problem == nil) {
+		return
+	}
+	outcome, mode := "failure", ""
+	if result.Problem != nil {
+		mode = failureMode(result.Problem)
+	} else {
+		outcome, mode = "success", failureMode(primaryResult)
+	}
+	va.metrics.remoteValidationDisagreements.With(prometheus.Labels{
+		"perspective":  result.perspective(),
+		"result":       outcome,
+		"failure_mode": mode,
+	}).Inc()
+}
+
 // logRemoteValidationDifferentials is called by `processRemoteResults` when the
 // `MultiVAFullResults` feature flag is enabled. It produces a JSON log line
-// that contains the primary VA result and the results each remote VA returned.
+// that compares the primary VA result with the results each remote VA
+// returned: the failing perspectives, how they failed, and the DNS answers
+// each VA saw.
 func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 	domain string,
 	acctID int64,
 	challengeType string,
 	primaryResult *probs.ProblemDetails,
+	primaryRecords []core.ValidationRecord,
 	remoteResults []*remoteValidationResult) {
 
 	var successes []*remoteValidationResult
-	var failures []*remoteValidationResult
+	var failures []remoteFailure
 
 	allEqual := true
 	for _, result := range remoteResults {
@@ -536,7 +805,14 @@ func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 		if result.Problem == nil {
 			successes = append(successes, result)
 		} else {
-			failures = append(failures, result)
+			failures = append(failures, remoteFailure{
+				VAHostname:  result.VAHostname,
+				Perspective: result.Perspective,
+				RIR:         result.RIR,
+				FailureMode: failureMode(result.Problem),
+				Problem:     result.Problem,
+				DNSAnswers:  dnsAnswersFromRecords(result.Records),
+			})
 		}
 	}
 	if allEqual {
@@ -552,20 +828,42 @@ func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 		va.metrics.prospectiveRemoteValidationFailures.Inc()
 	}
 
+	var primaryFailureMode string
+	if primaryResult != nil {
+		primaryFailureMode = failureMode(primaryResult)
+	}
+	var remoteSuccessDNSAnswers []perspectiveDNSAnswers
+	for _, result := range successes {
+		answers := dnsAnswersFromRecords(result.Records)
+		if len(answers) > 0 {
+			remoteSuccessDNSAnswers = append(remoteSuccessDNSAnswers, perspectiveDNSAnswers{
+				VAHostname:  result.VAHostname,
+				Perspective: result.Perspective,
+				DNSAnswers:  answers,
+			})
+		}
+	}
+
 	logOb := struct {
-		Domain          string
-		AccountID       int64
-		ChallengeType   string
-		PrimaryResult   *probs.ProblemDetails
-		RemoteSuccesses int
-		RemoteFailures  []*remoteValidationResult
+		Domain                  string
+		AccountID               int64
+		ChallengeType           string
+		PrimaryResult           *probs.ProblemDetails
+		PrimaryFailureMode      string       `json:",omitempty"`
+		PrimaryDNSAnswers       []dnsAnswers `json:",omitempty"`
+		RemoteSuccesses         int
+		RemoteSuccessDNSAnswers []perspectiveDNSAnswers `json:",omitempty"`
+		RemoteFailures          []remoteFailure
 	}{
-		Domain:          domain,
-		AccountID:       acctID,
-		ChallengeType:   challengeType,
-		PrimaryResult:   primaryResult,
-		RemoteSuccesses: len(successes),
-		RemoteFailures:  failures,
+		Domain:                  domain,
+		AccountID:               acctID,
+		ChallengeType:           challengeType,
+		PrimaryResult:           primaryResult,
+		PrimaryFailureMode:      primaryFailureMode,
+		PrimaryDNSAnswers:       dnsAnswersFromRecords(primaryRecords),
+		RemoteSuccesses:         len(successes),
+		RemoteSuccessDNSAnswers: remoteSuccessDNSAnswers,
+		RemoteFailures:          failures,
 	}
 
 	logJSON, err := json.Marshal(logOb)
@@ -582,10 +880,98 @@ func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 }
 
 // remoteValidationResult is a struct that combines a problem details instance
-// (that may be nil) with the remote VA hostname that produced it.
+// (that may be nil) with the remote VA hostname that produced it. The
+// validation records the remote VA returned are only used to log
+// differentials, and are left out of the audit log.
 type remoteValidationResult struct {
-	VAHostname string
-	Problem    *probs.ProblemDetails
+	VAHostname  string
+	Perspective string `json:",omitempty"`
+	RIR         string `json:",omitempty"`
+	Problem     *probs.ProblemDetails
+	Records     []core.ValidationRecord `json:"-"`
+}
+
+// perspective returns the name of the remote VA's network perspective, or its
+// hostname if it doesn't have one.
+func (r *remoteValidationResult) perspective() string {
+	if r.Perspective != "" {
+		return r.Perspective
+	}
+	return r.VAHostname
+}
+
+// remoteValidationRecords converts the validation records returned by a remote
+// VA, stopping at the first malformed one. They're only used for logging, so a
+// malformed record doesn't fail the remote VA's result.
+func remoteValidationRecords(pbRecords []*corepb.ValidationRecord) []core.ValidationRecord {
+	var records []core.ValidationRecord
+	for _, pbRecord := range pbRecords {
+		record, err := bgrpc.PBToValidationRecord(pbRecord)
+		if err != nil {
+			break
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// remoteFailure describes a remote VA which failed a validation in the
+// remoteVADifferentials log line.
+type remoteFailure struct {
+	VAHostname  string
+	Perspective string `json:",omitempty"`
+	RIR         string `json:",omitempty"`
+	FailureMode string
+	Problem     *probs.ProblemDetails
+	DNSAnswers  []dnsAnswers `json:",omitempty"`
+}
+
+// perspectiveDNSAnswers holds the DNS answers seen by a remote VA which
+// succeeded, for comparison with those seen by the VAs which failed.
+type perspectiveDNSAnswers struct {
+	VAHostname  string
+	Perspective string `json:",omitempty"`
+	DNSAnswers  []dnsAnswers
+}
+
+// dnsAnswers are the DNS answers a VA saw for one hostname while validating,
+// as recorded in its validation records.
+type dnsAnswers struct {
+	Hostname  string
+	Addresses []net.IP `json:",omitempty"`
+	TXT       []string `json:",omitempty"`
+}
+
+// dnsAnswersFromRecords returns the DNS answers recorded in records.
+func dnsAnswersFromRecords(records []core.ValidationRecord) []dnsAnswers {
+	var answers []dnsAnswers
+	for _, record := range records {
+		if len(record.AddressesResolved) == 0 && len(record.DNSAnswers) == 0 {
+			continue
+		}
+		answers = append(answers, dnsAnswers{
+			Hostname:  record.Hostname,
+			Addresses: record.AddressesResolved,
+			TXT:       record.DNSAnswers,
+		})
+	}
+	return answers
+}
+
+// multiPerspectiveEvent is audit logged by `processRemoteResults` to record
+// the result of each network perspective which took part in corroborating a
+// validation. Remote VAs which hadn't responded when the overall result was
+// decided are absent from Perspectives.
+type multiPerspectiveEvent struct {
+	Domain          string
+	AccountID       int64
+	ChallengeType   string
+	PrimaryResult   *probs.ProblemDetails
+	RemoteVAs       int
+	RequiredSuccess int
+	RequiredRIRs    int
+	Perspectives    []*remoteValidationResult
+	Result          string
 }
 
 // PerformValidation validates the challenge for the domain in the request.
@@ -602,6 +988,11 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 	}
 	vStart := va.clk.Now()
 
+	// Share DNS answers between the perspectives validating this challenge
+	// in-process, if the VA's DNSClient caches them.
+	ctx = bdns.WithAnswerCacheKey(ctx, fmt.Sprintf("%d:%s:%s:%s",
+		*req.Authz.RegID, *req.Domain, req.Challenge.GetType(), req.Challenge.GetToken()))
+
 	var remoteResults chan *remoteValidationResult
 	if remoteVACount := len(va.remoteVAs); remoteVACount > 0 {
 		remoteResults = make(chan *remoteValidationResult, remoteVACount)
@@ -613,9 +1004,15 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 		return nil, probs.ServerInternal("Challenge failed to deserialize")
 	}
 
-	records, prob := va.validate(ctx, identifier.DNSIdentifier(*req.Domain), *req.Authz.RegID, challenge)
+	records, prob := va.validate(ctx, identifier.FromValue(*req.Domain), *req.Authz.RegID, challenge)
 	challenge.ValidationRecord = records
 	localValidationLatency := time.Since(vStart)
+	if prob != nil {
+		va.metrics.validationFailures.With(prometheus.Labels{
+			"type":         string(challenge.Type),
+			"failure_mode": failureMode(prob),
+		}).Inc()
+	}
 
 	// Check for malformed ValidationRecords
 	if !challenge.RecordsSane() && prob == nil {
@@ -639,6 +1036,7 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 					*req.Authz.RegID,
 					string(challenge.Type),
 					prob,
+					records,
 					remoteResults,
 					len(va.remoteVAs))
 			}()
@@ -652,6 +1050,7 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 				*req.Authz.RegID,
 				string(challenge.Type),
 				prob,
+				records,
 				remoteResults,
 				len(va.remoteVAs))
 
@@ -687,7 +1086,7 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 		"problem_type": problemType,
 	}).Observe(validationLatency.Seconds())
 
-	va.log.AuditObject("Validation result", logEvent)
+	blog.ForContext(ctx, va.log).AuditObject("Validation result", logEvent)
 
 	return bgrpc.ValidationResultToPB(records, prob)
 }
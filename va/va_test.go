@@ -140,12 +140,17 @@ func setup(srv *httptest.Server, maxRemoteFailures int, userAgent string, remote
 		&bdns.MockDNSClient{Log: logger},
 		nil,
 		maxRemoteFailures,
+		0,
 		userAgent,
-		"letsencrypt.org",
+		[]string{"letsencrypt.org"},
 		metrics.NoopRegisterer,
 		clock.New(),
 		logger,
 		accountURIPrefixes,
+		false,
+		nil,
+		"",
+		TargetRateLimit{},
 	)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create validation authority: %v", err))
@@ -342,8 +347,8 @@ func TestMultiVA(t *testing.T) {
 	remoteVA2, _ := setupRemote(ms.Server, 0, remoteUA2)
 
 	remoteVAs := []RemoteVA{
-		{remoteVA1, remoteUA1},
-		{remoteVA2, remoteUA2},
+		{VAClient: remoteVA1, Address: remoteUA1},
+		{VAClient: remoteVA2, Address: remoteUA2},
 	}
 
 	enforceMultiVA := map[string]bool{
@@ -411,8 +416,8 @@ func TestMultiVA(t *testing.T) {
 			// If a remote VA fails with an internal err it should fail when enforcing multi VA
 			Name: "Local VA ok, remote VA internal err, enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{remoteVA1, remoteUA1},
-				{&brokenRemoteVA{}, "broken"},
+				{VAClient: remoteVA1, Address: remoteUA1},
+				{VAClient: &brokenRemoteVA{}, Address: "broken"},
 			},
 			AllowedUAs:   allowedUAs,
 			Features:     enforceMultiVA,
@@ -425,8 +430,8 @@ func TestMultiVA(t *testing.T) {
 			// enforcing multi VA
 			Name: "Local VA ok, remote VA internal err, no enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{remoteVA1, remoteUA1},
-				{&brokenRemoteVA{}, "broken"},
+				{VAClient: remoteVA1, Address: remoteUA1},
+				{VAClient: &brokenRemoteVA{}, Address: "broken"},
 			},
 			AllowedUAs: allowedUAs,
 			Features:   noEnforceMultiVA,
@@ -456,8 +461,8 @@ func TestMultiVA(t *testing.T) {
 			// When enforcing multi-VA, any cancellations are a problem.
 			Name: "Local VA and one remote VA OK, one cancelled VA, enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{remoteVA1, remoteUA1},
-				{cancelledVA{}, remoteUA2},
+				{VAClient: remoteVA1, Address: remoteUA1},
+				{VAClient: cancelledVA{}, Address: remoteUA2},
 			},
 			AllowedUAs:   allowedUAs,
 			Features:     enforceMultiVA,
@@ -467,8 +472,8 @@ func TestMultiVA(t *testing.T) {
 			// When enforcing multi-VA, any cancellations are a problem.
 			Name: "Local VA OK, two cancelled remote VAs, enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{cancelledVA{}, remoteUA1},
-				{cancelledVA{}, remoteUA2},
+				{VAClient: cancelledVA{}, Address: remoteUA1},
+				{VAClient: cancelledVA{}, Address: remoteUA2},
 			},
 			AllowedUAs:   allowedUAs,
 			Features:     enforceMultiVA,
@@ -547,8 +552,8 @@ func TestMultiVAEarlyReturn(t *testing.T) {
 	remoteVA2, _ := setupRemote(ms.Server, 0, remoteUA2)
 
 	remoteVAs := []RemoteVA{
-		{remoteVA1, remoteUA1},
-		{remoteVA2, remoteUA2},
+		{VAClient: remoteVA1, Address: remoteUA1},
+		{VAClient: remoteVA2, Address: remoteUA2},
 	}
 
 	// Create a local test VA with the two remote VAs
@@ -634,8 +639,8 @@ func TestMultiVAPolicy(t *testing.T) {
 	remoteVA2, _ := setupRemote(ms.Server, 0, remoteUA2)
 
 	remoteVAs := []RemoteVA{
-		{remoteVA1, remoteUA1},
-		{remoteVA2, remoteUA2},
+		{VAClient: remoteVA1, Address: remoteUA1},
+		{VAClient: remoteVA2, Address: remoteUA2},
 	}
 
 	// Create a local test VA with the two remote VAs
@@ -701,9 +706,9 @@ func TestLogRemoteValidationDifferentials(t *testing.T) {
 	remoteVA2, _ := setupRemote(nil, 0, "remote 2")
 	remoteVA3, _ := setupRemote(nil, 0, "remote 3")
 	remoteVAs := []RemoteVA{
-		{remoteVA1, "remote 1"},
-		{remoteVA2, "remote 2"},
-		{remoteVA3, "remote 3"},
+		{VAClient: remoteVA1, Address: "remote 1"},
+		{VAClient: remoteVA2, Address: "remote 2"},
+		{VAClient: remoteVA3, Address: "remote 3"},
 	}
 
 	// Set up a local VA that allows a max of 2 remote failures.
@@ -713,10 +718,11 @@ func TestLogRemoteValidationDifferentials(t *testing.T) {
 	egProbB := probs.OrderNotReady("please take a number")
 
 	testCases := []struct {
-		name          string
-		primaryResult *probs.ProblemDetails
-		remoteProbs   []*remoteValidationResult
-		expectedLog   string
+		name           string
+		primaryResult  *probs.ProblemDetails
+		primaryRecords []core.ValidationRecord
+		remoteProbs    []*remoteValidationResult
+		expectedLog    string
 	}{
 		{
 			name:          "remote and primary results equal (all nil)",
@@ -744,7 +750,7 @@ func TestLogRemoteValidationDifferentials(t *testing.T) {
 				{Problem: nil, VAHostname: "remoteB"},
 				{Problem: egProbB, VAHostname: "remoteC"},
 			},
-			expectedLog: `INFO: remoteVADifferentials JSON={"Domain":"example.com","AccountID":1999,"ChallengeType":"blorpus-01","PrimaryResult":null,"RemoteSuccesses":1,"RemoteFailures":[{"VAHostname":"remoteA","Problem":{"type":"dns","detail":"root DNS servers closed at 4:30pm","status":400}},{"VAHostname":"remoteC","Problem":{"type":"orderNotReady","detail":"please take a number","status":403}}]}`,
+			expectedLog: `INFO: remoteVADifferentials JSON={"Domain":"example.com","AccountID":1999,"ChallengeType":"blorpus-01","PrimaryResult":null,"RemoteSuccesses":1,"RemoteFailures":[{"VAHostname":"remoteA","FailureMode":"dns","Problem":{"type":"dns","detail":"root DNS servers closed at 4:30pm","status":400}},{"VAHostname":"remoteC","FailureMode":"orderNotReady","Problem":{"type":"orderNotReady","detail":"please take a number","status":403}}]}`,
 		},
 		{
 			name:          "remote and primary differ (primary not nil)",
@@ -754,7 +760,28 @@ func TestLogRemoteValidationDifferentials(t *testing.T) {
 				{Problem: egProbB, VAHostname: "remoteB"},
 				{Problem: nil, VAHostname: "remoteC"},
 			},
-			expectedLog: `INFO: remoteVADifferentials JSON={"Domain":"example.com","AccountID":1999,"ChallengeType":"blorpus-01","PrimaryResult":{"type":"dns","detail":"root DNS servers closed at 4:30pm","status":400},"RemoteSuccesses":2,"RemoteFailures":[{"VAHostname":"remoteB","Problem":{"type":"orderNotReady","detail":"please take a number","status":403}}]}`,
+			expectedLog: `INFO: remoteVADifferentials JSON={"Domain":"example.com","AccountID":1999,"ChallengeType":"blorpus-01","PrimaryResult":{"type":"dns","detail":"root DNS servers closed at 4:30pm","status":400},"PrimaryFailureMode":"dns","RemoteSuccesses":2,"RemoteFailures":[{"VAHostname":"remoteB","FailureMode":"orderNotReady","Problem":{"type":"orderNotReady","detail":"please take a number","status":403}}]}`,
+		},
+		{
+			name:          "DNS answers differ",
+			primaryResult: nil,
+			primaryRecords: []core.ValidationRecord{
+				{Hostname: "example.com", AddressesResolved: []net.IP{net.ParseIP("10.0.0.1")}},
+			},
+			remoteProbs: []*remoteValidationResult{
+				{
+					Problem:     egProbA,
+					VAHostname:  "remoteA",
+					Perspective: "eu-central",
+					Records:     []core.ValidationRecord{{Hostname: "example.com", DNSAnswers: []string{"stale"}}},
+				},
+				{
+					Problem:    nil,
+					VAHostname: "remoteB",
+					Records:    []core.ValidationRecord{{Hostname: "example.com", AddressesResolved: []net.IP{net.ParseIP("10.0.0.1")}}},
+				},
+			},
+			expectedLog: `INFO: remoteVADifferentials JSON={"Domain":"example.com","AccountID":1999,"ChallengeType":"blorpus-01","PrimaryResult":null,"PrimaryDNSAnswers":[{"Hostname":"example.com","Addresses":["10.0.0.1"]}],"RemoteSuccesses":1,"RemoteSuccessDNSAnswers":[{"VAHostname":"remoteB","DNSAnswers":[{"Hostname":"example.com","Addresses":["10.0.0.1"]}]}],"RemoteFailures":[{"VAHostname":"remoteA","Perspective":"eu-central","FailureMode":"dns","Problem":{"type":"dns","detail":"root DNS servers closed at 4:30pm","status":400},"DNSAnswers":[{"Hostname":"example.com","TXT":["stale"]}]}]}`,
 		},
 	}
 
@@ -763,7 +790,7 @@ func TestLogRemoteValidationDifferentials(t *testing.T) {
 			mockLog.Clear()
 
 			localVA.logRemoteValidationDifferentials(
-				"example.com", 1999, "blorpus-01", tc.primaryResult, tc.remoteProbs)
+				"example.com", 1999, "blorpus-01", tc.primaryResult, tc.primaryRecords, tc.remoteProbs)
 
 			lines := mockLog.GetAllMatching("remoteVADifferentials JSON=.*")
 			if tc.expectedLog != "" {
@@ -775,3 +802,142 @@ func TestLogRemoteValidationDifferentials(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoteValidationRIRQuorum(t *testing.T) {
+	remoteVA, _ := setupRemote(nil, 0, "remote")
+	remoteVAs := []RemoteVA{
+		{VAClient: remoteVA, Address: "remote 1", Perspective: "us-east", RIR: "ARIN"},
+		{VAClient: remoteVA, Address: "remote 2", Perspective: "us-west", RIR: "ARIN"},
+		{VAClient: remoteVA, Address: "remote 3", Perspective: "eu-central", RIR: "RIPE"},
+	}
+
+	// Every remote VA must have an RIR when a minimum number is required.
+	_, err := NewValidationAuthorityImpl(&cmd.PortConfig{}, &bdns.MockDNSClient{}, []RemoteVA{
+		{VAClient: remoteVA, Address: "remote 1", RIR: "ARIN"},
+		{VAClient: remoteVA, Address: "remote 2"},
+	}, 1, 1, "user agent", []string{"letsencrypt.org"}, metrics.NoopRegisterer, clock.New(), blog.NewMock(), accountURIPrefixes, false, nil, "", TargetRateLimit{})
+	test.AssertError(t, err, "constructed VA with a remote VA missing its RIR")
+	// And they must span enough of them.
+	_, err = NewValidationAuthorityImpl(&cmd.PortConfig{}, &bdns.MockDNSClient{}, remoteVAs,
+		1, 3, "user agent", []string{"letsencrypt.org"}, metrics.NoopRegisterer, clock.New(), blog.NewMock(), accountURIPrefixes, false, nil, "", TargetRateLimit{})
+	test.AssertError(t, err, "constructed VA with too few distinct RIRs")
+
+	localVA, mockLog := setup(nil, 1, "local", remoteVAs)
+	localVA.minRemoteRIRs = 2
+
+	unauthorized := probs.Unauthorized("nope")
+	testCases := []struct {
+		name         string
+		remoteProbs  []*remoteValidationResult
+		expectedProb *probs.ProblemDetails
+	}{
+		{
+			name: "successes span two RIRs",
+			remoteProbs: []*remoteValidationResult{
+				{VAHostname: "remote 1", RIR: "ARIN"},
+				{VAHostname: "remote 2", RIR: "ARIN", Problem: unauthorized},
+				{VAHostname: "remote 3", RIR: "RIPE"},
+			},
+		},
+		{
+			name: "successes span one RIR",
+			remoteProbs: []*remoteValidationResult{
+				{VAHostname: "remote 1", RIR: "ARIN"},
+				{VAHostname: "remote 2", RIR: "ARIN"},
+				{VAHostname: "remote 3", RIR: "RIPE", Problem: unauthorized},
+			},
+			expectedProb: probs.Unauthorized("During secondary validation: nope"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLog.Clear()
+			results := make(chan *remoteValidationResult, len(tc.remoteProbs))
+			for _, r := range tc.remoteProbs {
+				results <- r
+			}
+
+			prob := localVA.processRemoteResults("example.com", 1999, "http-01", nil, nil, results, len(tc.remoteProbs))
+			test.AssertDeepEquals(t, prob, tc.expectedProb)
+
+			lines := mockLog.GetAllMatching("Multi-perspective validation result JSON=")
+			test.AssertEquals(t, len(lines), 1)
+			test.AssertContains(t, lines[0], `"RequiredRIRs":2`)
+			test.AssertContains(t, lines[0], `"RIR":"RIPE"`)
+		})
+	}
+}
+
+func TestRemoteValidationDisagreements(t *testing.T) {
+	// Remote VAs return their validation records along with their result.
+	req := createValidationRequest("localhost", core.ChallengeTypeHTTP01)
+	hs := httpSrv(t, expectedToken)
+	defer hs.Close()
+	remoteVA, _ := setupRemote(hs, 0, "remote")
+	localVA, _ := setup(nil, 0, "local", []RemoteVA{{VAClient: remoteVA, Address: "remote 1", Perspective: "us-east"}})
+	results := make(chan *remoteValidationResult, 1)
+	localVA.performRemoteValidation(ctx, req, results)
+	result := <-results
+	test.Assert(t, result.Problem == nil, "remote validation failed")
+	test.AssertEquals(t, len(result.Records), 1)
+	test.AssertEquals(t, result.Records[0].Hostname, "localhost")
+
+	localVA, _ = setup(nil, 2, "local", nil)
+	unauthorized := probs.Unauthorized("nope")
+	results = make(chan *remoteValidationResult, 3)
+	results <- &remoteValidationResult{VAHostname: "remote 2", Perspective: "eu-central", Problem: unauthorized}
+	results <- &remoteValidationResult{VAHostname: "remote 3", Problem: probs.ConnectionFailure("Timeout during connect (likely firewall problem)")}
+	results <- &remoteValidationResult{VAHostname: "remote 1", Perspective: "us-east"}
+	_ = localVA.processRemoteResults("example.com", 1999, "http-01", nil, nil, results, 3)
+
+	// Only the remote VAs which failed where the primary succeeded disagreed.
+	test.AssertEquals(t, test.CountCounter(localVA.metrics.remoteValidationDisagreements.With(prometheus.Labels{
+		"perspective": "us-east", "result": "failure", "failure_mode": "unauthorized"})), 0)
+	test.AssertEquals(t, test.CountCounter(localVA.metrics.remoteValidationDisagreements.With(prometheus.Labels{
+		"perspective": "eu-central", "result": "failure", "failure_mode": "unauthorized"})), 1)
+	test.AssertEquals(t, test.CountCounter(localVA.metrics.remoteValidationDisagreements.With(prometheus.Labels{
+		"perspective": "remote 3", "result": "failure", "failure_mode": "connect_timeout"})), 1)
+
+	// When the primary VA failed, the remote VAs which succeeded disagreed.
+	results = make(chan *remoteValidationResult, 1)
+	results <- &remoteValidationResult{VAHostname: "remote 1", Perspective: "us-east"}
+	_ = localVA.processRemoteResults("example.com", 1999, "http-01", unauthorized, nil, results, 1)
+	test.AssertEquals(t, test.CountCounter(localVA.metrics.remoteValidationDisagreements.With(prometheus.Labels{
+		"perspective": "us-east", "result": "success", "failure_mode": "unauthorized"})), 1)
+}
+
+func TestChallengePolicies(t *testing.T) {
+	_, err := NewValidationAuthorityImpl(&cmd.PortConfig{}, &bdns.MockDNSClient{}, nil, 0, 0,
+		"user agent", []string{"letsencrypt.org"}, metrics.NoopRegisterer, clock.New(), blog.NewMock(), accountURIPrefixes, false,
+		map[core.AcmeChallenge]ChallengePolicy{"http-02": {ConnectTimeout: time.Second}}, "", TargetRateLimit{})
+	test.AssertError(t, err, "constructed VA with a policy for an unknown challenge type")
+
+	va, _ := setup(nil, 0, "", nil)
+	va.challengePolicies = map[core.AcmeChallenge]ChallengePolicy{
+		core.ChallengeTypeHTTP01: {ConnectTimeout: time.Second, ReadTimeout: 2 * time.Second},
+	}
+	test.AssertEquals(t, va.connectTimeout(core.ChallengeTypeHTTP01), time.Second)
+	test.AssertEquals(t, va.readTimeout(core.ChallengeTypeHTTP01), 2*time.Second)
+	// Challenge types without a policy keep the defaults.
+	test.AssertEquals(t, va.connectTimeout(core.ChallengeTypeTLSALPN01), va.singleDialTimeout)
+	test.AssertEquals(t, va.readTimeout(core.ChallengeTypeTLSALPN01), time.Duration(0))
+}
+
+func TestFailureMode(t *testing.T) {
+	testCases := []struct {
+		prob     *probs.ProblemDetails
+		expected string
+	}{
+		{probs.ConnectionFailure("Fetching http://example.com/: Timeout during connect (likely firewall problem)"), "connect_timeout"},
+		{probs.ConnectionFailure("Timeout during read (your server may be slow or overloaded)"), "read_timeout"},
+		{probs.ConnectionFailure("Timeout after connect (your server may be slow or overloaded)"), "read_timeout"},
+		{probs.DNS("DNS problem: query timed out looking up TXT for _acme-challenge.example.com"), "dns_timeout"},
+		{probs.DNS("DNS problem: NXDOMAIN looking up A for example.com"), "dns"},
+		{probs.ConnectionFailure("Connection refused"), "connection"},
+		{probs.Unauthorized("Incorrect TXT record"), "unauthorized"},
+	}
+	for _, tc := range testCases {
+		test.AssertEquals(t, failureMode(tc.prob), tc.expected)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/requestid"
 )
 
 type RequestEvent struct {
@@ -28,6 +29,16 @@ type RequestEvent struct {
 	Latency   float64 `json:"-"`
 	RealIP    string  `json:"-"`
 
+	// RequestID uniquely identifies the request in the logs of every service
+	// it reaches, and is sent to the client with any problem. It is only
+	// included in the request's own log line by handlers from
+	// NewJSONTopHandler.
+	RequestID string `json:"-"`
+	// ErrorType is the type of the problem sent in response to the request,
+	// if any. It is only logged by handlers from NewJSONTopHandler, as the
+	// Error field already contains it.
+	ErrorType string `json:"-"`
+
 	Slug           string   `json:",omitempty"`
 	InternalErrors []string `json:",omitempty"`
 	Error          string   `json:",omitempty"`
@@ -58,7 +69,7 @@ func (e *RequestEvent) AddError(msg string, args ...interface{}) {
 type WFEHandlerFunc func(context.Context, *RequestEvent, http.ResponseWriter, *http.Request)
 
 func (f WFEHandlerFunc) ServeHTTP(e *RequestEvent, w http.ResponseWriter, r *http.Request) {
-	ctx := context.TODO()
+	ctx := requestid.WithID(context.TODO(), e.RequestID)
 	f(ctx, e, w, r)
 }
 
@@ -69,6 +80,10 @@ type wfeHandler interface {
 type TopHandler struct {
 	wfe wfeHandler
 	log blog.Logger
+
+	// jsonLogs is true if each request is logged as a single JSON object,
+	// rather than as whitespace-separated fields followed by JSON.
+	jsonLogs bool
 }
 
 func NewTopHandler(log blog.Logger, wfe wfeHandler) *TopHandler {
@@ -78,6 +93,17 @@ func NewTopHandler(log blog.Logger, wfe wfeHandler) *TopHandler {
 	}
 }
 
+// NewJSONTopHandler is like NewTopHandler, but the returned handler logs each
+// request as a single JSON object, including its request ID, so that the logs
+// can be consumed without parsing each field out of the line.
+func NewJSONTopHandler(log blog.Logger, wfe wfeHandler) *TopHandler {
+	return &TopHandler{
+		wfe:      wfe,
+		log:      log,
+		jsonLogs: true,
+	}
+}
+
 // responseWriterWithStatus satisfies http.ResponseWriter, but keeps track of the
 // status code for logging.
 type responseWriterWithStatus struct {
@@ -104,6 +130,7 @@ func (th *TopHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		UserAgent: r.Header.Get("User-Agent"),
 		Origin:    r.Header.Get("Origin"),
 		Extra:     make(map[string]interface{}),
+		RequestID: requestid.New(),
 	}
 
 	if features.Enabled(features.StripDefaultSchemePort) {
@@ -143,7 +170,26 @@ func (th *TopHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	th.wfe.ServeHTTP(logEvent, rwws, r)
 }
 
+// jsonLogLine is the JSON object logged for each request by handlers from
+// NewJSONTopHandler. It includes the fields which NewTopHandler's handlers log
+// ahead of their JSON, alongside those of the embedded RequestEvent.
+type jsonLogLine struct {
+	RequestID string  `json:"requestID"`
+	Method    string  `json:"method"`
+	Endpoint  string  `json:"endpoint"`
+	Requester int64   `json:"accountID,omitempty"`
+	Code      int     `json:"status"`
+	LatencyMS float64 `json:"latencyMS"`
+	RealIP    string  `json:"realIP"`
+	ErrorType string  `json:"errorType,omitempty"`
+	*RequestEvent
+}
+
 func (th *TopHandler) logEvent(logEvent *RequestEvent) {
+	if th.jsonLogs {
+		th.logEventJSON(logEvent)
+		return
+	}
 	var msg string
 	jsonEvent, err := json.Marshal(logEvent)
 	if err != nil {
@@ -155,6 +201,25 @@ func (th *TopHandler) logEvent(logEvent *RequestEvent) {
 		int(logEvent.Latency*1000), logEvent.RealIP, jsonEvent)
 }
 
+func (th *TopHandler) logEventJSON(logEvent *RequestEvent) {
+	jsonLine, err := json.Marshal(jsonLogLine{
+		RequestID:    logEvent.RequestID,
+		Method:       logEvent.Method,
+		Endpoint:     logEvent.Endpoint,
+		Requester:    logEvent.Requester,
+		Code:         logEvent.Code,
+		LatencyMS:    logEvent.Latency * 1000,
+		RealIP:       logEvent.RealIP,
+		ErrorType:    logEvent.ErrorType,
+		RequestEvent: logEvent,
+	})
+	if err != nil {
+		th.log.AuditErrf("failed to marshal logEvent - %#v", err)
+		return
+	}
+	th.log.Info(string(jsonLine))
+}
+
 // Comma-separated list of HTTP clients involved in making this
 // request, starting with the original requestor and ending with the
 // remote end of our TCP connection (which is typically our own
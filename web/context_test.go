@@ -2,7 +2,9 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/requestid"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -75,6 +79,65 @@ func TestOrigin(t *testing.T) {
 	}
 }
 
+type errorHandler struct{}
+
+func (eh errorHandler) ServeHTTP(e *RequestEvent, w http.ResponseWriter, r *http.Request) {
+	e.Endpoint = "/endpoint"
+	e.Requester = 1234
+	SendError(blog.NewMock(), "urn:acme:error:", w, e, probs.Malformed("bad request"), nil)
+}
+
+func TestJSONLogging(t *testing.T) {
+	mockLog := blog.UseMock()
+	th := NewJSONTopHandler(mockLog, errorHandler{})
+	req, err := http.NewRequest("POST", "/thisisignored", &bytes.Reader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("User-Agent", "test-agent")
+	th.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := mockLog.GetAllMatching("^INFO: {")
+	test.AssertEquals(t, len(lines), 1)
+	var logged map[string]interface{}
+	err = json.Unmarshal([]byte(strings.TrimPrefix(lines[0], "INFO: ")), &logged)
+	test.AssertNotError(t, err, "Failed to unmarshal JSON log line")
+	test.AssertEquals(t, len(logged["requestID"].(string)), 16)
+	test.AssertEquals(t, logged["method"], "POST")
+	test.AssertEquals(t, logged["endpoint"], "/endpoint")
+	test.AssertEquals(t, logged["accountID"], float64(1234))
+	test.AssertEquals(t, logged["status"], float64(http.StatusBadRequest))
+	test.AssertEquals(t, logged["realIP"], "0.0.0.0")
+	test.AssertEquals(t, logged["ua"], "test-agent")
+	test.AssertEquals(t, logged["errorType"], string(probs.MalformedProblem))
+	_, present := logged["latencyMS"]
+	test.Assert(t, present, "latencyMS missing from JSON log line")
+}
+
+// requestIDHandler records the request ID in the context it's given.
+type requestIDHandler struct {
+	requestID *string
+}
+
+func (h requestIDHandler) ServeHTTP(e *RequestEvent, w http.ResponseWriter, r *http.Request) {
+	WFEHandlerFunc(func(ctx context.Context, _ *RequestEvent, _ http.ResponseWriter, _ *http.Request) {
+		*h.requestID = requestid.FromContext(ctx)
+	}).ServeHTTP(e, w, r)
+}
+
+func TestRequestID(t *testing.T) {
+	// Every request gets a request ID, whether or not it's logged as JSON,
+	// which is carried in the context given to the WFE's handlers.
+	var requestID string
+	th := NewTopHandler(blog.NewMock(), requestIDHandler{&requestID})
+	th.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	test.AssertEquals(t, len(requestID), 16)
+	firstID := requestID
+
+	th.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	test.AssertNotEquals(t, requestID, firstID)
+}
+
 type hostHeaderHandler struct {
 	f func(*RequestEvent, http.ResponseWriter, *http.Request)
 }
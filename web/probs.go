@@ -2,6 +2,7 @@ package web
 
 import (
 	"fmt"
+	"net/http"
 
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/probs"
@@ -19,6 +20,18 @@ func problemDetailsForBoulderError(err *berrors.BoulderError, msg string) *probs
 		outProb = probs.NotFound(fmt.Sprintf("%s :: %s", msg, err))
 	case berrors.RateLimit:
 		outProb = probs.RateLimited(fmt.Sprintf("%s :: %s", msg, err))
+		if err.RateLimit != nil {
+			// A rate limit error may only suggest when to retry, without
+			// naming a configured limit.
+			if err.RateLimit.Name != "" {
+				outProb.RateLimit = &probs.RateLimitDetails{
+					Limit:     err.RateLimit.Name,
+					Usage:     err.RateLimit.Usage,
+					Threshold: err.RateLimit.Threshold,
+				}
+			}
+			outProb.RetryAfter = err.RateLimit.RetryAfter
+		}
 	case berrors.InternalServer:
 		// Internal server error messages may include sensitive data, so we do
 		// not include it.
@@ -33,14 +46,21 @@ func problemDetailsForBoulderError(err *berrors.BoulderError, msg string) *probs
 		outProb = probs.CAA(fmt.Sprintf("%s :: %s", msg, err))
 	case berrors.MissingSCTs:
 		// MissingSCTs are an internal server error, but with a specific error
-		// message related to the SCT problem
+		// message related to the SCT problem. They're caused by CT logs being
+		// slow or unavailable, so they're sent with a 503 status to tell the
+		// client that trying again later may succeed.
 		outProb = probs.ServerInternal(fmt.Sprintf("%s :: %s", msg, "Unable to meet CA SCT embedding requirements"))
+		outProb.HTTPStatus = http.StatusServiceUnavailable
 	case berrors.OrderNotReady:
 		outProb = probs.OrderNotReady(fmt.Sprintf("%s :: %s", msg, err))
 	case berrors.BadPublicKey:
 		outProb = probs.BadPublicKey(fmt.Sprintf("%s :: %s", msg, err))
 	case berrors.BadCSR:
 		outProb = probs.BadCSR(fmt.Sprintf("%s :: %s", msg, err))
+	case berrors.Paused:
+		outProb = probs.UserActionRequired("%s :: %s", msg, err)
+	case berrors.AlreadyReplaced:
+		outProb = probs.AlreadyReplaced("%s :: %s", msg, err)
 	default:
 		// Internal server error messages may include sensitive data, so we do
 		// not include it.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/identifier"
@@ -37,6 +38,9 @@ func TestProblemDetailsFromError(t *testing.T) {
 		{berrors.RateLimitError(detailMsg), 429, probs.RateLimitedProblem, fullDetail + ": see https://letsencrypt.org/docs/rate-limits/"},
 		{berrors.InvalidEmailError(detailMsg), 400, probs.InvalidEmailProblem, fullDetail},
 		{berrors.RejectedIdentifierError(detailMsg), 400, probs.RejectedIdentifierProblem, fullDetail},
+		{berrors.PausedError(detailMsg), 403, probs.UserActionRequiredProblem, fullDetail},
+		{berrors.AlreadyReplacedError(detailMsg), 409, probs.AlreadyReplacedProblem, fullDetail},
+		{berrors.MissingSCTsError(detailMsg), 503, probs.ServerInternalProblem, errMsg + " :: Unable to meet CA SCT embedding requirements"},
 	}
 	for _, c := range testCases {
 		p := ProblemDetailsForError(c.err, errMsg)
@@ -99,3 +103,34 @@ func TestSubProblems(t *testing.T) {
 	test.AssertEquals(t, subProbA.Type, probs.CAAProblem)
 	test.AssertEquals(t, subProbB.Type, probs.MalformedProblem)
 }
+
+func TestRateLimitProblemDetails(t *testing.T) {
+	details := berrors.RateLimitDetails{
+		Name:       "certificatesPerName",
+		Usage:      51,
+		Threshold:  50,
+		RetryAfter: time.Hour,
+	}
+	topErr := berrors.RateLimitExceededError(details, "too many certificates").(*berrors.BoulderError).WithSubErrors(
+		[]berrors.SubBoulderError{
+			{
+				Identifier:   identifier.DNSIdentifier("example.com"),
+				BoulderError: berrors.RateLimitExceededError(details, "too many certificates").(*berrors.BoulderError),
+			},
+		})
+
+	prob := ProblemDetailsForError(topErr, "rate limited")
+	expected := &probs.RateLimitDetails{Limit: "certificatesPerName", Usage: 51, Threshold: 50}
+	test.AssertDeepEquals(t, prob.RateLimit, expected)
+	test.AssertEquals(t, prob.RetryAfter, time.Hour)
+	test.AssertEquals(t, len(prob.SubProblems), 1)
+	test.AssertDeepEquals(t, prob.SubProblems[0].RateLimit, expected)
+
+	// A rate limit error which only suggests when to retry has no limit
+	// details.
+	prob = ProblemDetailsForError(
+		berrors.RateLimitExceededError(berrors.RateLimitDetails{RetryAfter: time.Minute}, "slow down"),
+		"rate limited")
+	test.Assert(t, prob.RateLimit == nil, "rate limit details for an unnamed limit")
+	test.AssertEquals(t, prob.RetryAfter, time.Minute)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"google.golang.org/grpc"
@@ -14,11 +16,18 @@ import (
 	"github.com/letsencrypt/boulder/probs"
 )
 
+// serviceUnavailableRetryAfter is the Retry-After header value, in seconds,
+// sent with problems that have a 503 Service Unavailable status.
+const serviceUnavailableRetryAfter = "60"
+
 // SendError does a few things that we want for each error response:
 //  - Adds both the external and the internal error to a RequestEvent.
 //  - If the ProblemDetails provided is a ServerInternalProblem, audit logs the
 //    internal error.
 //  - Prefixes the Type field of the ProblemDetails with a namespace.
+//  - Adds a Retry-After header to 503 Service Unavailable responses, and to
+//    any others for which the problem has a RetryAfter.
+//  - Sets the problem's RequestID, and includes it in any audit log line.
 //  - Sends an HTTP response containing the error and an error code to the user.
 func SendError(
 	log blog.Logger,
@@ -28,11 +37,14 @@ func SendError(
 	prob *probs.ProblemDetails,
 	ierr error,
 ) {
+	log = blog.WithRequestID(log, logEvent.RequestID)
+
 	// Determine the HTTP status code to use for this problem
 	code := probs.ProblemDetailsToStatusCode(prob)
 
 	// Record details to the log event
 	logEvent.Error = fmt.Sprintf("%d :: %s :: %s", prob.HTTPStatus, prob.Type, prob.Detail)
+	logEvent.ErrorType = string(prob.Type)
 	if len(prob.SubProblems) > 0 {
 		subDetails := make([]string, len(prob.SubProblems))
 		for i, sub := range prob.SubProblems {
@@ -58,11 +70,14 @@ func SendError(
 	}
 
 	// Set the proper namespace for the problem and any
-	// sub-problems
+	// sub-problems. Each sub-problem keeps its own type, which may differ from
+	// the top-level problem's, e.g. a malformed identifier in an order
+	// rejected with rejectedIdentifier.
 	prob.Type = probs.ProblemType(namespace) + prob.Type
 	for i := range prob.SubProblems {
-		prob.SubProblems[i].Type = prob.Type
+		prob.SubProblems[i].Type = probs.ProblemType(namespace) + prob.SubProblems[i].Type
 	}
+	prob.RequestID = logEvent.RequestID
 	problemDoc, err := json.MarshalIndent(prob, "", "  ")
 	if err != nil {
 		log.AuditErrf("Could not marshal error message: %s - %+v", err, prob)
@@ -71,6 +86,11 @@ func SendError(
 
 	// Write the JSON problem response
 	response.Header().Set("Content-Type", "application/problem+json")
+	if prob.RetryAfter > 0 {
+		response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(prob.RetryAfter.Seconds()))))
+	} else if code == http.StatusServiceUnavailable {
+		response.Header().Set("Retry-After", serviceUnavailableRetryAfter)
+	}
 	response.WriteHeader(code)
 	response.Write(problemDoc)
 }
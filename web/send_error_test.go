@@ -2,8 +2,10 @@ package web
 
 import (
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/identifier"
@@ -28,7 +30,7 @@ func TestSendErrorSubProblemNamespace(t *testing.T) {
 			{
 				Identifier: identifier.DNSIdentifier("what about example.com"),
 				BoulderError: &berrors.BoulderError{
-					Type:   berrors.Malformed,
+					Type:   berrors.RejectedIdentifier,
 					Detail: "nah",
 				},
 			},
@@ -53,7 +55,7 @@ func TestSendErrorSubProblemNamespace(t *testing.T) {
 			}
 		  },
 		  {
-			"type": "namespace:test:malformed",
+			"type": "namespace:test:rejectedIdentifier",
 			"detail": "dfoop :: nah",
 			"status": 400,
 			"identifier": {
@@ -94,3 +96,54 @@ func TestSendErrorSubProbLogging(t *testing.T) {
 
 	test.AssertEquals(t, logEvent.Error, `400 :: malformed :: dfoop :: bad ["example.com :: malformed :: dfoop :: nop", "what about example.com :: malformed :: dfoop :: nah"]`)
 }
+
+func TestSendErrorRetryAfter(t *testing.T) {
+	rw := httptest.NewRecorder()
+	prob := ProblemDetailsForError(berrors.MissingSCTsError("no SCTs"), "dfoop")
+	SendError(log.NewMock(), "namespace:test:", rw, &RequestEvent{}, prob, nil)
+	test.AssertEquals(t, rw.Code, http.StatusServiceUnavailable)
+	test.AssertEquals(t, rw.Header().Get("Retry-After"), serviceUnavailableRetryAfter)
+
+	rw = httptest.NewRecorder()
+	prob = ProblemDetailsForError(berrors.InternalServerError("oops"), "dfoop")
+	SendError(log.NewMock(), "namespace:test:", rw, &RequestEvent{}, prob, nil)
+	test.AssertEquals(t, rw.Header().Get("Retry-After"), "")
+}
+
+func TestSendErrorRateLimitRetryAfter(t *testing.T) {
+	rw := httptest.NewRecorder()
+	prob := ProblemDetailsForError(berrors.RateLimitExceededError(berrors.RateLimitDetails{
+		Name:       "newOrdersPerAccount",
+		Usage:      300,
+		Threshold:  300,
+		RetryAfter: 3*time.Hour + 500*time.Millisecond,
+	}, "too many new orders recently"), "dfoop")
+	SendError(log.NewMock(), "namespace:test:", rw, &RequestEvent{}, prob, nil)
+	test.AssertEquals(t, rw.Code, http.StatusTooManyRequests)
+	// Retry-After is rounded up to a whole second.
+	test.AssertEquals(t, rw.Header().Get("Retry-After"), "10801")
+	test.AssertUnmarshaledEquals(t, rw.Body.String(), `{
+		"type": "namespace:test:rateLimited",
+		"detail": "dfoop :: too many new orders recently: see https://letsencrypt.org/docs/rate-limits/",
+		"status": 429,
+		"rateLimit": {
+			"limit": "newOrdersPerAccount",
+			"usage": 300,
+			"threshold": 300
+		}
+	}`)
+}
+
+func TestSendErrorRequestID(t *testing.T) {
+	rw := httptest.NewRecorder()
+	mockLog := log.NewMock()
+	prob := ProblemDetailsForError(berrors.InternalServerError("oops"), "dfoop")
+	SendError(mockLog, "namespace:test:", rw, &RequestEvent{RequestID: "abcd"}, prob, errors.New("it bad"))
+	test.AssertUnmarshaledEquals(t, rw.Body.String(), `{
+		"type": "namespace:test:serverInternal",
+		"detail": "dfoop",
+		"status": 500,
+		"requestID": "abcd"
+	}`)
+	test.AssertEquals(t, len(mockLog.GetAllMatching(`Internal error - dfoop - it bad requestID=\[abcd\]`)), 1)
+}
@@ -775,11 +775,13 @@ func (wfe *WebFrontEndImpl) NewAuthorization(ctx context.Context, logEvent *web.
 }
 
 func (wfe *WebFrontEndImpl) regHoldsAuthorizations(ctx context.Context, regID int64, names []string) (bool, error) {
-	now := wfe.clk.Now().UnixNano()
+	now := wfe.clk.Now()
+	nowNanos := now.UnixNano()
 	authzMapPB, err := wfe.SA.GetValidAuthorizations2(ctx, &sapb.GetValidAuthorizationsRequest{
 		RegistrationID: &regID,
 		Domains:        names,
-		Now:            &now,
+		Now:            &nowNanos,
+		NowTime:        bgrpc.TimeToPB(now),
 	})
 	if err != nil {
 		return false, err
@@ -838,11 +840,17 @@ func (wfe *WebFrontEndImpl) RevokeCertificate(ctx context.Context, logEvent *web
 		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve certificate"), err)
 		return
 	}
-	if !bytes.Equal(cert.DER, revokeRequest.CertificateDER) {
+	// Certificates stored without their DER are matched by digest instead.
+	if len(cert.DER) == 0 {
+		if cert.Digest != core.Fingerprint256(revokeRequest.CertificateDER) {
+			wfe.sendError(response, logEvent, probs.NotFound("No such certificate"), err)
+			return
+		}
+	} else if !bytes.Equal(cert.DER, revokeRequest.CertificateDER) {
 		wfe.sendError(response, logEvent, probs.NotFound("No such certificate"), err)
 		return
 	}
-	parsedCertificate, err := x509.ParseCertificate(cert.DER)
+	parsedCertificate, err := x509.ParseCertificate(revokeRequest.CertificateDER)
 	if err != nil {
 		// InternalServerError because this is a failure to decode from our DB.
 		wfe.sendError(response, logEvent, probs.ServerInternal("invalid parse of stored certificate"), err)
@@ -1460,6 +1468,11 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 		}
 		return
 	}
+	if len(cert.DER) == 0 {
+		// The certificate's DER was pruned once its retention period passed.
+		wfe.sendError(response, logEvent, probs.NotFound("Certificate no longer available"), nil)
+		return
+	}
 
 	// TODO Content negotiation
 	response.Header().Set("Content-Type", "application/pkix-cert")
@@ -253,6 +253,22 @@ func (ra *MockRegistrationAuthority) FinalizeOrder(ctx context.Context, _ *rapb.
 	return nil, nil
 }
 
+func (ra *MockRegistrationAuthority) SetRevocationWebhook(_ context.Context, _ *rapb.SetRevocationWebhookRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+func (ra *MockRegistrationAuthority) UnpauseAccount(_ context.Context, _ *rapb.UnpauseAccountRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+func (ra *MockRegistrationAuthority) RevokeCertByKey(_ context.Context, _ *rapb.RevokeCertByKeyRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+func (ra *MockRegistrationAuthority) AdministrativelyRevokeCertificates(_ context.Context, _ func() (*rapb.AdministrativelyRevokeCertificatesRequest, error)) (*rapb.AdministrativelyRevokeCertificatesResponse, error) {
+	return &rapb.AdministrativelyRevokeCertificatesResponse{}, nil
+}
+
 type mockPA struct{}
 
 func (pa *mockPA) ChallengesFor(identifier identifier.ACMEIdentifier) (challenges []core.Challenge, err error) {
@@ -403,6 +419,22 @@ func mustParseURL(s string) *url.URL {
 	}
 }
 
+// withoutRequestID checks that body, a problem document sent through the
+// WFE's handler, includes a request ID, and returns it without the request
+// ID, which is random, for comparison with the expected problem.
+func withoutRequestID(t *testing.T, body string) string {
+	t.Helper()
+	var prob map[string]interface{}
+	err := json.Unmarshal([]byte(body), &prob)
+	test.AssertNotError(t, err, "Unmarshaling problem")
+	requestID, _ := prob["requestID"].(string)
+	test.Assert(t, requestID != "", "Problem had no request ID")
+	delete(prob, "requestID")
+	stripped, err := json.Marshal(prob)
+	test.AssertNotError(t, err, "Marshaling problem")
+	return string(stripped)
+}
+
 func sortHeader(s string) string {
 	a := strings.Split(s, ", ")
 	sort.Strings(a)
@@ -479,7 +511,7 @@ func TestHandleFunc(t *testing.T) {
 			test.AssertEquals(t, rw.Code, http.StatusMethodNotAllowed)
 			test.AssertEquals(t, sortHeader(rw.Header().Get("Allow")), sortHeader(strings.Join(addHeadIfGet(c.allowed), ", ")))
 			test.AssertUnmarshaledEquals(t,
-				rw.Body.String(),
+				withoutRequestID(t, rw.Body.String()),
 				`{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 		}
 		nonce := rw.Header().Get("Replay-Nonce")
@@ -491,7 +523,7 @@ func TestHandleFunc(t *testing.T) {
 	// Disallowed method returns error JSON in body
 	runWrappedHandler(&http.Request{Method: "PUT"}, "GET", "POST")
 	test.AssertEquals(t, rw.Header().Get("Content-Type"), "application/problem+json")
-	test.AssertUnmarshaledEquals(t, rw.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, rw.Body.String()), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 	test.AssertEquals(t, sortHeader(rw.Header().Get("Allow")), "GET, HEAD, POST")
 
 	// Disallowed method special case: response to HEAD has got no body
@@ -505,7 +537,7 @@ func TestHandleFunc(t *testing.T) {
 	test.AssertEquals(t, rw.Code, http.StatusMethodNotAllowed)
 	test.AssertEquals(t, rw.Header().Get("Content-Type"), "application/problem+json")
 	test.AssertEquals(t, rw.Header().Get("Allow"), "POST")
-	test.AssertUnmarshaledEquals(t, rw.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, rw.Body.String()), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 
 	wfe.AllowOrigins = []string{"*"}
 	testOrigin := "https://example.com"
@@ -829,6 +861,18 @@ func (cr noopCAA) IsCAAValid(
 	return &vapb.IsCAAValidResponse{}, nil
 }
 
+func (cr noopCAA) IsCAAValidForNames(
+	ctx context.Context,
+	in *vapb.IsCAAValidForNamesRequest,
+	opts ...grpc.CallOption,
+) (*vapb.IsCAAValidForNamesResponse, error) {
+	resp := &vapb.IsCAAValidForNamesResponse{}
+	for range in.Checks {
+		resp.Results = append(resp.Results, &vapb.IsCAAValidResponse{})
+	}
+	return resp, nil
+}
+
 func TestRelativeDirectory(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	mux := wfe.Handler(metrics.NoopRegisterer)
@@ -895,7 +939,7 @@ func TestIssueCertificate(t *testing.T) {
 	// authorized, etc.
 	stats := metrics.NoopRegisterer
 
-	ctp := ctpolicy.New(&mocks.Publisher{}, nil, nil, wfe.log, metrics.NoopRegisterer)
+	ctp := ctpolicy.New(&mocks.Publisher{}, nil, nil, nil, wfe.log, metrics.NoopRegisterer)
 	ra := ra.NewRegistrationAuthorityImpl(
 		fc,
 		wfe.log,
@@ -927,7 +971,7 @@ func TestIssueCertificate(t *testing.T) {
 		URL:    mustParseURL(newCertPath),
 	})
 	test.AssertUnmarshaledEquals(t,
-		responseWriter.Body.String(),
+		withoutRequestID(t, responseWriter.Body.String()),
 		`{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 
 	// POST, but no body.
@@ -1445,7 +1489,7 @@ func TestNewRegistration(t *testing.T) {
 	for _, rt := range regErrTests {
 		responseWriter := httptest.NewRecorder()
 		mux.ServeHTTP(responseWriter, rt.r)
-		test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), rt.respBody)
+		test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()), rt.respBody)
 	}
 
 	responseWriter := httptest.NewRecorder()
@@ -1785,7 +1829,7 @@ func TestAuthorization(t *testing.T) {
 		Method: "GET",
 		URL:    mustParseURL(newAuthzPath),
 	})
-	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 
 	// POST, but no body.
 	responseWriter.Body.Reset()
@@ -1975,7 +2019,7 @@ func TestRegistration(t *testing.T) {
 		Body:   makeBody("invalid"),
 	})
 	test.AssertUnmarshaledEquals(t,
-		responseWriter.Body.String(),
+		withoutRequestID(t, responseWriter.Body.String()),
 		`{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 	responseWriter.Body.Reset()
 
@@ -1985,7 +2029,7 @@ func TestRegistration(t *testing.T) {
 		URL:    mustParseURL(regPath),
 	})
 	test.AssertUnmarshaledEquals(t,
-		responseWriter.Body.String(),
+		withoutRequestID(t, responseWriter.Body.String()),
 		`{"type":"`+probs.V1ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 	responseWriter.Body.Reset()
 
@@ -2122,7 +2166,7 @@ func TestGetCertificate(t *testing.T) {
 	mux.ServeHTTP(responseWriter, req)
 	test.AssertEquals(t, responseWriter.Code, 404)
 	test.AssertEquals(t, responseWriter.Header().Get("Cache-Control"), "public, max-age=0, no-cache")
-	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Certificate not found","status":404}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Certificate not found","status":404}`)
 
 	// Internal server error, no cache
 	mockLog.Clear()
@@ -2133,7 +2177,7 @@ func TestGetCertificate(t *testing.T) {
 	mux.ServeHTTP(responseWriter, req)
 	test.AssertEquals(t, responseWriter.Code, 500)
 	test.AssertEquals(t, responseWriter.Header().Get("Cache-Control"), "public, max-age=0, no-cache")
-	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`serverInternal","detail":"Failed to retrieve certificate","status":500}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()), `{"type":"`+probs.V1ErrorNS+`serverInternal","detail":"Failed to retrieve certificate","status":500}`)
 
 	// Invalid serial, no cache
 	responseWriter = httptest.NewRecorder()
@@ -2141,7 +2185,7 @@ func TestGetCertificate(t *testing.T) {
 	mux.ServeHTTP(responseWriter, req)
 	test.AssertEquals(t, responseWriter.Code, 404)
 	test.AssertEquals(t, responseWriter.Header().Get("Cache-Control"), "public, max-age=0, no-cache")
-	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Certificate not found","status":404}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Certificate not found","status":404}`)
 
 	// Invalid serial, no cache
 	responseWriter = httptest.NewRecorder()
@@ -2149,7 +2193,7 @@ func TestGetCertificate(t *testing.T) {
 	mux.ServeHTTP(responseWriter, req)
 	test.AssertEquals(t, responseWriter.Code, 404)
 	test.AssertEquals(t, responseWriter.Header().Get("Cache-Control"), "public, max-age=0, no-cache")
-	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Certificate not found","status":404}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Certificate not found","status":404}`)
 }
 
 func assertCsrLogged(t *testing.T, mockLog *blog.Mock) {
@@ -2659,7 +2703,7 @@ func TestNewCertificateSCTError(t *testing.T) {
 	// a serverInternal error with the right message.
 	test.AssertUnmarshaledEquals(t,
 		responseWriter.Body.String(),
-		`{"type":"`+probs.V1ErrorNS+`serverInternal","detail":"Error creating new cert :: Unable to meet CA SCT embedding requirements","status":500}`)
+		`{"type":"`+probs.V1ErrorNS+`serverInternal","detail":"Error creating new cert :: Unable to meet CA SCT embedding requirements","status":503}`)
 }
 
 type mockSAGetRegByKeyNotFoundAfterVerify struct {
@@ -0,0 +1,212 @@
+package wfe2
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/probs"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/web"
+)
+
+// renewalInfoRetryAfter is how long clients are asked to wait before fetching
+// a certificate's renewal information again.
+const renewalInfoRetryAfter = 6 * time.Hour
+
+// renewalInfoJSON is the renewalInfo resource described by
+// draft-ietf-acme-ari: the window within which the client should renew a
+// certificate.
+type renewalInfoJSON struct {
+	SuggestedWindow suggestedWindow `json:"suggestedWindow"`
+}
+
+type suggestedWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// suggestedRenewalWindow returns the window within which a certificate valid
+// from created until expires should be renewed: centered two thirds of the way
+// through its lifetime, and lasting 2% of it. A revoked certificate should be
+// renewed immediately, so its window has already passed.
+func suggestedRenewalWindow(created, expires time.Time, revoked bool, now time.Time) suggestedWindow {
+	if revoked {
+		return suggestedWindow{
+			Start: now.Add(-time.Hour).UTC().Truncate(time.Second),
+			End:   now.Add(-time.Minute).UTC().Truncate(time.Second),
+		}
+	}
+	lifetime := expires.Sub(created)
+	ideal := expires.Add(-lifetime / 3)
+	return suggestedWindow{
+		Start: ideal.Add(-lifetime / 100).UTC().Truncate(time.Second),
+		End:   ideal.Add(lifetime / 100).UTC().Truncate(time.Second),
+	}
+}
+
+// parseCertID parses a draft-ietf-acme-ari certificate identifier, the
+// base64url encoded authority key identifier and serial number of a
+// certificate joined by a ".", returning the certificate's serial. The
+// authority key identifier must be that of one of the WFE's issuers.
+func (wfe *WebFrontEndImpl) parseCertID(certID string) (string, *probs.ProblemDetails) {
+	parts := strings.Split(certID, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", probs.Malformed("Certificate ID %q isn't of the form <authority key identifier>.<serial>", certID)
+	}
+	akid, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", probs.Malformed("Certificate ID has a malformed authority key identifier: %s", err)
+	}
+	serialBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", probs.Malformed("Certificate ID has a malformed serial: %s", err)
+	}
+	knownIssuer := false
+	for _, issuer := range wfe.issuerCertificates {
+		if len(issuer.SubjectKeyId) > 0 && bytes.Equal(issuer.SubjectKeyId, akid) {
+			knownIssuer = true
+			break
+		}
+	}
+	if !knownIssuer {
+		return "", probs.NotFound("Certificate ID names an unknown issuer")
+	}
+	serial := core.SerialToString(new(big.Int).SetBytes(serialBytes))
+	if !core.ValidSerial(serial) {
+		return "", probs.Malformed("Certificate ID has an invalid serial")
+	}
+	return serial, nil
+}
+
+// RenewalInfo serves the renewalInfo resource of the certificate whose
+// draft-ietf-acme-ari certificate identifier is the request path.
+func (wfe *WebFrontEndImpl) RenewalInfo(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+	if !features.Enabled(features.RenewalInfo) {
+		wfe.sendError(response, logEvent, probs.NotFound("Renewal information is not supported"), nil)
+		return
+	}
+
+	serial, prob := wfe.parseCertID(request.URL.Path)
+	if prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
+	logEvent.Extra["RequestedSerial"] = serial
+
+	metadata, err := wfe.SA.GetSerialMetadata(ctx, &sapb.Serial{Serial: &serial})
+	if err != nil {
+		if berrors.Is(err, berrors.NotFound) {
+			wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"), nil)
+			return
+		}
+		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve certificate"), err)
+		return
+	}
+	status, err := wfe.SA.GetCertificateStatus(ctx, serial)
+	if err != nil {
+		if berrors.Is(err, berrors.NotFound) {
+			wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"), nil)
+			return
+		}
+		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve certificate status"), err)
+		return
+	}
+
+	created, err := bgrpc.PBOrNanosToTime(metadata.CreatedTime, metadata.Created)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve certificate"), err)
+		return
+	}
+	expires, err := bgrpc.PBOrNanosToTime(metadata.ExpiresTime, metadata.Expires)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve certificate"), err)
+		return
+	}
+	window := suggestedRenewalWindow(
+		created,
+		expires,
+		status.Status == core.OCSPStatusRevoked,
+		wfe.clk.Now())
+	response.Header().Set("Retry-After", strconv.Itoa(int(renewalInfoRetryAfter.Seconds())))
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, renewalInfoJSON{SuggestedWindow: window})
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Error marshaling renewal info"), err)
+		return
+	}
+}
+
+// validateReplacement checks that a new order for names by acct may claim to
+// replace the certificate with the given draft-ietf-acme-ari certificate
+// identifier, returning the certificate's serial if so. The certificate must
+// have been issued to the same account, for at least one of the same names,
+// and not already have been replaced.
+func (wfe *WebFrontEndImpl) validateReplacement(ctx context.Context, acct *core.Registration, names []string, certID string) (string, *probs.ProblemDetails) {
+	serial, prob := wfe.parseCertID(certID)
+	if prob != nil {
+		return "", prob
+	}
+
+	cert, err := wfe.SA.GetCertificate(ctx, serial)
+	if err != nil {
+		if berrors.Is(err, berrors.NotFound) {
+			return "", probs.Malformed("Certificate %s being replaced was not found", serial)
+		}
+		return "", probs.ServerInternal("Failed to retrieve certificate being replaced")
+	}
+	if cert.RegistrationID != acct.ID {
+		return "", probs.Unauthorized(fmt.Sprintf(
+			"Certificate %s being replaced was not issued to the requesting account", serial))
+	}
+	der := cert.DER
+	if len(der) == 0 {
+		// The certificate's DER has been pruned, so its names have to come
+		// from the precertificate instead.
+		precert, err := wfe.SA.GetPrecertificate(ctx, &sapb.Serial{Serial: &serial})
+		if err != nil {
+			return "", probs.ServerInternal("Failed to retrieve certificate being replaced")
+		}
+		der = precert.Der
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		return "", probs.ServerInternal("Failed to parse certificate being replaced")
+	}
+	certNames := make(map[string]bool, len(parsed.DNSNames)+len(parsed.IPAddresses))
+	for _, name := range parsed.DNSNames {
+		certNames[strings.ToLower(name)] = true
+	}
+	for _, ip := range parsed.IPAddresses {
+		certNames[ip.String()] = true
+	}
+	sharesName := false
+	for _, name := range names {
+		if certNames[strings.ToLower(name)] {
+			sharesName = true
+			break
+		}
+	}
+	if !sharesName {
+		return "", probs.Malformed("Certificate %s being replaced has none of the order's identifiers", serial)
+	}
+
+	exists, err := wfe.SA.ReplacementOrderExists(ctx, &sapb.Serial{Serial: &serial})
+	if err != nil {
+		return "", probs.ServerInternal("Failed to check for an existing replacement order")
+	}
+	if exists.GetExists() {
+		return "", probs.AlreadyReplaced("Certificate %s has already been replaced by another order", serial)
+	}
+	return serial, nil
+}
@@ -0,0 +1,211 @@
+package wfe2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/mocks"
+	"github.com/letsencrypt/boulder/probs"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// certIDFor returns the draft-ietf-acme-ari certificate identifier of the
+// certificate with the given serial issued by the WFE's first issuer.
+func certIDFor(wfe WebFrontEndImpl, serial int64) string {
+	return fmt.Sprintf("%s.%s",
+		base64.RawURLEncoding.EncodeToString(wfe.issuerCertificates[0].SubjectKeyId),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(serial).Bytes()))
+}
+
+func TestSuggestedRenewalWindow(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := created.Add(90 * 24 * time.Hour)
+	now := created.Add(24 * time.Hour)
+
+	// The window is centered 60 days into a 90 day certificate's lifetime, and
+	// lasts 1.8 days.
+	window := suggestedRenewalWindow(created, expires, false, now)
+	test.AssertEquals(t, window.Start, time.Date(2020, 2, 29, 2, 24, 0, 0, time.UTC))
+	test.AssertEquals(t, window.End, time.Date(2020, 3, 1, 21, 36, 0, 0, time.UTC))
+
+	// A revoked certificate's window has already passed.
+	window = suggestedRenewalWindow(created, expires, true, now)
+	test.Assert(t, window.Start.Before(window.End), "window starts after it ends")
+	test.Assert(t, window.End.Before(now), "revoked certificate's window hasn't passed")
+}
+
+func TestRenewalInfo(t *testing.T) {
+	wfe, fc := setupWFE(t)
+
+	err := features.Set(map[string]bool{"RenewalInfo": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	renewalInfo := func(certID string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		wfe.RenewalInfo(ctx, newRequestEvent(), responseWriter, &http.Request{
+			Method: http.MethodGet,
+			URL:    mustParseURL(certID),
+		})
+		return responseWriter
+	}
+
+	// The mock SA's certificate with serial 0xee was created an hour ago, is
+	// valid for 90 days, and hasn't been revoked.
+	responseWriter := renewalInfo(certIDFor(wfe, 0xee))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "21600")
+	var info renewalInfoJSON
+	err = json.Unmarshal(responseWriter.Body.Bytes(), &info)
+	test.AssertNotError(t, err, "unmarshaling renewal info")
+	created := fc.Now().Add(-time.Hour)
+	expected := suggestedRenewalWindow(created, created.Add(90*24*time.Hour), false, fc.Now())
+	test.AssertEquals(t, info.SuggestedWindow.Start, expected.Start)
+	test.AssertEquals(t, info.SuggestedWindow.End, expected.End)
+
+	// Its certificate with serial 0xb2 has been revoked, so should be renewed
+	// immediately.
+	responseWriter = renewalInfo(certIDFor(wfe, 0xb2))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	err = json.Unmarshal(responseWriter.Body.Bytes(), &info)
+	test.AssertNotError(t, err, "unmarshaling renewal info")
+	test.Assert(t, info.SuggestedWindow.End.Before(fc.Now()), "revoked certificate's window hasn't passed")
+
+	testCases := []struct {
+		name         string
+		certID       string
+		expectedCode int
+	}{
+		{"unknown serial", certIDFor(wfe, 0x1337), http.StatusNotFound},
+		{"unknown issuer", "AAAA." + base64.RawURLEncoding.EncodeToString([]byte{0xee}), http.StatusNotFound},
+		{"no serial", base64.RawURLEncoding.EncodeToString(wfe.issuerCertificates[0].SubjectKeyId), http.StatusBadRequest},
+		{"malformed serial", base64.RawURLEncoding.EncodeToString(wfe.issuerCertificates[0].SubjectKeyId) + ".!!", http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			test.AssertEquals(t, renewalInfo(tc.certID).Code, tc.expectedCode)
+		})
+	}
+
+	// Without the feature, there is no renewal info.
+	features.Reset()
+	test.AssertEquals(t, renewalInfo(certIDFor(wfe, 0xee)).Code, http.StatusNotFound)
+}
+
+// alreadyReplacedSA is a mock SA for which every certificate has already been
+// replaced.
+type alreadyReplacedSA struct {
+	*mocks.StorageAuthority
+}
+
+func (sa alreadyReplacedSA) ReplacementOrderExists(context.Context, *sapb.Serial) (*sapb.Exists, error) {
+	exists := true
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// racingReplacementRA is a mock RA for which another order replaced the
+// certificate between the WFE's check and the order being stored.
+type racingReplacementRA struct {
+	MockRegistrationAuthority
+}
+
+func (ra *racingReplacementRA) NewOrder(context.Context, *rapb.NewOrderRequest) (*corepb.Order, error) {
+	return nil, berrors.AlreadyReplacedError("certificate has already been replaced by another order")
+}
+
+// precertOnlySA is a mock SA whose certificates' DER has been pruned, but
+// whose precertificates are available.
+type precertOnlySA struct {
+	mockSAWithoutDER
+}
+
+func (sa precertOnlySA) GetPrecertificate(ctx context.Context, req *sapb.Serial) (*corepb.Certificate, error) {
+	cert, err := sa.StorageGetter.GetCertificate(ctx, req.GetSerial())
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Certificate{RegistrationID: &cert.RegistrationID, Serial: req.Serial, Der: cert.DER}, nil
+}
+
+func TestNewOrderReplaces(t *testing.T) {
+	wfe, fc := setupWFE(t)
+
+	err := features.Set(map[string]bool{"RenewalInfo": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+
+	signedURL := "http://localhost/new-order"
+	newOrder := func(replaces string, acctID int64) (*httptest.ResponseRecorder, map[string]interface{}) {
+		responseWriter := httptest.NewRecorder()
+		logEvent := newRequestEvent()
+		// The mock SA's certificate with serial 0xee is for bad.example.com.
+		body := fmt.Sprintf(`{"identifiers":[{"type": "dns", "value": "bad.example.com"}], "replaces":%q}`, replaces)
+		wfe.NewOrder(ctx, logEvent, responseWriter, signAndPost(t, "new-order", signedURL, body, acctID, wfe.nonceService))
+		return responseWriter, logEvent.Extra
+	}
+	problemType := func(responseWriter *httptest.ResponseRecorder) probs.ProblemType {
+		var prob probs.ProblemDetails
+		err := json.Unmarshal(responseWriter.Body.Bytes(), &prob)
+		test.AssertNotError(t, err, "unmarshaling problem")
+		return prob.Type
+	}
+
+	responseWriter, extra := newOrder(certIDFor(wfe, 0xee), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusCreated)
+	test.AssertEquals(t, extra["Replaces"], "0000000000000000000000000000000000ee")
+
+	// The replaced certificate must be known, issued to the same account, and
+	// for one of the order's names.
+	responseWriter, _ = newOrder(certIDFor(wfe, 0x1337), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+	test.AssertEquals(t, problemType(responseWriter), probs.V2ErrorNS+probs.MalformedProblem)
+	responseWriter, _ = newOrder(certIDFor(wfe, 0xee), 5)
+	test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+	test.AssertEquals(t, problemType(responseWriter), probs.V2ErrorNS+probs.UnauthorizedProblem)
+	responseWriter, _ = newOrder(certIDFor(wfe, 0xb2), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+	test.AssertEquals(t, problemType(responseWriter), probs.V2ErrorNS+probs.MalformedProblem)
+
+	// It mustn't have been replaced already.
+	wfe.SA = alreadyReplacedSA{mocks.NewStorageAuthority(fc)}
+	responseWriter, _ = newOrder(certIDFor(wfe, 0xee), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusConflict)
+	test.AssertEquals(t, problemType(responseWriter), probs.V2ErrorNS+probs.AlreadyReplacedProblem)
+
+	// Nor be replaced by an order stored concurrently, which the SA refuses.
+	wfe.SA = mocks.NewStorageAuthority(fc)
+	ra := wfe.RA
+	wfe.RA = &racingReplacementRA{}
+	responseWriter, _ = newOrder(certIDFor(wfe, 0xee), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusConflict)
+	test.AssertEquals(t, problemType(responseWriter), probs.V2ErrorNS+probs.AlreadyReplacedProblem)
+	wfe.RA = ra
+
+	// Certificates whose DER has been pruned are checked against their
+	// precertificates.
+	wfe.SA = precertOnlySA{mockSAWithoutDER{mocks.NewStorageAuthority(fc)}}
+	responseWriter, extra = newOrder(certIDFor(wfe, 0xee), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusCreated)
+	test.AssertEquals(t, extra["Replaces"], "0000000000000000000000000000000000ee")
+	responseWriter, _ = newOrder(certIDFor(wfe, 0xb2), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+	test.AssertEquals(t, problemType(responseWriter), probs.V2ErrorNS+probs.MalformedProblem)
+
+	// Without the feature, the replaces field is ignored.
+	features.Reset()
+	responseWriter, extra = newOrder(certIDFor(wfe, 0x1337), 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusCreated)
+	test.AssertEquals(t, extra["Replaces"], nil)
+}
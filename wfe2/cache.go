@@ -0,0 +1,219 @@
+package wfe2
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+// maxCacheEntries bounds the number of entries held by a ttlCache. Once it is
+// full, expired entries are evicted, and new entries aren't cached until there
+// is room.
+const maxCacheEntries = 10000
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// ttlCache is a size-bounded map whose entries expire a fixed time after they
+// are added.
+type ttlCache struct {
+	clk clock.Clock
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[interface{}]cacheEntry
+}
+
+func newTTLCache(clk clock.Clock, ttl time.Duration) *ttlCache {
+	return &ttlCache{clk: clk, ttl: ttl, entries: make(map[interface{}]cacheEntry)}
+}
+
+// get returns the value stored under key, if there is one and it hasn't
+// expired.
+func (c *ttlCache) get(key interface{}) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.clk.Now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// set stores value under key for the cache's TTL.
+func (c *ttlCache) set(key, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clk.Now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, expires: now.Add(c.ttl)}
+}
+
+// forget removes any value stored under key.
+func (c *ttlCache) forget(key interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// orderCacheKey identifies a cached order. UseV2Authorizations is part of the
+// key because it changes which authorizations the SA returns for the order.
+type orderCacheKey struct {
+	id          int64
+	useV2Authzs bool
+}
+
+// storageCache is a read-through cache in front of a core.StorageGetter for the
+// registrations, authorizations and orders that ACME clients poll
+// aggressively. Entries are kept for at most ttl. The WFE forgets entries
+// whenever it changes the underlying object through the RA, so a client only
+// sees stale data when the object was changed elsewhere, for example by the RA
+// completing a validation, and then for no longer than ttl.
+//
+// Registrations authenticate requests, so the WFE forgets an account as soon
+// as it updates it, deactivates it or rolls over its key. A change made
+// through another WFE or the admin-revoker may go unnoticed for up to ttl,
+// which is why ttl should be kept short.
+//
+// The forget methods may be called on a nil *storageCache.
+type storageCache struct {
+	core.StorageGetter
+	registrations *ttlCache
+	authzs        *ttlCache
+	orders        *ttlCache
+	lookups       *prometheus.CounterVec
+}
+
+func newStorageCache(sa core.StorageGetter, clk clock.Clock, ttl time.Duration, lookups *prometheus.CounterVec) *storageCache {
+	return &storageCache{
+		StorageGetter: sa,
+		registrations: newTTLCache(clk, ttl),
+		authzs:        newTTLCache(clk, ttl),
+		orders:        newTTLCache(clk, ttl),
+		lookups:       lookups,
+	}
+}
+
+// lookup gets key from cache, counting the result as a hit or miss for the
+// given type of object.
+func (c *storageCache) lookup(cache *ttlCache, typ string, key interface{}) (interface{}, bool) {
+	value, ok := cache.get(key)
+	if ok {
+		c.lookups.WithLabelValues(typ, "hit").Inc()
+	} else {
+		c.lookups.WithLabelValues(typ, "miss").Inc()
+	}
+	return value, ok
+}
+
+// GetRegistration implements core.StorageGetter. Callers receive their own
+// copy of the cached registration's contacts, which they are free to modify.
+func (c *storageCache) GetRegistration(ctx context.Context, regID int64) (core.Registration, error) {
+	if value, ok := c.lookup(c.registrations, "registration", regID); ok {
+		return copyRegistration(value.(core.Registration)), nil
+	}
+	reg, err := c.StorageGetter.GetRegistration(ctx, regID)
+	if err != nil {
+		return reg, err
+	}
+	c.registrations.set(regID, copyRegistration(reg))
+	return reg, nil
+}
+
+// copyRegistration returns a copy of reg which shares no contacts with it. The
+// key is shared, since it's replaced rather than modified.
+func copyRegistration(reg core.Registration) core.Registration {
+	if reg.Contact != nil {
+		contact := append([]string(nil), *reg.Contact...)
+		reg.Contact = &contact
+	}
+	return reg
+}
+
+// GetAuthorization2 implements core.StorageGetter. Callers receive their own
+// copy of the cached authorization, which they are free to modify.
+func (c *storageCache) GetAuthorization2(ctx context.Context, req *sapb.AuthorizationID2) (*corepb.Authorization, error) {
+	if value, ok := c.lookup(c.authzs, "authorization", req.GetId()); ok {
+		return proto.Clone(value.(*corepb.Authorization)).(*corepb.Authorization), nil
+	}
+	authz, err := c.StorageGetter.GetAuthorization2(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.authzs.set(req.GetId(), proto.Clone(authz))
+	return authz, nil
+}
+
+// GetOrder implements core.StorageGetter. Callers receive their own copy of the
+// cached order, which they are free to modify.
+func (c *storageCache) GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error) {
+	key := orderCacheKey{id: req.GetId(), useV2Authzs: req.GetUseV2Authorizations()}
+	if value, ok := c.lookup(c.orders, "order", key); ok {
+		return proto.Clone(value.(*corepb.Order)).(*corepb.Order), nil
+	}
+	order, err := c.StorageGetter.GetOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// A processing order is about to be updated by the RA, and clients poll
+	// it for exactly that, so it isn't cached.
+	if order.GetStatus() != string(core.StatusProcessing) {
+		c.orders.set(key, proto.Clone(order))
+	}
+	return order, nil
+}
+
+// forgetRegistration removes the registration with the given ID from the
+// cache.
+func (c *storageCache) forgetRegistration(regID int64) {
+	if c == nil {
+		return
+	}
+	c.registrations.forget(regID)
+}
+
+// forgetAuthorization removes the authorization with the given ID from the
+// cache. It takes the string form of the ID used by core.Authorization.
+func (c *storageCache) forgetAuthorization(authzID string) {
+	if c == nil {
+		return
+	}
+	id, err := strconv.ParseInt(authzID, 10, 64)
+	if err != nil {
+		return
+	}
+	c.authzs.forget(id)
+}
+
+// forgetOrder removes the order with the given ID from the cache.
+func (c *storageCache) forgetOrder(orderID int64) {
+	if c == nil {
+		return
+	}
+	c.orders.forget(orderCacheKey{id: orderID, useV2Authzs: true})
+	c.orders.forget(orderCacheKey{id: orderID, useV2Authzs: false})
+}
@@ -0,0 +1,200 @@
+package wfe2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/mocks"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// countingSA counts the calls which reach the underlying mock SA.
+type countingSA struct {
+	*mocks.StorageAuthority
+	regCalls   int
+	authzCalls int
+	orderCalls int
+}
+
+func (sa *countingSA) GetRegistration(ctx context.Context, id int64) (core.Registration, error) {
+	sa.regCalls++
+	return sa.StorageAuthority.GetRegistration(ctx, id)
+}
+
+func (sa *countingSA) GetAuthorization2(ctx context.Context, req *sapb.AuthorizationID2) (*corepb.Authorization, error) {
+	sa.authzCalls++
+	return sa.StorageAuthority.GetAuthorization2(ctx, req)
+}
+
+func (sa *countingSA) GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error) {
+	sa.orderCalls++
+	return sa.StorageAuthority.GetOrder(ctx, req)
+}
+
+func setupStorageCache(t *testing.T) (*storageCache, *countingSA, clock.FakeClock, *prometheus.CounterVec) {
+	fc := clock.NewFake()
+	sa := &countingSA{StorageAuthority: mocks.NewStorageAuthority(fc)}
+	lookups := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_cache_lookups",
+		Help: "test",
+	}, []string{"type", "result"})
+	return newStorageCache(sa, fc, time.Second, lookups), sa, fc, lookups
+}
+
+func TestStorageCacheRegistration(t *testing.T) {
+	cache, sa, fc, lookups := setupStorageCache(t)
+	ctx := context.Background()
+
+	_, err := cache.GetRegistration(ctx, 1)
+	test.AssertNotError(t, err, "GetRegistration failed")
+	_, err = cache.GetRegistration(ctx, 1)
+	test.AssertNotError(t, err, "GetRegistration failed")
+	test.AssertEquals(t, sa.regCalls, 1)
+	test.AssertEquals(t, test.CountCounter(lookups.WithLabelValues("registration", "hit")), 1)
+	test.AssertEquals(t, test.CountCounter(lookups.WithLabelValues("registration", "miss")), 1)
+
+	// Callers get their own copy of the contacts.
+	reg, err := cache.GetRegistration(ctx, 1)
+	test.AssertNotError(t, err, "GetRegistration failed")
+	test.AssertNotNil(t, reg.Contact, "GetRegistration returned no contacts")
+	(*reg.Contact)[0] = "mailto:changed@example.com"
+	reg, err = cache.GetRegistration(ctx, 1)
+	test.AssertNotError(t, err, "GetRegistration failed")
+	test.AssertNotEquals(t, (*reg.Contact)[0], "mailto:changed@example.com")
+	test.AssertEquals(t, sa.regCalls, 1)
+
+	// Errors shouldn't be cached.
+	_, err = cache.GetRegistration(ctx, 102)
+	test.AssertError(t, err, "GetRegistration didn't fail")
+	_, err = cache.GetRegistration(ctx, 102)
+	test.AssertError(t, err, "GetRegistration didn't fail")
+	test.AssertEquals(t, sa.regCalls, 3)
+
+	// Entries should expire after the TTL.
+	fc.Add(time.Second)
+	_, err = cache.GetRegistration(ctx, 1)
+	test.AssertNotError(t, err, "GetRegistration failed")
+	test.AssertEquals(t, sa.regCalls, 4)
+
+	// Forgotten entries should be fetched again.
+	cache.forgetRegistration(1)
+	_, err = cache.GetRegistration(ctx, 1)
+	test.AssertNotError(t, err, "GetRegistration failed")
+	test.AssertEquals(t, sa.regCalls, 5)
+}
+
+func TestStorageCacheAuthorization(t *testing.T) {
+	cache, sa, _, _ := setupStorageCache(t)
+	ctx := context.Background()
+	id := int64(1)
+	req := &sapb.AuthorizationID2{Id: &id}
+
+	authz, err := cache.GetAuthorization2(ctx, req)
+	test.AssertNotError(t, err, "GetAuthorization2 failed")
+	status := "invalid"
+	authz.Status = &status
+
+	// Modifying a returned authorization mustn't modify the cached copy.
+	authz, err = cache.GetAuthorization2(ctx, req)
+	test.AssertNotError(t, err, "GetAuthorization2 failed")
+	test.AssertEquals(t, authz.GetStatus(), string(core.StatusValid))
+	test.AssertEquals(t, sa.authzCalls, 1)
+
+	cache.forgetAuthorization("1")
+	_, err = cache.GetAuthorization2(ctx, req)
+	test.AssertNotError(t, err, "GetAuthorization2 failed")
+	test.AssertEquals(t, sa.authzCalls, 2)
+}
+
+func TestStorageCacheOrder(t *testing.T) {
+	cache, sa, _, _ := setupStorageCache(t)
+	ctx := context.Background()
+	id := int64(1)
+	useV2 := true
+	req := &sapb.OrderRequest{Id: &id, UseV2Authorizations: &useV2}
+
+	_, err := cache.GetOrder(ctx, req)
+	test.AssertNotError(t, err, "GetOrder failed")
+	_, err = cache.GetOrder(ctx, req)
+	test.AssertNotError(t, err, "GetOrder failed")
+	test.AssertEquals(t, sa.orderCalls, 1)
+
+	// A request for legacy authorizations is cached separately.
+	_, err = cache.GetOrder(ctx, &sapb.OrderRequest{Id: &id})
+	test.AssertNotError(t, err, "GetOrder failed")
+	test.AssertEquals(t, sa.orderCalls, 2)
+
+	cache.forgetOrder(1)
+	_, err = cache.GetOrder(ctx, req)
+	test.AssertNotError(t, err, "GetOrder failed")
+	_, err = cache.GetOrder(ctx, &sapb.OrderRequest{Id: &id})
+	test.AssertNotError(t, err, "GetOrder failed")
+	test.AssertEquals(t, sa.orderCalls, 4)
+}
+
+// processingSA returns every order as processing.
+type processingSA struct {
+	*countingSA
+}
+
+func (sa processingSA) GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error) {
+	order, err := sa.countingSA.GetOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	processing := string(core.StatusProcessing)
+	order.Status = &processing
+	return order, nil
+}
+
+func TestStorageCacheProcessingOrder(t *testing.T) {
+	cache, sa, _, _ := setupStorageCache(t)
+	cache.StorageGetter = processingSA{sa}
+	ctx := context.Background()
+	id := int64(1)
+	req := &sapb.OrderRequest{Id: &id}
+
+	// Processing orders are polled for their imminent change, so every
+	// lookup reaches the SA.
+	_, err := cache.GetOrder(ctx, req)
+	test.AssertNotError(t, err, "GetOrder failed")
+	_, err = cache.GetOrder(ctx, req)
+	test.AssertNotError(t, err, "GetOrder failed")
+	test.AssertEquals(t, sa.orderCalls, 2)
+}
+
+func TestStorageCacheNil(t *testing.T) {
+	// The WFE calls the forget methods whether or not caching is enabled.
+	var cache *storageCache
+	cache.forgetRegistration(1)
+	cache.forgetAuthorization("1")
+	cache.forgetOrder(1)
+}
+
+func TestTTLCacheFull(t *testing.T) {
+	fc := clock.NewFake()
+	cache := newTTLCache(fc, time.Second)
+	for i := 0; i < maxCacheEntries; i++ {
+		cache.set(i, i)
+	}
+
+	// A full cache shouldn't accept new entries while the old ones are live.
+	cache.set(maxCacheEntries, maxCacheEntries)
+	_, ok := cache.get(maxCacheEntries)
+	test.Assert(t, !ok, "full cache accepted a new entry")
+
+	// Once the old entries have expired they should make room.
+	fc.Add(time.Second)
+	cache.set(maxCacheEntries, maxCacheEntries)
+	value, ok := cache.get(maxCacheEntries)
+	test.Assert(t, ok, "cache didn't evict expired entries")
+	test.AssertEquals(t, value.(int), maxCacheEntries)
+	test.AssertEquals(t, len(cache.entries), 1)
+}
@@ -0,0 +1,137 @@
+package wfe2
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// pemChainContentType is the default certificate format: the leaf and its
+	// chain, PEM encoded, as described in RFC 8555 Section 9.1.
+	pemChainContentType = "application/pem-certificate-chain"
+	// derContentType is the leaf certificate alone, DER encoded.
+	derContentType = "application/pkix-cert"
+	// pkcs7ContentType is the leaf and its chain in a degenerate (certs-only)
+	// PKCS#7 SignedData, DER encoded.
+	pkcs7ContentType = "application/pkcs7-mime"
+)
+
+// certContentTypes lists the certificate formats the Certificate endpoint can
+// serve, in order of preference when a client accepts several equally.
+var certContentTypes = []string{pemChainContentType, derContentType, pkcs7ContentType}
+
+// negotiateCertContentType picks the certificate format to serve for the
+// given Accept header. Entries which can't be parsed are ignored, and if no
+// format the endpoint serves is acceptable the default PEM chain is used, so
+// clients which send a generic or broken Accept header keep working.
+func negotiateCertContentType(accept string) string {
+	if accept == "" {
+		return pemChainContentType
+	}
+
+	// For each format, the quality of the most specific matching Accept entry
+	// and how specific that entry was: 0 for */*, 1 for application/*, and 2
+	// for an exact match.
+	quality := make(map[string]float64)
+	specificity := make(map[string]int)
+	for _, entry := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(entry)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qParam, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(qParam, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		for _, contentType := range certContentTypes {
+			var s int
+			switch mediaType {
+			case contentType:
+				s = 2
+			case "application/*":
+				s = 1
+			case "*/*":
+				s = 0
+			default:
+				continue
+			}
+			if prev, ok := specificity[contentType]; ok && prev > s {
+				continue
+			}
+			quality[contentType] = q
+			specificity[contentType] = s
+		}
+	}
+
+	best := pemChainContentType
+	var bestQuality float64
+	for _, contentType := range certContentTypes {
+		if quality[contentType] > bestQuality {
+			best = contentType
+			bestQuality = quality[contentType]
+		}
+	}
+	return best
+}
+
+var (
+	oidPKCS7Data       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      pkcs7ContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      asn1.RawValue
+}
+
+// pemChainToPKCS7 converts a PEM certificate chain into a degenerate PKCS#7
+// SignedData, with no content or signers, carrying the same certificates in
+// the same order (RFC 2315 Section 9.1).
+func pemChainToPKCS7(chain []byte) ([]byte, error) {
+	var certs []byte
+	for {
+		var block *pem.Block
+		block, chain = pem.Decode(chain)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, errors.New("certificate chain contains a non-certificate PEM block")
+		}
+		certs = append(certs, block.Bytes...)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("certificate chain contains no certificates")
+	}
+
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	signedData, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidPKCS7Data},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos:      emptySet,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidPKCS7SignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+}
@@ -0,0 +1,84 @@
+package wfe2
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"io/ioutil"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// parsePKCS7Certs returns the certificates carried by a degenerate PKCS#7
+// SignedData.
+func parsePKCS7Certs(t *testing.T, der []byte) []*x509.Certificate {
+	t.Helper()
+	var contentInfo pkcs7ContentInfo
+	rest, err := asn1.Unmarshal(der, &contentInfo)
+	test.AssertNotError(t, err, "parsing PKCS#7 ContentInfo")
+	test.AssertEquals(t, len(rest), 0)
+	test.Assert(t, contentInfo.ContentType.Equal(oidPKCS7SignedData), "PKCS#7 content isn't SignedData")
+	var signedData pkcs7SignedData
+	_, err = asn1.Unmarshal(contentInfo.Content.Bytes, &signedData)
+	test.AssertNotError(t, err, "parsing PKCS#7 SignedData")
+	certs, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	test.AssertNotError(t, err, "parsing PKCS#7 certificates")
+	return certs
+}
+
+func TestNegotiateCertContentType(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected string
+	}{
+		{"", pemChainContentType},
+		{"*/*", pemChainContentType},
+		{"application/*", pemChainContentType},
+		{"application/json", pemChainContentType},
+		{"application/pem-certificate-chain", pemChainContentType},
+		{"application/pkix-cert", derContentType},
+		{"application/pkcs7-mime", pkcs7ContentType},
+		{"application/pkix-cert; q=0.5, application/pkcs7-mime", pkcs7ContentType},
+		{"application/pkix-cert, application/pem-certificate-chain", pemChainContentType},
+		{"application/pkix-cert, */*;q=0.1", derContentType},
+		{"*/*, application/pem-certificate-chain;q=0", derContentType},
+		{"application/pkix-cert;q=0", pemChainContentType},
+		// Malformed entries are ignored, falling back to the default when
+		// nothing else is acceptable.
+		{"garbage", pemChainContentType},
+		{";;;", pemChainContentType},
+		{"application/pkix-cert;q=lots", pemChainContentType},
+		{"application/pkix-cert;q=2", pemChainContentType},
+		{"application/pkix-cert;q=-1", pemChainContentType},
+		{"application/pkix-cert;;q=1=2", pemChainContentType},
+		{"application/pkix-cert;q=lots, application/pkcs7-mime", pkcs7ContentType},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.accept, func(t *testing.T) {
+			test.AssertEquals(t, negotiateCertContentType(tc.accept), tc.expected)
+		})
+	}
+}
+
+func TestPEMChainToPKCS7(t *testing.T) {
+	leafPEM, err := ioutil.ReadFile("test/178.crt")
+	test.AssertNotError(t, err, "reading test/178.crt")
+	chainPEM, err := ioutil.ReadFile("../test/test-ca2.pem")
+	test.AssertNotError(t, err, "reading ../test/test-ca2.pem")
+
+	der, err := pemChainToPKCS7(append(leafPEM, chainPEM...))
+	test.AssertNotError(t, err, "pemChainToPKCS7 failed")
+
+	certs := parsePKCS7Certs(t, der)
+	test.AssertEquals(t, len(certs), 2)
+	leafBlock, _ := pem.Decode(leafPEM)
+	chainBlock, _ := pem.Decode(chainPEM)
+	test.AssertByteEquals(t, certs[0].Raw, leafBlock.Bytes)
+	test.AssertByteEquals(t, certs[1].Raw, chainBlock.Bytes)
+
+	_, err = pemChainToPKCS7(nil)
+	test.AssertError(t, err, "pemChainToPKCS7 accepted an empty chain")
+	_, err = pemChainToPKCS7(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1}}))
+	test.AssertError(t, err, "pemChainToPKCS7 accepted a non-certificate block")
+}
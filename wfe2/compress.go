@@ -0,0 +1,131 @@
+package wfe2
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressibleContentTypes are the content types of responses worth
+// compressing. Binary certificate formats are left alone.
+var compressibleContentTypes = map[string]bool{
+	"application/json":                  true,
+	"application/problem+json":          true,
+	"application/pem-certificate-chain": true,
+	"text/plain":                        true,
+}
+
+// negotiateEncoding picks the content coding, "gzip", "deflate" or "" for
+// none, with which to compress a response given the request's
+// Accept-Encoding header. Entries which can't be parsed are ignored, and gzip
+// is preferred when both are equally acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	quality := make(map[string]float64)
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(entry, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		valid := true
+		for _, param := range fields[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.ToLower(kv[0]) != "q" {
+				continue
+			}
+			var err error
+			q, err = strconv.ParseFloat(kv[1], 64)
+			if err != nil || q < 0 || q > 1 {
+				valid = false
+			}
+		}
+		if valid {
+			quality[coding] = q
+		}
+	}
+
+	best := ""
+	var bestQuality float64
+	for _, coding := range []string{"gzip", "deflate"} {
+		q, ok := quality[coding]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > bestQuality {
+			best = coding
+			bestQuality = q
+		}
+	}
+	return best
+}
+
+// compressingResponseWriter compresses the body of a response with the
+// negotiated content coding, if its content type is compressible. Close must
+// be called once the handler has written the response.
+type compressingResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     io.WriteCloser
+	wroteHeader bool
+}
+
+// newCompressingResponseWriter wraps response so that its body is compressed
+// with a content coding acceptable to request, if there is one.
+func newCompressingResponseWriter(response http.ResponseWriter, request *http.Request) *compressingResponseWriter {
+	var encoding string
+	// The body of a HEAD response is never sent, so its headers are left
+	// to describe the uncompressed body.
+	if request.Method != http.MethodHead {
+		encoding = negotiateEncoding(request.Header.Get("Accept-Encoding"))
+	}
+	return &compressingResponseWriter{ResponseWriter: response, encoding: encoding}
+}
+
+func (w *compressingResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	contentType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if w.encoding != "" && compressibleContentTypes[contentType] &&
+		header.Get("Content-Encoding") == "" &&
+		status != http.StatusNoContent && status != http.StatusNotModified {
+		// The handler may have set the uncompressed body's length.
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+		switch w.encoding {
+		case "gzip":
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		case "deflate":
+			w.encoder = zlib.NewWriter(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressingResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Close flushes the compressed body, if the response is being compressed.
+func (w *compressingResponseWriter) Close() error {
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package wfe2
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"br", ""},
+		{"gzip", "gzip"},
+		{"GZIP", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"*", "gzip"},
+		{"*;q=0.5, gzip;q=0", "deflate"},
+		{"gzip;q=0", ""},
+		// Malformed entries are ignored.
+		{"gzip;q=lots", ""},
+		{"gzip;q=2, deflate", "deflate"},
+		{",,;", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.acceptEncoding, func(t *testing.T) {
+			test.AssertEquals(t, negotiateEncoding(tc.acceptEncoding), tc.expected)
+		})
+	}
+}
+
+func TestCompressingResponseWriter(t *testing.T) {
+	body := []byte(`{"hello": "world", "hello again": "world"}`)
+
+	serve := func(method, acceptEncoding, contentType string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/", nil)
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		responseWriter := httptest.NewRecorder()
+		w := newCompressingResponseWriter(responseWriter, request)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", "42")
+		_, err := w.Write(body)
+		test.AssertNotError(t, err, "Write failed")
+		test.AssertNotError(t, w.Close(), "Close failed")
+		test.AssertEquals(t, responseWriter.Header().Get("Vary"), "Accept-Encoding")
+		return responseWriter
+	}
+
+	responseWriter := serve(http.MethodGet, "gzip", "application/json")
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Encoding"), "gzip")
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Length"), "")
+	reader, err := gzip.NewReader(responseWriter.Body)
+	test.AssertNotError(t, err, "gzip.NewReader failed")
+	decompressed, err := ioutil.ReadAll(reader)
+	test.AssertNotError(t, err, "reading gzip body")
+	test.AssertByteEquals(t, decompressed, body)
+
+	responseWriter = serve(http.MethodGet, "deflate", "application/problem+json")
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Encoding"), "deflate")
+	zreader, err := zlib.NewReader(responseWriter.Body)
+	test.AssertNotError(t, err, "zlib.NewReader failed")
+	decompressed, err = ioutil.ReadAll(zreader)
+	test.AssertNotError(t, err, "reading deflate body")
+	test.AssertByteEquals(t, decompressed, body)
+
+	// Responses which aren't compressible, or for which no coding is
+	// acceptable, or to HEAD requests, are passed through unchanged.
+	for _, responseWriter := range []*httptest.ResponseRecorder{
+		serve(http.MethodGet, "gzip", "application/pkix-cert"),
+		serve(http.MethodGet, "", "application/json"),
+		serve(http.MethodHead, "gzip", "application/json"),
+	} {
+		test.AssertEquals(t, responseWriter.Header().Get("Content-Encoding"), "")
+		test.AssertEquals(t, responseWriter.Header().Get("Content-Length"), "42")
+		test.Assert(t, bytes.Equal(responseWriter.Body.Bytes(), body), "Body was changed")
+	}
+}
@@ -0,0 +1,131 @@
+package wfe2
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/letsencrypt/boulder/probs"
+)
+
+// concurrencyRetryAfter is the Retry-After of requests refused because too
+// many of their source IP's or account's requests are already in flight. Those
+// requests are expected to finish quickly.
+const concurrencyRetryAfter = time.Second
+
+// concurrencyLimits bounds the number of requests in flight from each source
+// IP and for each account. It is a cheap first line of defense against clients
+// flooding the WFE, ahead of the RA's rate limits. A zero limit is no limit.
+type concurrencyLimits struct {
+	perIP      int
+	perAccount int
+
+	sync.Mutex
+	ips      map[string]int
+	accounts map[int64]int
+}
+
+func newConcurrencyLimits(perIP, perAccount int) *concurrencyLimits {
+	return &concurrencyLimits{
+		perIP:      perIP,
+		perAccount: perAccount,
+		ips:        make(map[string]int),
+		accounts:   make(map[int64]int),
+	}
+}
+
+// requestSlots are the places in the concurrency limits held by a single
+// request, which it gives up by calling release once it has been handled.
+type requestSlots struct {
+	limits    *concurrencyLimits
+	ip        string
+	accountID int64
+}
+
+// requestSlotsKey is the context key under which a request's requestSlots are
+// stored, so that the account slot can be taken once the request has been
+// authenticated.
+type requestSlotsKey struct{}
+
+// acquireIP takes a slot for a request from ip, returning nil if ip already
+// has as many requests in flight as it's allowed.
+func (l *concurrencyLimits) acquireIP(ip string) *requestSlots {
+	l.Lock()
+	defer l.Unlock()
+	if l.perIP > 0 {
+		if l.ips[ip] >= l.perIP {
+			return nil
+		}
+		l.ips[ip]++
+	}
+	return &requestSlots{limits: l, ip: ip}
+}
+
+// acquireAccount takes a slot for the request in accountID's limit, returning
+// false if the account already has as many requests in flight as it's
+// allowed. A request only ever holds one account slot.
+func (s *requestSlots) acquireAccount(accountID int64) bool {
+	if s.accountID != 0 {
+		return true
+	}
+	l := s.limits
+	l.Lock()
+	defer l.Unlock()
+	if l.perAccount > 0 {
+		if l.accounts[accountID] >= l.perAccount {
+			return false
+		}
+		l.accounts[accountID]++
+	}
+	s.accountID = accountID
+	return true
+}
+
+// release gives up the request's slots.
+func (s *requestSlots) release() {
+	l := s.limits
+	l.Lock()
+	defer l.Unlock()
+	if l.perIP > 0 {
+		l.ips[s.ip]--
+		if l.ips[s.ip] <= 0 {
+			delete(l.ips, s.ip)
+		}
+	}
+	if l.perAccount > 0 && s.accountID != 0 {
+		l.accounts[s.accountID]--
+		if l.accounts[s.accountID] <= 0 {
+			delete(l.accounts, s.accountID)
+		}
+	}
+}
+
+// LimitConcurrentRequests makes the WFE refuse, with a rateLimited problem,
+// requests from source IPs with perIP requests already in flight, and
+// authenticated requests for accounts with perAccount requests already in
+// flight. A zero limit is no limit.
+func (wfe *WebFrontEndImpl) LimitConcurrentRequests(perIP, perAccount int) {
+	wfe.concurrencyLimits = newConcurrencyLimits(perIP, perAccount)
+}
+
+// concurrencyLimited returns the problem for a request refused by the
+// concurrency limits.
+func concurrencyLimited(detail string) *probs.ProblemDetails {
+	prob := probs.RateLimited(detail)
+	prob.RetryAfter = concurrencyRetryAfter
+	return prob
+}
+
+// acquireAccountSlot takes a slot in accountID's concurrency limit for the
+// request whose context is ctx, if the WFE limits concurrent requests.
+func (wfe *WebFrontEndImpl) acquireAccountSlot(ctx context.Context, accountID int64) *probs.ProblemDetails {
+	slots, ok := ctx.Value(requestSlotsKey{}).(*requestSlots)
+	if !ok {
+		return nil
+	}
+	if !slots.acquireAccount(accountID) {
+		wfe.stats.concurrencyLimited.WithLabelValues("account").Inc()
+		return concurrencyLimited("Too many concurrent requests for this account")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package wfe2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/web"
+)
+
+func TestConcurrencyLimits(t *testing.T) {
+	limits := newConcurrencyLimits(2, 1)
+
+	a := limits.acquireIP("10.0.0.1")
+	test.AssertNotNil(t, a, "first request from 10.0.0.1 was refused")
+	b := limits.acquireIP("10.0.0.1")
+	test.AssertNotNil(t, b, "second request from 10.0.0.1 was refused")
+	test.Assert(t, limits.acquireIP("10.0.0.1") == nil, "third request from 10.0.0.1 was allowed")
+	other := limits.acquireIP("10.0.0.2")
+	test.AssertNotNil(t, other, "request from 10.0.0.2 was refused")
+
+	test.Assert(t, a.acquireAccount(1), "first request for account 1 was refused")
+	test.Assert(t, a.acquireAccount(1), "request holding account 1's slot was refused it again")
+	test.Assert(t, !b.acquireAccount(1), "second request for account 1 was allowed")
+	test.Assert(t, other.acquireAccount(2), "request for account 2 was refused")
+
+	a.release()
+	test.Assert(t, b.acquireAccount(1), "account 1's slot wasn't released")
+	test.AssertNotNil(t, limits.acquireIP("10.0.0.1"), "10.0.0.1's slot wasn't released")
+
+	// A zero limit is no limit.
+	unlimited := newConcurrencyLimits(0, 0)
+	for i := 0; i < 10; i++ {
+		slots := unlimited.acquireIP("10.0.0.1")
+		test.AssertNotNil(t, slots, "request was refused without a limit")
+		test.Assert(t, slots.acquireAccount(1), "account request was refused without a limit")
+	}
+	test.AssertEquals(t, len(unlimited.ips), 0)
+	test.AssertEquals(t, len(unlimited.accounts), 0)
+}
+
+func TestLimitConcurrentRequests(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.LimitConcurrentRequests(1, 1)
+	mux := http.NewServeMux()
+
+	// The handlers block until told to finish, so that requests can be made
+	// while they're in flight.
+	entered := make(chan bool)
+	finish := make(chan bool)
+	wfe.HandleFunc(mux, "/ip", func(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+		entered <- true
+		<-finish
+	}, "GET")
+	wfe.HandleFunc(mux, "/account", func(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+		_, _, _, prob := wfe.validPOSTForAccount(request, ctx, logEvent)
+		if prob != nil {
+			wfe.sendError(response, logEvent, prob, nil)
+			return
+		}
+		entered <- true
+		<-finish
+	}, "POST")
+
+	get := func(ip string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		request.Header.Set("X-Real-IP", ip)
+		mux.ServeHTTP(responseWriter, request)
+		return responseWriter
+	}
+	post := func(ip string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		request := signAndPost(t, "/account", "http://localhost/account", "{}", 1, wfe.nonceService)
+		request.Header.Set("X-Real-IP", ip)
+		mux.ServeHTTP(responseWriter, request)
+		return responseWriter
+	}
+	inFlight := func(request func(string) *httptest.ResponseRecorder, ip string) chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			done <- request(ip)
+		}()
+		<-entered
+		return done
+	}
+	assertLimited := func(responseWriter *httptest.ResponseRecorder, detail string) {
+		t.Helper()
+		test.AssertEquals(t, responseWriter.Code, http.StatusTooManyRequests)
+		test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "1")
+		test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()),
+			`{"type":"`+probs.V2ErrorNS+`rateLimited","detail":"`+detail+`","status":429}`)
+	}
+
+	// While a request from an IP is in flight, other requests from it are
+	// refused, but those from other IPs aren't.
+	done := inFlight(get, "10.0.0.1")
+	assertLimited(get("10.0.0.1"), "Too many concurrent requests from this IP address")
+	otherDone := inFlight(get, "10.0.0.2")
+	finish <- true
+	finish <- true
+	test.AssertEquals(t, (<-done).Code, http.StatusOK)
+	test.AssertEquals(t, (<-otherDone).Code, http.StatusOK)
+	test.AssertEquals(t, test.CountCounterVec("limit", "ip", wfe.stats.concurrencyLimited), 1)
+
+	// While a request for an account is in flight, other requests for it are
+	// refused, even from other IPs.
+	done = inFlight(post, "10.0.0.1")
+	assertLimited(post("10.0.0.2"), "Too many concurrent requests for this account")
+	finish <- true
+	test.AssertEquals(t, (<-done).Code, http.StatusOK)
+	test.AssertEquals(t, test.CountCounterVec("limit", "account", wfe.stats.concurrencyLimited), 1)
+
+	// Once requests finish their slots are free again.
+	done = inFlight(post, "10.0.0.1")
+	finish <- true
+	test.AssertEquals(t, (<-done).Code, http.StatusOK)
+}
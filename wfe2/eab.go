@@ -0,0 +1,92 @@
+package wfe2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/eab"
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/web"
+)
+
+// eabAlgs are the MAC algorithms with which an external account binding may
+// be signed.
+var eabAlgs = map[string]bool{
+	string(jose.HS256): true,
+	string(jose.HS384): true,
+	string(jose.HS512): true,
+}
+
+// RequireExternalAccountBinding makes an external account binding, verified
+// against the MAC keys in keys, mandatory for new accounts. It is advertised
+// in the "externalAccountRequired" field of the directory's "meta" element.
+func (wfe *WebFrontEndImpl) RequireExternalAccountBinding(keys eab.KeyStore) {
+	wfe.eabKeys = keys
+}
+
+// validExternalAccountBinding checks that binding, the "externalAccountBinding"
+// field of a new account request, is a JWS as described in RFC 8555 Section
+// 7.3.4: one which is MACed by a key the WFE knows, is for the same URL as the
+// new account request, and binds the new account's key.
+func (wfe *WebFrontEndImpl) validExternalAccountBinding(
+	ctx context.Context,
+	binding json.RawMessage,
+	accountKey *jose.JSONWebKey,
+	request *http.Request,
+	logEvent *web.RequestEvent) *probs.ProblemDetails {
+	if len(binding) == 0 || string(binding) == "null" {
+		return probs.ExternalAccountRequired("New accounts require an external account binding")
+	}
+
+	jws, err := jose.ParseSigned(string(binding))
+	if err != nil {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABParseError"}).Inc()
+		return probs.Malformed("Parse error reading external account binding JWS")
+	}
+	if len(jws.Signatures) != 1 {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABMultiSig"}).Inc()
+		return probs.Malformed("External account binding JWS must have exactly one signature")
+	}
+	header := jws.Signatures[0].Header
+	if !eabAlgs[header.Algorithm] {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABAlgorithm"}).Inc()
+		return probs.BadSignatureAlgorithm(
+			"External account binding JWS has unsupported algorithm %q, expected one of HS256, HS384 or HS512",
+			header.Algorithm)
+	}
+	if header.KeyID == "" {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABMissingKeyID"}).Inc()
+		return probs.Malformed("External account binding JWS header parameter 'kid' required")
+	}
+	if prob := wfe.validPOSTURL(request, jws); prob != nil {
+		return prob
+	}
+	logEvent.Extra["ExternalAccountKeyID"] = header.KeyID
+
+	hmacKey, err := wfe.eabKeys.GetKey(ctx, header.KeyID)
+	if err == eab.ErrUnknownKey {
+		return probs.Unauthorized("External account binding key ID is unknown")
+	} else if err != nil {
+		return probs.ServerInternal("Failed to retrieve external account key")
+	}
+	payload, err := jws.Verify(hmacKey)
+	if err != nil {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABVerifyFailed"}).Inc()
+		return probs.Unauthorized("External account binding JWS verification failed")
+	}
+
+	var boundKey jose.JSONWebKey
+	err = json.Unmarshal(payload, &boundKey)
+	if err != nil {
+		return probs.Malformed("External account binding payload isn't a JWK")
+	}
+	if !core.KeyDigestEquals(boundKey.Key, accountKey.Key) {
+		return probs.Unauthorized("External account binding doesn't bind the new account's key")
+	}
+	return nil
+}
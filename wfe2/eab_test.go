@@ -0,0 +1,120 @@
+package wfe2
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/letsencrypt/boulder/eab"
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// signEAB returns an external account binding JWS binding accountKey, MACed
+// with hmacKey under the given key ID and algorithm, for the given URL.
+func signEAB(t *testing.T, alg jose.SignatureAlgorithm, keyID string, hmacKey []byte, url string, accountKey interface{}) string {
+	t.Helper()
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: hmacKey},
+		&jose.SignerOptions{
+			ExtraHeaders: map[jose.HeaderKey]interface{}{
+				"kid": keyID,
+				"url": url,
+			},
+		})
+	test.AssertNotError(t, err, "Failed to make EAB signer")
+	jwk, err := json.Marshal(jose.JSONWebKey{Key: accountKey})
+	test.AssertNotError(t, err, "Failed to marshal account key")
+	jws, err := signer.Sign(jwk)
+	test.AssertNotError(t, err, "Failed to sign EAB")
+	return jws.FullSerialize()
+}
+
+func TestNewAccountExternalAccountBinding(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	hmacKey := []byte("sixteen byte key")
+	keys, err := eab.NewStaticKeyStore(map[string]string{"kid-1": "c2l4dGVlbiBieXRlIGtleQ"})
+	test.AssertNotError(t, err, "NewStaticKeyStore failed")
+	wfe.RequireExternalAccountBinding(keys)
+
+	// The mock SA has no account for the test2 key.
+	key := loadKey(t, []byte(test2KeyPrivatePEM))
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	test.Assert(t, ok, "Couldn't load test2 key")
+	otherKey := loadKey(t, []byte(test3KeyPrivatePEM)).(*rsa.PrivateKey)
+	signedURL := fmt.Sprintf("http://localhost%s", newAcctPath)
+
+	newAccount := func(binding string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		payload := `{"termsOfServiceAgreed":true}`
+		if binding != "" {
+			payload = fmt.Sprintf(`{"termsOfServiceAgreed":true,"externalAccountBinding":%s}`, binding)
+		}
+		_, _, body := signRequestEmbed(t, key, signedURL, payload, wfe.nonceService)
+		wfe.NewAccount(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath(newAcctPath, body))
+		return responseWriter
+	}
+	problemType := func(responseWriter *httptest.ResponseRecorder) probs.ProblemType {
+		var prob probs.ProblemDetails
+		err := json.Unmarshal(responseWriter.Body.Bytes(), &prob)
+		test.AssertNotError(t, err, "unmarshaling problem")
+		return prob.Type
+	}
+
+	responseWriter := newAccount(signEAB(t, jose.HS256, "kid-1", hmacKey, signedURL, rsaKey.Public()))
+	test.AssertEquals(t, responseWriter.Code, http.StatusCreated)
+
+	testCases := []struct {
+		name         string
+		binding      string
+		expectedType probs.ProblemType
+	}{
+		{"no binding", "", probs.ExternalAccountRequiredProblem},
+		{"malformed binding", `"binding"`, probs.MalformedProblem},
+		{"unknown key ID", signEAB(t, jose.HS256, "kid-2", hmacKey, signedURL, rsaKey.Public()), probs.UnauthorizedProblem},
+		{"wrong MAC key", signEAB(t, jose.HS256, "kid-1", []byte("another 16B key!"), signedURL, rsaKey.Public()), probs.UnauthorizedProblem},
+		{"wrong URL", signEAB(t, jose.HS256, "kid-1", hmacKey, "http://localhost/acme/new-order", rsaKey.Public()), probs.MalformedProblem},
+		{"wrong account key", signEAB(t, jose.HS256, "kid-1", hmacKey, signedURL, otherKey.Public()), probs.UnauthorizedProblem},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			responseWriter := newAccount(tc.binding)
+			test.AssertEquals(t, problemType(responseWriter), probs.V2ErrorNS+tc.expectedType)
+		})
+	}
+
+	// Existing accounts are returned without a binding.
+	responseWriter = httptest.NewRecorder()
+	_, _, body := signRequestEmbed(t, nil, signedURL, `{"onlyReturnExisting":true}`, wfe.nonceService)
+	wfe.NewAccount(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath(newAcctPath, body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+}
+
+func TestDirectoryExternalAccountRequired(t *testing.T) {
+	getMeta := func(wfe WebFrontEndImpl) map[string]interface{} {
+		responseWriter := httptest.NewRecorder()
+		wfe.Directory(ctx, newRequestEvent(), responseWriter, &http.Request{
+			Method: http.MethodGet,
+			Host:   "localhost",
+		})
+		var directory map[string]interface{}
+		err := json.Unmarshal(responseWriter.Body.Bytes(), &directory)
+		test.AssertNotError(t, err, "unmarshaling directory")
+		return directory["meta"].(map[string]interface{})
+	}
+
+	wfe, _ := setupWFE(t)
+	_, present := getMeta(wfe)["externalAccountRequired"]
+	test.Assert(t, !present, "externalAccountRequired advertised without a key store")
+
+	wfe, _ = setupWFE(t)
+	keys, err := eab.NewStaticKeyStore(nil)
+	test.AssertNotError(t, err, "NewStaticKeyStore failed")
+	wfe.RequireExternalAccountBinding(keys)
+	test.AssertEquals(t, getMeta(wfe)["externalAccountRequired"], true)
+}
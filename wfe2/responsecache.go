@@ -0,0 +1,99 @@
+package wfe2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxCachedResponses bounds the number of entries in a responseCache. Since
+// the directory is cached per host the WFE is reached by, and the Host header
+// is chosen by the client, responses are built afresh rather than stored once
+// the cache is full.
+const maxCachedResponses = 100
+
+// cachedResponse is the body of a response which only changes when the WFE's
+// configuration does, and the ETag identifying it.
+type cachedResponse struct {
+	body []byte
+	etag string
+}
+
+// responseCache holds the bodies of responses, like the directory and the
+// issuer certificate, which only change when the WFE's configuration does, so
+// that clients polling them at high rates cost little more than a map lookup.
+// Entries are never evicted, so the WFE's configuration must not change once
+// it has started serving requests.
+type responseCache struct {
+	sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]*cachedResponse)}
+}
+
+// get returns the cached response for key, calling build to create it if
+// there isn't one. A nil responseCache caches nothing.
+func (c *responseCache) get(key string, build func() ([]byte, error)) (*cachedResponse, error) {
+	if c == nil {
+		return newCachedResponse(build)
+	}
+	c.Lock()
+	defer c.Unlock()
+	if resp, ok := c.entries[key]; ok {
+		return resp, nil
+	}
+	resp, err := newCachedResponse(build)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.entries) < maxCachedResponses {
+		c.entries[key] = resp
+	}
+	return resp, nil
+}
+
+func newCachedResponse(build func() ([]byte, error)) (*cachedResponse, error) {
+	body, err := build()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(body)
+	return &cachedResponse{
+		body: body,
+		etag: `"` + hex.EncodeToString(hash[:16]) + `"`,
+	}, nil
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison RFC 7232 Section 3.2 requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes the cached response with its ETag. A GET or HEAD request whose
+// If-None-Match header matches the ETag gets a 304 Not Modified response with
+// no body instead.
+func (resp *cachedResponse) write(response http.ResponseWriter, request *http.Request) (int, error) {
+	response.Header().Set("ETag", resp.etag)
+	if request.Method == http.MethodGet || request.Method == http.MethodHead {
+		if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, resp.etag) {
+			response.WriteHeader(http.StatusNotModified)
+			return 0, nil
+		}
+	}
+	response.WriteHeader(http.StatusOK)
+	return response.Write(resp.body)
+}
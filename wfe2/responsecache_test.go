@@ -0,0 +1,124 @@
+package wfe2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+	testCases := []struct {
+		ifNoneMatch string
+		expected    bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`*`, true},
+		{`"xyz"`, false},
+		{`abc`, false},
+		{``, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.ifNoneMatch, func(t *testing.T) {
+			test.AssertEquals(t, etagMatches(tc.ifNoneMatch, etag), tc.expected)
+		})
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	cache := newResponseCache()
+	builds := 0
+	build := func() ([]byte, error) {
+		builds++
+		return []byte(fmt.Sprintf("body %d", builds)), nil
+	}
+
+	first, err := cache.get("a", build)
+	test.AssertNotError(t, err, "get failed")
+	second, err := cache.get("a", build)
+	test.AssertNotError(t, err, "get failed")
+	test.AssertEquals(t, builds, 1)
+	test.AssertEquals(t, second, first)
+
+	other, err := cache.get("b", build)
+	test.AssertNotError(t, err, "get failed")
+	test.AssertEquals(t, builds, 2)
+	test.AssertNotEquals(t, other.etag, first.etag)
+
+	// Failed builds aren't cached.
+	_, err = cache.get("c", func() ([]byte, error) { return nil, errors.New("oops") })
+	test.AssertError(t, err, "get didn't fail")
+	_, err = cache.get("c", build)
+	test.AssertNotError(t, err, "get failed")
+	test.AssertEquals(t, builds, 3)
+
+	// Once the cache is full, responses are built but not stored.
+	for i := len(cache.entries); i < maxCachedResponses; i++ {
+		_, err = cache.get(fmt.Sprintf("filler %d", i), build)
+		test.AssertNotError(t, err, "get failed")
+	}
+	builds = 0
+	_, err = cache.get("d", build)
+	test.AssertNotError(t, err, "get failed")
+	_, err = cache.get("d", build)
+	test.AssertNotError(t, err, "get failed")
+	test.AssertEquals(t, builds, 2)
+	test.AssertEquals(t, len(cache.entries), maxCachedResponses)
+}
+
+func TestCachedResponsesNotModified(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.IssuerCert = []byte{0, 0, 1}
+	mux := wfe.Handler(metrics.NoopRegisterer)
+
+	for _, path := range []string{directoryPath, issuerPath} {
+		t.Run(path, func(t *testing.T) {
+			get := func(method, ifNoneMatch string) *httptest.ResponseRecorder {
+				request := httptest.NewRequest(method, "http://localhost"+path, nil)
+				if ifNoneMatch != "" {
+					request.Header.Set("If-None-Match", ifNoneMatch)
+				}
+				responseWriter := httptest.NewRecorder()
+				mux.ServeHTTP(responseWriter, request)
+				return responseWriter
+			}
+
+			responseWriter := get(http.MethodGet, "")
+			test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+			etag := responseWriter.Header().Get("ETag")
+			test.AssertNotEquals(t, etag, "")
+			body := responseWriter.Body.String()
+
+			// The cached response is served again, with the same ETag.
+			responseWriter = get(http.MethodGet, `"something else"`)
+			test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+			test.AssertEquals(t, responseWriter.Header().Get("ETag"), etag)
+			test.AssertEquals(t, responseWriter.Body.String(), body)
+
+			for _, method := range []string{http.MethodGet, http.MethodHead} {
+				responseWriter = get(method, etag)
+				test.AssertEquals(t, responseWriter.Code, http.StatusNotModified)
+				test.AssertEquals(t, responseWriter.Header().Get("ETag"), etag)
+				test.AssertEquals(t, responseWriter.Body.Len(), 0)
+			}
+		})
+	}
+
+	// The directory is cached separately for each host it's requested from.
+	request := httptest.NewRequest(http.MethodGet, "http://localhost"+directoryPath, nil)
+	responseWriter := httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, request)
+	request = httptest.NewRequest(http.MethodGet, "http://example.com"+directoryPath, nil)
+	request.Header.Set("If-None-Match", responseWriter.Header().Get("ETag"))
+	otherHost := httptest.NewRecorder()
+	mux.ServeHTTP(otherHost, request)
+	test.AssertEquals(t, otherHost.Code, http.StatusOK)
+	test.AssertContains(t, otherHost.Body.String(), "http://example.com/acme/new-acct")
+}
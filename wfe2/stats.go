@@ -17,6 +17,17 @@ type wfe2Stats struct {
 	// improperECFieldLengths counts the number of ACME account EC JWKs we see
 	// with improper X and Y lengths for their curve
 	improperECFieldLengths prometheus.Counter
+	// storageCacheLookups counts lookups in the storage cache by object type
+	// and result (hit or miss)
+	storageCacheLookups *prometheus.CounterVec
+	// nonceRedeemRoutes counts the nonces sent to a remote nonce service for
+	// redemption by route: to the service for their prefix ("prefix"), to the
+	// fallback service for unknown prefixes ("any"), or to none at all ("none")
+	nonceRedeemRoutes *prometheus.CounterVec
+	// concurrencyLimited counts requests refused because too many requests
+	// from the same source IP ("ip") or for the same account ("account") were
+	// already in flight
+	concurrencyLimited *prometheus.CounterVec
 }
 
 func initStats(stats prometheus.Registerer) wfe2Stats {
@@ -53,10 +64,40 @@ func initStats(stats prometheus.Registerer) wfe2Stats {
 	)
 	stats.MustRegister(improperECFieldLengths)
 
+	storageCacheLookups := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "storage_cache_lookups",
+			Help: "Number of storage cache lookups by object type and result",
+		},
+		[]string{"type", "result"},
+	)
+	stats.MustRegister(storageCacheLookups)
+
+	nonceRedeemRoutes := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nonce_redeem_routes",
+			Help: "Number of nonces sent for remote redemption by route (prefix, any or none)",
+		},
+		[]string{"route"},
+	)
+	stats.MustRegister(nonceRedeemRoutes)
+
+	concurrencyLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "concurrency_limited",
+			Help: "Number of requests refused for exceeding a concurrency limit, by limit (ip or account)",
+		},
+		[]string{"limit"},
+	)
+	stats.MustRegister(concurrencyLimited)
+
 	return wfe2Stats{
 		httpErrorCount:         httpErrorCount,
 		joseErrorCount:         joseErrorCount,
 		csrSignatureAlgs:       csrSignatureAlgs,
 		improperECFieldLengths: improperECFieldLengths,
+		storageCacheLookups:    storageCacheLookups,
+		nonceRedeemRoutes:      nonceRedeemRoutes,
+		concurrencyLimited:     concurrencyLimited,
 	}
 }
@@ -0,0 +1,33 @@
+package wfe2
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/letsencrypt/boulder/unpause"
+)
+
+// EnableUnpause makes paused errors link to unpauseURL, a self-service
+// frontend page where the subscriber can unpause their account. The link
+// carries a token, MACed with key and valid for tokenLifetime, naming the
+// account to be unpaused.
+func (wfe *WebFrontEndImpl) EnableUnpause(unpauseURL string, key []byte, tokenLifetime time.Duration) {
+	wfe.unpauseURL = unpauseURL
+	wfe.unpauseKey = key
+	wfe.unpauseTokenLifetime = tokenLifetime
+}
+
+// unpauseInstance returns the URL at which the account regID can be
+// unpaused, for the "instance" field of a paused error, or the empty string
+// if unpausing isn't enabled.
+func (wfe *WebFrontEndImpl) unpauseInstance(regID int64) string {
+	if wfe.unpauseURL == "" {
+		return ""
+	}
+	token, err := unpause.GenerateToken(wfe.unpauseKey, regID, wfe.clk.Now().Add(wfe.unpauseTokenLifetime))
+	if err != nil {
+		wfe.log.Warningf("Generating unpause token for account %d: %s", regID, err)
+		return ""
+	}
+	return wfe.unpauseURL + "?" + url.Values{"token": {token}}.Encode()
+}
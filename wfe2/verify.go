@@ -27,6 +27,8 @@ const (
 	// POST requests with a JWS body must have the following Content-Type header
 	expectedJWSContentType = "application/jose+json"
 
+	// maxRequestSize is the default maximum size of a request body, for
+	// endpoints without a MaxRequestSize in the WFE's EndpointLimits.
 	maxRequestSize = 50000
 )
 
@@ -194,7 +196,8 @@ func (wfe *WebFrontEndImpl) validNonce(ctx context.Context, jws *jose.JSONWebSig
 	}
 	var nonceValid bool
 	if wfe.remoteNonceService != nil {
-		valid, err := nonce.RemoteRedeem(ctx, wfe.noncePrefixMap, header.Nonce)
+		valid, route, err := nonce.RemoteRedeemRoute(ctx, wfe.noncePrefixMap, header.Nonce)
+		wfe.stats.nonceRedeemRoutes.With(prometheus.Labels{"route": route}).Inc()
 		if err != nil {
 			return probs.ServerInternal(fmt.Sprintf("failed to verify nonce validity: %s", err))
 		}
@@ -231,11 +234,17 @@ func (wfe *WebFrontEndImpl) validPOSTURL(
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWSMissingURL"}).Inc()
 		return probs.Malformed("JWS header parameter 'url' required")
 	}
-	// Compute the URL we expect to be in the JWS based on the HTTP request
+	// Compute the URL we expect to be in the JWS based on the HTTP request,
+	// including its query string, if any
+	path, rawQuery := request.RequestURI, ""
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
 	expectedURL := url.URL{
-		Scheme: requestProto(request),
-		Host:   request.Host,
-		Path:   request.RequestURI,
+		Scheme:   requestProto(request),
+		Host:     request.Host,
+		Path:     path,
+		RawQuery: rawQuery,
 	}
 	// Check that the URL we expect is the one that was found in the signed JWS
 	// header
@@ -352,8 +361,8 @@ func (wfe *WebFrontEndImpl) parseJWSRequest(request *http.Request) (*jose.JSONWe
 	}
 
 	// Read the POST request body's bytes. validPOSTRequest has already checked
-	// that the body is non-nil
-	bodyBytes, err := ioutil.ReadAll(http.MaxBytesReader(nil, request.Body, maxRequestSize))
+	// that the body is non-nil, and HandleFunc has limited its size
+	bodyBytes, err := ioutil.ReadAll(request.Body)
 	if err != nil {
 		if err.Error() == "http: request body too large" {
 			return nil, probs.Unauthorized("request body too large")
@@ -569,6 +578,12 @@ func (wfe *WebFrontEndImpl) validJWSForAccount(
 		return nil, nil, nil, prob
 	}
 
+	// Now that the request is known to be the account's, count it against the
+	// account's concurrency limit.
+	if prob := wfe.acquireAccountSlot(ctx, account.ID); prob != nil {
+		return nil, nil, nil, prob
+	}
+
 	return payload, jws, account, nil
 }
 
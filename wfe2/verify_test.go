@@ -679,6 +679,11 @@ func TestValidPOSTURL(t *testing.T) {
 	correctURLHeaderJWS, _, correctURLHeaderJWSBody := signRequestEmbed(t, nil, "http://localhost/test-path", "", wfe.nonceService)
 	correctURLHeaderRequest := makePostRequestWithPath("test-path", correctURLHeaderJWSBody)
 
+	// A JWS and HTTP request for a URL with a query string
+	queryURLHeaderJWS, _, queryURLHeaderJWSBody := signRequestEmbed(t, nil, "http://localhost/test-path?cursor=5", "", wfe.nonceService)
+	queryURLHeaderRequest := makePostRequestWithPath("test-path?cursor=5", queryURLHeaderJWSBody)
+	queryURLHeaderRequest.RequestURI = "/test-path?cursor=5"
+
 	testCases := []struct {
 		Name           string
 		JWS            *jose.JSONWebSignature
@@ -725,6 +730,12 @@ func TestValidPOSTURL(t *testing.T) {
 			Request:        correctURLHeaderRequest,
 			ExpectedResult: nil,
 		},
+		{
+			Name:           "Correct URL header with query string in JWS",
+			JWS:            queryURLHeaderJWS,
+			Request:        queryURLHeaderRequest,
+			ExpectedResult: nil,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -897,6 +908,11 @@ func TestParseJWSRequest(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
 			wfe.stats.joseErrorCount.Reset()
+			// HandleFunc limits the size of request bodies before they reach
+			// parseJWSRequest.
+			if tc.Request.Body != nil {
+				tc.Request.Body = http.MaxBytesReader(nil, tc.Request.Body, maxRequestSize)
+			}
 			_, prob := wfe.parseJWSRequest(tc.Request)
 			if tc.ExpectedProblem == nil && prob != nil {
 				t.Fatalf("Expected nil problem, got %#v\n", prob)
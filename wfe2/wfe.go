@@ -13,11 +13,13 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmhodges/clock"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/eab"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
@@ -33,6 +35,7 @@ import (
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/web"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
 	jose "gopkg.in/square/go-jose.v2"
 )
 
@@ -52,11 +55,14 @@ const (
 	revokeCertPath    = "/acme/revoke-cert"
 	issuerPath        = "/acme/issuer-cert"
 	buildIDPath       = "/build"
+	healthzPath       = "/healthz"
 	rolloverPath      = "/acme/key-change"
 	newNoncePath      = "/acme/new-nonce"
 	newOrderPath      = "/acme/new-order"
 	orderPath         = "/acme/order/"
 	finalizeOrderPath = "/acme/finalize/"
+	renewalInfoPath   = "/acme/renewal-info/"
+	ordersPath        = "/acme/orders/"
 
 	getAPIPrefix       = "/get/"
 	getOrderPath       = getAPIPrefix + "order/"
@@ -65,6 +71,14 @@ const (
 	getCertPath        = getAPIPrefix + "cert/"
 )
 
+// defaultValidationRetryAfter is the Retry-After of processing challenges when
+// the WFE isn't configured with an ExpectedValidationDuration.
+const defaultValidationRetryAfter = 5 * time.Second
+
+// issuanceRetryAfter is the Retry-After of orders which are processing, i.e.
+// whose certificate is being issued.
+const issuanceRetryAfter = 3 * time.Second
+
 // WebFrontEndImpl provides all the logic for Boulder's web-facing interface,
 // i.e., ACME.  Its members configure the paths for various ACME functions,
 // plus a few other data items used in ACME.  Its methods are primarily handlers
@@ -93,15 +107,32 @@ type WebFrontEndImpl struct {
 	// URL to the current subscriber agreement (should contain some version identifier)
 	SubscriberAgreementURL string
 
-	// DirectoryCAAIdentity is used for the /directory response's "meta"
-	// element's "caaIdentities" field. It should match the VA's issuerDomain
-	// field value.
-	DirectoryCAAIdentity string
+	// DirectoryCAAIdentities are used for the /directory response's "meta"
+	// element's "caaIdentities" field. They should match the VA's issuer
+	// domains.
+	DirectoryCAAIdentities []string
 
 	// DirectoryWebsite is used for the /directory response's "meta" element's
 	// "website" field.
 	DirectoryWebsite string
 
+	// CertificateProfiles is a map of certificate profile names, which clients
+	// may request using the "profile" field of a new order, to human-readable
+	// descriptions of those profiles. It is advertised in the /directory
+	// response's "meta" element's "profiles" field.
+	CertificateProfiles map[string]string
+
+	// CertificateProfileAccounts restricts the certificate profiles it names
+	// to the listed account IDs, for instance those of subscribers who were
+	// given external account binding keys for them. Profiles it doesn't name
+	// may be requested by any account.
+	CertificateProfileAccounts map[string][]int64
+
+	// OrdersPageSize is the number of order URLs returned per page of an
+	// account's orders list. If zero, accounts have no "orders" URL and the
+	// orders list endpoint is disabled.
+	OrdersPageSize int
+
 	// Allowed prefix for legacy accounts used by verify.go's `lookupJWK`.
 	// See `cmd/boulder-wfe2/main.go`'s comment on the configuration field
 	// `LegacyKeyIDPrefix` for more information.
@@ -118,9 +149,44 @@ type WebFrontEndImpl struct {
 	// CORS settings
 	AllowOrigins []string
 
+	// AllowLegacyGET re-allows unauthenticated GET requests to some types of
+	// resource while the MandatoryPOSTAsGET feature flag is enabled, so that
+	// old clients can be transitioned to POST-as-GET one resource at a time.
+	AllowLegacyGET LegacyGETAllowances
+
+	// JSONAccessLogs, if true, makes the WFE log each request as a single JSON
+	// object. See web.NewJSONTopHandler.
+	JSONAccessLogs bool
+
+	// BlockRolloverToBlockedKeys, if true, makes key rollover refuse new keys
+	// in the SA's blocked keys table, such as keys whose certificates were
+	// revoked for key compromise, even when the key policy wouldn't, e.g.
+	// because it's delegated to a key policy service which doesn't consult
+	// that table.
+	BlockRolloverToBlockedKeys bool
+
 	// Maximum duration of a request
 	RequestTimeout time.Duration
 
+	// ExpectedValidationDuration is how long a validation, including the
+	// remote VAs' checks from other network perspectives, is expected to take.
+	// It's sent as the Retry-After of challenges which are still processing,
+	// so that clients don't poll faster than is useful. If zero,
+	// defaultValidationRetryAfter is used.
+	ExpectedValidationDuration time.Duration
+
+	// EndpointLimits overrides the request timeout and maximum request body
+	// size for the endpoints registered at the given paths, so that, for
+	// instance, slow finalize requests can be given longer than the default.
+	EndpointLimits map[string]EndpointLimit
+
+	// CompressEndpoints is the set of paths of endpoints whose JSON and PEM
+	// responses are compressed when the client's Accept-Encoding allows it.
+	// Endpoints whose responses mix secrets with attacker-influenced content
+	// shouldn't be listed, since compression can leak the secrets (as in the
+	// BREACH attack).
+	CompressEndpoints map[string]bool
+
 	// StaleTimeout determines the required staleness for resources allowed to be
 	// accessed via Boulder-specific GET-able APIs. Resources newer than
 	// staleTimeout must be accessed via POST-as-GET and the RFC 8555 ACME API. We
@@ -133,6 +199,49 @@ type WebFrontEndImpl struct {
 	// match the ones used by the RA.
 	authorizationLifetime        time.Duration
 	pendingAuthorizationLifetime time.Duration
+
+	// storageCache, if not nil, is the read-through cache wrapping SA. The WFE
+	// uses it to forget cached objects that it changes through the RA.
+	storageCache *storageCache
+
+	// responses caches the bodies of the directory and issuer certificate
+	// responses. See responseCache.
+	responses *responseCache
+
+	// draining is set to 1 by StartDraining, after which the health check
+	// endpoint reports the WFE as unhealthy. It must be accessed atomically.
+	draining int32
+
+	// concurrencyLimits, if not nil, bounds the requests in flight per source
+	// IP and per account. See LimitConcurrentRequests.
+	concurrencyLimits *concurrencyLimits
+
+	// eabKeys, if not nil, holds the MAC keys with which new accounts' external
+	// account bindings must be signed. See RequireExternalAccountBinding.
+	eabKeys eab.KeyStore
+
+	// unpauseURL, if not empty, is the self-service frontend page linked from
+	// paused errors. See EnableUnpause.
+	unpauseURL           string
+	unpauseKey           []byte
+	unpauseTokenLifetime time.Duration
+}
+
+// LegacyGETAllowances lists the types of resource which may be fetched with an
+// unauthenticated GET request, rather than with POST-as-GET, while the
+// MandatoryPOSTAsGET feature flag is enabled. Challenges are fetched as part
+// of their authorization. Accounts can never be fetched with GET.
+type LegacyGETAllowances struct {
+	Authorizations bool
+	Orders         bool
+	Certificates   bool
+}
+
+// EndpointLimit is the request timeout and maximum request body size of an
+// endpoint. Zero values mean the WFE's defaults are used.
+type EndpointLimit struct {
+	Timeout        time.Duration
+	MaxRequestSize int64
 }
 
 // NewWebFrontEndImpl constructs a web service for Boulder
@@ -161,6 +270,7 @@ func NewWebFrontEndImpl(
 		staleTimeout:                 staleTimeout,
 		authorizationLifetime:        authorizationLifetime,
 		pendingAuthorizationLifetime: pendingAuthorizationLifetime,
+		responses:                    newResponseCache(),
 	}
 
 	if wfe.remoteNonceService == nil {
@@ -174,6 +284,23 @@ func NewWebFrontEndImpl(
 	return wfe, nil
 }
 
+// EnableStorageCache wraps the WFE's SA in a read-through cache of
+// registrations, authorizations and orders, each of which is kept for at most
+// ttl. It must be called after SA is set.
+func (wfe *WebFrontEndImpl) EnableStorageCache(ttl time.Duration) {
+	wfe.storageCache = newStorageCache(wfe.SA, wfe.clk, ttl, wfe.stats.storageCacheLookups)
+	wfe.SA = wfe.storageCache
+}
+
+// topHandler wraps h in a web.TopHandler which logs requests in the format
+// chosen by JSONAccessLogs.
+func (wfe *WebFrontEndImpl) topHandler(h web.WFEHandlerFunc) *web.TopHandler {
+	if wfe.JSONAccessLogs {
+		return web.NewJSONTopHandler(wfe.log, h)
+	}
+	return web.NewTopHandler(wfe.log, h)
+}
+
 // HandleFunc registers a handler at the given path. It's
 // http.HandleFunc(), but with a wrapper around the handler that
 // provides some generic per-request functionality:
@@ -189,6 +316,9 @@ func NewWebFrontEndImpl(
 //
 // * Set CORS headers when responding to CORS "actual" requests.
 //
+// * Limit the duration of the request and the size of its body, as
+// configured for the pattern in EndpointLimits.
+//
 // * Never send a body in response to a HEAD request. Anything
 // written by the handler will be discarded if the method is HEAD.
 // Also, all handlers that accept GET automatically accept HEAD.
@@ -203,7 +333,7 @@ func (wfe *WebFrontEndImpl) HandleFunc(mux *http.ServeMux, pattern string, h web
 		methodsMap["HEAD"] = true
 	}
 	methodsStr := strings.Join(methods, ", ")
-	handler := http.StripPrefix(pattern, web.NewTopHandler(wfe.log,
+	handler := http.StripPrefix(pattern, wfe.topHandler(
 		web.WFEHandlerFunc(func(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
 			if request.Method != "GET" || pattern == newNoncePath {
 				// Historically we did not return a error to the client
@@ -263,13 +393,50 @@ func (wfe *WebFrontEndImpl) HandleFunc(mux *http.ServeMux, pattern string, h web
 
 			wfe.setCORSHeaders(response, request, "")
 
-			timeout := wfe.RequestTimeout
+			// Requests are counted against their source IP's concurrency limit
+			// here, and against their account's once they're authenticated.
+			if wfe.concurrencyLimits != nil {
+				if ip, err := extractRequesterIP(request); err == nil && ip != nil {
+					slots := wfe.concurrencyLimits.acquireIP(ip.String())
+					if slots == nil {
+						wfe.stats.concurrencyLimited.WithLabelValues("ip").Inc()
+						wfe.sendError(response, logEvent, concurrencyLimited("Too many concurrent requests from this IP address"), nil)
+						return
+					}
+					defer slots.release()
+					ctx = context.WithValue(ctx, requestSlotsKey{}, slots)
+				}
+			}
+
+			limit := wfe.EndpointLimits[pattern]
+			timeout := limit.Timeout
+			if timeout == 0 {
+				timeout = wfe.RequestTimeout
+			}
 			if timeout == 0 {
 				timeout = 5 * time.Minute
 			}
 			ctx, cancel := context.WithTimeout(ctx, timeout)
+
+			maxSize := limit.MaxRequestSize
+			if maxSize == 0 {
+				maxSize = maxRequestSize
+			}
+			if request.Body != nil {
+				request.Body = http.MaxBytesReader(response, request.Body, maxSize)
+			}
 			// TODO(riking): add request context using WithValue
 
+			if wfe.CompressEndpoints[pattern] {
+				compressingResponse := newCompressingResponseWriter(response, request)
+				defer func() {
+					if err := compressingResponse.Close(); err != nil {
+						logEvent.AddError("finishing compressed response: %s", err)
+					}
+				}()
+				response = compressingResponse
+			}
+
 			// Call the wrapped handler.
 			h(ctx, logEvent, response, request)
 			cancel()
@@ -359,6 +526,7 @@ func (wfe *WebFrontEndImpl) Handler(stats prometheus.Registerer) http.Handler {
 	// Boulder specific endpoints
 	wfe.HandleFunc(m, issuerPath, wfe.Issuer, "GET")
 	wfe.HandleFunc(m, buildIDPath, wfe.BuildID, "GET")
+	wfe.HandleFunc(m, healthzPath, wfe.Healthz, "GET")
 
 	// POSTable ACME endpoints
 	wfe.HandleFunc(m, newAcctPath, wfe.NewAccount, "POST")
@@ -367,10 +535,13 @@ func (wfe *WebFrontEndImpl) Handler(stats prometheus.Registerer) http.Handler {
 	wfe.HandleFunc(m, rolloverPath, wfe.KeyRollover, "POST")
 	wfe.HandleFunc(m, newOrderPath, wfe.NewOrder, "POST")
 	wfe.HandleFunc(m, finalizeOrderPath, wfe.FinalizeOrder, "POST")
+	wfe.HandleFunc(m, ordersPath, wfe.Orders, "POST")
 
 	// GETable and POST-as-GETable ACME endpoints
 	wfe.HandleFunc(m, directoryPath, wfe.Directory, "GET", "POST")
 	wfe.HandleFunc(m, newNoncePath, wfe.Nonce, "GET", "POST")
+	// GETable ACME endpoints
+	wfe.HandleFunc(m, renewalInfoPath, wfe.RenewalInfo, "GET")
 	// POST-as-GETable ACME endpoints
 	// TODO(@cpu): After November 1st, 2020 support for "GET" to the following
 	// endpoints will be removed, leaving only POST-as-GET support.
@@ -387,7 +558,7 @@ func (wfe *WebFrontEndImpl) Handler(stats prometheus.Registerer) http.Handler {
 	// We don't use our special HandleFunc for "/" because it matches everything,
 	// meaning we can wind up returning 405 when we mean to return 404. See
 	// https://github.com/letsencrypt/boulder/issues/717
-	m.Handle("/", web.NewTopHandler(wfe.log, web.WFEHandlerFunc(wfe.Index)))
+	m.Handle("/", wfe.topHandler(web.WFEHandlerFunc(wfe.Index)))
 	return measured_http.New(m, wfe.clk, stats)
 }
 
@@ -441,14 +612,6 @@ func (wfe *WebFrontEndImpl) Directory(
 	logEvent *web.RequestEvent,
 	response http.ResponseWriter,
 	request *http.Request) {
-	directoryEndpoints := map[string]interface{}{
-		"newAccount": newAcctPath,
-		"newNonce":   newNoncePath,
-		"revokeCert": revokeCertPath,
-		"newOrder":   newOrderPath,
-		"keyChange":  rolloverPath,
-	}
-
 	if request.Method == http.MethodPost {
 		acct, prob := wfe.validPOSTAsGETForAccount(request, ctx, logEvent)
 		if prob != nil {
@@ -458,43 +621,74 @@ func (wfe *WebFrontEndImpl) Directory(
 		logEvent.Requester = acct.ID
 	}
 
+	// The directory only depends on the WFE's configuration and the scheme and
+	// host it was requested with, so it's built once for each of those.
+	dir, err := wfe.responses.get("directory "+web.RelativeEndpoint(request, "/"), func() ([]byte, error) {
+		return wfe.buildDirectory(request)
+	})
+	if err != nil {
+		marshalProb := probs.ServerInternal("unable to marshal JSON directory")
+		wfe.sendError(response, logEvent, marshalProb, nil)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	if _, err := dir.write(response, request); err != nil {
+		wfe.log.Warningf("Could not write response: %s", err)
+	}
+}
+
+// buildDirectory returns the directory object, with paths prefixed using the
+// `request.Host` of the HTTP request.
+func (wfe *WebFrontEndImpl) buildDirectory(request *http.Request) ([]byte, error) {
+	directoryEndpoints := map[string]interface{}{
+		"newAccount": newAcctPath,
+		"newNonce":   newNoncePath,
+		"revokeCert": revokeCertPath,
+		"newOrder":   newOrderPath,
+		"keyChange":  rolloverPath,
+	}
+	if features.Enabled(features.RenewalInfo) {
+		directoryEndpoints["renewalInfo"] = renewalInfoPath
+	}
+
 	// Add a random key to the directory in order to make sure that clients don't hardcode an
 	// expected set of keys. This ensures that we can properly extend the directory when we
-	// need to add a new endpoint or meta element.
+	// need to add a new endpoint or meta element. Since the directory is cached,
+	// the key changes from one WFE instance or restart to the next rather than
+	// with every request.
 	directoryEndpoints[core.RandomString(8)] = randomDirKeyExplanationLink
 
 	// ACME since draft-02 describes an optional "meta" directory entry. The
 	// meta entry may optionally contain a "termsOfService" URI for the
 	// current ToS.
-	metaMap := map[string]interface{}{
-		"termsOfService": wfe.SubscriberAgreementURL,
+	metaMap := map[string]interface{}{}
+	if wfe.SubscriberAgreementURL != "" {
+		metaMap["termsOfService"] = wfe.SubscriberAgreementURL
 	}
 	// The "meta" directory entry may also include a []string of CAA identities
-	if wfe.DirectoryCAAIdentity != "" {
-		// The specification says caaIdentities is an array of strings. In
-		// practice Boulder's VA only allows configuring ONE CAA identity. Given
-		// that constraint it doesn't make sense to allow multiple directory CAA
-		// identities so we use just the `wfe.DirectoryCAAIdentity` alone.
-		metaMap["caaIdentities"] = []string{
-			wfe.DirectoryCAAIdentity,
-		}
+	if len(wfe.DirectoryCAAIdentities) > 0 {
+		metaMap["caaIdentities"] = wfe.DirectoryCAAIdentities
 	}
 	// The "meta" directory entry may also include a string with a website URL
 	if wfe.DirectoryWebsite != "" {
 		metaMap["website"] = wfe.DirectoryWebsite
 	}
-	directoryEndpoints["meta"] = metaMap
-
-	response.Header().Set("Content-Type", "application/json")
-
-	relDir, err := wfe.relativeDirectory(request, directoryEndpoints)
-	if err != nil {
-		marshalProb := probs.ServerInternal("unable to marshal JSON directory")
-		wfe.sendError(response, logEvent, marshalProb, nil)
-		return
+	// The "meta" directory entry may also include a map of the certificate
+	// profiles that may be requested in a new order
+	if len(wfe.CertificateProfiles) > 0 {
+		metaMap["profiles"] = wfe.CertificateProfiles
+	}
+	// The "meta" directory entry may also indicate that new accounts must be
+	// bound to an external account
+	if wfe.eabKeys != nil {
+		metaMap["externalAccountRequired"] = true
+	}
+	if len(metaMap) > 0 {
+		directoryEndpoints["meta"] = metaMap
 	}
 
-	response.Write(relDir)
+	return wfe.relativeDirectory(request, directoryEndpoints)
 }
 
 // Nonce is an endpoint for getting a fresh nonce with an HTTP GET or HEAD
@@ -556,9 +750,10 @@ func (wfe *WebFrontEndImpl) NewAccount(
 	}
 
 	var accountCreateRequest struct {
-		Contact              *[]string `json:"contact"`
-		TermsOfServiceAgreed bool      `json:"termsOfServiceAgreed"`
-		OnlyReturnExisting   bool      `json:"onlyReturnExisting"`
+		Contact                *[]string       `json:"contact"`
+		TermsOfServiceAgreed   bool            `json:"termsOfServiceAgreed"`
+		OnlyReturnExisting     bool            `json:"onlyReturnExisting"`
+		ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
 	}
 
 	err := json.Unmarshal(body, &accountCreateRequest)
@@ -580,9 +775,7 @@ func (wfe *WebFrontEndImpl) NewAccount(
 			web.RelativeEndpoint(request, fmt.Sprintf("%s%d", acctPath, acct.ID)))
 		logEvent.Requester = acct.ID
 
-		prepAccountForDisplay(&acct)
-
-		err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, acct)
+		err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, wfe.accountForDisplay(request, &acct))
 		if err != nil {
 			// ServerInternal because we just created this account, and it
 			// should be OK.
@@ -614,6 +807,14 @@ func (wfe *WebFrontEndImpl) NewAccount(
 		return
 	}
 
+	if wfe.eabKeys != nil {
+		prob := wfe.validExternalAccountBinding(ctx, accountCreateRequest.ExternalAccountBinding, key, request, logEvent)
+		if prob != nil {
+			wfe.sendError(response, logEvent, prob, nil)
+			return
+		}
+	}
+
 	ip, err := extractRequesterIP(request)
 	if err != nil {
 		wfe.sendError(
@@ -660,9 +861,7 @@ func (wfe *WebFrontEndImpl) NewAccount(
 		response.Header().Add("Link", link(wfe.SubscriberAgreementURL, "terms-of-service"))
 	}
 
-	prepAccountForDisplay(&acct)
-
-	err = wfe.writeJsonResponse(response, logEvent, http.StatusCreated, acct)
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusCreated, wfe.accountForDisplay(request, &acct))
 	if err != nil {
 		// ServerInternal because we just created this account, and it
 		// should be OK.
@@ -672,11 +871,13 @@ func (wfe *WebFrontEndImpl) NewAccount(
 }
 
 func (wfe *WebFrontEndImpl) acctHoldsAuthorizations(ctx context.Context, acctID int64, names []string) (bool, error) {
-	now := wfe.clk.Now().UnixNano()
+	now := wfe.clk.Now()
+	nowNanos := now.UnixNano()
 	authzMapPB, err := wfe.SA.GetValidAuthorizations2(ctx, &sapb.GetValidAuthorizationsRequest{
 		RegistrationID: &acctID,
 		Domains:        names,
-		Now:            &now,
+		Now:            &nowNanos,
+		NowTime:        bgrpc.TimeToPB(now),
 	})
 	if err != nil {
 		return false, err
@@ -782,11 +983,16 @@ func (wfe *WebFrontEndImpl) processRevocation(
 			}
 			return probs.ServerInternal("unable to retrieve certificate")
 		}
-		// If the certificate in the DB isn't a byte for byte match, return a problem
-		if !bytes.Equal(cert.DER, revokeRequest.CertificateDER) {
+		// If the certificate in the DB isn't a byte for byte match, return a problem.
+		// Certificates stored without their DER are matched by digest instead.
+		if len(cert.DER) == 0 {
+			if cert.Digest != core.Fingerprint256(revokeRequest.CertificateDER) {
+				return notFoundProb
+			}
+		} else if !bytes.Equal(cert.DER, revokeRequest.CertificateDER) {
 			return notFoundProb
 		}
-		certDER = cert.DER
+		certDER = revokeRequest.CertificateDER
 	}
 
 	// Parse the certificate into memory
@@ -838,6 +1044,18 @@ func (wfe *WebFrontEndImpl) processRevocation(
 		reason = *revokeRequest.Reason
 	}
 
+	// A keyCompromise revocation request signed by the certificate's own key
+	// proves the key is compromised, so the RA also blocks the key and revokes
+	// the other certificates which share it.
+	if acctID == 0 && reason == ocsp.KeyCompromise {
+		_, err := wfe.RA.RevokeCertByKey(ctx, &rapb.RevokeCertByKeyRequest{Cert: parsedCertificate.Raw})
+		if err != nil {
+			return web.ProblemDetailsForError(err, "Failed to revoke certificate")
+		}
+		wfe.log.Debugf("Revoked %v and certificates sharing its key", serial)
+		return nil
+	}
+
 	// Revoke the certificate. AcctID may be 0 if there is no associated account
 	// (e.g. it was a self-authenticated JWS using the certificate public key)
 	if err := wfe.RA.RevokeCertificateWithReg(ctx, *parsedCertificate, reason, acctID); err != nil {
@@ -902,7 +1120,11 @@ func (wfe *WebFrontEndImpl) revokeCertByKeyID(
 			return nil
 		}
 		// Otherwise check if the account, while not the owner, has equivalent authorizations
-		valid, err := wfe.acctHoldsAuthorizations(ctx, acct.ID, parsedCertificate.DNSNames)
+		names := parsedCertificate.DNSNames
+		for _, ip := range parsedCertificate.IPAddresses {
+			names = append(names, ip.String())
+		}
+		valid, err := wfe.acctHoldsAuthorizations(ctx, acct.ID, names)
 		if err != nil {
 			return probs.ServerInternal("Failed to retrieve authorizations for names in certificate")
 		}
@@ -1000,7 +1222,7 @@ func (wfe *WebFrontEndImpl) RevokeCertificate(
 	response.WriteHeader(http.StatusOK)
 }
 
-func (wfe *WebFrontEndImpl) logCsr(request *http.Request, cr core.CertificateRequest, account core.Registration) {
+func (wfe *WebFrontEndImpl) logCsr(ctx context.Context, request *http.Request, cr core.CertificateRequest, account core.Registration) {
 	var csrLog = struct {
 		ClientAddr string
 		CSR        string
@@ -1010,7 +1232,7 @@ func (wfe *WebFrontEndImpl) logCsr(request *http.Request, cr core.CertificateReq
 		CSR:        hex.EncodeToString(cr.Bytes),
 		Requester:  account.ID,
 	}
-	wfe.log.AuditObject("Certificate request", csrLog)
+	blog.ForContext(ctx, wfe.log).AuditObject("Certificate request", csrLog)
 }
 
 // Challenge handles POST requests to challenge URLs belonging to
@@ -1055,7 +1277,7 @@ func (wfe *WebFrontEndImpl) Challenge(
 		return
 	}
 
-	if features.Enabled(features.MandatoryPOSTAsGET) && request.Method != http.MethodPost && !requiredStale(request, logEvent) {
+	if wfe.legacyGETForbidden(request, logEvent, wfe.AllowLegacyGET.Authorizations) {
 		wfe.sendError(response, logEvent, probs.MethodNotAllowed(), nil)
 		return
 	}
@@ -1106,6 +1328,24 @@ func prepAccountForDisplay(acct *core.Registration) {
 	acct.Agreement = ""
 }
 
+// accountJSON is an account as displayed to clients: the prepared
+// core.Registration, plus the URL of the account's orders list.
+type accountJSON struct {
+	*core.Registration
+	Orders string `json:"orders,omitempty"`
+}
+
+// accountForDisplay prepares acct for display with prepAccountForDisplay, and
+// returns it along with the URL of its orders list, if that is enabled.
+func (wfe *WebFrontEndImpl) accountForDisplay(request *http.Request, acct *core.Registration) accountJSON {
+	var ordersURL string
+	if wfe.OrdersPageSize > 0 {
+		ordersURL = web.RelativeEndpoint(request, fmt.Sprintf("%s%d", ordersPath, acct.ID))
+	}
+	prepAccountForDisplay(acct)
+	return accountJSON{Registration: acct, Orders: ordersURL}
+}
+
 // prepChallengeForDisplay takes a core.Challenge and prepares it for display to
 // the client by filling in its URL field and clearing its ID and URI fields.
 func (wfe *WebFrontEndImpl) prepChallengeForDisplay(request *http.Request, authz core.Authorization, challenge *core.Challenge) {
@@ -1119,6 +1359,13 @@ func (wfe *WebFrontEndImpl) prepChallengeForDisplay(request *http.Request, authz
 	// ACMEv2 never sends the KeyAuthorization back in a challenge object.
 	challenge.ProvidedKeyAuthorization = ""
 
+	// The onion-csr-01 challenge calls its token a nonce, since it's signed
+	// into a CSR rather than used to build a key authorization.
+	if challenge.Type == core.ChallengeTypeOnionCSR01 {
+		challenge.Nonce = challenge.Token
+		challenge.Token = ""
+	}
+
 	// Historically the Type field of a problem was always prefixed with a static
 	// error namespace. To support the V2 API and migrating to the correct IETF
 	// namespace we now prefix the Type with the correct namespace at runtime when
@@ -1175,6 +1422,7 @@ func (wfe *WebFrontEndImpl) getChallenge(
 	authzURL := urlForAuthz(authz, request)
 	response.Header().Add("Location", challenge.URL)
 	response.Header().Add("Link", link(authzURL, "up"))
+	wfe.addChallengeRetryAfter(response, *challenge)
 
 	err := wfe.writeJsonResponse(response, logEvent, http.StatusOK, challenge)
 	if err != nil {
@@ -1185,6 +1433,30 @@ func (wfe *WebFrontEndImpl) getChallenge(
 	}
 }
 
+// addChallengeRetryAfter adds a Retry-After header, in whole seconds, to the
+// response for a challenge whose validation is still processing (RFC 8555
+// Section 7.5.1).
+func (wfe *WebFrontEndImpl) addChallengeRetryAfter(response http.ResponseWriter, challenge core.Challenge) {
+	if challenge.Status != core.StatusProcessing {
+		return
+	}
+	retryAfter := wfe.ExpectedValidationDuration
+	if retryAfter <= 0 {
+		retryAfter = defaultValidationRetryAfter
+	}
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	response.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+// addOrderRetryAfter adds a Retry-After header to the response for an order
+// whose certificate is still being issued (RFC 8555 Section 7.4).
+func addOrderRetryAfter(response http.ResponseWriter, order *corepb.Order) {
+	if order.GetStatus() != string(core.StatusProcessing) {
+		return
+	}
+	response.Header().Set("Retry-After", strconv.Itoa(int(issuanceRetryAfter/time.Second)))
+}
+
 func (wfe *WebFrontEndImpl) postChallenge(
 	ctx context.Context,
 	response http.ResponseWriter,
@@ -1222,8 +1494,11 @@ func (wfe *WebFrontEndImpl) postChallenge(
 	// We can expect some clients to try and update a challenge for an authorization
 	// that is already valid. In this case we don't need to process the challenge
 	// update. It wouldn't be helpful, the overall authorization is already good!
+	// Likewise, a challenge whose validation is already in progress is returned
+	// as it is, with a Retry-After telling the client when to check back,
+	// rather than being validated again.
 	var returnAuthz core.Authorization
-	if authz.Status == core.StatusValid {
+	if authz.Status == core.StatusValid || authz.Challenges[challengeIndex].Status == core.StatusProcessing {
 		returnAuthz = authz
 	} else {
 
@@ -1238,6 +1513,21 @@ func (wfe *WebFrontEndImpl) postChallenge(
 			return
 		}
 
+		// An onion-csr-01 response, unlike those of the other challenge types,
+		// carries the CSR the VA validates, so it's passed along with the authz.
+		if authz.Challenges[challengeIndex].Type == core.ChallengeTypeOnionCSR01 {
+			var onionResponse core.OnionCSRResponse
+			if err := json.Unmarshal(body, &onionResponse); err != nil {
+				wfe.sendError(response, logEvent, probs.Malformed("Error unmarshaling onion-csr-01 challenge response"), err)
+				return
+			}
+			if onionResponse.CSR == "" {
+				wfe.sendError(response, logEvent, probs.Malformed("onion-csr-01 challenge response is missing a CSR"), nil)
+				return
+			}
+			authz.Challenges[challengeIndex].OnionCSRResponse = &onionResponse
+		}
+
 		authzPB, err := bgrpc.AuthzToPB(authz)
 		if err != nil {
 			wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Unable to serialize authz"), err)
@@ -1249,6 +1539,7 @@ func (wfe *WebFrontEndImpl) postChallenge(
 			Authz:          authzPB,
 			ChallengeIndex: &challIndex,
 		})
+		wfe.storageCache.forgetAuthorization(authz.ID)
 		if err != nil {
 			wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Unable to update challenge"), err)
 			return
@@ -1268,6 +1559,7 @@ func (wfe *WebFrontEndImpl) postChallenge(
 	authzURL := urlForAuthz(authz, request)
 	response.Header().Add("Location", challenge.URL)
 	response.Header().Add("Link", link(authzURL, "up"))
+	wfe.addChallengeRetryAfter(response, challenge)
 
 	err := wfe.writeJsonResponse(response, logEvent, http.StatusOK, challenge)
 	if err != nil {
@@ -1321,9 +1613,7 @@ func (wfe *WebFrontEndImpl) Account(
 		response.Header().Add("Link", link(wfe.SubscriberAgreementURL, "terms-of-service"))
 	}
 
-	prepAccountForDisplay(currAcct)
-
-	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, currAcct)
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, wfe.accountForDisplay(request, currAcct))
 	if err != nil {
 		// ServerInternal because we just generated the account, it should be OK
 		wfe.sendError(response, logEvent,
@@ -1341,11 +1631,13 @@ func (wfe *WebFrontEndImpl) updateAccount(
 	ctx context.Context,
 	requestBody []byte,
 	currAcct *core.Registration) (*core.Registration, *probs.ProblemDetails) {
-	// Only the Contact and Status fields of an account may be updated this way.
-	// For key updates clients should be using the key change endpoint.
+	// Only the Contact and Status fields of an account, and its revocation
+	// webhook, may be updated this way. For key updates clients should be using
+	// the key change endpoint.
 	var accountUpdateRequest struct {
-		Contact *[]string       `json:"contact"`
-		Status  core.AcmeStatus `json:"status"`
+		Contact           *[]string       `json:"contact"`
+		Status            core.AcmeStatus `json:"status"`
+		RevocationWebhook *string         `json:"revocationWebhook"`
 	}
 
 	err := json.Unmarshal(requestBody, &accountUpdateRequest)
@@ -1372,7 +1664,9 @@ func (wfe *WebFrontEndImpl) updateAccount(
 		if update.Status != core.StatusDeactivated {
 			return nil, probs.Malformed("Invalid value provided for status field")
 		}
-		if err := wfe.RA.DeactivateRegistration(ctx, *currAcct); err != nil {
+		err := wfe.RA.DeactivateRegistration(ctx, *currAcct)
+		wfe.storageCache.forgetRegistration(currAcct.ID)
+		if err != nil {
 			return nil, web.ProblemDetailsForError(err, "Unable to deactivate account")
 		}
 		currAcct.Status = core.StatusDeactivated
@@ -1386,10 +1680,37 @@ func (wfe *WebFrontEndImpl) updateAccount(
 	// JSON to send via RPC to the RA.
 	update.Key = currAcct.Key
 
+	// The revocation webhook isn't part of the registration object, so it is
+	// stored separately once the rest of the update has succeeded. It's
+	// validated first, so that a bad webhook doesn't leave the rest of the
+	// update committed. An empty string removes any existing webhook.
+	if accountUpdateRequest.RevocationWebhook != nil {
+		validateOnly := true
+		_, err := wfe.RA.SetRevocationWebhook(ctx, &rapb.SetRevocationWebhookRequest{
+			RegistrationID: &currAcct.ID,
+			Url:            accountUpdateRequest.RevocationWebhook,
+			ValidateOnly:   &validateOnly,
+		})
+		if err != nil {
+			return nil, web.ProblemDetailsForError(err, "Unable to update revocation webhook")
+		}
+	}
+
 	updatedAcct, err := wfe.RA.UpdateRegistration(ctx, *currAcct, update)
+	wfe.storageCache.forgetRegistration(currAcct.ID)
 	if err != nil {
 		return nil, web.ProblemDetailsForError(err, "Unable to update account")
 	}
+
+	if accountUpdateRequest.RevocationWebhook != nil {
+		_, err := wfe.RA.SetRevocationWebhook(ctx, &rapb.SetRevocationWebhookRequest{
+			RegistrationID: &currAcct.ID,
+			Url:            accountUpdateRequest.RevocationWebhook,
+		})
+		if err != nil {
+			return nil, web.ProblemDetailsForError(err, "Unable to update revocation webhook")
+		}
+	}
 	return &updatedAcct, nil
 }
 
@@ -1417,6 +1738,7 @@ func (wfe *WebFrontEndImpl) deactivateAuthorization(
 		return false
 	}
 	err = wfe.RA.DeactivateAuthorization(ctx, *authz)
+	wfe.storageCache.forgetAuthorization(authz.ID)
 	if err != nil {
 		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Error deactivating authorization"), err)
 		return false
@@ -1434,7 +1756,7 @@ func (wfe *WebFrontEndImpl) Authorization(
 	response http.ResponseWriter,
 	request *http.Request) {
 
-	if features.Enabled(features.MandatoryPOSTAsGET) && request.Method != http.MethodPost && !requiredStale(request, logEvent) {
+	if wfe.legacyGETForbidden(request, logEvent, wfe.AllowLegacyGET.Authorizations) {
 		wfe.sendError(response, logEvent, probs.MethodNotAllowed(), nil)
 		return
 	}
@@ -1528,10 +1850,21 @@ func (wfe *WebFrontEndImpl) Authorization(
 	}
 }
 
+// legacyGETForbidden returns true if request is an unauthenticated GET which
+// must be refused because the MandatoryPOSTAsGET feature flag is enabled and
+// allowed, the AllowLegacyGET setting for the type of resource requested, is
+// false. GET requests to the Boulder-specific GET API are never forbidden.
+func (wfe *WebFrontEndImpl) legacyGETForbidden(request *http.Request, logEvent *web.RequestEvent, allowed bool) bool {
+	return features.Enabled(features.MandatoryPOSTAsGET) &&
+		!allowed &&
+		request.Method != http.MethodPost &&
+		!requiredStale(request, logEvent)
+}
+
 // Certificate is used by clients to request a copy of their current certificate, or to
 // request a reissuance of the certificate.
 func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
-	if features.Enabled(features.MandatoryPOSTAsGET) && request.Method != http.MethodPost && !requiredStale(request, logEvent) {
+	if wfe.legacyGETForbidden(request, logEvent, wfe.AllowLegacyGET.Certificates) {
 		wfe.sendError(response, logEvent, probs.MethodNotAllowed(), nil)
 		return
 	}
@@ -1591,6 +1924,11 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 		}
 		return
 	}
+	if len(cert.DER) == 0 {
+		// The certificate's DER was pruned once its retention period passed.
+		wfe.sendError(response, logEvent, probs.NotFound("Certificate no longer available"), nil)
+		return
+	}
 
 	if requiredStale(request, logEvent) {
 		if prob := wfe.staleEnoughToGETCert(cert); prob != nil {
@@ -1676,15 +2014,32 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 		responsePEM = leafPEM
 	}
 
+	// Clients may ask for the leaf alone in DER, or for the chain in PKCS#7,
+	// instead of the default PEM chain.
+	contentType := negotiateCertContentType(request.Header.Get("Accept"))
+	responseBody := responsePEM
+	switch contentType {
+	case derContentType:
+		responseBody = cert.DER
+	case pkcs7ContentType:
+		responseBody, err = pemChainToPKCS7(responsePEM)
+		if err != nil {
+			wfe.sendError(response, logEvent, probs.ServerInternal(
+				fmt.Sprintf("unable to encode certificate with serial %#v as PKCS#7", serial)), err)
+			return
+		}
+	}
+
 	// NOTE(@cpu): We must explicitly set the Content-Length header here. The Go
 	// HTTP library will only add this header if the body is below a certain size
 	// and with the addition of a PEM encoded certificate chain the body size of
 	// this endpoint will exceed this threshold. Since we know the length we can
 	// reliably set it ourselves and not worry.
-	response.Header().Set("Content-Length", strconv.Itoa(len(responsePEM)))
-	response.Header().Set("Content-Type", "application/pem-certificate-chain")
+	response.Header().Set("Content-Length", strconv.Itoa(len(responseBody)))
+	response.Header().Set("Content-Type", contentType)
+	response.Header().Add("Vary", "Accept")
 	response.WriteHeader(http.StatusOK)
-	if _, err = response.Write(responsePEM); err != nil {
+	if _, err = response.Write(responseBody); err != nil {
 		wfe.log.Warningf("Could not write response: %s", err)
 	}
 }
@@ -1692,9 +2047,11 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 // Issuer obtains the issuer certificate used by this instance of Boulder.
 func (wfe *WebFrontEndImpl) Issuer(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
 	// TODO Content negotiation
+	issuer, _ := wfe.responses.get("issuer", func() ([]byte, error) {
+		return wfe.IssuerCert, nil
+	})
 	response.Header().Set("Content-Type", "application/pkix-cert")
-	response.WriteHeader(http.StatusOK)
-	if _, err := response.Write(wfe.IssuerCert); err != nil {
+	if _, err := issuer.write(response, request); err != nil {
 		wfe.log.Warningf("Could not write response: %s", err)
 	}
 }
@@ -1709,6 +2066,28 @@ func (wfe *WebFrontEndImpl) BuildID(ctx context.Context, logEvent *web.RequestEv
 	}
 }
 
+// StartDraining makes the health check endpoint report the WFE as unhealthy,
+// so that load balancers stop sending it new requests before it shuts down.
+// Requests to other endpoints continue to be served.
+func (wfe *WebFrontEndImpl) StartDraining() {
+	atomic.StoreInt32(&wfe.draining, 1)
+}
+
+// Healthz tells the requestor whether the WFE is healthy: it responds
+// 503 Service Unavailable once StartDraining has been called, and 200 OK
+// before then.
+func (wfe *WebFrontEndImpl) Healthz(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "text/plain")
+	status, body := http.StatusOK, "OK"
+	if atomic.LoadInt32(&wfe.draining) != 0 {
+		status, body = http.StatusServiceUnavailable, "Draining"
+	}
+	response.WriteHeader(status)
+	if _, err := fmt.Fprintln(response, body); err != nil {
+		wfe.log.Warningf("Could not write response: %s", err)
+	}
+}
+
 // Options responds to an HTTP OPTIONS request.
 func (wfe *WebFrontEndImpl) Options(response http.ResponseWriter, request *http.Request, methodsStr string, methodsMap map[string]bool) {
 	// Every OPTIONS request gets an Allow header with a list of supported methods.
@@ -1832,6 +2211,25 @@ func (wfe *WebFrontEndImpl) KeyRollover(
 		return
 	}
 
+	if wfe.BlockRolloverToBlockedKeys {
+		digest, err := core.KeyDigest(newKey.Key)
+		if err != nil {
+			wfe.sendError(response, logEvent, probs.ServerInternal("Unable to compute new key digest"), err)
+			return
+		}
+		blocked, err := wfe.SA.KeyBlocked(ctx, &sapb.KeyBlockedRequest{KeyHash: digest[:]})
+		if err != nil {
+			wfe.sendError(response, logEvent, probs.ServerInternal("Failed to check whether new key is blocked"), err)
+			return
+		}
+		if blocked.GetExists() {
+			wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "KeyRolloverBlockedKey"}).Inc()
+			wfe.sendError(response, logEvent, probs.BadPublicKey(
+				"New key specified by rollover request is blocked, for instance because it is known to be compromised"), nil)
+			return
+		}
+	}
+
 	// Check that the new key isn't already being used for an existing account
 	if existingAcct, err := wfe.SA.GetRegistrationByKey(ctx, &newKey); err == nil {
 		response.Header().Set("Location",
@@ -1846,6 +2244,7 @@ func (wfe *WebFrontEndImpl) KeyRollover(
 
 	// Update the account key to the new key
 	updatedAcct, err := wfe.RA.UpdateRegistration(ctx, *acct, core.Registration{Key: &newKey})
+	wfe.storageCache.forgetRegistration(acct.ID)
 	if err != nil {
 		if berrors.Is(err, berrors.Duplicate) {
 			// It is possible that between checking for the existing key, and preforming the update
@@ -1868,9 +2267,7 @@ func (wfe *WebFrontEndImpl) KeyRollover(
 		return
 	}
 
-	prepAccountForDisplay(&updatedAcct)
-
-	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, updatedAcct)
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, wfe.accountForDisplay(request, &updatedAcct))
 	if err != nil {
 		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to marshal updated account"), err)
 	}
@@ -1884,6 +2281,9 @@ type orderJSON struct {
 	Finalize       string                      `json:"finalize"`
 	Certificate    string                      `json:"certificate,omitempty"`
 	Error          *probs.ProblemDetails       `json:"error,omitempty"`
+	Profile        string                      `json:"profile,omitempty"`
+	NotBefore      *time.Time                  `json:"notBefore,omitempty"`
+	NotAfter       *time.Time                  `json:"notAfter,omitempty"`
 }
 
 // orderToOrderJSON converts a *corepb.Order instance into an orderJSON struct
@@ -1893,7 +2293,7 @@ type orderJSON struct {
 func (wfe *WebFrontEndImpl) orderToOrderJSON(request *http.Request, order *corepb.Order) orderJSON {
 	idents := make([]identifier.ACMEIdentifier, len(order.Names))
 	for i, name := range order.Names {
-		idents[i] = identifier.ACMEIdentifier{Type: identifier.DNS, Value: name}
+		idents[i] = identifier.FromValue(name)
 	}
 	finalizeURL := web.RelativeEndpoint(request,
 		fmt.Sprintf("%s%d/%d", finalizeOrderPath, *order.RegistrationID, *order.Id))
@@ -1902,6 +2302,15 @@ func (wfe *WebFrontEndImpl) orderToOrderJSON(request *http.Request, order *corep
 		Expires:     time.Unix(0, *order.Expires).UTC(),
 		Identifiers: idents,
 		Finalize:    finalizeURL,
+		Profile:     order.GetCertificateProfileName(),
+	}
+	if order.GetNotBefore() != 0 {
+		notBefore := time.Unix(0, order.GetNotBefore()).UTC()
+		respObj.NotBefore = &notBefore
+	}
+	if order.GetNotAfter() != 0 {
+		notAfter := time.Unix(0, order.GetNotAfter()).UTC()
+		respObj.NotAfter = &notAfter
 	}
 	// If there is an order error, prefix its type with the V2 namespace
 	if order.Error != nil {
@@ -1924,6 +2333,57 @@ func (wfe *WebFrontEndImpl) orderToOrderJSON(request *http.Request, order *corep
 	return respObj
 }
 
+// checkProfile returns a problem if the certificate profile named profile
+// doesn't exist or isn't available to the account acctID, or if certificate
+// profiles aren't enabled.
+func (wfe *WebFrontEndImpl) checkProfile(profile string, acctID int64) *probs.ProblemDetails {
+	if !features.Enabled(features.CertificateProfiles) {
+		return probs.InvalidProfile("Certificate profiles are not supported")
+	}
+	if _, ok := wfe.CertificateProfiles[profile]; !ok {
+		return probs.InvalidProfile("NewOrder request specified unknown profile %q", profile)
+	}
+	allowed, restricted := wfe.CertificateProfileAccounts[profile]
+	if !restricted {
+		return nil
+	}
+	for _, id := range allowed {
+		if id == acctID {
+			return nil
+		}
+	}
+	return probs.InvalidProfile("Profile %q is not available to this account", profile)
+}
+
+// parseValidityWindow parses the RFC 3339 `notBefore` and `notAfter` of a new
+// order request, either of which may be empty, into nanoseconds since the
+// epoch. Whether the window is acceptable for the order is up to the RA.
+func parseValidityWindow(notBefore, notAfter string) (*int64, *int64, *probs.ProblemDetails) {
+	var notBeforeNanos, notAfterNanos *int64
+	var notBeforeTime time.Time
+	if notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			return nil, nil, probs.Malformed("Invalid NotBefore %q", notBefore)
+		}
+		notBeforeTime = t
+		nanos := t.UnixNano()
+		notBeforeNanos = &nanos
+	}
+	if notAfter != "" {
+		t, err := time.Parse(time.RFC3339, notAfter)
+		if err != nil {
+			return nil, nil, probs.Malformed("Invalid NotAfter %q", notAfter)
+		}
+		if notBeforeNanos != nil && !t.After(notBeforeTime) {
+			return nil, nil, probs.Malformed("NotAfter must be after NotBefore")
+		}
+		nanos := t.UnixNano()
+		notAfterNanos = &nanos
+	}
+	return notBeforeNanos, notAfterNanos, nil
+}
+
 // NewOrder is used by clients to create a new order object from a CSR
 func (wfe *WebFrontEndImpl) NewOrder(
 	ctx context.Context,
@@ -1938,12 +2398,16 @@ func (wfe *WebFrontEndImpl) NewOrder(
 		return
 	}
 
-	// We only allow specifying Identifiers in a new order request - if the
-	// `notBefore` and/or `notAfter` fields described in Section 7.4 of acme-08
-	// are sent we return a probs.Malformed as we do not support them
+	// We allow specifying Identifiers, a certificate profile, the certificate
+	// the order replaces, and the `notBefore` and `notAfter` fields described
+	// in RFC 8555 Section 7.4 in a new order request. The latter are refused
+	// with a probs.Malformed unless the OrderValidityWindows feature is enabled
 	var newOrderRequest struct {
-		Identifiers         []identifier.ACMEIdentifier `json:"identifiers"`
-		NotBefore, NotAfter string
+		Identifiers []identifier.ACMEIdentifier `json:"identifiers"`
+		NotBefore   string                      `json:"notBefore"`
+		NotAfter    string                      `json:"notAfter"`
+		Profile     string                      `json:"profile"`
+		Replaces    string                      `json:"replaces"`
 	}
 	err := json.Unmarshal(body, &newOrderRequest)
 	if err != nil {
@@ -1957,31 +2421,101 @@ func (wfe *WebFrontEndImpl) NewOrder(
 			probs.Malformed("NewOrder request did not specify any identifiers"), nil)
 		return
 	}
+	var notBefore, notAfter *int64
 	if newOrderRequest.NotBefore != "" || newOrderRequest.NotAfter != "" {
-		wfe.sendError(response, logEvent, probs.Malformed("NotBefore and NotAfter are not supported"), nil)
-		return
+		if !features.Enabled(features.OrderValidityWindows) {
+			wfe.sendError(response, logEvent, probs.Malformed("NotBefore and NotAfter are not supported"), nil)
+			return
+		}
+		var prob *probs.ProblemDetails
+		notBefore, notAfter, prob = parseValidityWindow(newOrderRequest.NotBefore, newOrderRequest.NotAfter)
+		if prob != nil {
+			wfe.sendError(response, logEvent, prob, nil)
+			return
+		}
+	}
+	if newOrderRequest.Profile != "" {
+		if prob := wfe.checkProfile(newOrderRequest.Profile, acct.ID); prob != nil {
+			wfe.sendError(response, logEvent, prob, nil)
+			return
+		}
 	}
 
-	// Collect up all of the DNS identifier values into a []string for subsequent
-	// layers to process. We reject anything with a non-DNS type identifier here.
+	// Collect up all of the identifier values into a []string for subsequent
+	// layers to process. We reject anything with a non-DNS type identifier here,
+	// unless IP identifiers are enabled. The type of each value is inferred from
+	// it from here on, so with IP identifiers enabled a DNS identifier may not
+	// hold an IP address, and IP addresses are converted to canonical form.
+	// Every unacceptable identifier is reported, as a sub-problem, so that the
+	// client can fix them all at once.
+	ipIdentifiers := features.Enabled(features.IPIdentifiers)
 	names := make([]string, len(newOrderRequest.Identifiers))
+	var subProbs []probs.SubProblemDetails
 	for i, ident := range newOrderRequest.Identifiers {
-		if ident.Type != identifier.DNS {
-			wfe.sendError(response, logEvent,
-				probs.Malformed("NewOrder request included invalid non-DNS type identifier: type %q, value %q",
-					ident.Type, ident.Value),
-				nil)
-			return
+		var prob *probs.ProblemDetails
+		switch {
+		case ident.Type == identifier.DNS:
+			if ipIdentifiers && net.ParseIP(ident.Value) != nil {
+				prob = probs.Malformed("NewOrder request included an IP address as a DNS type identifier: %q", ident.Value)
+			} else {
+				names[i] = ident.Value
+			}
+		case ident.Type == identifier.IP && ipIdentifiers:
+			if ip := net.ParseIP(ident.Value); ip == nil {
+				prob = probs.Malformed("NewOrder request included a malformed IP type identifier: %q", ident.Value)
+			} else {
+				names[i] = ip.String()
+			}
+		default:
+			prob = probs.Malformed("NewOrder request included invalid non-DNS type identifier: type %q, value %q",
+				ident.Type, ident.Value)
+		}
+		if prob != nil {
+			subProbs = append(subProbs, probs.SubProblemDetails{ProblemDetails: *prob, Identifier: ident})
 		}
-		names[i] = ident.Value
+	}
+	if len(subProbs) == 1 {
+		wfe.sendError(response, logEvent, &subProbs[0].ProblemDetails, nil)
+		return
+	} else if len(subProbs) > 1 {
+		wfe.sendError(response, logEvent,
+			probs.Malformed("NewOrder request included %d invalid identifiers. Refer to sub-problems for more information.",
+				len(subProbs)).WithSubProblems(subProbs),
+			nil)
+		return
 	}
 
+	var profile *string
+	if newOrderRequest.Profile != "" {
+		profile = &newOrderRequest.Profile
+	}
+	// An order which replaces one of the account's certificates, identified by
+	// its draft-ietf-acme-ari certificate identifier, is exempt from some rate
+	// limits.
+	var replaces *string
+	if newOrderRequest.Replaces != "" && features.Enabled(features.RenewalInfo) {
+		serial, prob := wfe.validateReplacement(ctx, acct, names, newOrderRequest.Replaces)
+		if prob != nil {
+			wfe.sendError(response, logEvent, prob, nil)
+			return
+		}
+		logEvent.Extra["Replaces"] = serial
+		replaces = &serial
+	}
 	order, err := wfe.RA.NewOrder(ctx, &rapb.NewOrderRequest{
-		RegistrationID: &acct.ID,
-		Names:          names,
+		RegistrationID:         &acct.ID,
+		Names:                  names,
+		CertificateProfileName: profile,
+		Replaces:               replaces,
+		NotBefore:              notBefore,
+		NotAfter:               notAfter,
 	})
 	if err != nil {
-		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Error creating new order"), err)
+		prob := web.ProblemDetailsForError(err, "Error creating new order")
+		if berrors.Is(err, berrors.Paused) {
+			prob.Instance = wfe.unpauseInstance(acct.ID)
+		}
+		wfe.sendError(response, logEvent, prob, err)
 		return
 	}
 	logEvent.Created = fmt.Sprintf("%d", *order.Id)
@@ -2000,7 +2534,7 @@ func (wfe *WebFrontEndImpl) NewOrder(
 
 // GetOrder is used to retrieve a existing order object
 func (wfe *WebFrontEndImpl) GetOrder(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
-	if features.Enabled(features.MandatoryPOSTAsGET) && request.Method != http.MethodPost && !requiredStale(request, logEvent) {
+	if wfe.legacyGETForbidden(request, logEvent, wfe.AllowLegacyGET.Orders) {
 		wfe.sendError(response, logEvent, probs.MethodNotAllowed(), nil)
 		return
 	}
@@ -2065,6 +2599,7 @@ func (wfe *WebFrontEndImpl) GetOrder(ctx context.Context, logEvent *web.RequestE
 		return
 	}
 
+	addOrderRetryAfter(response, order)
 	respObj := wfe.orderToOrderJSON(request, order)
 	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, respObj)
 	if err != nil {
@@ -2073,6 +2608,81 @@ func (wfe *WebFrontEndImpl) GetOrder(ctx context.Context, logEvent *web.RequestE
 	}
 }
 
+// ordersJSON is a page of an account's orders list, as described by RFC 8555
+// Section 7.1.2.1.
+type ordersJSON struct {
+	Orders []string `json:"orders"`
+}
+
+// Orders is used by a client to POST-as-GET a page of its account's orders
+// list, newest first. If there are older orders, a Link header with relation
+// "next" points to the following page.
+func (wfe *WebFrontEndImpl) Orders(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+	if wfe.OrdersPageSize <= 0 {
+		wfe.sendError(response, logEvent, probs.NotFound("Orders lists are not supported"), nil)
+		return
+	}
+
+	acct, prob := wfe.validPOSTAsGETForAccount(request, ctx, logEvent)
+	addRequesterHeader(response, logEvent.Requester)
+	if prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
+
+	// Path prefix is stripped, so this should be like "<account ID>"
+	acctID, err := strconv.ParseInt(request.URL.Path, 10, 64)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.Malformed("Invalid account ID"), err)
+		return
+	}
+	if acctID != acct.ID {
+		wfe.sendError(response, logEvent,
+			probs.Unauthorized("Request signing key did not match account key"), nil)
+		return
+	}
+
+	var cursor int64
+	if cursorStr := request.URL.Query().Get("cursor"); cursorStr != "" {
+		cursor, err = strconv.ParseInt(cursorStr, 10, 64)
+		if err != nil || cursor <= 0 {
+			wfe.sendError(response, logEvent, probs.Malformed("Invalid orders list cursor"), err)
+			return
+		}
+	}
+
+	// Ask for one more order than fits on the page, to learn whether there is
+	// a next page.
+	limit := int64(wfe.OrdersPageSize + 1)
+	ids, err := wfe.SA.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{
+		RegistrationID: &acct.ID,
+		BeforeID:       &cursor,
+		Limit:          &limit,
+	})
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve orders"), err)
+		return
+	}
+
+	page := ids.Ids
+	if len(page) > wfe.OrdersPageSize {
+		page = page[:wfe.OrdersPageSize]
+		nextURL := fmt.Sprintf("%s?cursor=%d",
+			web.RelativeEndpoint(request, fmt.Sprintf("%s%d", ordersPath, acct.ID)), page[len(page)-1])
+		response.Header().Add("Link", link(nextURL, "next"))
+	}
+	respObj := ordersJSON{Orders: make([]string, 0, len(page))}
+	for _, id := range page {
+		respObj.Orders = append(respObj.Orders,
+			web.RelativeEndpoint(request, fmt.Sprintf("%s%d/%d", orderPath, acct.ID, id)))
+	}
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, respObj)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Error marshaling orders list"), err)
+		return
+	}
+}
+
 // FinalizeOrder is used to request issuance for a existing order object.
 // Most processing of the order details is handled by the RA but
 // we do attempt to throw away requests with invalid CSRs here.
@@ -2162,7 +2772,7 @@ func (wfe *WebFrontEndImpl) FinalizeOrder(ctx context.Context, logEvent *web.Req
 
 	certificateRequest := core.CertificateRequest{Bytes: rawCSR.CSR}
 	certificateRequest.CSR = csr
-	wfe.logCsr(request, certificateRequest, *acct)
+	wfe.logCsr(ctx, request, certificateRequest, *acct)
 
 	logEvent.Extra["CSRDNSNames"] = certificateRequest.CSR.DNSNames
 	logEvent.Extra["CSREmailAddresses"] = certificateRequest.CSR.EmailAddresses
@@ -2176,6 +2786,7 @@ func (wfe *WebFrontEndImpl) FinalizeOrder(ctx context.Context, logEvent *web.Req
 		Csr:   rawCSR.CSR,
 		Order: order,
 	})
+	wfe.storageCache.forgetOrder(orderID)
 	if err != nil {
 		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Error finalizing order"), err)
 		return
@@ -2185,6 +2796,7 @@ func (wfe *WebFrontEndImpl) FinalizeOrder(ctx context.Context, logEvent *web.Req
 		fmt.Sprintf("%s%d/%d", orderPath, acct.ID, *updatedOrder.Id))
 	response.Header().Set("Location", orderURL)
 
+	addOrderRetryAfter(response, updatedOrder)
 	respObj := wfe.orderToOrderJSON(request, updatedOrder)
 	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, respObj)
 	if err != nil {
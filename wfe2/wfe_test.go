@@ -2,6 +2,7 @@ package wfe2
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto"
 	"crypto/ecdsa"
@@ -25,6 +26,7 @@ import (
 	"time"
 
 	"github.com/jmhodges/clock"
+	"golang.org/x/crypto/ocsp"
 	jose "gopkg.in/square/go-jose.v2"
 
 	"github.com/letsencrypt/boulder/core"
@@ -32,6 +34,7 @@ import (
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/identifier"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
@@ -40,7 +43,9 @@ import (
 	"github.com/letsencrypt/boulder/probs"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
 	"github.com/letsencrypt/boulder/revocation"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/unpause"
 	"github.com/letsencrypt/boulder/web"
 )
 
@@ -203,6 +208,9 @@ EeMZ9nWyIM6bktLrE11HnFOnKhAYsM5fZA==
 
 type MockRegistrationAuthority struct {
 	lastRevocationReason revocation.Reason
+	// revokedByKey is set if the last revocation was a keyCompromise
+	// revocation requested with the certificate's key.
+	revokedByKey bool
 }
 
 func (ra *MockRegistrationAuthority) NewRegistration(ctx context.Context, acct core.Registration) (core.Registration, error) {
@@ -234,9 +242,20 @@ func (ra *MockRegistrationAuthority) PerformValidation(_ context.Context, _ *rap
 
 func (ra *MockRegistrationAuthority) RevokeCertificateWithReg(ctx context.Context, cert x509.Certificate, reason revocation.Reason, reg int64) error {
 	ra.lastRevocationReason = reason
+	ra.revokedByKey = false
 	return nil
 }
 
+func (ra *MockRegistrationAuthority) RevokeCertByKey(_ context.Context, _ *rapb.RevokeCertByKeyRequest) (*corepb.Empty, error) {
+	ra.lastRevocationReason = ocsp.KeyCompromise
+	ra.revokedByKey = true
+	return &corepb.Empty{}, nil
+}
+
+func (ra *MockRegistrationAuthority) AdministrativelyRevokeCertificates(_ context.Context, _ func() (*rapb.AdministrativelyRevokeCertificatesRequest, error)) (*rapb.AdministrativelyRevokeCertificatesResponse, error) {
+	return &rapb.AdministrativelyRevokeCertificatesResponse{}, nil
+}
+
 func (ra *MockRegistrationAuthority) AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, reason revocation.Reason, user string) error {
 	return nil
 }
@@ -254,16 +273,24 @@ func (ra *MockRegistrationAuthority) DeactivateRegistration(ctx context.Context,
 }
 
 func (ra *MockRegistrationAuthority) NewOrder(ctx context.Context, req *rapb.NewOrderRequest) (*corepb.Order, error) {
+	for _, name := range req.Names {
+		if name == "paused.example.com" {
+			return nil, berrors.PausedError("issuance for %s is paused for this account", name)
+		}
+	}
 	one := int64(1)
 	zero := int64(0)
 	status := string(core.StatusPending)
 	return &corepb.Order{
-		Id:               &one,
-		RegistrationID:   req.RegistrationID,
-		Expires:          &zero,
-		Names:            req.Names,
-		Status:           &status,
-		V2Authorizations: []int64{1},
+		Id:                     &one,
+		RegistrationID:         req.RegistrationID,
+		Expires:                &zero,
+		Names:                  req.Names,
+		Status:                 &status,
+		V2Authorizations:       []int64{1},
+		CertificateProfileName: req.CertificateProfileName,
+		NotBefore:              req.NotBefore,
+		NotAfter:               req.NotAfter,
 	}, nil
 }
 
@@ -273,6 +300,17 @@ func (ra *MockRegistrationAuthority) FinalizeOrder(ctx context.Context, req *rap
 	return req.Order, nil
 }
 
+func (ra *MockRegistrationAuthority) SetRevocationWebhook(_ context.Context, req *rapb.SetRevocationWebhookRequest) (*corepb.Empty, error) {
+	if strings.HasPrefix(req.GetUrl(), "http://") {
+		return nil, berrors.MalformedError("revocation webhook must use the https scheme")
+	}
+	return &corepb.Empty{}, nil
+}
+
+func (ra *MockRegistrationAuthority) UnpauseAccount(_ context.Context, _ *rapb.UnpauseAccountRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
 func makeBody(s string) io.ReadCloser {
 	return ioutil.NopCloser(strings.NewReader(s))
 }
@@ -396,6 +434,22 @@ func mustParseURL(s string) *url.URL {
 	}
 }
 
+// withoutRequestID checks that body, a problem document sent through the
+// WFE's handler, includes a request ID, and returns it without the request
+// ID, which is random, for comparison with the expected problem.
+func withoutRequestID(t *testing.T, body string) string {
+	t.Helper()
+	var prob map[string]interface{}
+	err := json.Unmarshal([]byte(body), &prob)
+	test.AssertNotError(t, err, "Unmarshaling problem")
+	requestID, _ := prob["requestID"].(string)
+	test.Assert(t, requestID != "", "Problem had no request ID")
+	delete(prob, "requestID")
+	stripped, err := json.Marshal(prob)
+	test.AssertNotError(t, err, "Marshaling problem")
+	return string(stripped)
+}
+
 func sortHeader(s string) string {
 	a := strings.Split(s, ", ")
 	sort.Strings(a)
@@ -454,7 +508,7 @@ func TestHandleFunc(t *testing.T) {
 			test.AssertEquals(t, rw.Code, http.StatusMethodNotAllowed)
 			test.AssertEquals(t, sortHeader(rw.Header().Get("Allow")), sortHeader(strings.Join(addHeadIfGet(c.allowed), ", ")))
 			test.AssertUnmarshaledEquals(t,
-				rw.Body.String(),
+				withoutRequestID(t, rw.Body.String()),
 				`{"type":"`+probs.V2ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 		}
 		if c.reqMethod == "GET" && c.pattern != newNoncePath {
@@ -479,7 +533,7 @@ func TestHandleFunc(t *testing.T) {
 	// Disallowed method returns error JSON in body
 	runWrappedHandler(&http.Request{Method: "PUT"}, "/test", "GET", "POST")
 	test.AssertEquals(t, rw.Header().Get("Content-Type"), "application/problem+json")
-	test.AssertUnmarshaledEquals(t, rw.Body.String(), `{"type":"`+probs.V2ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, rw.Body.String()), `{"type":"`+probs.V2ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 	test.AssertEquals(t, sortHeader(rw.Header().Get("Allow")), "GET, HEAD, POST")
 
 	// Disallowed method special case: response to HEAD has got no body
@@ -493,7 +547,7 @@ func TestHandleFunc(t *testing.T) {
 	test.AssertEquals(t, rw.Code, http.StatusMethodNotAllowed)
 	test.AssertEquals(t, rw.Header().Get("Content-Type"), "application/problem+json")
 	test.AssertEquals(t, rw.Header().Get("Allow"), "POST")
-	test.AssertUnmarshaledEquals(t, rw.Body.String(), `{"type":"`+probs.V2ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
+	test.AssertUnmarshaledEquals(t, withoutRequestID(t, rw.Body.String()), `{"type":"`+probs.V2ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 
 	wfe.AllowOrigins = []string{"*"}
 	testOrigin := "https://example.com"
@@ -631,6 +685,39 @@ func TestHandleFunc(t *testing.T) {
 	}
 }
 
+func TestHandleFuncEndpointLimits(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.RequestTimeout = time.Minute
+	wfe.EndpointLimits = map[string]EndpointLimit{
+		finalizeOrderPath: {Timeout: time.Hour, MaxRequestSize: 100},
+	}
+
+	var deadline time.Time
+	var bodyErr error
+	runWrappedHandler := func(pattern string, body string) {
+		mux := http.NewServeMux()
+		wfe.HandleFunc(mux, pattern, func(ctx context.Context, _ *web.RequestEvent, _ http.ResponseWriter, request *http.Request) {
+			deadline, _ = ctx.Deadline()
+			_, bodyErr = ioutil.ReadAll(request.Body)
+		}, "POST")
+		mux.ServeHTTP(httptest.NewRecorder(), makePostRequestWithPath(pattern, body))
+	}
+
+	// The finalize endpoint has its own timeout and body size limit.
+	runWrappedHandler(finalizeOrderPath, strings.Repeat("a", 100))
+	test.AssertNotError(t, bodyErr, "Body within the finalize limit was rejected")
+	test.Assert(t, deadline.After(time.Now().Add(59*time.Minute)), "Finalize deadline doesn't use its endpoint timeout")
+	runWrappedHandler(finalizeOrderPath, strings.Repeat("a", 101))
+	test.AssertError(t, bodyErr, "Body over the finalize limit was accepted")
+
+	// Other endpoints use the defaults.
+	runWrappedHandler(newOrderPath, strings.Repeat("a", 101))
+	test.AssertNotError(t, bodyErr, "Body within the default limit was rejected")
+	test.Assert(t, deadline.Before(time.Now().Add(2*time.Minute)), "New order deadline doesn't use the default timeout")
+	runWrappedHandler(newOrderPath, strings.Repeat("a", maxRequestSize+1))
+	test.AssertError(t, bodyErr, "Body over the default limit was accepted")
+}
+
 func TestPOST404(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	responseWriter := httptest.NewRecorder()
@@ -692,8 +779,6 @@ func (fr fakeRand) Read(p []byte) (int, error) {
 }
 
 func TestDirectory(t *testing.T) {
-	wfe, _ := setupWFE(t)
-	mux := wfe.Handler(metrics.NoopRegisterer)
 	core.RandReader = fakeRand{}
 	defer func() { core.RandReader = rand.Reader }()
 
@@ -705,15 +790,14 @@ func TestDirectory(t *testing.T) {
 		Host:   "localhost:4300",
 	}
 
-	_, _, jwsBody := signRequestKeyID(t, 1, nil, "http://localhost/directory", "", wfe.nonceService)
-	postAsGetReq := makePostRequestWithPath("/directory", jwsBody)
-
 	testCases := []struct {
 		name         string
-		caaIdent     string
+		caaIdents    []string
 		website      string
+		profiles     map[string]string
 		expectedJSON string
 		request      *http.Request
+		postAsGet    bool
 	}{
 		{
 			name:    "standard GET, no CAA ident/website meta",
@@ -731,16 +815,17 @@ func TestDirectory(t *testing.T) {
 }`,
 		},
 		{
-			name:     "standard GET, CAA ident/website meta",
-			caaIdent: "Radiant Lock",
-			website:  "zombo.com",
-			request:  getReq,
+			name:      "standard GET, CAA ident/website meta",
+			caaIdents: []string{"Radiant Lock", "Glowing Key"},
+			website:   "zombo.com",
+			request:   getReq,
 			expectedJSON: `{
   "AAAAAAAAAAA": "https://community.letsencrypt.org/t/adding-random-entries-to-the-directory/33417",
   "keyChange": "http://localhost:4300/acme/key-change",
   "meta": {
     "caaIdentities": [
-      "Radiant Lock"
+      "Radiant Lock",
+      "Glowing Key"
     ],
     "termsOfService": "http://example.invalid/terms",
     "website": "zombo.com"
@@ -752,10 +837,10 @@ func TestDirectory(t *testing.T) {
 }`,
 		},
 		{
-			name:     "POST-as-GET, CAA ident/website meta",
-			caaIdent: "Radiant Lock",
-			website:  "zombo.com",
-			request:  postAsGetReq,
+			name:      "POST-as-GET, CAA ident/website meta",
+			caaIdents: []string{"Radiant Lock"},
+			website:   "zombo.com",
+			postAsGet: true,
 			expectedJSON: `{
   "AAAAAAAAAAA": "https://community.letsencrypt.org/t/adding-random-entries-to-the-directory/33417",
   "keyChange": "http://localhost/acme/key-change",
@@ -770,18 +855,46 @@ func TestDirectory(t *testing.T) {
   "newNonce": "http://localhost/acme/new-nonce",
   "newOrder": "http://localhost/acme/new-order",
   "revokeCert": "http://localhost/acme/revoke-cert"
+}`,
+		},
+		{
+			name:     "standard GET, certificate profiles meta",
+			profiles: map[string]string{"shortlived": "Short-lived certificates"},
+			request:  getReq,
+			expectedJSON: `{
+  "AAAAAAAAAAA": "https://community.letsencrypt.org/t/adding-random-entries-to-the-directory/33417",
+  "keyChange": "http://localhost:4300/acme/key-change",
+  "meta": {
+    "profiles": {
+      "shortlived": "Short-lived certificates"
+    },
+    "termsOfService": "http://example.invalid/terms"
+  },
+  "newAccount": "http://localhost:4300/acme/new-acct",
+  "newNonce": "http://localhost:4300/acme/new-nonce",
+  "newOrder": "http://localhost:4300/acme/new-order",
+  "revokeCert": "http://localhost:4300/acme/revoke-cert"
 }`,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Configure a caaIdentity and website for the /directory meta based on the tc
-			wfe.DirectoryCAAIdentity = tc.caaIdent // "Radiant Lock"
-			wfe.DirectoryWebsite = tc.website      //"zombo.com"
+			// Configure caaIdentities and a website for the /directory meta based
+			// on the tc. The directory is cached, so each tc needs its own WFE.
+			wfe, _ := setupWFE(t)
+			wfe.DirectoryCAAIdentities = tc.caaIdents
+			wfe.DirectoryWebsite = tc.website
+			wfe.CertificateProfiles = tc.profiles
+			mux := wfe.Handler(metrics.NoopRegisterer)
+			request := tc.request
+			if tc.postAsGet {
+				_, _, jwsBody := signRequestKeyID(t, 1, nil, "http://localhost/directory", "", wfe.nonceService)
+				request = makePostRequestWithPath("/directory", jwsBody)
+			}
 			responseWriter := httptest.NewRecorder()
 			// Serve the /directory response for this request into a recorder
-			mux.ServeHTTP(responseWriter, tc.request)
+			mux.ServeHTTP(responseWriter, request)
 			// We expect all directory requests to return a json object with a good HTTP status
 			test.AssertEquals(t, responseWriter.Header().Get("Content-Type"), "application/json")
 			// We expect all requests to return status OK
@@ -796,6 +909,28 @@ func TestDirectory(t *testing.T) {
 	}
 }
 
+func TestDirectoryWithoutMeta(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.SubscriberAgreementURL = ""
+	mux := wfe.Handler(metrics.NoopRegisterer)
+
+	responseWriter := httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: "/directory"},
+		Host:   "localhost:4300",
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+
+	// With nothing to put in it, the directory has no "meta" entry at all,
+	// rather than one with an empty "termsOfService".
+	var directory map[string]interface{}
+	err := json.Unmarshal(responseWriter.Body.Bytes(), &directory)
+	test.AssertNotError(t, err, "Unmarshaling directory")
+	_, present := directory["meta"]
+	test.Assert(t, !present, "Directory had a meta entry")
+}
+
 func TestRelativeDirectory(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	mux := wfe.Handler(metrics.NoopRegisterer)
@@ -981,6 +1116,11 @@ func TestHTTPMethods(t *testing.T) {
 			Path:    buildIDPath,
 			Allowed: getOnly,
 		},
+		{
+			Name:    "Healthz path should be GET only",
+			Path:    healthzPath,
+			Allowed: getOnly,
+		},
 		{
 			Name:    "Rollover path should be POST only",
 			Path:    rolloverPath,
@@ -1226,6 +1366,155 @@ func TestUpdateChallengeRAError(t *testing.T) {
 	}`)
 }
 
+// mockSAOnionAuthz is a mock SA whose authorizations are pending and for a
+// .onion name, with an onion-csr-01 challenge.
+type mockSAOnionAuthz struct {
+	core.StorageGetter
+}
+
+func (sa mockSAOnionAuthz) GetAuthorization2(ctx context.Context, id *sapb.AuthorizationID2) (*corepb.Authorization, error) {
+	exp := time.Now().AddDate(100, 0, 0)
+	return bgrpc.AuthzToPB(core.Authorization{
+		ID:             fmt.Sprintf("%d", *id.Id),
+		Status:         core.StatusPending,
+		RegistrationID: 1,
+		Identifier:     identifier.DNSIdentifier("example.onion"),
+		Challenges:     []core.Challenge{core.OnionCSRChallenge01("token")},
+		Expires:        &exp,
+	})
+}
+
+// MockRAPerformValidationEcho is a mock RA that records the authorization it
+// was asked to validate and returns it unchanged.
+type MockRAPerformValidationEcho struct {
+	MockRegistrationAuthority
+	authz *corepb.Authorization
+}
+
+func (ra *MockRAPerformValidationEcho) PerformValidation(_ context.Context, req *rapb.PerformValidationRequest) (*corepb.Authorization, error) {
+	ra.authz = req.Authz
+	return req.Authz, nil
+}
+
+func TestUpdateChallengeOnionCSR(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.SA = mockSAOnionAuthz{wfe.SA}
+	ra := &MockRAPerformValidationEcho{}
+	wfe.RA = ra
+	path := "2/" + core.OnionCSRChallenge01("token").StringID()
+
+	post := func(body string) string {
+		_, _, jwsBody := signRequestKeyID(t, 1, nil, "http://localhost/"+path, body, wfe.nonceService)
+		responseWriter := httptest.NewRecorder()
+		wfe.Challenge(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath(path, jwsBody))
+		return responseWriter.Body.String()
+	}
+
+	// A response without a CSR is rejected.
+	test.AssertUnmarshaledEquals(t, post(`{}`), `{
+		"type": "urn:ietf:params:acme:error:malformed",
+		"detail": "onion-csr-01 challenge response is missing a CSR",
+		"status": 400
+	}`)
+	test.Assert(t, ra.authz == nil, "RA was asked to validate a response without a CSR")
+
+	// The CSR is passed to the RA with the challenge, and the challenge's
+	// token is displayed as its nonce.
+	test.AssertUnmarshaledEquals(t, post(`{"csr": "Y3Ny"}`), `{
+		"type": "onion-csr-01",
+		"status": "pending",
+		"nonce": "token",
+		"url": "http://localhost/acme/chall-v3/2/`+core.OnionCSRChallenge01("token").StringID()+`"
+	}`)
+	chall, err := bgrpc.PBToChallenge(ra.authz.Challenges[0])
+	test.AssertNotError(t, err, "converting challenge")
+	test.AssertDeepEquals(t, chall.OnionCSRResponse, &core.OnionCSRResponse{CSR: "Y3Ny"})
+}
+
+// mockSAChallengeStatus returns a pending DNS authorization whose challenge
+// has the given status.
+type mockSAChallengeStatus struct {
+	core.StorageGetter
+	status core.AcmeStatus
+}
+
+func (sa mockSAChallengeStatus) GetAuthorization2(ctx context.Context, id *sapb.AuthorizationID2) (*corepb.Authorization, error) {
+	exp := time.Now().AddDate(100, 0, 0)
+	chall := core.DNSChallenge01("token")
+	chall.Status = sa.status
+	return bgrpc.AuthzToPB(core.Authorization{
+		ID:             fmt.Sprintf("%d", *id.Id),
+		Status:         core.StatusPending,
+		RegistrationID: 1,
+		Identifier:     identifier.DNSIdentifier("example.com"),
+		Challenges:     []core.Challenge{chall},
+		Expires:        &exp,
+	})
+}
+
+// MockRAPerformValidationProcessing is a mock RA that returns the
+// authorization it was asked to validate with the challenge processing, as
+// the RA does while the validation is in flight.
+type MockRAPerformValidationProcessing struct {
+	MockRegistrationAuthority
+}
+
+func (ra *MockRAPerformValidationProcessing) PerformValidation(_ context.Context, req *rapb.PerformValidationRequest) (*corepb.Authorization, error) {
+	processing := string(core.StatusProcessing)
+	req.Authz.Challenges[*req.ChallengeIndex].Status = &processing
+	return req.Authz, nil
+}
+
+func TestUpdateChallengeRetryAfter(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	path := "2/" + core.DNSChallenge01("token").StringID()
+	expectedBody := `{
+		"type": "dns-01",
+		"status": "processing",
+		"token": "token",
+		"url": "http://localhost/acme/chall-v3/` + path + `"
+	}`
+
+	post := func(body string) *httptest.ResponseRecorder {
+		_, _, jwsBody := signRequestKeyID(t, 1, nil, "http://localhost/"+path, body, wfe.nonceService)
+		responseWriter := httptest.NewRecorder()
+		wfe.Challenge(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath(path, jwsBody))
+		return responseWriter
+	}
+
+	// Starting a validation returns the challenge as processing, with a
+	// Retry-After of the expected validation duration rounded up to a whole
+	// second.
+	wfe.SA = mockSAChallengeStatus{wfe.SA, core.StatusPending}
+	wfe.RA = &MockRAPerformValidationProcessing{}
+	wfe.ExpectedValidationDuration = 2500 * time.Millisecond
+	responseWriter := post(`{}`)
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "3")
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), expectedBody)
+
+	// POSTing a challenge which is already processing returns it as it is,
+	// without asking the RA to validate it again.
+	wfe.SA = mockSAChallengeStatus{wfe.SA, core.StatusProcessing}
+	wfe.RA = &MockRAPerformValidationError{}
+	wfe.ExpectedValidationDuration = 0
+	responseWriter = post(`{}`)
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "5")
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), expectedBody)
+
+	// Polling it with POST-as-GET gets the same Retry-After.
+	responseWriter = post("")
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "5")
+
+	// Challenges which aren't processing have no Retry-After.
+	wfe.SA = mockSAChallengeStatus{wfe.SA, core.StatusPending}
+	responseWriter = post("")
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "")
+}
+
 func TestBadNonce(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -1427,7 +1716,7 @@ func TestNewAccount(t *testing.T) {
 	for _, rt := range acctErrTests {
 		responseWriter := httptest.NewRecorder()
 		mux.ServeHTTP(responseWriter, rt.r)
-		test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), rt.respBody)
+		test.AssertUnmarshaledEquals(t, withoutRequestID(t, responseWriter.Body.String()), rt.respBody)
 	}
 
 	responseWriter := httptest.NewRecorder()
@@ -1660,7 +1949,7 @@ func TestAccount(t *testing.T) {
 		URL:    mustParseURL(acctPath),
 	})
 	test.AssertUnmarshaledEquals(t,
-		responseWriter.Body.String(),
+		withoutRequestID(t, responseWriter.Body.String()),
 		`{"type":"`+probs.V2ErrorNS+`malformed","detail":"Method not allowed","status":405}`)
 	responseWriter.Body.Reset()
 
@@ -1744,6 +2033,80 @@ func TestAccount(t *testing.T) {
 	}`)
 }
 
+func TestAccountRevocationWebhook(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	// Setting a valid revocation webhook should succeed
+	responseWriter := httptest.NewRecorder()
+	payload := `{"revocationWebhook":"https://webhook.example.com/revoked"}`
+	_, _, body := signRequestKeyID(t, 1, nil, "http://localhost/1", payload, wfe.nonceService)
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertNotContains(t, responseWriter.Body.String(), probs.V2ErrorNS)
+
+	// A revocation webhook rejected by the RA should be returned as a problem
+	responseWriter = httptest.NewRecorder()
+	payload = `{"revocationWebhook":"http://webhook.example.com/revoked"}`
+	_, _, body = signRequestKeyID(t, 1, nil, "http://localhost/1", payload, wfe.nonceService)
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{
+		"type": "`+probs.V2ErrorNS+`malformed",
+		"detail": "Unable to update revocation webhook :: revocation webhook must use the https scheme",
+		"status": 400
+	}`)
+
+	// The revocation webhook isn't stored if the rest of the update fails
+	ra := &failingUpdateRA{MockRegistrationAuthority: &MockRegistrationAuthority{}}
+	wfe.RA = ra
+	responseWriter = httptest.NewRecorder()
+	payload = `{"contact":["mailto:new@example.com"],"revocationWebhook":"https://webhook.example.com/revoked"}`
+	_, _, body = signRequestKeyID(t, 1, nil, "http://localhost/1", payload, wfe.nonceService)
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusInternalServerError)
+	test.Assert(t, !ra.webhookSet, "revocation webhook stored despite failed account update")
+
+	// Nor is the rest of the update committed if the revocation webhook is
+	// rejected
+	recordingRA := &recordingUpdateRA{MockRegistrationAuthority: &MockRegistrationAuthority{}}
+	wfe.RA = recordingRA
+	responseWriter = httptest.NewRecorder()
+	payload = `{"contact":["mailto:new@example.com"],"revocationWebhook":"http://webhook.example.com/revoked"}`
+	_, _, body = signRequestKeyID(t, 1, nil, "http://localhost/1", payload, wfe.nonceService)
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+	test.Assert(t, !recordingRA.updated, "account updated despite rejected revocation webhook")
+}
+
+// failingUpdateRA is a mock RA which fails every account update, and records
+// whether a revocation webhook was set.
+type failingUpdateRA struct {
+	*MockRegistrationAuthority
+	webhookSet bool
+}
+
+func (ra *failingUpdateRA) UpdateRegistration(context.Context, core.Registration, core.Registration) (core.Registration, error) {
+	return core.Registration{}, errors.New("update failed")
+}
+
+func (ra *failingUpdateRA) SetRevocationWebhook(ctx context.Context, req *rapb.SetRevocationWebhookRequest) (*corepb.Empty, error) {
+	if !req.GetValidateOnly() {
+		ra.webhookSet = true
+	}
+	return ra.MockRegistrationAuthority.SetRevocationWebhook(ctx, req)
+}
+
+// recordingUpdateRA is a mock RA which records whether an account update was
+// committed.
+type recordingUpdateRA struct {
+	*MockRegistrationAuthority
+	updated bool
+}
+
+func (ra *recordingUpdateRA) UpdateRegistration(ctx context.Context, base, update core.Registration) (core.Registration, error) {
+	ra.updated = true
+	return ra.MockRegistrationAuthority.UpdateRegistration(ctx, base, update)
+}
+
 func TestIssuer(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	wfe.IssuerCert = []byte{0, 0, 1}
@@ -1757,6 +2120,21 @@ func TestIssuer(t *testing.T) {
 	test.Assert(t, bytes.Compare(responseWriter.Body.Bytes(), wfe.IssuerCert) == 0, "Incorrect bytes returned")
 }
 
+func TestHealthz(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	responseWriter := httptest.NewRecorder()
+	wfe.Healthz(ctx, newRequestEvent(), responseWriter, &http.Request{Method: "GET"})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Body.String(), "OK\n")
+
+	wfe.StartDraining()
+	responseWriter = httptest.NewRecorder()
+	wfe.Healthz(ctx, newRequestEvent(), responseWriter, &http.Request{Method: "GET"})
+	test.AssertEquals(t, responseWriter.Code, http.StatusServiceUnavailable)
+	test.AssertEquals(t, responseWriter.Body.String(), "Draining\n")
+}
+
 func TestGetCertificate(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	mux := wfe.Handler(metrics.NoopRegisterer)
@@ -1981,7 +2359,7 @@ func TestGetCertificate(t *testing.T) {
 				}
 			} else {
 				// Otherwise if the expectation wasn't a certificate, check that the body matches the expected
-				body := responseWriter.Body.String()
+				body := withoutRequestID(t, responseWriter.Body.String())
 				test.AssertUnmarshaledEquals(t, body, tc.ExpectedBody)
 
 				// Unsuccessful requests should be logged as such
@@ -2029,6 +2407,103 @@ func TestGetCertificateHEADHasCorrectBodyLength(t *testing.T) {
 	test.AssertEquals(t, 0, len(body))
 }
 
+func TestGetCertificateContentNegotiation(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	mux := wfe.Handler(metrics.NoopRegisterer)
+
+	certPemBytes, _ := ioutil.ReadFile("test/178.crt")
+	chainPemBytes, _ := ioutil.ReadFile("../test/test-ca2.pem")
+	certBlock, _ := pem.Decode(certPemBytes)
+	chainBlock, _ := pem.Decode(chainPemBytes)
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		mux.ServeHTTP(responseWriter, &http.Request{
+			URL:    &url.URL{Path: "/acme/cert/0000000000000000000000000000000000b2"},
+			Method: "GET",
+			Header: http.Header{"Accept": {accept}},
+		})
+		test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+		test.AssertEquals(t, responseWriter.Header().Get("Vary"), "Accept")
+		test.AssertEquals(t, responseWriter.Header().Get("Content-Length"), strconv.Itoa(responseWriter.Body.Len()))
+		return responseWriter
+	}
+
+	responseWriter := get("application/pkix-cert")
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Type"), "application/pkix-cert")
+	test.AssertByteEquals(t, responseWriter.Body.Bytes(), certBlock.Bytes)
+
+	responseWriter = get("application/pkcs7-mime")
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Type"), "application/pkcs7-mime")
+	certs := parsePKCS7Certs(t, responseWriter.Body.Bytes())
+	test.AssertEquals(t, len(certs), 2)
+	test.AssertByteEquals(t, certs[0].Raw, certBlock.Bytes)
+	test.AssertByteEquals(t, certs[1].Raw, chainBlock.Bytes)
+
+	// Unsupported and malformed Accept values get the default PEM chain.
+	for _, accept := range []string{"application/json", "not a media type;;", "application/pkix-cert;q=x"} {
+		responseWriter = get(accept)
+		test.AssertEquals(t, responseWriter.Header().Get("Content-Type"), "application/pem-certificate-chain")
+		test.AssertByteEquals(t, responseWriter.Body.Bytes(), append(certPemBytes, append([]byte("\n"), chainPemBytes...)...))
+	}
+}
+
+// mockSAWithoutDER is a mock SA whose certificates' DER has been pruned, as
+// the janitor's certificateDER job does once their retention period passes.
+type mockSAWithoutDER struct {
+	core.StorageGetter
+}
+
+func (sa mockSAWithoutDER) GetCertificate(ctx context.Context, serial string) (core.Certificate, error) {
+	cert, err := sa.StorageGetter.GetCertificate(ctx, serial)
+	cert.DER = []byte{}
+	return cert, err
+}
+
+func TestGetCertificateWithoutDER(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.SA = mockSAWithoutDER{wfe.SA}
+	mux := wfe.Handler(metrics.NoopRegisterer)
+
+	responseWriter := httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		URL:    &url.URL{Path: "/acme/cert/0000000000000000000000000000000000b2"},
+		Method: "GET",
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusNotFound)
+	test.AssertContains(t, responseWriter.Body.String(), `"detail": "Certificate no longer available"`)
+}
+
+func TestCompressEndpoints(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.CompressEndpoints = map[string]bool{directoryPath: true}
+	mux := wfe.Handler(metrics.NoopRegisterer)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept-Encoding", "gzip")
+		mux.ServeHTTP(responseWriter, request)
+		test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+		return responseWriter
+	}
+
+	// The directory is listed, so it's compressed.
+	responseWriter := get(directoryPath)
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Encoding"), "gzip")
+	reader, err := gzip.NewReader(responseWriter.Body)
+	test.AssertNotError(t, err, "gzip.NewReader failed")
+	var directory map[string]interface{}
+	err = json.NewDecoder(reader).Decode(&directory)
+	test.AssertNotError(t, err, "decoding compressed directory")
+	test.AssertEquals(t, directory["newOrder"], "http://example.com/acme/new-order")
+
+	// The build ID endpoint isn't.
+	responseWriter = get(buildIDPath)
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Encoding"), "")
+	test.AssertContains(t, responseWriter.Body.String(), "Boulder=")
+}
+
 func newRequestEvent() *web.RequestEvent {
 	return &web.RequestEvent{Extra: make(map[string]interface{})}
 }
@@ -2182,6 +2657,10 @@ func TestDeactivateAccount(t *testing.T) {
 
 func TestNewOrder(t *testing.T) {
 	wfe, _ := setupWFE(t)
+	wfe.CertificateProfiles = map[string]string{"shortlived": "Short-lived certificates"}
+	err := features.Set(map[string]bool{"CertificateProfiles": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
 	responseWriter := httptest.NewRecorder()
 
 	targetHost := "localhost"
@@ -2248,6 +2727,11 @@ func TestNewOrder(t *testing.T) {
 			Request:      signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}], "notBefore":"now", "notAfter": "later"}`, 1, wfe.nonceService),
 			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NotBefore and NotAfter are not supported","status":400}`,
 		},
+		{
+			Name:         "POST, unknown profile in payload",
+			Request:      signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}], "profile":"longlived"}`, 1, wfe.nonceService),
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `invalidProfile","detail":"NewOrder request specified unknown profile \"longlived\"","status":400}`,
+		},
 		{
 			Name:    "POST, good payload",
 			Request: signAndPost(t, targetPath, signedURL, validOrderBody, 1, wfe.nonceService),
@@ -2265,6 +2749,23 @@ func TestNewOrder(t *testing.T) {
 						"finalize": "http://localhost/acme/finalize/1/1"
 					}`,
 		},
+		{
+			Name:    "POST, good payload with profile",
+			Request: signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}], "profile":"shortlived"}`, 1, wfe.nonceService),
+			ExpectedBody: `
+					{
+						"status": "pending",
+						"expires": "1970-01-01T00:00:00Z",
+						"identifiers": [
+							{ "type": "dns", "value": "not-example.com"}
+						],
+						"authorizations": [
+							"http://localhost/acme/authz-v3/1"
+						],
+						"finalize": "http://localhost/acme/finalize/1/1",
+						"profile": "shortlived"
+					}`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2292,6 +2793,214 @@ func TestNewOrder(t *testing.T) {
 	}
 }
 
+func TestNewOrderValidityWindow(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	responseWriter := httptest.NewRecorder()
+
+	targetPath := "new-order"
+	signedURL := fmt.Sprintf("http://localhost/%s", targetPath)
+	newOrder := func(window string) map[string]interface{} {
+		t.Helper()
+		responseWriter.Body.Reset()
+		body := `{"identifiers": [{"type": "dns", "value": "not-example.com"}]` + window + `}`
+		wfe.NewOrder(ctx, newRequestEvent(), responseWriter, signAndPost(t, targetPath, signedURL, body, 1, wfe.nonceService))
+		var result map[string]interface{}
+		err := json.Unmarshal(responseWriter.Body.Bytes(), &result)
+		test.AssertNotError(t, err, "unmarshaling response")
+		return result
+	}
+
+	window := `, "notBefore": "2030-01-01T00:00:00Z", "notAfter": "2030-01-31T00:00:00+01:00"`
+	test.AssertEquals(t, newOrder(window)["detail"], "NotBefore and NotAfter are not supported")
+
+	_ = features.Set(map[string]bool{"OrderValidityWindows": true})
+	defer features.Reset()
+
+	// The requested window is passed to the RA, and returned with the order.
+	order := newOrder(window)
+	test.AssertEquals(t, order["status"], "pending")
+	test.AssertEquals(t, order["notBefore"], "2030-01-01T00:00:00Z")
+	test.AssertEquals(t, order["notAfter"], "2030-01-30T23:00:00Z")
+
+	order = newOrder(`, "notAfter": "2030-01-31T00:00:00Z"`)
+	_, present := order["notBefore"]
+	test.Assert(t, !present, "order has a notBefore")
+	test.AssertEquals(t, order["notAfter"], "2030-01-31T00:00:00Z")
+
+	test.AssertEquals(t, newOrder(`, "notBefore": "tomorrow"`)["detail"], `Invalid NotBefore "tomorrow"`)
+	test.AssertEquals(t, newOrder(`, "notAfter": "2030-01-31"`)["detail"], `Invalid NotAfter "2030-01-31"`)
+	test.AssertEquals(t,
+		newOrder(`, "notBefore": "2030-01-31T00:00:00Z", "notAfter": "2030-01-01T00:00:00Z"`)["detail"],
+		"NotAfter must be after NotBefore")
+}
+
+func TestNewOrderIPIdentifiers(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	responseWriter := httptest.NewRecorder()
+
+	targetPath := "new-order"
+	signedURL := fmt.Sprintf("http://localhost/%s", targetPath)
+	ipOrderBody := `{"identifiers": [
+		{"type": "dns", "value": "not-example.com"},
+		{"type": "ip", "value": "2602:080a:6000::1"}
+	]}`
+
+	// Without the IPIdentifiers feature IP type identifiers are rejected.
+	wfe.NewOrder(ctx, newRequestEvent(), responseWriter, signAndPost(t, targetPath, signedURL, ipOrderBody, 1, wfe.nonceService))
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+		`{"type":"`+probs.V2ErrorNS+`malformed","detail":"NewOrder request included invalid non-DNS type identifier: type \"ip\", value \"2602:080a:6000::1\"","status":400}`)
+
+	_ = features.Set(map[string]bool{"IPIdentifiers": true})
+	defer features.Reset()
+
+	testCases := []struct {
+		Name         string
+		Body         string
+		ExpectedBody string
+	}{
+		{
+			Name:         "IP address in a DNS identifier",
+			Body:         `{"identifiers": [{"type": "dns", "value": "64.112.117.1"}]}`,
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request included an IP address as a DNS type identifier: \"64.112.117.1\"","status":400}`,
+		},
+		{
+			Name:         "malformed IP identifier",
+			Body:         `{"identifiers": [{"type": "ip", "value": "not-example.com"}]}`,
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request included a malformed IP type identifier: \"not-example.com\"","status":400}`,
+		},
+		{
+			// Every bad identifier gets its own sub-problem.
+			Name: "several bad identifiers",
+			Body: `{"identifiers": [
+				{"type": "dns", "value": "64.112.117.1"},
+				{"type": "dns", "value": "example.com"},
+				{"type": "ip", "value": "not-example.com"},
+				{"type": "fakeID", "value": "example.net"}
+			]}`,
+			ExpectedBody: `{
+				"type": "` + probs.V2ErrorNS + `malformed",
+				"detail": "NewOrder request included 3 invalid identifiers. Refer to sub-problems for more information.",
+				"status": 400,
+				"subproblems": [
+					{
+						"type": "` + probs.V2ErrorNS + `malformed",
+						"detail": "NewOrder request included an IP address as a DNS type identifier: \"64.112.117.1\"",
+						"status": 400,
+						"identifier": {"type": "dns", "value": "64.112.117.1"}
+					},
+					{
+						"type": "` + probs.V2ErrorNS + `malformed",
+						"detail": "NewOrder request included a malformed IP type identifier: \"not-example.com\"",
+						"status": 400,
+						"identifier": {"type": "ip", "value": "not-example.com"}
+					},
+					{
+						"type": "` + probs.V2ErrorNS + `malformed",
+						"detail": "NewOrder request included invalid non-DNS type identifier: type \"fakeID\", value \"example.net\"",
+						"status": 400,
+						"identifier": {"type": "fakeID", "value": "example.net"}
+					}
+				]
+			}`,
+		},
+		{
+			// IP addresses are converted to canonical form.
+			Name: "good payload",
+			Body: ipOrderBody,
+			ExpectedBody: `
+				{
+					"status": "pending",
+					"expires": "1970-01-01T00:00:00Z",
+					"identifiers": [
+						{ "type": "dns", "value": "not-example.com"},
+						{ "type": "ip", "value": "2602:80a:6000::1"}
+					],
+					"authorizations": [
+						"http://localhost/acme/authz-v3/1"
+					],
+					"finalize": "http://localhost/acme/finalize/1/1"
+				}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			responseWriter.Body.Reset()
+			wfe.NewOrder(ctx, newRequestEvent(), responseWriter, signAndPost(t, targetPath, signedURL, tc.Body, 1, wfe.nonceService))
+			test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), tc.ExpectedBody)
+		})
+	}
+}
+
+func TestNewOrderPaused(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	key := []byte("an unpause key")
+	targetPath := "new-order"
+	signedURL := fmt.Sprintf("http://localhost/%s", targetPath)
+	pausedBody := `{"identifiers": [{"type": "dns", "value": "paused.example.com"}]}`
+
+	newOrder := func() probs.ProblemDetails {
+		responseWriter := httptest.NewRecorder()
+		wfe.NewOrder(ctx, newRequestEvent(), responseWriter, signAndPost(t, targetPath, signedURL, pausedBody, 1, wfe.nonceService))
+		test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+		var prob probs.ProblemDetails
+		err := json.Unmarshal(responseWriter.Body.Bytes(), &prob)
+		test.AssertNotError(t, err, "unmarshaling problem")
+		test.AssertEquals(t, prob.Type, probs.V2ErrorNS+probs.UserActionRequiredProblem)
+		return prob
+	}
+
+	// Without an unpause URL configured there's no instance to link to.
+	test.AssertEquals(t, newOrder().Instance, "")
+
+	wfe.EnableUnpause("https://boulder.service.consul/sfe/v1/unpause", key, time.Hour)
+	instance, err := url.Parse(newOrder().Instance)
+	test.AssertNotError(t, err, "parsing instance URL")
+	test.AssertEquals(t, instance.Host, "boulder.service.consul")
+	test.AssertEquals(t, instance.Path, "/sfe/v1/unpause")
+	regID, err := unpause.ValidateToken(key, instance.Query().Get("token"), fc.Now())
+	test.AssertNotError(t, err, "unpause token doesn't validate")
+	test.AssertEquals(t, regID, int64(1))
+	_, err = unpause.ValidateToken(key, instance.Query().Get("token"), fc.Now().Add(time.Hour))
+	test.AssertError(t, err, "unpause token outlived its lifetime")
+}
+
+func TestNewOrderRestrictedProfile(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.CertificateProfiles = map[string]string{
+		"shortlived": "Short-lived certificates",
+		"partner":    "Certificates for partners",
+	}
+	wfe.CertificateProfileAccounts = map[string][]int64{"partner": {5}}
+	err := features.Set(map[string]bool{"CertificateProfiles": true})
+	test.AssertNotError(t, err, "failed to set features")
+	defer features.Reset()
+	targetPath := "new-order"
+	signedURL := fmt.Sprintf("http://localhost/%s", targetPath)
+
+	newOrder := func(profile string, acctID int64) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"identifiers":[{"type":"dns","value":"not-example.com"}],"profile":%q}`, profile)
+		wfe.NewOrder(ctx, newRequestEvent(), responseWriter, signAndPost(t, targetPath, signedURL, body, acctID, wfe.nonceService))
+		return responseWriter
+	}
+
+	// Unrestricted profiles are available to every account, and restricted
+	// ones to the accounts they list.
+	test.AssertEquals(t, newOrder("shortlived", 1).Code, http.StatusCreated)
+	test.AssertEquals(t, newOrder("partner", 5).Code, http.StatusCreated)
+
+	responseWriter := newOrder("partner", 1)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+		`{"type":"`+probs.V2ErrorNS+`invalidProfile","detail":"Profile \"partner\" is not available to this account","status":400}`)
+
+	// Without the feature the SA wouldn't store the profile, so orders naming
+	// one are refused rather than issued with the default profile.
+	features.Reset()
+	responseWriter = newOrder("shortlived", 1)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+		`{"type":"`+probs.V2ErrorNS+`invalidProfile","detail":"Certificate profiles are not supported","status":400}`)
+}
+
 func TestFinalizeOrder(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	responseWriter := httptest.NewRecorder()
@@ -2388,7 +3097,7 @@ func TestFinalizeOrder(t *testing.T) {
 		{
 			Name:            "Good CSR, Ready Order",
 			Request:         signAndPost(t, "1/8", "http://localhost/1/8", goodCertCSRPayload, 1, wfe.nonceService),
-			ExpectedHeaders: map[string]string{"Location": "http://localhost/acme/order/1/8"},
+			ExpectedHeaders: map[string]string{"Location": "http://localhost/acme/order/1/8", "Retry-After": "3"},
 			ExpectedBody: `
 {
   "status": "processing",
@@ -2524,6 +3233,132 @@ func TestKeyRollover(t *testing.T) {
 	}
 }
 
+func TestAccountChangesForgetCachedRegistration(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.EnableStorageCache(time.Hour)
+
+	newKeyBytes, err := ioutil.ReadFile("../test/test-key-5.der")
+	test.AssertNotError(t, err, "Failed to read ../test/test-key-5.der")
+	newKeyPriv, err := x509.ParsePKCS1PrivateKey(newKeyBytes)
+	test.AssertNotError(t, err, "Failed parsing private key")
+
+	// Authenticating a request caches the account, and rolling over its key
+	// forgets it, so the old key stops working immediately.
+	payload := `{"oldKey":` + test1KeyPublicJSON + `,"account":"http://localhost/acme/acct/1"}`
+	_, _, inner := signRequestEmbed(t, newKeyPriv, "http://localhost/key-change", payload, wfe.nonceService)
+	_, _, outer := signRequestKeyID(t, 1, nil, "http://localhost/key-change", inner, wfe.nonceService)
+	responseWriter := httptest.NewRecorder()
+	wfe.KeyRollover(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("key-change", outer))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	_, cached := wfe.storageCache.registrations.get(int64(1))
+	test.Assert(t, !cached, "account was still cached after key rollover")
+
+	// Updating the account forgets it too.
+	_, _, body := signRequestKeyID(t, 1, nil, "http://localhost/1", `{"contact":["mailto:new@example.com"]}`, wfe.nonceService)
+	responseWriter = httptest.NewRecorder()
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	_, cached = wfe.storageCache.registrations.get(int64(1))
+	test.Assert(t, !cached, "account was still cached after update")
+
+	// As does deactivating it.
+	_, _, body = signRequestKeyID(t, 1, nil, "http://localhost/1", `{"status":"deactivated"}`, wfe.nonceService)
+	responseWriter = httptest.NewRecorder()
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	_, cached = wfe.storageCache.registrations.get(int64(1))
+	test.Assert(t, !cached, "account was still cached after deactivation")
+}
+
+// mockSARolloverKey reports every key as blocked, if blocked is true, and as
+// belonging to account 7 once notFound lookups of it have failed, if notFound
+// isn't negative.
+type mockSARolloverKey struct {
+	core.StorageGetter
+	blocked  bool
+	notFound int
+}
+
+func (sa *mockSARolloverKey) KeyBlocked(_ context.Context, _ *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	return &sapb.Exists{Exists: &sa.blocked}, nil
+}
+
+func (sa *mockSARolloverKey) GetRegistrationByKey(_ context.Context, jwk *jose.JSONWebKey) (core.Registration, error) {
+	if sa.notFound != 0 {
+		sa.notFound--
+		return core.Registration{}, berrors.NotFoundError("reg not found")
+	}
+	return core.Registration{ID: 7, Key: jwk, Status: core.StatusValid}, nil
+}
+
+// MockRAUpdateRegistrationDuplicate is a mock RA whose UpdateRegistration
+// fails as if the new key were claimed by another account in the meantime.
+type MockRAUpdateRegistrationDuplicate struct {
+	MockRegistrationAuthority
+}
+
+func (ra *MockRAUpdateRegistrationDuplicate) UpdateRegistration(_ context.Context, _ core.Registration, _ core.Registration) (core.Registration, error) {
+	return core.Registration{}, berrors.DuplicateError("key is already in use for a different account")
+}
+
+func TestKeyRolloverConflictAndBlockedKey(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	newKeyBytes, err := ioutil.ReadFile("../test/test-key-5.der")
+	test.AssertNotError(t, err, "Failed to read ../test/test-key-5.der")
+	newKeyPriv, err := x509.ParsePKCS1PrivateKey(newKeyBytes)
+	test.AssertNotError(t, err, "Failed parsing private key")
+
+	rollover := func() *httptest.ResponseRecorder {
+		payload := `{"oldKey":` + test1KeyPublicJSON + `,"account":"http://localhost/acme/acct/1"}`
+		_, _, inner := signRequestEmbed(t, newKeyPriv, "http://localhost/key-change", payload, wfe.nonceService)
+		_, _, outer := signRequestKeyID(t, 1, nil, "http://localhost/key-change", inner, wfe.nonceService)
+		responseWriter := httptest.NewRecorder()
+		wfe.KeyRollover(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("key-change", outer))
+		return responseWriter
+	}
+	conflict := `{
+		"type": "` + probs.V2ErrorNS + `malformed",
+		"detail": "New key is already in use for a different account",
+		"status": 409
+	}`
+
+	// A blocked key is refused when BlockRolloverToBlockedKeys is set.
+	sa := &mockSARolloverKey{StorageGetter: wfe.SA, blocked: true, notFound: -1}
+	wfe.SA = sa
+	wfe.BlockRolloverToBlockedKeys = true
+	responseWriter := rollover()
+	test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{
+		"type": "`+probs.V2ErrorNS+`badPublicKey",
+		"detail": "New key specified by rollover request is blocked, for instance because it is known to be compromised",
+		"status": 400
+	}`)
+	test.AssertEquals(t, test.CountCounterVec("type", "KeyRolloverBlockedKey", wfe.stats.joseErrorCount), 1)
+
+	wfe.BlockRolloverToBlockedKeys = false
+	test.AssertEquals(t, rollover().Code, http.StatusOK)
+
+	// A key already used by another account is a conflict, with a Location
+	// of that account.
+	sa.blocked = false
+	sa.notFound = 0
+	wfe.BlockRolloverToBlockedKeys = true
+	responseWriter = rollover()
+	test.AssertEquals(t, responseWriter.Code, http.StatusConflict)
+	test.AssertEquals(t, responseWriter.Header().Get("Location"), "http://localhost/acme/acct/7")
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), conflict)
+
+	// So is a key claimed by another account between the WFE's check and the
+	// update.
+	sa.notFound = 1
+	wfe.RA = &MockRAUpdateRegistrationDuplicate{}
+	responseWriter = rollover()
+	test.AssertEquals(t, responseWriter.Code, http.StatusConflict)
+	test.AssertEquals(t, responseWriter.Header().Get("Location"), "http://localhost/acme/acct/7")
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), conflict)
+}
+
 func TestKeyRolloverMismatchedJWSURLs(t *testing.T) {
 	responseWriter := httptest.NewRecorder()
 	wfe, _ := setupWFE(t)
@@ -2544,6 +3379,40 @@ func TestKeyRolloverMismatchedJWSURLs(t *testing.T) {
 		}`)
 }
 
+// mockSAOrderProfile is a mock SA whose orders requested a certificate
+// profile.
+type mockSAOrderProfile struct {
+	core.StorageGetter
+	profile string
+}
+
+func (sa mockSAOrderProfile) GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error) {
+	order, err := sa.StorageGetter.GetOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	order.CertificateProfileName = &sa.profile
+	return order, nil
+}
+
+func TestGetOrderProfile(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.SA = mockSAOrderProfile{wfe.SA, "shortlived"}
+
+	responseWriter := httptest.NewRecorder()
+	wfe.GetOrder(ctx, newRequestEvent(), responseWriter, &http.Request{URL: &url.URL{Path: "1/1"}, Method: "GET"})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{
+		"status": "valid",
+		"expires": "1970-01-01T00:00:00.9466848Z",
+		"identifiers": [{"type":"dns", "value":"example.com"}],
+		"authorizations": ["http://localhost/acme/authz-v3/1"],
+		"finalize": "http://localhost/acme/finalize/1/1",
+		"certificate": "http://localhost/acme/cert/serial",
+		"profile": "shortlived"
+	}`)
+}
+
 func TestGetOrder(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -2828,6 +3697,36 @@ func TestRevokeCertificateReasons(t *testing.T) {
 	}
 }
 
+// A keyCompromise revocation request signed with the cert key is passed to the
+// RA as proof of key compromise, while one signed by an account isn't.
+func TestRevokeCertificateKeyCompromise(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	ra := wfe.RA.(*MockRegistrationAuthority)
+	wfe.SA = &mockSANoSuchRegistration{mocks.NewStorageAuthority(fc)}
+
+	keyPemBytes, err := ioutil.ReadFile("test/238.key")
+	test.AssertNotError(t, err, "Failed to load key")
+	key := loadKey(t, keyPemBytes)
+	keyCompromise := revocation.Reason(ocsp.KeyCompromise)
+	revokeRequestJSON, err := makeRevokeRequestJSON(&keyCompromise)
+	test.AssertNotError(t, err, "Failed to make revokeRequestJSON")
+
+	responseWriter := httptest.NewRecorder()
+	_, _, jwsBody := signRequestEmbed(t, key, "http://localhost/revoke-cert", string(revokeRequestJSON), wfe.nonceService)
+	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("revoke-cert", jwsBody))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.Assert(t, ra.revokedByKey, "keyCompromise revocation signed with the cert key wasn't revoked by key")
+
+	wfe, _ = setupWFE(t)
+	ra = wfe.RA.(*MockRegistrationAuthority)
+	responseWriter = httptest.NewRecorder()
+	_, _, jwsBody = signRequestKeyID(t, 1, nil, "http://localhost/revoke-cert", string(revokeRequestJSON), wfe.nonceService)
+	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("revoke-cert", jwsBody))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, ra.lastRevocationReason, keyCompromise)
+	test.Assert(t, !ra.revokedByKey, "keyCompromise revocation signed by an account was revoked by key")
+}
+
 // Valid revocation request for existing, non-revoked cert, signed with account
 // that issued the cert.
 func TestRevokeCertificateIssuingAccount(t *testing.T) {
@@ -3116,7 +4015,9 @@ func TestFinalizeSCTError(t *testing.T) {
 	// a serverInternal error with the right message.
 	test.AssertUnmarshaledEquals(t,
 		responseWriter.Body.String(),
-		`{"type":"`+probs.V2ErrorNS+`serverInternal","detail":"Error finalizing order :: Unable to meet CA SCT embedding requirements","status":500}`)
+		`{"type":"`+probs.V2ErrorNS+`serverInternal","detail":"Error finalizing order :: Unable to meet CA SCT embedding requirements","status":503}`)
+	test.AssertEquals(t, responseWriter.Code, http.StatusServiceUnavailable)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "60")
 }
 
 func TestOrderToOrderJSONV2Authorizations(t *testing.T) {
@@ -3199,6 +4100,58 @@ func TestMandatoryPOSTAsGET(t *testing.T) {
 	}
 }
 
+// TestAllowLegacyGET tests that the AllowLegacyGET setting re-allows
+// unauthenticated GET requests to only the configured types of resource while
+// the MandatoryPOSTAsGET feature flag is enabled.
+func TestAllowLegacyGET(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.AllowLegacyGET = LegacyGETAllowances{Authorizations: true}
+
+	_ = features.Set(map[string]bool{"MandatoryPOSTAsGET": true})
+	defer features.Reset()
+
+	testCases := []struct {
+		name         string
+		path         string
+		handler      web.WFEHandlerFunc
+		expectedCode int
+	}{
+		{
+			name:         "GET Authz",
+			path:         "1",
+			handler:      wfe.Authorization,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "GET Chall",
+			path:         "1/-ZfxEw",
+			handler:      wfe.Challenge,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "GET Order",
+			path:         "1/1",
+			handler:      wfe.GetOrder,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "GET Cert",
+			path:         "acme/cert/0000000000000000000000000000000000b2",
+			handler:      wfe.Certificate,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			responseWriter := httptest.NewRecorder()
+			req := &http.Request{URL: &url.URL{Path: tc.path}, Method: "GET"}
+			tc.handler(ctx, newRequestEvent(), responseWriter, req)
+			test.AssertEquals(t, responseWriter.Code, tc.expectedCode)
+		})
+	}
+}
+
 func TestGetChallengeUpRel(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -3233,6 +4186,84 @@ func TestPrepAccountForDisplay(t *testing.T) {
 	test.AssertEquals(t, acct.ID, int64(0))
 }
 
+func TestAccountForDisplay(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	request := &http.Request{Host: "localhost"}
+
+	// Without an orders page size, accounts have no orders URL.
+	acctJSON := wfe.accountForDisplay(request, &core.Registration{ID: 1987})
+	test.AssertEquals(t, acctJSON.Orders, "")
+	test.AssertEquals(t, acctJSON.ID, int64(0))
+	marshaled, err := json.Marshal(acctJSON)
+	test.AssertNotError(t, err, "marshaling account")
+	test.AssertNotContains(t, string(marshaled), "orders")
+
+	wfe.OrdersPageSize = 10
+	acctJSON = wfe.accountForDisplay(request, &core.Registration{ID: 1987})
+	test.AssertEquals(t, acctJSON.Orders, "http://localhost/acme/orders/1987")
+	test.AssertEquals(t, acctJSON.ID, int64(0))
+	marshaled, err = json.Marshal(acctJSON)
+	test.AssertNotError(t, err, "marshaling account")
+	test.AssertContains(t, string(marshaled), `"orders":"http://localhost/acme/orders/1987"`)
+}
+
+func TestOrders(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	// postOrders POST-as-GETs the orders list page at path, signed by the
+	// given account.
+	postOrders := func(path string, acctID int64) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		_, _, body := signRequestKeyID(t, acctID, nil, "http://localhost/"+path, "", wfe.nonceService)
+		request := makePostRequestWithPath(path, body)
+		request.RequestURI = path
+		wfe.Orders(ctx, newRequestEvent(), responseWriter, request)
+		return responseWriter
+	}
+
+	// Without an orders page size, the endpoint is disabled.
+	responseWriter := postOrders("1", 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusNotFound)
+
+	// The mock SA's accounts each have orders 1 through 10, which are listed
+	// newest first over three pages.
+	wfe.OrdersPageSize = 4
+	testCases := []struct {
+		path           string
+		expectedOrders []int64
+		expectedNext   string
+	}{
+		{"1", []int64{10, 9, 8, 7}, `<http://localhost/acme/orders/1?cursor=7>;rel="next"`},
+		{"1?cursor=7", []int64{6, 5, 4, 3}, `<http://localhost/acme/orders/1?cursor=3>;rel="next"`},
+		{"1?cursor=3", []int64{2, 1}, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			responseWriter := postOrders(tc.path, 1)
+			test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+			test.AssertEquals(t, responseWriter.Header().Get("Link"), tc.expectedNext)
+			var orders ordersJSON
+			err := json.Unmarshal(responseWriter.Body.Bytes(), &orders)
+			test.AssertNotError(t, err, "unmarshaling orders list")
+			var expectedOrders []string
+			for _, id := range tc.expectedOrders {
+				expectedOrders = append(expectedOrders, fmt.Sprintf("http://localhost/acme/order/1/%d", id))
+			}
+			test.AssertDeepEquals(t, orders.Orders, expectedOrders)
+		})
+	}
+
+	// Accounts may only list their own orders.
+	responseWriter = postOrders("5", 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+
+	// The cursor must be a positive integer.
+	responseWriter = postOrders("1?cursor=seven", 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+	responseWriter = postOrders("1?cursor=-7", 1)
+	test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+}
+
 func TestGETAPIAuthz(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	makeGet := func(path, endpoint string) (*http.Request, *web.RequestEvent) {
@@ -106,6 +106,74 @@ var signatureAlgorithmDetails = []struct {
 	{x509.PureEd25519, "Ed25519", oidSignatureEd25519, x509.Ed25519, crypto.Hash(0) /* no pre-hashing */},
 }
 
+// FORK NOTE: this is the isRSAPSS method of x509.SignatureAlgorithm, which
+// is unexported.
+func isRSAPSS(algo x509.SignatureAlgorithm) bool {
+	switch algo {
+	case x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS:
+		return true
+	default:
+		return false
+	}
+}
+
+// pssParameters reflects the parameters in an AlgorithmIdentifier that
+// specifies RSA PSS. See RFC 3447, Appendix A.2.3.
+type pssParameters struct {
+	// The following three fields are not marked as
+	// optional because the default values specify SHA-1,
+	// which is no longer suitable for use in signatures.
+	Hash         pkix.AlgorithmIdentifier `asn1:"explicit,tag:0"`
+	MGF          pkix.AlgorithmIdentifier `asn1:"explicit,tag:1"`
+	SaltLength   int                      `asn1:"explicit,tag:2"`
+	TrailerField int                      `asn1:"optional,explicit,tag:3,default:1"`
+}
+
+// rsaPSSParameters returns an asn1.RawValue suitable for use as the Parameters
+// in an AlgorithmIdentifier that specifies RSA PSS.
+func rsaPSSParameters(hashFunc crypto.Hash) asn1.RawValue {
+	var hashOID asn1.ObjectIdentifier
+
+	switch hashFunc {
+	case crypto.SHA256:
+		hashOID = oidSHA256
+	case crypto.SHA384:
+		hashOID = oidSHA384
+	case crypto.SHA512:
+		hashOID = oidSHA512
+	}
+
+	params := pssParameters{
+		Hash: pkix.AlgorithmIdentifier{
+			Algorithm:  hashOID,
+			Parameters: asn1.NullRawValue,
+		},
+		MGF: pkix.AlgorithmIdentifier{
+			Algorithm: oidMGF1,
+		},
+		SaltLength:   hashFunc.Size(),
+		TrailerField: 1,
+	}
+
+	mgf1Params := pkix.AlgorithmIdentifier{
+		Algorithm:  hashOID,
+		Parameters: asn1.NullRawValue,
+	}
+
+	var err error
+	params.MGF.Parameters.FullBytes, err = asn1.Marshal(mgf1Params)
+	if err != nil {
+		panic(err)
+	}
+
+	serialized, err := asn1.Marshal(params)
+	if err != nil {
+		panic(err)
+	}
+
+	return asn1.RawValue{FullBytes: serialized}
+}
+
 // signingParamsForPublicKey returns the parameters to use for signing with
 // priv. If requestedSigAlgo is not zero then it overrides the default
 // signature algorithm.
@@ -164,12 +232,11 @@ func signingParamsForPublicKey(pub interface{}, requestedSigAlgo x509.SignatureA
 				err = errors.New("x509: cannot sign with hash function requested")
 				return
 			}
-			// FORK NOTE: we don't use PSS so rather than pulling in this code,
-			// which requires locally redefining x509.SignatureAlgorithm, we just
-			// comment it out.
-			// if requestedSigAlgo.isRSAPSS() {
-			// 	sigAlgo.Parameters = rsaPSSParameters(hashFunc)
-			// }
+			// FORK NOTE: x509.SignatureAlgorithm.isRSAPSS is unexported, so we
+			// use the local isRSAPSS function instead.
+			if isRSAPSS(requestedSigAlgo) {
+				sigAlgo.Parameters = rsaPSSParameters(hashFunc)
+			}
 			found = true
 			break
 		}
@@ -291,15 +358,12 @@ func CreateRevocationList(rand io.Reader, template *RevocationList, issuer *x509
 		input = h.Sum(nil)
 	}
 	var signerOpts crypto.SignerOpts = hashFunc
-	// FORK NOTE: we don't use PSS so rather than pulling in this code,
-	// which requires locally redefining x509.SignatureAlgorithm, we just
-	// comment it out.
-	// if template.SignatureAlgorithm.isRSAPSS() {
-	// 	signerOpts = &rsa.PSSOptions{
-	// 		SaltLength: rsa.PSSSaltLengthEqualsHash,
-	// 		Hash:       hashFunc,
-	// 	}
-	// }
+	if isRSAPSS(template.SignatureAlgorithm) {
+		signerOpts = &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       hashFunc,
+		}
+	}
 
 	signature, err := priv.Sign(rand, input, signerOpts)
 	if err != nil {
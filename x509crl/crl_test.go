@@ -0,0 +1,78 @@
+package x509crl
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// pssSHA256AlgorithmIdentifier is the encoding of an RSASSA-PSS
+// AlgorithmIdentifier using SHA-256, MGF1 with SHA-256, and a 32 byte salt,
+// as given in Section 7.1.3.2 of the Baseline Requirements.
+const pssSHA256AlgorithmIdentifier = "304106092a864886f70d01010a3034a00f300d06096086480165030402010500a11c301a06092a864886f70d010108300d06096086480165030402010500a203020120"
+
+func TestRSAPSSParameters(t *testing.T) {
+	algo := pkix.AlgorithmIdentifier{
+		Algorithm:  oidSignatureRSAPSS,
+		Parameters: rsaPSSParameters(crypto.SHA256),
+	}
+	der, err := asn1.Marshal(algo)
+	test.AssertNotError(t, err, "failed to marshal AlgorithmIdentifier")
+	test.AssertEquals(t, hex.EncodeToString(der), pssSHA256AlgorithmIdentifier)
+}
+
+func TestCreateRevocationListRSAPSS(t *testing.T) {
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate test key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "asd"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCRLSign,
+		SubjectKeyId:          []byte{1, 2, 3},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	issuerBytes, err := x509.CreateCertificate(rand.Reader, template, template, k.Public(), k)
+	test.AssertNotError(t, err, "failed to create test issuer")
+	issuer, err := x509.ParseCertificate(issuerBytes)
+	test.AssertNotError(t, err, "failed to parse test issuer")
+
+	crlBytes, err := CreateRevocationList(rand.Reader, &RevocationList{
+		SignatureAlgorithm: x509.SHA256WithRSAPSS,
+		Number:             big.NewInt(1),
+		ThisUpdate:         time.Now(),
+		NextUpdate:         time.Now().Add(time.Hour),
+	}, issuer, k)
+	test.AssertNotError(t, err, "CreateRevocationList failed")
+
+	var crl pkix.CertificateList
+	_, err = asn1.Unmarshal(crlBytes, &crl)
+	test.AssertNotError(t, err, "failed to parse CRL")
+
+	// Both copies of the AlgorithmIdentifier must carry the PSS parameters.
+	expected, _ := hex.DecodeString(pssSHA256AlgorithmIdentifier)
+	for _, algo := range []pkix.AlgorithmIdentifier{crl.SignatureAlgorithm, crl.TBSCertList.Signature} {
+		der, err := asn1.Marshal(algo)
+		test.AssertNotError(t, err, "failed to marshal AlgorithmIdentifier")
+		test.Assert(t, bytes.Equal(der, expected), "wrong signature AlgorithmIdentifier")
+	}
+
+	digest := sha256.Sum256(crl.TBSCertList.Raw)
+	err = rsa.VerifyPSS(&k.PublicKey, crypto.SHA256, digest[:], crl.SignatureValue.Bytes, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+	})
+	test.AssertNotError(t, err, "CRL signature is not a valid RSASSA-PSS signature")
+}